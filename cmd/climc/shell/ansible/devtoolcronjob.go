@@ -29,6 +29,9 @@ import (
 func paramValidator(param jsonutils.JSONObject) (bool, error) {
 	// TODO 移到 server 端
 	log.Infof("paramValidator: param: %+v", param)
+	if param.Contains("cron_expr") {
+		return true, nil
+	}
 	interval, err := param.Int("interval")
 	if err != nil {
 		return true, nil
@@ -69,6 +72,11 @@ func init() {
 		Interval int64  `help:"Cronjob runs at given interval" default:"0"`
 		Start    bool   `help:"start job when created" default:"false"`
 		Enabled  bool   `help:"Set job status enabled" default:"false"`
+
+		CronExpr string `help:"Standard cron expression with 5 or 6 (leading seconds) fields, e.g. '30 2 * * mon-fri'"`
+		TimeZone string `help:"Time zone of cron expression, e.g. Asia/Shanghai"`
+		Jitter   int    `help:"Max random delay seconds of each run"`
+		CatchUp  string `help:"Policy of missed runs" choices:"skip|once|all"`
 	}
 	R(
 		&CronjobCreateOptions{},
@@ -98,6 +106,18 @@ func init() {
 				params.Add(jsonutils.JSONTrue, "start")
 
 			}
+			if len(args.CronExpr) > 0 {
+				params.Add(jsonutils.NewString(args.CronExpr), "cron_expr")
+			}
+			if len(args.TimeZone) > 0 {
+				params.Add(jsonutils.NewString(args.TimeZone), "time_zone")
+			}
+			if args.Jitter > 0 {
+				params.Add(jsonutils.NewInt(int64(args.Jitter)), "jitter")
+			}
+			if len(args.CatchUp) > 0 {
+				params.Add(jsonutils.NewString(args.CatchUp), "catch_up")
+			}
 			if args.Enabled {
 				params.Add(jsonutils.JSONTrue, "enabled")
 			} else if args.Interval > 0 {
@@ -147,6 +167,11 @@ func init() {
 		Stop     bool   `help:"start job when created"`
 		Enable   bool   `help:"Set job status enabled"`
 		Disable  bool   `help:"Set job status enabled"`
+
+		CronExpr string `help:"Standard cron expression with 5 or 6 (leading seconds) fields, set to 'none' to clear"`
+		TimeZone string `help:"Time zone of cron expression, e.g. Asia/Shanghai"`
+		Jitter   int    `help:"Max random delay seconds of each run" default:"-1"`
+		CatchUp  string `help:"Policy of missed runs" choices:"skip|once|all"`
 	}
 	R(&DevToolCronjobUpdateOptions{}, "devtoolcronjob-update", "Update DevToolCronjob", func(s *mcclient.ClientSession, args *DevToolCronjobUpdateOptions) error {
 		result, err := modules.DevToolCronjobs.Get(s, args.ID, nil)
@@ -183,6 +208,23 @@ func init() {
 			params.Add(jsonutils.NewInt(int64(args.Sec)), "sec")
 		}
 
+		if args.CronExpr == "none" {
+			params.Add(jsonutils.NewString(""), "cron_expr")
+		} else if len(args.CronExpr) > 0 {
+			params.Add(jsonutils.NewString(args.CronExpr), "cron_expr")
+		} else if cronExpr, _ := result.GetString("cron_expr"); len(cronExpr) > 0 {
+			params.Add(jsonutils.NewString(cronExpr), "cron_expr")
+		}
+		if len(args.TimeZone) > 0 {
+			params.Add(jsonutils.NewString(args.TimeZone), "time_zone")
+		}
+		if args.Jitter >= 0 {
+			params.Add(jsonutils.NewInt(int64(args.Jitter)), "jitter")
+		}
+		if len(args.CatchUp) > 0 {
+			params.Add(jsonutils.NewString(args.CatchUp), "catch_up")
+		}
+
 		ok, err := paramValidator(params)
 		if err != nil || !ok {
 			return err
@@ -211,6 +253,23 @@ func init() {
 		return nil
 	})
 
+	type DevToolCronjobRunListOptions struct {
+		options.BaseListOptions
+		CRONJOB string `help:"ID or Name of the DevToolCronjob" json:"cronjob"`
+	}
+	R(&DevToolCronjobRunListOptions{}, "devtoolcronjob-run-list", "List run history of DevToolCronjob", func(s *mcclient.ClientSession, args *DevToolCronjobRunListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.DevToolCronjobRuns.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.DevToolCronjobRuns.GetColumns(s))
+		return nil
+	})
+
 	R(&DevToolCronjobShowOptions{}, "devtoolcronjob-delete", "Delete DevToolCronjob", func(s *mcclient.ClientSession, args *DevToolCronjobShowOptions) error {
 		result, err := modules.DevToolCronjobs.Delete(s, args.ID, nil)
 		if err != nil {
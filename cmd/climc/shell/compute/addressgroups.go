@@ -0,0 +1,116 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type AddressGroupListOptions struct {
+		options.BaseListOptions
+		Ip string `help:"filter address groups containing the address"`
+	}
+	R(&AddressGroupListOptions{}, "address-group-list", "List address groups", func(s *mcclient.ClientSession, args *AddressGroupListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.AddressGroups.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.AddressGroups.GetColumns(s))
+		return nil
+	})
+
+	type AddressGroupIdOptions struct {
+		ID string `help:"ID or Name of address group"`
+	}
+	R(&AddressGroupIdOptions{}, "address-group-show", "Show details of address group", func(s *mcclient.ClientSession, args *AddressGroupIdOptions) error {
+		result, err := modules.AddressGroups.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&AddressGroupIdOptions{}, "address-group-delete", "Delete address group", func(s *mcclient.ClientSession, args *AddressGroupIdOptions) error {
+		result, err := modules.AddressGroups.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type AddressGroupCreateOptions struct {
+		NAME string   `help:"Name of address group"`
+		Ip   []string `help:"IPv4 address or cidr, e.g. --ip 10.0.0.0/8 --ip 192.168.1.10"`
+		Ip6  []string `help:"IPv6 address or cidr"`
+		Desc string   `help:"Description" metavar:"Description"`
+	}
+	R(&AddressGroupCreateOptions{}, "address-group-create", "Create address group", func(s *mcclient.ClientSession, args *AddressGroupCreateOptions) error {
+		params := jsonutils.NewDict()
+		params.Add(jsonutils.NewString(args.NAME), "name")
+		params.Add(jsonutils.NewStringArray(args.Ip), "ip_list")
+		params.Add(jsonutils.NewStringArray(args.Ip6), "ip6_list")
+		if len(args.Desc) > 0 {
+			params.Add(jsonutils.NewString(args.Desc), "description")
+		}
+		result, err := modules.AddressGroups.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type AddressGroupUpdateOptions struct {
+		ID       string   `help:"ID or Name of address group"`
+		Name     string   `help:"New name of address group"`
+		Ip       []string `help:"Replace IPv4 address list"`
+		Ip6      []string `help:"Replace IPv6 address list"`
+		ClearIp  bool     `help:"Clear IPv4 address list"`
+		ClearIp6 bool     `help:"Clear IPv6 address list"`
+		Desc     string   `help:"Description" metavar:"Description"`
+	}
+	R(&AddressGroupUpdateOptions{}, "address-group-update", "Update address group", func(s *mcclient.ClientSession, args *AddressGroupUpdateOptions) error {
+		params := jsonutils.NewDict()
+		if len(args.Name) > 0 {
+			params.Add(jsonutils.NewString(args.Name), "name")
+		}
+		if len(args.Ip) > 0 || args.ClearIp {
+			params.Add(jsonutils.NewStringArray(args.Ip), "ip_list")
+		}
+		if len(args.Ip6) > 0 || args.ClearIp6 {
+			params.Add(jsonutils.NewStringArray(args.Ip6), "ip6_list")
+		}
+		if len(args.Desc) > 0 {
+			params.Add(jsonutils.NewString(args.Desc), "description")
+		}
+		result, err := modules.AddressGroups.Update(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
@@ -39,6 +39,9 @@ func init() {
 	cmd.CreateWithKeyword("create-huawei", &options.SHuaweiCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-ucloud", &options.SUcloudCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-zstack", &options.SZStackCloudAccountCreateOptions{})
+	cmd.CreateWithKeyword("create-proxmox", &options.SProxmoxCloudAccountCreateOptions{})
+	cmd.CreateWithKeyword("create-ovirt", &options.SOVirtCloudAccountCreateOptions{})
+	cmd.CreateWithKeyword("create-libvirt", &options.SLibvirtCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-s3", &options.SS3CloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-ceph", &options.SCephCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-xsky", &options.SXskyCloudAccountCreateOptions{})
@@ -55,6 +58,9 @@ func init() {
 	cmd.UpdateWithKeyword("update-huawei", &options.SHuaweiCloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-ucloud", &options.SUcloudCloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-zstack", &options.SZStackCloudAccountUpdateOptions{})
+	cmd.UpdateWithKeyword("update-proxmox", &options.SProxmoxCloudAccountUpdateOptions{})
+	cmd.UpdateWithKeyword("update-ovirt", &options.SOVirtCloudAccountUpdateOptions{})
+	cmd.UpdateWithKeyword("update-libvirt", &options.SLibvirtCloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-s3", &options.SS3CloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-ctyun", &options.SCtyunCloudAccountUpdateOptions{})
 
@@ -70,6 +76,9 @@ func init() {
 	cmd.PerformWithKeyword("update-credential-huawei", "update-credential", &options.SHuaweiCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-ucloud", "update-credential", &options.SUcloudCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-zstack", "update-credential", &options.SZStackCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("update-credential-proxmox", "update-credential", &options.SProxmoxCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("update-credential-ovirt", "update-credential", &options.SOVirtCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("update-credential-libvirt", "update-credential", &options.SLibvirtCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-s3", "update-credential", &options.SS3CloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-ctyun", "update-credential", &options.SCtyunCloudAccountUpdateCredentialOptions{})
 
@@ -83,6 +92,9 @@ func init() {
 	cmd.PerformWithKeyword("test-connectivity-huawei", "test-connectivity", &options.SHuaweiCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-ucloud", "test-connectivity", &options.SUcloudCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-zstack", "test-connectivity", &options.SZStackCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("test-connectivity-proxmox", "test-connectivity", &options.SProxmoxCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("test-connectivity-ovirt", "test-connectivity", &options.SOVirtCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("test-connectivity-libvirt", "test-connectivity", &options.SLibvirtCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-s3", "test-connectivity", &options.SS3CloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-ctyun", "test-connectivity", &options.SCtyunCloudAccountUpdateCredentialOptions{})
 
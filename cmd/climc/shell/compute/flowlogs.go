@@ -0,0 +1,163 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type FlowLogListOptions struct {
+		options.BaseListOptions
+		ResourceType string `help:"filter by resource type" choices:"vpc|network|server"`
+		ResourceId   string `help:"filter by resource id"`
+		TrafficType  string `help:"filter by traffic type" choices:"accept|reject|all"`
+		SinkType     string `help:"filter by sink type" choices:"file|bucket|logger"`
+	}
+	R(&FlowLogListOptions{}, "flow-log-list", "List flow logs", func(s *mcclient.ClientSession, args *FlowLogListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.FlowLogs.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.FlowLogs.GetColumns(s))
+		return nil
+	})
+
+	type FlowLogIdOptions struct {
+		ID string `help:"ID or Name of flow log"`
+	}
+	R(&FlowLogIdOptions{}, "flow-log-show", "Show details of flow log", func(s *mcclient.ClientSession, args *FlowLogIdOptions) error {
+		result, err := modules.FlowLogs.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&FlowLogIdOptions{}, "flow-log-delete", "Delete flow log", func(s *mcclient.ClientSession, args *FlowLogIdOptions) error {
+		result, err := modules.FlowLogs.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&FlowLogIdOptions{}, "flow-log-enable", "Enable flow log", func(s *mcclient.ClientSession, args *FlowLogIdOptions) error {
+		result, err := modules.FlowLogs.PerformAction(s, args.ID, "enable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&FlowLogIdOptions{}, "flow-log-disable", "Disable flow log", func(s *mcclient.ClientSession, args *FlowLogIdOptions) error {
+		result, err := modules.FlowLogs.PerformAction(s, args.ID, "disable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type FlowLogCreateOptions struct {
+		NAME          string `help:"Name of flow log"`
+		RESOURCE_TYPE string `help:"Type of resource to capture" choices:"vpc|network|server"`
+		RESOURCE      string `help:"ID or Name of vpc, network or server"`
+		Ifname        string `help:"Capture only this nic when resource is a server"`
+		TrafficType   string `help:"Traffic to capture" choices:"accept|reject|all" default:"all"`
+		SinkType      string `help:"Where to deliver records" choices:"file|bucket|logger" default:"file"`
+		Bucket        string `help:"ID or Name of bucket when sink type is bucket"`
+		SinkPath      string `help:"File path, or object key prefix when sink type is bucket"`
+		RateLimit     int    `help:"Max records per second per host"`
+		Disabled      bool   `help:"Create flow log in disabled state"`
+		Desc          string `help:"Description" metavar:"Description"`
+	}
+	R(&FlowLogCreateOptions{}, "flow-log-create", "Create flow log", func(s *mcclient.ClientSession, args *FlowLogCreateOptions) error {
+		params := jsonutils.NewDict()
+		params.Add(jsonutils.NewString(args.NAME), "name")
+		params.Add(jsonutils.NewString(args.RESOURCE_TYPE), "resource_type")
+		params.Add(jsonutils.NewString(args.RESOURCE), "resource")
+		if len(args.Ifname) > 0 {
+			params.Add(jsonutils.NewString(args.Ifname), "ifname")
+		}
+		params.Add(jsonutils.NewString(args.TrafficType), "traffic_type")
+		params.Add(jsonutils.NewString(args.SinkType), "sink_type")
+		if len(args.Bucket) > 0 {
+			params.Add(jsonutils.NewString(args.Bucket), "bucket")
+		}
+		if len(args.SinkPath) > 0 {
+			params.Add(jsonutils.NewString(args.SinkPath), "sink_path")
+		}
+		if args.RateLimit > 0 {
+			params.Add(jsonutils.NewInt(int64(args.RateLimit)), "rate_limit")
+		}
+		if args.Disabled {
+			params.Add(jsonutils.JSONFalse, "enabled")
+		}
+		if len(args.Desc) > 0 {
+			params.Add(jsonutils.NewString(args.Desc), "description")
+		}
+		result, err := modules.FlowLogs.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type FlowLogUpdateOptions struct {
+		ID          string `help:"ID or Name of flow log"`
+		Name        string `help:"New name of flow log"`
+		TrafficType string `help:"Traffic to capture" choices:"accept|reject|all"`
+		SinkPath    string `help:"File path, or object key prefix when sink type is bucket"`
+		RateLimit   int    `help:"Max records per second per host"`
+		Desc        string `help:"Description" metavar:"Description"`
+	}
+	R(&FlowLogUpdateOptions{}, "flow-log-update", "Update flow log", func(s *mcclient.ClientSession, args *FlowLogUpdateOptions) error {
+		params := jsonutils.NewDict()
+		if len(args.Name) > 0 {
+			params.Add(jsonutils.NewString(args.Name), "name")
+		}
+		if len(args.TrafficType) > 0 {
+			params.Add(jsonutils.NewString(args.TrafficType), "traffic_type")
+		}
+		if len(args.SinkPath) > 0 {
+			params.Add(jsonutils.NewString(args.SinkPath), "sink_path")
+		}
+		if args.RateLimit > 0 {
+			params.Add(jsonutils.NewInt(int64(args.RateLimit)), "rate_limit")
+		}
+		if len(args.Desc) > 0 {
+			params.Add(jsonutils.NewString(args.Desc), "description")
+		}
+		result, err := modules.FlowLogs.Update(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
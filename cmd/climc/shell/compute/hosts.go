@@ -16,11 +16,13 @@ package compute
 
 import (
 	"fmt"
+	"strings"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
 	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
@@ -677,6 +679,77 @@ func init() {
 		return nil
 	})
 
+	type HostBiosOptions struct {
+		ID string `help:"ID or name of host"`
+	}
+	R(&HostBiosOptions{}, "host-bios", "Get BIOS attributes of baremetal host", func(s *mcclient.ClientSession, args *HostBiosOptions) error {
+		bios, err := modules.Hosts.GetSpecific(s, args.ID, "bios", nil)
+		if err != nil {
+			return err
+		}
+		printObject(bios)
+		return nil
+	})
+
+	type HostSetBiosOptions struct {
+		ID   string   `help:"ID or name of host"`
+		ATTR []string `help:"BIOS attribute in format of key=value, value is parsed as JSON if possible"`
+	}
+	R(&HostSetBiosOptions{}, "host-set-bios", "Set BIOS attributes of baremetal host, take effect on next reset", func(s *mcclient.ClientSession, args *HostSetBiosOptions) error {
+		attrs := jsonutils.NewDict()
+		for _, kv := range args.ATTR {
+			pos := strings.Index(kv, "=")
+			if pos <= 0 {
+				return fmt.Errorf("invalid attribute %s", kv)
+			}
+			val, err := jsonutils.ParseString(kv[pos+1:])
+			if err != nil {
+				val = jsonutils.NewString(kv[pos+1:])
+			}
+			attrs.Set(kv[:pos], val)
+		}
+		params := jsonutils.NewDict()
+		params.Add(attrs, "attributes")
+		result, err := modules.Hosts.PerformAction(s, args.ID, "set-bios", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type HostFirmwareListOptions struct {
+		ID string `help:"ID or name of host"`
+	}
+	R(&HostFirmwareListOptions{}, "host-firmware-list", "List firmware inventory of baremetal host", func(s *mcclient.ClientSession, args *HostFirmwareListOptions) error {
+		result, err := modules.Hosts.GetSpecific(s, args.ID, "firmwares", nil)
+		if err != nil {
+			return err
+		}
+		fws, err := result.GetArray("firmwares")
+		if err != nil {
+			return err
+		}
+		printList(&modulebase.ListResult{Data: fws, Total: len(fws)}, nil)
+		return nil
+	})
+
+	type HostUpdateFirmwareOptions struct {
+		ID       string   `help:"ID or name of host" json:"-"`
+		ImageUri string   `help:"URI of firmware image" required:"true" json:"image_uri"`
+		Protocol string   `help:"transfer protocol, e.g. HTTP, HTTPS, NFS" json:"protocol"`
+		Targets  []string `help:"path of firmware inventory item to update" json:"targets"`
+	}
+	R(&HostUpdateFirmwareOptions{}, "host-update-firmware", "Update firmware of baremetal host through BMC", func(s *mcclient.ClientSession, args *HostUpdateFirmwareOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Hosts.PerformAction(s, args.ID, "update-firmware", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 	type HostInsertIsoOptions struct {
 		ID    string `help:"ID or name of host" json:"-"`
 		Image string `help:"ID or name or ISO image name" json:"image"`
@@ -806,6 +879,8 @@ func init() {
 		ID      string `help:"ID or name of host"`
 		Enable  bool   `help:"enable auto migrate"`
 		Disable bool   `help:"disable auto migrate"`
+
+		FencePolicy string `help:"fence host before migrating servers, only BMC power off is supported" choices:"none|power_off"`
 	}
 	R(&HostAutoMigrateOnHostDownOptions{}, "host-auto-migrate-on-host-down", "Get change owner candidate domain list", func(s *mcclient.ClientSession, args *HostAutoMigrateOnHostDownOptions) error {
 		params := jsonutils.NewDict()
@@ -813,8 +888,11 @@ func init() {
 			params.Set("auto_migrate_on_host_down", jsonutils.NewString("disable"))
 		} else if args.Enable {
 			params.Set("auto_migrate_on_host_down", jsonutils.NewString("enable"))
-		} else {
-			return fmt.Errorf("missing input enable or disable")
+		} else if len(args.FencePolicy) == 0 {
+			return fmt.Errorf("missing input enable, disable or fence policy")
+		}
+		if len(args.FencePolicy) > 0 {
+			params.Set("fence_policy", jsonutils.NewString(args.FencePolicy))
 		}
 		result, err := modules.Hosts.PerformAction(s, args.ID, "auto-migrate-on-host-down", params)
 		if err != nil {
@@ -71,6 +71,9 @@ func init() {
 		IsAutoAlloc *bool  `help:"Auto allocation IP pool"`
 		BgpType     string `help:"Internet service provider name" positional:"false"`
 		Desc        string `help:"Description" metavar:"DESCRIPTION"`
+		Ip6Prefix   string `help:"IPv6 prefix of dual-stack network, e.g. fd00:1::/64"`
+		Gateway6    string `help:"Default IPv6 gateway"`
+		Dns6        string `help:"IPv6 DNS server"`
 	}
 	R(&NetworkCreateOptions{}, "network-create", "Create a virtual network", func(s *mcclient.ClientSession, args *NetworkCreateOptions) error {
 		params := jsonutils.NewDict()
@@ -102,6 +105,15 @@ func init() {
 		if args.IsAutoAlloc != nil {
 			params.Add(jsonutils.NewBool(*args.IsAutoAlloc), "is_auto_alloc")
 		}
+		if len(args.Ip6Prefix) > 0 {
+			params.Add(jsonutils.NewString(args.Ip6Prefix), "guest_ip6_prefix")
+		}
+		if len(args.Gateway6) > 0 {
+			params.Add(jsonutils.NewString(args.Gateway6), "guest_gateway6")
+		}
+		if len(args.Dns6) > 0 {
+			params.Add(jsonutils.NewString(args.Dns6), "guest_dns6")
+		}
 		net, e := modules.Networks.CreateInContext(s, params, &modules.Wires, args.WIRE)
 		if e != nil {
 			return e
@@ -118,11 +130,15 @@ func init() {
 		PREFIX  string `help:"Start of IPv4 address range"`
 		BgpType string `help:"Internet service provider name" positional:"false"`
 		Desc    string `help:"Description" metavar:"DESCRIPTION"`
+		Prefix6 string `help:"IPv6 prefix of dual-stack network, e.g. fd00:1::/64"`
 	}
 	R(&NetworkCreateOptions2{}, "network-create2", "Create a virtual network", func(s *mcclient.ClientSession, args *NetworkCreateOptions2) error {
 		params := jsonutils.NewDict()
 		params.Add(jsonutils.NewString(args.NAME), "name")
 		params.Add(jsonutils.NewString(args.PREFIX), "guest_ip_prefix")
+		if len(args.Prefix6) > 0 {
+			params.Add(jsonutils.NewString(args.Prefix6), "guest_ip6_prefix")
+		}
 		if len(args.BgpType) > 0 {
 			params.Add(jsonutils.NewString(args.BgpType), "bgp_type")
 		}
@@ -0,0 +1,248 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type RebalancerListOptions struct {
+		options.BaseListOptions
+		Zone     string `help:"Zone ID or Name" json:"zone_id"`
+		Schedtag string `help:"Host schedtag ID or Name" json:"schedtag_id"`
+		Mode     string `help:"Execution mode" choices:"manual|auto"`
+	}
+	R(&RebalancerListOptions{}, "rebalancer-list", "List host rebalancers",
+		func(s *mcclient.ClientSession, args *RebalancerListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			result, err := modules.Rebalancers.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(result, modules.Rebalancers.GetColumns(s))
+			return nil
+		},
+	)
+
+	type RebalancerShowOptions struct {
+		ID string `help:"Rebalancer ID or Name"`
+	}
+	R(&RebalancerShowOptions{}, "rebalancer-show", "Show host rebalancer",
+		func(s *mcclient.ClientSession, args *RebalancerShowOptions) error {
+			result, err := modules.Rebalancers.Get(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type RebalancerCreateOptions struct {
+		NAME             string `help:"Rebalancer Name"`
+		Zone             string `help:"Rebalance hosts in zone"`
+		Schedtag         string `help:"Rebalance hosts with schedtag"`
+		Mode             string `help:"Execution mode of migration plan" choices:"manual|auto" default:"manual"`
+		Threshold        int    `help:"Rebalance when host load exceeds average load by this percentage points" default:"15"`
+		NoUtilization    bool   `help:"Only use cpu and memory commit rate, ignore real utilization from monitor"`
+		MaxConcurrency   int    `help:"Maximal concurrent live migrations" default:"2"`
+		MaxMigrations    int    `help:"Maximal migrations in one plan" default:"5"`
+		EvaluateInterval int    `help:"Evaluate interval, unit: minute" default:"30"`
+	}
+	R(&RebalancerCreateOptions{}, "rebalancer-create", "Create host rebalancer",
+		func(s *mcclient.ClientSession, args *RebalancerCreateOptions) error {
+			useUtilization := !args.NoUtilization
+			input := api.RebalancerCreateInput{
+				ZoneId:           args.Zone,
+				SchedtagId:       args.Schedtag,
+				Mode:             args.Mode,
+				Threshold:        args.Threshold,
+				UseUtilization:   &useUtilization,
+				MaxConcurrency:   args.MaxConcurrency,
+				MaxMigrations:    args.MaxMigrations,
+				EvaluateInterval: args.EvaluateInterval,
+			}
+			input.Name = args.NAME
+			result, err := modules.Rebalancers.Create(s, jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type RebalancerUpdateOptions struct {
+		ID               string `help:"Rebalancer ID or Name"`
+		Mode             string `help:"Execution mode of migration plan" choices:"manual|auto"`
+		Threshold        int    `help:"Rebalance when host load exceeds average load by this percentage points"`
+		UseUtilization   string `help:"Whether to use real utilization from monitor" choices:"true|false"`
+		MaxConcurrency   int    `help:"Maximal concurrent live migrations"`
+		MaxMigrations    int    `help:"Maximal migrations in one plan"`
+		EvaluateInterval int    `help:"Evaluate interval, unit: minute"`
+	}
+	R(&RebalancerUpdateOptions{}, "rebalancer-update", "Update host rebalancer",
+		func(s *mcclient.ClientSession, args *RebalancerUpdateOptions) error {
+			params := jsonutils.NewDict()
+			if len(args.Mode) > 0 {
+				params.Set("mode", jsonutils.NewString(args.Mode))
+			}
+			if args.Threshold > 0 {
+				params.Set("threshold", jsonutils.NewInt(int64(args.Threshold)))
+			}
+			if len(args.UseUtilization) > 0 {
+				params.Set("use_utilization", jsonutils.NewBool(args.UseUtilization == "true"))
+			}
+			if args.MaxConcurrency > 0 {
+				params.Set("max_concurrency", jsonutils.NewInt(int64(args.MaxConcurrency)))
+			}
+			if args.MaxMigrations > 0 {
+				params.Set("max_migrations", jsonutils.NewInt(int64(args.MaxMigrations)))
+			}
+			if args.EvaluateInterval > 0 {
+				params.Set("evaluate_interval", jsonutils.NewInt(int64(args.EvaluateInterval)))
+			}
+			result, err := modules.Rebalancers.Update(s, args.ID, params)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type RebalancerIdOptions struct {
+		ID string `help:"Rebalancer ID or Name"`
+	}
+	R(&RebalancerIdOptions{}, "rebalancer-delete", "Delete host rebalancer",
+		func(s *mcclient.ClientSession, args *RebalancerIdOptions) error {
+			result, err := modules.Rebalancers.Delete(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	R(&RebalancerIdOptions{}, "rebalancer-enable", "Enable host rebalancer",
+		func(s *mcclient.ClientSession, args *RebalancerIdOptions) error {
+			result, err := modules.Rebalancers.PerformAction(s, args.ID, "enable", nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	R(&RebalancerIdOptions{}, "rebalancer-disable", "Disable host rebalancer",
+		func(s *mcclient.ClientSession, args *RebalancerIdOptions) error {
+			result, err := modules.Rebalancers.PerformAction(s, args.ID, "disable", nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type RebalancerEvaluateOptions struct {
+		ID     string `help:"Rebalancer ID or Name"`
+		DryRun bool   `help:"Only show the migration plan, do not record or execute it"`
+	}
+	R(&RebalancerEvaluateOptions{}, "rebalancer-evaluate", "Evaluate host loads and generate migration plan now",
+		func(s *mcclient.ClientSession, args *RebalancerEvaluateOptions) error {
+			input := api.RebalancerEvaluateInput{DryRun: args.DryRun}
+			result, err := modules.Rebalancers.PerformAction(s, args.ID, "evaluate", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type RebalanceMigrationListOptions struct {
+		options.BaseListOptions
+		Rebalancer string `help:"Rebalancer ID or Name" json:"rebalancer_id"`
+		Guest      string `help:"Guest ID or Name" json:"guest_id"`
+		Active     *bool  `help:"Only list migrations not finished" negative:"finished"`
+	}
+	R(&RebalanceMigrationListOptions{}, "rebalance-migration-list", "List migration plan of host rebalancers",
+		func(s *mcclient.ClientSession, args *RebalanceMigrationListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			result, err := modules.RebalanceMigrations.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(result, modules.RebalanceMigrations.GetColumns(s))
+			return nil
+		},
+	)
+
+	type RebalanceMigrationIdOptions struct {
+		ID string `help:"RebalanceMigration ID or Name"`
+	}
+	R(&RebalanceMigrationIdOptions{}, "rebalance-migration-show", "Show rebalance migration",
+		func(s *mcclient.ClientSession, args *RebalanceMigrationIdOptions) error {
+			result, err := modules.RebalanceMigrations.Get(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	R(&RebalanceMigrationIdOptions{}, "rebalance-migration-approve", "Approve rebalance migration",
+		func(s *mcclient.ClientSession, args *RebalanceMigrationIdOptions) error {
+			result, err := modules.RebalanceMigrations.PerformAction(s, args.ID, "approve", nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type RebalanceMigrationRejectOptions struct {
+		ID     string `help:"RebalanceMigration ID or Name"`
+		Reason string `help:"Reason of rejection"`
+	}
+	R(&RebalanceMigrationRejectOptions{}, "rebalance-migration-reject", "Reject rebalance migration",
+		func(s *mcclient.ClientSession, args *RebalanceMigrationRejectOptions) error {
+			input := api.RebalanceMigrationRejectInput{Reason: args.Reason}
+			result, err := modules.RebalanceMigrations.PerformAction(s, args.ID, "reject", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type ScalingLifecycleHookListOptions struct {
+		options.BaseListOptions
+		ScalingGroup string `help:"ScalingGroup ID or Name"`
+		Transition   string `help:"Transition" choices:"scale_out|scale_in"`
+	}
+	R(&ScalingLifecycleHookListOptions{}, "scaling-lifecycle-hook-list", "List lifecycle hooks of scaling group",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			result, err := modules.ScalingLifecycleHook.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(result, modules.ScalingLifecycleHook.GetColumns(s))
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookShowOptions struct {
+		ID string `help:"ScalingLifecycleHook ID or Name"`
+	}
+	R(&ScalingLifecycleHookShowOptions{}, "scaling-lifecycle-hook-show", "Show lifecycle hook of scaling group",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookShowOptions) error {
+			result, err := modules.ScalingLifecycleHook.Get(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookCreateOptions struct {
+		NAME          string `help:"ScalingLifecycleHook Name"`
+		SCALINGGROUP  string `help:"ScalingGroup ID or Name"`
+		TRANSITION    string `help:"Transition" choices:"scale_out|scale_in"`
+		WebhookUrl    string `help:"Webhook url which is notified when instance enters pending:wait"`
+		Timeout       int    `help:"Timeout of waiting, unit: s" default:"300"`
+		DefaultResult string `help:"Default result after timeout" choices:"continue|abandon" default:"continue"`
+	}
+	R(&ScalingLifecycleHookCreateOptions{}, "scaling-lifecycle-hook-create", "Create lifecycle hook of scaling group",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookCreateOptions) error {
+			input := api.ScalingLifecycleHookCreateInput{
+				ScalingGroup:  args.SCALINGGROUP,
+				Transition:    args.TRANSITION,
+				WebhookUrl:    args.WebhookUrl,
+				Timeout:       args.Timeout,
+				DefaultResult: args.DefaultResult,
+			}
+			input.Name = args.NAME
+			result, err := modules.ScalingLifecycleHook.Create(s, jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookUpdateOptions struct {
+		ID            string `help:"ScalingLifecycleHook ID or Name"`
+		WebhookUrl    string `help:"Webhook url which is notified when instance enters pending:wait"`
+		Timeout       int    `help:"Timeout of waiting, unit: s"`
+		DefaultResult string `help:"Default result after timeout" choices:"continue|abandon"`
+	}
+	R(&ScalingLifecycleHookUpdateOptions{}, "scaling-lifecycle-hook-update", "Update lifecycle hook of scaling group",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookUpdateOptions) error {
+			params := jsonutils.NewDict()
+			if len(args.WebhookUrl) > 0 {
+				params.Set("webhook_url", jsonutils.NewString(args.WebhookUrl))
+			}
+			if args.Timeout > 0 {
+				params.Set("timeout", jsonutils.NewInt(int64(args.Timeout)))
+			}
+			if len(args.DefaultResult) > 0 {
+				params.Set("default_result", jsonutils.NewString(args.DefaultResult))
+			}
+			result, err := modules.ScalingLifecycleHook.Update(s, args.ID, params)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookDeleteOptions struct {
+		ID string `help:"ScalingLifecycleHook ID or Name"`
+	}
+	R(&ScalingLifecycleHookDeleteOptions{}, "scaling-lifecycle-hook-delete", "Delete lifecycle hook of scaling group",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookDeleteOptions) error {
+			result, err := modules.ScalingLifecycleHook.Delete(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+
+	type ScalingGroupCompleteLifecycleActionOptions struct {
+		ID     string `help:"ScalingGroup ID or Name"`
+		GUEST  string `help:"Guest ID or Name in pending:wait state"`
+		Token  string `help:"Lifecycle token from the webhook notification"`
+		Result string `help:"Lifecycle result" choices:"continue|abandon" default:"continue"`
+	}
+	R(&ScalingGroupCompleteLifecycleActionOptions{}, "scaling-group-complete-lifecycle-action",
+		"Complete the lifecycle action of instance in pending:wait state",
+		func(s *mcclient.ClientSession, args *ScalingGroupCompleteLifecycleActionOptions) error {
+			input := api.ScalingGroupCompleteLifecycleActionInput{
+				Guest:          args.GUEST,
+				LifecycleToken: args.Token,
+				Result:         args.Result,
+			}
+			result, err := modules.ScalingGroup.PerformAction(s, args.ID, "complete-lifecycle-action", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		},
+	)
+}
@@ -44,7 +44,7 @@ func init() {
 	type ScalingPolicyListOptions struct {
 		options.BaseListOptions
 		ScalingGroup string `help:"ScalingGroup ID or Name"`
-		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle"`
+		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle|target_tracking"`
 	}
 	R(&ScalingPolicyListOptions{}, "scaling-policy-list", "List Scaling Policy", func(s *mcclient.ClientSession,
 		args *ScalingPolicyListOptions) error {
@@ -81,14 +81,25 @@ func init() {
 		AlarmValue     float64 `help:"Value of Indicator" json:"value"`
 	}
 
+	type ScalingTargetTracking struct {
+		TrackingIndicator        string  `help:"Indicator for 'target_tracking' trigger" choices:"cpu|mem|custom"`
+		TrackingCustomQuery      string  `help:"Monitor metric query in json for 'custom' indicator"`
+		TrackingTargetValue      float64 `help:"Target value of indicator"`
+		TrackingCycle            int     `help:"Monitoring cycle for indicator, unit: s"`
+		TrackingScaleOutCooldown int     `help:"Cooldown after scaling out, unit: s"`
+		TrackingScaleInCooldown  int     `help:"Cooldown after scaling in, unit: s"`
+		TrackingDisableScaleIn   bool    `help:"Only scale out for 'target_tracking' trigger"`
+	}
+
 	type ScalingPolicyCreateOptions struct {
 		NAME         string `help:"ScalingPolicy Name" json:"name"`
 		ScalingGroup string `help:"ScalingGroup ID or Name" json:"scaling_group"`
-		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle" json:"trigger_type"`
+		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle|target_tracking" json:"trigger_type"`
 
 		Timer
 		CycleTimer
 		ScalingAlarm
+		ScalingTargetTracking
 
 		Action      string `help:"Action for scaling policy" choices:"add|remove|set" json:"action"`
 		Number      int    `help:"Instance number for action" json:"number"`
@@ -99,17 +110,32 @@ func init() {
 	R(&ScalingPolicyCreateOptions{}, "scaling-policy-create", "Create Scaling Policy",
 		func(s *mcclient.ClientSession, args *ScalingPolicyCreateOptions) error {
 			formatStr := "2006-01-02 15:04:05"
-			timingExecTime, err := time.Parse(formatStr, args.TimingExecTime)
-			if err != nil {
-				return fmt.Errorf("invalid time format for 'exec_time'")
+			var timingExecTime, cycleStarTime, cycleEndTime time.Time
+			var err error
+			if len(args.TimingExecTime) > 0 {
+				timingExecTime, err = time.Parse(formatStr, args.TimingExecTime)
+				if err != nil {
+					return fmt.Errorf("invalid time format for 'exec_time'")
+				}
 			}
-			cycleStarTime, err := time.Parse(formatStr, args.CycleStartTime)
-			if err != nil {
-				return fmt.Errorf("invalid time format for 'start_time'")
+			if len(args.CycleStartTime) > 0 {
+				cycleStarTime, err = time.Parse(formatStr, args.CycleStartTime)
+				if err != nil {
+					return fmt.Errorf("invalid time format for 'start_time'")
+				}
 			}
-			cycleEndTime, err := time.Parse(formatStr, args.CycleEndTime)
-			if err != nil {
-				return fmt.Errorf("invalid time format for 'end_time'")
+			if len(args.CycleEndTime) > 0 {
+				cycleEndTime, err = time.Parse(formatStr, args.CycleEndTime)
+				if err != nil {
+					return fmt.Errorf("invalid time format for 'end_time'")
+				}
+			}
+			var customQuery jsonutils.JSONObject
+			if len(args.TrackingCustomQuery) > 0 {
+				customQuery, err = jsonutils.ParseString(args.TrackingCustomQuery)
+				if err != nil {
+					return fmt.Errorf("invalid json for 'tracking_custom_query'")
+				}
 			}
 			spCreateInput := api.ScalingPolicyCreateInput{
 				ScalingGroup: args.ScalingGroup,
@@ -134,6 +160,15 @@ func init() {
 					Operator:  args.AlarmOperator,
 					Value:     args.AlarmValue,
 				},
+				TargetTracking: api.ScalingTargetTrackingCreateInput{
+					Indicator:        args.TrackingIndicator,
+					CustomQuery:      customQuery,
+					TargetValue:      args.TrackingTargetValue,
+					Cycle:            args.TrackingCycle,
+					ScaleOutCooldown: args.TrackingScaleOutCooldown,
+					ScaleInCooldown:  args.TrackingScaleInCooldown,
+					DisableScaleIn:   args.TrackingDisableScaleIn,
+				},
 				Action:      args.Action,
 				Number:      args.Number,
 				Unit:        args.Unit,
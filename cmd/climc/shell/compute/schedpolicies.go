@@ -15,6 +15,10 @@
 package compute
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"yunion.io/x/jsonutils"
 
 	"yunion.io/x/onecloud/pkg/mcclient"
@@ -50,6 +54,8 @@ func init() {
 		CONDITION string `help:"condition that assign schedtag to hosts"`
 		Enable    bool   `help:"create the policy with enabled status"`
 		Disable   bool   `help:"create the policy with disabled status"`
+
+		UtilizationWeights string `help:"weights of host actual utilization, e.g. cpu=1,memory=1,disk_io=0.5,network=0.5"`
 	}
 	R(&SchedpoliciesCreateOptions{}, "sched-policy-create", "create a sched policty", func(s *mcclient.ClientSession, args *SchedpoliciesCreateOptions) error {
 		params := jsonutils.NewDict()
@@ -57,6 +63,13 @@ func init() {
 		params.Add(jsonutils.NewString(args.STRATEGY), "strategy")
 		params.Add(jsonutils.NewString(args.CONDITION), "condition")
 		params.Add(jsonutils.NewString(args.SCHEDTAG), "schedtag")
+		if len(args.UtilizationWeights) > 0 {
+			weights, err := parseUtilizationWeights(args.UtilizationWeights)
+			if err != nil {
+				return err
+			}
+			params.Add(weights, "utilization_weights")
+		}
 
 		if args.Enable {
 			params.Add(jsonutils.JSONTrue, "enabled")
@@ -80,6 +93,8 @@ func init() {
 		Condition string `help:"condition that assign schedtag to hosts"`
 		Enable    bool   `help:"make the sched policy enabled"`
 		Disable   bool   `help:"make the sched policy disabled"`
+
+		UtilizationWeights string `help:"weights of host actual utilization, e.g. cpu=1,memory=1,disk_io=0.5,network=0.5"`
 	}
 	R(&SchedpoliciesUpdateOptions{}, "sched-policy-update", "update a sched policy", func(s *mcclient.ClientSession, args *SchedpoliciesUpdateOptions) error {
 		params := jsonutils.NewDict()
@@ -96,6 +111,13 @@ func init() {
 		if len(args.SchedTag) > 0 {
 			params.Add(jsonutils.NewString(args.SchedTag), "schedtag")
 		}
+		if len(args.UtilizationWeights) > 0 {
+			weights, err := parseUtilizationWeights(args.UtilizationWeights)
+			if err != nil {
+				return err
+			}
+			params.Add(weights, "utilization_weights")
+		}
 		if args.Enable {
 			params.Add(jsonutils.JSONTrue, "enabled")
 		} else if args.Disable {
@@ -154,3 +176,24 @@ func init() {
 		return nil
 	})
 }
+
+func parseUtilizationWeights(str string) (*jsonutils.JSONDict, error) {
+	weights := jsonutils.NewDict()
+	for _, seg := range strings.Split(str, ",") {
+		kv := strings.SplitN(strings.TrimSpace(seg), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid utilization weight %q, expect key=value", seg)
+		}
+		switch kv[0] {
+		case "cpu", "memory", "disk_io", "network":
+		default:
+			return nil, fmt.Errorf("unknown utilization weight key %s", kv[0])
+		}
+		val, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight %s of %s", kv[1], kv[0])
+		}
+		weights.Add(jsonutils.NewFloat64(val), kv[0])
+	}
+	return weights, nil
+}
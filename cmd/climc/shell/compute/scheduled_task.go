@@ -61,11 +61,16 @@ func init() {
 
 	type ScheduledTaskCreateOptions struct {
 		NAME          string `help:"ScheduledTask Name" json:"name"`
-		ScheduledType string `help:"Scheudled Type" choices:"timing|cycle" json:"scheduled_type"`
+		ScheduledType string `help:"Scheudled Type" choices:"timing|cycle|cron" json:"scheduled_type"`
 
 		Timer
 		CycleTimer
 
+		CronExpr     string `help:"Cron expression for cron timer, e.g. '30 2 * * mon-fri', start and end time are shared with cycle timer"`
+		CronTimeZone string `help:"Time zone for cron timer, e.g. Asia/Shanghai"`
+		CronJitter   int    `help:"Max random delay seconds for cron timer"`
+		CronCatchUp  string `help:"Policy of missed runs for cron timer" choices:"skip|once|all"`
+
 		ResourceType string   `help:"resource type"`
 		Operation    string   `help:"operation"`
 		LabelType    string   `help:"label type"`
@@ -107,6 +112,14 @@ func init() {
 				StartTime: starttime,
 				EndTime:   endtime,
 			},
+			CronTimer: apis.CronTimerCreateInput{
+				Expr:      args.CronExpr,
+				TimeZone:  args.CronTimeZone,
+				Jitter:    args.CronJitter,
+				CatchUp:   args.CronCatchUp,
+				StartTime: starttime,
+				EndTime:   endtime,
+			},
 			ResourceType: args.ResourceType,
 			Operation:    args.Operation,
 			LabelType:    args.LabelType,
@@ -78,6 +78,8 @@ func init() {
 		Cidr      string `help:"Cidr of rule"`
 		Priority  int64  `help:"priority of Rule"`
 		Desc      string `help:"Description"`
+
+		AddressGroup string `help:"Address group ID or Name, exclusive with --cidr"`
 	}
 
 	R(&SecGroupRulesCreateOptions{}, "secgroup-rule-create", "Create all security group rule", func(s *mcclient.ClientSession, args *SecGroupRulesCreateOptions) error {
@@ -103,6 +105,9 @@ func init() {
 		if len(args.Cidr) > 0 {
 			params.Add(jsonutils.NewString(args.Cidr), "cidr")
 		}
+		if len(args.AddressGroup) > 0 {
+			params.Add(jsonutils.NewString(args.AddressGroup), "address_group")
+		}
 		params.Add(jsonutils.NewString(args.SECGROUP), "secgroup")
 		secgrouprules, err := modules.SecGroupRules.Create(s, params)
 		if err != nil {
@@ -121,6 +126,8 @@ func init() {
 		Cidr     string `help:"Cidr of rule"`
 		Action   string `help:"filter Actin of rule" choices:"allow|deny"`
 		Desc     string `help:"Description" metavar:"Description"`
+
+		AddressGroup string `help:"Address group ID or Name, replaces cidr of rule"`
 	}
 
 	R(&SecGroupRulesUpdateOptions{}, "secgroup-rule-update", "Update property of a security group rule", func(s *mcclient.ClientSession, args *SecGroupRulesUpdateOptions) error {
@@ -146,6 +153,9 @@ func init() {
 		if len(args.Action) > 0 {
 			params.Add(jsonutils.NewString(args.Action), "action")
 		}
+		if len(args.AddressGroup) > 0 {
+			params.Add(jsonutils.NewString(args.AddressGroup), "address_group")
+		}
 		if rule, e := modules.SecGroupRules.Update(s, args.ID, params); e != nil {
 			return e
 		} else {
@@ -0,0 +1,152 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	cmd := shell.NewResourceCmd(&modules.Webhooks).WithKeyword("webhook")
+	cmd.List(&WebhookListOptions{})
+	cmd.Show(&options.BaseShowOptions{})
+	cmd.Create(&WebhookCreateOptions{})
+	cmd.Update(&WebhookUpdateOptions{})
+	cmd.Delete(&WebhookIdOptions{})
+	cmd.Perform("enable", &WebhookIdOptions{})
+	cmd.Perform("disable", &WebhookIdOptions{})
+	cmd.Perform("ping", &WebhookIdOptions{})
+	cmd.Perform("redeliver", &WebhookIdOptions{})
+
+	deliveryCmd := shell.NewResourceCmd(&modules.WebhookDeliveries).WithKeyword("webhook-delivery")
+	deliveryCmd.List(&WebhookDeliveryListOptions{})
+	deliveryCmd.Show(&options.BaseShowOptions{})
+	deliveryCmd.Delete(&WebhookIdOptions{})
+	deliveryCmd.Perform("redeliver", &WebhookIdOptions{})
+}
+
+type WebhookIdOptions struct {
+	ID string `help:"ID or name"`
+}
+
+func (opts *WebhookIdOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *WebhookIdOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type WebhookListOptions struct {
+	options.BaseListOptions
+	ResourceType string `help:"filter by subscribed resource type, e.g. servers"`
+	ProjectId    string `help:"filter by project scope" json:"project_id"`
+}
+
+func (opts *WebhookListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type WebhookCreateOptions struct {
+	NAME         string   `help:"name of webhook"`
+	URL          string   `help:"http or https url receiving events"`
+	ResourceType []string `help:"keyword plural of resources to subscribe, e.g. servers" required:"true"`
+	Action       []string `help:"actions to subscribe, all actions if not specified" choices:"create|update|delete"`
+	ProjectId    string   `help:"only deliver events of resources in this project" json:"project_id"`
+	Secret       string   `help:"secret to sign events with HMAC-SHA256"`
+	MaxAttempts  int      `help:"attempts before a delivery turns dead"`
+	Desc         string   `help:"description"`
+}
+
+func (opts *WebhookCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(opts.NAME), "name")
+	params.Add(jsonutils.NewString(opts.URL), "url")
+	params.Add(jsonutils.NewStringArray(opts.ResourceType), "resource_types")
+	if len(opts.Action) > 0 {
+		params.Add(jsonutils.NewStringArray(opts.Action), "actions")
+	}
+	if len(opts.ProjectId) > 0 {
+		params.Add(jsonutils.NewString(opts.ProjectId), "project_id")
+	}
+	if len(opts.Secret) > 0 {
+		params.Add(jsonutils.NewString(opts.Secret), "secret")
+	}
+	if opts.MaxAttempts > 0 {
+		params.Add(jsonutils.NewInt(int64(opts.MaxAttempts)), "max_attempts")
+	}
+	if len(opts.Desc) > 0 {
+		params.Add(jsonutils.NewString(opts.Desc), "description")
+	}
+	return params, nil
+}
+
+type WebhookUpdateOptions struct {
+	WebhookIdOptions
+	Name         string   `help:"new name of webhook"`
+	Url          string   `help:"http or https url receiving events"`
+	ResourceType []string `help:"keyword plural of resources to subscribe"`
+	Action       []string `help:"actions to subscribe" choices:"create|update|delete"`
+	ProjectId    *string  `help:"only deliver events of resources in this project, empty string to clear" json:"project_id"`
+	Secret       *string  `help:"secret to sign events, empty string to clear"`
+	MaxAttempts  int      `help:"attempts before a delivery turns dead"`
+	Desc         string   `help:"description"`
+}
+
+func (opts *WebhookUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if len(opts.Name) > 0 {
+		params.Add(jsonutils.NewString(opts.Name), "name")
+	}
+	if len(opts.Url) > 0 {
+		params.Add(jsonutils.NewString(opts.Url), "url")
+	}
+	if len(opts.ResourceType) > 0 {
+		params.Add(jsonutils.NewStringArray(opts.ResourceType), "resource_types")
+	}
+	if len(opts.Action) > 0 {
+		params.Add(jsonutils.NewStringArray(opts.Action), "actions")
+	}
+	if opts.ProjectId != nil {
+		params.Add(jsonutils.NewString(*opts.ProjectId), "project_id")
+	}
+	if opts.Secret != nil {
+		params.Add(jsonutils.NewString(*opts.Secret), "secret")
+	}
+	if opts.MaxAttempts > 0 {
+		params.Add(jsonutils.NewInt(int64(opts.MaxAttempts)), "max_attempts")
+	}
+	if len(opts.Desc) > 0 {
+		params.Add(jsonutils.NewString(opts.Desc), "description")
+	}
+	return params, nil
+}
+
+type WebhookDeliveryListOptions struct {
+	options.BaseListOptions
+	Webhook      string   `help:"ID or name of webhook" json:"webhook_id"`
+	Status       []string `help:"filter by status" choices:"pending|retrying|succeeded|dead"`
+	ResourceType string   `help:"filter by resource type"`
+	EventId      string   `help:"filter by CloudEvent id"`
+	Subject      string   `help:"filter by resource id"`
+}
+
+func (opts *WebhookDeliveryListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
@@ -0,0 +1,219 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"io/ioutil"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type ActionVerifyOptions struct {
+		StartId int64 `help:"verify from this action log id"`
+		EndId   int64 `help:"verify until this action log id"`
+	}
+	R(&ActionVerifyOptions{}, "action-verify", "Verify hash chain and signed checkpoints of action logs", func(s *mcclient.ClientSession, args *ActionVerifyOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Actions.Get(s, "verify", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ActionlogCheckpointListOptions struct {
+		options.BaseListOptions
+	}
+	R(&ActionlogCheckpointListOptions{}, "actionlog-checkpoint-list", "List signed checkpoints of action logs", func(s *mcclient.ClientSession, args *ActionlogCheckpointListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.ActionlogCheckpoints.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.ActionlogCheckpoints.GetColumns(s))
+		return nil
+	})
+
+	cmd := shell.NewResourceCmd(&modules.ActionlogExporters).WithKeyword("actionlog-exporter")
+	cmd.List(&ActionlogExporterListOptions{})
+	cmd.Show(&options.BaseShowOptions{})
+	cmd.Delete(&ActionlogExporterIdOptions{})
+	cmd.Perform("enable", &ActionlogExporterIdOptions{})
+	cmd.Perform("disable", &ActionlogExporterIdOptions{})
+	cmd.Perform("reset-cursor", &ActionlogExporterResetCursorOptions{})
+
+	R(&ActionlogExporterCreateOptions{}, "actionlog-exporter-create", "Create an exporter streaming action logs to SIEM", func(s *mcclient.ClientSession, args *ActionlogExporterCreateOptions) error {
+		params, err := args.Params()
+		if err != nil {
+			return err
+		}
+		result, err := modules.ActionlogExporters.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ActionlogExporterUpdateOptions{}, "actionlog-exporter-update", "Update an action log exporter", func(s *mcclient.ClientSession, args *ActionlogExporterUpdateOptions) error {
+		params, err := args.Params()
+		if err != nil {
+			return err
+		}
+		result, err := modules.ActionlogExporters.Update(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
+
+type ActionlogExporterIdOptions struct {
+	ID string `help:"ID or name of exporter"`
+}
+
+func (opts *ActionlogExporterIdOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *ActionlogExporterIdOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type ActionlogExporterListOptions struct {
+	options.BaseListOptions
+	Format    []string `help:"filter by format" choices:"rfc5424|cef|json"`
+	Transport []string `help:"filter by transport" choices:"tcp|tls"`
+}
+
+func (opts *ActionlogExporterListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type ActionlogExporterResetCursorOptions struct {
+	ActionlogExporterIdOptions
+	CURSOR int64 `help:"export action logs with id greater than cursor"`
+}
+
+func (opts *ActionlogExporterResetCursorOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]int64{"cursor": opts.CURSOR}), nil
+}
+
+type ActionlogExporterFilterOptions struct {
+	FilterService  []string `help:"only export action logs of these services"`
+	FilterAction   []string `help:"only export these actions"`
+	FilterObjType  []string `help:"only export action logs of these object types"`
+	FilterFailOnly bool     `help:"only export failed actions"`
+}
+
+func (opts *ActionlogExporterFilterOptions) filter() jsonutils.JSONObject {
+	if len(opts.FilterService) == 0 && len(opts.FilterAction) == 0 && len(opts.FilterObjType) == 0 && !opts.FilterFailOnly {
+		return nil
+	}
+	filter := jsonutils.NewDict()
+	if len(opts.FilterService) > 0 {
+		filter.Add(jsonutils.NewStringArray(opts.FilterService), "services")
+	}
+	if len(opts.FilterAction) > 0 {
+		filter.Add(jsonutils.NewStringArray(opts.FilterAction), "actions")
+	}
+	if len(opts.FilterObjType) > 0 {
+		filter.Add(jsonutils.NewStringArray(opts.FilterObjType), "obj_types")
+	}
+	if opts.FilterFailOnly {
+		filter.Add(jsonutils.JSONTrue, "failed_only")
+	}
+	return filter
+}
+
+type ActionlogExporterCreateOptions struct {
+	NAME        string `help:"name of exporter"`
+	FORMAT      string `help:"message format" choices:"rfc5424|cef|json"`
+	ADDRESS     string `help:"address of collector, host:port"`
+	Transport   string `help:"transport protocol" choices:"tcp|tls" default:"tcp"`
+	TlsCaCert   string `help:"path of CA certificate file to verify collector"`
+	TlsInsecure bool   `help:"skip verifying certificate of collector"`
+	StartId     *int64 `help:"export action logs with id greater than this, default from now on"`
+	ActionlogExporterFilterOptions
+}
+
+func (opts *ActionlogExporterCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(opts.NAME), "name")
+	params.Add(jsonutils.NewString(opts.FORMAT), "format")
+	params.Add(jsonutils.NewString(opts.ADDRESS), "address")
+	params.Add(jsonutils.NewString(opts.Transport), "transport")
+	if len(opts.TlsCaCert) > 0 {
+		cert, err := ioutil.ReadFile(opts.TlsCaCert)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(jsonutils.NewString(string(cert)), "tls_ca_cert")
+	}
+	if opts.TlsInsecure {
+		params.Add(jsonutils.JSONTrue, "tls_insecure")
+	}
+	if opts.StartId != nil {
+		params.Add(jsonutils.NewInt(*opts.StartId), "start_id")
+	}
+	if filter := opts.filter(); filter != nil {
+		params.Add(filter, "filter")
+	}
+	return params, nil
+}
+
+type ActionlogExporterUpdateOptions struct {
+	ID          string `help:"ID or name of exporter"`
+	Name        string `help:"new name of exporter"`
+	Address     string `help:"address of collector, host:port"`
+	TlsCaCert   string `help:"path of CA certificate file to verify collector"`
+	TlsInsecure string `help:"skip verifying certificate of collector" choices:"true|false"`
+	ActionlogExporterFilterOptions
+}
+
+func (opts *ActionlogExporterUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if len(opts.Name) > 0 {
+		params.Add(jsonutils.NewString(opts.Name), "name")
+	}
+	if len(opts.Address) > 0 {
+		params.Add(jsonutils.NewString(opts.Address), "address")
+	}
+	if len(opts.TlsCaCert) > 0 {
+		cert, err := ioutil.ReadFile(opts.TlsCaCert)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(jsonutils.NewString(string(cert)), "tls_ca_cert")
+	}
+	if len(opts.TlsInsecure) > 0 {
+		params.Add(jsonutils.NewBool(opts.TlsInsecure == "true"), "tls_insecure")
+	}
+	if filter := opts.filter(); filter != nil {
+		params.Add(filter, "filter")
+	}
+	return params, nil
+}
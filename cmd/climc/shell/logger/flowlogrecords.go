@@ -0,0 +1,42 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+func init() {
+	type FlowLogRecordListOptions struct {
+		FlowLogId    string `help:"filter by flow log id"`
+		HostId       string `help:"filter by host id"`
+		PagingMarker string `help:"marker for pagination"`
+		Limit        int    `help:"page limit, default 20" default:"20"`
+		Since        string `help:"Show records since specific date" metavar:"DATETIME"`
+		Until        string `help:"Show records until specific date" metavar:"DATETIME"`
+	}
+	R(&FlowLogRecordListOptions{}, "flow-log-record-list", "List flow log records delivered to logger", func(s *mcclient.ClientSession, args *FlowLogRecordListOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.FlowLogRecords.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.FlowLogRecords.GetColumns(s))
+		return nil
+	})
+}
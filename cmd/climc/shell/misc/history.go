@@ -0,0 +1,133 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package misc
+
+import (
+	"fmt"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+)
+
+func getModule(s *mcclient.ClientSession, name string) (modulebase.Manager, error) {
+	mod, err := modulebase.GetModule(s, name)
+	if err != nil {
+		return nil, fmt.Errorf("module %s not found %s", name, err)
+	}
+	if mod == nil {
+		return nil, fmt.Errorf("No module %s found", name)
+	}
+	return mod, nil
+}
+
+func printFieldDiffs(diff jsonutils.JSONObject) {
+	diffMap, _ := diff.GetMap()
+	fields := make([]string, 0, len(diffMap))
+	for k := range diffMap {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	rows := make([]jsonutils.JSONObject, 0, len(fields))
+	for _, k := range fields {
+		row := jsonutils.NewDict()
+		row.Add(jsonutils.NewString(k), "field")
+		if old, err := diffMap[k].Get("old"); err == nil {
+			row.Add(old, "old")
+		}
+		if newVal, err := diffMap[k].Get("new"); err == nil {
+			row.Add(newVal, "new")
+		}
+		rows = append(rows, row)
+	}
+	printList(&modulebase.ListResult{Data: rows, Total: len(rows)}, []string{"field", "old", "new"})
+}
+
+func init() {
+	type ResourceHistoryOptions struct {
+		MODULE string `help:"module name, e.g. servers"`
+		ID     string `help:"ID or name of resource"`
+		At     string `help:"point in time, e.g. 2020-08-01T08:00:00Z, default now"`
+	}
+	R(&ResourceHistoryOptions{}, "resource-history", "Show state of a resource at a point in time", func(s *mcclient.ClientSession, args *ResourceHistoryOptions) error {
+		mod, err := getModule(s, args.MODULE)
+		if err != nil {
+			return err
+		}
+		params := jsonutils.NewDict()
+		if len(args.At) > 0 {
+			params.Add(jsonutils.NewString(args.At), "at")
+		}
+		result, err := mod.GetSpecific(s, args.ID, "history", params)
+		if err != nil {
+			return err
+		}
+		if exists, _ := result.Bool("exists"); !exists {
+			at, _ := result.GetString("at")
+			return fmt.Errorf("%s %s does not exist at %s", args.MODULE, args.ID, at)
+		}
+		if complete, _ := result.Bool("complete"); !complete {
+			fmt.Println("Warning: changes are not fully recorded since then, the state may be inaccurate")
+		}
+		obj, _ := result.Get("object")
+		if obj != nil {
+			printObject(obj)
+		}
+		return nil
+	})
+
+	type ResourceDiffOptions struct {
+		MODULE  string `help:"module name, e.g. servers"`
+		ID      string `help:"ID or name of resource"`
+		SINCE   string `help:"start time, e.g. 2020-08-01T08:00:00Z"`
+		Until   string `help:"end time, default now"`
+		Changes bool   `help:"also show every change in between"`
+	}
+	R(&ResourceDiffOptions{}, "resource-diff", "Show changes of a resource between two points in time", func(s *mcclient.ClientSession, args *ResourceDiffOptions) error {
+		mod, err := getModule(s, args.MODULE)
+		if err != nil {
+			return err
+		}
+		params := jsonutils.NewDict()
+		params.Add(jsonutils.NewString(args.SINCE), "since")
+		if len(args.Until) > 0 {
+			params.Add(jsonutils.NewString(args.Until), "until")
+		}
+		result, err := mod.GetSpecific(s, args.ID, "diff", params)
+		if err != nil {
+			return err
+		}
+		if complete, _ := result.Bool("complete"); !complete {
+			fmt.Println("Warning: changes are not fully recorded since start time, the diff may be inaccurate")
+		}
+		if diff, _ := result.Get("diff"); diff != nil {
+			printFieldDiffs(diff)
+		}
+		if args.Changes {
+			changes, _ := result.GetArray("changes")
+			for _, change := range changes {
+				opsTime, _ := change.GetString("ops_time")
+				action, _ := change.GetString("action")
+				fmt.Printf("%s %s\n", opsTime, action)
+				if diff, _ := change.Get("diff"); diff != nil {
+					printFieldDiffs(diff)
+				}
+			}
+		}
+		return nil
+	})
+}
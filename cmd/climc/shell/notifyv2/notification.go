@@ -31,6 +31,7 @@ func init() {
 		TOPIC       string   `help:"Topic"`
 		Priority    string   `help:"Priority"`
 		MESSAGE     string   `help:"Message"`
+		DedupKey    string   `help:"Dedup key, same notifications are sent to a receiver only once within dedup window"`
 		Oldsdk      bool     `help:"Old sdk"`
 	}
 	R(&NotificationCreateInput{}, "notify-send", "Send a notify message", func(s *mcclient.ClientSession, args *NotificationCreateInput) error {
@@ -59,6 +60,7 @@ func init() {
 				Topic:       args.TOPIC,
 				Priority:    args.Priority,
 				Message:     args.MESSAGE,
+				DedupKey:    args.DedupKey,
 			}
 			ret, err = modules.Notification.Create(s, jsonutils.Marshal(input))
 			if err != nil {
@@ -79,6 +81,14 @@ func init() {
 		printObject(ret)
 		return nil
 	})
+	R(&NotificationInput{}, "notify-ack", "Acknowledge a notify message to stop escalation", func(s *mcclient.ClientSession, args *NotificationInput) error {
+		ret, err := modules.Notification.PerformAction(s, args.ID, "ack", nil)
+		if err != nil {
+			return err
+		}
+		printObject(ret)
+		return nil
+	})
 	type NotificationListInput struct {
 		options.BaseListOptions
 
@@ -0,0 +1,51 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifyv2
+
+import (
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	options "yunion.io/x/onecloud/pkg/mcclient/options/notify"
+)
+
+func init() {
+	cmd := shell.NewResourceCmd(&modules.NotifyTopic).WithKeyword("notify-topic")
+	cmd.List(new(options.TopicListOptions))
+	cmd.Create(new(options.TopicCreateOptions))
+	cmd.Update(new(options.TopicUpdateOptions))
+	cmd.Show(new(options.TopicOptions))
+	cmd.Delete(new(options.TopicOptions))
+	cmd.Perform("enable", new(options.TopicOptions))
+	cmd.Perform("disable", new(options.TopicOptions))
+	cmd.PerformClass("publish", new(options.TopicPublishOptions))
+
+	cmd = shell.NewResourceCmd(&modules.NotifySubscription).WithKeyword("notify-subscription")
+	cmd.List(new(options.SubscriptionListOptions))
+	cmd.Create(new(options.SubscriptionCreateOptions))
+	cmd.Update(new(options.SubscriptionUpdateOptions))
+	cmd.Show(new(options.SubscriptionOptions))
+	cmd.Delete(new(options.SubscriptionOptions))
+	cmd.Perform("enable", new(options.SubscriptionOptions))
+	cmd.Perform("disable", new(options.SubscriptionOptions))
+
+	cmd = shell.NewResourceCmd(&modules.NotifyEscalation).WithKeyword("notify-escalation")
+	cmd.List(new(options.EscalationListOptions))
+	cmd.Create(new(options.EscalationCreateOptions))
+	cmd.Update(new(options.EscalationUpdateOptions))
+	cmd.Show(new(options.EscalationOptions))
+	cmd.Delete(new(options.EscalationOptions))
+	cmd.Perform("enable", new(options.EscalationOptions))
+	cmd.Perform("disable", new(options.EscalationOptions))
+}
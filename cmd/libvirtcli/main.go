@@ -0,0 +1,78 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"yunion.io/x/onecloud/pkg/multicloud/libvirt"
+	_ "yunion.io/x/onecloud/pkg/multicloud/libvirt/shell"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+type BaseOptions struct {
+	Debug      bool   `help:"debug mode"`
+	Help       bool   `help:"Show help"`
+	Uris       string `help:"Comma separated libvirt uris, e.g. qemu+ssh://10.0.0.1/system" default:"$LIBVIRT_URIS" metavar:"LIBVIRT_URIS"`
+	Username   string `help:"Ssh username" default:"$LIBVIRT_USERNAME" metavar:"LIBVIRT_USERNAME"`
+	Password   string `help:"Ssh password or private key" default:"$LIBVIRT_PASSWORD" metavar:"LIBVIRT_PASSWORD"`
+	RegionID   string `help:"RegionId" default:"$LIBVIRT_REGION_ID" metavar:"LIBVIRT_REGION_ID"`
+	SUBCOMMAND string `help:"libvirtcli subcommand" subcommand:"true"`
+}
+
+func (options *BaseOptions) IsHelp() bool {
+	return options.Help
+}
+
+func (options *BaseOptions) GetSubcommand() string {
+	return options.SUBCOMMAND
+}
+
+func newClient(options *BaseOptions) (*libvirt.SRegion, error) {
+	if len(options.Uris) == 0 {
+		return nil, fmt.Errorf("Missing Uris")
+	}
+
+	cli, err := libvirt.NewLibvirtClient(
+		libvirt.NewLibvirtClientConfig(
+			options.Uris,
+			options.Username,
+			options.Password,
+		).Debug(options.Debug),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.RegionID) == 0 {
+		options.RegionID = libvirt.LIBVIRT_DEFAULT_REGION
+	}
+	region := cli.GetRegion(options.RegionID)
+	if region == nil {
+		return nil, fmt.Errorf("No such region %s", options.RegionID)
+	}
+	return region, nil
+}
+
+func main() {
+	options := &BaseOptions{}
+	parser, e := shellutils.NewSubcommandParser(options, "libvirtcli", "Command-line interface to libvirt hosts.")
+	if e != nil {
+		shellutils.ShowErrorAndExit(e)
+	}
+	shellutils.ParseAndRun(parser, os.Args[1:], func() (interface{}, error) {
+		return newClient(options)
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+	_ "yunion.io/x/onecloud/pkg/multicloud/ovirt/shell"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+type BaseOptions struct {
+	Debug      bool   `help:"debug mode"`
+	Help       bool   `help:"Show help"`
+	AuthURL    string `help:"Auth URL" default:"$OVIRT_AUTH_URL" metavar:"OVIRT_AUTH_URL"`
+	Username   string `help:"Username, e.g. admin@internal" default:"$OVIRT_USERNAME" metavar:"OVIRT_USERNAME"`
+	Password   string `help:"Password" default:"$OVIRT_PASSWORD" metavar:"OVIRT_PASSWORD"`
+	RegionID   string `help:"RegionId" default:"$OVIRT_REGION_ID" metavar:"OVIRT_REGION_ID"`
+	SUBCOMMAND string `help:"ovirtcli subcommand" subcommand:"true"`
+}
+
+func (options *BaseOptions) IsHelp() bool {
+	return options.Help
+}
+
+func (options *BaseOptions) GetSubcommand() string {
+	return options.SUBCOMMAND
+}
+
+func newClient(options *BaseOptions) (*ovirt.SRegion, error) {
+	if len(options.AuthURL) == 0 {
+		return nil, fmt.Errorf("Missing AuthURL")
+	}
+
+	if len(options.Username) == 0 {
+		return nil, fmt.Errorf("Missing Username")
+	}
+
+	if len(options.Password) == 0 {
+		return nil, fmt.Errorf("Missing Password")
+	}
+
+	cli, err := ovirt.NewOVirtClient(
+		ovirt.NewOVirtClientConfig(
+			options.AuthURL,
+			options.Username,
+			options.Password,
+		).Debug(options.Debug),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.RegionID) == 0 {
+		options.RegionID = ovirt.OVIRT_DEFAULT_REGION
+	}
+	region := cli.GetRegion(options.RegionID)
+	if region == nil {
+		return nil, fmt.Errorf("No such region %s", options.RegionID)
+	}
+	return region, nil
+}
+
+func main() {
+	options := &BaseOptions{}
+	parser, e := shellutils.NewSubcommandParser(options, "ovirtcli", "Command-line interface to oVirt REST API.")
+	if e != nil {
+		shellutils.ShowErrorAndExit(e)
+	}
+	shellutils.ParseAndRun(parser, os.Args[1:], func() (interface{}, error) {
+		return newClient(options)
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	_ "yunion.io/x/onecloud/pkg/multicloud/proxmox/shell"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+type BaseOptions struct {
+	Debug      bool   `help:"debug mode"`
+	Help       bool   `help:"Show help"`
+	AuthURL    string `help:"Auth URL" default:"$PROXMOX_AUTH_URL" metavar:"PROXMOX_AUTH_URL"`
+	Username   string `help:"Username, e.g. root@pam or root@pam!tokenid" default:"$PROXMOX_USERNAME" metavar:"PROXMOX_USERNAME"`
+	Password   string `help:"Password or API token secret" default:"$PROXMOX_PASSWORD" metavar:"PROXMOX_PASSWORD"`
+	RegionID   string `help:"RegionId" default:"$PROXMOX_REGION_ID" metavar:"PROXMOX_REGION_ID"`
+	SUBCOMMAND string `help:"proxmoxcli subcommand" subcommand:"true"`
+}
+
+func (options *BaseOptions) IsHelp() bool {
+	return options.Help
+}
+
+func (options *BaseOptions) GetSubcommand() string {
+	return options.SUBCOMMAND
+}
+
+func newClient(options *BaseOptions) (*proxmox.SRegion, error) {
+	if len(options.AuthURL) == 0 {
+		return nil, fmt.Errorf("Missing AuthURL")
+	}
+
+	if len(options.Username) == 0 {
+		return nil, fmt.Errorf("Missing Username")
+	}
+
+	if len(options.Password) == 0 {
+		return nil, fmt.Errorf("Missing Password")
+	}
+
+	cli, err := proxmox.NewProxmoxClient(
+		proxmox.NewProxmoxClientConfig(
+			options.AuthURL,
+			options.Username,
+			options.Password,
+		).Debug(options.Debug),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.RegionID) == 0 {
+		options.RegionID = proxmox.PROXMOX_DEFAULT_REGION
+	}
+	region := cli.GetRegion(options.RegionID)
+	if region == nil {
+		return nil, fmt.Errorf("No such region %s", options.RegionID)
+	}
+	return region, nil
+}
+
+func main() {
+	options := &BaseOptions{}
+	parser, e := shellutils.NewSubcommandParser(options, "proxmoxcli", "Command-line interface to Proxmox VE API.")
+	if e != nil {
+		shellutils.ShowErrorAndExit(e)
+	}
+	shellutils.ParseAndRun(parser, os.Args[1:], func() (interface{}, error) {
+		return newClient(options)
+	})
+}
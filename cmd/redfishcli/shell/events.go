@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+
+	type EventSubscriptionListOptions struct {
+	}
+	shellutils.R(&EventSubscriptionListOptions{}, "event-subscription-list", "List event subscriptions", func(cli redfish.IRedfishDriver, args *EventSubscriptionListOptions) error {
+		subs, err := cli.GetEventSubscriptions(context.Background())
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(subs, 0, 0, 0, nil)
+		return nil
+	})
+
+	type EventSubscriptionCreateOptions struct {
+		DESTINATION string   `help:"URL where events are pushed to"`
+		EventType   []string `help:"event types to subscribe, default all supported types"`
+		Context     string   `help:"context string carried in every event"`
+	}
+	shellutils.R(&EventSubscriptionCreateOptions{}, "event-subscription-create", "Create event subscription", func(cli redfish.IRedfishDriver, args *EventSubscriptionCreateOptions) error {
+		sub, err := cli.CreateEventSubscription(context.Background(), args.DESTINATION, args.EventType, args.Context)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonutils.Marshal(sub).PrettyString())
+		return nil
+	})
+
+	type EventSubscriptionDeleteOptions struct {
+		PATH string `help:"path of event subscription"`
+	}
+	shellutils.R(&EventSubscriptionDeleteOptions{}, "event-subscription-delete", "Delete event subscription", func(cli redfish.IRedfishDriver, args *EventSubscriptionDeleteOptions) error {
+		err := cli.DeleteEventSubscription(context.Background(), args.PATH)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Success!")
+		return nil
+	})
+
+}
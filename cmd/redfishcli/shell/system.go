@@ -17,6 +17,7 @@ package shell
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"yunion.io/x/jsonutils"
 
@@ -47,6 +48,30 @@ func init() {
 		return nil
 	})
 
+	type BiosSetOptions struct {
+		ATTRS []string `help:"BIOS attributes to set, in format of key=value, value is parsed as JSON if possible"`
+	}
+	shellutils.R(&BiosSetOptions{}, "bios-set", "Set BIOS attributes, effective on next reset", func(cli redfish.IRedfishDriver, args *BiosSetOptions) error {
+		attrs := jsonutils.NewDict()
+		for _, kv := range args.ATTRS {
+			pos := strings.Index(kv, "=")
+			if pos <= 0 {
+				return fmt.Errorf("invalid attribute %s", kv)
+			}
+			val, err := jsonutils.ParseString(kv[pos+1:])
+			if err != nil {
+				val = jsonutils.NewString(kv[pos+1:])
+			}
+			attrs.Set(kv[:pos], val)
+		}
+		err := cli.SetBiosAttributes(context.Background(), attrs)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Success!")
+		return nil
+	})
+
 	type SetNextBootOptions struct {
 		DEV string `help:"next boot device"`
 	}
@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+
+	type UpdateServiceGetOptions struct {
+	}
+	shellutils.R(&UpdateServiceGetOptions{}, "update-service-get", "Get details of update service", func(cli redfish.IRedfishDriver, args *UpdateServiceGetOptions) error {
+		info, err := cli.GetUpdateServiceInfo(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonutils.Marshal(info).PrettyString())
+		return nil
+	})
+
+	type FirmwareListOptions struct {
+	}
+	shellutils.R(&FirmwareListOptions{}, "firmware-list", "List firmware inventory", func(cli redfish.IRedfishDriver, args *FirmwareListOptions) error {
+		fws, err := cli.GetFirmwareInventory(context.Background())
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(fws, 0, 0, 0, nil)
+		return nil
+	})
+
+	type FirmwareUpdateOptions struct {
+		URI      string   `help:"URI of firmware image"`
+		Protocol string   `help:"transfer protocol, e.g. HTTP, HTTPS, NFS"`
+		Target   []string `help:"path of firmware inventory item to update"`
+	}
+	shellutils.R(&FirmwareUpdateOptions{}, "firmware-update", "Update firmware with SimpleUpdate action", func(cli redfish.IRedfishDriver, args *FirmwareUpdateOptions) error {
+		err := cli.SimpleUpdate(context.Background(), args.URI, args.Protocol, args.Target)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Success!")
+		return nil
+	})
+
+}
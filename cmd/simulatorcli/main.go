@@ -0,0 +1,136 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"yunion.io/x/structarg"
+
+	"yunion.io/x/onecloud/pkg/multicloud/simulator"
+	_ "yunion.io/x/onecloud/pkg/multicloud/simulator/shell"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+type BaseOptions struct {
+	Debug      bool   `help:"debug mode"`
+	Help       bool   `help:"Show help"`
+	StoreURL   string `help:"Store URL, e.g. file:///tmp/simulator.json" default:"$SIMULATOR_STORE_URL" metavar:"SIMULATOR_STORE_URL"`
+	Account    string `help:"Account" default:"$SIMULATOR_ACCOUNT" metavar:"SIMULATOR_ACCOUNT"`
+	RegionID   string `help:"RegionId" default:"$SIMULATOR_REGION_ID" metavar:"SIMULATOR_REGION_ID"`
+	SUBCOMMAND string `help:"simulatorcli subcommand" subcommand:"true"`
+}
+
+func getSubcommandParser() (*structarg.ArgumentParser, error) {
+	parse, e := structarg.NewArgumentParser(&BaseOptions{},
+		"simulatorcli",
+		"Command-line interface to simulated cloud.",
+		`See "simulatorcli help COMMAND" for help on a specific command.`)
+
+	if e != nil {
+		return nil, e
+	}
+
+	subcmd := parse.GetSubcommand()
+	if subcmd == nil {
+		return nil, fmt.Errorf("No subcommand argument.")
+	}
+	type HelpOptions struct {
+		SUBCOMMAND string `help:"sub-command name"`
+	}
+	shellutils.R(&HelpOptions{}, "help", "Show help of a subcommand", func(args *HelpOptions) error {
+		helpstr, e := subcmd.SubHelpString(args.SUBCOMMAND)
+		if e != nil {
+			return e
+		} else {
+			fmt.Print(helpstr)
+			return nil
+		}
+	})
+	for _, v := range shellutils.CommandTable {
+		_, e := subcmd.AddSubParser(v.Options, v.Command, v.Desc, v.Callback)
+		if e != nil {
+			return nil, e
+		}
+	}
+	return parse, nil
+}
+
+func showErrorAndExit(e error) {
+	fmt.Fprintf(os.Stderr, "%s", e)
+	fmt.Fprintln(os.Stderr)
+	os.Exit(1)
+}
+
+func newClient(options *BaseOptions) (*simulator.SRegion, error) {
+	if !strings.HasPrefix(options.StoreURL, "file://") {
+		return nil, fmt.Errorf("StoreURL must be file:// to share state across processes")
+	}
+
+	cli, err := simulator.NewSimulatorClient(
+		simulator.NewSimulatorClientConfig(
+			options.StoreURL,
+			options.Account,
+		).Debug(options.Debug),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cli.GetRegion(options.RegionID)
+}
+
+func main() {
+	parser, e := getSubcommandParser()
+	if e != nil {
+		showErrorAndExit(e)
+	}
+	e = parser.ParseArgs(os.Args[1:], false)
+	options := parser.Options().(*BaseOptions)
+
+	if options.Help {
+		fmt.Print(parser.HelpString())
+	} else {
+		subcmd := parser.GetSubcommand()
+		subparser := subcmd.GetSubParser()
+		if e != nil {
+			if subparser != nil {
+				fmt.Print(subparser.Usage())
+			} else {
+				fmt.Print(parser.Usage())
+			}
+			showErrorAndExit(e)
+		} else {
+			suboptions := subparser.Options()
+			if options.SUBCOMMAND == "help" {
+				e = subcmd.Invoke(suboptions)
+			} else {
+				var region *simulator.SRegion
+				if len(options.RegionID) == 0 {
+					options.RegionID = simulator.SIMULATOR_DEFAULT_REGION
+				}
+				region, e = newClient(options)
+				if e != nil {
+					showErrorAndExit(e)
+				}
+				e = subcmd.Invoke(region, suboptions)
+			}
+			if e != nil {
+				showErrorAndExit(e)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	WEBHOOK_STATUS_READY = "ready"
+	WEBHOOK_STATUS_ERROR = "error"
+
+	ACTION_CREATE = "create"
+	ACTION_UPDATE = "update"
+	ACTION_DELETE = "delete"
+
+	DELIVERY_STATUS_PENDING   = "pending"
+	DELIVERY_STATUS_RETRYING  = "retrying"
+	DELIVERY_STATUS_SUCCEEDED = "succeeded"
+	DELIVERY_STATUS_DEAD      = "dead"
+
+	DEFAULT_MAX_ATTEMPTS = 8
+	MAX_MAX_ATTEMPTS     = 20
+
+	CLOUDEVENTS_SPEC_VERSION = "1.0"
+	CLOUDEVENTS_CONTENT_TYPE = "application/cloudevents+json; charset=utf-8"
+	CLOUDEVENTS_TYPE_PREFIX  = "io.yunion.onecloud"
+
+	// unix timestamp when the request is signed
+	HEADER_TIMESTAMP = "X-Onecloud-Webhook-Timestamp"
+	// sha256=hex(hmac_sha256(secret, timestamp + "." + body))
+	HEADER_SIGNATURE = "X-Onecloud-Webhook-Signature"
+	HEADER_DELIVERY  = "X-Onecloud-Webhook-Delivery"
+)
+
+var (
+	ACTIONS = []string{ACTION_CREATE, ACTION_UPDATE, ACTION_DELETE}
+)
+
+type WebhookCreateInput struct {
+	apis.EnabledStatusStandaloneResourceCreateInput
+
+	// http or https url receiving the events
+	Url string `json:"url"`
+	// secret to sign the events with HMAC-SHA256, no signature if empty
+	Secret string `json:"secret"`
+
+	// keyword plural of resources to subscribe, e.g. servers, all if empty
+	ResourceTypes []string `json:"resource_types"`
+	// actions to subscribe, all if empty
+	// enum: create, update, delete
+	Actions []string `json:"actions"`
+	// only deliver events of resources in this project, id or name
+	ProjectId string `json:"project_id"`
+
+	// attempts before a delivery turns dead
+	MaxAttempts int `json:"max_attempts"`
+}
+
+type WebhookUpdateInput struct {
+	apis.EnabledStatusStandaloneResourceBaseUpdateInput
+
+	Url           string   `json:"url"`
+	Secret        *string  `json:"secret"`
+	ResourceTypes []string `json:"resource_types"`
+	Actions       []string `json:"actions"`
+	ProjectId     *string  `json:"project_id"`
+	MaxAttempts   *int     `json:"max_attempts"`
+}
+
+type WebhookListInput struct {
+	apis.EnabledStatusStandaloneResourceListInput
+
+	// filter by subscribed resource type
+	ResourceType string `json:"resource_type"`
+	ProjectId    string `json:"project_id"`
+}
+
+type WebhookDetails struct {
+	apis.EnabledStatusStandaloneResourceDetails
+
+	Project string `json:"project"`
+	// deliveries waiting to be sent
+	PendingCount int `json:"pending_count"`
+	// deliveries exceeded max attempts
+	DeadCount int `json:"dead_count"`
+}
+
+type WebhookDeliveryListInput struct {
+	apis.StandaloneAnonResourceListInput
+
+	// id or name of webhook
+	WebhookId string   `json:"webhook_id"`
+	Status    []string `json:"status"`
+
+	ResourceType string `json:"resource_type"`
+	EventId      string `json:"event_id"`
+	// id of resource
+	Subject string `json:"subject"`
+}
+
+type WebhookDeliveryDetails struct {
+	apis.StandaloneAnonResourceDetails
+
+	Webhook string `json:"webhook"`
+}
+
+// CloudEvent is a CloudEvents 1.0 event in structured content mode
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	Id              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+
+	Data *ResourceEventData `json:"data"`
+}
+
+type ResourceEventData struct {
+	ResourceType string `json:"resource_type"`
+	Action       string `json:"action"`
+	// state of resource after change
+	Object json.RawMessage `json:"object"`
+	// state of resource before update
+	OldObject json.RawMessage `json:"old_object,omitempty"`
+}
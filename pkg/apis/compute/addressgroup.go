@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"net"
+	"reflect"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+const (
+	ADDRESS_GROUP_STATUS_AVAILABLE = "available"
+
+	// 单个地址组最多包含的地址条目
+	ADDRESS_GROUP_MAX_ENTRIES = 1000
+	// 被安全组规则引用的地址组最多包含的地址条目,
+	// 除VPC(OVN地址集合)外, 宿主机与公有云下发时规则按条目逐条展开, 需限制展开后的规则数
+	ADDRESS_GROUP_MAX_RULE_ENTRIES = 64
+)
+
+// SAddressList 地址组中的IP或CIDR列表
+type SAddressList []string
+
+func (l SAddressList) String() string {
+	return jsonutils.Marshal(l).String()
+}
+
+func (l SAddressList) IsZero() bool {
+	return len(l) == 0
+}
+
+func (l SAddressList) Contains(addr string) bool {
+	for i := range l {
+		if l[i] == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 规范化地址列表, 单个IP转换为/32或/128的CIDR, 并检查地址族与重复项
+func (l *SAddressList) Validate(v6 bool) error {
+	found := map[string]struct{}{}
+	ret := SAddressList{}
+	for _, addr := range *l {
+		addr = strings.TrimSpace(addr)
+		if len(addr) == 0 {
+			continue
+		}
+		var ipNet *net.IPNet
+		if strings.Index(addr, "/") > 0 {
+			_, n, err := net.ParseCIDR(addr)
+			if err != nil {
+				return errors.Wrapf(httperrors.ErrInputParameter, "invalid cidr %s", addr)
+			}
+			ipNet = n
+		} else {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return errors.Wrapf(httperrors.ErrInputParameter, "invalid addr %s", addr)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		if is6 := ipNet.IP.To4() == nil; is6 != v6 {
+			if v6 {
+				return errors.Wrapf(httperrors.ErrInputParameter, "%s is not an ipv6 address", addr)
+			}
+			return errors.Wrapf(httperrors.ErrInputParameter, "%s is not an ipv4 address", addr)
+		}
+		// normalize from 192.168.1.3/24 to 192.168.1.0/24
+		addr = ipNet.String()
+		if _, ok := found[addr]; ok {
+			return httperrors.NewInputParameterError("duplicate address %s", addr)
+		}
+		found[addr] = struct{}{}
+		ret = append(ret, addr)
+	}
+	if len(ret) > ADDRESS_GROUP_MAX_ENTRIES {
+		return httperrors.NewInputParameterError("too many addresses (%d>%d)", len(ret), ADDRESS_GROUP_MAX_ENTRIES)
+	}
+	*l = ret
+	return nil
+}
+
+type AddressGroupCreateInput struct {
+	apis.SharableVirtualResourceCreateInput
+
+	// IPv4地址或CIDR列表
+	// example: ["10.0.0.0/8", "192.168.1.10"]
+	IpList *SAddressList `json:"ip_list"`
+
+	// IPv6地址或CIDR列表
+	// required: false
+	Ip6List *SAddressList `json:"ip6_list"`
+}
+
+type AddressGroupUpdateInput struct {
+	apis.SharableVirtualResourceBaseUpdateInput
+
+	// IPv4地址或CIDR列表, 整体替换
+	IpList *SAddressList `json:"ip_list"`
+
+	// IPv6地址或CIDR列表, 整体替换
+	Ip6List *SAddressList `json:"ip6_list"`
+}
+
+type AddressGroupListInput struct {
+	apis.SharableVirtualResourceListInput
+
+	// 过滤包含指定地址的地址组
+	Ip string `json:"ip"`
+}
+
+type AddressGroupDetails struct {
+	apis.SharableVirtualResourceDetails
+	SAddressGroup
+
+	// 引用此地址组的安全组规则数量
+	RuleCnt int `json:"rule_cnt,allowempty"`
+}
+
+type AddressGroupResourceInfo struct {
+	// 地址组名称
+	AddressGroup string `json:"address_group"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SAddressList{}), func() gotypes.ISerializable {
+		return &SAddressList{}
+	})
+}
@@ -55,9 +55,8 @@ type NetworkConfig struct {
 	// required: false
 	Address string `json:"address"`
 
-	// 子网内的IPv6地址
+	// 子网内的IPv6地址, 仅子网配置了IPv6地址段时有效, 若不指定会按分配策略自动分配
 	// required: false
-	// swagger:ignore
 	Address6 string `json:"address6"`
 
 	// 驱动方式
@@ -324,6 +323,10 @@ type ServerConfigs struct {
 	// 主机组列表, 参数可以是主机组名称或ID,建议使用ID
 	InstanceGroupIds []string `json:"groups"`
 
+	// NUMA绑定策略, 仅对KVM生效, strict表示限定在单个NUMA节点内并独占绑定vCPU
+	// enum: strict
+	NumaPolicy string `json:"numa_policy"`
+
 	// DEPRECATE
 	Suggestion bool `json:"suggestion"`
 }
@@ -49,6 +49,10 @@ const (
 	CLOUD_PROVIDER_ZSTACK    = "ZStack"
 	CLOUD_PROVIDER_GOOGLE    = "Google"
 	CLOUD_PROVIDER_CTYUN     = "Ctyun"
+	CLOUD_PROVIDER_PROXMOX   = "Proxmox"
+	CLOUD_PROVIDER_OVIRT     = "oVirt"
+	CLOUD_PROVIDER_SIMULATOR = "Simulator"
+	CLOUD_PROVIDER_LIBVIRT   = "Libvirt"
 
 	CLOUD_PROVIDER_GENERICS3 = "S3"
 	CLOUD_PROVIDER_CEPH      = "Ceph"
@@ -92,7 +96,7 @@ const (
 var (
 	CLOUD_PROVIDER_VALID_STATUS        = []string{CLOUD_PROVIDER_CONNECTED}
 	CLOUD_PROVIDER_VALID_HEALTH_STATUS = []string{CLOUD_PROVIDER_HEALTH_NORMAL, CLOUD_PROVIDER_HEALTH_NO_PERMISSION}
-	PRIVATE_CLOUD_PROVIDERS            = []string{CLOUD_PROVIDER_ZSTACK, CLOUD_PROVIDER_OPENSTACK, CLOUD_PROVIDER_APSARA}
+	PRIVATE_CLOUD_PROVIDERS            = []string{CLOUD_PROVIDER_ZSTACK, CLOUD_PROVIDER_OPENSTACK, CLOUD_PROVIDER_APSARA, CLOUD_PROVIDER_PROXMOX, CLOUD_PROVIDER_OVIRT, CLOUD_PROVIDER_LIBVIRT}
 
 	CLOUD_PROVIDERS = []string{
 		CLOUD_PROVIDER_ONECLOUD,
@@ -108,6 +112,9 @@ var (
 		CLOUD_PROVIDER_ZSTACK,
 		CLOUD_PROVIDER_GOOGLE,
 		CLOUD_PROVIDER_CTYUN,
+		CLOUD_PROVIDER_PROXMOX,
+		CLOUD_PROVIDER_OVIRT,
+		CLOUD_PROVIDER_LIBVIRT,
 	}
 )
 
@@ -0,0 +1,135 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	FLOW_LOG_STATUS_AVAILABLE = "available"
+
+	FLOW_LOG_RESOURCE_VPC     = "vpc"
+	FLOW_LOG_RESOURCE_NETWORK = "network"
+	FLOW_LOG_RESOURCE_SERVER  = "server"
+
+	FLOW_LOG_TRAFFIC_ACCEPT = "accept"
+	FLOW_LOG_TRAFFIC_REJECT = "reject"
+	FLOW_LOG_TRAFFIC_ALL    = "all"
+
+	FLOW_LOG_SINK_FILE   = "file"
+	FLOW_LOG_SINK_BUCKET = "bucket"
+	FLOW_LOG_SINK_LOGGER = "logger"
+
+	// 每台宿主机每秒记录的流日志条数上限
+	FLOW_LOG_DEFAULT_RATE_LIMIT = 100
+	FLOW_LOG_MAX_RATE_LIMIT     = 10000
+
+	FLOW_LOG_VERDICT_ALLOW = "allow"
+	FLOW_LOG_VERDICT_DROP  = "drop"
+)
+
+var (
+	FLOW_LOG_RESOURCE_TYPES = []string{FLOW_LOG_RESOURCE_VPC, FLOW_LOG_RESOURCE_NETWORK, FLOW_LOG_RESOURCE_SERVER}
+	FLOW_LOG_TRAFFIC_TYPES  = []string{FLOW_LOG_TRAFFIC_ACCEPT, FLOW_LOG_TRAFFIC_REJECT, FLOW_LOG_TRAFFIC_ALL}
+	FLOW_LOG_SINK_TYPES     = []string{FLOW_LOG_SINK_FILE, FLOW_LOG_SINK_BUCKET, FLOW_LOG_SINK_LOGGER}
+)
+
+type FlowLogCreateInput struct {
+	apis.VirtualResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// 采集对象类型
+	// enum: vpc, network, server
+	// required: true
+	ResourceType string `json:"resource_type"`
+	// 采集对象(ID或Name)
+	// required: true
+	Resource string `json:"resource"`
+	// swagger:ignore
+	ResourceId string `json:"resource_id"`
+	// 采集对象为server时, 可指定单个网卡, 为空表示全部网卡
+	Ifname string `json:"ifname"`
+
+	// 采集的流量类型
+	// enum: accept, reject, all
+	// default: all
+	TrafficType string `json:"traffic_type"`
+
+	// 流日志投递目标
+	// enum: file, bucket, logger
+	// default: file
+	SinkType string `json:"sink_type"`
+	// sink_type为bucket时指定存储桶(ID或Name)
+	Bucket string `json:"bucket"`
+	// swagger:ignore
+	BucketId string `json:"bucket_id"`
+	// sink_type为file时为宿主机上的文件路径, 为bucket时为对象前缀
+	SinkPath string `json:"sink_path"`
+
+	// 每台宿主机每秒最多记录条数
+	// default: 100
+	RateLimit int `json:"rate_limit"`
+}
+
+type FlowLogUpdateInput struct {
+	apis.VirtualResourceBaseUpdateInput
+
+	TrafficType string `json:"traffic_type"`
+	SinkPath    string `json:"sink_path"`
+	RateLimit   *int   `json:"rate_limit"`
+}
+
+type FlowLogListInput struct {
+	apis.VirtualResourceListInput
+	apis.EnabledResourceBaseListInput
+
+	ResourceType string `json:"resource_type"`
+	ResourceId   string `json:"resource_id"`
+	TrafficType  string `json:"traffic_type"`
+	SinkType     string `json:"sink_type"`
+}
+
+type FlowLogDetails struct {
+	apis.VirtualResourceDetails
+	SFlowLog
+
+	// 采集对象名称
+	Resource string `json:"resource"`
+	// 存储桶名称
+	Bucket string `json:"bucket"`
+}
+
+// FlowLogRecord 宿主机采集端由ovn-controller acl_log解析得到的单条流记录
+type FlowLogRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	FlowLogId string    `json:"flow_log_id"`
+	HostId    string    `json:"host_id"`
+	Verdict   string    `json:"verdict"`
+	Severity  string    `json:"severity"`
+	Direction string    `json:"direction"`
+	Protocol  string    `json:"protocol"`
+	SrcMac    string    `json:"src_mac"`
+	DstMac    string    `json:"dst_mac"`
+	SrcIp     string    `json:"src_ip"`
+	DstIp     string    `json:"dst_ip"`
+	SrcPort   int       `json:"src_port,omitempty"`
+	DstPort   int       `json:"dst_port,omitempty"`
+	IcmpType  int       `json:"icmp_type,omitempty"`
+	IcmpCode  int       `json:"icmp_code,omitempty"`
+	TcpFlags  string    `json:"tcp_flags,omitempty"`
+}
@@ -164,6 +164,10 @@ const (
 	HYPERVISOR_ZSTACK    = "zstack"
 	HYPERVISOR_GOOGLE    = "google"
 	HYPERVISOR_CTYUN     = "ctyun"
+	HYPERVISOR_PROXMOX   = "proxmox"
+	HYPERVISOR_OVIRT     = "ovirt"
+	HYPERVISOR_SIMULATOR = "simulator"
+	HYPERVISOR_LIBVIRT   = "libvirt"
 
 	//	HYPERVISOR_DEFAULT = HYPERVISOR_KVM
 	HYPERVISOR_DEFAULT = HYPERVISOR_KVM
@@ -198,6 +202,9 @@ var HYPERVISORS = []string{
 	HYPERVISOR_ZSTACK,
 	HYPERVISOR_GOOGLE,
 	HYPERVISOR_CTYUN,
+	HYPERVISOR_PROXMOX,
+	HYPERVISOR_OVIRT,
+	HYPERVISOR_LIBVIRT,
 }
 
 var ONECLOUD_HYPERVISORS = []string{
@@ -221,6 +228,9 @@ var PRIVATE_CLOUD_HYPERVISORS = []string{
 	HYPERVISOR_ZSTACK,
 	HYPERVISOR_OPENSTACK,
 	HYPERVISOR_APSARA,
+	HYPERVISOR_PROXMOX,
+	HYPERVISOR_OVIRT,
+	HYPERVISOR_LIBVIRT,
 }
 
 // var HYPERVISORS = []string{HYPERVISOR_ALIYUN}
@@ -241,6 +251,9 @@ var HYPERVISOR_HOSTTYPE = map[string]string{
 	HYPERVISOR_ZSTACK:    HOST_TYPE_ZSTACK,
 	HYPERVISOR_GOOGLE:    HOST_TYPE_GOOGLE,
 	HYPERVISOR_CTYUN:     HOST_TYPE_CTYUN,
+	HYPERVISOR_PROXMOX:   HOST_TYPE_PROXMOX,
+	HYPERVISOR_OVIRT:     HOST_TYPE_OVIRT,
+	HYPERVISOR_LIBVIRT:   HOST_TYPE_LIBVIRT,
 }
 
 var HOSTTYPE_HYPERVISOR = map[string]string{
@@ -259,6 +272,9 @@ var HOSTTYPE_HYPERVISOR = map[string]string{
 	HOST_TYPE_ZSTACK:     HYPERVISOR_ZSTACK,
 	HOST_TYPE_GOOGLE:     HYPERVISOR_GOOGLE,
 	HOST_TYPE_CTYUN:      HYPERVISOR_CTYUN,
+	HOST_TYPE_PROXMOX:    HYPERVISOR_PROXMOX,
+	HOST_TYPE_OVIRT:      HYPERVISOR_OVIRT,
+	HOST_TYPE_LIBVIRT:    HYPERVISOR_LIBVIRT,
 }
 
 const (
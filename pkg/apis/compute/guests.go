@@ -176,12 +176,16 @@ type ServerDetails struct {
 	VirtualIps string `json:"virtual_ips"`
 	// 安全组规则
 	SecurityRules string `json:"security_rules"`
+	// IPv6安全组规则
+	SecurityRules6 string `json:"security_rules6"`
 	// 操作系统名称
 	OsName string `json:"os_name"`
 	// 操作系统类型
 	OsType string `json:"os_type"`
 	// 系统管理员可见的安全组规则
 	AdminSecurityRules string `json:"admin_security_rules"`
+	// 系统管理员可见的IPv6安全组规则
+	AdminSecurityRules6 string `json:"admin_security_rules6"`
 
 	// list
 	AttachTime time.Time `json:"attach_time"`
@@ -184,6 +184,8 @@ type HostDetails struct {
 	// 允许开启宿主机健康检查
 	AllowHealthCheck      bool `json:"allow_health_check"`
 	AutoMigrateOnHostDown bool `json:"auto_migrate_on_host_down"`
+	// 宿主机失联后迁移虚拟机前的隔离策略
+	FencePolicyOnHostDown string `json:"fence_policy_on_host_down"`
 
 	// reserved resource for isolated device
 	ReservedResourceForGpu IsolatedDeviceReservedResourceInput `json:"reserved_resource_for_gpu"`
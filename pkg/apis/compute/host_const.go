@@ -34,6 +34,10 @@ const (
 	HOST_TYPE_ZSTACK    = "zstack"
 	HOST_TYPE_GOOGLE    = "google"
 	HOST_TYPE_CTYUN     = "ctyun"
+	HOST_TYPE_PROXMOX   = "proxmox"
+	HOST_TYPE_OVIRT     = "ovirt"
+	HOST_TYPE_SIMULATOR = "simulator"
+	HOST_TYPE_LIBVIRT   = "libvirt"
 
 	HOST_TYPE_DEFAULT = HOST_TYPE_HYPERVISOR
 
@@ -113,6 +117,9 @@ var HOST_TYPES = []string{
 	HOST_TYPE_ZSTACK,
 	HOST_TYPE_CTYUN,
 	HOST_TYPE_GOOGLE,
+	HOST_TYPE_PROXMOX,
+	HOST_TYPE_OVIRT,
+	HOST_TYPE_LIBVIRT,
 }
 
 var NIC_TYPES = []string{NIC_TYPE_IPMI, NIC_TYPE_ADMIN}
@@ -132,6 +139,18 @@ const (
 	HOST_HEALTH_LOCK_PREFIX    = "host-health"
 )
 
+const (
+	// 宿主机失联后不做隔离, 直接迁移虚拟机
+	HOST_FENCE_POLICY_NONE = "none"
+	// 通过BMC(Redfish或IPMI)关闭宿主机电源并确认已关机后再迁移虚拟机
+	HOST_FENCE_POLICY_POWER_OFF = "power_off"
+
+	// 存储层隔离(如RBD blocklist)不在支持范围内: region服务不链接librados,
+	// 没有BMC的宿主机只能选择none, 由管理员确认宿主机已停止写存储
+)
+
+var HOST_FENCE_POLICIES = []string{HOST_FENCE_POLICY_NONE, HOST_FENCE_POLICY_POWER_OFF}
+
 const (
 	CPU_ARCH_AARCH64 = "aarch64"
 )
@@ -0,0 +1,81 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+)
+
+const (
+	// 不做 NUMA 绑定, vCPU 在所有物理核心上调度
+	GUEST_NUMA_POLICY_NONE = ""
+	// 虚拟机限定在单个 NUMA 节点内, vCPU 独占绑定物理核心, 内存从本节点分配
+	GUEST_NUMA_POLICY_STRICT = "strict"
+)
+
+var GUEST_NUMA_POLICIES = []string{GUEST_NUMA_POLICY_NONE, GUEST_NUMA_POLICY_STRICT}
+
+type HostNumaNode struct {
+	NodeId int `json:"node_id"`
+	// 节点内的逻辑 CPU 编号
+	Cpus []int `json:"cpus"`
+	// 节点内存大小
+	MemSizeMb int `json:"mem_size_mb"`
+}
+
+// 宿主机 NUMA 拓扑, 由 host agent 上报
+type HostTopology struct {
+	Nodes []HostNumaNode `json:"nodes"`
+}
+
+func (t HostTopology) String() string {
+	return jsonutils.Marshal(t).String()
+}
+
+func (t HostTopology) IsZero() bool {
+	return len(t.Nodes) == 0
+}
+
+// 虚拟机在宿主机上的 NUMA 绑定结果, 由 host agent 分配后上报
+type GuestNumaPin struct {
+	// 分配绑定结果的宿主机, 迁移后目标宿主机重新分配前与虚拟机所在宿主机不一致
+	HostId string `json:"host_id"`
+	NodeId int    `json:"node_id"`
+	// 第 i 个 vCPU 独占的物理 CPU
+	VcpuPin []int `json:"vcpu_pin"`
+	// 模拟器线程可用的物理 CPU
+	EmulatorCpus []int `json:"emulator_cpus"`
+	MemSizeMb    int   `json:"mem_size_mb"`
+}
+
+func (p GuestNumaPin) String() string {
+	return jsonutils.Marshal(p).String()
+}
+
+func (p GuestNumaPin) IsZero() bool {
+	return len(p.VcpuPin) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&HostTopology{}), func() gotypes.ISerializable {
+		return &HostTopology{}
+	})
+	gotypes.RegisterSerializable(reflect.TypeOf(&GuestNumaPin{}), func() gotypes.ISerializable {
+		return &GuestNumaPin{}
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 迁移计划需人工审批后执行
+	REBALANCER_MODE_MANUAL = "manual"
+	// 迁移计划生成后自动执行
+	REBALANCER_MODE_AUTO = "auto"
+
+	REBALANCER_STATUS_READY      = "ready"
+	REBALANCER_STATUS_EVALUATING = "evaluating"
+
+	REBALANCE_MIGRATION_STATUS_PENDING   = "pending_approval"
+	REBALANCE_MIGRATION_STATUS_APPROVED  = "approved"
+	REBALANCE_MIGRATION_STATUS_MIGRATING = "migrating"
+	REBALANCE_MIGRATION_STATUS_DONE      = "done"
+	REBALANCE_MIGRATION_STATUS_FAILED    = "failed"
+	REBALANCE_MIGRATION_STATUS_REJECTED  = "rejected"
+	// 新一轮评估时未执行的旧计划项
+	REBALANCE_MIGRATION_STATUS_EXPIRED = "expired"
+)
+
+var REBALANCER_MODES = []string{REBALANCER_MODE_MANUAL, REBALANCER_MODE_AUTO}
+
+// 尚未结束的迁移计划项状态
+var REBALANCE_MIGRATION_ACTIVE_STATUS = []string{
+	REBALANCE_MIGRATION_STATUS_PENDING,
+	REBALANCE_MIGRATION_STATUS_APPROVED,
+	REBALANCE_MIGRATION_STATUS_MIGRATING,
+}
+
+type RebalancerCreateInput struct {
+	apis.EnabledStatusStandaloneResourceCreateInput
+
+	// description: 均衡范围，可用区ID或名称，与schedtag至少指定一个
+	// example: zone1
+	ZoneId string `json:"zone_id"`
+
+	// description: 均衡范围，宿主机调度标签ID或名称
+	// example: rack-a
+	SchedtagId string `json:"schedtag_id"`
+
+	// description: 执行模式
+	// enum: manual,auto
+	// default: manual
+	Mode string `json:"mode"`
+
+	// description: 宿主机负载高于平均负载的百分点超过此值时触发均衡，范围1-100
+	// default: 15
+	Threshold int `json:"threshold"`
+
+	// description: 是否参考监控数据中的实际利用率，否则只按CPU/内存分配率计算负载
+	// default: true
+	UseUtilization *bool `json:"use_utilization"`
+
+	// description: 同时进行的迁移数量上限
+	// default: 2
+	MaxConcurrency int `json:"max_concurrency"`
+
+	// description: 单次评估生成的迁移数量上限
+	// default: 5
+	MaxMigrations int `json:"max_migrations"`
+
+	// description: 评估周期，单位分钟
+	// default: 30
+	EvaluateInterval int `json:"evaluate_interval"`
+}
+
+type RebalancerUpdateInput struct {
+	apis.EnabledStatusStandaloneResourceBaseUpdateInput
+
+	Mode             string `json:"mode"`
+	Threshold        *int   `json:"threshold"`
+	UseUtilization   *bool  `json:"use_utilization"`
+	MaxConcurrency   *int   `json:"max_concurrency"`
+	MaxMigrations    *int   `json:"max_migrations"`
+	EvaluateInterval *int   `json:"evaluate_interval"`
+}
+
+type RebalancerListInput struct {
+	apis.EnabledStatusStandaloneResourceListInput
+
+	// 以可用区过滤
+	ZoneId string `json:"zone_id"`
+	// 以调度标签过滤
+	SchedtagId string `json:"schedtag_id"`
+	// 以执行模式过滤
+	Mode string `json:"mode"`
+}
+
+type RebalancerDetails struct {
+	apis.EnabledStatusStandaloneResourceDetails
+	SRebalancer
+
+	// 可用区名称
+	Zone string `json:"zone"`
+	// 调度标签名称
+	Schedtag string `json:"schedtag"`
+	// 未结束的迁移计划项数量
+	ActiveMigrationCount int `json:"active_migration_count"`
+}
+
+type RebalanceMigrationListInput struct {
+	apis.StatusStandaloneResourceListInput
+
+	// 以均衡器过滤
+	RebalancerId string `json:"rebalancer_id"`
+	// 以虚拟机过滤
+	GuestId string `json:"guest_id"`
+	// 只列出未结束的计划项
+	Active *bool `json:"active"`
+}
+
+type RebalanceMigrationDetails struct {
+	apis.StatusStandaloneResourceDetails
+	SRebalanceMigration
+
+	Rebalancer string `json:"rebalancer"`
+	Guest      string `json:"guest"`
+	SourceHost string `json:"source_host"`
+	TargetHost string `json:"target_host"`
+}
+
+type RebalanceMigrationRejectInput struct {
+	// 拒绝原因
+	Reason string `json:"reason"`
+}
+
+type RebalancerEvaluateInput struct {
+	// 只计算迁移计划，不记录也不执行
+	DryRun bool `json:"dry_run"`
+}
+
+// RebalancerEvaluateOutput is the migration plan generated by evaluate
+type RebalancerEvaluateOutput struct {
+	AverageLoad float64                     `json:"average_load"`
+	Hosts       []RebalancerHostLoad        `json:"hosts"`
+	Migrations  []RebalanceMigrationDetails `json:"migrations"`
+	EvaluatedAt time.Time                   `json:"evaluated_at"`
+}
+
+type RebalancerHostLoad struct {
+	Id   string  `json:"id"`
+	Name string  `json:"name"`
+	Load float64 `json:"load"`
+	// 计划执行后的负载
+	PlannedLoad float64 `json:"planned_load"`
+}
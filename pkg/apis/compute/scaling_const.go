@@ -29,6 +29,8 @@ const (
 	TRIGGER_TIMING = "timing" // 定时
 	TRIGGER_CYCLE  = "cycle"  // 周期定时
 
+	TRIGGER_TARGET_TRACKING = "target_tracking" // 目标追踪
+
 	ACTION_ADD    = "add"    // 增加
 	ACTION_REMOVE = "remove" // 减少
 	ACTION_SET    = "set"    // 设置
@@ -42,6 +44,7 @@ const (
 	INDICATOR_DISK_WRITE = "disk_write" // 磁盘写速率
 	INDICATOR_FLOW_INTO  = "flow_into"  // 网络入流量
 	INDICATOR_FLOW_OUT   = "flow_out"   // 网络出流量
+	INDICATOR_CUSTOM     = "custom"     // 自定义监控查询
 
 	WRAPPER_MAX  = "max"     // 最大值
 	WRAPPER_MIN  = "min"     //最小值
@@ -61,6 +64,13 @@ const (
 	SG_GUEST_STATUS_REMOVING       = "removing"       // 移除中
 	SG_GUEST_STATUS_REMOVE_FAILED  = "remove_failed"  // 移除失败
 	SG_GUEST_STATUS_PENDING_REMOVE = "pending_remove" // 机器进入回收站
+	SG_GUEST_STATUS_PENDING_WAIT   = "pending:wait"   // 等待生命周期挂钩完成
+
+	LIFECYCLE_TRANSITION_SCALE_OUT = "scale_out" // 扩容
+	LIFECYCLE_TRANSITION_SCALE_IN  = "scale_in"  // 缩容
+
+	LIFECYCLE_RESULT_CONTINUE = "continue" // 继续伸缩
+	LIFECYCLE_RESULT_ABANDON  = "abandon"  // 放弃，扩容时会删除实例
 
 	// 只有ready状态是正常的
 	SG_STATUS_READY              = "ready"              // 正常
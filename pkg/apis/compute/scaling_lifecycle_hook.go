@@ -0,0 +1,101 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+type ScalingLifecycleHookCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// description: scaling_group ID or Name
+	// example: sg-test-one
+	ScalingGroup string `json:"scaling_group"`
+
+	// swagger: ignore
+	ScalingGroupId string `json:"scaling_group_id"`
+
+	// description: 挂钩生效的伸缩动作
+	// enum: scale_out,scale_in
+	// example: scale_out
+	Transition string `json:"transition"`
+
+	// description: 实例进入等待状态后通知的地址，POST JSON
+	// example: https://example.com/hooks/scaling
+	WebhookUrl string `json:"webhook_url"`
+
+	// description: 等待超时时间，单位s，范围30-7200
+	// example: 300
+	Timeout int `json:"timeout"`
+
+	// description: 超时后的默认动作
+	// enum: continue,abandon
+	// example: continue
+	DefaultResult string `json:"default_result"`
+}
+
+type ScalingLifecycleHookUpdateInput struct {
+	apis.VirtualResourceBaseUpdateInput
+
+	WebhookUrl    string `json:"webhook_url"`
+	Timeout       *int   `json:"timeout"`
+	DefaultResult string `json:"default_result"`
+}
+
+type ScalingLifecycleHookListInput struct {
+	apis.VirtualResourceListInput
+	ScalingGroupFilterListInput
+
+	// description: transition
+	// enum: scale_out,scale_in
+	Transition string `json:"transition"`
+}
+
+type ScalingLifecycleHookDetails struct {
+	apis.VirtualResourceDetails
+	ScalingGroupResourceInfo
+	SScalingLifecycleHook
+}
+
+type ScalingGroupCompleteLifecycleActionInput struct {
+	// description: 处于pending:wait状态的实例 Id or Name
+	// example: vm-test
+	Guest string `json:"guest"`
+
+	// description: webhook 通知中携带的 token，用于防止误操作
+	LifecycleToken string `json:"lifecycle_token"`
+
+	// description: 结果
+	// enum: continue,abandon
+	// example: continue
+	Result string `json:"result"`
+}
+
+// ScalingLifecycleHookNotification is the body posted to webhook_url
+type ScalingLifecycleHookNotification struct {
+	Event            string    `json:"event"`
+	ScalingGroupId   string    `json:"scaling_group_id"`
+	ScalingGroupName string    `json:"scaling_group_name"`
+	LifecycleHookId  string    `json:"lifecycle_hook_id"`
+	Transition       string    `json:"transition"`
+	GuestId          string    `json:"guest_id"`
+	GuestName        string    `json:"guest_name"`
+	LifecycleToken   string    `json:"lifecycle_token"`
+	Deadline         time.Time `json:"deadline"`
+	DefaultResult    string    `json:"default_result"`
+}
@@ -26,6 +26,8 @@ type ScalingPolicyDetails struct {
 	CycleTimer CycleTimerDetails `json:"cycle_timer"`
 	//  告警方式触发
 	Alarm ScalingAlarmDetails `json:"alarm"`
+	// 目标追踪方式触发
+	TargetTracking ScalingTargetTrackingDetails `json:"target_tracking"`
 }
 
 type ScalingPolicyCreateInput struct {
@@ -40,12 +42,13 @@ type ScalingPolicyCreateInput struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 
 	// description: trigger type
-	// enum: timing,cycle,alarm
+	// enum: timing,cycle,alarm,target_tracking
 	TriggerType string `json:"trigger_type"`
 
-	Timer      TimerCreateInput        `json:"timer"`
-	CycleTimer CycleTimerCreateInput   `json:"cycle_timer"`
-	Alarm      ScalingAlarmCreateInput `json:"alarm"`
+	Timer          TimerCreateInput                 `json:"timer"`
+	CycleTimer     CycleTimerCreateInput            `json:"cycle_timer"`
+	Alarm          ScalingAlarmCreateInput          `json:"alarm"`
+	TargetTracking ScalingTargetTrackingCreateInput `json:"target_tracking"`
 
 	// desciption: 伸缩策略的行为(增加还是删除或者调整为)
 	// enum: add,remove,set
@@ -75,7 +78,7 @@ type ScalingPolicyListInput struct {
 	ScalingGroupFilterListInput
 
 	// description: trigger type
-	// enum: timing,cycel,alarm
+	// enum: timing,cycel,alarm,target_tracking
 	// example: alarm
 	TriggerType string `json:"trigger_type"`
 }
@@ -14,7 +14,11 @@
 
 package compute
 
-import "time"
+import (
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
 
 type TimerCreateInput struct {
 
@@ -81,6 +85,37 @@ type ScalingAlarmCreateInput struct {
 	Value float64 `json:"value"`
 }
 
+type ScalingTargetTrackingCreateInput struct {
+
+	// description: 追踪的监控指标
+	// example: cpu
+	// enum: cpu,mem,custom
+	Indicator string `json:"indicator"`
+
+	// description: 自定义监控查询(monitor的metric_query格式)，indicator为custom时必填，查询结果取所有序列的平均值
+	CustomQuery jsonutils.JSONObject `json:"custom_query"`
+
+	// description: 监控指标的目标值
+	// example: 60
+	TargetValue float64 `json:"target_value"`
+
+	// description: 监控周期，单位s
+	// example: 300
+	Cycle int `json:"cycle"`
+
+	// description: 扩容冷却时间，单位s
+	// example: 300
+	ScaleOutCooldown int `json:"scale_out_cooldown"`
+
+	// description: 缩容冷却时间，单位s
+	// example: 600
+	ScaleInCooldown int `json:"scale_in_cooldown"`
+
+	// description: 只扩容不缩容
+	// example: false
+	DisableScaleIn bool `json:"disable_scale_in"`
+}
+
 type TimerDetails struct {
 	// description: 执行时间
 	ExecTime time.Time `json:"exec_time"`
@@ -117,3 +152,24 @@ type ScalingAlarmDetails struct {
 	// description: 阈值
 	Value float64 `json:"value"`
 }
+
+type ScalingTargetTrackingDetails struct {
+	// description: 指标
+	Indicator string `json:"indicator"`
+	// description: 自定义监控查询
+	CustomQuery jsonutils.JSONObject `json:"custom_query"`
+	// description: 目标值
+	TargetValue float64 `json:"target_value"`
+	// description: 监控周期
+	Cycle int `json:"cycle"`
+	// description: 扩容冷却时间
+	ScaleOutCooldown int `json:"scale_out_cooldown"`
+	// description: 缩容冷却时间
+	ScaleInCooldown int `json:"scale_in_cooldown"`
+	// description: 只扩容不缩容
+	DisableScaleIn bool `json:"disable_scale_in"`
+	// description: 最近一次采集的指标值
+	LastValue float64 `json:"last_value"`
+	// description: 最近一次采集时间
+	LastCheckTime time.Time `json:"last_check_time"`
+}
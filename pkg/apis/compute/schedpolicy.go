@@ -14,7 +14,15 @@
 
 package compute
 
-import "yunion.io/x/onecloud/pkg/apis"
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
 
 type SchedpolicyDetails struct {
 	apis.StandaloneResourceDetails
@@ -22,3 +30,34 @@ type SchedpolicyDetails struct {
 
 	SSchedpolicy
 }
+
+// 基于主机实际资源利用率（p95）打分时各项指标的权重，为0表示不参与打分
+type SchedUtilizationWeights struct {
+	Cpu     float64 `json:"cpu"`
+	Memory  float64 `json:"memory"`
+	DiskIo  float64 `json:"disk_io"`
+	Network float64 `json:"network"`
+}
+
+func (w SchedUtilizationWeights) String() string {
+	return jsonutils.Marshal(w).String()
+}
+
+func (w SchedUtilizationWeights) IsZero() bool {
+	return w.Cpu == 0 && w.Memory == 0 && w.DiskIo == 0 && w.Network == 0
+}
+
+func (w SchedUtilizationWeights) Validate() error {
+	for k, v := range map[string]float64{"cpu": w.Cpu, "memory": w.Memory, "disk_io": w.DiskIo, "network": w.Network} {
+		if v < 0 {
+			return httperrors.NewInputParameterError("utilization weight of %s must not be negative", k)
+		}
+	}
+	return nil
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SchedUtilizationWeights{}), func() gotypes.ISerializable {
+		return &SchedUtilizationWeights{}
+	})
+}
@@ -30,6 +30,8 @@ type ScheduledTaskDetails struct {
 	Timer TimerDetails `json:"timer"`
 	// 周期方式触发
 	CycleTimer CycleTimerDetails `json:"cycle_timer"`
+	// cron 表达式方式触发
+	CronTimer CronTimerDetails `json:"cron_timer"`
 	// 绑定的所有标示
 	Labels       []string      `json:"labels,allowempty"`
 	LabelDetails []LabelDetail `json:"label_details,allowempty"`
@@ -64,11 +66,12 @@ type ScheduledTaskCreateInput struct {
 	apis.EnabledBaseResourceCreateInput
 
 	// description: scheduled type
-	// enum: cycle,timing
+	// enum: cycle,timing,cron
 	// example: timing
 	ScheduledType string                `json:"scheduled_type"`
 	Timer         TimerCreateInput      `json:"timer"`
 	CycleTimer    CycleTimerCreateInput `json:"cycle_timer"`
+	CronTimer     CronTimerCreateInput  `json:"cron_timer"`
 
 	// description: resource type
 	// enum: server
@@ -106,6 +109,51 @@ type ScheduledTaskFilterListInput struct {
 type ScheduledTaskActivityDetails struct {
 	apis.StatusStandaloneResourceDetails
 	SScheduledTaskActivity
+
+	// 执行时长, 单位秒
+	Duration float64 `json:"duration"`
+}
+
+type CronTimerCreateInput struct {
+	// description: 标准 cron 表达式, 5 段(分 时 日 月 周)或 6 段(秒 分 时 日 月 周), 最小间隔为 1 分钟
+	// example: 30 2 * * mon-fri
+	Expr string `json:"expr"`
+
+	// description: cron 表达式使用的时区, 默认为服务所在时区
+	// example: Asia/Shanghai
+	TimeZone string `json:"time_zone"`
+
+	// description: 随机延迟执行的最大秒数
+	// example: 60
+	Jitter int `json:"jitter"`
+
+	// description: 错过执行时间后的补跑策略, skip: 跳过, once: 补跑一次, all: 每次都补跑
+	// enum: skip,once,all
+	// example: skip
+	CatchUp string `json:"catch_up"`
+
+	// description: 开始时间
+	StartTime time.Time `json:"start_time"`
+
+	// description: 截止时间, 为空表示不截止
+	EndTime time.Time `json:"end_time"`
+}
+
+type CronTimerDetails struct {
+	// description: cron 表达式
+	Expr string `json:"expr"`
+	// description: 时区
+	TimeZone string `json:"time_zone"`
+	// description: 随机延迟执行的最大秒数
+	Jitter int `json:"jitter"`
+	// description: 补跑策略
+	CatchUp string `json:"catch_up"`
+	// description: 开始时间
+	StartTime time.Time `json:"start_time"`
+	// description: 截止时间
+	EndTime time.Time `json:"end_time"`
+	// description: 下次执行时间
+	NextTime time.Time `json:"next_time"`
 }
 
 type ScheduledTaskActivityListInput struct {
@@ -17,6 +17,7 @@ package compute
 const (
 	ST_TYPE_TIMING = "timing" // 定时
 	ST_TYPE_CYCLE  = "cycle"  // 周期
+	ST_TYPE_CRON   = "cron"   // cron 表达式
 
 	ST_STATUS_READY         = "ready"
 	ST_STATUS_CREATE_FAILED = "create_failed"
@@ -16,6 +16,7 @@ package compute
 
 import (
 	"fmt"
+	"net"
 
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/regutils"
@@ -72,6 +73,12 @@ type SSecgroupRuleCreateInput struct {
 	// example: 192.168.222.121
 	CIDR string `json:"cidr"`
 
+	// 引用的地址组(ID或Name), 与cidr互斥
+	// required: false
+	AddressGroup string `json:"address_group"`
+	// swagger:ignore
+	AddressGroupId string `json:"address_group_id"`
+
 	// 行为
 	// deny: 拒绝
 	// allow: 允许
@@ -92,6 +99,11 @@ type SSecgroupRuleCreateInput struct {
 	SecgroupId string
 }
 
+func isCIDR6(cidr string) bool {
+	ip, _, err := net.ParseCIDR(cidr)
+	return err == nil && ip.To4() == nil
+}
+
 func (input *SSecgroupRuleCreateInput) Check() error {
 	rule := secrules.SecurityRule{
 		Priority:  input.Priority,
@@ -110,11 +122,14 @@ func (input *SSecgroupRuleCreateInput) Check() error {
 		}
 	}
 
+	if len(input.CIDR) > 0 && len(input.AddressGroupId) > 0 {
+		return fmt.Errorf("cidr and address_group are mutually exclusive")
+	}
 	if len(input.CIDR) > 0 {
-		if !regutils.MatchCIDR(input.CIDR) && !regutils.MatchIPAddr(input.CIDR) {
+		if !regutils.MatchCIDR(input.CIDR) && !regutils.MatchIPAddr(input.CIDR) && !isCIDR6(input.CIDR) {
 			return fmt.Errorf("invalid ip address: %s", input.CIDR)
 		}
-	} else {
+	} else if len(input.AddressGroupId) == 0 {
 		input.CIDR = "0.0.0.0/0"
 	}
 
@@ -0,0 +1,34 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build simulator
+
+package compute
+
+// Simulator 仅用于集成测试, 需使用 simulator 编译标签才会注册到以下列表
+func init() {
+	PRIVATE_CLOUD_PROVIDERS = append(PRIVATE_CLOUD_PROVIDERS, CLOUD_PROVIDER_SIMULATOR)
+	CLOUD_PROVIDERS = append(CLOUD_PROVIDERS, CLOUD_PROVIDER_SIMULATOR)
+
+	HYPERVISORS = append(HYPERVISORS, HYPERVISOR_SIMULATOR)
+	PRIVATE_CLOUD_HYPERVISORS = append(PRIVATE_CLOUD_HYPERVISORS, HYPERVISOR_SIMULATOR)
+	HYPERVISOR_HOSTTYPE[HYPERVISOR_SIMULATOR] = HOST_TYPE_SIMULATOR
+	HOSTTYPE_HYPERVISOR[HOST_TYPE_SIMULATOR] = HYPERVISOR_SIMULATOR
+	HOST_TYPES = append(HOST_TYPES, HOST_TYPE_SIMULATOR)
+
+	STORAGE_LOCAL_TYPES = append(STORAGE_LOCAL_TYPES, STORAGE_SIMULATOR_LOCAL)
+	STORAGE_SUPPORT_TYPES = STORAGE_LOCAL_TYPES
+	STORAGE_TYPES = append(STORAGE_TYPES, STORAGE_SIMULATOR_LOCAL, STORAGE_SIMULATOR_SHARED)
+	HOST_STORAGE_LOCAL_TYPES = append(HOST_STORAGE_LOCAL_TYPES, STORAGE_SIMULATOR_LOCAL)
+}
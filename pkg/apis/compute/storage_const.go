@@ -94,6 +94,28 @@ const (
 	STORAGE_CTYUN_SSD  = "SSD"  // 超高IO云硬盘
 	STORAGE_CTYUN_SAS  = "SAS"  // 高IO云硬盘
 	STORAGE_CTYUN_SATA = "SATA" // 普通IO云硬盘
+
+	// Proxmox storage type
+	STORAGE_PROXMOX_LOCAL  = "proxmox_local"  // 节点本地存储
+	STORAGE_PROXMOX_SHARED = "proxmox_shared" // 集群共享存储
+
+	// oVirt storage domain type
+	STORAGE_OVIRT_NFS       = "ovirt_nfs"
+	STORAGE_OVIRT_ISCSI     = "ovirt_iscsi"
+	STORAGE_OVIRT_FCP       = "ovirt_fcp"
+	STORAGE_OVIRT_GLUSTERFS = "ovirt_glusterfs"
+	STORAGE_OVIRT_LOCALFS   = "ovirt_localfs"
+	STORAGE_OVIRT_POSIXFS   = "ovirt_posixfs"
+
+	// Simulator storage type
+	STORAGE_SIMULATOR_LOCAL  = "simulator_local"
+	STORAGE_SIMULATOR_SHARED = "simulator_shared"
+
+	// Libvirt storage pool type
+	STORAGE_LIBVIRT_DIR     = "libvirt_dir"
+	STORAGE_LIBVIRT_LOGICAL = "libvirt_logical"
+	STORAGE_LIBVIRT_NETFS   = "libvirt_netfs"
+	STORAGE_LIBVIRT_RBD     = "libvirt_rbd"
 )
 
 const (
@@ -117,7 +139,8 @@ var (
 	DISK_TYPES          = []string{DISK_TYPE_ROTATE, DISK_TYPE_SSD, DISK_TYPE_HYBRID}
 	STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_UCLOUD_LOCAL_NORMAL, STORAGE_UCLOUD_LOCAL_SSD, STORAGE_UCLOUD_EXCLUSIVE_LOCAL_DISK,
 		STORAGE_EPHEMERAL_SSD, STORAGE_LOCAL_BASIC, STORAGE_LOCAL_SSD, STORAGE_LOCAL_PRO, STORAGE_OPENSTACK_NOVA,
-		STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_GOOGLE_LOCAL_SSD}
+		STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_GOOGLE_LOCAL_SSD, STORAGE_PROXMOX_LOCAL, STORAGE_OVIRT_LOCALFS,
+		STORAGE_LIBVIRT_DIR, STORAGE_LIBVIRT_LOGICAL}
 	STORAGE_SUPPORT_TYPES = STORAGE_LOCAL_TYPES
 	STORAGE_ALL_TYPES     = []string{
 		STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_SHEEPDOG,
@@ -135,9 +158,13 @@ var (
 		STORAGE_OPENSTACK_ISCSI, STORAGE_UCLOUD_CLOUD_NORMAL, STORAGE_UCLOUD_CLOUD_SSD,
 		STORAGE_UCLOUD_LOCAL_NORMAL, STORAGE_UCLOUD_LOCAL_SSD, STORAGE_UCLOUD_EXCLUSIVE_LOCAL_DISK,
 		STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_ZSTACK_CEPH, STORAGE_GPFS, STORAGE_CIFS,
+		STORAGE_PROXMOX_LOCAL, STORAGE_PROXMOX_SHARED,
+		STORAGE_OVIRT_NFS, STORAGE_OVIRT_ISCSI, STORAGE_OVIRT_FCP, STORAGE_OVIRT_GLUSTERFS, STORAGE_OVIRT_LOCALFS, STORAGE_OVIRT_POSIXFS,
+		STORAGE_LIBVIRT_DIR, STORAGE_LIBVIRT_LOGICAL, STORAGE_LIBVIRT_NETFS, STORAGE_LIBVIRT_RBD,
 	}
 
-	HOST_STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_OPENSTACK_NOVA}
+	HOST_STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_OPENSTACK_NOVA, STORAGE_PROXMOX_LOCAL, STORAGE_OVIRT_LOCALFS,
+		STORAGE_LIBVIRT_DIR, STORAGE_LIBVIRT_LOGICAL}
 
 	STORAGE_LIMITED_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_NAS, STORAGE_RBD, STORAGE_NFS, STORAGE_GPFS, STORAGE_VSAN, STORAGE_CIFS}
 
@@ -21,6 +21,15 @@ import (
 	"yunion.io/x/onecloud/pkg/apis/cloudprovider"
 )
 
+// SAddressGroup is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SAddressGroup.
+type SAddressGroup struct {
+	apis.SSharableVirtualResourceBase
+	// IPv4地址或CIDR列表
+	IpList *SAddressList `json:"ip_list"`
+	// IPv6地址或CIDR列表
+	Ip6List *SAddressList `json:"ip6_list"`
+}
+
 // SAwsCachedLb is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SAwsCachedLb.
 type SAwsCachedLb struct {
 	apis.SVirtualResourceBase
@@ -839,6 +848,28 @@ type SExternalProject struct {
 	CloudaccountId string `json:"cloudaccount_id"`
 }
 
+// SFlowLog is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SFlowLog.
+type SFlowLog struct {
+	apis.SVirtualResourceBase
+	apis.SEnabledResourceBase
+	// 采集对象类型 vpc|network|server
+	ResourceType string `json:"resource_type"`
+	// 采集对象Id
+	ResourceId string `json:"resource_id"`
+	// 采集对象为server时的网卡名称, 为空表示全部网卡
+	Ifname string `json:"ifname"`
+	// 采集的流量类型 accept|reject|all
+	TrafficType string `json:"traffic_type"`
+	// 投递目标 file|bucket|logger
+	SinkType string `json:"sink_type"`
+	// 投递到存储桶时的存储桶Id
+	BucketId string `json:"bucket_id"`
+	// 文件路径或对象前缀
+	SinkPath string `json:"sink_path"`
+	// 每台宿主机每秒最多记录条数
+	RateLimit int `json:"rate_limit"`
+}
+
 // SGlobalVpc is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGlobalVpc.
 type SGlobalVpc struct {
 	apis.SEnabledStatusInfrasResourceBase
@@ -933,6 +964,11 @@ type SGuest struct {
 	Hypervisor string `json:"hypervisor"`
 	// 套餐名称
 	InstanceType string `json:"instance_type"`
+	// NUMA绑定策略
+	// example: strict
+	NumaPolicy string `json:"numa_policy"`
+	// 宿主机上的NUMA节点及vCPU绑定结果, 由宿主机分配后上报
+	CpuNumaPin *GuestNumaPin `json:"cpu_numa_pin"`
 }
 
 // SGuestJointsBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestJointsBase.
@@ -1061,6 +1097,8 @@ type SHost struct {
 	CpuCount int `json:"cpu_count"`
 	// 物理CPU颗数
 	NodeCount byte `json:"node_count"`
+	// NUMA拓扑
+	Topology *HostTopology `json:"topology"`
 	// CPU描述信息
 	CpuDesc string `json:"cpu_desc"`
 	// CPU频率
@@ -1869,6 +1907,37 @@ type SQcloudCachedLbbg struct {
 	AssociatedType string `json:"associated_type"`
 }
 
+// SRebalanceMigration is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SRebalanceMigration.
+type SRebalanceMigration struct {
+	apis.SStatusStandaloneResourceBase
+	RebalancerId string `json:"rebalancer_id"`
+	GuestId      string `json:"guest_id"`
+	SourceHostId string `json:"source_host_id"`
+	TargetHostId string `json:"target_host_id"`
+	// 生成计划时源宿主机和目标宿主机的负载
+	SourceLoad float64 `json:"source_load"`
+	TargetLoad float64 `json:"target_load"`
+	// 生成该迁移项的原因
+	Reason     string    `json:"reason"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// SRebalancer is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SRebalancer.
+type SRebalancer struct {
+	apis.SEnabledStatusStandaloneResourceBase
+	ZoneId         string `json:"zone_id"`
+	SchedtagId     string `json:"schedtag_id"`
+	Mode           string `json:"mode"`
+	Threshold      int    `json:"threshold"`
+	UseUtilization *bool  `json:"use_utilization,omitempty"`
+	MaxConcurrency int    `json:"max_concurrency"`
+	MaxMigrations  int    `json:"max_migrations"`
+	// 评估周期，单位分钟
+	EvaluateInterval int       `json:"evaluate_interval"`
+	LastEvaluateAt   time.Time `json:"last_evaluate_at"`
+}
+
 // SReservedip is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SReservedip.
 type SReservedip struct {
 	apis.SResourceBase
@@ -1989,6 +2058,11 @@ type SScalingGroupGuest struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 	GuestStatus    string `json:"guest_status"`
 	Manual         *bool  `json:"manual,omitempty"`
+	// lifecycle hook which the guest is waiting for
+	LifecycleHookId   string    `json:"lifecycle_hook_id"`
+	LifecycleToken    string    `json:"lifecycle_token"`
+	LifecycleDeadline time.Time `json:"lifecycle_deadline"`
+	LifecycleResult   string    `json:"lifecycle_result"`
 }
 
 // SScalingGroupNetwork is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingGroupNetwork.
@@ -2004,6 +2078,16 @@ type SScalingGroupResourceBase struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 }
 
+// SScalingLifecycleHook is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingLifecycleHook.
+type SScalingLifecycleHook struct {
+	apis.SVirtualResourceBase
+	SScalingGroupResourceBase
+	Transition    string `json:"transition"`
+	WebhookUrl    string `json:"webhook_url"`
+	Timeout       int    `json:"timeout"`
+	DefaultResult string `json:"default_result"`
+}
+
 // SScalingPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingPolicy.
 type SScalingPolicy struct {
 	apis.SVirtualResourceBase
@@ -2025,6 +2109,21 @@ type SScalingPolicyBase struct {
 	ScalingPolicyId string `json:"scaling_policy_id"`
 }
 
+// SScalingTargetTracking is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingTargetTracking.
+type SScalingTargetTracking struct {
+	apis.SStandaloneResourceBase
+	SScalingPolicyBase
+	Indicator   string      `json:"indicator"`
+	CustomQuery interface{} `json:"custom_query"`
+	TargetValue float64     `json:"target_value"`
+	// Cycle of metric evaluation, unit: s
+	Cycle            int     `json:"cycle"`
+	ScaleOutCooldown int     `json:"scale_out_cooldown"`
+	ScaleInCooldown  int     `json:"scale_in_cooldown"`
+	DisableScaleIn   bool    `json:"disable_scale_in"`
+	LastValue        float64 `json:"last_value"`
+}
+
 // SScalingTimer is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingTimer.
 type SScalingTimer struct {
 	apis.SStandaloneResourceBase
@@ -2039,6 +2138,8 @@ type SSchedpolicy struct {
 	Condition string `json:"condition"`
 	Strategy  string `json:"strategy"`
 	Enabled   *bool  `json:"enabled,omitempty"`
+	// 匹配该策略时主机实际利用率打分的权重
+	UtilizationWeights *SchedUtilizationWeights `json:"utilization_weights"`
 }
 
 // SSchedtag is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSchedtag.
@@ -2068,6 +2169,14 @@ type SScheduledTask struct {
 	apis.SEnabledResourceBase
 	ScheduledType string `json:"scheduled_type"`
 	STimer
+	// cron 表达式, 仅 cron 类型有效
+	CronExpr string `json:"cron_expr"`
+	// cron 表达式使用的时区
+	TimeZone string `json:"time_zone"`
+	// 随机延迟执行的最大秒数
+	Jitter int `json:"jitter"`
+	// 错过执行时间后的补跑策略
+	CatchUp      string `json:"catch_up"`
 	ResourceType string `json:"resource_type"`
 	Operation    string `json:"operation"`
 	LabelType    string `json:"label_type"`
@@ -2125,6 +2234,8 @@ type SSecurityGroupRule struct {
 	CIDR        string `json:"cidr"`
 	Action      string `json:"action"`
 	Description string `json:"description"`
+	// 引用的地址组, 设置时忽略CIDR
+	AddressGroupId string `json:"address_group_id"`
 }
 
 // SServerSku is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SServerSku.
@@ -0,0 +1,39 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devtool
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	CRONJOB_RUN_STATUS_RUNNING = "running"
+	CRONJOB_RUN_STATUS_SUCCEED = "succeed"
+	CRONJOB_RUN_STATUS_FAILED  = "failed"
+)
+
+type CronjobRunListInput struct {
+	apis.StatusStandaloneResourceListInput
+
+	// 定时任务Id或名称
+	Cronjob string `json:"cronjob"`
+}
+
+type CronjobRunDetails struct {
+	apis.StatusStandaloneResourceDetails
+
+	// 定时任务名称
+	Cronjob string `json:"cronjob"`
+}
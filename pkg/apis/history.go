@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+// 获取资源在某一时刻的状态
+type GetHistoryInput struct {
+	// 时间点，默认为当前时间
+	At time.Time `json:"at"`
+}
+
+type GetHistoryOutput struct {
+	At time.Time `json:"at"`
+	// 资源在该时刻是否存在
+	Exists bool `json:"exists"`
+	// 保留的变更记录是否完整覆盖了该时刻之后的变更，否则重建的状态可能不准确
+	Complete bool `json:"complete"`
+	// 该时刻的资源字段
+	Object jsonutils.JSONObject `json:"object"`
+}
+
+// 获取资源在一段时间内的变更
+type GetDiffInput struct {
+	// 开始时间
+	Since time.Time `json:"since"`
+	// 结束时间，默认为当前时间
+	Until time.Time `json:"until"`
+}
+
+type FieldDiff struct {
+	Old jsonutils.JSONObject `json:"old"`
+	New jsonutils.JSONObject `json:"new"`
+}
+
+type ResourceChange struct {
+	OpsTime time.Time            `json:"ops_time"`
+	Action  string               `json:"action"`
+	Diff    map[string]FieldDiff `json:"diff"`
+}
+
+type GetDiffOutput struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	// 保留的变更记录是否完整覆盖了开始时间之后的变更
+	Complete bool `json:"complete"`
+	// 开始和结束时刻之间字段的差异
+	Diff map[string]FieldDiff `json:"diff"`
+	// 时间段内的变更记录，按时间先后排列
+	Changes []ResourceChange `json:"changes"`
+}
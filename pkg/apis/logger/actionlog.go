@@ -0,0 +1,143 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	EXPORTER_FORMAT_RFC5424 = "rfc5424"
+	EXPORTER_FORMAT_CEF     = "cef"
+	EXPORTER_FORMAT_JSON    = "json"
+
+	EXPORTER_TRANSPORT_TCP = "tcp"
+	EXPORTER_TRANSPORT_TLS = "tls"
+
+	EXPORTER_STATUS_READY     = "ready"
+	EXPORTER_STATUS_EXPORTING = "exporting"
+	EXPORTER_STATUS_ERROR     = "error"
+)
+
+var (
+	EXPORTER_FORMATS    = []string{EXPORTER_FORMAT_RFC5424, EXPORTER_FORMAT_CEF, EXPORTER_FORMAT_JSON}
+	EXPORTER_TRANSPORTS = []string{EXPORTER_TRANSPORT_TCP, EXPORTER_TRANSPORT_TLS}
+)
+
+type ActionlogVerifyInput struct {
+	// verify action logs with id greater or equal than start_id
+	StartId int64 `json:"start_id"`
+	// verify action logs with id less or equal than end_id
+	EndId int64 `json:"end_id"`
+}
+
+type ActionlogVerifyOutput struct {
+	// whether the chain and all checkpoints in range are intact
+	Verified bool `json:"verified"`
+	// number of verified action logs
+	Count   int64 `json:"count"`
+	FirstId int64 `json:"first_id"`
+	LastId  int64 `json:"last_id"`
+	// id of first action log whose hash mismatches
+	BrokenId int64  `json:"broken_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+
+	Checkpoints       int `json:"checkpoints"`
+	CheckpointsFailed int `json:"checkpoints_failed"`
+	// whether checkpoints are signed by the key of current logger service
+	PublicKey string `json:"public_key"`
+}
+
+type ActionlogExportFilter struct {
+	// only export action logs of these services
+	Services []string `json:"services"`
+	// only export action logs of these actions
+	Actions []string `json:"actions"`
+	// only export action logs of these object types
+	ObjTypes []string `json:"obj_types"`
+	// only export failed action logs
+	FailedOnly bool `json:"failed_only"`
+}
+
+func (filter ActionlogExportFilter) String() string {
+	return jsonutils.Marshal(filter).String()
+}
+
+func (filter ActionlogExportFilter) IsZero() bool {
+	return len(filter.Services) == 0 && len(filter.Actions) == 0 && len(filter.ObjTypes) == 0 && !filter.FailedOnly
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&ActionlogExportFilter{}), func() gotypes.ISerializable {
+		return &ActionlogExportFilter{}
+	})
+}
+
+type ActionlogExporterCreateInput struct {
+	apis.EnabledStatusStandaloneResourceCreateInput
+
+	// output format
+	// enum: rfc5424, cef, json
+	Format string `json:"format"`
+	// transport
+	// enum: tcp, tls
+	Transport string `json:"transport"`
+	// address of SIEM collector, host:port
+	Address string `json:"address"`
+
+	// PEM encoded CA certificates to verify collector, use system roots if empty
+	TlsCaCert string `json:"tls_ca_cert"`
+	// skip verifying collector certificate
+	TlsInsecure bool `json:"tls_insecure"`
+
+	Filter *ActionlogExportFilter `json:"filter"`
+
+	// export action logs with id greater than start_id, default from latest
+	StartId *int64 `json:"start_id"`
+}
+
+type ActionlogExporterUpdateInput struct {
+	apis.EnabledStatusStandaloneResourceBaseUpdateInput
+
+	Address     string                 `json:"address"`
+	TlsCaCert   string                 `json:"tls_ca_cert"`
+	TlsInsecure *bool                  `json:"tls_insecure"`
+	Filter      *ActionlogExportFilter `json:"filter"`
+}
+
+type ActionlogExporterListInput struct {
+	apis.EnabledStatusStandaloneResourceListInput
+
+	Format    []string `json:"format"`
+	Transport []string `json:"transport"`
+}
+
+type ActionlogExporterDetails struct {
+	apis.EnabledStatusStandaloneResourceDetails
+}
+
+type ActionlogExporterResetCursorInput struct {
+	// export action logs with id greater than cursor
+	Cursor int64 `json:"cursor"`
+}
+
+type ActionlogCheckpointListInput struct {
+	apis.ModelBaseListInput
+}
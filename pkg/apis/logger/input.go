@@ -28,3 +28,16 @@ type BaremetalEventListInput struct {
 	// until
 	Until time.Time `json:"until"`
 }
+
+type FlowLogRecordListInput struct {
+	apis.ModelBaseListInput
+
+	// 流日志ID
+	FlowLogId string `json:"flow_log_id"`
+	// 宿主机ID
+	HostId string `json:"host_id"`
+	// since
+	Since time.Time `json:"since"`
+	// until
+	Until time.Time `json:"until"`
+}
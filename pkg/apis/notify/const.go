@@ -38,6 +38,10 @@ const (
 	RECEIVER_NOTIFICATION_OK       = "sent_ok"   // Notification was sent successfully
 	RECEIVER_NOTIFICATION_FAIL     = "sent_fail" // That sent a notification is failed
 
+	RECEIVER_NOTIFICATION_SUPPRESSED     = "suppressed"     // Duplicate notification within dedup window, not sent
+	RECEIVER_NOTIFICATION_DIGEST_PENDING = "digest_pending" // Waiting to be sent in next digest, also used for notifications exceeding rate limits
+	RECEIVER_NOTIFICATION_DIGESTED       = "digested"       // Sent as part of a digest
+
 	VERIFICATION_SENT          = "sent"      // Verification was sent
 	VERIFICATION_SENT_FAIL     = "sent_fail" // Verification was sent failed
 	VERIFICATION_VERIFIED      = "verified"  // Verification was verified
@@ -57,8 +61,11 @@ const (
 	NOTIFICATION_STATUS_FAILED   = "failed"
 	NOTIFICATION_STATUS_OK       = "ok"
 	NOTIFICATION_STATUS_PART_OK  = "part_ok"
+	// 免打扰时段内延迟发送
+	NOTIFICATION_STATUS_DEFERRED = "deferred"
 
-	NOTIFICATION_TAG_ALERT = "alert"
+	NOTIFICATION_TAG_ALERT  = "alert"
+	NOTIFICATION_TAG_DIGEST = "digest"
 
 	TEMPLATE_TYPE_TITLE   = "title"
 	TEMPLATE_TYPE_CONTENT = "content"
@@ -52,6 +52,9 @@ type NotificationCreateInput struct {
 	Tag                       string                 `json:"tag"`
 	Metadata                  map[string]interface{} `json:"metadata"`
 	IgnoreNonexistentReceiver bool                   `json:"ignore_nonexistent_receiver"`
+	// description: notifications with same dedup key are sent to a receiver only once within dedup window, default to digest of topic and message
+	// required: false
+	DedupKey string `json:"dedup_key"`
 }
 
 type ReceiveDetail struct {
@@ -0,0 +1,233 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	SUBSCRIBER_TYPE_USER  = "user"
+	SUBSCRIBER_TYPE_GROUP = "group"
+
+	// 升级通知记录其来源通知, 确认任一通知即停止升级
+	NOTIFICATION_METADATA_ESCALATED_FROM = "escalated_from"
+
+	// 各服务发布的事件类别
+	TOPIC_EVENT_CLASS_ALERT        = "alert"
+	TOPIC_EVENT_CLASS_SYNC_FAILURE = "sync_failure"
+	TOPIC_EVENT_CLASS_FAILURE      = "failure"
+	TOPIC_EVENT_CLASS_BALANCE      = "balance"
+)
+
+var NOTIFICATION_PRIORITIES = []string{
+	NOTIFICATION_PRIORITY_NORMAL,
+	NOTIFICATION_PRIORITY_IMPORTANT,
+	NOTIFICATION_PRIORITY_CRITICAL,
+}
+
+type TopicCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// description: resource type of topic
+	// required: true
+	// example: server
+	ResourceType string `json:"resource_type"`
+
+	// description: event class of topic
+	// required: true
+	// example: failure
+	EventClass string `json:"event_class"`
+
+	// description: template topic used to render message, default to topic name
+	// required: false
+	// example: SERVER_FAILURE
+	TemplateTopic string `json:"template_topic"`
+}
+
+type TopicUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	TemplateTopic string `json:"template_topic"`
+}
+
+type TopicListInput struct {
+	apis.StandaloneResourceListInput
+	apis.EnabledResourceBaseListInput
+
+	ResourceType string `json:"resource_type"`
+	EventClass   string `json:"event_class"`
+}
+
+type TopicDetails struct {
+	apis.StandaloneResourceDetails
+
+	STopic
+
+	SubscriptionCount int `json:"subscription_count"`
+}
+
+type TopicPublishInput struct {
+	// description: id or name of topic, resource_type and event_class are used when empty
+	// example: server-failure
+	Topic string `json:"topic"`
+
+	ResourceType string `json:"resource_type"`
+	EventClass   string `json:"event_class"`
+
+	// description: owner project and domain of resource, used to match subscriptions
+	ProjectId string `json:"project_id"`
+	DomainId  string `json:"domain_id"`
+
+	ResourceId   string `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+
+	// enum: fatal,important,normal
+	Priority string `json:"priority"`
+	// description: message content or jsonobject
+	// required: true
+	Message string `json:"message"`
+	Tag     string `json:"tag"`
+	// description: dedup key of notifications, default to digest of topic and message
+	DedupKey string `json:"dedup_key"`
+}
+
+type TopicPublishOutput struct {
+	Notifications []string `json:"notifications"`
+}
+
+type SubscriptionCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.DomainizedResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// description: id or name of topic
+	// required: true
+	Topic string `json:"topic"`
+	// swagger:ignore
+	TopicId string `json:"topic_id"`
+
+	// description: subscriber type, default to user
+	// enum: user,group
+	SubscriberType string `json:"subscriber_type"`
+	// description: id or name of user or group, default to current user
+	Subscriber string `json:"subscriber"`
+	// swagger:ignore
+	SubscriberId string `json:"subscriber_id"`
+
+	// description: only receive events of resources in this project
+	ProjectId string `json:"project_id"`
+	// description: only receive events of resources in this domain
+	ResourceDomainId string `json:"resource_domain_id"`
+
+	// description: channels to receive notifications, default to webconsole
+	// example: {"email", "webconsole"}
+	ContactTypes []string `json:"contact_types"`
+
+	// description: ignore events whose priority lower than this
+	// enum: fatal,important,normal
+	MinPriority string `json:"min_priority"`
+
+	// description: quiet hours in local time of notify service, HH:MM, end may be earlier than start
+	// example: 22:00
+	QuietHoursStart string `json:"quiet_hours_start"`
+	// example: 08:00
+	QuietHoursEnd string `json:"quiet_hours_end"`
+
+	// description: id or name of escalation chain
+	Escalation string `json:"escalation"`
+	// swagger:ignore
+	EscalationId string `json:"escalation_id"`
+}
+
+type SubscriptionUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	ContactTypes    []string `json:"contact_types"`
+	MinPriority     string   `json:"min_priority"`
+	QuietHoursStart *string  `json:"quiet_hours_start"`
+	QuietHoursEnd   *string  `json:"quiet_hours_end"`
+	Escalation      *string  `json:"escalation"`
+	// swagger:ignore
+	EscalationId *string `json:"escalation_id"`
+}
+
+type SubscriptionListInput struct {
+	apis.StandaloneResourceListInput
+	apis.DomainizedResourceListInput
+	apis.EnabledResourceBaseListInput
+
+	Topic          string `json:"topic"`
+	SubscriberType string `json:"subscriber_type"`
+	SubscriberId   string `json:"subscriber_id"`
+	Escalation     string `json:"escalation"`
+}
+
+type SubscriptionDetails struct {
+	apis.StandaloneResourceDetails
+	apis.DomainizedResourceInfo
+
+	SSubscription
+
+	Topic        string   `json:"topic"`
+	Escalation   string   `json:"escalation"`
+	ContactTypes []string `json:"contact_types"`
+}
+
+type EscalationCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// description: ids or names of receiver, notified one by one until acked
+	// required: true
+	Receivers []string `json:"receivers"`
+
+	// description: contact type used to notify escalation receivers, default to the origin one
+	ContactType string `json:"contact_type"`
+
+	// description: minutes to wait for ack before notify next receiver
+	// example: 15
+	AckTimeoutMinutes int `json:"ack_timeout_minutes"`
+
+	// description: only escalate notifications whose priority not lower than this, default to important
+	// enum: fatal,important,normal
+	MinPriority string `json:"min_priority"`
+}
+
+type EscalationUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	Receivers         []string `json:"receivers"`
+	ContactType       *string  `json:"contact_type"`
+	AckTimeoutMinutes int      `json:"ack_timeout_minutes"`
+	MinPriority       string   `json:"min_priority"`
+}
+
+type EscalationListInput struct {
+	apis.StandaloneResourceListInput
+	apis.EnabledResourceBaseListInput
+}
+
+type EscalationDetails struct {
+	apis.StandaloneResourceDetails
+
+	SEscalation
+
+	Receivers []string `json:"receivers"`
+}
+
+type NotificationAckInput struct {
+}
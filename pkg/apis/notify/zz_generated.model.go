@@ -27,6 +27,20 @@ type SConfig struct {
 	Content interface{} `json:"content"`
 }
 
+// SEscalation is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.SEscalation.
+type SEscalation struct {
+	apis.SStandaloneResourceBase
+	apis.SEnabledResourceBase
+	// 按顺序排列的接收人Id, 逗号分隔
+	Receivers string `json:"receivers"`
+	// 通知升级接收人使用的渠道, 为空时沿用原通知渠道
+	ContactType string `json:"contact_type"`
+	// 等待确认的分钟数
+	AckTimeoutMinutes int `json:"ack_timeout_minutes"`
+	// 低于此级别的通知不升级
+	MinPriority string `json:"min_priority"`
+}
+
 // SNotification is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.SNotification.
 type SNotification struct {
 	apis.SStatusStandaloneResourceBase
@@ -38,6 +52,17 @@ type SNotification struct {
 	Message    string    `json:"message"`
 	ReceivedAt time.Time `json:"received_at"`
 	SendTimes  int       `json:"send_times"`
+	// 确认时间及确认人
+	AckedAt time.Time `json:"acked_at"`
+	AckedBy string    `json:"acked_by"`
+	// 升级链及当前升级级别, 超过 EscalateAt 仍未确认则通知下一接收人
+	EscalationId    string    `json:"escalation_id"`
+	EscalationLevel int       `json:"escalation_level"`
+	EscalateAt      time.Time `json:"escalate_at"`
+	// 去重键, 去重窗口内同一接收人只发送一次
+	DedupKey string `json:"dedup_key"`
+	// 免打扰时段内的通知延迟到 SendAfter 之后发送
+	SendAfter time.Time `json:"send_after"`
 }
 
 // SReceiver is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.SReceiver.
@@ -81,6 +106,31 @@ type SSubContact struct {
 	VerifiedNote      string `json:"verified_note"`
 }
 
+// SSubscription is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.SSubscription.
+type SSubscription struct {
+	apis.SStandaloneResourceBase
+	// 订阅者所属域
+	apis.SDomainizedResourceBase
+	apis.SEnabledResourceBase
+	TopicId string `json:"topic_id"`
+	// 订阅者类型, user 或 group
+	SubscriberType string `json:"subscriber_type"`
+	// 用户Id或用户组Id
+	SubscriberId string `json:"subscriber_id"`
+	// 只订阅指定项目或域下资源的事件
+	ProjectId        string `json:"project_id"`
+	ResourceDomainId string `json:"resource_domain_id"`
+	// 接收渠道, 逗号分隔
+	ContactTypes string `json:"contact_types"`
+	// 最低通知级别
+	MinPriority string `json:"min_priority"`
+	// 免打扰时段, HH:MM, 紧急通知不受影响
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	// 未确认时的升级链
+	EscalationId string `json:"escalation_id"`
+}
+
 // STemplate is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.STemplate.
 type STemplate struct {
 	apis.SStandaloneResourceBase
@@ -92,6 +142,18 @@ type STemplate struct {
 	Example      string `json:"example"`
 }
 
+// STopic is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.STopic.
+type STopic struct {
+	apis.SStandaloneResourceBase
+	apis.SEnabledResourceBase
+	// 资源类型, 如 server, cloudaccount
+	ResourceType string `json:"resource_type"`
+	// 事件类别, 如 failure, balance_low
+	EventClass string `json:"event_class"`
+	// 渲染消息使用的模板主题
+	TemplateTopic string `json:"template_topic"`
+}
+
 // SVerification is an autogenerated struct via yunion.io/x/onecloud/pkg/notify/models.SVerification.
 type SVerification struct {
 	apis.SStandaloneResourceBase
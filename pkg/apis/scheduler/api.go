@@ -92,6 +92,9 @@ type ScheduleInput struct {
 	HasIsolatedDevice bool
 
 	PendingUsages []jsonutils.JSONObject
+
+	// 主机实际利用率打分权重，未指定时由调度策略或调度器默认配置决定
+	UtilizationWeights *compute.SchedUtilizationWeights `json:"utilization_weights"`
 }
 
 func (input ScheduleInput) ToConditionInput() *jsonutils.JSONDict {
@@ -41,6 +41,7 @@ import (
 	"yunion.io/x/onecloud/pkg/i18n"
 	"yunion.io/x/onecloud/pkg/proxy"
 	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/tracing"
 )
 
 type Application struct {
@@ -223,7 +224,9 @@ func (app *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else {
 		counter = &hi.counter5XX
 	}
-	duration := float64(time.Since(start).Nanoseconds()) / 1000000
+	elapsed := time.Since(start)
+	observeRequest(hi, r.Method, lrw.status, elapsed)
+	duration := float64(elapsed.Nanoseconds()) / 1000000
 	counter.hit += 1
 	counter.duration += duration
 	skipLog := false
@@ -317,6 +320,24 @@ func (app *Application) defaultHandle(w http.ResponseWriter, r *http.Request, ri
 								}
 							}()
 							ctx = context.WithValue(ctx, appctx.APP_CONTEXT_KEY_TRACE, span)
+
+							var otSpan *tracing.SSpan
+							ctx, otSpan = tracing.StartSpan(tracing.Extract(ctx, r.Header), fmt.Sprintf("%s /%s", r.Method, strings.Join(hand.path, "/")), tracing.SpanKindServer)
+							defer func() {
+								status := fw.status
+								if status == 0 {
+									status = http.StatusOK
+								}
+								otSpan.SetAttribute("http.status_code", status)
+								if status >= http.StatusInternalServerError {
+									otSpan.SetError(http.StatusText(status))
+								}
+								otSpan.End()
+							}()
+							otSpan.SetAttribute("http.method", r.Method)
+							otSpan.SetAttribute("http.target", r.URL.Path)
+							otSpan.SetAttribute("http.request_id", rid)
+							otSpan.SetAttribute("handler", hand.name)
 							hand.handler(ctx, &fw, r)
 						}()
 					} // otherwise, the task has been timeout
@@ -364,6 +385,7 @@ func (app *Application) addDefaultHandlers() {
 	app.AddDefaultHandler("POST", "/ping", PingHandler, "ping")
 	app.AddDefaultHandler("GET", "/ping", PingHandler, "ping")
 	app.AddDefaultHandler("GET", "/worker_stats", WorkerStatsHandler, "worker_stats")
+	app.AddDefaultHandler("GET", "/metrics", MetricsHandler, "metrics")
 }
 
 func timeoutHandle(h http.Handler) http.HandlerFunc {
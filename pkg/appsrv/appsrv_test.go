@@ -77,6 +77,14 @@ func (suite *ApplicationTestSuit) TestHandler() {
 	assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/delaypanic", nil, "the handler is delay panic"))
 }
 
+func (suite *ApplicationTestSuit) TestMetrics() {
+	app := suite.app
+	app.addDefaultHandlers()
+	assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/panic", nil, "the handler is panic"))
+	assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/metrics", nil, `appsrv_request_duration_seconds_count{handler="/panic",method="GET",status_class="5xx"}`))
+	assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/metrics", nil, "appsrv_worker_queue_depth"))
+}
+
 func TestApplicationTestSuite(t *testing.T) {
 	suite.Run(t, new(ApplicationTestSuit))
 }
@@ -0,0 +1,116 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsrv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "appsrv",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests served by appsrv handlers",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"method", "handler", "status_class"},
+	)
+
+	workerQueueDesc = prometheus.NewDesc(
+		"appsrv_worker_queue_depth",
+		"Number of tasks waiting in the queue of a worker manager",
+		[]string{"worker"}, nil,
+	)
+	workerActiveDesc = prometheus.NewDesc(
+		"appsrv_worker_active",
+		"Number of active workers of a worker manager",
+		[]string{"worker"}, nil,
+	)
+	workerDetachedDesc = prometheus.NewDesc(
+		"appsrv_worker_detached",
+		"Number of detached workers of a worker manager",
+		[]string{"worker"}, nil,
+	)
+	workerMaxDesc = prometheus.NewDesc(
+		"appsrv_worker_max",
+		"Maximal number of workers of a worker manager",
+		[]string{"worker"}, nil,
+	)
+)
+
+type workerCollector struct{}
+
+func (c workerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workerQueueDesc
+	ch <- workerActiveDesc
+	ch <- workerDetachedDesc
+	ch <- workerMaxDesc
+}
+
+func (c workerCollector) Collect(ch chan<- prometheus.Metric) {
+	workerManagerLock.Lock()
+	// worker managers of the same name, e.g. those of several applications in one process, are summed up
+	names := make([]string, 0)
+	states := make(map[string]*SWorkerManagerStates)
+	for _, wm := range workerManagers {
+		state := wm.getState()
+		if sum, ok := states[state.Name]; ok {
+			sum.QueueCnt += state.QueueCnt
+			sum.ActiveWorkerCnt += state.ActiveWorkerCnt
+			sum.DetachWorkerCnt += state.DetachWorkerCnt
+			sum.MaxWorkerCnt += state.MaxWorkerCnt
+		} else {
+			names = append(names, state.Name)
+			states[state.Name] = &state
+		}
+	}
+	workerManagerLock.Unlock()
+
+	for _, name := range names {
+		state := states[name]
+		ch <- prometheus.MustNewConstMetric(workerQueueDesc, prometheus.GaugeValue, float64(state.QueueCnt), name)
+		ch <- prometheus.MustNewConstMetric(workerActiveDesc, prometheus.GaugeValue, float64(state.ActiveWorkerCnt), name)
+		ch <- prometheus.MustNewConstMetric(workerDetachedDesc, prometheus.GaugeValue, float64(state.DetachWorkerCnt), name)
+		ch <- prometheus.MustNewConstMetric(workerMaxDesc, prometheus.GaugeValue, float64(state.MaxWorkerCnt), name)
+	}
+}
+
+var metricsHandler http.Handler
+
+func init() {
+	prometheus.MustRegister(requestDuration, workerCollector{})
+	metricsHandler = promhttp.Handler()
+}
+
+func observeRequest(hi *SHandlerInfo, method string, status int, duration time.Duration) {
+	// the path template keeps the cardinality of handler bounded
+	handler := "/" + strings.Join(hi.path, "/")
+	statusClass := fmt.Sprintf("%dxx", status/100)
+	requestDuration.WithLabelValues(method, handler, statusClass).Observe(duration.Seconds())
+}
+
+// MetricsHandler exposes metrics registered to the default prometheus registry,
+// which also includes metrics of taskman, lockman and db of the service
+func MetricsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	metricsHandler.ServeHTTP(w, r)
+}
@@ -199,6 +199,7 @@ func (agent *SBaremetalAgent) startFileServer() {
 	http.Handle("/images/", http.StripPrefix("/images/", cacheFs))
 	isoFs := http.FileServer(httputils.Dir(o.Options.BootIsoPath))
 	http.Handle("/bootiso/", http.StripPrefix("/bootiso/", isoFs))
+	http.Handle(pxe.IPXE_SCRIPT_PREFIX, pxe.NewHTTPHandler(agent.Manager))
 	go func() {
 		if err := http.ListenAndServe(fmt.Sprintf("%s:%d", dhcpListenIp, o.Options.Port+1000), nil); err != nil {
 			panic(fmt.Sprintf("start http file server: %v", err))
@@ -0,0 +1,209 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+func (b *SBaremetalInstance) getRedfishApi(ctx context.Context) (redfish.IRedfishDriver, error) {
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return nil, errors.Wrap(httperrors.ErrNotSupported, "no valid redfish api")
+	}
+	return redfishApi, nil
+}
+
+func (b *SBaremetalInstance) GetBiosInfo(ctx context.Context) (redfish.SBiosInfo, error) {
+	redfishApi, err := b.getRedfishApi(ctx)
+	if err != nil {
+		return redfish.SBiosInfo{}, err
+	}
+	return redfishApi.GetBiosInfo(ctx)
+}
+
+func (b *SBaremetalInstance) SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) (redfish.SBiosInfo, error) {
+	redfishApi, err := b.getRedfishApi(ctx)
+	if err != nil {
+		return redfish.SBiosInfo{}, err
+	}
+	err = redfishApi.SetBiosAttributes(ctx, attrs)
+	if err != nil {
+		return redfish.SBiosInfo{}, errors.Wrap(err, "SetBiosAttributes")
+	}
+	return redfishApi.GetBiosInfo(ctx)
+}
+
+func (b *SBaremetalInstance) GetFirmwareInventory(ctx context.Context) ([]redfish.SFirmwareInfo, error) {
+	redfishApi, err := b.getRedfishApi(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return redfishApi.GetFirmwareInventory(ctx)
+}
+
+func (b *SBaremetalInstance) UpdateFirmware(ctx context.Context, imageUri string, protocol string, targets []string) error {
+	redfishApi, err := b.getRedfishApi(ctx)
+	if err != nil {
+		return err
+	}
+	return redfishApi.SimpleUpdate(ctx, imageUri, protocol, targets)
+}
+
+func (b *SBaremetalInstance) getRedfishEventTokenFilePath() string {
+	return filepath.Join(b.GetDir(), "redfish_event_token")
+}
+
+func (b *SBaremetalInstance) getRedfishEventSubscriptionFilePath() string {
+	return filepath.Join(b.GetDir(), "redfish_event_subscription")
+}
+
+// getRedfishEventToken returns the secret carried in Context of event
+// subscription, which is used to authenticate events pushed by BMC
+func (b *SBaremetalInstance) getRedfishEventToken() (string, error) {
+	path := b.getRedfishEventTokenFilePath()
+	content, err := ioutil.ReadFile(path)
+	if err == nil && len(content) > 0 {
+		return strings.TrimSpace(string(content)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "read %s", path)
+	}
+	token := stringutils.UUID4()
+	err = ioutil.WriteFile(path, []byte(token), 0600)
+	if err != nil {
+		return "", errors.Wrapf(err, "write %s", path)
+	}
+	return token, nil
+}
+
+func (b *SBaremetalInstance) getRedfishEventDestination() (string, error) {
+	accessIp, err := b.manager.Agent.GetAccessIP()
+	if err != nil {
+		return "", errors.Wrap(err, "GetAccessIP")
+	}
+	schema := "http"
+	if o.Options.EnableSsl {
+		schema = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d/baremetals/%s/redfish-events", schema, accessIp, o.Options.Port, b.GetId()), nil
+}
+
+func (b *SBaremetalInstance) isRedfishEventSubscribed() bool {
+	return o.Options.EnableRedfishEvents && fileutils2.Exists(b.getRedfishEventSubscriptionFilePath())
+}
+
+// SubscribeRedfishEvents makes sure there is exactly one event subscription
+// on BMC pointing to this agent
+func (b *SBaremetalInstance) SubscribeRedfishEvents(ctx context.Context) error {
+	redfishApi, err := b.getRedfishApi(ctx)
+	if err != nil {
+		return err
+	}
+	dest, err := b.getRedfishEventDestination()
+	if err != nil {
+		return errors.Wrap(err, "getRedfishEventDestination")
+	}
+	token, err := b.getRedfishEventToken()
+	if err != nil {
+		return errors.Wrap(err, "getRedfishEventToken")
+	}
+	subs, err := redfishApi.GetEventSubscriptions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetEventSubscriptions")
+	}
+	var found *redfish.SEventSubscription
+	for i := range subs {
+		if subs[i].Destination != dest {
+			continue
+		}
+		if found == nil && subs[i].Context == token {
+			found = &subs[i]
+			continue
+		}
+		// stale subscription of this agent, e.g. token regenerated
+		err := redfishApi.DeleteEventSubscription(ctx, subs[i].Path)
+		if err != nil {
+			log.Warningf("delete stale event subscription %s fail %s", subs[i].Path, err)
+		}
+	}
+	if found == nil {
+		sub, err := redfishApi.CreateEventSubscription(ctx, dest, nil, token)
+		if err != nil {
+			return errors.Wrap(err, "CreateEventSubscription")
+		}
+		found = &sub
+		log.Infof("Baremetal %s subscribed redfish events at %s", b.GetName(), sub.Path)
+	}
+	return fileutils2.FilePutContents(b.getRedfishEventSubscriptionFilePath(), jsonutils.Marshal(found).String(), false)
+}
+
+func (b *SBaremetalInstance) UnsubscribeRedfishEvents(ctx context.Context) error {
+	path := b.getRedfishEventSubscriptionFilePath()
+	if !fileutils2.Exists(path) {
+		return nil
+	}
+	defer os.Remove(path)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", path)
+	}
+	sub := redfish.SEventSubscription{}
+	subJson, err := jsonutils.Parse(content)
+	if err != nil {
+		return errors.Wrapf(err, "parse %s", path)
+	}
+	err = subJson.Unmarshal(&sub)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal subscription")
+	}
+	redfishApi, err := b.getRedfishApi(ctx)
+	if err != nil {
+		return err
+	}
+	return redfishApi.DeleteEventSubscription(ctx, sub.Path)
+}
+
+// HandleRedfishEvents verifies and forwards the events pushed by BMC to logger
+func (b *SBaremetalInstance) HandleRedfishEvents(ctx context.Context, body jsonutils.JSONObject) error {
+	subContext, events, err := redfish.ParseEventPayload(body)
+	if err != nil {
+		return httperrors.NewInputParameterError("invalid event payload: %s", err)
+	}
+	token, err := b.getRedfishEventToken()
+	if err != nil {
+		return errors.Wrap(err, "getRedfishEventToken")
+	}
+	if subtle.ConstantTimeCompare([]byte(subContext), []byte(token)) != 1 {
+		return httperrors.NewForbiddenError("event context mismatch")
+	}
+	return sendEvents(b, b.GetClientSession(), events)
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+	"yunion.io/x/pkg/util/stringutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+const (
+	// file in root directory of boot ISO describing how deploy ramdisk
+	// configures network and registers itself to the agent
+	BOOT_ISO_INFO_FILE = "bootinfo.json"
+)
+
+type sBootIsoNetConfig struct {
+	Mac     string `json:"mac"`
+	Addr    string `json:"addr"`
+	Mask    string `json:"mask"`
+	Gateway string `json:"gateway"`
+	Dns     string `json:"dns,omitempty"`
+	VlanId  int    `json:"vlan_id,omitempty"`
+}
+
+type sBootIsoInfo struct {
+	Id        string            `json:"id"`
+	Dest      string            `json:"dest"`
+	NotifyUrl string            `json:"url"`
+	Token     string            `json:"token"`
+	Network   sBootIsoNetConfig `json:"network"`
+}
+
+func (info sBootIsoInfo) toKernelArgs() []string {
+	args := []string{
+		fmt.Sprintf("token=%s", info.Token),
+		fmt.Sprintf("url=%s", info.NotifyUrl),
+		fmt.Sprintf("dest=%s", info.Dest),
+		fmt.Sprintf("gateway=%s", info.Network.Gateway),
+		fmt.Sprintf("addr=%s", info.Network.Addr),
+		fmt.Sprintf("mask=%s", info.Network.Mask),
+	}
+	if len(info.Network.Mac) > 0 {
+		args = append(args, fmt.Sprintf("mac=%s", info.Network.Mac))
+	}
+	if len(info.Network.Dns) > 0 {
+		args = append(args, fmt.Sprintf("dns=%s", info.Network.Dns))
+	}
+	if info.Network.VlanId > 1 {
+		args = append(args, fmt.Sprintf("vlan=%d", info.Network.VlanId))
+	}
+	return args
+}
+
+func (b *SBaremetalInstance) getBootIsoNetConfig() sBootIsoNetConfig {
+	conf := sBootIsoNetConfig{}
+	adminNic := b.GetAdminNic()
+	if adminNic != nil {
+		conf.Mac = adminNic.Mac
+		conf.Addr = adminNic.IpAddr
+		conf.Mask = adminNic.GetNetMask()
+		conf.Gateway = adminNic.Gateway
+		conf.Dns = adminNic.Dns
+		if len(adminNic.NetId) > 0 {
+			netObj, err := modules.Networks.Get(b.GetClientSession(), adminNic.NetId, nil)
+			if err != nil {
+				log.Warningf("get network %s of admin nic fail %s", adminNic.NetId, err)
+			} else {
+				vlanId, _ := netObj.Int("vlan_id")
+				conf.VlanId = int(vlanId)
+			}
+		}
+	} else {
+		accessIp := b.GetAccessIp()
+		accessNet, _ := b.findAccessNetwork(accessIp)
+		if accessNet != nil {
+			conf.Addr = accessIp
+			conf.Mask = netutils.Masklen2Mask(int8(accessNet.GuestIpMask)).String()
+			conf.Gateway = accessNet.GuestGateway
+			conf.VlanId = accessNet.VlanId
+		}
+	}
+	return conf
+}
+
+func (b *SBaremetalInstance) getBootIsoInfo() sBootIsoInfo {
+	info := sBootIsoInfo{
+		Id:        b.GetId(),
+		NotifyUrl: b.GetNotifyUrl(),
+		Network:   b.getBootIsoNetConfig(),
+	}
+	serverIP, err := b.manager.Agent.GetDHCPServerIP()
+	if err != nil {
+		log.Errorf("GetDHCPServerIP fail %s", err)
+	} else {
+		info.Dest = serverIP.String()
+	}
+	info.Token, err = b.getRegistrationToken()
+	if err != nil {
+		log.Errorf("getRegistrationToken fail %s", err)
+	}
+	return info
+}
+
+func (b *SBaremetalInstance) getRegistrationTokenFilePath() string {
+	return filepath.Join(b.GetDir(), "registration_token")
+}
+
+func (b *SBaremetalInstance) getRegistrationToken() (string, error) {
+	content, err := ioutil.ReadFile(b.getRegistrationTokenFilePath())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// renewRegistrationToken generates a new token for every boot ISO, so that
+// a leaked ISO can not be used to register after the machine is deployed
+func (b *SBaremetalInstance) renewRegistrationToken() (string, error) {
+	token := stringutils.UUID4()
+	err := ioutil.WriteFile(b.getRegistrationTokenFilePath(), []byte(token), 0600)
+	if err != nil {
+		return "", errors.Wrap(err, "write registration token")
+	}
+	return token, nil
+}
+
+// ensureRegistrationToken returns the current registration token, a new one
+// is generated if there is none, e.g. network boot without boot ISO
+func (b *SBaremetalInstance) ensureRegistrationToken() (string, error) {
+	token, err := b.getRegistrationToken()
+	if err == nil && len(token) > 0 {
+		return token, nil
+	}
+	return b.renewRegistrationToken()
+}
+
+func (b *SBaremetalInstance) clearRegistrationToken() {
+	path := b.getRegistrationTokenFilePath()
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warningf("remove %s fail %s", path, err)
+	}
+}
+
+func (b *SBaremetalInstance) VerifyRegistrationToken(token string) bool {
+	if len(token) == 0 {
+		return false
+	}
+	expect, err := b.getRegistrationToken()
+	if err != nil || len(expect) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expect)) == 1
+}
@@ -167,8 +167,12 @@ func fetchLogs(baremetal *SBaremetalInstance, ctx context.Context, logType strin
 			}
 		}
 	}
-	for i := offset; i < len(logs); i += 1 {
-		eventData := eventToJson(logs[i])
+	return sendEvents(baremetal, s, logs[offset:])
+}
+
+func sendEvents(baremetal *SBaremetalInstance, s *mcclient.ClientSession, events []redfish.SEvent) error {
+	for i := range events {
+		eventData := eventToJson(events[i])
 		eventData.Add(jsonutils.NewString(baremetal.GetId()), "host_id")
 		eventData.Add(jsonutils.NewString(baremetal.GetName()), "host_name")
 		eventData.Add(jsonutils.NewString(baremetal.GetIPMINicIPAddr()), "ipmi_ip")
@@ -185,6 +189,11 @@ func (job *SLogFetchJob) Do(ctx context.Context, now time.Time) error {
 	if !job.baremetal.isRedfishCapable() {
 		return nil
 	}
+	if job.baremetal.isRedfishEventSubscribed() {
+		// events are pushed by BMC, no need to poll
+		job.lastTime = now
+		return nil
+	}
 	err := fetchLogs(job.baremetal, ctx, redfish.EVENT_TYPE_SYSTEM)
 	if err != nil {
 		return errors.Wrap(err, "fetchLogs api.EVENT_TYPE_SYSTEM")
@@ -198,6 +207,35 @@ func (job *SLogFetchJob) Do(ctx context.Context, now time.Time) error {
 	return nil
 }
 
+type SEventSubscribeJob struct {
+	SBaseBaremetalCronJob
+}
+
+func NewEventSubscribeJob(baremetal *SBaremetalInstance, interval time.Duration) IBaremetalCronJob {
+	return &SEventSubscribeJob{
+		SBaseBaremetalCronJob: SBaseBaremetalCronJob{
+			baremetal: baremetal,
+			interval:  interval,
+		},
+	}
+}
+
+func (job *SEventSubscribeJob) Name() string {
+	return "EventSubscribeJob"
+}
+
+func (job *SEventSubscribeJob) Do(ctx context.Context, now time.Time) error {
+	if !o.Options.EnableRedfishEvents || !job.baremetal.isRedfishCapable() {
+		return nil
+	}
+	err := job.baremetal.SubscribeRedfishEvents(ctx)
+	if err != nil {
+		return errors.Wrap(err, "SubscribeRedfishEvents")
+	}
+	job.lastTime = now
+	return nil
+}
+
 type SSendMetricsJob struct {
 	SBaseBaremetalCronJob
 }
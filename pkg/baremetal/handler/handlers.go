@@ -23,6 +23,8 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
+	identityapi "yunion.io/x/onecloud/pkg/apis/identity"
+	"yunion.io/x/onecloud/pkg/appctx"
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/baremetal"
 	baremetalstatus "yunion.io/x/onecloud/pkg/baremetal/status"
@@ -53,6 +55,24 @@ func AddHandler2(app *appsrv.Application, method string, prefix string,
 	return app.AddHandler2(method, prefix, auth.Authenticate(handler), metadata, name, tags)
 }
 
+// AddRegistrationTokenHandler accepts either a keystone token or the one-time
+// registration token baked into the baremetal boot ISO
+func AddRegistrationTokenHandler(app *appsrv.Application, method string, prefix string,
+	handler func(context.Context, http.ResponseWriter, *http.Request)) *appsrv.SHandlerInfo {
+	authHandler := auth.Authenticate(handler)
+	return app.AddHandler(method, prefix, func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		tokenStr := r.Header.Get(identityapi.AUTH_TOKEN_HEADER)
+		bmId := appctx.AppContextParams(ctx)[PARAMS_BMID_KEY]
+		bm := NewContext(ctx, w, r).GetBaremetalManager().GetBaremetalById(bmId)
+		if bm != nil && bm.VerifyRegistrationToken(tokenStr) {
+			ctx = context.WithValue(ctx, appctx.APP_CONTEXT_KEY_AUTH_TOKEN, auth.AdminCredential())
+			handler(ctx, w, r)
+			return
+		}
+		authHandler(ctx, w, r)
+	})
+}
+
 func customizeHandlerInfo(info *appsrv.SHandlerInfo) {
 	if info.GetName(nil) == "baremetal-register" {
 		info.SetProcessTimeout(time.Second * 300).SetWorkerManager(registerWorkMan)
@@ -61,7 +81,7 @@ func customizeHandlerInfo(info *appsrv.SHandlerInfo) {
 
 func initBaremetalsHandler(app *appsrv.Application) {
 	// baremetal actions handler
-	AddHandler(app, "GET", bmActionPrefix("notify"), bmObjMiddleware(handleBaremetalNotify))
+	AddRegistrationTokenHandler(app, "GET", bmActionPrefix("notify"), bmObjMiddleware(handleBaremetalNotify))
 	AddHandler(app, "POST", bmActionPrefix("maintenance"), bmObjMiddleware(handleBaremetalMaintenance))
 	AddHandler(app, "POST", bmActionPrefix("unmaintenance"), bmObjMiddleware(handleBaremetalUnmaintenance))
 	AddHandler(app, "POST", bmActionPrefix("delete"), bmObjMiddlewareWithFetch(handleBaremetalDelete, false))
@@ -73,6 +93,12 @@ func initBaremetalsHandler(app *appsrv.Application) {
 	AddHandler(app, "POST", bmActionPrefix("ipmi-probe"), bmObjMiddleware(handleBaremetalIpmiProbe))
 	AddHandler(app, "POST", bmActionPrefix("cdrom"), bmObjMiddleware(handleBaremetalCdromTask))
 	AddHandler(app, "POST", bmActionPrefix("jnlp"), bmObjMiddleware(handleBaremetalJnlpTask))
+	AddHandler(app, "POST", bmActionPrefix("bios"), bmObjMiddleware(handleBaremetalBios))
+	AddHandler(app, "POST", bmActionPrefix("set-bios"), bmObjMiddleware(handleBaremetalSetBios))
+	AddHandler(app, "POST", bmActionPrefix("firmwares"), bmObjMiddleware(handleBaremetalFirmwares))
+	AddHandler(app, "POST", bmActionPrefix("update-firmware"), bmObjMiddleware(handleBaremetalUpdateFirmware))
+	// events pushed by BMC carry no keystone token, they are verified by subscription context
+	app.AddHandler("POST", bmActionPrefix("redfish-events"), bmObjMiddlewareWithFetch(handleBaremetalRedfishEvents, false))
 
 	// server actions handler
 	AddHandler(app, "POST", srvActionPrefix("create"), srvClassMiddleware(handleServerCreate))
@@ -173,6 +199,66 @@ func handleBaremetalJnlpTask(ctx *Context, bm *baremetal.SBaremetalInstance) {
 	ctx.ResponseJson(result)
 }
 
+func handleBaremetalBios(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	bios, err := bm.GetBiosInfo(ctx)
+	if err != nil {
+		ctx.ResponseError(errors.Wrap(err, "GetBiosInfo"))
+		return
+	}
+	ctx.ResponseJson(jsonutils.Marshal(bios))
+}
+
+func handleBaremetalSetBios(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	attrs, err := ctx.Data().Get("attributes")
+	if err != nil {
+		ctx.ResponseError(httperrors.NewMissingParameterError("attributes"))
+		return
+	}
+	bios, err := bm.SetBiosAttributes(ctx, attrs)
+	if err != nil {
+		ctx.ResponseError(errors.Wrap(err, "SetBiosAttributes"))
+		return
+	}
+	ctx.ResponseJson(jsonutils.Marshal(bios))
+}
+
+func handleBaremetalFirmwares(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	fws, err := bm.GetFirmwareInventory(ctx)
+	if err != nil {
+		ctx.ResponseError(errors.Wrap(err, "GetFirmwareInventory"))
+		return
+	}
+	result := jsonutils.NewDict()
+	result.Add(jsonutils.Marshal(fws), "firmwares")
+	ctx.ResponseJson(result)
+}
+
+func handleBaremetalUpdateFirmware(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	imageUri, err := ctx.Data().GetString("image_uri")
+	if err != nil {
+		ctx.ResponseError(httperrors.NewMissingParameterError("image_uri"))
+		return
+	}
+	protocol, _ := ctx.Data().GetString("protocol")
+	targets, _ := jsonutils.GetStringArray(ctx.Data(), "targets")
+	err = bm.UpdateFirmware(ctx, imageUri, protocol, targets)
+	if err != nil {
+		ctx.ResponseError(errors.Wrap(err, "UpdateFirmware"))
+		return
+	}
+	ctx.ResponseOk()
+}
+
+func handleBaremetalRedfishEvents(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	err := bm.HandleRedfishEvents(ctx, ctx.Data())
+	if err != nil {
+		log.Errorf("Baremetal %s handle redfish events fail: %s", bm.GetName(), err)
+		ctx.ResponseError(err)
+		return
+	}
+	ctx.ResponseOk()
+}
+
 func handleServerCreate(ctx *Context, bm *baremetal.SBaremetalInstance) {
 	err := bm.StartServerCreateTask(ctx.UserCred(), ctx.TaskId(), ctx.Data())
 	if err != nil {
@@ -32,7 +32,6 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
-	"yunion.io/x/pkg/util/netutils"
 	"yunion.io/x/pkg/util/regutils"
 	"yunion.io/x/pkg/util/seclib"
 	"yunion.io/x/pkg/util/sets"
@@ -524,6 +523,7 @@ func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*S
 		NewStatusProbeJob(bm, time.Duration(o.Options.StatusProbeIntervalSeconds)*time.Second),
 		NewLogFetchJob(bm, time.Duration(o.Options.LogFetchIntervalSeconds)*time.Second),
 		NewSendMetricsJob(bm, time.Duration(o.Options.SendMetricsIntervalSeconds)*time.Second),
+		NewEventSubscribeJob(bm, time.Duration(o.Options.RedfishEventSubscribeIntervalSeconds)*time.Second),
 	}
 	err := os.MkdirAll(bm.GetDir(), 0755)
 	if err != nil {
@@ -970,11 +970,15 @@ func (b *SBaremetalInstance) GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.Respo
 	if nic == nil {
 		return nil, fmt.Errorf("GetNicDHCPConfig no nic found by mac: %s", cliMac)
 	}
-	return b.getDHCPConfig(nic, hostname, false, 0)
+	return b.getDHCPConfig(nic, hostname, false, 0, pxe.BootClientPXE)
 }
 
-func (b *SBaremetalInstance) GetPXEDHCPConfig(arch uint16) (*dhcp.ResponseConfig, error) {
-	return b.getDHCPConfig(b.GetAdminNic(), "", true, arch)
+func (b *SBaremetalInstance) GetPXEDHCPConfig(arch uint16, client pxe.BootClient) (*dhcp.ResponseConfig, error) {
+	if !b.NeedPXEBoot() {
+		// machine is rebooting to local disk, ssh config of deploy ramdisk is stale
+		b.ClearSSHConfig()
+	}
+	return b.getDHCPConfig(b.GetAdminNic(), "", true, arch, client)
 }
 
 func (b *SBaremetalInstance) getDHCPConfig(
@@ -982,6 +986,7 @@ func (b *SBaremetalInstance) getDHCPConfig(
 	hostName string,
 	isPxe bool,
 	arch uint16,
+	client pxe.BootClient,
 ) (*dhcp.ResponseConfig, error) {
 	if hostName == "" {
 		hostName = b.GetName()
@@ -990,7 +995,7 @@ func (b *SBaremetalInstance) getDHCPConfig(
 	if err != nil {
 		return nil, err
 	}
-	return GetNicDHCPConfig(nic, serverIP.String(), hostName, isPxe, arch)
+	return GetNicDHCPConfig(nic, serverIP.String(), hostName, isPxe, arch, client)
 }
 
 func (b *SBaremetalInstance) GetNotifyUrl() string {
@@ -1060,6 +1065,37 @@ func (b *SBaremetalInstance) findAccessNetwork(accessIp string) (*types.SNetwork
 	return &network, err
 }
 
+// getNetbootToken returns the per-machine registration token for deploy
+// ramdisk booted over network, boot files are served without authentication
+// so that admin token must not be carried
+func (b *SBaremetalInstance) getNetbootToken() string {
+	token, err := b.ensureRegistrationToken()
+	if err != nil {
+		log.Errorf("ensureRegistrationToken fail %s", err)
+	}
+	return token
+}
+
+// GetIPXEScript boots deploy ramdisk over HTTP, or exits to local disk
+func (b *SBaremetalInstance) GetIPXEScript() string {
+	resp := "#!ipxe\n"
+	if b.NeedPXEBoot() {
+		args := []string{
+			"initrd=initramfs",
+			fmt.Sprintf("token=%s", b.getNetbootToken()),
+			fmt.Sprintf("url=%s", b.GetNotifyUrl()),
+			fmt.Sprintf("bootmod=%s", api.BOOT_MODE_PXE),
+		}
+		resp += fmt.Sprintf("kernel %s %s\n", b.getTftpFileUrl("kernel"), strings.Join(args, " "))
+		resp += fmt.Sprintf("initrd --name initramfs %s\n", b.getTftpFileUrl("initramfs"))
+		resp += "boot\n"
+	} else {
+		// return to firmware, which boots from next device
+		resp += "exit\n"
+	}
+	return resp
+}
+
 func (b *SBaremetalInstance) getSyslinuxConf(isTftp bool) string {
 	resp := `DEFAULT start
 serial 1 115200
@@ -1079,33 +1115,16 @@ LABEL start
 		resp += fmt.Sprintf("    kernel %s\n", kernel)
 		args := []string{
 			fmt.Sprintf("initrd=%s", initramfs),
-			fmt.Sprintf("token=%s", auth.GetTokenString()),
-			fmt.Sprintf("url=%s", b.GetNotifyUrl()),
 		}
 		bootmode := api.BOOT_MODE_PXE
-		if !isTftp {
-			adminNic := b.GetAdminNic()
-			var addr string
-			var mask string
-			var gateway string
-			if adminNic != nil {
-				addr = adminNic.IpAddr
-				mask = adminNic.GetNetMask()
-				gateway = adminNic.Gateway
-			} else {
-				accessIp := b.GetAccessIp()
-				accessNet, _ := b.findAccessNetwork(accessIp)
-				if accessNet != nil {
-					addr = accessIp
-					mask = netutils.Masklen2Mask(int8(accessNet.GuestIpMask)).String()
-					gateway = accessNet.GuestGateway
-				}
-			}
-			serverIP, _ := b.manager.Agent.GetDHCPServerIP()
-			args = append(args, fmt.Sprintf("dest=%s", serverIP))
-			args = append(args, fmt.Sprintf("gateway=%s", gateway))
-			args = append(args, fmt.Sprintf("addr=%s", addr))
-			args = append(args, fmt.Sprintf("mask=%s", mask))
+		if isTftp {
+			args = append(args, fmt.Sprintf("token=%s", b.getNetbootToken()))
+			args = append(args, fmt.Sprintf("url=%s", b.GetNotifyUrl()))
+		} else {
+			// the ISO is downloaded by BMC without authentication, so only
+			// carry a per-machine registration token instead of admin token
+			info := b.getBootIsoInfo()
+			args = append(args, info.toKernelArgs()...)
 			bootmode = api.BOOT_MODE_ISO
 		}
 		args = append(args, fmt.Sprintf("bootmod=%s", bootmode))
@@ -1113,7 +1132,6 @@ LABEL start
 	} else {
 		resp += fmt.Sprintf("    COM32 %s\n", b.getSyslinuxPath("chain.c32", isTftp))
 		resp += "    APPEND hd0 0\n"
-		b.ClearSSHConfig()
 	}
 	// log.Debugf("[SysLinux config]: \n%s", resp)
 	return resp
@@ -1514,6 +1532,10 @@ func (b *SBaremetalInstance) DelayedRemove(_ jsonutils.JSONObject) (jsonutils.JS
 }
 
 func (b *SBaremetalInstance) remove() {
+	err := b.UnsubscribeRedfishEvents(context.Background())
+	if err != nil {
+		log.Warningf("Baremetal %s unsubscribe redfish events fail: %s", b.GetName(), err)
+	}
 	b.manager.CleanBaremetal(b.GetId())
 	b.manager = nil
 	b.desc = nil
@@ -1734,6 +1756,9 @@ func (b *SBaremetalInstance) EnablePxeBoot() bool {
 
 func (b *SBaremetalInstance) GenerateBootISO() error {
 	// precheck
+	if !o.Options.EnableVirtualMediaBoot {
+		return errors.Error("GenerateBootISO: virtual media boot disabled")
+	}
 	conf := b.GetRawIPMIConfig()
 	if !conf.Verified {
 		return errors.Error("GenerateBootISO: IPMI not supported")
@@ -1795,11 +1820,22 @@ func (b *SBaremetalInstance) GenerateBootISO() error {
 			return errors.Wrapf(err, "cp %s", f)
 		}
 	}
+	_, err = b.renewRegistrationToken()
+	if err != nil {
+		return errors.Wrap(err, "renewRegistrationToken")
+	}
 	cfgCont := b.getIsolinuxConf()
 	err = fileutils2.FilePutContents(filepath.Join(isoLinDir, "isolinux.cfg"), cfgCont, false)
 	if err != nil {
 		return errors.Wrap(err, "fileutils.FilePutContent")
 	}
+	// network config and registration token for deploy ramdisk, in case
+	// kernel command line is not available
+	bootInfo := jsonutils.Marshal(b.getBootIsoInfo()).PrettyString()
+	err = fileutils2.FilePutContents(filepath.Join(isoDir, BOOT_ISO_INFO_FILE), bootInfo, false)
+	if err != nil {
+		return errors.Wrapf(err, "write %s", BOOT_ISO_INFO_FILE)
+	}
 	args := []string{
 		"-quiet",
 		"-J", "-R",
@@ -1830,6 +1866,7 @@ func (b *SBaremetalInstance) clearBootIso() error {
 	if redfishApi == nil {
 		return errors.Wrap(httperrors.ErrNotSupported, "no valid redfishApi")
 	}
+	b.clearRegistrationToken()
 	err := redfish.UmountVirtualCdrom(ctx, redfishApi)
 	if err != nil {
 		return errors.Wrap(err, "redfish.UmountVirtualCdrom")
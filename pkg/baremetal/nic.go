@@ -24,16 +24,24 @@ import (
 	"yunion.io/x/pkg/util/netutils"
 
 	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/baremetal/pxe"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/util/dhcp"
 )
 
+const (
+	// iPXE binaries loaded over TFTP, then chain to HTTP for the rest
+	IPXE_BIOS_BOOT_FILE = "undionly.kpxe"
+	IPXE_EFI_BOOT_FILE  = "ipxe.efi"
+)
+
 func GetNicDHCPConfig(
 	n *types.SNic,
 	serverIP string,
 	hostName string,
 	isPxe bool,
 	arch uint16,
+	client pxe.BootClient,
 ) (*dhcp.ResponseConfig, error) {
 	if n == nil {
 		return nil, fmt.Errorf("Nic is nil")
@@ -69,35 +77,78 @@ func GetNicDHCPConfig(
 	}
 
 	if isPxe {
-		conf.BootServer = serverIP
-		switch arch {
-		case 7, 9:
-			conf.BootFile = "bootx64.efi"
-		case 6:
-			conf.BootFile = "bootia32.efi"
-		default:
-			//if o.Options.EnableTftpHttpDownload {
-			// bootFile = "lpxelinux.0"
-			//}else {
-			// bootFile := "pxelinux.0"
-			//}
-			conf.BootFile = "lpxelinux.0"
-		}
-		pxePath := filepath.Join(o.Options.TftpRoot, conf.BootFile)
-		if f, err := os.Open(pxePath); err != nil {
+		err := setPXEBootFile(conf, n, serverIP, arch, client)
+		if err != nil {
 			return nil, err
-		} else {
-			if info, err := f.Stat(); err != nil {
-				return nil, err
-			} else {
-				pxeSize := info.Size()
-				pxeBlk := pxeSize / 512
-				if pxeSize > pxeBlk*512 {
-					pxeBlk += 1
-				}
-				conf.BootBlock = uint16(pxeBlk)
-			}
 		}
 	}
 	return conf, nil
 }
+
+func getHttpFileServerUrl(serverIP string) string {
+	return fmt.Sprintf("http://%s:%d", serverIP, o.Options.Port+1000)
+}
+
+func getTftpBootBlock(bootFile string) (uint16, error) {
+	info, err := os.Stat(filepath.Join(o.Options.TftpRoot, bootFile))
+	if err != nil {
+		return 0, err
+	}
+	pxeSize := info.Size()
+	pxeBlk := pxeSize / 512
+	if pxeSize > pxeBlk*512 {
+		pxeBlk += 1
+	}
+	return uint16(pxeBlk), nil
+}
+
+func isTftpFileExists(bootFile string) bool {
+	_, err := os.Stat(filepath.Join(o.Options.TftpRoot, bootFile))
+	return err == nil
+}
+
+func setPXEBootFile(conf *dhcp.ResponseConfig, n *types.SNic, serverIP string, arch uint16, client pxe.BootClient) error {
+	switch client {
+	case pxe.BootClientIPXE:
+		// iPXE fetches per-machine script over HTTP
+		conf.BootFile = fmt.Sprintf("%s%s%s", getHttpFileServerUrl(serverIP), pxe.IPXE_SCRIPT_PREFIX, n.Mac)
+		return nil
+	case pxe.BootClientHTTP:
+		if arch != 16 {
+			return fmt.Errorf("unsupported UEFI HTTP Boot client arch %d", arch)
+		}
+		if !isTftpFileExists(IPXE_EFI_BOOT_FILE) {
+			return fmt.Errorf("UEFI HTTP Boot requires %s in %s", IPXE_EFI_BOOT_FILE, o.Options.TftpRoot)
+		}
+		conf.BootFile = fmt.Sprintf("%s/tftp/%s", getHttpFileServerUrl(serverIP), IPXE_EFI_BOOT_FILE)
+		conf.VendorClassId = pxe.HTTP_BOOT_VENDOR_CLASS
+		return nil
+	}
+
+	conf.BootServer = serverIP
+	switch arch {
+	case 7, 9:
+		conf.BootFile = "bootx64.efi"
+		if o.Options.EnableIpxeChainload && isTftpFileExists(IPXE_EFI_BOOT_FILE) {
+			conf.BootFile = IPXE_EFI_BOOT_FILE
+		}
+	case 6:
+		conf.BootFile = "bootia32.efi"
+	default:
+		//if o.Options.EnableTftpHttpDownload {
+		// bootFile = "lpxelinux.0"
+		//}else {
+		// bootFile := "pxelinux.0"
+		//}
+		conf.BootFile = "lpxelinux.0"
+		if o.Options.EnableIpxeChainload && isTftpFileExists(IPXE_BIOS_BOOT_FILE) {
+			conf.BootFile = IPXE_BIOS_BOOT_FILE
+		}
+	}
+	blk, err := getTftpBootBlock(conf.BootFile)
+	if err != nil {
+		return err
+	}
+	conf.BootBlock = blk
+	return nil
+}
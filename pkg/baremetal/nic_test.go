@@ -0,0 +1,85 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/baremetal/pxe"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/util/dhcp"
+)
+
+func newPXEPacket(arch uint16, vendorCls string) dhcp.Packet {
+	hwAddr, _ := net.ParseMAC("52:54:00:12:34:56")
+	archBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(archBytes, arch)
+	opts := []dhcp.Option{
+		{Code: dhcp.OptionClientArchitecture, Value: archBytes},
+		{Code: dhcp.OptionClientNetworkInterfaceIdentifier, Value: []byte{1, 3, 0}},
+		{Code: dhcp.OptionVendorClassIdentifier, Value: []byte(vendorCls)},
+	}
+	return dhcp.RequestPacket(dhcp.Discover, hwAddr, nil, []byte{1, 2, 3, 4}, true, opts)
+}
+
+func TestSetPXEBootFileHTTPBoot(t *testing.T) {
+	tftpRoot, err := ioutil.TempDir("", "tftproot")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tftpRoot)
+	savedRoot, savedPort := o.Options.TftpRoot, o.Options.Port
+	defer func() { o.Options.TftpRoot, o.Options.Port = savedRoot, savedPort }()
+	o.Options.TftpRoot = tftpRoot
+	o.Options.Port = 8885
+
+	arch, client, err := pxe.GetBootClient(newPXEPacket(16, "HTTPClient:Arch:00016:UNDI:003001"))
+	if err != nil {
+		t.Fatalf("GetBootClient: %v", err)
+	}
+	if arch != 16 || client != pxe.BootClientHTTP {
+		t.Fatalf("want arch 16 %s, got arch %d %s", pxe.BootClientHTTP, arch, client)
+	}
+
+	nic := &types.SNic{Mac: "52:54:00:12:34:56"}
+	conf := &dhcp.ResponseConfig{}
+	if err := setPXEBootFile(conf, nic, "10.168.222.2", arch, client); err == nil {
+		t.Errorf("HTTP Boot without %s should fail", IPXE_EFI_BOOT_FILE)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(tftpRoot, IPXE_EFI_BOOT_FILE), []byte("efi"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	conf = &dhcp.ResponseConfig{}
+	if err := setPXEBootFile(conf, nic, "10.168.222.2", arch, client); err != nil {
+		t.Fatalf("setPXEBootFile: %v", err)
+	}
+	if want := "http://10.168.222.2:9885/tftp/" + IPXE_EFI_BOOT_FILE; conf.BootFile != want {
+		t.Errorf("boot file got %q want %q", conf.BootFile, want)
+	}
+	if conf.VendorClassId != pxe.HTTP_BOOT_VENDOR_CLASS {
+		t.Errorf("vendor class got %q want %q", conf.VendorClassId, pxe.HTTP_BOOT_VENDOR_CLASS)
+	}
+	if len(conf.BootServer) > 0 {
+		t.Errorf("HTTP Boot should not set TFTP boot server, got %s", conf.BootServer)
+	}
+}
@@ -47,9 +47,16 @@ type BaremetalOptions struct {
 	EnablePxeBoot bool   `help:"Enable DHCP PXE boot" default:"true"`
 	BootIsoPath   string `help:"iso boot image path"`
 
+	EnableIpxeChainload bool `help:"Load iPXE over TFTP if undionly.kpxe or ipxe.efi exists in tftp root, then fetch kernel and initramfs over HTTP" default:"true"`
+
+	EnableVirtualMediaBoot bool `help:"Boot baremetal from a per-machine ISO mounted through BMC virtual media, fallback to PXE boot on failure" default:"true"`
+
 	StatusProbeIntervalSeconds int `help:"interval to probe baremetal status, default is 60 seconds" default:"60"`
 	LogFetchIntervalSeconds    int `help:"interval to fetch baremetal log, default is 900 seconds" default:"900"`
 	SendMetricsIntervalSeconds int `help:"interval to send baremetal metrics, default is 300 seconds" default:"300"`
+
+	EnableRedfishEvents                  bool `help:"Subscribe to Redfish EventService of BMC and receive pushed events instead of polling logs" default:"false"`
+	RedfishEventSubscribeIntervalSeconds int  `help:"interval to check Redfish event subscription, default is 3600 seconds" default:"3600"`
 }
 
 var (
@@ -56,6 +56,7 @@ type dhcpRequest struct {
 	RelayAddr             net.IP           // IP address of DHCP relay agent
 	Options               dhcp.Options     // dhcp packet options
 	VendorClassId         string
+	UserClass             string
 	ClientArch            uint16
 	NetworkInterfaceIdent NetworkInterfaceIdent
 	ClientGuid            string
@@ -105,6 +106,7 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 
 	var (
 		vendorClsId string
+		userCls     string
 		cliArch     uint16
 		err         error
 		netIfIdent  NetworkInterfaceIdent
@@ -115,6 +117,8 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 		switch optCode {
 		case dhcp.OptionVendorClassIdentifier:
 			vendorClsId, err = req.Options.String(optCode)
+		case dhcp.OptionUserClass:
+			userCls, err = req.Options.String(optCode)
 		case dhcp.OptionClientArchitecture:
 			cliArch, err = req.Options.Uint16(optCode)
 		case dhcp.OptionClientNetworkInterfaceIdentifier:
@@ -152,6 +156,7 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 		cliUUIDStr = formatUuidString([]byte(cliGuid)[1:])
 	}
 	req.VendorClassId = vendorClsId
+	req.UserClass = userCls
 	req.ClientArch = cliArch
 	req.NetworkInterfaceIdent = netIfIdent
 	req.ClientGuid = cliUUIDStr
@@ -208,7 +213,9 @@ func (req *dhcpRequest) fetchConfig(session *mcclient.ClientSession) (*dhcp.Resp
 		// always response PXE request
 		// let bootloader decide boot local or remote
 		// if req.baremetalInstance.NeedPXEBoot() {
-		conf, err := req.baremetalInstance.GetPXEDHCPConfig(req.ClientArch)
+		bootClient := req.getBootClient()
+		log.Infof("DHCP boot client of %s is %s, arch %d", req.ClientMac, bootClient, req.ClientArch)
+		conf, err := req.baremetalInstance.GetPXEDHCPConfig(req.ClientArch, bootClient)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "req.baremetalInstance.GetPXEDHCPConfig")
 		}
@@ -405,6 +412,27 @@ func (req *dhcpRequest) doInitBaremetalAdminNetif(desc jsonutils.JSONObject) err
 	return err
 }
 
+// GetBootClient returns client arch and boot client of a PXE DHCP packet
+func GetBootClient(pkt dhcp.Packet) (uint16, BootClient, error) {
+	req, err := (&DHCPHandler{}).newRequest(pkt, nil)
+	if err != nil {
+		return 0, BootClientPXE, err
+	}
+	return req.ClientArch, req.getBootClient(), nil
+}
+
+// getBootClient tells iPXE apart from the firmware which loaded it, as
+// iPXE keeps sending PXEClient vendor class
+func (req *dhcpRequest) getBootClient() BootClient {
+	if strings.Contains(req.UserClass, IPXE_USER_CLASS) {
+		return BootClientIPXE
+	}
+	if strings.HasPrefix(req.VendorClassId, HTTP_BOOT_VENDOR_CLASS) {
+		return BootClientHTTP
+	}
+	return BootClientPXE
+}
+
 func (req *dhcpRequest) isPXERequest() bool {
 	pkt := req.packet
 	return dhcp.IsPXERequest(pkt)
@@ -462,6 +490,13 @@ func (s *Server) validateDHCP(pkt dhcp.Packet) (Machine, Firmware, error) {
 		// EFI x86-64
 		mach.Arch = ArchX64
 		fwtype = FirmwareEFIBC
+	case 15:
+		// x86 UEFI HTTP Boot, no ia32 iPXE binary to serve
+		return mach, 0, errors.Error("unsupported client firmware type '15', x86 UEFI HTTP Boot")
+	case 16:
+		// x64 UEFI HTTP Boot
+		mach.Arch = ArchX64
+		fwtype = FirmwareEFI64
 	default:
 		return mach, 0, fmt.Errorf("unsupported client firmware type '%d'", fwtype)
 	}
@@ -0,0 +1,221 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/dhcp"
+)
+
+// newClientPacket builds DHCPDISCOVER as sent by OVMF firmware or iPXE
+// through a DHCP relay
+func newClientPacket(mac string, arch uint16, vendorCls string, userCls string) dhcp.Packet {
+	hwAddr, _ := net.ParseMAC(mac)
+	archBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(archBytes, arch)
+	opts := []dhcp.Option{
+		{Code: dhcp.OptionClientArchitecture, Value: archBytes},
+		{Code: dhcp.OptionClientNetworkInterfaceIdentifier, Value: []byte{1, 3, 0}},
+		{Code: dhcp.OptionVendorClassIdentifier, Value: []byte(vendorCls)},
+	}
+	if len(userCls) > 0 {
+		opts = append(opts, dhcp.Option{Code: dhcp.OptionUserClass, Value: []byte(userCls)})
+	}
+	pkt := dhcp.RequestPacket(dhcp.Discover, hwAddr, nil, []byte{1, 2, 3, 4}, true, opts)
+	pkt.SetGIAddr(net.ParseIP("10.168.222.1"))
+	return pkt
+}
+
+func TestGetBootClient(t *testing.T) {
+	cases := []struct {
+		name      string
+		arch      uint16
+		vendorCls string
+		userCls   string
+		want      BootClient
+	}{
+		{
+			name:      "OVMF PXE",
+			arch:      7,
+			vendorCls: "PXEClient:Arch:00007:UNDI:003000",
+			want:      BootClientPXE,
+		},
+		{
+			name:      "OVMF HTTP Boot",
+			arch:      16,
+			vendorCls: "HTTPClient:Arch:00016:UNDI:003001",
+			want:      BootClientHTTP,
+		},
+		{
+			name:      "iPXE chainloaded",
+			arch:      7,
+			vendorCls: "PXEClient:Arch:00007:UNDI:003010",
+			userCls:   "iPXE",
+			want:      BootClientIPXE,
+		},
+		{
+			name:      "BIOS PXE",
+			arch:      0,
+			vendorCls: "PXEClient:Arch:00000:UNDI:002001",
+			want:      BootClientPXE,
+		},
+	}
+	h := &DHCPHandler{}
+	for _, c := range cases {
+		pkt := newClientPacket("52:54:00:12:34:56", c.arch, c.vendorCls, c.userCls)
+		if !dhcp.IsPXERequest(pkt) {
+			t.Errorf("%s: should be PXE request", c.name)
+			continue
+		}
+		req, err := h.newRequest(pkt, nil)
+		if err != nil {
+			t.Errorf("%s: newRequest: %v", c.name, err)
+			continue
+		}
+		if req.ClientArch != c.arch {
+			t.Errorf("%s: arch got %d want %d", c.name, req.ClientArch, c.arch)
+		}
+		if got := req.getBootClient(); got != c.want {
+			t.Errorf("%s: boot client got %s want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestHTTPBootReply(t *testing.T) {
+	pkt := newClientPacket("52:54:00:12:34:56", 16, "HTTPClient:Arch:00016:UNDI:003001", "")
+	conf := &dhcp.ResponseConfig{
+		ServerIP:      net.ParseIP("10.168.222.2"),
+		ClientIP:      net.ParseIP("10.168.222.100"),
+		SubnetMask:    net.ParseIP("255.255.255.0"),
+		BootFile:      "http://10.168.222.2:9885/tftp/ipxe.efi",
+		VendorClassId: HTTP_BOOT_VENDOR_CLASS,
+	}
+	resp, err := dhcp.MakeReplyPacket(pkt, conf)
+	if err != nil {
+		t.Fatalf("MakeReplyPacket: %v", err)
+	}
+	opts := resp.ParseOptions()
+	if vendorCls, _ := opts.String(dhcp.OptionVendorClassIdentifier); vendorCls != HTTP_BOOT_VENDOR_CLASS {
+		t.Errorf("vendor class got %q want %q", vendorCls, HTTP_BOOT_VENDOR_CLASS)
+	}
+	if bootFile, _ := opts.String(dhcp.OptionBootFileName); bootFile != conf.BootFile+"\x00" {
+		t.Errorf("boot file got %q want %q", bootFile, conf.BootFile)
+	}
+	if _, ok := opts[dhcp.OptionBootFileSize]; ok {
+		t.Errorf("boot file size should not be set for HTTP Boot")
+	}
+}
+
+type fakeBaremetalManager struct {
+	instances map[string]IBaremetalInstance
+}
+
+func (m *fakeBaremetalManager) GetZoneId() string { return "" }
+
+func (m *fakeBaremetalManager) GetBaremetalByMac(mac net.HardwareAddr) IBaremetalInstance {
+	return m.instances[mac.String()]
+}
+
+func (m *fakeBaremetalManager) AddBaremetal(desc jsonutils.JSONObject) (IBaremetalInstance, error) {
+	return nil, nil
+}
+
+func (m *fakeBaremetalManager) GetClientSession() *mcclient.ClientSession { return nil }
+
+type fakeBaremetalInstance struct {
+	script string
+}
+
+func (b *fakeBaremetalInstance) NeedPXEBoot() bool                              { return true }
+func (b *fakeBaremetalInstance) GetIPMINic(cliMac net.HardwareAddr) *types.SNic { return nil }
+func (b *fakeBaremetalInstance) GetPXEDHCPConfig(arch uint16, client BootClient) (*dhcp.ResponseConfig, error) {
+	return nil, nil
+}
+func (b *fakeBaremetalInstance) GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.ResponseConfig, error) {
+	return nil, nil
+}
+func (b *fakeBaremetalInstance) InitAdminNetif(cliMac net.HardwareAddr, wireId, nicType, netType string, isDoImport bool, ipAddr string) error {
+	return nil
+}
+func (b *fakeBaremetalInstance) RegisterNetif(cliMac net.HardwareAddr, wireId string) error {
+	return nil
+}
+func (b *fakeBaremetalInstance) GetTFTPResponse() string { return "" }
+func (b *fakeBaremetalInstance) GetIPXEScript() string   { return b.script }
+
+func TestHTTPHandler(t *testing.T) {
+	script := "#!ipxe\nkernel http://10.168.222.2:9885/tftp/kernel initrd=initramfs\ninitrd --name initramfs http://10.168.222.2:9885/tftp/initramfs\nboot\n"
+	man := &fakeBaremetalManager{
+		instances: map[string]IBaremetalInstance{
+			"52:54:00:12:34:56": &fakeBaremetalInstance{script: script},
+		},
+	}
+	srv := httptest.NewServer(NewHTTPHandler(man))
+	defer srv.Close()
+
+	cases := []struct {
+		mac    string
+		status int
+	}{
+		{"52:54:00:12:34:56", http.StatusOK},
+		{"52-54-00-12-34-56", http.StatusOK},
+		{"52:54:00:12:34:57", http.StatusNotFound},
+		{"invalid", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		resp, err := http.Get(srv.URL + IPXE_SCRIPT_PREFIX + c.mac)
+		if err != nil {
+			t.Fatalf("get %s: %v", c.mac, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != c.status {
+			t.Errorf("%s: status got %d want %d", c.mac, resp.StatusCode, c.status)
+			continue
+		}
+		if c.status == http.StatusOK && string(body) != script {
+			t.Errorf("%s: script got %q want %q", c.mac, body, script)
+		}
+	}
+}
+
+func TestValidateDHCPHTTPBootArch(t *testing.T) {
+	s := &Server{}
+	cases := []struct {
+		arch    uint16
+		wantErr bool
+	}{
+		{7, false},
+		{15, true},
+		{16, false},
+	}
+	for _, c := range cases {
+		pkt := newClientPacket("52:54:00:12:34:56", c.arch, "HTTPClient:Arch:00016:UNDI:003001", "")
+		_, _, err := s.validateDHCP(pkt)
+		if (err != nil) != c.wantErr {
+			t.Errorf("arch %d: want error %v, got %v", c.arch, c.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"yunion.io/x/log"
+)
+
+const (
+	// iPXE script of baremetal is served at IPXE_SCRIPT_PREFIX<mac>
+	IPXE_SCRIPT_PREFIX = "/ipxe/"
+)
+
+// HTTPHandler serves per-machine iPXE scripts, kernel and initramfs are
+// fetched from the same HTTP file server instead of TFTP
+type HTTPHandler struct {
+	BaremetalManager IBaremetalManager
+}
+
+func NewHTTPHandler(baremetalManager IBaremetalManager) *HTTPHandler {
+	return &HTTPHandler{
+		BaremetalManager: baremetalManager,
+	}
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mac := strings.TrimPrefix(r.URL.Path, IPXE_SCRIPT_PREFIX)
+	macAddr, err := net.ParseMAC(mac)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid mac %q", mac), http.StatusBadRequest)
+		return
+	}
+	bmInstance := h.BaremetalManager.GetBaremetalByMac(macAddr)
+	if bmInstance == nil {
+		log.Errorf("[HTTP] not found baremetal instance by mac: %s", macAddr)
+		http.NotFound(w, r)
+		return
+	}
+	script := bmInstance.GetIPXEScript()
+	log.Debugf("[HTTP] get ipxe script of %s: %s", macAddr, script)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(script)))
+	w.Write([]byte(script))
+}
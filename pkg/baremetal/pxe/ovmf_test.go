@@ -0,0 +1,127 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestOVMFIPXEChainload boots an OVMF (UEFI) QEMU guest from network, the
+// firmware loads iPXE over TFTP and iPXE chains the per-machine script served
+// by HTTPHandler. It is an integration test and skipped unless the following
+// environment variables are set:
+//
+//	PXE_TEST_OVMF       path of OVMF firmware, e.g. /usr/share/OVMF/OVMF.fd
+//	PXE_TEST_IPXE_EFI   path of ipxe.efi built with an embedded script:
+//	                    #!ipxe
+//	                    dhcp
+//	                    chain http://10.0.2.2:${PXE_TEST_HTTP_PORT}/ipxe/${net0/mac}
+//	PXE_TEST_HTTP_PORT  port of the script server, defaults to 8989
+//	PXE_TEST_QEMU       qemu binary, defaults to qemu-system-x86_64
+func TestOVMFIPXEChainload(t *testing.T) {
+	ovmf := os.Getenv("PXE_TEST_OVMF")
+	ipxeEfi := os.Getenv("PXE_TEST_IPXE_EFI")
+	if len(ovmf) == 0 || len(ipxeEfi) == 0 {
+		t.Skip("PXE_TEST_OVMF and PXE_TEST_IPXE_EFI are not set, skip OVMF boot test")
+	}
+	qemu := os.Getenv("PXE_TEST_QEMU")
+	if len(qemu) == 0 {
+		qemu = "qemu-system-x86_64"
+	}
+	if _, err := exec.LookPath(qemu); err != nil {
+		t.Skipf("%s not found: %v", qemu, err)
+	}
+	port := os.Getenv("PXE_TEST_HTTP_PORT")
+	if len(port) == 0 {
+		port = "8989"
+	}
+
+	const mac = "52:54:00:12:34:56"
+	// the guest powers off once the script served by us is executed
+	script := "#!ipxe\necho pxe-test-chainload-ok\npoweroff\n"
+	man := &fakeBaremetalManager{
+		instances: map[string]IBaremetalInstance{
+			mac: &fakeBaremetalInstance{script: script},
+		},
+	}
+	requested := make(chan string, 4)
+	handler := NewHTTPHandler(man)
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatalf("listen on %s: %v", port, err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- r.URL.Path
+		handler.ServeHTTP(w, r)
+	})}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	tftpDir, err := ioutil.TempDir("", "pxe-ovmf-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(tftpDir)
+	content, err := ioutil.ReadFile(ipxeEfi)
+	if err != nil {
+		t.Fatalf("read %s: %v", ipxeEfi, err)
+	}
+	err = ioutil.WriteFile(filepath.Join(tftpDir, "ipxe.efi"), content, 0644)
+	if err != nil {
+		t.Fatalf("write ipxe.efi: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, qemu,
+		"-machine", "q35", "-m", "512", "-nographic", "-no-reboot",
+		"-bios", ovmf,
+		"-netdev", fmt.Sprintf("user,id=net0,tftp=%s,bootfile=ipxe.efi", tftpDir),
+		"-device", fmt.Sprintf("virtio-net-pci,netdev=net0,mac=%s,romfile=", mac),
+		"-boot", "n",
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		t.Fatalf("guest did not power off in time, console:\n%s", output.String())
+	}
+	if err != nil {
+		t.Fatalf("qemu exit with %v, console:\n%s", err, output.String())
+	}
+
+	select {
+	case path := <-requested:
+		if path != IPXE_SCRIPT_PREFIX+mac {
+			t.Errorf("requested %s, want %s", path, IPXE_SCRIPT_PREFIX+mac)
+		}
+	default:
+		t.Fatalf("iPXE script was never requested, console:\n%s", output.String())
+	}
+	if !bytes.Contains(output.Bytes(), []byte("pxe-test-chainload-ok")) {
+		t.Errorf("served script was not executed, console:\n%s", output.String())
+	}
+}
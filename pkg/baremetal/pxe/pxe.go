@@ -80,6 +80,36 @@ const (
 	FirmwareUnknown
 )
 
+// BootClient describes the network boot program sending DHCP request
+type BootClient int
+
+const (
+	BootClientPXE  BootClient = iota // PXE ROM, fetch boot files over TFTP
+	BootClientIPXE                   // iPXE chainloaded from PXE or HTTP Boot, fetch boot files over HTTP
+	BootClientHTTP                   // UEFI HTTP Boot, fetch boot files over HTTP
+)
+
+const (
+	// vendor class identifier prefix of UEFI HTTP Boot client, must be
+	// echoed back in the offer
+	HTTP_BOOT_VENDOR_CLASS = "HTTPClient"
+	// user class sent by iPXE
+	IPXE_USER_CLASS = "iPXE"
+)
+
+func (c BootClient) String() string {
+	switch c {
+	case BootClientPXE:
+		return "PXE"
+	case BootClientIPXE:
+		return "iPXE"
+	case BootClientHTTP:
+		return "HTTPBoot"
+	default:
+		return "Unknown boot client"
+	}
+}
+
 type IBaremetalManager interface {
 	GetZoneId() string
 	GetBaremetalByMac(mac net.HardwareAddr) IBaremetalInstance
@@ -90,11 +120,12 @@ type IBaremetalManager interface {
 type IBaremetalInstance interface {
 	NeedPXEBoot() bool
 	GetIPMINic(cliMac net.HardwareAddr) *types.SNic
-	GetPXEDHCPConfig(arch uint16) (*dhcp.ResponseConfig, error)
+	GetPXEDHCPConfig(arch uint16, client BootClient) (*dhcp.ResponseConfig, error)
 	GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.ResponseConfig, error)
 	InitAdminNetif(cliMac net.HardwareAddr, wireId, nicType, netType string, isDoImport bool, ipAddr string) error
 	RegisterNetif(cliMac net.HardwareAddr, wireId string) error
 	GetTFTPResponse() string
+	GetIPXEScript() string
 }
 
 type Server struct {
@@ -0,0 +1,40 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"yunion.io/x/log"
+
+	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
+	"yunion.io/x/onecloud/pkg/util/tracing"
+)
+
+func initTracing(options *common_options.BaseOptions) {
+	var exporter tracing.IExporter
+	switch options.TracingExporter {
+	case "otlp":
+		exporter = tracing.NewOtlpHttpExporter(options.TracingOtlpEndpoint)
+	case "file":
+		var err error
+		exporter, err = tracing.NewFileExporter(options.TracingFilePath)
+		if err != nil {
+			log.Errorf("init tracing file exporter fail: %s", err)
+			return
+		}
+	default:
+		return
+	}
+	tracing.Init(options.ApplicationID, options.TracingSamplePercent, exporter)
+}
@@ -0,0 +1,393 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronman
+
+import (
+	"math/bits"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SCronExpr 标准 cron 表达式, 支持 5 段(分 时 日 月 周)或 6 段(秒 分 时 日 月 周)
+// 以及 @yearly, @monthly, @weekly, @daily, @hourly 等简写
+type SCronExpr struct {
+	expr string
+
+	second, minute, hour, dom, month, dow uint64
+	// 日和周均有限制时满足其一即可
+	domStar, dowStar bool
+}
+
+type cronBounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = cronBounds{0, 59, nil}
+	minuteBounds = cronBounds{0, 59, nil}
+	hourBounds   = cronBounds{0, 23, nil}
+	domBounds    = cronBounds{1, 31, nil}
+	monthBounds  = cronBounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = cronBounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// 最多向后查找的年数, 用于排除 2 月 30 日之类永不触发的表达式
+const cronMaxSearchYears = 5
+
+func ParseCronExpr(expr string) (*SCronExpr, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.Errorf("invalid cron expression %q: expect 5 or 6 fields, got %d", expr, len(fields))
+	}
+	e := &SCronExpr{expr: strings.TrimSpace(expr)}
+	var err error
+	for i, f := range []struct {
+		bits   *uint64
+		bounds cronBounds
+		name   string
+	}{
+		{&e.second, secondBounds, "second"},
+		{&e.minute, minuteBounds, "minute"},
+		{&e.hour, hourBounds, "hour"},
+		{&e.dom, domBounds, "day of month"},
+		{&e.month, monthBounds, "month"},
+		{&e.dow, dowBounds, "day of week"},
+	} {
+		*f.bits, err = parseCronField(fields[i], f.bounds)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s field of cron expression %q", f.name, expr)
+		}
+	}
+	// 7 也表示周日
+	if e.dow&(1<<7) != 0 {
+		e.dow = (e.dow | 1) &^ (1 << 7)
+	}
+	e.domStar = isCronStar(fields[3])
+	e.dowStar = isCronStar(fields[5])
+	return e, nil
+}
+
+func isCronStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func parseCronField(field string, b cronBounds) (uint64, error) {
+	var ret uint64
+	for _, part := range strings.Split(field, ",") {
+		bits, err := parseCronRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		ret |= bits
+	}
+	return ret, nil
+}
+
+func parseCronRange(expr string, b cronBounds) (uint64, error) {
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, errors.Errorf("invalid range %q", expr)
+	}
+	var start, end, step uint
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	switch {
+	case isCronStar(lowAndHigh[0]) && len(lowAndHigh) == 1:
+		start, end = b.min, b.max
+	case len(lowAndHigh) == 1:
+		v, err := parseCronValue(lowAndHigh[0], b)
+		if err != nil {
+			return 0, err
+		}
+		start, end = v, v
+		if len(rangeAndStep) == 2 {
+			// a/n 表示从 a 开始到最大值
+			end = b.max
+		}
+	case len(lowAndHigh) == 2:
+		var err error
+		start, err = parseCronValue(lowAndHigh[0], b)
+		if err != nil {
+			return 0, err
+		}
+		end, err = parseCronValue(lowAndHigh[1], b)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.Errorf("invalid range %q", expr)
+	}
+	step = 1
+	if len(rangeAndStep) == 2 {
+		v, err := strconv.ParseUint(rangeAndStep[1], 10, 32)
+		if err != nil || v == 0 {
+			return 0, errors.Errorf("invalid step %q", rangeAndStep[1])
+		}
+		step = uint(v)
+	}
+	if start > end {
+		return 0, errors.Errorf("invalid range %q: start %d beyond end %d", expr, start, end)
+	}
+	var ret uint64
+	for i := start; i <= end; i += step {
+		ret |= 1 << i
+	}
+	return ret, nil
+}
+
+func parseCronValue(str string, b cronBounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(str)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(str, 10, 32)
+	if err != nil {
+		return 0, errors.Errorf("invalid value %q", str)
+	}
+	if uint(v) < b.min || uint(v) > b.max {
+		return 0, errors.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+	return uint(v), nil
+}
+
+func (e *SCronExpr) String() string {
+	return e.expr
+}
+
+func (e *SCronExpr) dayMatches(t time.Time) bool {
+	domMatch := e.dom&(1<<uint(t.Day())) != 0
+	dowMatch := e.dow&(1<<uint(t.Weekday())) != 0
+	if e.domStar || e.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next 返回 t 之后第一个满足表达式的时间, 使用 t 所在时区; 找不到时返回零值
+func (e *SCronExpr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(time.Second - time.Duration(t.Nanosecond())*time.Nanosecond)
+	yearLimit := t.Year() + cronMaxSearchYears
+
+	added := false
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for e.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+	for !e.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// 夏令时切换可能导致零点不存在
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+	for e.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+	for e.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+	for e.second&(1<<uint(t.Second())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+	return t
+}
+
+// MinInterval 表达式两次触发的最小间隔的粗略估计
+func (e *SCronExpr) MinInterval() time.Duration {
+	switch {
+	case bits.OnesCount64(e.second) > 1:
+		return time.Second
+	case bits.OnesCount64(e.minute) > 1:
+		return time.Minute
+	case bits.OnesCount64(e.hour) > 1:
+		return time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+const (
+	// 错过的触发时间直接跳过
+	CRON_CATCH_UP_SKIP = "skip"
+	// 错过的若干次触发合并补跑一次
+	CRON_CATCH_UP_ONCE = "once"
+	// 错过的每次触发都补跑
+	CRON_CATCH_UP_ALL = "all"
+
+	// 补跑次数上限, 超过后跳过剩余的触发
+	cronMaxCatchUpRuns = 100
+)
+
+var CRON_CATCH_UP_POLICIES = []string{CRON_CATCH_UP_SKIP, CRON_CATCH_UP_ONCE, CRON_CATCH_UP_ALL}
+
+// CronTimer 按 cron 表达式在指定时区触发, 支持随机抖动和错过触发后的补跑策略
+type CronTimer struct {
+	expr    *SCronExpr
+	loc     *time.Location
+	jitter  time.Duration
+	catchUp string
+
+	// 上一次触发对应的表达式时间(不含抖动)
+	last time.Time
+	// 已计算但尚未触发的时间(含抖动)
+	scheduled   time.Time
+	catchUpRuns int
+}
+
+// NewCronTimer 创建 cron 定时器, lastRun 为上一次运行的时间, 用于进程重启后按补跑策略处理错过的触发
+func NewCronTimer(expr string, timezone string, jitter time.Duration, catchUp string, lastRun time.Time) (*CronTimer, error) {
+	e, err := ParseCronExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.Local
+	if len(timezone) > 0 {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid time zone %q", timezone)
+		}
+	}
+	if jitter < 0 {
+		return nil, errors.New("jitter must >= 0")
+	}
+	if jitter >= e.MinInterval() {
+		return nil, errors.Errorf("jitter %s must be less than the interval of cron expression %q", jitter, expr)
+	}
+	switch catchUp {
+	case "":
+		catchUp = CRON_CATCH_UP_SKIP
+	case CRON_CATCH_UP_SKIP, CRON_CATCH_UP_ONCE, CRON_CATCH_UP_ALL:
+	default:
+		return nil, errors.Errorf("invalid catch up policy %q, want %s", catchUp, strings.Join(CRON_CATCH_UP_POLICIES, ","))
+	}
+	if e.Next(time.Now().In(loc)).IsZero() {
+		return nil, errors.Errorf("cron expression %q never fires", expr)
+	}
+	return &CronTimer{
+		expr:    e,
+		loc:     loc,
+		jitter:  jitter,
+		catchUp: catchUp,
+		last:    lastRun,
+	}, nil
+}
+
+func (t *CronTimer) Location() *time.Location {
+	return t.loc
+}
+
+func (t *CronTimer) Next(now time.Time) time.Time {
+	// 尚未触发时重复调用(例如重新选主)返回同一时间
+	if !t.scheduled.IsZero() && t.scheduled.After(now) {
+		return t.scheduled
+	}
+	from := now
+	if !t.last.IsZero() && t.last.Before(now) {
+		from = t.last
+	}
+	next := t.expr.Next(from.In(t.loc))
+	if !next.IsZero() && !next.After(now) {
+		// 有错过的触发
+		switch {
+		case t.catchUp == CRON_CATCH_UP_ONCE && t.catchUpRuns == 0:
+			t.catchUpRuns++
+			next = now
+		case t.catchUp == CRON_CATCH_UP_ALL && t.catchUpRuns < cronMaxCatchUpRuns:
+			t.catchUpRuns++
+			t.last = next
+			t.scheduled = next
+			return next
+		default:
+			next = t.expr.Next(now.In(t.loc))
+		}
+	} else {
+		t.catchUpRuns = 0
+	}
+	t.last = next
+	t.scheduled = next
+	if !next.IsZero() && t.jitter > 0 && !next.Equal(now) {
+		t.scheduled = next.Add(time.Duration(rand.Int63n(int64(t.jitter))))
+	}
+	return t.scheduled
+}
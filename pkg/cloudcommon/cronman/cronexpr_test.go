@@ -0,0 +1,135 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronman
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronExpr(t *testing.T) {
+	for _, c := range []struct {
+		expr string
+		ok   bool
+	}{
+		{"* * * * *", true},
+		{"*/5 * * * *", true},
+		{"0 30 2 * * MON-FRI", true},
+		{"0 0 1,15 jan-jun *", true},
+		{"5/15 * * * ? *", true},
+		{"@daily", true},
+		{"@Hourly", true},
+		{"* * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"* * * foo *", false},
+	} {
+		_, err := ParseCronExpr(c.expr)
+		if (err == nil) != c.ok {
+			t.Errorf("ParseCronExpr(%q) error %v, want ok %v", c.expr, err, c.ok)
+		}
+	}
+}
+
+func TestSCronExpr_Next(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+	for _, c := range []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2021, 3, 1, 10, 7, 30, 0, time.UTC), time.Date(2021, 3, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2021, 3, 1, 2, 0, 0, 0, shanghai), time.Date(2021, 3, 2, 2, 0, 0, 0, shanghai)},
+		{"30 * * * * *", time.Date(2021, 3, 1, 10, 7, 30, 0, time.UTC), time.Date(2021, 3, 1, 10, 8, 30, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2021, 3, 5, 9, 0, 0, 0, time.UTC), time.Date(2021, 3, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC)},
+		// 日和周均有限制时满足其一即可
+		{"0 0 13 * 5", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// 夏令时开始当天 2:30 不存在
+		{"30 2 * * *", time.Date(2021, 3, 13, 3, 0, 0, 0, newYork), time.Date(2021, 3, 15, 2, 30, 0, 0, newYork)},
+		{"0 0 30 2 *", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+	} {
+		e, err := ParseCronExpr(c.expr)
+		if err != nil {
+			t.Fatalf("ParseCronExpr(%q): %v", c.expr, err)
+		}
+		got := e.Next(c.from)
+		if !got.Equal(c.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", c.expr, c.from, got, c.want)
+		}
+	}
+}
+
+func TestCronTimer_Next(t *testing.T) {
+	if _, err := NewCronTimer("* * * * *", "Mars/Olympus", 0, "", time.Time{}); err == nil {
+		t.Errorf("invalid time zone should fail")
+	}
+	if _, err := NewCronTimer("* * * * *", "", time.Minute, "", time.Time{}); err == nil {
+		t.Errorf("jitter beyond interval should fail")
+	}
+	if _, err := NewCronTimer("* * * * *", "", 0, "later", time.Time{}); err == nil {
+		t.Errorf("invalid catch up policy should fail")
+	}
+
+	now := time.Date(2021, 3, 1, 10, 30, 20, 0, time.UTC)
+	lastRun := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	timer, _ := NewCronTimer("*/10 * * * *", "UTC", 0, CRON_CATCH_UP_SKIP, lastRun)
+	if got, want := timer.Next(now), time.Date(2021, 3, 1, 10, 40, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("skip: got %s want %s", got, want)
+	}
+
+	timer, _ = NewCronTimer("*/10 * * * *", "UTC", 0, CRON_CATCH_UP_ONCE, lastRun)
+	if got := timer.Next(now); !got.Equal(now) {
+		t.Errorf("once: got %s want %s", got, now)
+	}
+	if got, want := timer.Next(now), time.Date(2021, 3, 1, 10, 40, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("once: got %s want %s", got, want)
+	}
+
+	timer, _ = NewCronTimer("*/10 * * * *", "UTC", 0, CRON_CATCH_UP_ALL, lastRun)
+	for _, m := range []int{10, 20, 30, 40} {
+		if got, want := timer.Next(now), time.Date(2021, 3, 1, 10, m, 0, 0, time.UTC); !got.Equal(want) {
+			t.Errorf("all: got %s want %s", got, want)
+		}
+	}
+
+	timer, _ = NewCronTimer("0 * * * *", "UTC", 10*time.Minute, "", time.Time{})
+	base := time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC)
+	next := timer.Next(now)
+	if next.Before(base) || !next.Before(base.Add(10*time.Minute)) {
+		t.Errorf("jitter: got %s, want within [%s, +10m)", next, base)
+	}
+	if again := timer.Next(now); !again.Equal(next) {
+		t.Errorf("pending next changed: %s != %s", again, next)
+	}
+	if got, want := timer.Next(next), base.Add(time.Hour); got.Before(want) || !got.Before(want.Add(10*time.Minute)) {
+		t.Errorf("jitter: got %s, want within [%s, +10m)", got, want)
+	}
+}
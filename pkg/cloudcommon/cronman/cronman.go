@@ -264,6 +264,33 @@ func (self *SCronJobManager) AddJobEveryFewHour(name string, hour, min, sec int,
 	return nil
 }
 
+// AddJobByCronTimer 按 cron 表达式定时运行任务, timer 由 NewCronTimer 创建
+func (self *SCronJobManager) AddJobByCronTimer(name string, timer *CronTimer, jobFunc TCronJobFunction, startRun bool) error {
+	if timer == nil {
+		return errors.New("AddJobByCronTimer: timer must not be nil")
+	}
+
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+
+	if !self.IsNameUnique(name) {
+		return ErrCronJobNameConflict
+	}
+
+	job := SCronJob{
+		Name:     name,
+		job:      jobFunc,
+		Timer:    timer,
+		StartRun: startRun,
+	}
+	if !self.running {
+		self.jobs = append(self.jobs, &job)
+	} else {
+		self.addJob(&job)
+	}
+	return nil
+}
+
 func (self *SCronJobManager) addJob(newJob *SCronJob) {
 	now := time.Now()
 	newJob.Next = newJob.Timer.Next(now)
@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -27,6 +28,7 @@ import (
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/etcd"
 	"yunion.io/x/onecloud/pkg/cloudcommon/informer"
 	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
@@ -59,6 +61,11 @@ func InitDB(options *common_options.DBOptions) {
 		panic(err)
 	}
 	sqlchemy.SetDB(dbConn)
+	registerDBStats("default", dbConn)
+
+	if len(options.SqlReplicaConnections) > 0 || len(options.SqlReplicaUncheckedConnections) > 0 {
+		initReplicaDB(options)
+	}
 
 	switch options.LockmanMethod {
 	case common_options.LockMethodInMemory, "":
@@ -107,8 +114,49 @@ func InitDB(options *common_options.DBOptions) {
 	}
 }
 
+func initReplicaDB(options *common_options.DBOptions) {
+	dialects, sqlStrs, err := options.GetReplicaDBConnections()
+	if err != nil {
+		log.Fatalf("Invalid SqlReplicaConnections: %v", err)
+	}
+	uncheckedDialects, uncheckedSqlStrs, err := options.GetUncheckedReplicaDBConnections()
+	if err != nil {
+		log.Fatalf("Invalid SqlReplicaUncheckedConnections: %v", err)
+	}
+	set := replica.NewReplicaSet(options.SqlReplicaMaxLagSeconds)
+	for i := range sqlStrs {
+		dbConn, err := sql.Open(dialects[i], sqlStrs[i])
+		if err != nil {
+			panic(err)
+		}
+		name := fmt.Sprintf("replica%d", i)
+		set.AddReplica(name, dbConn)
+		registerDBStats(name, dbConn)
+	}
+	for i := range uncheckedSqlStrs {
+		dbConn, err := sql.Open(uncheckedDialects[i], uncheckedSqlStrs[i])
+		if err != nil {
+			panic(err)
+		}
+		name := fmt.Sprintf("replica%d", len(sqlStrs)+i)
+		set.AddUncheckedReplica(name, dbConn)
+		registerDBStats(name, dbConn)
+	}
+	interval := options.SqlReplicaCheckIntervalSeconds
+	if interval <= 0 {
+		interval = 10
+	}
+	log.Infof("using %d read replicas (%d unchecked), max lag %d seconds", len(sqlStrs)+len(uncheckedSqlStrs), len(uncheckedSqlStrs), options.SqlReplicaMaxLagSeconds)
+	replica.Init(set, time.Duration(interval)*time.Second)
+}
+
 func CloseDB() {
 	sqlchemy.CloseDB()
+	if set := replica.GetReplicaSet(); set != nil {
+		for _, r := range set.Replicas() {
+			r.DB.Close()
+		}
+	}
 }
 
 func AppDBInit(app *appsrv.Application) {
@@ -121,6 +169,12 @@ func AppDBInit(app *appsrv.Application) {
 		log.Infof("Total %d db workers, set db connection max", connMax)
 		dbConn.SetMaxIdleConns(connMax)
 		dbConn.SetMaxOpenConns(connMax*2 + 1)
+		if set := replica.GetReplicaSet(); set != nil {
+			for _, r := range set.Replicas() {
+				r.DB.SetMaxIdleConns(connMax)
+				r.DB.SetMaxOpenConns(connMax*2 + 1)
+			}
+		}
 	}
 
 	app.AddDefaultHandler("GET", "/db_stats", DBStatsHandler, "db_stats")
@@ -133,5 +187,17 @@ func DBStatsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request)
 		stats := dbConn.Stats()
 		result.Add(jsonutils.Marshal(&stats), "db_stats")
 	}
+	if set := replica.GetReplicaSet(); set != nil {
+		replicas := jsonutils.NewArray()
+		for _, r := range set.Replicas() {
+			stats := r.DB.Stats()
+			info := jsonutils.NewDict()
+			info.Add(jsonutils.NewString(r.Name), "name")
+			info.Add(jsonutils.NewInt(r.Lag()), "lag")
+			info.Add(jsonutils.Marshal(&stats), "db_stats")
+			replicas.Add(info)
+		}
+		result.Add(replicas, "replicas")
+	}
 	fmt.Fprintf(w, result.String())
 }
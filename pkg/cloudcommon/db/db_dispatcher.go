@@ -35,6 +35,7 @@ import (
 	"yunion.io/x/onecloud/pkg/appsrv/dispatcher"
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
@@ -352,7 +353,7 @@ func Query2List(manager IModelManager, ctx context.Context, userCred mcclient.To
 	}
 	var items []interface{}
 	extraResults := make([]*jsonutils.JSONDict, 0)
-	rows, err := q.Rows()
+	rows, err := replica.Rows(ctx, q)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -634,7 +635,7 @@ func ListItems(manager IModelManager, ctx context.Context, userCred mcclient.Tok
 
 	var totalCnt int
 	if pagingConf == nil {
-		totalCnt, err = q.CountWithError()
+		totalCnt, err = replica.Count(ctx, q)
 		if err != nil {
 			return nil, err
 		}
@@ -823,6 +824,7 @@ func calculateListResult(data []jsonutils.JSONObject, total, limit, offset int64
 }
 
 func (dispatcher *DBModelDispatcher) List(ctx context.Context, query jsonutils.JSONObject, ctxIds []dispatcher.SResourceContext) (*modulebase.ListResult, error) {
+	ctx = replica.WithReadReplica(ctx)
 	userCred := fetchUserCredential(ctx)
 
 	items, err := ListItems(dispatcher.modelManager, ctx, userCred, query, ctxIds)
@@ -968,6 +970,7 @@ func (dispatcher *DBModelDispatcher) tryGetModelProperty(ctx context.Context, pr
 
 func (dispatcher *DBModelDispatcher) Get(ctx context.Context, idStr string, query jsonutils.JSONObject, isHead bool) (jsonutils.JSONObject, error) {
 	// log.Debugf("Get %s", idStr)
+	ctx = replica.WithReadReplica(ctx)
 	userCred := fetchUserCredential(ctx)
 
 	data, err := dispatcher.tryGetModelProperty(ctx, idStr, query)
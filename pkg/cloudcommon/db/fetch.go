@@ -25,6 +25,7 @@ import (
 	"yunion.io/x/sqlchemy"
 
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
@@ -152,7 +153,7 @@ func fetchItemById(manager IModelManager, ctx context.Context, userCred mcclient
 		}
 	}
 	q = manager.FilterById(q, idStr)
-	count, err := q.CountWithError()
+	count, err := replica.Count(ctx, q)
 	if err != nil {
 		return nil, err
 	}
@@ -161,7 +162,7 @@ func fetchItemById(manager IModelManager, ctx context.Context, userCred mcclient
 		if err != nil {
 			return nil, err
 		}
-		err = q.First(item)
+		err = replica.First(ctx, q, item)
 		if err != nil {
 			return nil, err
 		}
@@ -183,14 +184,14 @@ func fetchItemByName(manager IModelManager, ctx context.Context, userCred mcclie
 		}
 	}
 	q = manager.FilterByName(q, idStr)
-	count, err := q.CountWithError()
+	count, err := replica.Count(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 	if count > 0 {
 		q = manager.FilterByOwner(q, userCred, manager.NamespaceScope())
 		q = manager.FilterBySystemAttributes(q, nil, nil, manager.ResourceScope())
-		count, err = q.CountWithError()
+		count, err = replica.Count(ctx, q)
 		if err != nil {
 			return nil, err
 		}
@@ -200,7 +201,7 @@ func fetchItemByName(manager IModelManager, ctx context.Context, userCred mcclie
 		if err != nil {
 			return nil, err
 		}
-		err = q.First(item)
+		err = replica.First(ctx, q, item)
 		if err != nil {
 			return nil, err
 		}
@@ -17,6 +17,9 @@ package lockman
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type ILockedClass interface {
@@ -68,11 +71,30 @@ func getJointObjectKey(model ILockedObject, model2 ILockedObject) string {
 
 var _lockman ILockManager
 
+var lockWaitDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "lockman",
+		Name:      "wait_duration_seconds",
+		Help:      "Time spent waiting to acquire locks",
+		Buckets:   []float64{0.0001, 0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60},
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	prometheus.MustRegister(lockWaitDuration)
+}
+
+func observeWait(kind string, start time.Time) {
+	lockWaitDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
+
 func Init(man ILockManager) {
 	_lockman = man
 }
 
 func LockClass(ctx context.Context, manager ILockedClass, projectId string) {
+	defer observeWait("class", time.Now())
 	_lockman.LockClass(ctx, manager, projectId)
 }
 
@@ -81,6 +103,7 @@ func ReleaseClass(ctx context.Context, manager ILockedClass, projectId string) {
 }
 
 func LockObject(ctx context.Context, model ILockedObject) {
+	defer observeWait("object", time.Now())
 	_lockman.LockObject(ctx, model)
 }
 
@@ -89,6 +112,7 @@ func ReleaseObject(ctx context.Context, model ILockedObject) {
 }
 
 func LockRawObject(ctx context.Context, resName string, resId string) {
+	defer observeWait("raw", time.Now())
 	_lockman.LockRawObject(ctx, resName, resId)
 }
 
@@ -97,6 +121,7 @@ func ReleaseRawObject(ctx context.Context, resName string, resId string) {
 }
 
 func LockJointObject(ctx context.Context, model ILockedObject, model2 ILockedObject) {
+	defer observeWait("joint", time.Now())
 	_lockman.LockJointObject(ctx, model, model2)
 }
 
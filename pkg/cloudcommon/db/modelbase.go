@@ -27,6 +27,7 @@ import (
 
 	"yunion.io/x/onecloud/pkg/apis"
 	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/object"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/httperrors"
@@ -385,7 +386,7 @@ func (manager *SModelBaseManager) GetPropertyDistinctField(ctx context.Context,
 	for i := 0; i < len(fields); i++ {
 		var nq = backupQuery
 		nq.AppendField(nq.Field(fields[i]))
-		of, err := nq.Distinct().AllStringMap()
+		of, err := replica.AllStringMap(ctx, nq.Distinct())
 		if err == sql.ErrNoRows {
 			continue
 		}
@@ -407,7 +408,7 @@ func (manager *SModelBaseManager) GetPropertyDistinctField(ctx context.Context,
 		if err != nil {
 			continue
 		}
-		ef, err := nqp.AllStringMap()
+		ef, err := replica.AllStringMap(ctx, nqp)
 		if errors.Cause(err) == sql.ErrNoRows {
 			continue
 		}
@@ -244,6 +244,14 @@ const (
 	ACT_GUEST_PANICKED                   = "guest_panicked"
 	ACT_HOST_MAINTENANCE                 = "host_maintenance"
 	ACT_HOST_DOWN                        = "host_down"
+	ACT_HOST_FENCE                       = "host_fence"
+	ACT_HOST_FENCE_FAIL                  = "host_fence_fail"
+
+	ACT_REBALANCE_EVALUATE     = "rebalance_evaluate"
+	ACT_REBALANCE_APPROVE      = "rebalance_approve"
+	ACT_REBALANCE_REJECT       = "rebalance_reject"
+	ACT_REBALANCE_MIGRATE      = "rebalance_migrate"
+	ACT_REBALANCE_MIGRATE_FAIL = "rebalance_migrate_fail"
 
 	ACT_UPLOAD_OBJECT  = "upload_obj"
 	ACT_DELETE_OBJECT  = "delete_obj"
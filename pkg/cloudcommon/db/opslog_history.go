@@ -0,0 +1,404 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type SOpsLogDiffManager struct {
+	SModelBaseManager
+}
+
+// SOpsLogDiff keeps field level changes of a standalone model, the state of
+// the model at a point in time is reconstructed by reverting changes after
+// that time from current state
+type SOpsLogDiff struct {
+	SModelBase
+
+	Id      int64  `primary:"true" auto_increment:"true" list:"user"`
+	ObjType string `width:"40" charset:"ascii" nullable:"false" list:"user"`
+	ObjId   string `width:"128" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	Action  string `width:"32" charset:"ascii" nullable:"false" list:"user"`
+	// column => {"old": old, "new": new}, only new values are recorded for create
+	Diff *jsonutils.JSONDict `charset:"utf8" length:"medium" nullable:"true" list:"user"`
+
+	OpsTime time.Time `nullable:"false" list:"user"`
+}
+
+var OpsLogDiff *SOpsLogDiffManager
+
+// IHistoryRecordedManager is implemented by managers opting in to keep
+// history, each write of their models costs an extra insert of opslog_diff
+type IHistoryRecordedManager interface {
+	IsHistoryRecorded() bool
+}
+var (
+	opslogHistoryEnabled = false
+
+	// columns changing too often to be kept in history
+	historyIgnoreColumns = map[string]bool{
+		// not included in update diffs
+		"updated_at":     true,
+		"update_version": true,
+
+		"last_ping_at":     true,
+		"last_sync":        true,
+		"last_sync_end_at": true,
+		"last_auto_sync":   true,
+		"progress":         true,
+	}
+	historyColumnsCache sync.Map
+)
+
+func init() {
+	OpsLogDiff = &SOpsLogDiffManager{NewModelBaseManagerWithSplitable(
+		SOpsLogDiff{},
+		"opslog_diff_tbl",
+		"opslog_diff",
+		"opslog_diffs",
+		"id",
+		"ops_time",
+		consts.SplitableMaxDuration(),
+		consts.SplitableMaxKeepSegments(),
+	)}
+	OpsLogDiff.SetVirtualObject(OpsLogDiff)
+}
+
+// EnableOpsLogHistory starts recording field level changes of models whose
+// managers implement IHistoryRecordedManager, the service should
+// register OpsLogDiff as well
+func EnableOpsLogHistory() {
+	opslogHistoryEnabled = true
+}
+
+func isHistoryRecorded(manager IModelManager) bool {
+	man, ok := manager.(IHistoryRecordedManager)
+	return ok && man.IsHistoryRecorded()
+}
+
+func (diff *SOpsLogDiff) GetId() string {
+	return diff.ObjId
+}
+
+func (diff *SOpsLogDiff) GetName() string {
+	return diff.ObjType + "-" + diff.Action
+}
+
+func (diff *SOpsLogDiff) GetModelManager() IModelManager {
+	return OpsLogDiff
+}
+
+// historyColumns returns columns visible from API, which are the only ones
+// worth keeping in history
+func historyColumns(ts ITableSpec) map[string]bool {
+	if cols, ok := historyColumnsCache.Load(ts.Name()); ok {
+		return cols.(map[string]bool)
+	}
+	cols := make(map[string]bool)
+	for _, col := range ts.Columns() {
+		tags := col.Tags()
+		if len(tags["list"]) == 0 && len(tags["get"]) == 0 {
+			continue
+		}
+		if historyIgnoreColumns[col.Name()] {
+			continue
+		}
+		cols[col.Name()] = true
+	}
+	historyColumnsCache.Store(ts.Name(), cols)
+	return cols
+}
+
+func (manager *SOpsLogDiffManager) recordCreate(ts ITableSpec, model IModel) {
+	cols := historyColumns(ts)
+	obj := jsonutils.Marshal(model).(*jsonutils.JSONDict)
+	diff := jsonutils.NewDict()
+	for k, v := range obj.Value() {
+		if cols[k] {
+			diff.Set(k, jsonutils.Marshal(map[string]jsonutils.JSONObject{"new": v}))
+		}
+	}
+	manager.insert(model, ACT_CREATE, diff)
+}
+
+func (manager *SOpsLogDiffManager) recordUpdate(ts ITableSpec, model IModel, action string, uds sqlchemy.UpdateDiffs) {
+	cols := historyColumns(ts)
+	udsJson, err := jsonutils.ParseString(uds.String())
+	if err != nil {
+		log.Errorf("parse update diffs of %s %s: %s", model.Keyword(), model.GetId(), err)
+		return
+	}
+	diff := jsonutils.NewDict()
+	for k, v := range udsJson.(*jsonutils.JSONDict).Value() {
+		if cols[k] {
+			diff.Set(k, v)
+		}
+	}
+	if diff.Size() == 0 && action == ACT_UPDATE {
+		return
+	}
+	manager.insert(model, action, diff)
+}
+
+func (manager *SOpsLogDiffManager) insert(model IModel, action string, diff *jsonutils.JSONDict) {
+	rec := &SOpsLogDiff{
+		ObjType: model.Keyword(),
+		ObjId:   model.GetId(),
+		Action:  action,
+		Diff:    diff,
+		OpsTime: time.Now().UTC(),
+	}
+	rec.SetModelManager(manager, rec)
+	err := manager.TableSpec().Insert(context.Background(), rec)
+	if err != nil {
+		log.Errorf("fail to insert opslog diff: %s", err)
+	}
+}
+
+// fetchChanges returns changes in (since, until] in time order, zero time means unbounded
+func (manager *SOpsLogDiffManager) fetchChanges(objType, objId string, since, until time.Time) ([]SOpsLogDiff, error) {
+	q := manager.Query().Equals("obj_type", objType).Equals("obj_id", objId)
+	if !since.IsZero() {
+		q = q.GT("ops_time", since)
+	}
+	if !until.IsZero() {
+		q = q.LE("ops_time", until)
+	}
+	q = q.Asc("ops_time").Asc("id")
+	changes := make([]SOpsLogDiff, 0)
+	err := FetchModelObjects(manager, q, &changes)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return changes, nil
+}
+
+// isRecordedSince tells whether all changes of objType after t are still
+// kept, types opting in later have no records before their first change
+func (manager *SOpsLogDiffManager) isRecordedSince(objType string, t time.Time) bool {
+	q := manager.Query().Equals("obj_type", objType).Asc("ops_time").Limit(1)
+	first := SOpsLogDiff{}
+	first.SetModelManager(manager, &first)
+	err := q.First(&first)
+	if err != nil {
+		return false
+	}
+	return !first.OpsTime.After(t)
+}
+
+// revert applies changes backwards on state
+func revertChanges(state *jsonutils.JSONDict, changes []SOpsLogDiff) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Diff == nil {
+			continue
+		}
+		for k, v := range changes[i].Diff.Value() {
+			old, err := v.Get("old")
+			if err != nil || old == jsonutils.JSONNull {
+				state.Remove(k)
+			} else {
+				state.Set(k, old)
+			}
+		}
+	}
+}
+
+type sModelHistory struct {
+	state    *jsonutils.JSONDict
+	exists   bool
+	complete bool
+}
+
+// historyAt reverts changes after at from current state, the history is
+// known complete only if the creation of the model is among the changes
+func historyAt(current *jsonutils.JSONDict, changes []SOpsLogDiff, at time.Time) *sModelHistory {
+	ret := &sModelHistory{
+		state:  current.Copy(),
+		exists: true,
+	}
+	revertChanges(ret.state, changes)
+	for i := range changes {
+		if changes[i].Action == ACT_CREATE {
+			ret.exists = false
+			ret.complete = true
+			ret.state = jsonutils.NewDict()
+			return ret
+		}
+	}
+	if createdAt, err := ret.state.GetTime("created_at"); err == nil && createdAt.After(at) {
+		ret.exists = false
+		ret.state = jsonutils.NewDict()
+	}
+	return ret
+}
+
+func reconstructHistory(model IModel, at time.Time) (*sModelHistory, error) {
+	changes, err := OpsLogDiff.fetchChanges(model.Keyword(), model.GetId(), at, time.Time{})
+	if err != nil {
+		return nil, errors.Wrap(err, "fetchChanges")
+	}
+	current := jsonutils.NewDict()
+	cols := historyColumns(model.GetModelManager().TableSpec())
+	for k, v := range jsonutils.Marshal(model).(*jsonutils.JSONDict).Value() {
+		if cols[k] {
+			current.Set(k, v)
+		}
+	}
+	ret := historyAt(current, changes, at)
+	if !ret.complete {
+		ret.complete = OpsLogDiff.isRecordedSince(model.Keyword(), at)
+	}
+	return ret, nil
+}
+
+// visibleFields drops fields which userCred is not allowed to see
+func visibleFields(manager IModelManager, userCred mcclient.TokenCredential) map[string]bool {
+	includes, _ := GetDetailFields(manager, userCred)
+	ret := make(map[string]bool, len(includes))
+	for _, k := range includes {
+		ret[k] = true
+	}
+	return ret
+}
+
+func filterFields(dict *jsonutils.JSONDict, visible map[string]bool) *jsonutils.JSONDict {
+	ret := jsonutils.NewDict()
+	for k, v := range dict.Value() {
+		if visible[k] {
+			ret.Set(k, v)
+		}
+	}
+	return ret
+}
+
+func diffFields(diff *jsonutils.JSONDict, visible map[string]bool) map[string]apis.FieldDiff {
+	ret := make(map[string]apis.FieldDiff)
+	if diff == nil {
+		return ret
+	}
+	for k, v := range diff.Value() {
+		if !visible[k] {
+			continue
+		}
+		fd := apis.FieldDiff{}
+		fd.Old, _ = v.Get("old")
+		fd.New, _ = v.Get("new")
+		ret[k] = fd
+	}
+	return ret
+}
+
+func checkHistoryEnabled(manager IModelManager) error {
+	if !opslogHistoryEnabled {
+		return httperrors.NewNotSupportedError("resource history is not enabled")
+	}
+	if !isHistoryRecorded(manager) {
+		return httperrors.NewNotSupportedError("history of %s is not recorded", manager.Keyword())
+	}
+	return nil
+}
+
+// diffHistory compares the visible fields of two states and lists the changes between them
+func diffHistory(before, after *sModelHistory, changes []SOpsLogDiff, visible map[string]bool) (map[string]apis.FieldDiff, []apis.ResourceChange) {
+	diff := make(map[string]apis.FieldDiff)
+	for k := range visible {
+		oldVal, _ := before.state.Get(k)
+		newVal, _ := after.state.Get(k)
+		if oldVal == nil && newVal == nil {
+			continue
+		}
+		if oldVal != nil && newVal != nil && oldVal.Equals(newVal) {
+			continue
+		}
+		diff[k] = apis.FieldDiff{Old: oldVal, New: newVal}
+	}
+	rcs := make([]apis.ResourceChange, 0, len(changes))
+	for i := range changes {
+		rcs = append(rcs, apis.ResourceChange{
+			OpsTime: changes[i].OpsTime,
+			Action:  changes[i].Action,
+			Diff:    diffFields(changes[i].Diff, visible),
+		})
+	}
+	return diff, rcs
+}
+
+func GetModelHistory(model IModel, userCred mcclient.TokenCredential, input apis.GetHistoryInput) (*apis.GetHistoryOutput, error) {
+	err := checkHistoryEnabled(model.GetModelManager())
+	if err != nil {
+		return nil, err
+	}
+	if input.At.IsZero() {
+		input.At = time.Now().UTC()
+	}
+	history, err := reconstructHistory(model, input.At)
+	if err != nil {
+		return nil, errors.Wrap(err, "reconstructHistory")
+	}
+	return &apis.GetHistoryOutput{
+		At:       input.At,
+		Exists:   history.exists,
+		Complete: history.complete,
+		Object:   filterFields(history.state, visibleFields(model.GetModelManager(), userCred)),
+	}, nil
+}
+
+func GetModelDiff(model IModel, userCred mcclient.TokenCredential, input apis.GetDiffInput) (*apis.GetDiffOutput, error) {
+	err := checkHistoryEnabled(model.GetModelManager())
+	if err != nil {
+		return nil, err
+	}
+	if input.Since.IsZero() {
+		return nil, httperrors.NewMissingParameterError("since")
+	}
+	if input.Until.IsZero() {
+		input.Until = time.Now().UTC()
+	}
+	if !input.Until.After(input.Since) {
+		return nil, httperrors.NewInputParameterError("until should be later than since")
+	}
+	before, err := reconstructHistory(model, input.Since)
+	if err != nil {
+		return nil, errors.Wrap(err, "reconstructHistory since")
+	}
+	after, err := reconstructHistory(model, input.Until)
+	if err != nil {
+		return nil, errors.Wrap(err, "reconstructHistory until")
+	}
+	changes, err := OpsLogDiff.fetchChanges(model.Keyword(), model.GetId(), input.Since, input.Until)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetchChanges")
+	}
+	output := &apis.GetDiffOutput{
+		Since:    input.Since,
+		Until:    input.Until,
+		Complete: before.complete,
+	}
+	output.Diff, output.Changes = diffHistory(before, after, changes, visibleFields(model.GetModelManager(), userCred))
+	return output, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+func parseHistoryDict(t *testing.T, s string) *jsonutils.JSONDict {
+	obj, err := jsonutils.ParseString(s)
+	if err != nil {
+		t.Fatalf("parse %s: %s", s, err)
+	}
+	return obj.(*jsonutils.JSONDict)
+}
+
+func TestRevertChanges(t *testing.T) {
+	parse := func(s string) *jsonutils.JSONDict {
+		return parseHistoryDict(t, s)
+	}
+	state := parse(`{"name":"vm3","status":"running","vcpu_count":4}`)
+	changes := []SOpsLogDiff{
+		{Action: ACT_UPDATE, Diff: parse(`{"name":{"old":"vm1","new":"vm2"},"description":{"old":null,"new":"test"}}`)},
+		{Action: ACT_UPDATE, Diff: parse(`{"name":{"old":"vm2","new":"vm3"},"vcpu_count":{"old":2,"new":4}}`)},
+		{Action: ACT_UPDATE, Diff: parse(`{"status":{"old":"ready","new":"running"}}`)},
+	}
+	revertChanges(state, changes)
+	want := parse(`{"name":"vm1","status":"ready","vcpu_count":2}`)
+	if !state.Equals(want) {
+		t.Errorf("want %s got %s", want, state)
+	}
+}
+
+func TestHistoryAt(t *testing.T) {
+	parse := func(s string) *jsonutils.JSONDict {
+		return parseHistoryDict(t, s)
+	}
+	current := parse(`{"name":"vm3","status":"running","created_at":"2020-01-01T00:00:00Z"}`)
+	cases := []struct {
+		name     string
+		at       time.Time
+		changes  []SOpsLogDiff
+		exists   bool
+		complete bool
+		want     string
+	}{
+		{
+			name:   "no changes after",
+			at:     time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+			exists: true,
+			want:   `{"name":"vm3","status":"running","created_at":"2020-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "revert updates",
+			at:   time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+			changes: []SOpsLogDiff{
+				{Action: ACT_UPDATE, Diff: parse(`{"name":{"old":"vm1","new":"vm3"}}`)},
+				{Action: ACT_UPDATE, Diff: parse(`{"status":{"old":"ready","new":"running"}}`)},
+			},
+			exists: true,
+			want:   `{"name":"vm1","status":"ready","created_at":"2020-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "before create",
+			at:   time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC),
+			changes: []SOpsLogDiff{
+				{Action: ACT_CREATE, Diff: parse(`{"name":{"new":"vm1"}}`)},
+				{Action: ACT_UPDATE, Diff: parse(`{"name":{"old":"vm1","new":"vm3"}}`)},
+			},
+			exists:   false,
+			complete: true,
+			want:     `{}`,
+		},
+		{
+			name:   "created after without record",
+			at:     time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC),
+			exists: false,
+			want:   `{}`,
+		},
+	}
+	for _, c := range cases {
+		history := historyAt(current, c.changes, c.at)
+		if history.exists != c.exists {
+			t.Errorf("%s: want exists %v got %v", c.name, c.exists, history.exists)
+		}
+		if history.complete != c.complete {
+			t.Errorf("%s: want complete %v got %v", c.name, c.complete, history.complete)
+		}
+		if want := parse(c.want); !history.state.Equals(want) {
+			t.Errorf("%s: want %s got %s", c.name, want, history.state)
+		}
+	}
+	if name, _ := current.GetString("name"); name != "vm3" {
+		t.Errorf("current state should not be modified, got name %s", name)
+	}
+}
+
+func TestDiffHistory(t *testing.T) {
+	parse := func(s string) *jsonutils.JSONDict {
+		return parseHistoryDict(t, s)
+	}
+	before := &sModelHistory{state: parse(`{"name":"vm1","status":"ready","secret":"a"}`), exists: true}
+	after := &sModelHistory{state: parse(`{"name":"vm3","status":"ready","description":"test","secret":"b"}`), exists: true}
+	changes := []SOpsLogDiff{
+		{Action: ACT_UPDATE, Diff: parse(`{"name":{"old":"vm1","new":"vm2"},"secret":{"old":"a","new":"b"}}`)},
+		{Action: ACT_UPDATE, Diff: parse(`{"name":{"old":"vm2","new":"vm3"},"description":{"old":null,"new":"test"}}`)},
+	}
+	visible := map[string]bool{"name": true, "status": true, "description": true}
+	diff, rcs := diffHistory(before, after, changes, visible)
+
+	if len(diff) != 2 {
+		t.Errorf("want diff of name and description, got %v", diff)
+	}
+	if fd, ok := diff["name"]; !ok || !fd.Old.Equals(jsonutils.NewString("vm1")) || !fd.New.Equals(jsonutils.NewString("vm3")) {
+		t.Errorf("unexpected diff of name: %#v", diff["name"])
+	}
+	if fd, ok := diff["description"]; !ok || fd.Old != nil || !fd.New.Equals(jsonutils.NewString("test")) {
+		t.Errorf("unexpected diff of description: %#v", diff["description"])
+	}
+	if _, ok := diff["secret"]; ok {
+		t.Errorf("invisible field secret should not be in diff")
+	}
+	if len(rcs) != 2 {
+		t.Fatalf("want 2 changes, got %d", len(rcs))
+	}
+	if _, ok := rcs[0].Diff["secret"]; ok {
+		t.Errorf("invisible field secret should not be in changes")
+	}
+	if len(rcs[0].Diff) != 1 || len(rcs[1].Diff) != 2 {
+		t.Errorf("unexpected changes: %#v", rcs)
+	}
+}
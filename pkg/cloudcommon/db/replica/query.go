@@ -0,0 +1,140 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+)
+
+// Rows 在允许时于只读库执行查询，只读库出错则回退到主库
+func Rows(ctx context.Context, q *sqlchemy.SQuery) (*sql.Rows, error) {
+	return rowsOn(pickReplica(ctx), q)
+}
+
+// 在已选定的只读库上执行查询, r 为空时使用主库
+func rowsOn(r *SReplica, q *sqlchemy.SQuery) (*sql.Rows, error) {
+	if r == nil {
+		return q.Rows()
+	}
+	sqlstr := q.String()
+	vars := q.Variables()
+	if sqlchemy.DEBUG_SQLCHEMY {
+		log.Debugf("SQuery on replica %s: %s with vars: %s", r.Name, sqlstr, vars)
+	}
+	rows, err := r.DB.Query(sqlstr, vars...)
+	if err != nil {
+		log.Warningf("query on read replica %s fail, fallback to primary: %s", r.Name, err)
+		r.setLag(LAG_UNKNOWN)
+		return q.Rows()
+	}
+	return rows, nil
+}
+
+func Count(ctx context.Context, q *sqlchemy.SQuery) (int, error) {
+	r := pickReplica(ctx)
+	if r == nil {
+		return q.CountWithError()
+	}
+	tq := *q
+	cq := tq.Limit(0).Offset(0).SubQuery().Query(sqlchemy.COUNT("count"))
+	rows, err := rowsOn(r, cq)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+	count := 0
+	if rows.Next() {
+		err = rows.Scan(&count)
+	} else {
+		err = rows.Err()
+	}
+	if err != nil {
+		log.Errorf("SQuery count %s failed: %s", cq.String(), err)
+		return -1, err
+	}
+	return count, nil
+}
+
+func First(ctx context.Context, q *sqlchemy.SQuery, dest interface{}) error {
+	r := pickReplica(ctx)
+	if r == nil {
+		return q.First(dest)
+	}
+	rows, err := rowsOn(r, q.Limit(1))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return q.Row2Struct(rows, dest)
+}
+
+func All(ctx context.Context, q *sqlchemy.SQuery, dest interface{}) error {
+	r := pickReplica(ctx)
+	if r == nil {
+		return q.All(dest)
+	}
+	arrayValue := reflect.ValueOf(dest).Elem()
+	if arrayValue.Kind() != reflect.Slice {
+		return errors.Wrap(sqlchemy.ErrNeedsArray, "dest is not a slice")
+	}
+	rows, err := rowsOn(r, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	elemType := arrayValue.Type().Elem()
+	for rows.Next() {
+		elemPtr := reflect.New(elemType)
+		err := q.Row2Struct(rows, elemPtr.Interface())
+		if err != nil {
+			return err
+		}
+		arrayValue.Set(reflect.Append(arrayValue, elemPtr.Elem()))
+	}
+	return rows.Err()
+}
+
+func AllStringMap(ctx context.Context, q *sqlchemy.SQuery) ([]map[string]string, error) {
+	r := pickReplica(ctx)
+	if r == nil {
+		return q.AllStringMap()
+	}
+	rows, err := rowsOn(r, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]map[string]string, 0)
+	for rows.Next() {
+		result, err := q.Row2Map(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
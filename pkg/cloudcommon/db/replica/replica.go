@@ -0,0 +1,220 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/appsrv"
+)
+
+const (
+	// 请求携带该头且值为true时，只读请求强制读主库，用于写后读一致性
+	READ_PRIMARY_HEADER = "X-Yunion-Read-Primary"
+
+	LAG_UNKNOWN = -1
+)
+
+type sReadReplicaKey struct{}
+
+type SReplica struct {
+	Name string
+	DB   *sql.DB
+
+	// 复制延迟（秒），LAG_UNKNOWN 表示复制中断或检测失败
+	lag int64
+	// 经由代理接入的只读库无法获取复制状态，不检测延迟
+	skipLagCheck bool
+}
+
+func (r *SReplica) Lag() int64 {
+	return atomic.LoadInt64(&r.lag)
+}
+
+func (r *SReplica) setLag(lag int64) {
+	atomic.StoreInt64(&r.lag, lag)
+}
+
+type SReplicaSet struct {
+	replicas []*SReplica
+	maxLag   int64
+	next     uint32
+
+	lagFunc func(db *sql.DB) (int64, error)
+}
+
+var (
+	replicaSet *SReplicaSet
+	initOnce   sync.Once
+)
+
+func NewReplicaSet(maxLagSeconds int) *SReplicaSet {
+	return &SReplicaSet{
+		maxLag:  int64(maxLagSeconds),
+		lagFunc: fetchMySQLReplicaLag,
+	}
+}
+
+// 新加入的只读库在首次检测延迟前不参与路由
+func (set *SReplicaSet) AddReplica(name string, db *sql.DB) {
+	set.replicas = append(set.replicas, &SReplica{Name: name, DB: db, lag: LAG_UNKNOWN})
+}
+
+// 经由代理接入、无法获取复制状态的只读库，需显式加入，不检测延迟并始终参与路由
+func (set *SReplicaSet) AddUncheckedReplica(name string, db *sql.DB) {
+	set.replicas = append(set.replicas, &SReplica{Name: name, DB: db, lag: 0, skipLagCheck: true})
+}
+
+func (set *SReplicaSet) Replicas() []*SReplica {
+	return set.replicas
+}
+
+func (set *SReplicaSet) CheckLag() {
+	for _, r := range set.replicas {
+		if r.skipLagCheck {
+			continue
+		}
+		lag, err := set.lagFunc(r.DB)
+		if err != nil {
+			if r.Lag() != LAG_UNKNOWN {
+				log.Warningf("read replica %s excluded: %s", r.Name, err)
+			}
+			r.setLag(LAG_UNKNOWN)
+			continue
+		}
+		if lag > set.maxLag && r.Lag() <= set.maxLag && r.Lag() != LAG_UNKNOWN {
+			log.Warningf("read replica %s excluded: lag %ds exceeds %ds", r.Name, lag, set.maxLag)
+		}
+		r.setLag(lag)
+	}
+}
+
+func (set *SReplicaSet) startLagChecker(interval time.Duration) {
+	set.CheckLag()
+	go func() {
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for range tick.C {
+			set.CheckLag()
+		}
+	}()
+}
+
+// 轮询选择延迟不超过上限的只读库，没有可用只读库时返回nil
+func (set *SReplicaSet) pick() *SReplica {
+	healthy := make([]*SReplica, 0, len(set.replicas))
+	for _, r := range set.replicas {
+		lag := r.Lag()
+		if lag != LAG_UNKNOWN && lag <= set.maxLag {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	idx := atomic.AddUint32(&set.next, 1)
+	return healthy[int(idx%uint32(len(healthy)))]
+}
+
+func Init(set *SReplicaSet, checkInterval time.Duration) {
+	initOnce.Do(func() {
+		replicaSet = set
+		set.startLagChecker(checkInterval)
+	})
+}
+
+func GetReplicaSet() *SReplicaSet {
+	return replicaSet
+}
+
+// 标记ctx内的查询可以读只读库，只应用于只读的API请求
+func WithReadReplica(ctx context.Context) context.Context {
+	if replicaSet == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sReadReplicaKey{}, true)
+}
+
+func isReadReplicaAllowed(ctx context.Context) bool {
+	if ctx == nil || ctx.Value(sReadReplicaKey{}) == nil {
+		return false
+	}
+	// 任务内的读取总是走主库
+	if ctx.Value(appctx.APP_CONTEXT_KEY_TASK_ID) != nil {
+		return false
+	}
+	params := appsrv.AppContextGetParams(ctx)
+	if params != nil && params.Request != nil {
+		if force, _ := strconv.ParseBool(params.Request.Header.Get(READ_PRIMARY_HEADER)); force {
+			return false
+		}
+	}
+	return true
+}
+
+func pickReplica(ctx context.Context) *SReplica {
+	if replicaSet == nil || !isReadReplicaAllowed(ctx) {
+		return nil
+	}
+	return replicaSet.pick()
+}
+
+func fetchMySQLReplicaLag(db *sql.DB) (int64, error) {
+	rows, err := db.Query("SHOW SLAVE STATUS")
+	if err != nil {
+		return LAG_UNKNOWN, errors.Wrap(err, "show slave status")
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		// 非复制实例无法确认数据是否同步，经由代理接入的只读库需用 AddUncheckedReplica 显式加入
+		return LAG_UNKNOWN, errors.Error("empty slave status, not a replica")
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return LAG_UNKNOWN, errors.Wrap(err, "Columns")
+	}
+	values := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	err = rows.Scan(dest...)
+	if err != nil {
+		return LAG_UNKNOWN, errors.Wrap(err, "Scan")
+	}
+	for i, col := range cols {
+		if col != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return LAG_UNKNOWN, errors.Error("replication is not running")
+		}
+		lag, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return LAG_UNKNOWN, errors.Wrapf(err, "invalid Seconds_Behind_Master %s", values[i].String)
+		}
+		return lag, nil
+	}
+	return LAG_UNKNOWN, errors.Error("no Seconds_Behind_Master in slave status")
+}
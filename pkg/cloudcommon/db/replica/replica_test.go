@@ -0,0 +1,117 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/appsrv"
+)
+
+func TestReplicaSetPick(t *testing.T) {
+	set := NewReplicaSet(5)
+	set.AddReplica("r0", nil)
+	set.AddReplica("r1", nil)
+	set.AddReplica("r2", nil)
+	if r := set.pick(); r != nil {
+		t.Fatalf("replica %s picked before lag checked", r.Name)
+	}
+
+	set.replicas[0].setLag(0)
+	set.replicas[1].setLag(30)
+	set.replicas[2].setLag(5)
+	picked := map[string]int{}
+	for i := 0; i < 10; i++ {
+		picked[set.pick().Name]++
+	}
+	if picked["r1"] != 0 {
+		t.Errorf("lagging replica r1 picked %d times", picked["r1"])
+	}
+	if picked["r0"] != 5 || picked["r2"] != 5 {
+		t.Errorf("replicas not picked in round robin: %v", picked)
+	}
+
+	set.replicas[0].setLag(LAG_UNKNOWN)
+	set.replicas[2].setLag(6)
+	if r := set.pick(); r != nil {
+		t.Errorf("replica %s picked while all lagging", r.Name)
+	}
+}
+
+func TestIsReadReplicaAllowed(t *testing.T) {
+	replicaSet = NewReplicaSet(5)
+	defer func() { replicaSet = nil }()
+
+	newCtx := func(hdr string) context.Context {
+		req, _ := http.NewRequest("GET", "/servers", nil)
+		if len(hdr) > 0 {
+			req.Header.Set(READ_PRIMARY_HEADER, hdr)
+		}
+		return context.WithValue(context.Background(), appsrv.APP_CONTEXT_KEY_APP_PARAMS, &appsrv.SAppParams{Request: req})
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"unmarked", newCtx(""), false},
+		{"marked", WithReadReplica(newCtx("")), true},
+		{"force primary", WithReadReplica(newCtx("true")), false},
+		{"header false", WithReadReplica(newCtx("false")), true},
+		{"in task", context.WithValue(WithReadReplica(newCtx("")), appctx.APP_CONTEXT_KEY_TASK_ID, "task"), false},
+	}
+	for _, c := range cases {
+		if got := isReadReplicaAllowed(c.ctx); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestReplicaSetCheckLag(t *testing.T) {
+	set := NewReplicaSet(5)
+	checked := map[*sql.DB]bool{}
+	lags := map[*sql.DB]int64{}
+	set.lagFunc = func(db *sql.DB) (int64, error) {
+		checked[db] = true
+		if lag, ok := lags[db]; ok {
+			return lag, nil
+		}
+		return LAG_UNKNOWN, errors.Error("empty slave status, not a replica")
+	}
+	db0, db1, db2 := &sql.DB{}, &sql.DB{}, &sql.DB{}
+	lags[db0] = 1
+	set.AddReplica("r0", db0)
+	set.AddReplica("r1", db1)
+	set.AddUncheckedReplica("r2", db2)
+	set.CheckLag()
+
+	if lag := set.replicas[0].Lag(); lag != 1 {
+		t.Errorf("r0: want lag 1 got %d", lag)
+	}
+	// 不是复制实例的只读库不参与路由
+	if lag := set.replicas[1].Lag(); lag != LAG_UNKNOWN {
+		t.Errorf("r1: want lag unknown got %d", lag)
+	}
+	if checked[db2] || set.replicas[2].Lag() != 0 {
+		t.Errorf("unchecked replica r2 should not be checked and always be routed")
+	}
+}
@@ -382,6 +382,24 @@ func (model *SStandaloneAnonResourceBase) GetDetailsMetadata(ctx context.Context
 	return val, nil
 }
 
+func (model *SStandaloneAnonResourceBase) AllowGetDetailsHistory(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return model.GetIStandaloneModel().AllowGetDetails(ctx, userCred, query)
+}
+
+// 获取资源在某一时刻的状态
+func (model *SStandaloneAnonResourceBase) GetDetailsHistory(ctx context.Context, userCred mcclient.TokenCredential, input apis.GetHistoryInput) (*apis.GetHistoryOutput, error) {
+	return GetModelHistory(model.GetIStandaloneModel(), userCred, input)
+}
+
+func (model *SStandaloneAnonResourceBase) AllowGetDetailsDiff(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return model.GetIStandaloneModel().AllowGetDetails(ctx, userCred, query)
+}
+
+// 获取资源在一段时间内的字段变更
+func (model *SStandaloneAnonResourceBase) GetDetailsDiff(ctx context.Context, userCred mcclient.TokenCredential, input apis.GetDiffInput) (*apis.GetDiffOutput, error) {
+	return GetModelDiff(model.GetIStandaloneModel(), userCred, input)
+}
+
 func (model *SStandaloneAnonResourceBase) AllowPerformMetadata(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
 	return IsAllowPerform(rbacutils.ScopeSystem, userCred, model, "metadata")
 }
@@ -24,6 +24,7 @@ import (
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/sqlchemy"
 
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
 	"yunion.io/x/onecloud/pkg/cloudcommon/informer"
 	"yunion.io/x/onecloud/pkg/util/nopanic"
 	"yunion.io/x/onecloud/pkg/util/splitable"
@@ -120,6 +121,7 @@ func (ts *sTableSpec) Insert(ctx context.Context, dt interface{}) error {
 		return err
 	}
 	ts.inform(ctx, dt, informer.Create)
+	ts.recordHistory(dt, ACT_CREATE, nil)
 	return nil
 }
 
@@ -147,8 +149,10 @@ func (ts *sTableSpec) Update(ctx context.Context, dt interface{}, doUpdate func(
 	}
 	if isDeleted {
 		ts.inform(ctx, dt, informer.Delete)
+		ts.recordHistory(dt, ACT_DELETE, diffs)
 	} else {
 		ts.informUpdate(ctx, dt, oldObj.(*jsonutils.JSONDict))
+		ts.recordHistory(dt, ACT_UPDATE, diffs)
 	}
 	return diffs, nil
 }
@@ -214,3 +218,23 @@ func (ts *sTableSpec) informUpdate(ctx context.Context, dt interface{}, oldObj *
 	}
 	nopanic.Run(nf)
 }
+
+func (ts *sTableSpec) recordHistory(dt interface{}, action string, diffs sqlchemy.UpdateDiffs) {
+	if !opslogHistoryEnabled || !consts.OpsLogEnabled() {
+		return
+	}
+	model, ok := dt.(IModel)
+	if !ok {
+		return
+	}
+	if !isHistoryRecorded(model.GetModelManager()) {
+		return
+	}
+	nopanic.Run(func() {
+		if action == ACT_CREATE {
+			OpsLogDiff.recordCreate(ts, model)
+		} else {
+			OpsLogDiff.recordUpdate(ts, model, action, diffs)
+		}
+	})
+}
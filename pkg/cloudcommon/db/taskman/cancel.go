@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func (self *STask) AllowPerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "cancel")
+}
+
+// PerformCancel cancels a running task together with its pending local subtasks,
+// the failed handler of the current stage of each task is called to restore its objects
+func (self *STask) PerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.IsFinished() {
+		return nil, httperrors.NewInvalidStatusError("task %s is %s", self.Id, self.Stage)
+	}
+	if self.IsCanceled() {
+		return nil, httperrors.NewInvalidStatusError("task %s is being canceled", self.Id)
+	}
+	reason, _ := data.GetString("reason")
+	err := self.cancel(ctx, userCred, reason)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func (self *STask) cancel(ctx context.Context, userCred mcclient.TokenCredential, reason string) error {
+	lockman.LockRawObject(ctx, "tasks", self.Id)
+	defer lockman.ReleaseRawObject(ctx, "tasks", self.Id)
+
+	canceled := jsonutils.NewDict()
+	canceled.Add(jsonutils.NewString(userCred.GetUserName()), "user")
+	canceled.Add(jsonutils.NewString(reason), "reason")
+	canceled.Add(jsonutils.NewTimeString(time.Now()), "at")
+	_, err := db.Update(self, func() error {
+		params := self.Params.CopyExcludes(TASK_CANCELED_KEY)
+		params.Add(canceled, TASK_CANCELED_KEY)
+		self.Params = params
+		self.StageDeadline = time.Time{}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+
+	// callbacks of subtasks are dropped once the parent is marked as canceled,
+	// so the parent is always failed by its own cancel body below
+	for _, st := range SubTaskManager.GetInitSubtasks(self.Id, self.Stage) {
+		subtask := TaskManager.fetchTask(st.SubtaskId)
+		if subtask == nil || subtask.IsFinished() || subtask.IsCanceled() {
+			continue
+		}
+		err := subtask.cancel(ctx, userCred, reason)
+		if err != nil {
+			log.Errorf("cancel subtask %s(%s) of %s fail: %s", subtask.TaskName, subtask.Id, self.Id, err)
+		}
+	}
+
+	return self.ScheduleRun(self.cancelBody())
+}
+
+func (self *STask) cancelBody() *jsonutils.JSONDict {
+	msg := "task canceled"
+	canceled, _ := self.Params.Get(TASK_CANCELED_KEY)
+	if canceled != nil {
+		user, _ := canceled.GetString("user")
+		reason, _ := canceled.GetString("reason")
+		msg = fmt.Sprintf("task canceled by %s", user)
+		if len(reason) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, reason)
+		}
+	}
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewString("error"), "__status__")
+	body.Add(jsonutils.NewString(msg), "__reason__")
+	body.Add(jsonutils.JSONTrue, TASK_CANCEL_KEY)
+	return body
+}
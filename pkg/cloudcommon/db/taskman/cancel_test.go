@@ -0,0 +1,81 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestCancelBody(t *testing.T) {
+	cases := []struct {
+		name     string
+		canceled jsonutils.JSONObject
+		want     string
+	}{
+		{
+			name: "no record",
+			want: "task canceled",
+		},
+		{
+			name:     "without reason",
+			canceled: jsonutils.Marshal(map[string]string{"user": "admin"}),
+			want:     "task canceled by admin",
+		},
+		{
+			name:     "with reason",
+			canceled: jsonutils.Marshal(map[string]string{"user": "admin", "reason": "stuck"}),
+			want:     "task canceled by admin: stuck",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			params := jsonutils.NewDict()
+			if c.canceled != nil {
+				params.Add(c.canceled, TASK_CANCELED_KEY)
+			}
+			task := &STask{Params: params}
+			body := task.cancelBody()
+			if reason, _ := body.GetString("__reason__"); reason != c.want {
+				t.Errorf("want reason %q got %q", c.want, reason)
+			}
+			if !jsonutils.QueryBoolean(body, TASK_CANCEL_KEY, false) {
+				t.Errorf("cancel body should be marked with %s", TASK_CANCEL_KEY)
+			}
+		})
+	}
+}
+
+func TestCanceledTaskAcceptData(t *testing.T) {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.Marshal(map[string]string{"user": "admin"}), TASK_CANCELED_KEY)
+	task := &STask{Stage: "OnWait", Params: params}
+
+	if _, accept := task.acceptData(jsonutils.Marshal(map[string]string{"status": "ok"})); accept {
+		t.Errorf("callback of canceled task should be dropped")
+	}
+	if _, accept := task.acceptData(timeoutBody("OnWait")); accept {
+		t.Errorf("timeout of canceled task should be dropped")
+	}
+	if _, accept := task.acceptData(task.cancelBody()); !accept {
+		t.Errorf("cancel body of canceled task should be accepted")
+	}
+
+	task.Params = jsonutils.NewDict()
+	if _, accept := task.acceptData(jsonutils.Marshal(map[string]string{"status": "ok"})); !accept {
+		t.Errorf("callback of running task should be accepted")
+	}
+}
@@ -17,6 +17,7 @@ package taskman
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"yunion.io/x/jsonutils"
 
@@ -33,6 +34,7 @@ type ITask interface {
 	GetUserCred() mcclient.TokenCredential
 	GetTaskId() string
 	SetStage(stageName string, data *jsonutils.JSONDict) error
+	SetStageWithTimeout(stageName string, data *jsonutils.JSONDict, timeout time.Duration) error
 
 	GetTaskRequestHeader() http.Header
 
@@ -0,0 +1,113 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"yunion.io/x/log"
+	"yunion.io/x/sqlchemy"
+)
+
+const openTasksRefreshInterval = 30 * time.Second
+
+var (
+	tasksFinished = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "taskman",
+			Name:      "tasks_finished_total",
+			Help:      "Number of tasks completed or failed since the service started",
+		},
+		[]string{"task", "state"},
+	)
+
+	openTasksDesc = prometheus.NewDesc(
+		"taskman_open_tasks",
+		"Number of tasks neither completed nor failed, pending tasks have not left the init stage",
+		[]string{"task", "state"}, nil,
+	)
+
+	registerCollectorOnce sync.Once
+)
+
+func init() {
+	prometheus.MustRegister(tasksFinished)
+}
+
+// openTasksCollector counts open tasks in the tasks table,
+// the result is cached to keep frequent scrapes off the database
+type openTasksCollector struct {
+	lock      sync.Mutex
+	updatedAt time.Time
+	counts    map[[2]string]int
+}
+
+func (c *openTasksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openTasksDesc
+}
+
+func (c *openTasksCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if time.Since(c.updatedAt) > openTasksRefreshInterval {
+		counts, err := TaskManager.countOpenTasks()
+		if err != nil {
+			log.Errorf("count open tasks fail: %s", err)
+		} else {
+			c.counts = counts
+			c.updatedAt = time.Now()
+		}
+	}
+	for key, cnt := range c.counts {
+		ch <- prometheus.MustNewConstMetric(openTasksDesc, prometheus.GaugeValue, float64(cnt), key[0], key[1])
+	}
+}
+
+func (manager *STaskManager) countOpenTasks() (map[[2]string]int, error) {
+	q := manager.Query("task_name", "stage")
+	q = q.AppendField(sqlchemy.COUNT("count"))
+	q = q.NotIn("stage", []string{TASK_STAGE_COMPLETE, TASK_STAGE_FAILED})
+	q = q.GroupBy(q.Field("task_name"), q.Field("stage"))
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[[2]string]int)
+	for rows.Next() {
+		var taskName, stage string
+		var cnt int
+		err := rows.Scan(&taskName, &stage, &cnt)
+		if err != nil {
+			return nil, err
+		}
+		state := "running"
+		if stage == TASK_INIT_STAGE {
+			state = "pending"
+		}
+		counts[[2]string{taskName, state}] += cnt
+	}
+	return counts, nil
+}
+
+func registerMetrics() {
+	registerCollectorOnce.Do(func() {
+		prometheus.MustRegister(&openTasksCollector{})
+	})
+}
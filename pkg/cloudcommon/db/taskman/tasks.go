@@ -44,6 +44,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/util/httputils"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/tracing"
 )
 
 const (
@@ -64,6 +65,15 @@ const (
 	CONVERT_TASK = "convert_task"
 
 	LANG = "lang"
+
+	// TASK_TIMEOUT_KEY carries the stage that expired in the body dispatched by the stage timeout checker
+	TASK_TIMEOUT_KEY = "__timeout__"
+	// TASK_CANCEL_KEY marks the body dispatched to a task being canceled
+	TASK_CANCEL_KEY = "__cancel__"
+	// TASK_CANCELED_KEY records who canceled the task and why in task params
+	TASK_CANCELED_KEY = "__canceled"
+
+	TASK_TIMEOUT_HANDLER = "OnTimeout"
 )
 
 type STaskManager struct {
@@ -92,6 +102,10 @@ type STask struct {
 
 	Stage string `width:"64" charset:"ascii" nullable:"false" default:"on_init" list:"user"` // Column(VARCHAR(64, charset='ascii'), nullable=False, default='on_init')
 
+	// StageDeadline is the time by which the current stage should have been left,
+	// zero if the stage has no deadline
+	StageDeadline time.Time `nullable:"true" list:"user"`
+
 	taskObject  db.IStandaloneModel   `ignore:"true"`
 	taskObjects []db.IStandaloneModel `ignore:"true"`
 }
@@ -400,6 +414,48 @@ func (manager *STaskManager) execTask(taskId string, data jsonutils.JSONObject)
 	}
 }
 
+// acceptData drops data of a canceled task except its cancel body,
+// and timeout of a stage the task has already left
+func (task *STask) acceptData(data jsonutils.JSONObject) (string, bool) {
+	var timeoutStage string
+	isCancel := false
+	if dictdata, ok := data.(*jsonutils.JSONDict); ok {
+		timeoutStage, _ = dictdata.GetString(TASK_TIMEOUT_KEY)
+		isCancel = jsonutils.QueryBoolean(dictdata, TASK_CANCEL_KEY, false)
+	}
+	if task.IsCanceled() && !isCancel {
+		log.Warningf("Task %s(%s) has been canceled, drop data %s", task.TaskName, task.Id, data)
+		return "", false
+	}
+	if len(timeoutStage) > 0 && timeoutStage != task.Stage {
+		log.Warningf("Task %s(%s) has left stage %s, ignore timeout", task.TaskName, task.Id, timeoutStage)
+		return "", false
+	}
+	return timeoutStage, true
+}
+
+func (task *STask) stageHandler(taskValue reflect.Value, taskFailed bool, timeoutStage string) (string, reflect.Value) {
+	var stageName string
+	if taskFailed {
+		stageName = fmt.Sprintf("%sFailed", task.Stage)
+	} else {
+		stageName = task.Stage
+	}
+
+	var funcValue reflect.Value
+	if len(timeoutStage) > 0 {
+		// OnTimeout is optional, fallback to the failed handler of the stage
+		funcValue = taskValue.MethodByName(TASK_TIMEOUT_HANDLER)
+		if funcValue.IsValid() && !funcValue.IsNil() {
+			stageName = TASK_TIMEOUT_HANDLER
+		}
+	}
+	if !funcValue.IsValid() || funcValue.IsNil() {
+		funcValue = taskValue.MethodByName(stageName)
+	}
+	return stageName, funcValue
+}
+
 func execITask(taskValue reflect.Value, task *STask, odata jsonutils.JSONObject, isMulti bool) {
 	ctxData := task.GetRequestContext()
 	ctx := ctxData.GetContext()
@@ -434,14 +490,12 @@ func execITask(taskValue reflect.Value, task *STask, odata jsonutils.JSONObject,
 		data = jsonutils.NewDict()
 	}
 
-	var stageName string
-	if taskFailed {
-		stageName = fmt.Sprintf("%sFailed", task.Stage)
-	} else {
-		stageName = task.Stage
+	timeoutStage, accept := task.acceptData(data)
+	if !accept {
+		return
 	}
 
-	funcValue := taskValue.MethodByName(stageName)
+	stageName, funcValue := task.stageHandler(taskValue, taskFailed, timeoutStage)
 
 	if !funcValue.IsValid() || funcValue.IsNil() {
 		log.Debugf("Stage %s not found, try kebab to camel and find again", stageName)
@@ -484,6 +538,20 @@ func execITask(taskValue reflect.Value, task *STask, odata jsonutils.JSONObject,
 		return
 	}
 
+	ctx, span := tracing.StartSpan(ctx, fmt.Sprintf("%s.%s", task.TaskName, stageName), tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("task.id", task.Id)
+	span.SetAttribute("task.object", fmt.Sprintf("%s/%s", task.ObjName, task.ObjId))
+	if taskFailed {
+		if reason, _ := data.Get("__reason__"); reason != nil {
+			span.SetError(reason.String())
+		}
+	}
+	if span != nil && len(ctxData.TraceParent) == 0 {
+		// a task started out of any trace, e.g. by a cronjob, continues the trace of its first stage
+		ctxData.TraceParent = span.SpanContext.Traceparent()
+	}
+
 	params := make([]reflect.Value, 3)
 	params[0] = reflect.ValueOf(ctx)
 
@@ -550,6 +618,14 @@ func execITask(taskValue reflect.Value, task *STask, odata jsonutils.JSONObject,
 	log.Debugf("Call %s %s %#v", task.TaskName, stageName, params)
 	funcValue.Call(params)
 
+	if task.IsCanceled() {
+		// only the cancel body reaches a canceled task, which must not stay open even if the failed handler does not fail it
+		if latest := TaskManager.fetchTask(task.Id); latest != nil && !latest.IsFinished() {
+			reason, _ := data.Get("__reason__")
+			latest.SetStageFailed(ctx, reason)
+		}
+	}
+
 	// call save request context
 	saveRequestContextFuncValue := taskValue.MethodByName("SaveRequestContext")
 	saveRequestContextFuncValue.Call([]reflect.Value{reflect.ValueOf(&ctxData)})
@@ -606,7 +682,34 @@ func (self *STask) SaveParams(data *jsonutils.JSONDict) error {
 	return self.SetStage("", data)
 }
 
+// defaultStageTimeout bounds the stages switched by SetStage, so that a task
+// waiting for a callback which never comes is failed by the stage timeout checker
+var defaultStageTimeout time.Duration
+
+// SetDefaultStageTimeout sets the timeout of stages without explicit timeout, 0 means no timeout
+func SetDefaultStageTimeout(timeout time.Duration) {
+	defaultStageTimeout = timeout
+}
+
+func defaultStageDeadline(stageName string) time.Time {
+	if defaultStageTimeout <= 0 || utils.IsInStringArray(stageName, []string{TASK_STAGE_COMPLETE, TASK_STAGE_FAILED}) {
+		return time.Time{}
+	}
+	return timeutils.UtcNow().Add(defaultStageTimeout)
+}
+
 func (self *STask) SetStage(stageName string, data *jsonutils.JSONDict) error {
+	return self.setStage(stageName, data, defaultStageDeadline(stageName))
+}
+
+// SetStageWithTimeout switches to stageName and expects the task to leave it
+// within timeout, otherwise OnTimeout, or the failed handler of the stage
+// if OnTimeout is not implemented, is called by the stage timeout checker
+func (self *STask) SetStageWithTimeout(stageName string, data *jsonutils.JSONDict, timeout time.Duration) error {
+	return self.setStage(stageName, data, timeutils.UtcNow().Add(timeout))
+}
+
+func (self *STask) setStage(stageName string, data *jsonutils.JSONDict, deadline time.Time) error {
 	_, err := db.Update(self, func() error {
 		params := jsonutils.NewDict()
 		params.Update(self.Params)
@@ -625,6 +728,7 @@ func (self *STask) SetStage(stageName string, data *jsonutils.JSONDict) error {
 			stageData.Add(jsonutils.NewTimeString(time.Now()), "complete_at")
 			stageList.Add(stageData)
 			self.Stage = stageName
+			self.StageDeadline = deadline
 		}
 		self.Params = params
 		return nil
@@ -635,6 +739,14 @@ func (self *STask) SetStage(stageName string, data *jsonutils.JSONDict) error {
 	return err
 }
 
+func (self *STask) IsFinished() bool {
+	return utils.IsInStringArray(self.Stage, []string{TASK_STAGE_COMPLETE, TASK_STAGE_FAILED})
+}
+
+func (self *STask) IsCanceled() bool {
+	return self.Params != nil && self.Params.Contains(TASK_CANCELED_KEY)
+}
+
 func (self *STask) GetObjectIdStr() string {
 	if self.ObjId == MULTI_OBJECTS_ID {
 		return strings.Join(TaskObjectManager.GetObjectIds(self), ",")
@@ -646,6 +758,7 @@ func (self *STask) GetObjectIdStr() string {
 func (self *STask) SetStageComplete(ctx context.Context, data *jsonutils.JSONDict) {
 	log.Infof("XXX TASK %s complete", self.TaskName)
 	self.SetStage(TASK_STAGE_COMPLETE, data)
+	tasksFinished.WithLabelValues(self.TaskName, TASK_STAGE_COMPLETE).Inc()
 	if data == nil {
 		data = jsonutils.NewDict()
 	}
@@ -681,6 +794,7 @@ func (self *STask) SetStageFailed(ctx context.Context, reason jsonutils.JSONObje
 	data := jsonutils.NewDict()
 	data.Add(reason, "__failed_reason")
 	self.SetStage(TASK_STAGE_FAILED, data)
+	tasksFinished.WithLabelValues(self.TaskName, TASK_STAGE_FAILED).Inc()
 	self.NotifyParentTaskFailure(ctx, reason)
 }
 
@@ -0,0 +1,178 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/util/timeutils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func (manager *STaskManager) fetchOpenTasks(q func(q *sqlchemy.SQuery) *sqlchemy.SQuery) ([]STask, error) {
+	query := manager.Query().NotIn("stage", []string{TASK_STAGE_COMPLETE, TASK_STAGE_FAILED})
+	tasks := make([]STask, 0)
+	err := db.FetchModelObjects(manager, q(query), &tasks)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// CheckStageTimeout is a cronjob that dispatches tasks whose stage deadline has passed
+func (manager *STaskManager) CheckStageTimeout(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	tasks, err := manager.fetchOpenTasks(func(q *sqlchemy.SQuery) *sqlchemy.SQuery {
+		return q.LT("stage_deadline", timeutils.UtcNow())
+	})
+	if err != nil {
+		log.Errorf("fetch timeout tasks fail: %s", err)
+		return
+	}
+	for i := range tasks {
+		tasks[i].fireStageTimeout(ctx)
+	}
+}
+
+func (self *STask) fireStageTimeout(ctx context.Context) {
+	lockman.LockRawObject(ctx, "tasks", self.Id)
+	defer lockman.ReleaseRawObject(ctx, "tasks", self.Id)
+
+	stage := self.Stage
+	deadline := self.StageDeadline
+	_, err := db.Update(self, func() error {
+		self.StageDeadline = time.Time{}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("clear stage deadline of task %s fail: %s", self.Id, err)
+		return
+	}
+	log.Warningf("Task %s(%s) stage %s timeout at %s", self.TaskName, self.Id, stage, timeutils.FullIsoTime(deadline))
+	err = self.ScheduleRun(timeoutBody(stage))
+	if err != nil {
+		log.Errorf("schedule timeout of task %s fail: %s", self.Id, err)
+	}
+}
+
+func timeoutBody(stage string) *jsonutils.JSONDict {
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewString("error"), "__status__")
+	body.Add(jsonutils.NewString(fmt.Sprintf("stage %s timeout", stage)), "__reason__")
+	body.Add(jsonutils.NewString(stage), TASK_TIMEOUT_KEY)
+	return body
+}
+
+func (self *STask) abandon(reason string) error {
+	failed := jsonutils.NewDict()
+	failed.Add(jsonutils.NewString(self.Stage), "stage")
+	failed.Add(jsonutils.NewString(reason), "reason")
+	data := jsonutils.NewDict()
+	data.Add(failed, "__failed_reason")
+	return self.SetStage(TASK_STAGE_FAILED, data)
+}
+
+// RecoverTasks settles tasks left open by a dead process. It must be run once
+// when the service starts, by the elected leader only, as a task is considered
+// orphaned when it has not been updated within gracePeriod before the start,
+// tasks idle longer than lookback are ignored:
+//   - tasks of unknown type are marked failed without calling any handler
+//   - canceled tasks are dispatched to their failed handler again
+//   - tasks that never left on_init are scheduled to run again
+//   - tasks waiting for a callback or subtasks without stage deadline get the
+//     default stage deadline, so that the stage timeout checker fails them if
+//     the callback never comes
+//   - tasks with a stage deadline, or in on_init with pending local subtasks,
+//     are left to the stage timeout checker and the subtasks
+func (manager *STaskManager) RecoverTasks(ctx context.Context, gracePeriod time.Duration, lookback time.Duration) {
+	manager.recoverTasks(ctx, timeutils.UtcNow(), gracePeriod, lookback)
+}
+
+func (manager *STaskManager) recoverTasks(ctx context.Context, now time.Time, gracePeriod time.Duration, lookback time.Duration) {
+	tasks, err := manager.fetchOpenTasks(func(q *sqlchemy.SQuery) *sqlchemy.SQuery {
+		return q.LT("updated_at", now.Add(-gracePeriod)).GE("updated_at", now.Add(-lookback))
+	})
+	if err != nil {
+		log.Errorf("fetch open tasks fail: %s", err)
+		return
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		err := task.recover(ctx)
+		if err != nil {
+			log.Errorf("recover task %s(%s) at stage %s fail: %s", task.TaskName, task.Id, task.Stage, err)
+		}
+	}
+}
+
+const (
+	recoverNone    = ""
+	recoverAbandon = "abandon"
+	recoverCancel  = "cancel"
+	recoverRerun   = "rerun"
+	recoverTimeout = "timeout"
+)
+
+// recoverAction decides how a task left by a dead process is recovered,
+// hasSubtasks is only queried when the task may be rerun
+func (self *STask) recoverAction(hasSubtasks func() bool) string {
+	switch {
+	case !isTaskExist(self.TaskName):
+		return recoverAbandon
+	case self.IsCanceled():
+		return recoverCancel
+	case !self.StageDeadline.IsZero():
+		return recoverNone
+	case self.Stage == TASK_INIT_STAGE && !hasSubtasks():
+		return recoverRerun
+	case self.Stage != TASK_INIT_STAGE && defaultStageTimeout > 0:
+		return recoverTimeout
+	}
+	return recoverNone
+}
+
+func (self *STask) recover(ctx context.Context) error {
+	lockman.LockRawObject(ctx, "tasks", self.Id)
+	defer lockman.ReleaseRawObject(ctx, "tasks", self.Id)
+
+	action := self.recoverAction(func() bool {
+		return len(SubTaskManager.GetInitSubtasks(self.Id, self.Stage)) > 0
+	})
+	if action != recoverNone {
+		log.Infof("recover task %s(%s) at stage %s: %s", self.TaskName, self.Id, self.Stage, action)
+	}
+	switch action {
+	case recoverAbandon:
+		return self.abandon(fmt.Sprintf("task %s not found", self.TaskName))
+	case recoverCancel:
+		return self.ScheduleRun(self.cancelBody())
+	case recoverRerun:
+		return self.ScheduleRun(nil)
+	case recoverTimeout:
+		_, err := db.Update(self, func() error {
+			self.StageDeadline = defaultStageDeadline(self.Stage)
+			return nil
+		})
+		return err
+	}
+	return nil
+}
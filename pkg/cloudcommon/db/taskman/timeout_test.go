@@ -0,0 +1,187 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+type testTimeoutTask struct {
+	STask
+}
+
+func (self *testTimeoutTask) OnWait(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+}
+
+func (self *testTimeoutTask) OnWaitFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+}
+
+func (self *testTimeoutTask) OnTimeout(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+}
+
+type testNoTimeoutTask struct {
+	STask
+}
+
+func (self *testNoTimeoutTask) OnWait(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+}
+
+func (self *testNoTimeoutTask) OnWaitFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+}
+
+func init() {
+	RegisterTask(testTimeoutTask{})
+}
+
+func TestStageTimeout(t *testing.T) {
+	cases := []struct {
+		name      string
+		taskType  reflect.Type
+		stage     string
+		accept    bool
+		wantStage string
+	}{
+		{
+			name:      "expired stage with OnTimeout",
+			taskType:  reflect.TypeOf(testTimeoutTask{}),
+			stage:     "OnWait",
+			accept:    true,
+			wantStage: TASK_TIMEOUT_HANDLER,
+		},
+		{
+			name:      "expired stage fallback to failed handler",
+			taskType:  reflect.TypeOf(testNoTimeoutTask{}),
+			stage:     "OnWait",
+			accept:    true,
+			wantStage: "OnWaitFailed",
+		},
+		{
+			name:     "stage already left",
+			taskType: reflect.TypeOf(testTimeoutTask{}),
+			stage:    "OnNext",
+			accept:   false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			task := &STask{TaskName: c.taskType.Name(), Stage: c.stage, Params: jsonutils.NewDict()}
+			body := timeoutBody("OnWait")
+			if status, _ := body.GetString("__status__"); status != "error" {
+				t.Fatalf("timeout body should fail the stage, got status %q", status)
+			}
+			timeoutStage, accept := task.acceptData(body)
+			if accept != c.accept {
+				t.Fatalf("want accept %v got %v", c.accept, accept)
+			}
+			if !accept {
+				return
+			}
+			stageName, funcValue := task.stageHandler(reflect.New(c.taskType), true, timeoutStage)
+			if stageName != c.wantStage {
+				t.Errorf("want handler %s got %s", c.wantStage, stageName)
+			}
+			if !funcValue.IsValid() {
+				t.Errorf("handler %s not found", stageName)
+			}
+		})
+	}
+}
+
+func TestRecoverAction(t *testing.T) {
+	canceled := jsonutils.NewDict()
+	canceled.Add(jsonutils.NewDict(), TASK_CANCELED_KEY)
+	name := reflect.TypeOf(testTimeoutTask{}).Name()
+	cases := []struct {
+		name        string
+		task        STask
+		hasSubtasks bool
+		want        string
+	}{
+		{
+			name: "unknown task type",
+			task: STask{TaskName: "NoSuchTask", Stage: TASK_INIT_STAGE, Params: jsonutils.NewDict()},
+			want: recoverAbandon,
+		},
+		{
+			name: "canceled",
+			task: STask{TaskName: name, Stage: "OnWait", Params: canceled},
+			want: recoverCancel,
+		},
+		{
+			name: "never left on_init",
+			task: STask{TaskName: name, Stage: TASK_INIT_STAGE, Params: jsonutils.NewDict()},
+			want: recoverRerun,
+		},
+		{
+			name:        "on_init with pending subtasks",
+			task:        STask{TaskName: name, Stage: TASK_INIT_STAGE, Params: jsonutils.NewDict()},
+			hasSubtasks: true,
+			want:        recoverNone,
+		},
+		{
+			name: "on_init with stage deadline",
+			task: STask{TaskName: name, Stage: TASK_INIT_STAGE, Params: jsonutils.NewDict(), StageDeadline: time.Now()},
+			want: recoverNone,
+		},
+		{
+			name: "waiting for callback",
+			task: STask{TaskName: name, Stage: "OnWait", Params: jsonutils.NewDict()},
+			want: recoverNone,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.task.recoverAction(func() bool { return c.hasSubtasks })
+			if got != c.want {
+				t.Errorf("want %q got %q", c.want, got)
+			}
+		})
+	}
+
+	SetDefaultStageTimeout(time.Hour)
+	defer SetDefaultStageTimeout(0)
+	waiting := STask{TaskName: name, Stage: "OnWait", Params: jsonutils.NewDict()}
+	if got := waiting.recoverAction(func() bool { return false }); got != recoverTimeout {
+		t.Errorf("waiting for callback without deadline: want %q got %q", recoverTimeout, got)
+	}
+	waiting.StageDeadline = time.Now()
+	if got := waiting.recoverAction(func() bool { return false }); got != recoverNone {
+		t.Errorf("waiting for callback with deadline: want %q got %q", recoverNone, got)
+	}
+}
+
+func TestDefaultStageDeadline(t *testing.T) {
+	if !defaultStageDeadline("OnWait").IsZero() {
+		t.Errorf("no deadline without default stage timeout")
+	}
+	SetDefaultStageTimeout(time.Hour)
+	defer SetDefaultStageTimeout(0)
+	deadline := defaultStageDeadline("OnWait")
+	if d := time.Until(deadline); d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("deadline should be an hour later, got %s", deadline)
+	}
+	for _, stage := range []string{TASK_STAGE_COMPLETE, TASK_STAGE_FAILED} {
+		if !defaultStageDeadline(stage).IsZero() {
+			t.Errorf("finished stage %s should have no deadline", stage)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudcommon/webhook"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	deliveryTimeout = 15 * time.Second
+
+	// response body kept in delivery history
+	maxResponseBody = 1024
+)
+
+func eventSource(resourceType string) string {
+	return fmt.Sprintf("/onecloud/%s/%s", consts.GetServiceType(), resourceType)
+}
+
+func eventType(resourceType, action string) string {
+	return fmt.Sprintf("%s.%s.%s", api.CLOUDEVENTS_TYPE_PREFIX, resourceType, action)
+}
+
+func newResourceEvent(resourceType, action, subject string, obj, oldObj *jsonutils.JSONDict) *api.CloudEvent {
+	data := &api.ResourceEventData{
+		ResourceType: resourceType,
+		Action:       action,
+	}
+	if obj != nil {
+		data.Object = json.RawMessage(obj.String())
+	}
+	if oldObj != nil {
+		data.OldObject = json.RawMessage(oldObj.String())
+	}
+	return &api.CloudEvent{
+		SpecVersion:     api.CLOUDEVENTS_SPEC_VERSION,
+		Id:              db.DefaultUUIDGenerator(),
+		Source:          eventSource(resourceType),
+		Type:            eventType(resourceType, action),
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+func newPingEvent(webhookId string) *api.CloudEvent {
+	event := newResourceEvent(WebhookManager.KeywordPlural(), "ping", webhookId, nil, nil)
+	event.Data.Object = json.RawMessage(jsonutils.Marshal(map[string]string{"id": webhookId}).String())
+	return event
+}
+
+func marshalEvent(event *api.CloudEvent) (string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", errors.Wrap(err, "json.Marshal")
+	}
+	return string(payload), nil
+}
+
+// signPayload returns the value of signature header, the signed content is
+// timestamp and body joined by a dot so that receivers can reject replays
+func signPayload(secret string, timestamp int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type sSendResult struct {
+	statusCode int
+	err        error
+}
+
+func sendEvent(ctx context.Context, url, secret, deliveryId, payload string) sSendResult {
+	header := http.Header{}
+	header.Set("Content-Type", api.CLOUDEVENTS_CONTENT_TYPE)
+	header.Set(api.HEADER_DELIVERY, deliveryId)
+	if len(secret) > 0 {
+		timestamp := time.Now().Unix()
+		header.Set(api.HEADER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+		header.Set(api.HEADER_SIGNATURE, signPayload(secret, timestamp, payload))
+	}
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	client := httputils.GetTimeoutClient(deliveryTimeout)
+	resp, err := httputils.Request(client, ctx, httputils.POST, url, header, bytes.NewReader([]byte(payload)), false)
+	if err != nil {
+		return sSendResult{err: err}
+	}
+	defer resp.Body.Close()
+	result := sSendResult{statusCode: resp.StatusCode}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+		result.err = errors.Errorf("status %d: %s", resp.StatusCode, string(body))
+	} else {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	}
+	return result
+}
@@ -0,0 +1,378 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudcommon/webhook"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	deliveryMinBackoff = 10 * time.Second
+	deliveryMaxBackoff = time.Hour
+	deliveryBatchSize  = 100
+)
+
+type SWebhookDeliveryManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+// SWebhookDelivery is a CloudEvent queued for a webhook, succeeded and dead
+// deliveries are kept as history
+type SWebhookDelivery struct {
+	db.SStandaloneAnonResourceBase
+
+	WebhookId    string `width:"128" charset:"ascii" nullable:"false" index:"true" list:"admin"`
+	EventId      string `width:"128" charset:"ascii" nullable:"false" index:"true" list:"admin"`
+	EventType    string `width:"128" charset:"ascii" nullable:"false" list:"admin"`
+	ResourceType string `width:"64" charset:"ascii" nullable:"false" list:"admin"`
+	Action       string `width:"16" charset:"ascii" nullable:"false" list:"admin"`
+	// id of resource
+	Subject string `width:"128" charset:"ascii" nullable:"true" list:"admin"`
+	// CloudEvent in json
+	Payload string `length:"medium" charset:"utf8" nullable:"false" get:"admin"`
+
+	Status         string    `width:"16" charset:"ascii" nullable:"false" index:"true" list:"admin"`
+	Attempts       int       `nullable:"false" default:"0" list:"admin"`
+	NextAttemptAt  time.Time `nullable:"true" index:"true" list:"admin"`
+	LastStatusCode int       `nullable:"false" default:"0" list:"admin"`
+	LastError      string    `width:"256" charset:"utf8" nullable:"true" list:"admin"`
+	DeliveredAt    time.Time `nullable:"true" list:"admin"`
+}
+
+var WebhookDeliveryManager *SWebhookDeliveryManager
+
+func init() {
+	WebhookDeliveryManager = &SWebhookDeliveryManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SWebhookDelivery{},
+			"webhook_deliveries_tbl",
+			"webhook_delivery",
+			"webhook_deliveries",
+		),
+	}
+	WebhookDeliveryManager.SetVirtualObject(WebhookDeliveryManager)
+}
+
+func (manager *SWebhookDeliveryManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (manager *SWebhookDeliveryManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return false
+}
+
+func (delivery *SWebhookDelivery) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	return false
+}
+
+// Webhook投递记录列表
+func (manager *SWebhookDeliveryManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.WebhookDeliveryListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.ListItemFilter")
+	}
+	if len(query.WebhookId) > 0 {
+		webhook, err := WebhookManager.FetchByIdOrName(userCred, query.WebhookId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(WebhookManager.Keyword(), query.WebhookId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("webhook_id", webhook.GetId())
+	}
+	if len(query.Status) > 0 {
+		q = q.In("status", query.Status)
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.Equals("resource_type", query.ResourceType)
+	}
+	if len(query.EventId) > 0 {
+		q = q.Equals("event_id", query.EventId)
+	}
+	if len(query.Subject) > 0 {
+		q = q.Equals("subject", query.Subject)
+	}
+	return q, nil
+}
+
+func (manager *SWebhookDeliveryManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.WebhookDeliveryDetails {
+	rows := make([]api.WebhookDeliveryDetails, len(objs))
+	stdRows := manager.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	webhookIds := make([]string, 0)
+	for i := range rows {
+		rows[i] = api.WebhookDeliveryDetails{
+			StandaloneAnonResourceDetails: stdRows[i],
+		}
+		webhookIds = append(webhookIds, objs[i].(*SWebhookDelivery).WebhookId)
+	}
+	webhooks := make(map[string]SWebhook)
+	err := db.FetchStandaloneObjectsByIds(WebhookManager, webhookIds, &webhooks)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds: %s", err)
+		return rows
+	}
+	for i := range rows {
+		if webhook, ok := webhooks[objs[i].(*SWebhookDelivery).WebhookId]; ok {
+			rows[i].Webhook = webhook.Name
+		}
+	}
+	return rows
+}
+
+func (delivery *SWebhookDelivery) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.WebhookDeliveryDetails, error) {
+	return api.WebhookDeliveryDetails{}, nil
+}
+
+func (delivery *SWebhookDelivery) AllowPerformRedeliver(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, delivery, "redeliver")
+}
+
+// 重新投递，用于处理死信或者要求接收方重放
+func (delivery *SWebhookDelivery) PerformRedeliver(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if utils.IsInStringArray(delivery.Status, []string{api.DELIVERY_STATUS_PENDING, api.DELIVERY_STATUS_RETRYING}) {
+		return nil, httperrors.NewInvalidStatusError("delivery is %s", delivery.Status)
+	}
+	_, err := db.Update(delivery, func() error {
+		delivery.Status = api.DELIVERY_STATUS_PENDING
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Update")
+	}
+	return nil, nil
+}
+
+func (manager *SWebhookDeliveryManager) enqueue(ctx context.Context, webhookId string, event *api.CloudEvent, payload string) error {
+	m, err := db.NewModelObject(manager)
+	if err != nil {
+		return errors.Wrap(err, "NewModelObject")
+	}
+	delivery := m.(*SWebhookDelivery)
+	delivery.WebhookId = webhookId
+	delivery.EventId = event.Id
+	delivery.EventType = event.Type
+	delivery.ResourceType = event.Data.ResourceType
+	delivery.Action = event.Data.Action
+	delivery.Subject = event.Subject
+	delivery.Payload = payload
+	delivery.Status = api.DELIVERY_STATUS_PENDING
+	delivery.NextAttemptAt = time.Now()
+	return manager.TableSpec().Insert(ctx, delivery)
+}
+
+func (manager *SWebhookDeliveryManager) countByStatus(webhookIds []string) (map[string]map[string]int, error) {
+	q := manager.Query("webhook_id", "status")
+	q = q.In("webhook_id", webhookIds)
+	q = q.In("status", []string{api.DELIVERY_STATUS_PENDING, api.DELIVERY_STATUS_RETRYING, api.DELIVERY_STATUS_DEAD})
+	q = q.AppendField(sqlchemy.COUNT("count"))
+	q = q.GroupBy(q.Field("webhook_id"), q.Field("status"))
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "q.Rows")
+	}
+	defer rows.Close()
+	ret := make(map[string]map[string]int)
+	for rows.Next() {
+		var webhookId, status string
+		var count int
+		err := rows.Scan(&webhookId, &status, &count)
+		if err != nil {
+			return nil, errors.Wrap(err, "rows.Scan")
+		}
+		if _, ok := ret[webhookId]; !ok {
+			ret[webhookId] = make(map[string]int)
+		}
+		ret[webhookId][status] = count
+	}
+	return ret, nil
+}
+
+func (manager *SWebhookDeliveryManager) redeliverDead(webhookId string) (int64, error) {
+	result, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf(
+			"update %s set status = ?, attempts = 0, next_attempt_at = ? where webhook_id = ? and status = ?",
+			manager.TableSpec().Name(),
+		), api.DELIVERY_STATUS_PENDING, time.Now().UTC(), webhookId, api.DELIVERY_STATUS_DEAD,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (manager *SWebhookDeliveryManager) purgeWebhook(webhookId string) error {
+	_, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf("delete from %s where webhook_id = ?", manager.TableSpec().Name()),
+		webhookId,
+	)
+	return err
+}
+
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := deliveryMinBackoff
+	for i := 1; i < attempts && backoff < deliveryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > deliveryMaxBackoff {
+		backoff = deliveryMaxBackoff
+	}
+	return backoff
+}
+
+func (delivery *SWebhookDelivery) deliver(ctx context.Context, webhook *SWebhook) error {
+	secret, err := webhook.getSecret()
+	if err != nil {
+		return errors.Wrapf(err, "get secret of webhook %s", webhook.Name)
+	}
+	result := sendEvent(ctx, webhook.Url, secret, delivery.Id, delivery.Payload)
+	now := time.Now()
+	_, err = db.Update(delivery, func() error {
+		delivery.Attempts += 1
+		delivery.LastStatusCode = result.statusCode
+		if result.err == nil {
+			delivery.Status = api.DELIVERY_STATUS_SUCCEEDED
+			delivery.LastError = ""
+			delivery.DeliveredAt = now
+			delivery.NextAttemptAt = time.Time{}
+		} else {
+			delivery.LastError = utils.TruncateString(result.err.Error(), 250)
+			if delivery.Attempts >= webhook.MaxAttempts {
+				delivery.Status = api.DELIVERY_STATUS_DEAD
+				delivery.NextAttemptAt = time.Time{}
+			} else {
+				delivery.Status = api.DELIVERY_STATUS_RETRYING
+				delivery.NextAttemptAt = now.Add(deliveryBackoff(delivery.Attempts))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "update delivery")
+	}
+	_, err = db.Update(webhook, func() error {
+		webhook.LastDeliveryAt = now
+		if result.err == nil {
+			webhook.DeliveredCount += 1
+			webhook.LastError = ""
+			webhook.Status = api.WEBHOOK_STATUS_READY
+		} else {
+			webhook.LastError = delivery.LastError
+			webhook.Status = api.WEBHOOK_STATUS_ERROR
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "update webhook")
+	}
+	return result.err
+}
+
+// DeliverWebhookEvents sends due deliveries, a failure postpones the rest
+// deliveries of that webhook till next round
+func DeliverWebhookEvents(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	deliveries := make([]SWebhookDelivery, 0)
+	q := WebhookDeliveryManager.Query()
+	q = q.In("status", []string{api.DELIVERY_STATUS_PENDING, api.DELIVERY_STATUS_RETRYING})
+	q = q.LE("next_attempt_at", time.Now().UTC())
+	q = q.Asc("next_attempt_at").Limit(deliveryBatchSize)
+	err := db.FetchModelObjects(WebhookDeliveryManager, q, &deliveries)
+	if err != nil {
+		log.Errorf("fetch webhook deliveries: %s", err)
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	webhookIds := make([]string, 0)
+	for i := range deliveries {
+		webhookIds = append(webhookIds, deliveries[i].WebhookId)
+	}
+	webhookMap := make(map[string]SWebhook)
+	err = db.FetchStandaloneObjectsByIds(WebhookManager, webhookIds, &webhookMap)
+	if err != nil {
+		log.Errorf("fetch webhooks: %s", err)
+		return
+	}
+	webhooks := make(map[string]*SWebhook)
+	for id := range webhookMap {
+		webhook := webhookMap[id]
+		webhook.SetModelManager(WebhookManager, &webhook)
+		webhooks[id] = &webhook
+	}
+	failed := make(map[string]bool)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, ok := webhooks[delivery.WebhookId]
+		if !ok || !webhook.Enabled.Bool() {
+			// keep the delivery queued till webhook is enabled again
+			continue
+		}
+		if failed[webhook.Id] {
+			continue
+		}
+		err := delivery.deliver(ctx, webhook)
+		if err != nil {
+			log.Warningf("deliver event %s to webhook %s: %s", delivery.EventId, webhook.Name, err)
+			failed[webhook.Id] = true
+		}
+	}
+}
+
+// CleanupWebhookDeliveries removes succeeded deliveries older than retention days
+func CleanupWebhookDeliveries(retentionDays int) func(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	return func(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+		if retentionDays <= 0 {
+			return
+		}
+		_, err := sqlchemy.GetDB().Exec(
+			fmt.Sprintf("delete from %s where status = ? and delivered_at < ?", WebhookDeliveryManager.TableSpec().Name()),
+			api.DELIVERY_STATUS_SUCCEEDED, time.Now().UTC().AddDate(0, 0, -retentionDays),
+		)
+		if err != nil {
+			log.Errorf("cleanup webhook deliveries: %s", err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudcommon/webhook"
+	"yunion.io/x/onecloud/pkg/cloudcommon/informer"
+)
+
+const (
+	subscriberType = "webhook"
+)
+
+// sSubscriber turns model changes reported by informer into webhook deliveries
+type sSubscriber struct{}
+
+// Init subscribes changes of resources of this service
+func Init() {
+	informer.AddSubscriber(&sSubscriber{})
+}
+
+func (s *sSubscriber) GetType() string {
+	return subscriberType
+}
+
+func (s *sSubscriber) IsResourceWatched(keywordPlural string) bool {
+	_, watched := WebhookManager.getRules()
+	return watched[keywordPlural]
+}
+
+func (s *sSubscriber) Create(ctx context.Context, obj *informer.ModelObject) error {
+	return s.publish(ctx, api.ACTION_CREATE, obj, nil)
+}
+
+func (s *sSubscriber) Update(ctx context.Context, obj *informer.ModelObject, oldObj *jsonutils.JSONDict) error {
+	return s.publish(ctx, api.ACTION_UPDATE, obj, oldObj)
+}
+
+func (s *sSubscriber) Delete(ctx context.Context, obj *informer.ModelObject) error {
+	return s.publish(ctx, api.ACTION_DELETE, obj, nil)
+}
+
+func (s *sSubscriber) publish(ctx context.Context, action string, obj *informer.ModelObject, oldObj *jsonutils.JSONDict) error {
+	rules, _ := WebhookManager.getRules()
+	var projectId string
+	if obj.Object != nil {
+		projectId, _ = obj.Object.GetString("tenant_id")
+	}
+	subject := obj.Id
+	if obj.IsJoint {
+		subject = obj.MasterId + "/" + obj.SlaveId
+	}
+	var event *api.CloudEvent
+	var payload string
+	for i := range rules {
+		if !rules[i].match(obj.KeywordPlural, action, projectId) {
+			continue
+		}
+		if event == nil {
+			// all webhooks share the same event id
+			event = newResourceEvent(obj.KeywordPlural, action, subject, obj.Object, oldObj)
+			var err error
+			payload, err = marshalEvent(event)
+			if err != nil {
+				return errors.Wrap(err, "marshalEvent")
+			}
+		}
+		err := WebhookDeliveryManager.enqueue(ctx, rules[i].id, event, payload)
+		if err != nil {
+			log.Errorf("enqueue event %s for webhook %s: %s", event.Id, rules[i].id, err)
+		}
+	}
+	return nil
+}
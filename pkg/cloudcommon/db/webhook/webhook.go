@@ -0,0 +1,483 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/cloudcommon/webhook"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	webhookCacheTTL = 30 * time.Second
+)
+
+type SWebhookManager struct {
+	db.SEnabledStatusStandaloneResourceBaseManager
+
+	cacheLock    sync.Mutex
+	cacheExpire  time.Time
+	cachedRules  []sWebhookRule
+	watchedTypes map[string]bool
+}
+
+// SWebhook subscribes changes of resources, events are delivered to Url in
+// CloudEvents format and signed with Secret
+type SWebhook struct {
+	db.SEnabledStatusStandaloneResourceBase
+
+	Url string `width:"512" charset:"ascii" nullable:"false" list:"admin" create:"admin_required" update:"admin"`
+	// encrypted with id as key
+	Secret string `width:"256" charset:"ascii" nullable:"true"`
+
+	ResourceTypes *jsonutils.JSONArray `nullable:"true" list:"admin" create:"admin_required" update:"admin"`
+	Actions       *jsonutils.JSONArray `nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+	ProjectId     string               `width:"128" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+
+	MaxAttempts int `nullable:"false" default:"8" list:"admin" create:"admin_optional" update:"admin"`
+
+	DeliveredCount int64     `nullable:"false" default:"0" list:"admin"`
+	LastError      string    `width:"256" charset:"utf8" nullable:"true" list:"admin"`
+	LastDeliveryAt time.Time `nullable:"true" list:"admin"`
+}
+
+var WebhookManager *SWebhookManager
+
+func init() {
+	WebhookManager = &SWebhookManager{
+		SEnabledStatusStandaloneResourceBaseManager: db.NewEnabledStatusStandaloneResourceBaseManager(
+			SWebhook{},
+			"webhooks_tbl",
+			"webhook",
+			"webhooks",
+		),
+	}
+	WebhookManager.SetVirtualObject(WebhookManager)
+}
+
+func (manager *SWebhookManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func validateWebhookUrl(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || len(parsed.Host) == 0 {
+		return httperrors.NewInputParameterError("invalid url %q", u)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return httperrors.NewInputParameterError("unsupported url scheme %q", parsed.Scheme)
+	}
+	return nil
+}
+
+func validateResourceTypes(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return nil, httperrors.NewMissingParameterError("resource_types")
+	}
+	plurals := make(map[string]bool)
+	for _, man := range db.GlobalModelManagerTables() {
+		plurals[man.KeywordPlural()] = true
+	}
+	ret := stringutils2.NewSortedStrings(nil)
+	for _, t := range types {
+		switch t {
+		case WebhookManager.KeywordPlural(), WebhookDeliveryManager.KeywordPlural():
+			return nil, httperrors.NewInputParameterError("resource type %s can not be subscribed", t)
+		}
+		if !plurals[t] {
+			return nil, httperrors.NewInputParameterError("unknown resource type %s", t)
+		}
+		ret = stringutils2.Append(ret, t)
+	}
+	return ret, nil
+}
+
+func validateActions(actions []string) error {
+	for _, action := range actions {
+		if !utils.IsInStringArray(action, api.ACTIONS) {
+			return httperrors.NewInputParameterError("invalid action %s, choices %s", action, api.ACTIONS)
+		}
+	}
+	return nil
+}
+
+func validateMaxAttempts(maxAttempts int) error {
+	if maxAttempts < 1 || maxAttempts > api.MAX_MAX_ATTEMPTS {
+		return httperrors.NewOutOfRangeError("max_attempts should be between 1 and %d", api.MAX_MAX_ATTEMPTS)
+	}
+	return nil
+}
+
+func validateProject(ctx context.Context, projectId string) (string, error) {
+	if len(projectId) == 0 {
+		return "", nil
+	}
+	tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, projectId)
+	if err != nil {
+		return "", httperrors.NewResourceNotFoundError2("project", projectId)
+	}
+	return tenant.GetId(), nil
+}
+
+func (manager *SWebhookManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.WebhookCreateInput) (api.WebhookCreateInput, error) {
+	var err error
+	input.EnabledStatusStandaloneResourceCreateInput, err = manager.SEnabledStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	err = validateWebhookUrl(input.Url)
+	if err != nil {
+		return input, err
+	}
+	input.ResourceTypes, err = validateResourceTypes(input.ResourceTypes)
+	if err != nil {
+		return input, err
+	}
+	err = validateActions(input.Actions)
+	if err != nil {
+		return input, err
+	}
+	input.ProjectId, err = validateProject(ctx, input.ProjectId)
+	if err != nil {
+		return input, err
+	}
+	if input.MaxAttempts == 0 {
+		input.MaxAttempts = api.DEFAULT_MAX_ATTEMPTS
+	}
+	err = validateMaxAttempts(input.MaxAttempts)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.WEBHOOK_STATUS_READY
+	if input.Enabled == nil {
+		enabled := true
+		input.Enabled = &enabled
+	}
+	return input, nil
+}
+
+func (webhook *SWebhook) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	webhook.SEnabledStatusStandaloneResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	secret, _ := data.GetString("secret")
+	if len(secret) > 0 {
+		err := webhook.saveSecret(secret)
+		if err != nil {
+			log.Errorf("save secret of webhook %s: %s", webhook.Name, err)
+		}
+	}
+	WebhookManager.invalidateCache()
+}
+
+func (webhook *SWebhook) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.WebhookUpdateInput) (api.WebhookUpdateInput, error) {
+	var err error
+	input.EnabledStatusStandaloneResourceBaseUpdateInput, err = webhook.SEnabledStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Url) > 0 {
+		err = validateWebhookUrl(input.Url)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.ResourceTypes != nil {
+		input.ResourceTypes, err = validateResourceTypes(input.ResourceTypes)
+		if err != nil {
+			return input, err
+		}
+	}
+	err = validateActions(input.Actions)
+	if err != nil {
+		return input, err
+	}
+	if input.ProjectId != nil {
+		projectId, err := validateProject(ctx, *input.ProjectId)
+		if err != nil {
+			return input, err
+		}
+		input.ProjectId = &projectId
+	}
+	if input.MaxAttempts != nil {
+		err = validateMaxAttempts(*input.MaxAttempts)
+		if err != nil {
+			return input, err
+		}
+	}
+	return input, nil
+}
+
+func (webhook *SWebhook) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	webhook.SEnabledStatusStandaloneResourceBase.PostUpdate(ctx, userCred, query, data)
+	if data.Contains("secret") {
+		secret, _ := data.GetString("secret")
+		err := webhook.saveSecret(secret)
+		if err != nil {
+			log.Errorf("save secret of webhook %s: %s", webhook.Name, err)
+		}
+	}
+	WebhookManager.invalidateCache()
+}
+
+func (webhook *SWebhook) PostDelete(ctx context.Context, userCred mcclient.TokenCredential) {
+	webhook.SEnabledStatusStandaloneResourceBase.PostDelete(ctx, userCred)
+	WebhookManager.invalidateCache()
+}
+
+func (webhook *SWebhook) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	err := WebhookDeliveryManager.purgeWebhook(webhook.Id)
+	if err != nil {
+		return errors.Wrap(err, "purge deliveries")
+	}
+	return webhook.SEnabledStatusStandaloneResourceBase.Delete(ctx, userCred)
+}
+
+func (webhook *SWebhook) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	ret, err := webhook.SEnabledStatusStandaloneResourceBase.PerformEnable(ctx, userCred, query, input)
+	WebhookManager.invalidateCache()
+	return ret, err
+}
+
+func (webhook *SWebhook) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	ret, err := webhook.SEnabledStatusStandaloneResourceBase.PerformDisable(ctx, userCred, query, input)
+	WebhookManager.invalidateCache()
+	return ret, err
+}
+
+func (webhook *SWebhook) saveSecret(secret string) error {
+	sec := ""
+	if len(secret) > 0 {
+		var err error
+		sec, err = utils.EncryptAESBase64(webhook.Id, secret)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := db.Update(webhook, func() error {
+		webhook.Secret = sec
+		return nil
+	})
+	return err
+}
+
+func (webhook *SWebhook) getSecret() (string, error) {
+	if len(webhook.Secret) == 0 {
+		return "", nil
+	}
+	return utils.DescryptAESBase64(webhook.Id, webhook.Secret)
+}
+
+func jsonArrayStrings(arr *jsonutils.JSONArray) []string {
+	if arr == nil {
+		return nil
+	}
+	return arr.GetStringArray()
+}
+
+func (webhook *SWebhook) GetResourceTypes() []string {
+	return jsonArrayStrings(webhook.ResourceTypes)
+}
+
+func (webhook *SWebhook) GetActions() []string {
+	return jsonArrayStrings(webhook.Actions)
+}
+
+// Webhook列表
+func (manager *SWebhookManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.WebhookListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.Contains("resource_types", fmt.Sprintf("%q", query.ResourceType))
+	}
+	if len(query.ProjectId) > 0 {
+		projectId, err := validateProject(ctx, query.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("project_id", projectId)
+	}
+	return q, nil
+}
+
+func (manager *SWebhookManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.WebhookDetails {
+	rows := make([]api.WebhookDetails, len(objs))
+	stdRows := manager.SEnabledStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	webhookIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.WebhookDetails{
+			EnabledStatusStandaloneResourceDetails: stdRows[i],
+		}
+		webhook := objs[i].(*SWebhook)
+		webhookIds[i] = webhook.Id
+		if len(webhook.ProjectId) > 0 {
+			tenant, err := db.TenantCacheManager.FetchTenantById(ctx, webhook.ProjectId)
+			if err == nil {
+				rows[i].Project = tenant.GetName()
+			}
+		}
+	}
+	counts, err := WebhookDeliveryManager.countByStatus(webhookIds)
+	if err != nil {
+		log.Errorf("count webhook deliveries: %s", err)
+		return rows
+	}
+	for i := range rows {
+		cnt := counts[webhookIds[i]]
+		rows[i].PendingCount = cnt[api.DELIVERY_STATUS_PENDING] + cnt[api.DELIVERY_STATUS_RETRYING]
+		rows[i].DeadCount = cnt[api.DELIVERY_STATUS_DEAD]
+	}
+	return rows
+}
+
+func (webhook *SWebhook) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.WebhookDetails, error) {
+	return api.WebhookDetails{}, nil
+}
+
+func (webhook *SWebhook) AllowPerformPing(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, webhook, "ping")
+}
+
+// 发送一个测试事件，同步返回投递结果
+func (webhook *SWebhook) PerformPing(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	event := newPingEvent(webhook.Id)
+	payload, err := marshalEvent(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalEvent")
+	}
+	secret, err := webhook.getSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, "getSecret")
+	}
+	result := sendEvent(ctx, webhook.Url, secret, event.Id, payload)
+	ret := jsonutils.NewDict()
+	ret.Add(jsonutils.NewString(event.Id), "event_id")
+	ret.Add(jsonutils.NewInt(int64(result.statusCode)), "status_code")
+	if result.err != nil {
+		ret.Add(jsonutils.JSONFalse, "ok")
+		ret.Add(jsonutils.NewString(result.err.Error()), "error")
+	} else {
+		ret.Add(jsonutils.JSONTrue, "ok")
+	}
+	return ret, nil
+}
+
+func (webhook *SWebhook) AllowPerformRedeliver(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, webhook, "redeliver")
+}
+
+// 重新投递所有死信
+func (webhook *SWebhook) PerformRedeliver(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	cnt, err := WebhookDeliveryManager.redeliverDead(webhook.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "redeliverDead")
+	}
+	db.OpsLog.LogEvent(webhook, "redeliver", fmt.Sprintf("%d dead deliveries", cnt), userCred)
+	ret := jsonutils.NewDict()
+	ret.Add(jsonutils.NewInt(int64(cnt)), "count")
+	return ret, nil
+}
+
+// sWebhookRule is the in memory form of an enabled webhook for event matching
+type sWebhookRule struct {
+	id            string
+	resourceTypes []string
+	actions       []string
+	projectId     string
+}
+
+func (rule *sWebhookRule) match(resourceType, action, projectId string) bool {
+	if !utils.IsInStringArray(resourceType, rule.resourceTypes) {
+		return false
+	}
+	if len(rule.actions) > 0 && !utils.IsInStringArray(action, rule.actions) {
+		return false
+	}
+	if len(rule.projectId) > 0 && rule.projectId != projectId {
+		return false
+	}
+	return true
+}
+
+func (manager *SWebhookManager) invalidateCache() {
+	manager.cacheLock.Lock()
+	defer manager.cacheLock.Unlock()
+
+	manager.cacheExpire = time.Time{}
+}
+
+func (manager *SWebhookManager) getRules() ([]sWebhookRule, map[string]bool) {
+	manager.cacheLock.Lock()
+	defer manager.cacheLock.Unlock()
+
+	if time.Now().Before(manager.cacheExpire) {
+		return manager.cachedRules, manager.watchedTypes
+	}
+	webhooks := make([]SWebhook, 0)
+	q := manager.Query().IsTrue("enabled")
+	err := db.FetchModelObjects(manager, q, &webhooks)
+	if err != nil {
+		// keep using the stale rules and retry later
+		log.Errorf("fetch webhooks: %s", err)
+		return manager.cachedRules, manager.watchedTypes
+	}
+	rules := make([]sWebhookRule, len(webhooks))
+	watched := make(map[string]bool)
+	for i := range webhooks {
+		rules[i] = sWebhookRule{
+			id:            webhooks[i].Id,
+			resourceTypes: webhooks[i].GetResourceTypes(),
+			actions:       webhooks[i].GetActions(),
+			projectId:     webhooks[i].ProjectId,
+		}
+		for _, t := range rules[i].resourceTypes {
+			watched[t] = true
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].id < rules[j].id })
+	manager.cachedRules = rules
+	manager.watchedTypes = watched
+	manager.cacheExpire = time.Now().Add(webhookCacheTTL)
+	return rules, watched
+}
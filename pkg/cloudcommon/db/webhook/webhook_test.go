@@ -0,0 +1,113 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudcommon/webhook"
+)
+
+func TestSignPayload(t *testing.T) {
+	// echo -n '1600000000.{"id":"abc"}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=29038468817da624ab4030a696a4236f97104c4a8b1ebf6f933ec40f9b2320c7"
+	got := signPayload("secret", 1600000000, `{"id":"abc"}`)
+	if got != want {
+		t.Errorf("signature want %s got %s", want, got)
+	}
+	if signPayload("other", 1600000000, `{"id":"abc"}`) == got {
+		t.Errorf("signature should depend on secret")
+	}
+	if signPayload("secret", 1600000001, `{"id":"abc"}`) == got {
+		t.Errorf("signature should depend on timestamp")
+	}
+}
+
+func TestResourceEvent(t *testing.T) {
+	obj := jsonutils.NewDict()
+	obj.Add(jsonutils.NewString("vm1"), "name")
+	oldObj := jsonutils.NewDict()
+	oldObj.Add(jsonutils.NewString("vm0"), "name")
+	event := newResourceEvent("servers", api.ACTION_UPDATE, "guest-id", obj, oldObj)
+	payload, err := marshalEvent(event)
+	if err != nil {
+		t.Fatalf("marshalEvent: %s", err)
+	}
+	parsed := map[string]interface{}{}
+	err = json.Unmarshal([]byte(payload), &parsed)
+	if err != nil {
+		t.Fatalf("unmarshal %s: %s", payload, err)
+	}
+	for k, v := range map[string]string{
+		"specversion":     "1.0",
+		"type":            "io.yunion.onecloud.servers.update",
+		"subject":         "guest-id",
+		"datacontenttype": "application/json",
+	} {
+		if parsed[k] != v {
+			t.Errorf("%s want %s got %v", k, v, parsed[k])
+		}
+	}
+	if id, _ := parsed["id"].(string); len(id) == 0 {
+		t.Errorf("empty event id")
+	}
+	if _, err := time.Parse(time.RFC3339, parsed["time"].(string)); err != nil {
+		t.Errorf("invalid time %v", parsed["time"])
+	}
+	data := parsed["data"].(map[string]interface{})
+	if data["object"].(map[string]interface{})["name"] != "vm1" || data["old_object"].(map[string]interface{})["name"] != "vm0" {
+		t.Errorf("unexpected data %v", data)
+	}
+}
+
+func TestWebhookRuleMatch(t *testing.T) {
+	rule := sWebhookRule{
+		resourceTypes: []string{"servers", "disks"},
+		actions:       []string{api.ACTION_DELETE},
+		projectId:     "p1",
+	}
+	cases := []struct {
+		resourceType string
+		action       string
+		projectId    string
+		want         bool
+	}{
+		{"servers", api.ACTION_DELETE, "p1", true},
+		{"servers", api.ACTION_CREATE, "p1", false},
+		{"hosts", api.ACTION_DELETE, "p1", false},
+		{"disks", api.ACTION_DELETE, "p2", false},
+	}
+	for _, c := range cases {
+		if got := rule.match(c.resourceType, c.action, c.projectId); got != c.want {
+			t.Errorf("match(%s, %s, %s) want %v got %v", c.resourceType, c.action, c.projectId, c.want, got)
+		}
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	if got := deliveryBackoff(1); got != deliveryMinBackoff {
+		t.Errorf("first backoff %s", got)
+	}
+	if got := deliveryBackoff(3); got != 4*deliveryMinBackoff {
+		t.Errorf("third backoff %s", got)
+	}
+	if got := deliveryBackoff(100); got != deliveryMaxBackoff {
+		t.Errorf("backoff should be capped, got %s", got)
+	}
+}
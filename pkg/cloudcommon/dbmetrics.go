@@ -0,0 +1,94 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudcommon
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"yunion.io/x/log"
+)
+
+var (
+	dbOpenConnectionsDesc = prometheus.NewDesc(
+		"db_open_connections", "Number of established connections to the database, both in use and idle",
+		[]string{"db"}, nil,
+	)
+	dbInUseConnectionsDesc = prometheus.NewDesc(
+		"db_in_use_connections", "Number of connections to the database currently in use",
+		[]string{"db"}, nil,
+	)
+	dbIdleConnectionsDesc = prometheus.NewDesc(
+		"db_idle_connections", "Number of idle connections to the database",
+		[]string{"db"}, nil,
+	)
+	dbMaxOpenConnectionsDesc = prometheus.NewDesc(
+		"db_max_open_connections", "Maximal number of open connections to the database",
+		[]string{"db"}, nil,
+	)
+	dbWaitCountDesc = prometheus.NewDesc(
+		"db_wait_count_total", "Total number of connections waited for",
+		[]string{"db"}, nil,
+	)
+	dbWaitDurationDesc = prometheus.NewDesc(
+		"db_wait_duration_seconds_total", "Total time blocked waiting for a new connection",
+		[]string{"db"}, nil,
+	)
+	dbMaxIdleClosedDesc = prometheus.NewDesc(
+		"db_max_idle_closed_total", "Total number of connections closed due to max idle connections",
+		[]string{"db"}, nil,
+	)
+	dbMaxLifetimeClosedDesc = prometheus.NewDesc(
+		"db_max_lifetime_closed_total", "Total number of connections closed due to max connection lifetime",
+		[]string{"db"}, nil,
+	)
+)
+
+// dbStatsCollector exports the connection pool statistics of a database
+type dbStatsCollector struct {
+	name string
+	db   *sql.DB
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbOpenConnectionsDesc
+	ch <- dbInUseConnectionsDesc
+	ch <- dbIdleConnectionsDesc
+	ch <- dbMaxOpenConnectionsDesc
+	ch <- dbWaitCountDesc
+	ch <- dbWaitDurationDesc
+	ch <- dbMaxIdleClosedDesc
+	ch <- dbMaxLifetimeClosedDesc
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(dbOpenConnectionsDesc, prometheus.GaugeValue, float64(stats.OpenConnections), c.name)
+	ch <- prometheus.MustNewConstMetric(dbInUseConnectionsDesc, prometheus.GaugeValue, float64(stats.InUse), c.name)
+	ch <- prometheus.MustNewConstMetric(dbIdleConnectionsDesc, prometheus.GaugeValue, float64(stats.Idle), c.name)
+	ch <- prometheus.MustNewConstMetric(dbMaxOpenConnectionsDesc, prometheus.GaugeValue, float64(stats.MaxOpenConnections), c.name)
+	ch <- prometheus.MustNewConstMetric(dbWaitCountDesc, prometheus.CounterValue, float64(stats.WaitCount), c.name)
+	ch <- prometheus.MustNewConstMetric(dbWaitDurationDesc, prometheus.CounterValue, stats.WaitDuration.Seconds(), c.name)
+	ch <- prometheus.MustNewConstMetric(dbMaxIdleClosedDesc, prometheus.CounterValue, float64(stats.MaxIdleClosed), c.name)
+	ch <- prometheus.MustNewConstMetric(dbMaxLifetimeClosedDesc, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), c.name)
+}
+
+func registerDBStats(name string, db *sql.DB) {
+	err := prometheus.Register(&dbStatsCollector{name: name, db: db})
+	if err != nil {
+		log.Errorf("register stats of db %s fail: %s", name, err)
+	}
+}
@@ -28,6 +28,9 @@ const (
 
 var (
 	defaultBackend IInformerBackend
+
+	// subscribers receive changes of all resources, not limited to watched ones
+	subscribers []IInformerBackend
 )
 
 type IInformerBackend interface {
@@ -56,7 +59,19 @@ func GetDefaultBackend() IInformerBackend {
 }
 
 func IsInit() bool {
-	return defaultBackend != nil
+	return defaultBackend != nil || len(subscribers) > 0
+}
+
+// IResourceFilter is optionally implemented by subscribers to skip changes
+// of uninterested resources before queueing them
+type IResourceFilter interface {
+	IsResourceWatched(keywordPlural string) bool
+}
+
+// AddSubscriber registers a backend notified of changes of every resource,
+// it should be called before service starts
+func AddSubscriber(be IInformerBackend) {
+	subscribers = append(subscribers, be)
 }
 
 type ModelObject struct {
@@ -89,28 +104,34 @@ func isResourceWatched(keywordPlural string) bool {
 }
 
 func Create(ctx context.Context, obj *ModelObject) error {
+	f := func(ctx context.Context, be IInformerBackend) error {
+		return be.Create(ctx, obj)
+	}
+	notifySubscribers(obj.KeywordPlural, f)
 	if !isResourceWatched(obj.KeywordPlural) {
 		return nil
 	}
-	return run(ctx, func(ctx context.Context, be IInformerBackend) error {
-		return be.Create(ctx, obj)
-	})
+	return run(ctx, f)
 }
 
 func Update(ctx context.Context, obj *ModelObject, oldObj *jsonutils.JSONDict) error {
+	f := func(ctx context.Context, be IInformerBackend) error {
+		return be.Update(ctx, obj, oldObj)
+	}
+	notifySubscribers(obj.KeywordPlural, f)
 	if !isResourceWatched(obj.KeywordPlural) {
 		return nil
 	}
-	return run(ctx, func(ctx context.Context, be IInformerBackend) error {
-		return be.Update(ctx, obj, oldObj)
-	})
+	return run(ctx, f)
 }
 
 func Delete(ctx context.Context, obj *ModelObject) error {
+	f := func(ctx context.Context, be IInformerBackend) error {
+		return be.Delete(ctx, obj)
+	}
+	notifySubscribers(obj.KeywordPlural, f)
 	if !isResourceWatched(obj.KeywordPlural) {
 		return nil
 	}
-	return run(ctx, func(ctx context.Context, be IInformerBackend) error {
-		return be.Delete(ctx, obj)
-	})
+	return run(ctx, f)
 }
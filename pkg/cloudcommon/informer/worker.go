@@ -56,14 +56,27 @@ func run(ctx context.Context, f func(ctx context.Context, be IInformerBackend) e
 	if be == nil {
 		return ErrBackendNotInit
 	}
+	runBackend(be, f)
+	return nil
+}
+
+func notifySubscribers(keywordPlural string, f func(ctx context.Context, be IInformerBackend) error) {
+	for _, be := range subscribers {
+		if filter, ok := be.(IResourceFilter); ok && !filter.IsResourceWatched(keywordPlural) {
+			continue
+		}
+		runBackend(be, f)
+	}
+}
+
+func runBackend(be IInformerBackend, f func(ctx context.Context, be IInformerBackend) error) {
 	wf := func() {
 		nopanic.Run(func() {
 			// outside context ignored cause of run in worker
 			if err := f(context.Background(), be); err != nil {
-				log.Errorf("run informer error: %v", err)
+				log.Errorf("run informer %s error: %v", be.GetType(), err)
 			}
 		})
 	}
 	informerWorkerMan.Run(wf, nil, nil)
-	return nil
 }
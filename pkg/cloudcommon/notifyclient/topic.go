@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifyclient
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	npk "yunion.io/x/onecloud/pkg/mcclient/modules/notify"
+)
+
+// PublishTopicEvent 发布事件到资源类型和事件类别对应的主题, 由主题的订阅决定接收人
+func PublishTopicEvent(ctx context.Context, input api.TopicPublishInput) {
+	notifyClientWorkerMan.Run(func() {
+		s, err := AdminSessionGenerator(context.Background(), consts.GetRegion(), "")
+		if err != nil {
+			log.Errorf("fail to get session: %v", err)
+			return
+		}
+		_, err = modules.NotifyTopic.PerformClassAction(s, "publish", jsonutils.Marshal(input))
+		if err != nil {
+			log.Errorf("unable to publish %s %s event of %s: %v", input.ResourceType, input.EventClass, input.ResourceId, err)
+		}
+	}, nil, nil)
+}
+
+// PublishResourceEvent 发布资源事件, 资源的项目和域用于匹配订阅
+func PublishResourceEvent(ctx context.Context, obj db.IModel, eventClass string, priority npk.TNotifyPriority, message string) {
+	input := api.TopicPublishInput{
+		ResourceType: obj.GetModelManager().Keyword(),
+		EventClass:   eventClass,
+		ResourceId:   obj.GetId(),
+		ResourceName: obj.GetName(),
+		Priority:     string(priority),
+		Message:      message,
+	}
+	if owner := obj.GetOwnerId(); owner != nil {
+		input.ProjectId = owner.GetProjectId()
+		input.DomainId = owner.GetProjectDomainId()
+	}
+	PublishTopicEvent(ctx, input)
+}
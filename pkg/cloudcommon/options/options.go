@@ -59,6 +59,11 @@ type BaseOptions struct {
 	LogVerboseLevel int    `help:"log verbosity level" default:"0"`
 	LogFilePrefix   string `help:"prefix of log files"`
 
+	TracingExporter      string `help:"exporter of tracing spans, none to disable tracing" default:"none" choices:"none|otlp|file"`
+	TracingOtlpEndpoint  string `help:"OTLP/HTTP traces endpoint of the OpenTelemetry collector" default:"http://127.0.0.1:4318/v1/traces"`
+	TracingFilePath      string `help:"file to append spans to if tracing exporter is file, stdout if empty"`
+	TracingSamplePercent int    `help:"percentage of traces started by this service to sample" default:"100"`
+
 	CorsHosts []string `help:"List of hostname that allow CORS"`
 	TempPath  string   `help:"Path for store temp file, at least 40G space" default:"/opt/yunion/tmp"`
 
@@ -135,6 +140,11 @@ type HostCommonOptions struct {
 type DBOptions struct {
 	SqlConnection string `help:"SQL connection string" alias:"connection"`
 
+	SqlReplicaConnections          []string `help:"SQL connection strings of read replicas, read-only list and get requests are served by replicas"`
+	SqlReplicaUncheckedConnections []string `help:"SQL connection strings of read replicas behind proxies which can not report replication status, their lag is not checked"`
+	SqlReplicaMaxLagSeconds        int      `help:"read replicas lagging behind primary more than this seconds are excluded" default:"5"`
+	SqlReplicaCheckIntervalSeconds int      `help:"interval seconds to check replication lag of read replicas" default:"10"`
+
 	AutoSyncTable   bool `help:"Automatically synchronize table changes if differences are detected"`
 	ExitAfterDBInit bool `help:"Exit program after db initialization" default:"false"`
 
@@ -222,6 +232,26 @@ func (this *DBOptions) GetDBConnection() (dialect, connstr string, err error) {
 	return utils.TransSQLAchemyURL(this.SqlConnection)
 }
 
+func (this *DBOptions) GetReplicaDBConnections() (dialects, connstrs []string, err error) {
+	return transSQLAchemyURLs(this.SqlReplicaConnections)
+}
+
+func (this *DBOptions) GetUncheckedReplicaDBConnections() (dialects, connstrs []string, err error) {
+	return transSQLAchemyURLs(this.SqlReplicaUncheckedConnections)
+}
+
+func transSQLAchemyURLs(conns []string) (dialects, connstrs []string, err error) {
+	for _, conn := range conns {
+		dialect, connstr, err := utils.TransSQLAchemyURL(conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		dialects = append(dialects, dialect)
+		connstrs = append(connstrs, connstr)
+	}
+	return dialects, connstrs, nil
+}
+
 func ParseOptions(optStruct interface{}, args []string, configFileName string, serviceType string) {
 	if len(serviceType) == 0 {
 		log.Fatalf("ServiceType must provided!")
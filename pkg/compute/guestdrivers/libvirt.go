@@ -0,0 +1,93 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestdrivers
+
+import (
+	"context"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SLibvirtGuestDriver struct {
+	SManagedClusterGuestDriver
+}
+
+func init() {
+	driver := SLibvirtGuestDriver{}
+	models.RegisterGuestDriver(&driver)
+}
+
+func (self *SLibvirtGuestDriver) GetHypervisor() string {
+	return api.HYPERVISOR_LIBVIRT
+}
+
+func (self *SLibvirtGuestDriver) GetProvider() string {
+	return api.CLOUD_PROVIDER_LIBVIRT
+}
+
+func (self *SLibvirtGuestDriver) GetComputeQuotaKeys(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, brand string) models.SComputeResourceKeys {
+	keys := models.SComputeResourceKeys{}
+	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(scope, ownerId)
+	keys.CloudEnv = api.CLOUD_ENV_PRIVATE_CLOUD
+	keys.Provider = api.CLOUD_PROVIDER_LIBVIRT
+	keys.Brand = brand
+	keys.Hypervisor = api.HYPERVISOR_LIBVIRT
+	return keys
+}
+
+func (self *SLibvirtGuestDriver) GetDefaultSysDiskBackend() string {
+	return api.STORAGE_LIBVIRT_DIR
+}
+
+func (self *SLibvirtGuestDriver) GetStorageTypes() []string {
+	return []string{
+		api.STORAGE_LIBVIRT_DIR,
+		api.STORAGE_LIBVIRT_LOGICAL,
+		api.STORAGE_LIBVIRT_NETFS,
+		api.STORAGE_LIBVIRT_RBD,
+	}
+}
+
+func (self *SLibvirtGuestDriver) ChooseHostStorage(host *models.SHost, guest *models.SGuest, diskConfig *api.DiskConfig, storageIds []string) (*models.SStorage, error) {
+	return self.chooseHostStorage(self, host, diskConfig.Backend, storageIds), nil
+}
+
+// 配置修改写入持久化定义, 需关机后进行
+func (self *SLibvirtGuestDriver) GetChangeConfigStatus(guest *models.SGuest) ([]string, error) {
+	return []string{api.VM_READY}, nil
+}
+
+func (self *SLibvirtGuestDriver) GetDeployStatus() ([]string, error) {
+	return []string{}, httperrors.NewUnsupportOperationError("%s not support deploy", self.GetHypervisor())
+}
+
+// 仅纳管libvirt主机上已有的虚拟机
+func (self *SLibvirtGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*api.ServerCreateInput, error) {
+	return nil, httperrors.NewUnsupportOperationError("%s not support create virtual machine", self.GetHypervisor())
+}
+
+func (self *SLibvirtGuestDriver) IsNeedInjectPasswordByCloudInit(desc *cloudprovider.SManagedVMCreateConfig) bool {
+	return false
+}
+
+func (self *SLibvirtGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapability {
+	return self.getInstanceCapability(self.GetHypervisor(), self.GetProvider())
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestdrivers
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/billing"
+)
+
+// 纳管的虚拟化集群公共驱动, 平台没有VPC与安全组, 每台虚拟机仅支持一块网卡
+type SManagedClusterGuestDriver struct {
+	SManagedVirtualizedGuestDriver
+}
+
+func (self *SManagedClusterGuestDriver) DoScheduleCPUFilter() bool { return true }
+
+func (self *SManagedClusterGuestDriver) DoScheduleMemoryFilter() bool { return true }
+
+func (self *SManagedClusterGuestDriver) DoScheduleSKUFilter() bool { return false }
+
+func (self *SManagedClusterGuestDriver) DoScheduleStorageFilter() bool { return true }
+
+func (self *SManagedClusterGuestDriver) GetMinimalSysDiskSizeGb() int {
+	return 10
+}
+
+func (self *SManagedClusterGuestDriver) GetMaxSecurityGroupCount() int {
+	return 0
+}
+
+func (self *SManagedClusterGuestDriver) GetDetachDiskStatus() ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SManagedClusterGuestDriver) GetAttachDiskStatus() ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SManagedClusterGuestDriver) GetRebuildRootStatus() ([]string, error) {
+	return []string{}, httperrors.NewUnsupportOperationError("not support rebuild root")
+}
+
+func (self *SManagedClusterGuestDriver) GetChangeConfigStatus(guest *models.SGuest) ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SManagedClusterGuestDriver) GetDeployStatus() ([]string, error) {
+	return []string{api.VM_RUNNING}, nil
+}
+
+func (self *SManagedClusterGuestDriver) IsNeedRestartForResetLoginInfo() bool {
+	return false
+}
+
+func (self *SManagedClusterGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) error {
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return fmt.Errorf("Cannot resize disk when guest in status %s", guest.Status)
+	}
+	return nil
+}
+
+func (self *SManagedClusterGuestDriver) ValidateCreateEip(ctx context.Context, userCred mcclient.TokenCredential, data jsonutils.JSONObject) error {
+	return httperrors.NewInputParameterError("not support eip")
+}
+
+func (self *SManagedClusterGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*api.ServerCreateInput, error) {
+	input, err := self.SManagedVirtualizedGuestDriver.ValidateCreateData(ctx, userCred, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Networks) > 1 {
+		return nil, httperrors.NewInputParameterError("cannot support more than 1 nic")
+	}
+	if len(input.Eip) > 0 || input.EipBw > 0 {
+		return nil, httperrors.NewUnsupportOperationError("%s not support create virtual machine with eip", input.Hypervisor)
+	}
+	return input, nil
+}
+
+func (self *SManagedClusterGuestDriver) GetGuestInitialStateAfterCreate() string {
+	return api.VM_RUNNING
+}
+
+func (self *SManagedClusterGuestDriver) GetGuestInitialStateAfterRebuild() string {
+	return api.VM_READY
+}
+
+func (self *SManagedClusterGuestDriver) IsNeedInjectPasswordByCloudInit(desc *cloudprovider.SManagedVMCreateConfig) bool {
+	return true
+}
+
+func (self *SManagedClusterGuestDriver) GetUserDataType() string {
+	return cloudprovider.CLOUD_SHELL
+}
+
+func (self *SManagedClusterGuestDriver) IsWindowsUserDataTypeNeedEncode() bool {
+	return true
+}
+
+func (self *SManagedClusterGuestDriver) getInstanceCapability(hypervisor, provider string) cloudprovider.SInstanceCapability {
+	return cloudprovider.SInstanceCapability{
+		Hypervisor: hypervisor,
+		Provider:   provider,
+		DefaultAccount: cloudprovider.SDefaultAccount{
+			Linux: cloudprovider.SOsDefaultAccount{
+				DefaultAccount: api.VM_DEFAULT_LINUX_LOGIN_USER,
+			},
+			Windows: cloudprovider.SOsDefaultAccount{
+				DefaultAccount: api.VM_DEFAULT_WINDOWS_LOGIN_USER,
+			},
+		},
+	}
+}
+
+func (self *SManagedClusterGuestDriver) GetLinuxDefaultAccount(desc cloudprovider.SManagedVMCreateConfig) string {
+	userName := "root"
+	if desc.OsType == "Windows" {
+		userName = "Administrator"
+	}
+	return userName
+}
+
+func (self *SManagedClusterGuestDriver) AllowReconfigGuest() bool {
+	return true
+}
+
+func (self *SManagedClusterGuestDriver) IsSupportedBillingCycle(bc billing.SBillingCycle) bool {
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestdrivers
+
+import (
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SOVirtGuestDriver struct {
+	SManagedClusterGuestDriver
+}
+
+func init() {
+	driver := SOVirtGuestDriver{}
+	models.RegisterGuestDriver(&driver)
+}
+
+func (self *SOVirtGuestDriver) GetHypervisor() string {
+	return api.HYPERVISOR_OVIRT
+}
+
+func (self *SOVirtGuestDriver) GetProvider() string {
+	return api.CLOUD_PROVIDER_OVIRT
+}
+
+func (self *SOVirtGuestDriver) GetComputeQuotaKeys(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, brand string) models.SComputeResourceKeys {
+	keys := models.SComputeResourceKeys{}
+	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(scope, ownerId)
+	keys.CloudEnv = api.CLOUD_ENV_PRIVATE_CLOUD
+	keys.Provider = api.CLOUD_PROVIDER_OVIRT
+	keys.Brand = brand
+	keys.Hypervisor = api.HYPERVISOR_OVIRT
+	return keys
+}
+
+func (self *SOVirtGuestDriver) GetDefaultSysDiskBackend() string {
+	return api.STORAGE_OVIRT_NFS
+}
+
+func (self *SOVirtGuestDriver) GetStorageTypes() []string {
+	return []string{
+		api.STORAGE_OVIRT_NFS,
+		api.STORAGE_OVIRT_ISCSI,
+		api.STORAGE_OVIRT_FCP,
+		api.STORAGE_OVIRT_GLUSTERFS,
+		api.STORAGE_OVIRT_LOCALFS,
+		api.STORAGE_OVIRT_POSIXFS,
+	}
+}
+
+func (self *SOVirtGuestDriver) ChooseHostStorage(host *models.SHost, guest *models.SGuest, diskConfig *api.DiskConfig, storageIds []string) (*models.SStorage, error) {
+	return self.chooseHostStorage(self, host, diskConfig.Backend, storageIds), nil
+}
+
+func (self *SOVirtGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapability {
+	return self.getInstanceCapability(self.GetHypervisor(), self.GetProvider())
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestdrivers
+
+import (
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SProxmoxGuestDriver struct {
+	SManagedClusterGuestDriver
+}
+
+func init() {
+	driver := SProxmoxGuestDriver{}
+	models.RegisterGuestDriver(&driver)
+}
+
+func (self *SProxmoxGuestDriver) GetHypervisor() string {
+	return api.HYPERVISOR_PROXMOX
+}
+
+func (self *SProxmoxGuestDriver) GetProvider() string {
+	return api.CLOUD_PROVIDER_PROXMOX
+}
+
+func (self *SProxmoxGuestDriver) GetComputeQuotaKeys(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, brand string) models.SComputeResourceKeys {
+	keys := models.SComputeResourceKeys{}
+	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(scope, ownerId)
+	keys.CloudEnv = api.CLOUD_ENV_PRIVATE_CLOUD
+	keys.Provider = api.CLOUD_PROVIDER_PROXMOX
+	keys.Brand = brand
+	keys.Hypervisor = api.HYPERVISOR_PROXMOX
+	return keys
+}
+
+func (self *SProxmoxGuestDriver) GetDefaultSysDiskBackend() string {
+	return api.STORAGE_PROXMOX_LOCAL
+}
+
+func (self *SProxmoxGuestDriver) GetStorageTypes() []string {
+	return []string{
+		api.STORAGE_PROXMOX_LOCAL,
+		api.STORAGE_PROXMOX_SHARED,
+	}
+}
+
+func (self *SProxmoxGuestDriver) ChooseHostStorage(host *models.SHost, guest *models.SGuest, diskConfig *api.DiskConfig, storageIds []string) (*models.SStorage, error) {
+	return self.chooseHostStorage(self, host, diskConfig.Backend, storageIds), nil
+}
+
+func (self *SProxmoxGuestDriver) GetDetachDiskStatus() ([]string, error) {
+	return []string{}, httperrors.NewUnsupportOperationError("%s not support detach disk", self.GetHypervisor())
+}
+
+func (self *SProxmoxGuestDriver) GetAttachDiskStatus() ([]string, error) {
+	return []string{}, httperrors.NewUnsupportOperationError("%s not support attach disk", self.GetHypervisor())
+}
+
+func (self *SProxmoxGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapability {
+	return self.getInstanceCapability(self.GetHypervisor(), self.GetProvider())
+}
@@ -0,0 +1,184 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build simulator
+
+package guestdrivers
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/billing"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SSimulatorGuestDriver struct {
+	SManagedVirtualizedGuestDriver
+}
+
+func init() {
+	driver := SSimulatorGuestDriver{}
+	models.RegisterGuestDriver(&driver)
+}
+
+func (self *SSimulatorGuestDriver) DoScheduleCPUFilter() bool { return true }
+
+func (self *SSimulatorGuestDriver) DoScheduleMemoryFilter() bool { return true }
+
+func (self *SSimulatorGuestDriver) DoScheduleSKUFilter() bool { return false }
+
+func (self *SSimulatorGuestDriver) DoScheduleStorageFilter() bool { return true }
+
+func (self *SSimulatorGuestDriver) GetHypervisor() string {
+	return api.HYPERVISOR_SIMULATOR
+}
+
+func (self *SSimulatorGuestDriver) GetProvider() string {
+	return api.CLOUD_PROVIDER_SIMULATOR
+}
+
+func (self *SSimulatorGuestDriver) GetComputeQuotaKeys(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, brand string) models.SComputeResourceKeys {
+	keys := models.SComputeResourceKeys{}
+	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(scope, ownerId)
+	keys.CloudEnv = api.CLOUD_ENV_PRIVATE_CLOUD
+	keys.Provider = api.CLOUD_PROVIDER_SIMULATOR
+	keys.Brand = brand
+	keys.Hypervisor = api.HYPERVISOR_SIMULATOR
+	return keys
+}
+
+func (self *SSimulatorGuestDriver) GetDefaultSysDiskBackend() string {
+	return api.STORAGE_SIMULATOR_LOCAL
+}
+
+func (self *SSimulatorGuestDriver) GetMinimalSysDiskSizeGb() int {
+	return 10
+}
+
+func (self *SSimulatorGuestDriver) GetStorageTypes() []string {
+	return []string{
+		api.STORAGE_SIMULATOR_LOCAL,
+		api.STORAGE_SIMULATOR_SHARED,
+	}
+}
+
+func (self *SSimulatorGuestDriver) GetMaxSecurityGroupCount() int {
+	return 0
+}
+
+func (self *SSimulatorGuestDriver) ChooseHostStorage(host *models.SHost, guest *models.SGuest, diskConfig *api.DiskConfig, storageIds []string) (*models.SStorage, error) {
+	return self.chooseHostStorage(self, host, diskConfig.Backend, storageIds), nil
+}
+
+func (self *SSimulatorGuestDriver) GetDetachDiskStatus() ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SSimulatorGuestDriver) GetAttachDiskStatus() ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SSimulatorGuestDriver) GetRebuildRootStatus() ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SSimulatorGuestDriver) GetChangeConfigStatus(guest *models.SGuest) ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
+func (self *SSimulatorGuestDriver) GetDeployStatus() ([]string, error) {
+	return []string{api.VM_RUNNING}, nil
+}
+
+func (self *SSimulatorGuestDriver) IsNeedRestartForResetLoginInfo() bool {
+	return false
+}
+
+func (self *SSimulatorGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) error {
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return fmt.Errorf("Cannot resize disk when guest in status %s", guest.Status)
+	}
+	return nil
+}
+
+func (self *SSimulatorGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*api.ServerCreateInput, error) {
+	input, err := self.SManagedVirtualizedGuestDriver.ValidateCreateData(ctx, userCred, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Networks) > 1 {
+		return nil, httperrors.NewInputParameterError("cannot support more than 1 nic")
+	}
+	return input, nil
+}
+
+func (self *SSimulatorGuestDriver) GetGuestInitialStateAfterCreate() string {
+	return api.VM_RUNNING
+}
+
+func (self *SSimulatorGuestDriver) GetGuestInitialStateAfterRebuild() string {
+	return api.VM_READY
+}
+
+func (self *SSimulatorGuestDriver) IsNeedInjectPasswordByCloudInit(desc *cloudprovider.SManagedVMCreateConfig) bool {
+	return true
+}
+
+func (self *SSimulatorGuestDriver) GetUserDataType() string {
+	return cloudprovider.CLOUD_SHELL
+}
+
+func (self *SSimulatorGuestDriver) IsWindowsUserDataTypeNeedEncode() bool {
+	return true
+}
+
+func (self *SSimulatorGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapability {
+	return cloudprovider.SInstanceCapability{
+		Hypervisor: self.GetHypervisor(),
+		Provider:   self.GetProvider(),
+		DefaultAccount: cloudprovider.SDefaultAccount{
+			Linux: cloudprovider.SOsDefaultAccount{
+				DefaultAccount: api.VM_DEFAULT_LINUX_LOGIN_USER,
+			},
+			Windows: cloudprovider.SOsDefaultAccount{
+				DefaultAccount: api.VM_DEFAULT_WINDOWS_LOGIN_USER,
+			},
+		},
+	}
+}
+
+func (self *SSimulatorGuestDriver) GetLinuxDefaultAccount(desc cloudprovider.SManagedVMCreateConfig) string {
+	userName := "root"
+	if desc.OsType == "Windows" {
+		userName = "Administrator"
+	}
+	return userName
+}
+
+func (self *SSimulatorGuestDriver) AllowReconfigGuest() bool {
+	return true
+}
+
+func (self *SSimulatorGuestDriver) IsSupportedBillingCycle(bc billing.SBillingCycle) bool {
+	return false
+}
@@ -0,0 +1,324 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"net"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/secrules"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// SAddressGroupManager 地址组(IP集合), 供安全组规则引用以替代大量单CIDR规则
+type SAddressGroupManager struct {
+	db.SSharableVirtualResourceBaseManager
+}
+
+var AddressGroupManager *SAddressGroupManager
+
+func init() {
+	AddressGroupManager = &SAddressGroupManager{
+		SSharableVirtualResourceBaseManager: db.NewSharableVirtualResourceBaseManager(
+			SAddressGroup{},
+			"addressgroups_tbl",
+			"addressgroup",
+			"addressgroups",
+		),
+	}
+	AddressGroupManager.SetVirtualObject(AddressGroupManager)
+}
+
+type SAddressGroup struct {
+	db.SSharableVirtualResourceBase
+
+	// IPv4地址或CIDR列表
+	IpList *api.SAddressList `list:"user" update:"user" create:"optional"`
+	// IPv6地址或CIDR列表
+	Ip6List *api.SAddressList `list:"user" update:"user" create:"optional"`
+}
+
+// 地址组列表
+func (manager *SAddressGroupManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.AddressGroupListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Ip) > 0 {
+		q = q.Filter(sqlchemy.OR(
+			sqlchemy.Contains(q.Field("ip_list"), query.Ip),
+			sqlchemy.Contains(q.Field("ip6_list"), query.Ip),
+		))
+	}
+	return q, nil
+}
+
+func (manager *SAddressGroupManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.AddressGroupListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SAddressGroupManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (self *SAddressGroup) GetExtraDetails(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	isList bool,
+) (api.AddressGroupDetails, error) {
+	return api.AddressGroupDetails{}, nil
+}
+
+func (manager *SAddressGroupManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.AddressGroupDetails {
+	rows := make([]api.AddressGroupDetails, len(objs))
+	virtRows := manager.SSharableVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	groupIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.AddressGroupDetails{
+			SharableVirtualResourceDetails: virtRows[i],
+		}
+		groupIds[i] = objs[i].(*SAddressGroup).Id
+	}
+
+	q := SecurityGroupRuleManager.Query().In("address_group_id", groupIds)
+	rules := []SSecurityGroupRule{}
+	err := db.FetchModelObjects(SecurityGroupRuleManager, q, &rules)
+	if err != nil {
+		log.Errorf("FetchModelObjects secgrouprules fail: %v", err)
+		return rows
+	}
+	ruleCnt := map[string]int{}
+	for i := range rules {
+		ruleCnt[rules[i].AddressGroupId] += 1
+	}
+	for i := range rows {
+		rows[i].RuleCnt = ruleCnt[groupIds[i]]
+	}
+	return rows
+}
+
+func (manager *SAddressGroupManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.AddressGroupCreateInput,
+) (api.AddressGroupCreateInput, error) {
+	var err error
+	if input.IpList == nil {
+		input.IpList = &api.SAddressList{}
+	}
+	if input.Ip6List == nil {
+		input.Ip6List = &api.SAddressList{}
+	}
+	if err := input.IpList.Validate(false); err != nil {
+		return input, errors.Wrap(err, "ip_list")
+	}
+	if err := input.Ip6List.Validate(true); err != nil {
+		return input, errors.Wrap(err, "ip6_list")
+	}
+	input.Status = api.ADDRESS_GROUP_STATUS_AVAILABLE
+	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SAddressGroup) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.AddressGroupUpdateInput,
+) (api.AddressGroupUpdateInput, error) {
+	var err error
+	ipList, ip6List := self.IpList, self.Ip6List
+	if input.IpList != nil {
+		if err := input.IpList.Validate(false); err != nil {
+			return input, errors.Wrap(err, "ip_list")
+		}
+		ipList = input.IpList
+	}
+	if input.Ip6List != nil {
+		if err := input.Ip6List.Validate(true); err != nil {
+			return input, errors.Wrap(err, "ip6_list")
+		}
+		ip6List = input.Ip6List
+	}
+	if input.IpList != nil || input.Ip6List != nil {
+		cnt, err := SecurityGroupRuleManager.Query().Equals("address_group_id", self.Id).CountWithError()
+		if err != nil {
+			return input, httperrors.NewInternalServerError("count secgrouprules fail %s", err)
+		}
+		if cnt > 0 {
+			if err := validateAddressGroupRuleEntries(self.Name, addressListLen(ipList)+addressListLen(ip6List)); err != nil {
+				return input, err
+			}
+		}
+	}
+	input.SharableVirtualResourceBaseUpdateInput, err = self.SSharableVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.SharableVirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SSharableVirtualResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+// PostUpdate 地址变更后需要重新下发所有引用此地址组的安全组
+func (self *SAddressGroup) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SSharableVirtualResourceBase.PostUpdate(ctx, userCred, query, data)
+
+	if !data.Contains("ip_list") && !data.Contains("ip6_list") {
+		return
+	}
+	secgroups, err := self.GetSecgroups()
+	if err != nil {
+		log.Errorf("address group %s GetSecgroups: %v", self.Name, err)
+		return
+	}
+	for i := range secgroups {
+		secgroups[i].DoSync(ctx, userCred)
+	}
+}
+
+func (self *SAddressGroup) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := SecurityGroupRuleManager.Query().Equals("address_group_id", self.Id).CountWithError()
+	if err != nil {
+		return httperrors.NewInternalServerError("count secgrouprules fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("address group is referenced by %d security group rules", cnt)
+	}
+	return self.SSharableVirtualResourceBase.ValidateDeleteCondition(ctx)
+}
+
+// GetSecgroups 引用此地址组的安全组
+func (self *SAddressGroup) GetSecgroups() ([]SSecurityGroup, error) {
+	sq := SecurityGroupRuleManager.Query("secgroup_id").Equals("address_group_id", self.Id).Distinct().SubQuery()
+	q := SecurityGroupManager.Query().In("id", sq)
+	secgroups := []SSecurityGroup{}
+	err := db.FetchModelObjects(SecurityGroupManager, q, &secgroups)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return secgroups, nil
+}
+
+// GetCidrs 返回地址组内全部IPv4与IPv6 CIDR
+func (self *SAddressGroup) GetCidrs() []string {
+	cidrs := []string{}
+	if self.IpList != nil {
+		cidrs = append(cidrs, (*self.IpList)...)
+	}
+	if self.Ip6List != nil {
+		cidrs = append(cidrs, (*self.Ip6List)...)
+	}
+	return cidrs
+}
+
+func addressListLen(l *api.SAddressList) int {
+	if l == nil {
+		return 0
+	}
+	return len(*l)
+}
+
+// validateAddressGroupRuleEntries 被规则引用的地址组条目数不能超过 ADDRESS_GROUP_MAX_RULE_ENTRIES
+func validateAddressGroupRuleEntries(name string, cnt int) error {
+	if cnt > api.ADDRESS_GROUP_MAX_RULE_ENTRIES {
+		return httperrors.NewOutOfLimitError("address group %s has %d addresses, security group rules can only reference address groups with at most %d addresses",
+			name, cnt, api.ADDRESS_GROUP_MAX_RULE_ENTRIES)
+	}
+	return nil
+}
+
+func (self *SAddressGroup) validateRuleEntries() error {
+	return validateAddressGroupRuleEntries(self.Name, len(self.GetCidrs()))
+}
+
+// ExpandRule 将引用地址组的规则展开为每个CIDR一条, 用于不支持地址集合的下发端(宿主机与公有云),
+// 仅VPC经OVN地址集合原生支持地址组, 宿主机侧不渲染ipset;
+// 展开的规则数受 ADDRESS_GROUP_MAX_RULE_ENTRIES 限制
+func (self *SAddressGroup) ExpandRule(rule secrules.SecurityRule) ([]secrules.SecurityRule, error) {
+	if err := self.validateRuleEntries(); err != nil {
+		return nil, err
+	}
+	ret := []secrules.SecurityRule{}
+	for _, cidr := range self.GetCidrs() {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Warningf("address group %s: invalid cidr %s", self.Name, cidr)
+			continue
+		}
+		r := rule
+		r.IPNet = ipNet
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+func (manager *SAddressGroupManager) FetchAddressGroupById(id string) (*SAddressGroup, error) {
+	obj, err := manager.FetchById(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchById(%s)", id)
+	}
+	return obj.(*SAddressGroup), nil
+}
+
+func (manager *SAddressGroupManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.ListItemExportKeys")
+	}
+	return q, nil
+}
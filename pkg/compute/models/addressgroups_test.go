@@ -0,0 +1,95 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"yunion.io/x/pkg/util/secrules"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestAddressGroupExpandRule(t *testing.T) {
+	newGroup := func(ips, ip6s []string) *SAddressGroup {
+		ag := &SAddressGroup{}
+		if ips != nil {
+			l := api.SAddressList(ips)
+			ag.IpList = &l
+		}
+		if ip6s != nil {
+			l := api.SAddressList(ip6s)
+			ag.Ip6List = &l
+		}
+		return ag
+	}
+	cases := []struct {
+		name string
+		ag   *SAddressGroup
+		want []string
+	}{
+		{
+			name: "ipv4 and ipv6",
+			ag:   newGroup([]string{"10.0.0.0/8", "192.168.1.10/32"}, []string{"2001:db8::/64"}),
+			want: []string{"10.0.0.0/8", "192.168.1.10/32", "2001:db8::/64"},
+		},
+		{
+			name: "invalid cidr skipped",
+			ag:   newGroup([]string{"10.0.0.0/8", "bad"}, nil),
+			want: []string{"10.0.0.0/8"},
+		},
+		{
+			name: "empty group",
+			ag:   newGroup(nil, nil),
+			want: []string{},
+		},
+	}
+	rule := *secrules.MustParseSecurityRule("in:allow tcp 22")
+	rule.Priority = 10
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, err := c.ag.ExpandRule(rule)
+			if err != nil {
+				t.Fatalf("ExpandRule: %v", err)
+			}
+			if len(rules) != len(c.want) {
+				t.Fatalf("want %d rules got %d", len(c.want), len(rules))
+			}
+			for i := range rules {
+				if rules[i].IPNet.String() != c.want[i] {
+					t.Errorf("want cidr %s got %s", c.want[i], rules[i].IPNet.String())
+				}
+				if rules[i].Priority != rule.Priority || rules[i].Protocol != rule.Protocol || rules[i].GetPortsString() != "22" {
+					t.Errorf("expanded rule %s lost its attributes", rules[i].String())
+				}
+			}
+		})
+	}
+	if rule.IPNet.String() != "0.0.0.0/0" {
+		t.Errorf("ExpandRule should not modify the origin rule, got %s", rule.IPNet.String())
+	}
+
+	ips := []string{}
+	for i := 0; i <= api.ADDRESS_GROUP_MAX_RULE_ENTRIES; i++ {
+		ips = append(ips, fmt.Sprintf("10.0.%d.0/24", i))
+	}
+	if _, err := newGroup(ips, nil).ExpandRule(rule); err == nil {
+		t.Errorf("expect error when address group has more than %d addresses", api.ADDRESS_GROUP_MAX_RULE_ENTRIES)
+	}
+	if _, err := newGroup(ips[1:], nil).ExpandRule(rule); err != nil {
+		t.Errorf("address group with %d addresses: %v", api.ADDRESS_GROUP_MAX_RULE_ENTRIES, err)
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
 	"yunion.io/x/onecloud/pkg/compute/options"
@@ -1687,7 +1688,7 @@ type SBucketUsages struct {
 	Bytes   int64
 }
 
-func (manager *SBucketManager) TotalCount(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, rangeObjs []db.IStandaloneModel, providers []string, brands []string, cloudEnv string) SBucketUsages {
+func (manager *SBucketManager) TotalCount(ctx context.Context, scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, rangeObjs []db.IStandaloneModel, providers []string, brands []string, cloudEnv string) SBucketUsages {
 	usage := SBucketUsages{}
 	buckets := manager.Query().SubQuery()
 	bucketsQ := buckets.Query(
@@ -1714,7 +1715,7 @@ func (manager *SBucketManager) TotalCount(scope rbacutils.TRbacScope, ownerId mc
 		sqlchemy.SUM("objects", buckets.Field("object_cnt1")),
 		sqlchemy.SUM("bytes", buckets.Field("size_bytes1")),
 	)
-	err := q.First(&usage)
+	err := replica.First(ctx, q, &usage)
 	if err != nil {
 		log.Errorf("Query bucket usage error %s", err)
 	}
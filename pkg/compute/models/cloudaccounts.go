@@ -36,17 +36,20 @@ import (
 	"yunion.io/x/onecloud/pkg/apis"
 	proxyapi "yunion.io/x/onecloud/pkg/apis/cloudcommon/proxy"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	notifyapi "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/proxy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
 	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/notify"
 	"yunion.io/x/onecloud/pkg/multicloud/esxi/vcenter"
 	"yunion.io/x/onecloud/pkg/util/choices"
 	"yunion.io/x/onecloud/pkg/util/httputils"
@@ -1918,6 +1921,7 @@ func (account *SCloudaccount) probeAccountStatus(ctx context.Context, userCred m
 	}
 	iamLoginUrl := manager.GetIamLoginUrl()
 	factory := manager.GetFactory()
+	oldHealthStatus := account.HealthStatus
 	diff, err := db.Update(account, func() error {
 		isPublic := factory.IsPublicCloud()
 		account.IsPublicCloud = tristate.NewFromBool(isPublic)
@@ -1940,11 +1944,27 @@ func (account *SCloudaccount) probeAccountStatus(ctx context.Context, userCred m
 		log.Errorf("Failed to update db %s", err)
 	} else {
 		db.OpsLog.LogSyncUpdate(account, diff, userCred)
+		if needNotifyBalance(oldHealthStatus, account.HealthStatus) {
+			msg := fmt.Sprintf("health status %s, balance %f", account.HealthStatus, account.Balance)
+			notifyclient.PublishResourceEvent(ctx, account, notifyapi.TOPIC_EVENT_CLASS_BALANCE, notify.NotifyPriorityImportant, msg)
+		}
 	}
 
 	return manager.GetSubAccounts()
 }
 
+// needNotifyBalance 账号首次进入余额不足、欠费或冻结状态时需要发布余额事件
+func needNotifyBalance(oldStatus, newStatus string) bool {
+	if oldStatus == newStatus {
+		return false
+	}
+	return utils.IsInStringArray(newStatus, []string{
+		api.CLOUD_PROVIDER_HEALTH_INSUFFICIENT,
+		api.CLOUD_PROVIDER_HEALTH_ARREARS,
+		api.CLOUD_PROVIDER_HEALTH_SUSPENDED,
+	})
+}
+
 func (account *SCloudaccount) importAllSubaccounts(ctx context.Context, userCred mcclient.TokenCredential, subAccounts []cloudprovider.SSubAccount) []SCloudprovider {
 	oldProviders := account.GetCloudproviders()
 	existProviders := make([]SCloudprovider, 0)
@@ -37,6 +37,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
@@ -1819,6 +1820,7 @@ type SRdsCountStat struct {
 }
 
 func (man *SDBInstanceManager) TotalCount(
+	ctx context.Context,
 	scope rbacutils.TRbacScope,
 	ownerId mcclient.IIdentityProvider,
 	rangeObjs []db.IStandaloneModel,
@@ -1834,8 +1836,7 @@ func (man *SDBInstanceManager) TotalCount(
 	q = RangeObjectsFilter(q, rangeObjs, q.Field("cloudregion_id"), nil, q.Field("manager_id"), nil, nil)
 
 	stat := SRdsCountStat{}
-	row := q.Row()
-	err := q.Row2Struct(row, &stat)
+	err := replica.First(ctx, q, &stat)
 	return stat, err
 }
 
@@ -123,6 +123,11 @@ type SDisk struct {
 	IsSsd bool `nullable:"false" default:"false" list:"user" update:"user" create:"optional"`
 }
 
+// 记录字段级变更历史
+func (manager *SDiskManager) IsHistoryRecorded() bool {
+	return true
+}
+
 func (manager *SDiskManager) GetContextManagers() [][]db.IModelManager {
 	return [][]db.IModelManager{
 		{StorageManager},
@@ -33,6 +33,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -108,6 +109,11 @@ type SElasticip struct {
 	// CloudregionId string `width:"36" charset:"ascii" nullable:"false" list:"user" create:"required"`
 }
 
+// 记录字段级变更历史
+func (manager *SElasticipManager) IsHistoryRecorded() bool {
+	return true
+}
+
 // 弹性公网IP列表
 func (manager *SElasticipManager) ListItemFilter(
 	ctx context.Context,
@@ -1444,7 +1450,7 @@ func (manager *SElasticipManager) usageQ(q *sqlchemy.SQuery, rangeObjs []db.ISta
 	return q
 }
 
-func (manager *SElasticipManager) TotalCount(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, rangeObjs []db.IStandaloneModel, providers []string, brands []string, cloudEnv string) EipUsage {
+func (manager *SElasticipManager) TotalCount(ctx context.Context, scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, rangeObjs []db.IStandaloneModel, providers []string, brands []string, cloudEnv string) EipUsage {
 	usage := EipUsage{}
 	q1 := manager.Query().Equals("mode", api.EIP_MODE_INSTANCE_PUBLICIP)
 	q1 = manager.usageQ(q1, rangeObjs, providers, brands, cloudEnv)
@@ -1464,9 +1470,9 @@ func (manager *SElasticipManager) TotalCount(scope rbacutils.TRbacScope, ownerId
 		q2 = q2.Equals("tenant_id", ownerId.GetProjectId())
 		q3 = q3.Equals("tenant_id", ownerId.GetProjectId())
 	}
-	usage.PublicIPCount, _ = q1.CountWithError()
-	usage.EIPCount, _ = q2.CountWithError()
-	usage.EIPUsedCount, _ = q3.CountWithError()
+	usage.PublicIPCount, _ = replica.Count(ctx, q1)
+	usage.EIPCount, _ = replica.Count(ctx, q2)
+	usage.EIPUsedCount, _ = replica.Count(ctx, q3)
 	return usage
 }
 
@@ -0,0 +1,388 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// SFlowLogManager VPC流日志, 由vpcagent开启OVN ACL日志, 宿主机采集端解析后投递
+type SFlowLogManager struct {
+	db.SVirtualResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var FlowLogManager *SFlowLogManager
+
+func init() {
+	FlowLogManager = &SFlowLogManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SFlowLog{},
+			"flowlogs_tbl",
+			"flowlog",
+			"flowlogs",
+		),
+	}
+	FlowLogManager.SetVirtualObject(FlowLogManager)
+}
+
+type SFlowLog struct {
+	db.SVirtualResourceBase
+	db.SEnabledResourceBase
+
+	// 采集对象类型 vpc|network|server
+	ResourceType string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"required"`
+	// 采集对象Id
+	ResourceId string `width:"36" charset:"ascii" nullable:"false" index:"true" list:"user" create:"required"`
+	// 采集对象为server时的网卡名称, 为空表示全部网卡
+	Ifname string `width:"16" charset:"ascii" nullable:"true" list:"user" create:"optional"`
+
+	// 采集的流量类型 accept|reject|all
+	TrafficType string `width:"8" charset:"ascii" nullable:"false" default:"all" list:"user" update:"user" create:"optional"`
+
+	// 投递目标 file|bucket|logger
+	SinkType string `width:"8" charset:"ascii" nullable:"false" default:"file" list:"user" create:"optional"`
+	// 投递到存储桶时的存储桶Id
+	BucketId string `width:"36" charset:"ascii" nullable:"true" list:"user" create:"optional"`
+	// 文件路径或对象前缀
+	SinkPath string `width:"256" charset:"utf8" nullable:"true" list:"user" update:"user" create:"optional"`
+
+	// 每台宿主机每秒最多记录条数
+	RateLimit int `nullable:"false" default:"100" list:"user" update:"user" create:"optional"`
+}
+
+// 流日志列表
+func (manager *SFlowLogManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.FlowLogListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.Equals("resource_type", query.ResourceType)
+	}
+	if len(query.ResourceId) > 0 {
+		q = q.Equals("resource_id", query.ResourceId)
+	}
+	if len(query.TrafficType) > 0 {
+		q = q.Equals("traffic_type", query.TrafficType)
+	}
+	if len(query.SinkType) > 0 {
+		q = q.Equals("sink_type", query.SinkType)
+	}
+	return q, nil
+}
+
+func (manager *SFlowLogManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.FlowLogListInput,
+) (*sqlchemy.SQuery, error) {
+	return manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+}
+
+func (manager *SFlowLogManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (self *SFlowLog) GetExtraDetails(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	isList bool,
+) (api.FlowLogDetails, error) {
+	return api.FlowLogDetails{}, nil
+}
+
+func (manager *SFlowLogManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.FlowLogDetails {
+	rows := make([]api.FlowLogDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.FlowLogDetails{
+			VirtualResourceDetails: virtRows[i],
+		}
+		fl := objs[i].(*SFlowLog)
+		if res, err := fl.getResource(); err == nil {
+			rows[i].Resource = res.GetName()
+		}
+		if len(fl.BucketId) > 0 {
+			if bucket, err := BucketManager.FetchById(fl.BucketId); err == nil {
+				rows[i].Bucket = bucket.GetName()
+			}
+		}
+	}
+	return rows
+}
+
+func flowLogResourceManager(resType string) db.IModelManager {
+	switch resType {
+	case api.FLOW_LOG_RESOURCE_VPC:
+		return VpcManager
+	case api.FLOW_LOG_RESOURCE_NETWORK:
+		return NetworkManager
+	case api.FLOW_LOG_RESOURCE_SERVER:
+		return GuestManager
+	}
+	return nil
+}
+
+func (self *SFlowLog) getResource() (db.IModel, error) {
+	man := flowLogResourceManager(self.ResourceType)
+	if man == nil {
+		return nil, errors.Wrapf(httperrors.ErrNotSupported, "resource type %s", self.ResourceType)
+	}
+	return man.FetchById(self.ResourceId)
+}
+
+// validateFlowLogResource 流日志依赖OVN ACL, 只能作用于VPC(非经典网络)内的资源
+func validateFlowLogResource(userCred mcclient.TokenCredential, input *api.FlowLogCreateInput) error {
+	man := flowLogResourceManager(input.ResourceType)
+	if man == nil {
+		return httperrors.NewInputParameterError("invalid resource_type %q, want one of %v", input.ResourceType, api.FLOW_LOG_RESOURCE_TYPES)
+	}
+	resStr := input.Resource
+	if len(resStr) == 0 {
+		resStr = input.ResourceId
+	}
+	if len(resStr) == 0 {
+		return httperrors.NewMissingParameterError("resource")
+	}
+	obj, err := man.FetchByIdOrName(userCred, resStr)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return httperrors.NewResourceNotFoundError2(man.Keyword(), resStr)
+		}
+		return httperrors.NewGeneralError(err)
+	}
+	input.ResourceId = obj.GetId()
+
+	switch res := obj.(type) {
+	case *SVpc:
+		region, err := res.GetRegion()
+		if err != nil {
+			return httperrors.NewGeneralError(errors.Wrap(err, "GetRegion"))
+		}
+		if res.Id == api.DEFAULT_VPC_ID || region.Provider != api.CLOUD_PROVIDER_ONECLOUD {
+			return httperrors.NewUnsupportOperationError("flow log is only supported for on-premise vpc")
+		}
+	case *SNetwork:
+		if !res.isOneCloudVpcNetwork() {
+			return httperrors.NewUnsupportOperationError("flow log is only supported for on-premise vpc network")
+		}
+	case *SGuest:
+		gns, err := res.GetNetworks("")
+		if err != nil {
+			return httperrors.NewGeneralError(errors.Wrap(err, "GetNetworks"))
+		}
+		found := false
+		for i := range gns {
+			network := gns[i].GetNetwork()
+			if network == nil || !network.isOneCloudVpcNetwork() {
+				continue
+			}
+			if len(input.Ifname) == 0 || gns[i].Ifname == input.Ifname {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if len(input.Ifname) > 0 {
+				return httperrors.NewInputParameterError("server has no vpc nic %s", input.Ifname)
+			}
+			return httperrors.NewUnsupportOperationError("server has no nic in on-premise vpc")
+		}
+	}
+	if input.ResourceType != api.FLOW_LOG_RESOURCE_SERVER {
+		input.Ifname = ""
+	}
+	return nil
+}
+
+func validateFlowLogRateLimit(rateLimit int) error {
+	if rateLimit <= 0 || rateLimit > api.FLOW_LOG_MAX_RATE_LIMIT {
+		return httperrors.NewOutOfRangeError("rate_limit should be in range 1-%d", api.FLOW_LOG_MAX_RATE_LIMIT)
+	}
+	return nil
+}
+
+func (manager *SFlowLogManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.FlowLogCreateInput,
+) (api.FlowLogCreateInput, error) {
+	var err error
+	err = validateFlowLogResource(userCred, &input)
+	if err != nil {
+		return input, err
+	}
+
+	if len(input.TrafficType) == 0 {
+		input.TrafficType = api.FLOW_LOG_TRAFFIC_ALL
+	}
+	if !utils.IsInStringArray(input.TrafficType, api.FLOW_LOG_TRAFFIC_TYPES) {
+		return input, httperrors.NewInputParameterError("invalid traffic_type %q, want one of %v", input.TrafficType, api.FLOW_LOG_TRAFFIC_TYPES)
+	}
+
+	if len(input.SinkType) == 0 {
+		input.SinkType = api.FLOW_LOG_SINK_FILE
+	}
+	switch input.SinkType {
+	case api.FLOW_LOG_SINK_FILE, api.FLOW_LOG_SINK_LOGGER:
+		input.BucketId = ""
+	case api.FLOW_LOG_SINK_BUCKET:
+		bucketStr := input.Bucket
+		if len(bucketStr) == 0 {
+			bucketStr = input.BucketId
+		}
+		if len(bucketStr) == 0 {
+			return input, httperrors.NewMissingParameterError("bucket")
+		}
+		bucket, err := BucketManager.FetchByIdOrName(userCred, bucketStr)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return input, httperrors.NewResourceNotFoundError2(BucketManager.Keyword(), bucketStr)
+			}
+			return input, httperrors.NewGeneralError(err)
+		}
+		input.BucketId = bucket.GetId()
+	default:
+		return input, httperrors.NewInputParameterError("invalid sink_type %q, want one of %v", input.SinkType, api.FLOW_LOG_SINK_TYPES)
+	}
+
+	if input.RateLimit == 0 {
+		input.RateLimit = api.FLOW_LOG_DEFAULT_RATE_LIMIT
+	}
+	if err := validateFlowLogRateLimit(input.RateLimit); err != nil {
+		return input, err
+	}
+
+	input.Status = api.FLOW_LOG_STATUS_AVAILABLE
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SFlowLog) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SVirtualResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+
+	input := api.FlowLogCreateInput{}
+	data.Unmarshal(&input)
+	if input.Enabled == nil || *input.Enabled {
+		_, err := db.Update(self, func() error {
+			self.Enabled = tristate.True
+			return nil
+		})
+		if err != nil {
+			log.Errorf("flowlog %s enable: %v", self.Name, err)
+		}
+	}
+}
+
+func (self *SFlowLog) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.FlowLogUpdateInput,
+) (api.FlowLogUpdateInput, error) {
+	var err error
+	if len(input.TrafficType) > 0 && !utils.IsInStringArray(input.TrafficType, api.FLOW_LOG_TRAFFIC_TYPES) {
+		return input, httperrors.NewInputParameterError("invalid traffic_type %q, want one of %v", input.TrafficType, api.FLOW_LOG_TRAFFIC_TYPES)
+	}
+	if input.RateLimit != nil {
+		if err := validateFlowLogRateLimit(*input.RateLimit); err != nil {
+			return input, err
+		}
+	}
+	input.VirtualResourceBaseUpdateInput, err = self.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.VirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SVirtualResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (self *SFlowLog) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return db.IsProjectAllowPerform(userCred, self, "enable")
+}
+
+func (self *SFlowLog) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(self, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (self *SFlowLog) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return db.IsProjectAllowPerform(userCred, self, "disable")
+}
+
+func (self *SFlowLog) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(self, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (manager *SFlowLogManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemExportKeys")
+	}
+	return q, nil
+}
@@ -159,6 +159,9 @@ var HypervisorBrandMap = map[string]string{
 	computeapis.HYPERVISOR_ZSTACK:    computeapis.CLOUD_PROVIDER_ZSTACK,
 	computeapis.HYPERVISOR_GOOGLE:    computeapis.CLOUD_PROVIDER_GOOGLE,
 	computeapis.HYPERVISOR_CTYUN:     computeapis.CLOUD_PROVIDER_CTYUN,
+	computeapis.HYPERVISOR_PROXMOX:   computeapis.CLOUD_PROVIDER_PROXMOX,
+	computeapis.HYPERVISOR_OVIRT:     computeapis.CLOUD_PROVIDER_OVIRT,
+	computeapis.HYPERVISOR_LIBVIRT:   computeapis.CLOUD_PROVIDER_LIBVIRT,
 }
 
 var BrandHypervisorMap = map[string]string{
@@ -174,6 +177,9 @@ var BrandHypervisorMap = map[string]string{
 	computeapis.CLOUD_PROVIDER_ZSTACK:    computeapis.HYPERVISOR_ZSTACK,
 	computeapis.CLOUD_PROVIDER_GOOGLE:    computeapis.HYPERVISOR_GOOGLE,
 	computeapis.CLOUD_PROVIDER_CTYUN:     computeapis.HYPERVISOR_CTYUN,
+	computeapis.CLOUD_PROVIDER_PROXMOX:   computeapis.HYPERVISOR_PROXMOX,
+	computeapis.CLOUD_PROVIDER_OVIRT:     computeapis.HYPERVISOR_OVIRT,
+	computeapis.CLOUD_PROVIDER_LIBVIRT:   computeapis.HYPERVISOR_LIBVIRT,
 }
 
 func Hypervisor2Brand(hypervisor string) string {
@@ -35,6 +35,7 @@ import (
 	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
 	randutil "yunion.io/x/onecloud/pkg/util/rand"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
@@ -242,6 +243,7 @@ type newGuestNetworkArgs struct {
 	index int8
 
 	ipAddr              string
+	ip6Addr             string
 	allocDir            api.IPAllocationDirection
 	tryReserved         bool
 	requireDesignatedIP bool
@@ -269,6 +271,7 @@ func (manager *SGuestnetworkManager) newGuestNetwork(
 		network              = args.network
 		index                = args.index
 		address              = args.ipAddr
+		address6             = args.ip6Addr
 		mac                  = args.macAddr
 		driver               = args.nicDriver
 		bwLimit              = args.bwLimit
@@ -330,6 +333,22 @@ func (manager *SGuestnetworkManager) newGuestNetwork(
 			gn.IpAddr = ipAddr
 		}
 
+		if network.IsSupportIPv6() {
+			if addr6, err := netutils2.NewIPV6Addr(address6); err == nil {
+				address6 = addr6.String()
+			}
+			ip6Addr, err := network.GetFreeIP6(ctx, userCred, nil, nil, address6, allocDir)
+			if err != nil {
+				return nil, errors.Wrap(err, "GetFreeIP6")
+			}
+			if len(address6) > 0 && ip6Addr != address6 && requiredDesignatedIp {
+				return nil, fmt.Errorf("candidate ipv6 %s is occupied!", address6)
+			}
+			gn.Ip6Addr = ip6Addr
+		} else if len(address6) > 0 {
+			return nil, httperrors.NewInputParameterError("network %s does not support ipv6", network.Name)
+		}
+
 		if vpc := network.GetVpc(); vpc == nil {
 			return nil, fmt.Errorf("cannot find vpc of network %s(%s)", network.Id, network.Name)
 		} else if vpc.Id != api.DEFAULT_VPC_ID && vpc.GetProviderName() == api.CLOUD_PROVIDER_ONECLOUD {
@@ -573,6 +592,16 @@ func (self *SGuestnetwork) getJsonDesc(network *SNetwork) *jsonutils.JSONDict {
 	}
 	desc.Add(jsonutils.NewString(self.GetIfname()), "ifname")
 	desc.Add(jsonutils.NewInt(int64(network.GuestIpMask)), "masklen")
+	if len(self.Ip6Addr) > 0 {
+		desc.Add(jsonutils.NewString(self.Ip6Addr), "ip6")
+		desc.Add(jsonutils.NewInt(int64(network.GuestIp6Mask)), "masklen6")
+		if len(network.GuestGateway6) > 0 {
+			desc.Add(jsonutils.NewString(network.GuestGateway6), "gateway6")
+		}
+		if dns6 := network.GetDNS6(); len(dns6) > 0 {
+			desc.Add(jsonutils.NewString(dns6), "dns6")
+		}
+	}
 	desc.Add(jsonutils.NewString(self.Driver), "driver")
 	desc.Add(jsonutils.NewInt(int64(network.VlanId)), "vlan")
 	desc.Add(jsonutils.NewInt(int64(self.getBandwidth())), "bw")
@@ -866,11 +895,19 @@ func (self *SGuestnetwork) GetIfname() string {
 }
 
 func (manager *SGuestnetworkManager) getRecentlyReleasedIPAddresses(networkId string, recentDuration time.Duration) map[string]bool {
+	return manager.getRecentlyReleasedAddresses("ip_addr", networkId, recentDuration)
+}
+
+func (manager *SGuestnetworkManager) getRecentlyReleasedIP6Addresses(networkId string, recentDuration time.Duration) map[string]bool {
+	return manager.getRecentlyReleasedAddresses("ip6_addr", networkId, recentDuration)
+}
+
+func (manager *SGuestnetworkManager) getRecentlyReleasedAddresses(field string, networkId string, recentDuration time.Duration) map[string]bool {
 	if recentDuration == 0 {
 		return nil
 	}
 	since := time.Now().UTC().Add(-recentDuration)
-	q := manager.RawQuery("ip_addr")
+	q := manager.RawQuery(field)
 	q = q.Equals("network_id", networkId).IsTrue("deleted")
 	q = q.GT("deleted_at", since).Distinct()
 	rows, err := q.Rows()
@@ -46,6 +46,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
@@ -151,6 +152,17 @@ type SGuest struct {
 
 	// 套餐名称
 	InstanceType string `width:"64" charset:"utf8" nullable:"true" list:"user" create:"optional"`
+
+	// NUMA绑定策略
+	// example: strict
+	NumaPolicy string `width:"16" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// 宿主机上的NUMA节点及vCPU绑定结果, 由宿主机分配后上报
+	CpuNumaPin *api.GuestNumaPin `nullable:"true" get:"user" update:"admin"`
+}
+
+// 记录字段级变更历史
+func (manager *SGuestManager) IsHistoryRecorded() bool {
+	return true
 }
 
 func (manager *SGuestManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
@@ -944,6 +956,16 @@ func ValidateCpuData(vcpuCount int, driver IGuestDriver) (int, error) {
 	return vcpuCount, nil
 }
 
+func validateNumaPolicy(numaPolicy string, hypervisor string) error {
+	if !utils.IsInStringArray(numaPolicy, api.GUEST_NUMA_POLICIES) {
+		return httperrors.NewInputParameterError("invalid numa_policy %s, must be one of %s", numaPolicy, api.GUEST_NUMA_POLICIES)
+	}
+	if len(numaPolicy) > 0 && len(hypervisor) > 0 && hypervisor != api.HYPERVISOR_KVM {
+		return httperrors.NewUnsupportOperationError("numa_policy is not supported by hypervisor %s", hypervisor)
+	}
+	return nil
+}
+
 func ValidateMemCpuData(vmemSize, vcpuCount int, hypervisor string) (int, int, error) {
 	if len(hypervisor) == 0 {
 		hypervisor = api.HYPERVISOR_DEFAULT
@@ -999,6 +1021,18 @@ func (self *SGuest) ValidateUpdateData(ctx context.Context, userCred mcclient.To
 		data.Add(jsonutils.NewInt(int64(vcpuCount)), "vcpu_count")
 	}
 
+	if data.Contains("numa_policy") {
+		numaPolicy, _ := data.GetString("numa_policy")
+		if numaPolicy != self.NumaPolicy {
+			if err := validateNumaPolicy(numaPolicy, self.Hypervisor); err != nil {
+				return nil, err
+			}
+			if self.Status != api.VM_READY {
+				return nil, httperrors.NewInvalidStatusError("Cannot modify numa policy in status %s", self.Status)
+			}
+		}
+	}
+
 	data, err = self.GetDriver().ValidateUpdateData(ctx, userCred, data)
 	if err != nil {
 		return nil, err
@@ -1252,6 +1286,10 @@ func (manager *SGuestManager) validateCreateData(
 		return nil, httperrors.NewBadRequestError("Miss operating system???")
 	}
 
+	if err := validateNumaPolicy(input.NumaPolicy, input.Hypervisor); err != nil {
+		return nil, err
+	}
+
 	hypervisor = input.Hypervisor
 	if hypervisor != api.HYPERVISOR_CONTAINER {
 		// support sku here
@@ -1952,7 +1990,7 @@ func (self *SGuest) moreExtraInfo(
 		out.Disks = self.getDisksDetails()
 		out.DisksInfo = self.getDisksInfoDetails()
 		out.VirtualIps = strings.Join(self.getVirtualIPs(), ",")
-		out.SecurityRules = self.getSecurityGroupsRules()
+		out.SecurityRules, out.SecurityRules6 = self.getSecurityGroupsRules()
 
 		osName := self.GetOS()
 		if len(osName) > 0 {
@@ -1963,7 +2001,7 @@ func (self *SGuest) moreExtraInfo(
 		}
 
 		if userCred.HasSystemAdminPrivilege() {
-			out.AdminSecurityRules = self.getAdminSecurityRules()
+			out.AdminSecurityRules, out.AdminSecurityRules6 = self.getAdminSecurityRules()
 		}
 
 	}
@@ -2335,8 +2373,8 @@ func (self *SGuest) getAdminSecgroupName() string {
 	return ""
 }
 
-//获取多个安全组规则，优先级降序排序
-func (self *SGuest) getSecurityGroupsRules() string {
+//获取多个安全组规则，优先级降序排序, IPv6规则单独返回
+func (self *SGuest) getSecurityGroupsRules() (string, string) {
 	secgroups, _ := self.GetSecgroups()
 	secgroupids := []string{}
 	for _, secgroup := range secgroups {
@@ -2347,22 +2385,24 @@ func (self *SGuest) getSecurityGroupsRules() string {
 	secrules := []SSecurityGroupRule{}
 	if err := db.FetchModelObjects(SecurityGroupRuleManager, q, &secrules); err != nil {
 		log.Errorf("Get rules error: %v", err)
-		return options.Options.DefaultSecurityRules
+		return options.Options.DefaultSecurityRules, ""
 	}
-	rules := []string{}
+	rules, rules6 := []string{}, []string{}
 	for _, rule := range secrules {
-		rules = append(rules, rule.String())
+		r, r6 := rule.ruleStrings()
+		rules = append(rules, r...)
+		rules6 = append(rules6, r6...)
 	}
-	return strings.Join(rules, SECURITY_GROUP_SEPARATOR)
+	return strings.Join(rules, SECURITY_GROUP_SEPARATOR), strings.Join(rules6, SECURITY_GROUP_SEPARATOR)
 }
 
-func (self *SGuest) getAdminSecurityRules() string {
+func (self *SGuest) getAdminSecurityRules() (string, string) {
 	secgrp := self.getAdminSecgroup()
 	if secgrp != nil {
-		ret, _ := secgrp.getSecurityRuleString()
-		return ret
+		ret, ret6, _ := secgrp.getSecurityRuleString()
+		return ret, ret6
 	} else {
-		return options.Options.DefaultAdminSecurityRules
+		return options.Options.DefaultAdminSecurityRules, ""
 	}
 }
 
@@ -2667,6 +2707,7 @@ func (manager *SGuestManager) newCloudVM(ctx context.Context, userCred mcclient.
 }
 
 func (manager *SGuestManager) TotalCount(
+	ctx context.Context,
 	scope rbacutils.TRbacScope,
 	ownerId mcclient.IIdentityProvider,
 	rangeObjs []db.IStandaloneModel,
@@ -2674,7 +2715,7 @@ func (manager *SGuestManager) TotalCount(
 	includeSystem bool, pendingDelete bool,
 	hostTypes []string, resourceTypes []string, providers []string, brands []string, cloudEnv string,
 ) SGuestCountStat {
-	return usageTotalGuestResouceCount(scope, ownerId, rangeObjs, status, hypervisors, includeSystem, pendingDelete, hostTypes, resourceTypes, providers, brands, cloudEnv)
+	return usageTotalGuestResouceCount(ctx, scope, ownerId, rangeObjs, status, hypervisors, includeSystem, pendingDelete, hostTypes, resourceTypes, providers, brands, cloudEnv)
 }
 
 func (self *SGuest) detachNetworks(ctx context.Context, userCred mcclient.TokenCredential, gns []SGuestnetwork, reserve bool, deploy bool) error {
@@ -2737,6 +2778,7 @@ type Attach2NetworkArgs struct {
 	Network *SNetwork
 
 	IpAddr              string
+	Ip6Addr             string
 	AllocDir            api.IPAllocationDirection
 	TryReserved         bool
 	RequireDesignatedIP bool
@@ -2759,6 +2801,7 @@ func (args *Attach2NetworkArgs) onceArgs(i int) attach2NetworkOnceArgs {
 		network: args.Network,
 
 		ipAddr:              args.IpAddr,
+		ip6Addr:             args.Ip6Addr,
 		allocDir:            args.AllocDir,
 		tryReserved:         args.TryReserved,
 		requireDesignatedIP: args.RequireDesignatedIP,
@@ -2774,6 +2817,7 @@ func (args *Attach2NetworkArgs) onceArgs(i int) attach2NetworkOnceArgs {
 	}
 	if i > 0 {
 		r.ipAddr = ""
+		r.ip6Addr = ""
 		r.bwLimit = 0
 		r.virtual = true
 		r.tryReserved = false
@@ -2789,6 +2833,7 @@ type attach2NetworkOnceArgs struct {
 	network *SNetwork
 
 	ipAddr              string
+	ip6Addr             string
 	allocDir            api.IPAllocationDirection
 	tryReserved         bool
 	requireDesignatedIP bool
@@ -2857,6 +2902,7 @@ func (self *SGuest) attach2NetworkOnce(
 		index: index,
 
 		ipAddr:              args.ipAddr,
+		ip6Addr:             args.ip6Addr,
 		allocDir:            args.allocDir,
 		tryReserved:         args.tryReserved,
 		requireDesignatedIP: args.requireDesignatedIP,
@@ -3259,6 +3305,7 @@ type SGuestCountStat struct {
 }
 
 func usageTotalGuestResouceCount(
+	ctx context.Context,
 	scope rbacutils.TRbacScope,
 	ownerId mcclient.IIdentityProvider,
 	rangeObjs []db.IStandaloneModel,
@@ -3278,8 +3325,7 @@ func usageTotalGuestResouceCount(
 	}
 
 	stat := SGuestCountStat{}
-	row := q.Row()
-	err := q.Row2Struct(row, &stat)
+	err := replica.First(ctx, q, &stat)
 	if err != nil {
 		log.Errorf("%s", err)
 	}
@@ -3467,6 +3513,7 @@ func (self *SGuest) attach2NamedNetworkDesc(ctx context.Context, userCred mcclie
 			Network:             net,
 			PendingUsage:        pendingUsage,
 			IpAddr:              netConfig.Address,
+			Ip6Addr:             netConfig.Address6,
 			NicDriver:           netConfig.Driver,
 			BwLimit:             netConfig.BwLimit,
 			Virtual:             netConfig.Vip,
@@ -3984,6 +4031,9 @@ func (self *SGuest) GetJsonDescAtHypervisor(ctx context.Context, host *SHost) *j
 	desc.Add(jsonutils.NewString(self.getMachine()), "machine")
 	desc.Add(jsonutils.NewString(self.getBios()), "bios")
 	desc.Add(jsonutils.NewString(self.BootOrder), "boot_order")
+	if len(self.NumaPolicy) > 0 {
+		desc.Add(jsonutils.NewString(self.NumaPolicy), "numa_policy")
+	}
 
 	desc.Add(jsonutils.NewBool(self.SrcIpCheck.Bool()), "src_ip_check")
 	desc.Add(jsonutils.NewBool(self.SrcMacCheck.Bool()), "src_mac_check")
@@ -4077,14 +4127,20 @@ func (self *SGuest) GetJsonDescAtHypervisor(ctx context.Context, host *SHost) *j
 		if srs.estimatedSinglePortRuleCount() <= options.FirewallFlowCountLimit {
 	*/
 
-	rules := self.getSecurityGroupsRules()
+	rules, rules6 := self.getSecurityGroupsRules()
 	if len(rules) > 0 {
 		desc.Add(jsonutils.NewString(rules), "security_rules")
 	}
-	rules = self.getAdminSecurityRules()
+	if len(rules6) > 0 {
+		desc.Add(jsonutils.NewString(rules6), "security_rules6")
+	}
+	rules, rules6 = self.getAdminSecurityRules()
 	if len(rules) > 0 {
 		desc.Add(jsonutils.NewString(rules), "admin_security_rules")
 	}
+	if len(rules6) > 0 {
+		desc.Add(jsonutils.NewString(rules6), "admin_security_rules6")
+	}
 
 	extraOptions := self.getExtraOptions()
 	if extraOptions != nil {
@@ -4203,14 +4259,20 @@ func (self *SGuest) GetJsonDescAtBaremetal(ctx context.Context, host *SHost) *js
 		desc.Add(jsonutils.NewStringArray(netRoles), "network_roles")
 	}
 
-	rules := self.getSecurityGroupsRules()
+	rules, rules6 := self.getSecurityGroupsRules()
 	if len(rules) > 0 {
 		desc.Add(jsonutils.NewString(rules), "security_rules")
 	}
-	rules = self.getAdminSecurityRules()
+	if len(rules6) > 0 {
+		desc.Add(jsonutils.NewString(rules6), "security_rules6")
+	}
+	rules, rules6 = self.getAdminSecurityRules()
 	if len(rules) > 0 {
 		desc.Add(jsonutils.NewString(rules), "admin_security_rules")
 	}
+	if len(rules6) > 0 {
+		desc.Add(jsonutils.NewString(rules6), "admin_security_rules6")
+	}
 
 	zone := self.getZone()
 	if zone != nil {
@@ -5098,6 +5160,7 @@ func (self *SGuest) ToSchedDesc() *schedapi.ScheduleInput {
 	}*/
 
 	config.Hypervisor = self.GetHypervisor()
+	config.NumaPolicy = self.NumaPolicy
 	desc.ServerConfig = *config
 	desc.OsArch = self.OsArch
 	return desc
@@ -5306,6 +5369,7 @@ func (self *SGuest) toCreateInput() *api.ServerCreateInput {
 
 	r.ServerConfigs = new(api.ServerConfigs)
 	r.Hypervisor = self.Hypervisor
+	r.NumaPolicy = self.NumaPolicy
 	r.InstanceType = self.InstanceType
 	r.ProjectId = self.ProjectId
 	r.ProjectDomainId = self.DomainId
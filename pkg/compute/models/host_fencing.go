@@ -0,0 +1,218 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/baremetal/utils/ipmitool"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	_ "yunion.io/x/onecloud/pkg/util/redfish/loader"
+)
+
+const (
+	hostFencePolicyMetaKey = "__fence_policy_on_host_down"
+
+	hostFencePollInterval = 5 * time.Second
+)
+
+const (
+	errHostNoBmc     = errors.Error("host has no bmc information, power off is the only supported fencing and storage level fencing is not supported")
+	errHostFenceWait = errors.Error("timeout waiting for host to power off")
+)
+
+// IHostEvacuator 隔离成功后疏散宿主机上的虚拟机
+type IHostEvacuator interface {
+	EvacuateOnHostDown(ctx context.Context, userCred mcclient.TokenCredential)
+}
+
+// EvacuateOnHostDown 切换备机并迁移共享存储上的虚拟机
+func (host *SHost) EvacuateOnHostDown(ctx context.Context, userCred mcclient.TokenCredential) {
+	host.switchWithBackup(ctx, userCred)
+	host.migrateOnHostDown(ctx, userCred)
+}
+
+// getFencePolicy 宿主机元数据中的隔离策略优先于全局配置
+func (host *SHost) getFencePolicy() string {
+	return resolveFencePolicy(host.GetMetadata(hostFencePolicyMetaKey, nil), options.Options.HostFencePolicy)
+}
+
+// resolveFencePolicy 依次取有效的宿主机策略与全局策略, 都无效时不隔离
+func resolveFencePolicy(hostPolicy, globalPolicy string) string {
+	for _, policy := range []string{hostPolicy, globalPolicy} {
+		if utils.IsInStringArray(policy, api.HOST_FENCE_POLICIES) {
+			return policy
+		}
+	}
+	return api.HOST_FENCE_POLICY_NONE
+}
+
+// needEvacuateOnHostDown 没有需要切换或迁移的虚拟机时无需隔离
+func (host *SHost) needEvacuateOnHostDown() bool {
+	if host.GetMetadata("__auto_migrate_on_host_down", nil) == "enable" {
+		return true
+	}
+	return len(host.GetGuestsMasterOnThisHost()) > 0 || len(host.GetGuestsBackupOnThisHost()) > 0
+}
+
+// evacuateOnHostDown 宿主机失联后按隔离策略先隔离宿主机, 隔离成功后才切换备机与迁移虚拟机,
+// 避免网络分区时原宿主机仍在写共享存储
+func (host *SHost) evacuateOnHostDown(ctx context.Context, userCred mcclient.TokenCredential) {
+	policy := host.getFencePolicy()
+	if policy == api.HOST_FENCE_POLICY_NONE || !host.needEvacuateOnHostDown() {
+		host.OnHostFenced(ctx, userCred, host)
+		return
+	}
+	params := jsonutils.NewDict()
+	params.Set("fence_policy", jsonutils.NewString(policy))
+	task, err := taskman.TaskManager.NewTask(ctx, "HostFenceTask", host, userCred, params, "", "", nil)
+	if err != nil {
+		log.Errorf("host %s(%s) start fence task: %v", host.Name, host.Id, err)
+		host.OnHostFenceFailed(ctx, userCred, errors.Wrap(err, "NewTask"))
+		return
+	}
+	task.ScheduleRun(nil)
+}
+
+// OnHostFenced 隔离成功(或无需隔离)后由evacuator疏散虚拟机, 通常为宿主机自身
+func (host *SHost) OnHostFenced(ctx context.Context, userCred mcclient.TokenCredential, evacuator IHostEvacuator) {
+	evacuator.EvacuateOnHostDown(ctx, userCred)
+}
+
+// OnHostFenceFailed 隔离失败时不迁移虚拟机, 由管理员确认宿主机状态后手动处理
+func (host *SHost) OnHostFenceFailed(ctx context.Context, userCred mcclient.TokenCredential, err error) {
+	msg := "fence failed, servers are not evacuated: " + err.Error()
+	db.OpsLog.LogEvent(host, db.ACT_HOST_FENCE_FAIL, msg, userCred)
+	logclient.AddSimpleActionLog(host, logclient.ACT_HOST_FENCE, msg, userCred, false)
+}
+
+// Fence 按策略隔离宿主机, 返回前确认宿主机已经关机
+func (host *SHost) Fence(ctx context.Context, policy string) error {
+	switch policy {
+	case api.HOST_FENCE_POLICY_NONE:
+		return nil
+	case api.HOST_FENCE_POLICY_POWER_OFF:
+		return host.fencePowerOff(ctx)
+	}
+	return errors.Wrapf(errors.ErrNotSupported, "fence policy %q", policy)
+}
+
+func (host *SHost) fencePowerOff(ctx context.Context) error {
+	info, err := host.GetIpmiInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetIpmiInfo")
+	}
+	if err := checkFenceBmc(info); err != nil {
+		return err
+	}
+	password, err := utils.DescryptAESBase64(host.Id, info.Password)
+	if err != nil {
+		return errors.Wrap(err, "decrypt bmc password")
+	}
+
+	var (
+		powerOff  func() error
+		isPowerOn func() (bool, error)
+	)
+	if info.RedfishApi {
+		drv := redfish.NewRedfishDriver(ctx, "https://"+info.IpAddr, info.Username, password, false)
+		if drv != nil {
+			powerOff = func() error {
+				err := drv.Reset(ctx, "ForceOff")
+				// 409表示已经处于关机状态
+				if err != nil && httputils.ErrorCode(err) != 409 {
+					return errors.Wrap(err, "redfish Reset ForceOff")
+				}
+				return nil
+			}
+			isPowerOn = func() (bool, error) {
+				_, sysInfo, err := drv.GetSystemInfo(ctx)
+				if err != nil {
+					return false, errors.Wrap(err, "redfish GetSystemInfo")
+				}
+				return !strings.EqualFold(sysInfo.PowerState, "off"), nil
+			}
+		} else {
+			log.Warningf("host %s(%s) no redfish driver found, fallback to ipmi", host.Name, host.Id)
+		}
+	}
+	if powerOff == nil {
+		cli := ipmitool.NewLanPlusIPMI(info.IpAddr, info.Username, password)
+		powerOff = func() error {
+			return errors.Wrap(ipmitool.DoHardShutdown(cli), "ipmi DoHardShutdown")
+		}
+		isPowerOn = func() (bool, error) {
+			status, err := ipmitool.GetChassisPowerStatus(cli)
+			if err != nil {
+				return false, errors.Wrap(err, "ipmi GetChassisPowerStatus")
+			}
+			return status != "off", nil
+		}
+	}
+
+	if err := powerOff(); err != nil {
+		return err
+	}
+	timeout := time.Duration(options.Options.HostFenceTimeoutSeconds) * time.Second
+	err = waitPowerOff(isPowerOn, timeout, hostFencePollInterval)
+	if err != nil {
+		return errors.Wrapf(err, "host %s(%s)", host.Name, host.Id)
+	}
+	return nil
+}
+
+// checkFenceBmc 隔离需要完整的带外管理地址与账号
+func checkFenceBmc(info types.SIPMIInfo) error {
+	if !info.Present || len(info.IpAddr) == 0 || len(info.Username) == 0 {
+		return errHostNoBmc
+	}
+	return nil
+}
+
+// waitPowerOff 轮询电源状态直到确认关机, 超时返回errHostFenceWait
+func waitPowerOff(isPowerOn func() (bool, error), timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		on, err := isPowerOn()
+		if err == nil && !on {
+			return nil
+		}
+		if err != nil {
+			log.Warningf("query power status: %v", err)
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return errors.Wrap(errHostFenceWait, err.Error())
+			}
+			return errHostFenceWait
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func TestResolveFencePolicy(t *testing.T) {
+	cases := []struct {
+		host   string
+		global string
+		want   string
+	}{
+		{"", "", api.HOST_FENCE_POLICY_NONE},
+		{"", api.HOST_FENCE_POLICY_POWER_OFF, api.HOST_FENCE_POLICY_POWER_OFF},
+		{api.HOST_FENCE_POLICY_NONE, api.HOST_FENCE_POLICY_POWER_OFF, api.HOST_FENCE_POLICY_NONE},
+		{api.HOST_FENCE_POLICY_POWER_OFF, api.HOST_FENCE_POLICY_NONE, api.HOST_FENCE_POLICY_POWER_OFF},
+		{"reboot", api.HOST_FENCE_POLICY_POWER_OFF, api.HOST_FENCE_POLICY_POWER_OFF},
+		{"reboot", "shutdown", api.HOST_FENCE_POLICY_NONE},
+	}
+	for _, c := range cases {
+		if got := resolveFencePolicy(c.host, c.global); got != c.want {
+			t.Errorf("resolveFencePolicy(%q, %q) = %q, want %q", c.host, c.global, got, c.want)
+		}
+	}
+}
+
+func TestCheckFenceBmc(t *testing.T) {
+	cases := []struct {
+		name string
+		info types.SIPMIInfo
+		err  error
+	}{
+		{"no ipmi", types.SIPMIInfo{}, errHostNoBmc},
+		{"not present", types.SIPMIInfo{IpAddr: "10.0.0.1", Username: "root"}, errHostNoBmc},
+		{"no address", types.SIPMIInfo{Present: true, Username: "root"}, errHostNoBmc},
+		{"no username", types.SIPMIInfo{Present: true, IpAddr: "10.0.0.1"}, errHostNoBmc},
+		{"ok", types.SIPMIInfo{Present: true, IpAddr: "10.0.0.1", Username: "root"}, nil},
+	}
+	for _, c := range cases {
+		if err := checkFenceBmc(c.info); err != c.err {
+			t.Errorf("%s: want %v got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestWaitPowerOff(t *testing.T) {
+	queryErr := errors.Error("bmc unreachable")
+	cases := []struct {
+		name   string
+		states []bool
+		errs   []error
+		err    error
+	}{
+		{"off at once", []bool{false}, []error{nil}, nil},
+		{"off after polls", []bool{true, true, false}, []error{nil, nil, nil}, nil},
+		{"never off", []bool{true}, []error{nil}, errHostFenceWait},
+		{"query keeps failing", []bool{false}, []error{queryErr}, errHostFenceWait},
+	}
+	for _, c := range cases {
+		i := 0
+		isPowerOn := func() (bool, error) {
+			idx := i
+			if idx >= len(c.states) {
+				idx = len(c.states) - 1
+			}
+			i++
+			return c.states[idx], c.errs[idx]
+		}
+		err := waitPowerOff(isPowerOn, 20*time.Millisecond, time.Millisecond)
+		if errors.Cause(err) != c.err {
+			t.Errorf("%s: want %v got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestFenceUnknownPolicy(t *testing.T) {
+	host := &SHost{}
+	if err := host.Fence(context.Background(), api.HOST_FENCE_POLICY_NONE); err != nil {
+		t.Errorf("fence with policy none: %v", err)
+	}
+	if err := host.Fence(context.Background(), "reboot"); errors.Cause(err) != errors.ErrNotSupported {
+		t.Errorf("fence with unknown policy: want %v got %v", errors.ErrNotSupported, err)
+	}
+}
+
+type sFakeEvacuator struct {
+	evacuated int
+}
+
+func (e *sFakeEvacuator) EvacuateOnHostDown(ctx context.Context, userCred mcclient.TokenCredential) {
+	e.evacuated++
+}
+
+func TestOnHostFenceResult(t *testing.T) {
+	consts.DisableOpsLog()
+	evacuator := &sFakeEvacuator{}
+
+	host := &SHost{}
+	host.OnHostFenceFailed(context.Background(), nil, errHostFenceWait)
+	if evacuator.evacuated != 0 {
+		t.Errorf("servers should not be evacuated when fence failed")
+	}
+	host.OnHostFenced(context.Background(), nil, evacuator)
+	if evacuator.evacuated != 1 {
+		t.Errorf("servers should be evacuated once when fenced, got %d", evacuator.evacuated)
+	}
+}
@@ -44,6 +44,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -113,6 +114,8 @@ type SHost struct {
 	CpuCount int `nullable:"true" list:"domain" update:"domain" create:"domain_optional"`
 	// 物理CPU颗数
 	NodeCount int8 `nullable:"true" list:"domain" update:"domain" create:"domain_optional"`
+	// NUMA拓扑
+	Topology *api.HostTopology `nullable:"true" get:"domain" update:"domain" create:"domain_optional"`
 	// CPU描述信息
 	CpuDesc string `width:"64" charset:"ascii" nullable:"true" get:"domain" update:"domain" create:"domain_optional"`
 	// CPU频率
@@ -190,6 +193,11 @@ type SHost struct {
 	OvnMappedIpAddr string `width:"16" charset:"ascii" nullable:"true" list:"user"`
 }
 
+// 记录字段级变更历史
+func (manager *SHostManager) IsHistoryRecorded() bool {
+	return true
+}
+
 func (manager *SHostManager) GetContextManagers() [][]db.IModelManager {
 	return [][]db.IModelManager{
 		{ZoneManager},
@@ -2602,7 +2610,7 @@ type HostsCountStat struct {
 	IsolatedReservedStorage int64
 }
 
-func (manager *SHostManager) calculateCount(q *sqlchemy.SQuery) HostsCountStat {
+func (manager *SHostManager) calculateCount(ctx context.Context, q *sqlchemy.SQuery) HostsCountStat {
 	usableSize := func(act, reserved int) int {
 		aSize := 0
 		if reserved > 0 && reserved < act {
@@ -2628,7 +2636,7 @@ func (manager *SHostManager) calculateCount(q *sqlchemy.SQuery) HostsCountStat {
 		totalCPU int64 = 0
 	)
 	stats := make([]HostStat, 0)
-	err := q.All(&stats)
+	err := replica.All(ctx, q, &stats)
 	if err != nil {
 		log.Errorf("%v", err)
 	}
@@ -2676,6 +2684,7 @@ func (manager *SHostManager) calculateCount(q *sqlchemy.SQuery) HostsCountStat {
 }
 
 func (manager *SHostManager) TotalCount(
+	ctx context.Context,
 	userCred mcclient.IIdentityProvider,
 	scope rbacutils.TRbacScope,
 	rangeObjs []db.IStandaloneModel,
@@ -2686,6 +2695,7 @@ func (manager *SHostManager) TotalCount(
 	enabled, isBaremetal tristate.TriState,
 ) HostsCountStat {
 	return manager.calculateCount(
+		ctx,
 		manager.totalCountQ(
 			userCred,
 			scope,
@@ -2927,6 +2937,7 @@ func (self *SHost) getMoreDetails(ctx context.Context, out api.HostDetails, show
 	if self.GetMetadata("__auto_migrate_on_host_down", nil) == "enable" {
 		out.AutoMigrateOnHostDown = true
 	}
+	out.FencePolicyOnHostDown = self.getFencePolicy()
 
 	if count, rs := self.GetReservedResourceForIsolatedDevice(); rs != nil {
 		out.ReservedResourceForGpu = *rs
@@ -3839,9 +3850,20 @@ func (self *SHost) PerformAutoMigrateOnHostDown(
 	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject,
 ) (jsonutils.JSONObject, error) {
 	val, _ := data.GetString("auto_migrate_on_host_down")
+	fencePolicy, _ := data.GetString("fence_policy")
+	if len(fencePolicy) > 0 && !utils.IsInStringArray(fencePolicy, api.HOST_FENCE_POLICIES) {
+		return nil, httperrors.NewInputParameterError("invalid fence_policy %q, want one of %v", fencePolicy, api.HOST_FENCE_POLICIES)
+	}
+	if fencePolicy == api.HOST_FENCE_POLICY_POWER_OFF {
+		if info, _ := self.GetIpmiInfo(); !info.Present || len(info.IpAddr) == 0 {
+			return nil, httperrors.NewInputParameterError("host %s has no ipmi information, cannot use fence policy %s", self.Name, fencePolicy)
+		}
+	}
 
 	var meta map[string]interface{}
-	if val == "enable" {
+	if len(val) == 0 && len(fencePolicy) > 0 {
+		meta = map[string]interface{}{}
+	} else if val == "enable" {
 		meta = map[string]interface{}{
 			"__auto_migrate_on_host_down": "enable",
 			"__on_host_down":              "shutdown-servers",
@@ -3857,6 +3879,9 @@ func (self *SHost) PerformAutoMigrateOnHostDown(
 			"__on_host_down":              "",
 		}
 	}
+	if len(fencePolicy) > 0 {
+		meta[hostFencePolicyMetaKey] = fencePolicy
+	}
 
 	return nil, self.SetAllMetadata(ctx, meta, userCred)
 }
@@ -5392,8 +5417,7 @@ func (host *SHost) OnHostDown(ctx context.Context, userCred mcclient.TokenCreden
 		log.Errorf("update host %s failed %s", host.Id, err)
 	}
 	host.SyncCleanSchedDescCache()
-	host.switchWithBackup(ctx, userCred)
-	host.migrateOnHostDown(ctx, userCred)
+	host.evacuateOnHostDown(ctx, userCred)
 }
 
 func (host *SHost) switchWithBackup(ctx context.Context, userCred mcclient.TokenCredential) {
@@ -5611,6 +5635,110 @@ func (self *SHost) GetDetailsJnlp(ctx context.Context, userCred mcclient.TokenCr
 	return resp, nil
 }
 
+func (self *SHost) AllowGetDetailsBios(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, self, "bios")
+}
+
+func (self *SHost) GetDetailsBios(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Cannot get BIOS of a non-baremetal host")
+	}
+	url := fmt.Sprintf("/baremetals/%s/bios", self.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	resp, err := self.BaremetalSyncRequest(ctx, "POST", url, header, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "BaremetalSyncRequest")
+	}
+	return resp, nil
+}
+
+func (self *SHost) AllowPerformSetBios(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "set-bios")
+}
+
+// 设置BIOS属性, 重启后生效
+func (self *SHost) PerformSetBios(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Cannot set BIOS of a non-baremetal host")
+	}
+	attrs, err := data.Get("attributes")
+	if err != nil {
+		return nil, httperrors.NewMissingParameterError("attributes")
+	}
+	if _, ok := attrs.(*jsonutils.JSONDict); !ok {
+		return nil, httperrors.NewInputParameterError("attributes should be a dict")
+	}
+	url := fmt.Sprintf("/baremetals/%s/set-bios", self.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	body := jsonutils.NewDict()
+	body.Add(attrs, "attributes")
+	resp, err := self.BaremetalSyncRequest(ctx, "POST", url, header, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "BaremetalSyncRequest")
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, attrs, userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UPDATE, attrs, userCred, true)
+	return resp, nil
+}
+
+func (self *SHost) AllowGetDetailsFirmwares(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, self, "firmwares")
+}
+
+func (self *SHost) GetDetailsFirmwares(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Cannot get firmwares of a non-baremetal host")
+	}
+	url := fmt.Sprintf("/baremetals/%s/firmwares", self.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	resp, err := self.BaremetalSyncRequest(ctx, "POST", url, header, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "BaremetalSyncRequest")
+	}
+	return resp, nil
+}
+
+func (self *SHost) AllowPerformUpdateFirmware(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "update-firmware")
+}
+
+func (self *SHost) PerformUpdateFirmware(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Cannot update firmware of a non-baremetal host")
+	}
+	if !utils.IsInStringArray(self.Status, []string{api.BAREMETAL_READY, api.BAREMETAL_RUNNING}) {
+		return nil, httperrors.NewInvalidStatusError("Cannot do update-firmware in status %s", self.Status)
+	}
+	imageUri, _ := data.GetString("image_uri")
+	if len(imageUri) == 0 {
+		return nil, httperrors.NewMissingParameterError("image_uri")
+	}
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewString(imageUri), "image_uri")
+	if protocol, _ := data.GetString("protocol"); len(protocol) > 0 {
+		body.Add(jsonutils.NewString(protocol), "protocol")
+	}
+	if targets, _ := jsonutils.GetStringArray(data, "targets"); len(targets) > 0 {
+		body.Add(jsonutils.NewStringArray(targets), "targets")
+	}
+	url := fmt.Sprintf("/baremetals/%s/update-firmware", self.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	_, err := self.BaremetalSyncRequest(ctx, "POST", url, header, body)
+	if err != nil {
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UPDATE, err, userCred, false)
+		return nil, errors.Wrap(err, "BaremetalSyncRequest")
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, body, userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UPDATE, body, userCred, true)
+	return nil, nil
+}
+
 func (self *SHost) AllowPerformInsertIso(ctx context.Context,
 	userCred mcclient.TokenCredential,
 	query jsonutils.JSONObject,
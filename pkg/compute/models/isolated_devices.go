@@ -29,6 +29,7 @@ import (
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
@@ -538,6 +539,7 @@ type IsolatedDeviceCountStat struct {
 }
 
 func (manager *SIsolatedDeviceManager) totalCount(
+	ctx context.Context,
 	devType,
 	hostTypes []string,
 	resourceTypes []string,
@@ -546,7 +548,7 @@ func (manager *SIsolatedDeviceManager) totalCount(
 	cloudEnv string,
 	rangeObjs []db.IStandaloneModel,
 ) (int, error) {
-	return manager.totalCountQ(
+	q := manager.totalCountQ(
 		devType,
 		hostTypes,
 		resourceTypes,
@@ -554,10 +556,12 @@ func (manager *SIsolatedDeviceManager) totalCount(
 		brands,
 		cloudEnv,
 		rangeObjs,
-	).CountWithError()
+	)
+	return replica.Count(ctx, q)
 }
 
 func (manager *SIsolatedDeviceManager) TotalCount(
+	ctx context.Context,
 	hostType []string,
 	resourceTypes []string,
 	providers []string,
@@ -567,6 +571,7 @@ func (manager *SIsolatedDeviceManager) TotalCount(
 ) (IsolatedDeviceCountStat, error) {
 	stat := IsolatedDeviceCountStat{}
 	devCnt, err := manager.totalCount(
+		ctx,
 		nil, hostType, resourceTypes,
 		providers, brands, cloudEnv,
 		rangeObjs)
@@ -574,6 +579,7 @@ func (manager *SIsolatedDeviceManager) TotalCount(
 		return stat, err
 	}
 	gpuCnt, err := manager.totalCount(
+		ctx,
 		VALID_GPU_TYPES, hostType, resourceTypes,
 		providers, brands, cloudEnv,
 		rangeObjs)
@@ -38,6 +38,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -47,6 +48,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/util/billing"
 	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
 	"yunion.io/x/onecloud/pkg/util/rand"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
@@ -94,11 +96,16 @@ type SNetwork struct {
 
 	GuestDomain string `width:"128" charset:"ascii" nullable:"true" get:"user" update:"user"`
 
-	GuestIp6Start string `width:"64" charset:"ascii" nullable:"true"`
-	GuestIp6End   string `width:"64" charset:"ascii" nullable:"true"`
-	GuestIp6Mask  int8   `nullable:"true"`
-	GuestGateway6 string `width:"64" charset:"ascii" nullable:"true"`
-	GuestDns6     string `width:"64" charset:"ascii" nullable:"true"`
+	// IPv6起始地址
+	GuestIp6Start string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6结束地址
+	GuestIp6End string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6前缀长度
+	GuestIp6Mask int8 `nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6网关地址
+	GuestGateway6 string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6 DNS
+	GuestDns6 string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
 
 	GuestDomain6 string `width:"128" charset:"ascii" nullable:"true"`
 
@@ -124,6 +131,11 @@ type SNetwork struct {
 	BgpType string `width:"64" charset:"utf8" nullable:"false" list:"user" get:"user" update:"user" create:"optional"`
 }
 
+// 记录字段级变更历史
+func (manager *SNetworkManager) IsHistoryRecorded() bool {
+	return true
+}
+
 func (manager *SNetworkManager) GetContextManagers() [][]db.IModelManager {
 	return [][]db.IModelManager{
 		{WireManager},
@@ -485,6 +497,112 @@ func (self *SNetwork) getFreeIP(addrTable map[string]bool, recentUsedAddrTable m
 	return "", httperrors.NewInsufficientResourceError("Out of IP address")
 }
 
+// IsSupportIPv6 网络是否配置了IPv6地址段
+func (self *SNetwork) IsSupportIPv6() bool {
+	return len(self.GuestIp6Start) > 0 && len(self.GuestIp6End) > 0 && self.GuestIp6Mask > 0
+}
+
+func (self *SNetwork) getIP6Range() netutils2.IPV6AddrRange {
+	start, _ := netutils2.NewIPV6Addr(self.GuestIp6Start)
+	end, _ := netutils2.NewIPV6Addr(self.GuestIp6End)
+	return netutils2.NewIPV6AddrRange(start, end)
+}
+
+func (self *SNetwork) GetIP6Prefix() string {
+	start, err := netutils2.NewIPV6Addr(self.GuestIp6Start)
+	if err != nil {
+		return ""
+	}
+	prefix := netutils2.IPV6Prefix{
+		Address: start.NetAddr(uint8(self.GuestIp6Mask)),
+		MaskLen: uint8(self.GuestIp6Mask),
+	}
+	return prefix.String()
+}
+
+func (self *SNetwork) GetUsedAddresses6() map[string]bool {
+	used := make(map[string]bool)
+	q := GuestnetworkManager.Query("ip6_addr").Equals("network_id", self.Id).IsNotEmpty("ip6_addr")
+	rows, err := q.Rows()
+	if err != nil {
+		log.Errorf("GetUsedAddresses6 fail %s", err)
+		return used
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			log.Errorf("scan error %s", err)
+			continue
+		}
+		used[ip] = true
+	}
+	return used
+}
+
+// getFreeIP6 与getFreeIP的分配策略保持一致，随机分配失败后退化为顺序分配
+func (self *SNetwork) getFreeIP6(addrTable map[string]bool, recentUsedAddrTable map[string]bool, candidate string, allocDir api.IPAllocationDirection) (string, error) {
+	iprange := self.getIP6Range()
+	if len(candidate) > 0 {
+		candIP, err := netutils2.NewIPV6Addr(candidate)
+		if err != nil {
+			return "", httperrors.NewInputParameterError("invalid ipv6 address %s", candidate)
+		}
+		if !iprange.Contains(candIP) {
+			return "", httperrors.NewInputParameterError("candidate %s out of range", candidate)
+		}
+		if _, ok := addrTable[candIP.String()]; !ok {
+			return candIP.String(), nil
+		}
+	}
+	if len(self.AllocPolicy) > 0 && api.IPAllocationDirection(self.AllocPolicy) != api.IPAllocationNone {
+		allocDir = api.IPAllocationDirection(self.AllocPolicy)
+	}
+	// 地址段可能极大，顺序查找最多尝试已用地址数加上最近释放的地址数次
+	maxTries := len(addrTable) + len(recentUsedAddrTable) + 1
+	if len(allocDir) == 0 || allocDir == api.IPAllocationStepdown {
+		ip := iprange.EndIp()
+		for i := 0; i < maxTries && iprange.Contains(ip); i++ {
+			if !isIpUsed(ip.String(), addrTable, recentUsedAddrTable) {
+				return ip.String(), nil
+			}
+			ip = ip.StepDown()
+		}
+	} else {
+		if allocDir == api.IPAllocationRadnom {
+			const MAX_TRIES = 5
+			for i := 0; i < MAX_TRIES; i += 1 {
+				ip := iprange.Random()
+				if !isIpUsed(ip.String(), addrTable, recentUsedAddrTable) {
+					return ip.String(), nil
+				}
+			}
+		}
+		ip := iprange.StartIp()
+		for i := 0; i < maxTries && iprange.Contains(ip); i++ {
+			if !isIpUsed(ip.String(), addrTable, recentUsedAddrTable) {
+				return ip.String(), nil
+			}
+			ip = ip.StepUp()
+		}
+	}
+	return "", httperrors.NewInsufficientResourceError("Out of IPv6 address")
+}
+
+func (self *SNetwork) GetFreeIP6(ctx context.Context, userCred mcclient.TokenCredential, addrTable map[string]bool, recentUsedAddrTable map[string]bool, candidate string, allocDir api.IPAllocationDirection) (string, error) {
+	if addrTable == nil {
+		addrTable = self.GetUsedAddresses6()
+	}
+	if recentUsedAddrTable == nil {
+		recentUsedAddrTable = GuestnetworkManager.getRecentlyReleasedIP6Addresses(self.Id, self.getAllocTimoutDuration())
+	}
+	return self.getFreeIP6(addrTable, recentUsedAddrTable, candidate, allocDir)
+}
+
+func (self *SNetwork) GetDNS6() string {
+	return self.GuestDns6
+}
+
 func (self *SNetwork) GetFreeIPWithLock(ctx context.Context, userCred mcclient.TokenCredential, addrTable map[string]bool, recentUsedAddrTable map[string]bool, candidate string, allocDir api.IPAllocationDirection, reserved bool) (string, error) {
 	lockman.LockObject(ctx, self)
 	defer lockman.ReleaseObject(ctx, self)
@@ -912,18 +1030,20 @@ type NetworkPortStat struct {
 }
 
 func (manager *SNetworkManager) TotalPortCount(
+	ctx context.Context,
 	scope rbacutils.TRbacScope,
 	userCred mcclient.IIdentityProvider,
 	providers []string, brands []string, cloudEnv string,
 	rangeObjs []db.IStandaloneModel,
 ) NetworkPortStat {
 	nets := make([]SNetwork, 0)
-	err := manager.totalPortCountQ(
+	q := manager.totalPortCountQ(
 		scope,
 		userCred,
 		providers, brands, cloudEnv,
 		rangeObjs,
-	).All(&nets)
+	)
+	err := replica.All(ctx, q, &nets)
 	if err != nil {
 		log.Errorf("TotalPortCount: %v", err)
 	}
@@ -1202,6 +1322,135 @@ func (self *SNetwork) GetDetailsReservedIps(ctx context.Context, userCred mcclie
 	return ret, nil
 }
 
+// validateIP6Range 校验IPv6地址段与网关是否在同一前缀内
+func validateIP6Range(ip6Start, ip6End string, masklen uint8, gateway6 string) (netutils2.IPV6AddrRange, error) {
+	if masklen < 48 || masklen > 126 {
+		return netutils2.IPV6AddrRange{}, httperrors.NewInputParameterError("Invalid ipv6 masklen %d", masklen)
+	}
+	startIp, err := netutils2.NewIPV6Addr(ip6Start)
+	if err != nil {
+		return netutils2.IPV6AddrRange{}, httperrors.NewInputParameterError("Invalid ipv6 start ip: %s", ip6Start)
+	}
+	endIp, err := netutils2.NewIPV6Addr(ip6End)
+	if err != nil {
+		return netutils2.IPV6AddrRange{}, httperrors.NewInputParameterError("Invalid ipv6 end ip: %s", ip6End)
+	}
+	ipRange := netutils2.NewIPV6AddrRange(startIp, endIp)
+	netAddr := ipRange.StartIp().NetAddr(masklen)
+	if ipRange.EndIp().NetAddr(masklen) != netAddr {
+		return ipRange, httperrors.NewInputParameterError("ipv6 start and end ip not in the same subnet")
+	}
+	if len(gateway6) > 0 {
+		gw, err := netutils2.NewIPV6Addr(gateway6)
+		if err != nil {
+			return ipRange, httperrors.NewInputParameterError("bad ipv6 gateway ip: %s", gateway6)
+		}
+		if gw.NetAddr(masklen) != netAddr {
+			return ipRange, httperrors.NewInputParameterError("ipv6 gateway ip must be in the same subnet as start, end ip")
+		}
+		if ipRange.Contains(gw) {
+			return ipRange, httperrors.NewInputParameterError("ipv6 gateway ip must not be in the range of start, end ip")
+		}
+	}
+	return ipRange, nil
+}
+
+func isOverlapNetworks6(nets []SNetwork, ipRange netutils2.IPV6AddrRange) bool {
+	for i := range nets {
+		if nets[i].IsSupportIPv6() && nets[i].getIP6Range().IsOverlap(ipRange) {
+			return true
+		}
+	}
+	return false
+}
+
+func (manager *SNetworkManager) validateIP6CreateData(input api.NetworkCreateInput, isOneCloudVpc bool, nets []SNetwork) (api.NetworkCreateInput, error) {
+	if len(input.GuestIp6Prefix) > 0 {
+		prefix, err := netutils2.NewIPV6Prefix(input.GuestIp6Prefix)
+		if err != nil {
+			return input, httperrors.NewInputParameterError("ip6_prefix error: %s", err)
+		}
+		ipRange := prefix.ToIPRange()
+		input.GuestIp6Start = ipRange.StartIp().String()
+		input.GuestIp6End = ipRange.EndIp().String()
+		input.GuestIp6Mask = prefix.MaskLen
+		input.GuestIp6Prefix = prefix.String()
+	}
+	if isOneCloudVpc {
+		// 与IPv4一致，保留前缀内第一个地址作为路由器网关
+		startIp, err := netutils2.NewIPV6Addr(input.GuestIp6Start)
+		if err != nil {
+			return input, httperrors.NewInputParameterError("Invalid ipv6 start ip: %s", input.GuestIp6Start)
+		}
+		gateway := startIp.NetAddr(input.GuestIp6Mask).StepUp()
+		if startIp.Cmp(gateway) <= 0 {
+			input.GuestIp6Start = gateway.StepUp().String()
+		}
+		input.GuestGateway6 = gateway.String()
+	}
+	ipRange, err := validateIP6Range(input.GuestIp6Start, input.GuestIp6End, input.GuestIp6Mask, input.GuestGateway6)
+	if err != nil {
+		return input, err
+	}
+	if len(input.GuestDns6) > 0 && !regutils.MatchIP6Addr(input.GuestDns6) {
+		return input, httperrors.NewInputParameterError("guest_dns6: Invalid IPv6 address %s", input.GuestDns6)
+	}
+	if isOverlapNetworks6(nets, ipRange) {
+		return input, httperrors.NewInputParameterError("Conflict ipv6 address space with existing networks")
+	}
+	input.GuestIp6Start = ipRange.StartIp().String()
+	input.GuestIp6End = ipRange.EndIp().String()
+	return input, nil
+}
+
+func (self *SNetwork) validateIP6UpdateData(input api.NetworkUpdateInput) (api.NetworkUpdateInput, error) {
+	if len(input.GuestDns6) > 0 && !regutils.MatchIP6Addr(input.GuestDns6) {
+		return input, httperrors.NewInputParameterError("guest_dns6: Invalid IPv6 address %s", input.GuestDns6)
+	}
+	if input.GuestIp6Start == "" && input.GuestIp6End == "" && input.GuestIp6Mask == nil && input.GuestGateway6 == "" {
+		return input, nil
+	}
+	var (
+		ip6Start = self.GuestIp6Start
+		ip6End   = self.GuestIp6End
+		masklen  = uint8(self.GuestIp6Mask)
+		gateway6 = self.GuestGateway6
+	)
+	if input.GuestIp6Start != "" {
+		ip6Start = input.GuestIp6Start
+	}
+	if input.GuestIp6End != "" {
+		ip6End = input.GuestIp6End
+	}
+	if input.GuestIp6Mask != nil {
+		masklen = *input.GuestIp6Mask
+	}
+	if input.GuestGateway6 != "" {
+		gateway6 = input.GuestGateway6
+	}
+	ipRange, err := validateIP6Range(ip6Start, ip6End, masklen, gateway6)
+	if err != nil {
+		return input, err
+	}
+	nets := NetworkManager.getAllNetworks(self.WireId, self.Id)
+	if nets == nil {
+		return input, httperrors.NewInternalServerError("query all networks fail")
+	}
+	if isOverlapNetworks6(nets, ipRange) {
+		return input, httperrors.NewInputParameterError("Conflict ipv6 address space with existing networks")
+	}
+	for usedIpStr := range self.GetUsedAddresses6() {
+		usedIp, _ := netutils2.NewIPV6Addr(usedIpStr)
+		if !ipRange.Contains(usedIp) {
+			return input, httperrors.NewInputParameterError("IPv6 address been assigned out of new range")
+		}
+	}
+	input.GuestIp6Start = ipRange.StartIp().String()
+	input.GuestIp6End = ipRange.EndIp().String()
+	input.GuestIp6Mask = &masklen
+	return input, nil
+}
+
 func isValidMaskLen(maskLen int64) bool {
 	if maskLen < 12 || maskLen > 30 {
 		return false
@@ -1544,6 +1793,18 @@ func (manager *SNetworkManager) ValidateCreateData(ctx context.Context, userCred
 		}
 	}
 
+	if len(input.GuestIp6Prefix) > 0 || len(input.GuestIp6Start) > 0 || len(input.GuestIp6End) > 0 {
+		nets := manager.getAllNetworks(wire.Id, "")
+		if nets == nil {
+			return input, httperrors.NewInternalServerError("query all networks fail")
+		}
+		isOneCloudVpc := region.Provider == api.CLOUD_PROVIDER_ONECLOUD && vpc.Id != api.DEFAULT_VPC_ID
+		input, err = manager.validateIP6CreateData(input, isOneCloudVpc, nets)
+		if err != nil {
+			return input, err
+		}
+	}
+
 	input.GuestIpStart = ipStart.String()
 	input.GuestIpEnd = ipEnd.String()
 	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
@@ -1656,6 +1917,11 @@ func (self *SNetwork) validateUpdateData(ctx context.Context, userCred mcclient.
 		}
 	}
 
+	input, err = self.validateIP6UpdateData(input)
+	if err != nil {
+		return input, err
+	}
+
 	if input.IsAutoAlloc != nil && *input.IsAutoAlloc {
 		if self.ServerType != api.NETWORK_TYPE_GUEST {
 			return input, httperrors.NewInputParameterError("network server_type %s not support auto alloc", self.ServerType)
@@ -1680,6 +1946,11 @@ func (self *SNetwork) ValidateUpdateData(ctx context.Context, userCred mcclient.
 		input.GuestDns = ""
 		input.GuestDomain = ""
 		input.GuestDhcp = ""
+		input.GuestIp6Start = ""
+		input.GuestIp6End = ""
+		input.GuestIp6Mask = nil
+		input.GuestGateway6 = ""
+		input.GuestDns6 = ""
 	}
 
 	var err error
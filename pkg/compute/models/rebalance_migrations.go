@@ -0,0 +1,306 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SRebalanceMigrationManager struct {
+	db.SStatusStandaloneResourceBaseManager
+}
+
+var RebalanceMigrationManager *SRebalanceMigrationManager
+
+func init() {
+	RebalanceMigrationManager = &SRebalanceMigrationManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SRebalanceMigration{},
+			"rebalance_migrations_tbl",
+			"rebalancemigration",
+			"rebalancemigrations",
+		),
+	}
+	RebalanceMigrationManager.SetVirtualObject(RebalanceMigrationManager)
+}
+
+// SRebalanceMigration 均衡器生成的迁移计划项，记录迁移决策及执行结果
+type SRebalanceMigration struct {
+	db.SStatusStandaloneResourceBase
+
+	RebalancerId string `width:"36" charset:"ascii" nullable:"false" list:"admin" index:"true"`
+	GuestId      string `width:"36" charset:"ascii" nullable:"false" list:"admin" index:"true"`
+	SourceHostId string `width:"36" charset:"ascii" nullable:"false" list:"admin"`
+	TargetHostId string `width:"36" charset:"ascii" nullable:"false" list:"admin"`
+
+	// 生成计划时源宿主机和目标宿主机的负载
+	SourceLoad float64 `nullable:"false" default:"0" list:"admin"`
+	TargetLoad float64 `nullable:"false" default:"0" list:"admin"`
+	// 生成该迁移项的原因
+	Reason string `length:"text" nullable:"true" list:"admin"`
+
+	StartedAt  time.Time `nullable:"true" list:"admin"`
+	FinishedAt time.Time `nullable:"true" list:"admin"`
+}
+
+func (manager *SRebalanceMigrationManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return false
+}
+
+func (manager *SRebalanceMigrationManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.RebalanceMigrationListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(input.RebalancerId) > 0 {
+		r, err := RebalancerManager.FetchByIdOrName(userCred, input.RebalancerId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(RebalancerManager.Keyword(), input.RebalancerId)
+			}
+			return nil, errors.Wrap(err, "RebalancerManager.FetchByIdOrName")
+		}
+		q = q.Equals("rebalancer_id", r.GetId())
+	}
+	if len(input.GuestId) > 0 {
+		guest, err := GuestManager.FetchByIdOrName(userCred, input.GuestId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), input.GuestId)
+			}
+			return nil, errors.Wrap(err, "GuestManager.FetchByIdOrName")
+		}
+		q = q.Equals("guest_id", guest.GetId())
+	}
+	if input.Active != nil {
+		if *input.Active {
+			q = q.In("status", api.REBALANCE_MIGRATION_ACTIVE_STATUS)
+		} else {
+			q = q.NotIn("status", api.REBALANCE_MIGRATION_ACTIVE_STATUS)
+		}
+	}
+	return q, nil
+}
+
+func (manager *SRebalanceMigrationManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.RebalanceMigrationListInput) (*sqlchemy.SQuery, error) {
+	return manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+}
+
+func (manager *SRebalanceMigrationManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (self *SRebalanceMigration) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, isList bool) (api.RebalanceMigrationDetails, error) {
+	return api.RebalanceMigrationDetails{}, nil
+}
+
+func (manager *SRebalanceMigrationManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.RebalanceMigrationDetails {
+	rows := make([]api.RebalanceMigrationDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	rebalancerIds := make([]string, len(objs))
+	guestIds := make([]string, len(objs))
+	hostIds := make([]string, 0, len(objs)*2)
+	for i := range objs {
+		m := objs[i].(*SRebalanceMigration)
+		rebalancerIds[i] = m.RebalancerId
+		guestIds[i] = m.GuestId
+		hostIds = append(hostIds, m.SourceHostId, m.TargetHostId)
+	}
+	rebalancerNames, err := db.FetchIdNameMap2(RebalancerManager, rebalancerIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 rebalancers: %v", err)
+	}
+	guestNames, err := db.FetchIdNameMap2(GuestManager, guestIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 guests: %v", err)
+	}
+	hostNames, err := db.FetchIdNameMap2(HostManager, hostIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 hosts: %v", err)
+	}
+	for i := range rows {
+		m := objs[i].(*SRebalanceMigration)
+		rows[i].StatusStandaloneResourceDetails = stdRows[i]
+		rows[i].Rebalancer = rebalancerNames[m.RebalancerId]
+		rows[i].Guest = guestNames[m.GuestId]
+		rows[i].SourceHost = hostNames[m.SourceHostId]
+		rows[i].TargetHost = hostNames[m.TargetHostId]
+	}
+	return rows
+}
+
+func (manager *SRebalanceMigrationManager) createMigration(ctx context.Context, userCred mcclient.TokenCredential, m *SRebalanceMigration, guestName string) error {
+	var err error
+	m.Name, err = db.GenerateName(manager, nil, guestName+"-rebalance")
+	if err != nil {
+		return errors.Wrap(err, "db.GenerateName")
+	}
+	m.SetModelManager(manager, m)
+	err = manager.TableSpec().Insert(ctx, m)
+	if err != nil {
+		return errors.Wrap(err, "Insert")
+	}
+	db.OpsLog.LogEvent(m, db.ACT_CREATE, m.Reason, userCred)
+	return nil
+}
+
+func (self *SRebalanceMigration) getRebalancer() (*SRebalancer, error) {
+	obj, err := RebalancerManager.FetchById(self.RebalancerId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch rebalancer %s", self.RebalancerId)
+	}
+	return obj.(*SRebalancer), nil
+}
+
+func (self *SRebalanceMigration) finish(ctx context.Context, userCred mcclient.TokenCredential, status string, reason string) {
+	_, err := db.Update(self, func() error {
+		self.FinishedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update rebalance migration %s: %v", self.Name, err)
+	}
+	self.SetStatus(userCred, status, reason)
+}
+
+// start 对虚拟机发起热迁移，返回是否成功开始
+func (self *SRebalanceMigration) start(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	obj, err := GuestManager.FetchById(self.GuestId)
+	if err != nil {
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, "guest not found")
+		return false
+	}
+	guest := obj.(*SGuest)
+
+	lockman.LockObject(ctx, guest)
+	defer lockman.ReleaseObject(ctx, guest)
+
+	// 计划生成后虚拟机可能已被迁移或关机
+	if guest.HostId != self.SourceHostId || guest.Status != api.VM_RUNNING {
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, "guest is no longer running on source host")
+		return false
+	}
+	_, err = guest.PerformLiveMigrate(ctx, userCred, nil, api.GuestLiveMigrateInput{PreferHost: self.TargetHostId})
+	if err != nil {
+		db.OpsLog.LogEvent(guest, db.ACT_REBALANCE_MIGRATE_FAIL, err.Error(), userCred)
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, err.Error())
+		return false
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_REBALANCE_MIGRATE, self.Reason, userCred)
+	_, err = db.Update(self, func() error {
+		self.StartedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update rebalance migration %s: %v", self.Name, err)
+	}
+	self.SetStatus(userCred, api.REBALANCE_MIGRATION_STATUS_MIGRATING, "")
+	return true
+}
+
+// sync 根据虚拟机当前所在宿主机和状态判断迁移是否结束
+func (self *SRebalanceMigration) sync(ctx context.Context, userCred mcclient.TokenCredential) {
+	obj, err := GuestManager.FetchById(self.GuestId)
+	if err != nil {
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, "guest not found")
+		return
+	}
+	guest := obj.(*SGuest)
+	switch {
+	case guest.HostId != self.SourceHostId && guest.Status == api.VM_RUNNING:
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_DONE, "")
+	case guest.Status == api.VM_MIGRATE_FAILED:
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, "live migrate failed")
+	case guest.HostId == self.SourceHostId && guest.Status == api.VM_RUNNING:
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, "guest stays on source host")
+	case time.Since(self.StartedAt) > time.Duration(options.Options.RebalanceMigrationTimeoutMinutes)*time.Minute:
+		self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_FAILED, "live migrate timeout")
+	}
+}
+
+func (manager *SRebalanceMigrationManager) syncMigrations(ctx context.Context, userCred mcclient.TokenCredential) {
+	q := manager.Query().Equals("status", api.REBALANCE_MIGRATION_STATUS_MIGRATING)
+	migrations := make([]SRebalanceMigration, 0)
+	err := db.FetchModelObjects(manager, q, &migrations)
+	if err != nil {
+		log.Errorf("fetch running rebalance migrations: %v", err)
+		return
+	}
+	for i := range migrations {
+		migrations[i].sync(ctx, userCred)
+	}
+}
+
+func (self *SRebalanceMigration) AllowPerformApprove(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "approve")
+}
+
+// 批准迁移计划项，在均衡器并发上限内开始执行
+func (self *SRebalanceMigration) PerformApprove(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.Status != api.REBALANCE_MIGRATION_STATUS_PENDING {
+		return nil, httperrors.NewInvalidStatusError("cannot approve migration in status %s", self.Status)
+	}
+	r, err := self.getRebalancer()
+	if err != nil {
+		return nil, err
+	}
+	self.SetStatus(userCred, api.REBALANCE_MIGRATION_STATUS_APPROVED, "")
+	db.OpsLog.LogEvent(r, db.ACT_REBALANCE_APPROVE, self.Name, userCred)
+	r.startMigrations(ctx, userCred)
+	return nil, nil
+}
+
+func (self *SRebalanceMigration) AllowPerformReject(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.RebalanceMigrationRejectInput) bool {
+	return db.IsAdminAllowPerform(userCred, self, "reject")
+}
+
+// 拒绝尚未开始执行的迁移计划项
+func (self *SRebalanceMigration) PerformReject(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.RebalanceMigrationRejectInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.REBALANCE_MIGRATION_STATUS_PENDING && self.Status != api.REBALANCE_MIGRATION_STATUS_APPROVED {
+		return nil, httperrors.NewInvalidStatusError("cannot reject migration in status %s", self.Status)
+	}
+	r, err := self.getRebalancer()
+	if err != nil {
+		return nil, err
+	}
+	self.finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_REJECTED, input.Reason)
+	db.OpsLog.LogEvent(r, db.ACT_REBALANCE_REJECT, self.Name, userCred)
+	return nil, nil
+}
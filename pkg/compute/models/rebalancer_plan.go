@@ -0,0 +1,252 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// rebalanceHost 宿主机在均衡计算中的负载模型
+// 负载取CPU/内存分配率以及实际利用率中最高的一项(主导资源)，范围0-1
+type rebalanceHost struct {
+	Id   string
+	Name string
+
+	// 物理CPU核数和内存大小(MB)
+	CpuCount int
+	MemSize  int
+
+	// 超售后可分配的vcpu和内存
+	VcpuCapacity float64
+	VmemCapacity float64
+
+	// 运行中虚拟机分配的vcpu和内存
+	VcpuCommit float64
+	VmemCommit float64
+
+	// 监控数据中的实际利用率，百分比，没有数据时为nil
+	CpuUtil *float64
+	MemUtil *float64
+}
+
+type rebalanceGuest struct {
+	Id         string
+	Name       string
+	HostId     string
+	VcpuDemand int
+	VmemDemand int
+	GroupIds   []string
+}
+
+type rebalanceMove struct {
+	Guest  *rebalanceGuest
+	Source *rebalanceHost
+	Target *rebalanceHost
+
+	SourceLoad float64
+	TargetLoad float64
+	Reason     string
+}
+
+func (h *rebalanceHost) load() float64 {
+	load := 0.0
+	if h.VcpuCapacity > 0 {
+		load = maxFloat(load, h.VcpuCommit/h.VcpuCapacity)
+	}
+	if h.VmemCapacity > 0 {
+		load = maxFloat(load, h.VmemCommit/h.VmemCapacity)
+	}
+	if h.CpuUtil != nil {
+		load = maxFloat(load, *h.CpuUtil/100)
+	}
+	if h.MemUtil != nil {
+		load = maxFloat(load, *h.MemUtil/100)
+	}
+	return load
+}
+
+// guestUsage 按虚拟机分配量在宿主机分配总量中的占比估算其实际使用的CPU核数和内存
+func (h *rebalanceHost) guestUsage(g *rebalanceGuest) (float64, float64) {
+	var cpu, mem float64
+	if h.CpuUtil != nil && h.VcpuCommit > 0 {
+		cpu = *h.CpuUtil / 100 * float64(h.CpuCount) * float64(g.VcpuDemand) / h.VcpuCommit
+	}
+	if h.MemUtil != nil && h.VmemCommit > 0 {
+		mem = *h.MemUtil / 100 * float64(h.MemSize) * float64(g.VmemDemand) / h.VmemCommit
+	}
+	return cpu, mem
+}
+
+func (h *rebalanceHost) addGuest(g *rebalanceGuest, cpuUsage, memUsage float64, sign float64) {
+	h.VcpuCommit += sign * float64(g.VcpuDemand)
+	h.VmemCommit += sign * float64(g.VmemDemand)
+	if h.CpuUtil != nil && h.CpuCount > 0 {
+		util := *h.CpuUtil + sign*cpuUsage/float64(h.CpuCount)*100
+		h.CpuUtil = &util
+	}
+	if h.MemUtil != nil && h.MemSize > 0 {
+		util := *h.MemUtil + sign*memUsage/float64(h.MemSize)*100
+		h.MemUtil = &util
+	}
+}
+
+// moveGuest 更新迁移后源和目标宿主机的负载
+func moveGuest(g *rebalanceGuest, src, dst *rebalanceHost) {
+	cpu, mem := src.guestUsage(g)
+	src.addGuest(g, cpu, mem, -1)
+	dst.addGuest(g, cpu, mem, 1)
+}
+
+func (h rebalanceHost) clone() *rebalanceHost {
+	if h.CpuUtil != nil {
+		util := *h.CpuUtil
+		h.CpuUtil = &util
+	}
+	if h.MemUtil != nil {
+		util := *h.MemUtil
+		h.MemUtil = &util
+	}
+	return &h
+}
+
+func averageLoad(hosts []*rebalanceHost) float64 {
+	if len(hosts) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, h := range hosts {
+		total += h.load()
+	}
+	return total / float64(len(hosts))
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// planRebalance 反复从负载最高的宿主机上挑选虚拟机迁往负载较低的宿主机，
+// 直到所有宿主机负载与平均负载的差距都不超过threshold，或达到maxMoves
+// targetsFunc 返回调度器认为可以接收该虚拟机的宿主机
+// hosts 中的负载会被更新为计划执行后的负载
+func planRebalance(
+	hosts []*rebalanceHost,
+	guests map[string][]*rebalanceGuest,
+	threshold float64,
+	maxMoves int,
+	targetsFunc func(g *rebalanceGuest) ([]string, error),
+) []rebalanceMove {
+	hostMap := make(map[string]*rebalanceHost, len(hosts))
+	for _, h := range hosts {
+		hostMap[h.Id] = h
+	}
+	// 本次计划中已迁入各宿主机的主机组，避免同组虚拟机被迁到同一宿主机
+	plannedGroups := make(map[string]map[string]bool)
+	tried := make(map[string]bool)
+	stuck := make(map[string]bool)
+	moves := make([]rebalanceMove, 0)
+
+	for len(moves) < maxMoves {
+		avg := averageLoad(hosts)
+		var src *rebalanceHost
+		for _, h := range hosts {
+			if stuck[h.Id] {
+				continue
+			}
+			if src == nil || h.load() > src.load() {
+				src = h
+			}
+		}
+		if src == nil || src.load()-avg <= threshold {
+			break
+		}
+
+		candidates := make([]*rebalanceGuest, 0)
+		for _, g := range guests[src.Id] {
+			if !tried[g.Id] {
+				candidates = append(candidates, g)
+			}
+		}
+		// 优先迁移占用较多的虚拟机，减少迁移次数
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].VcpuDemand*1024+candidates[i].VmemDemand > candidates[j].VcpuDemand*1024+candidates[j].VmemDemand
+		})
+
+		var move *rebalanceMove
+		for _, g := range candidates {
+			tried[g.Id] = true
+			targetIds, err := targetsFunc(g)
+			if err != nil {
+				continue
+			}
+			srcLoad := src.load()
+			var best *rebalanceHost
+			bestLoad := 0.0
+			for _, id := range targetIds {
+				dst, ok := hostMap[id]
+				if !ok || dst.Id == src.Id || hasAnyGroup(plannedGroups[dst.Id], g.GroupIds) {
+					continue
+				}
+				srcAfter, dstAfter := src.clone(), dst.clone()
+				moveGuest(g, srcAfter, dstAfter)
+				// 迁移后两台宿主机的负载都需低于迁移前源宿主机的负载，目标宿主机不能因此过载
+				if maxFloat(srcAfter.load(), dstAfter.load()) >= srcLoad || dstAfter.load() > avg+threshold {
+					continue
+				}
+				if best == nil || dstAfter.load() < bestLoad {
+					best, bestLoad = dst, dstAfter.load()
+				}
+			}
+			if best == nil {
+				continue
+			}
+			move = &rebalanceMove{
+				Guest:      g,
+				Source:     src,
+				Target:     best,
+				SourceLoad: srcLoad,
+				TargetLoad: best.load(),
+				Reason: fmt.Sprintf("host %s load %.2f exceeds average %.2f by more than %.2f, move to %s with load %.2f -> %.2f",
+					src.Name, srcLoad, avg, threshold, best.Name, best.load(), bestLoad),
+			}
+			break
+		}
+		if move == nil {
+			stuck[src.Id] = true
+			continue
+		}
+		moveGuest(move.Guest, move.Source, move.Target)
+		if _, ok := plannedGroups[move.Target.Id]; !ok {
+			plannedGroups[move.Target.Id] = make(map[string]bool)
+		}
+		for _, gid := range move.Guest.GroupIds {
+			plannedGroups[move.Target.Id][gid] = true
+		}
+		moves = append(moves, *move)
+	}
+	return moves
+}
+
+func hasAnyGroup(groups map[string]bool, groupIds []string) bool {
+	for _, gid := range groupIds {
+		if groups[gid] {
+			return true
+		}
+	}
+	return false
+}
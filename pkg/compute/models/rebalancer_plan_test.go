@@ -0,0 +1,142 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func newTestRebalanceHost(id string, vcpu, vmem float64) *rebalanceHost {
+	return &rebalanceHost{
+		Id:           id,
+		Name:         id,
+		CpuCount:     16,
+		MemSize:      65536,
+		VcpuCapacity: 64,
+		VmemCapacity: 65536,
+		VcpuCommit:   vcpu,
+		VmemCommit:   vmem,
+	}
+}
+
+func TestPlanRebalance(t *testing.T) {
+	allTargets := func(g *rebalanceGuest) ([]string, error) {
+		return []string{"h1", "h2", "h3"}, nil
+	}
+	cases := []struct {
+		name      string
+		hosts     []*rebalanceHost
+		guests    map[string][]*rebalanceGuest
+		targets   func(g *rebalanceGuest) ([]string, error)
+		wantMoves int
+		wantHosts []string
+	}{
+		{
+			name: "balanced",
+			hosts: []*rebalanceHost{
+				newTestRebalanceHost("h1", 16, 16384),
+				newTestRebalanceHost("h2", 20, 16384),
+			},
+			guests: map[string][]*rebalanceGuest{
+				"h2": {{Id: "g1", HostId: "h2", VcpuDemand: 4, VmemDemand: 4096}},
+			},
+			targets:   allTargets,
+			wantMoves: 0,
+		},
+		{
+			name: "move from overloaded host",
+			hosts: []*rebalanceHost{
+				newTestRebalanceHost("h1", 48, 49152),
+				newTestRebalanceHost("h2", 8, 8192),
+				newTestRebalanceHost("h3", 8, 8192),
+			},
+			guests: map[string][]*rebalanceGuest{
+				"h1": {
+					{Id: "g1", HostId: "h1", VcpuDemand: 8, VmemDemand: 8192},
+					{Id: "g2", HostId: "h1", VcpuDemand: 16, VmemDemand: 16384},
+					{Id: "g3", HostId: "h1", VcpuDemand: 8, VmemDemand: 8192},
+				},
+			},
+			targets:   allTargets,
+			wantMoves: 2,
+			wantHosts: []string{"h2", "h3"},
+		},
+		{
+			name: "anti affinity group",
+			hosts: []*rebalanceHost{
+				newTestRebalanceHost("h1", 56, 57344),
+				newTestRebalanceHost("h2", 8, 8192),
+				newTestRebalanceHost("h3", 44, 45056),
+			},
+			guests: map[string][]*rebalanceGuest{
+				"h1": {
+					{Id: "g1", HostId: "h1", VcpuDemand: 8, VmemDemand: 8192, GroupIds: []string{"grp"}},
+					{Id: "g2", HostId: "h1", VcpuDemand: 8, VmemDemand: 8192, GroupIds: []string{"grp"}},
+				},
+			},
+			targets:   allTargets,
+			wantMoves: 1,
+			wantHosts: []string{"h2"},
+		},
+		{
+			name: "scheduler rejects",
+			hosts: []*rebalanceHost{
+				newTestRebalanceHost("h1", 48, 49152),
+				newTestRebalanceHost("h2", 8, 8192),
+			},
+			guests: map[string][]*rebalanceGuest{
+				"h1": {{Id: "g1", HostId: "h1", VcpuDemand: 8, VmemDemand: 8192}},
+			},
+			targets: func(g *rebalanceGuest) ([]string, error) {
+				return []string{}, nil
+			},
+			wantMoves: 0,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			moves := planRebalance(c.hosts, c.guests, 0.15, 5, c.targets)
+			if len(moves) != c.wantMoves {
+				t.Fatalf("want %d moves, got %d", c.wantMoves, len(moves))
+			}
+			for i := range c.wantHosts {
+				if moves[i].Target.Id != c.wantHosts[i] {
+					t.Errorf("move %d: want target %s, got %s", i, c.wantHosts[i], moves[i].Target.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestRebalanceHostUtilization(t *testing.T) {
+	cpuUtil, memUtil := 80.0, 50.0
+	src := newTestRebalanceHost("h1", 32, 32768)
+	src.CpuUtil, src.MemUtil = &cpuUtil, &memUtil
+	dstCpu, dstMem := 10.0, 10.0
+	dst := newTestRebalanceHost("h2", 8, 8192)
+	dst.CpuUtil, dst.MemUtil = &dstCpu, &dstMem
+
+	if load := src.load(); load != 0.8 {
+		t.Fatalf("want load 0.8, got %f", load)
+	}
+	moveGuest(&rebalanceGuest{Id: "g1", VcpuDemand: 16, VmemDemand: 16384}, src, dst)
+	if math.Abs(*src.CpuUtil-40) > 1e-6 || math.Abs(*dst.CpuUtil-50) > 1e-6 {
+		t.Errorf("unexpected cpu utilization after move: src %f dst %f", *src.CpuUtil, *dst.CpuUtil)
+	}
+	if src.VcpuCommit != 16 || dst.VcpuCommit != 24 {
+		t.Errorf("unexpected vcpu commit after move: src %f dst %f", src.VcpuCommit, dst.VcpuCommit)
+	}
+}
@@ -0,0 +1,640 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	REBALANCER_MIN_EVALUATE_INTERVAL = 5
+	REBALANCER_MAX_MIGRATIONS        = 100
+)
+
+type SRebalancerManager struct {
+	db.SEnabledStatusStandaloneResourceBaseManager
+}
+
+var RebalancerManager *SRebalancerManager
+
+func init() {
+	RebalancerManager = &SRebalancerManager{
+		SEnabledStatusStandaloneResourceBaseManager: db.NewEnabledStatusStandaloneResourceBaseManager(
+			SRebalancer{},
+			"rebalancers_tbl",
+			"rebalancer",
+			"rebalancers",
+		),
+	}
+	RebalancerManager.SetVirtualObject(RebalancerManager)
+}
+
+// SRebalancer 周期性评估可用区或调度标签内KVM宿主机的负载，
+// 为负载不均衡的宿主机生成热迁移计划，并按执行模式自动或经审批后执行
+type SRebalancer struct {
+	db.SEnabledStatusStandaloneResourceBase
+
+	ZoneId     string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional"`
+	SchedtagId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional"`
+
+	Mode string `width:"16" charset:"ascii" nullable:"false" default:"manual" list:"admin" create:"admin_optional" update:"admin"`
+	// 宿主机负载高于平均负载的百分点
+	Threshold      int               `nullable:"false" default:"15" list:"admin" create:"admin_optional" update:"admin"`
+	UseUtilization tristate.TriState `nullable:"false" default:"true" list:"admin" create:"admin_optional" update:"admin"`
+	MaxConcurrency int               `nullable:"false" default:"2" list:"admin" create:"admin_optional" update:"admin"`
+	MaxMigrations  int               `nullable:"false" default:"5" list:"admin" create:"admin_optional" update:"admin"`
+	// 评估周期，单位分钟
+	EvaluateInterval int       `nullable:"false" default:"30" list:"admin" create:"admin_optional" update:"admin"`
+	LastEvaluateAt   time.Time `nullable:"true" list:"admin"`
+}
+
+func (manager *SRebalancerManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.RebalancerListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(input.ZoneId) > 0 {
+		zone, err := ZoneManager.FetchByIdOrName(userCred, input.ZoneId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(ZoneManager.Keyword(), input.ZoneId)
+			}
+			return nil, errors.Wrap(err, "ZoneManager.FetchByIdOrName")
+		}
+		q = q.Equals("zone_id", zone.GetId())
+	}
+	if len(input.SchedtagId) > 0 {
+		tag, err := SchedtagManager.FetchByIdOrName(userCred, input.SchedtagId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(SchedtagManager.Keyword(), input.SchedtagId)
+			}
+			return nil, errors.Wrap(err, "SchedtagManager.FetchByIdOrName")
+		}
+		q = q.Equals("schedtag_id", tag.GetId())
+	}
+	if len(input.Mode) > 0 {
+		q = q.Equals("mode", input.Mode)
+	}
+	return q, nil
+}
+
+func (manager *SRebalancerManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.RebalancerListInput) (*sqlchemy.SQuery, error) {
+	return manager.SEnabledStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.EnabledStatusStandaloneResourceListInput)
+}
+
+func (manager *SRebalancerManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SEnabledStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (self *SRebalancer) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, isList bool) (api.RebalancerDetails, error) {
+	return api.RebalancerDetails{}, nil
+}
+
+func (manager *SRebalancerManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.RebalancerDetails {
+	rows := make([]api.RebalancerDetails, len(objs))
+	stdRows := manager.SEnabledStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].EnabledStatusStandaloneResourceDetails = stdRows[i]
+		r := objs[i].(*SRebalancer)
+		if len(r.ZoneId) > 0 {
+			if zone := ZoneManager.FetchZoneById(r.ZoneId); zone != nil {
+				rows[i].Zone = zone.Name
+			}
+		}
+		if len(r.SchedtagId) > 0 {
+			if tag, _ := SchedtagManager.FetchById(r.SchedtagId); tag != nil {
+				rows[i].Schedtag = tag.GetName()
+			}
+		}
+		rows[i].ActiveMigrationCount, _ = r.getMigrationsQuery(api.REBALANCE_MIGRATION_ACTIVE_STATUS...).CountWithError()
+	}
+	return rows
+}
+
+func validateRebalancerParams(mode string, threshold, maxConcurrency, maxMigrations, interval *int) error {
+	if len(mode) > 0 && !utils.IsInStringArray(mode, api.REBALANCER_MODES) {
+		return httperrors.NewInputParameterError("invalid mode %s", mode)
+	}
+	if threshold != nil && (*threshold < 1 || *threshold > 100) {
+		return httperrors.NewOutOfRangeError("threshold should be between 1 and 100")
+	}
+	if maxConcurrency != nil && *maxConcurrency < 1 {
+		return httperrors.NewOutOfRangeError("max_concurrency should be at least 1")
+	}
+	if maxMigrations != nil && (*maxMigrations < 1 || *maxMigrations > REBALANCER_MAX_MIGRATIONS) {
+		return httperrors.NewOutOfRangeError("max_migrations should be between 1 and %d", REBALANCER_MAX_MIGRATIONS)
+	}
+	if interval != nil && *interval < REBALANCER_MIN_EVALUATE_INTERVAL {
+		return httperrors.NewOutOfRangeError("evaluate_interval should be at least %d minutes", REBALANCER_MIN_EVALUATE_INTERVAL)
+	}
+	return nil
+}
+
+func (manager *SRebalancerManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.RebalancerCreateInput) (api.RebalancerCreateInput, error) {
+	var err error
+	input.EnabledStatusStandaloneResourceCreateInput, err = manager.SEnabledStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.ZoneId) == 0 && len(input.SchedtagId) == 0 {
+		return input, httperrors.NewMissingParameterError("zone_id or schedtag_id")
+	}
+	if len(input.ZoneId) > 0 {
+		zone, err := ZoneManager.FetchByIdOrName(userCred, input.ZoneId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return input, httperrors.NewResourceNotFoundError2(ZoneManager.Keyword(), input.ZoneId)
+			}
+			return input, errors.Wrap(err, "ZoneManager.FetchByIdOrName")
+		}
+		input.ZoneId = zone.GetId()
+	}
+	if len(input.SchedtagId) > 0 {
+		tag, err := SchedtagManager.FetchByIdOrName(userCred, input.SchedtagId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return input, httperrors.NewResourceNotFoundError2(SchedtagManager.Keyword(), input.SchedtagId)
+			}
+			return input, errors.Wrap(err, "SchedtagManager.FetchByIdOrName")
+		}
+		if tag.(*SSchedtag).ResourceType != HostManager.KeywordPlural() {
+			return input, httperrors.NewInputParameterError("schedtag %s is not for hosts", tag.GetName())
+		}
+		input.SchedtagId = tag.GetId()
+	}
+	if len(input.Mode) == 0 {
+		input.Mode = api.REBALANCER_MODE_MANUAL
+	}
+	if input.Threshold == 0 {
+		input.Threshold = 15
+	}
+	if input.MaxConcurrency == 0 {
+		input.MaxConcurrency = 2
+	}
+	if input.MaxMigrations == 0 {
+		input.MaxMigrations = 5
+	}
+	if input.EvaluateInterval == 0 {
+		input.EvaluateInterval = 30
+	}
+	err = validateRebalancerParams(input.Mode, &input.Threshold, &input.MaxConcurrency, &input.MaxMigrations, &input.EvaluateInterval)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SRebalancer) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	self.Status = api.REBALANCER_STATUS_READY
+	return self.SEnabledStatusStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (self *SRebalancer) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.RebalancerUpdateInput) (api.RebalancerUpdateInput, error) {
+	var err error
+	input.EnabledStatusStandaloneResourceBaseUpdateInput, err = self.SEnabledStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	err = validateRebalancerParams(input.Mode, input.Threshold, input.MaxConcurrency, input.MaxMigrations, input.EvaluateInterval)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SRebalancer) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := self.getMigrationsQuery(api.REBALANCE_MIGRATION_STATUS_MIGRATING).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "getMigrationsQuery")
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("rebalancer has %d migrations in progress", cnt)
+	}
+	return self.SEnabledStatusStandaloneResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SRebalancer) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	err := self.expireMigrations(ctx, userCred)
+	if err != nil {
+		return errors.Wrap(err, "expireMigrations")
+	}
+	return self.SEnabledStatusStandaloneResourceBase.CustomizeDelete(ctx, userCred, query, data)
+}
+
+func (self *SRebalancer) getMigrationsQuery(status ...string) *sqlchemy.SQuery {
+	q := RebalanceMigrationManager.Query().Equals("rebalancer_id", self.Id)
+	if len(status) > 0 {
+		q = q.In("status", status)
+	}
+	return q
+}
+
+func (self *SRebalancer) getMigrations(status ...string) ([]SRebalanceMigration, error) {
+	q := self.getMigrationsQuery(status...).Asc("created_at")
+	migrations := make([]SRebalanceMigration, 0)
+	err := db.FetchModelObjects(RebalanceMigrationManager, q, &migrations)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return migrations, nil
+}
+
+// getRunningPlanCount 返回已批准或正在迁移的计划项数量，上一轮计划执行完之前不生成新计划
+func (self *SRebalancer) getRunningPlanCount() (int, error) {
+	cnt, err := self.getMigrationsQuery(api.REBALANCE_MIGRATION_STATUS_APPROVED, api.REBALANCE_MIGRATION_STATUS_MIGRATING).CountWithError()
+	if err != nil {
+		return 0, errors.Wrap(err, "getMigrationsQuery")
+	}
+	return cnt, nil
+}
+
+// expireMigrations 作废尚未开始执行的计划项
+func (self *SRebalancer) expireMigrations(ctx context.Context, userCred mcclient.TokenCredential) error {
+	migrations, err := self.getMigrations(api.REBALANCE_MIGRATION_STATUS_PENDING, api.REBALANCE_MIGRATION_STATUS_APPROVED)
+	if err != nil {
+		return err
+	}
+	for i := range migrations {
+		migrations[i].finish(ctx, userCred, api.REBALANCE_MIGRATION_STATUS_EXPIRED, "superseded by a new evaluation")
+	}
+	return nil
+}
+
+// getHosts 返回均衡范围内启用且在线的KVM宿主机
+func (self *SRebalancer) getHosts() ([]SHost, error) {
+	q := HostManager.Query().Equals("host_type", api.HOST_TYPE_HYPERVISOR).IsTrue("enabled").Equals("host_status", api.HOST_ONLINE)
+	q = q.Filter(sqlchemy.OR(sqlchemy.IsNull(q.Field("is_maintenance")), sqlchemy.IsFalse(q.Field("is_maintenance"))))
+	if len(self.ZoneId) > 0 {
+		q = q.Equals("zone_id", self.ZoneId)
+	}
+	if len(self.SchedtagId) > 0 {
+		sq := HostschedtagManager.Query("host_id").Equals("schedtag_id", self.SchedtagId).SubQuery()
+		q = q.In("id", sq)
+	}
+	hosts := make([]SHost, 0)
+	err := db.FetchModelObjects(HostManager, q, &hosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return hosts, nil
+}
+
+type rebalanceHostUtilization struct {
+	Cpu    *float64 `json:"cpu"`
+	Memory *float64 `json:"memory"`
+}
+
+func (self *SRebalancer) getHostLoad(s *mcclient.ClientSession, host *SHost) *rebalanceHost {
+	h := &rebalanceHost{
+		Id:           host.Id,
+		Name:         host.Name,
+		CpuCount:     host.GetCpuCount(),
+		MemSize:      host.GetMemSize(),
+		VcpuCapacity: float64(host.GetVirtualCPUCount()),
+		VmemCapacity: float64(host.GetVirtualMemorySize()),
+	}
+	usage := host.getGuestsResource(api.VM_RUNNING)
+	if usage != nil {
+		h.VcpuCommit = float64(usage.GuestVcpuCount)
+		h.VmemCommit = float64(usage.GuestVmemSize)
+	}
+	if !self.UseUtilization.IsTrue() {
+		return h
+	}
+	// 实际利用率取自调度器缓存的监控数据，获取失败时只按分配率计算
+	params := jsonutils.NewDict()
+	params.Set("type", jsonutils.NewString("host"))
+	candidate, err := modules.SchedManager.CandidateDetail(s, host.Id, params)
+	if err != nil {
+		log.Warningf("rebalancer %s get candidate detail of host %s: %v", self.Name, host.Name, err)
+		return h
+	}
+	if candidate.Contains("utilization") {
+		util := rebalanceHostUtilization{}
+		err = candidate.Unmarshal(&util, "utilization")
+		if err == nil {
+			h.CpuUtil = util.Cpu
+			h.MemUtil = util.Memory
+		}
+	}
+	return h
+}
+
+// getMovableGuests 返回宿主机上可以热迁移且不在其他迁移计划中的虚拟机
+func (self *SRebalancer) getMovableGuests(userCred mcclient.TokenCredential, hostId string) ([]SGuest, error) {
+	q := GuestManager.Query().Equals("host_id", hostId).Equals("hypervisor", api.HYPERVISOR_KVM).Equals("status", api.VM_RUNNING)
+	q = q.IsNullOrEmpty("backup_host_id")
+	planned := RebalanceMigrationManager.Query("guest_id").In("status", api.REBALANCE_MIGRATION_ACTIVE_STATUS).SubQuery()
+	q = q.NotIn("id", planned)
+	guests := make([]SGuest, 0)
+	err := db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	ret := make([]SGuest, 0, len(guests))
+	for i := range guests {
+		if guests[i].GetDriver().CheckLiveMigrate(&guests[i], userCred, api.GuestLiveMigrateInput{}) != nil {
+			continue
+		}
+		ret = append(ret, guests[i])
+	}
+	return ret, nil
+}
+
+// getMigrateTargets 以热迁移方式向调度器预测，返回能够接收该虚拟机的宿主机
+// 调度器的迁移及主机组过滤保证了计划不违反反亲和约束
+func (self *SRebalancer) getMigrateTargets(s *mcclient.ClientSession, guest *SGuest, hostIds []string) ([]string, error) {
+	desc := guest.ToSchedDesc()
+	desc.LiveMigrate = true
+	desc.ReuseNetwork = true
+	if guest.GetMetadata("__cpu_mode", nil) != api.CPU_MODE_QEMU {
+		host := guest.GetHost()
+		if host == nil {
+			return nil, errors.Wrapf(errors.ErrNotFound, "host of guest %s", guest.Name)
+		}
+		desc.CpuDesc = host.CpuDesc
+		desc.CpuMicrocode = host.CpuMicrocode
+		desc.CpuMode = api.CPU_MODE_HOST
+	} else {
+		desc.CpuMode = api.CPU_MODE_QEMU
+	}
+	if len(self.ZoneId) > 0 {
+		desc.PreferZone = self.ZoneId
+	}
+	if len(self.SchedtagId) > 0 {
+		desc.Schedtags = append(desc.Schedtags, &api.SchedtagConfig{
+			Id:           self.SchedtagId,
+			Strategy:     api.STRATEGY_REQUIRE,
+			ResourceType: HostManager.KeywordPlural(),
+		})
+	}
+	canMigrate, res, err := modules.SchedManager.DoScheduleForecast(s, desc, 1)
+	if err != nil {
+		return nil, errors.Wrap(err, "DoScheduleForecast")
+	}
+	if !canMigrate {
+		return nil, errors.Wrapf(errors.ErrNotFound, "no candidate host for guest %s", guest.Name)
+	}
+	filtered := make(map[string]bool)
+	objs, _ := res.GetArray("filtered_candidates")
+	for _, obj := range objs {
+		id, _ := obj.GetString("id")
+		filtered[id] = true
+	}
+	targets := make([]string, 0)
+	for _, id := range hostIds {
+		if id != guest.HostId && !filtered[id] {
+			targets = append(targets, id)
+		}
+	}
+	return targets, nil
+}
+
+func (self *SRebalancer) evaluate(ctx context.Context, userCred mcclient.TokenCredential, dryRun bool) (*api.RebalancerEvaluateOutput, error) {
+	if !dryRun {
+		cnt, err := self.getRunningPlanCount()
+		if err != nil {
+			return nil, err
+		}
+		if cnt > 0 {
+			return nil, httperrors.NewInvalidStatusError("previous plan of rebalancer %s has %d migrations not finished", self.Name, cnt)
+		}
+		self.SetStatus(userCred, api.REBALANCER_STATUS_EVALUATING, "")
+		defer self.SetStatus(userCred, api.REBALANCER_STATUS_READY, "")
+	}
+
+	s := auth.GetAdminSession(ctx, options.Options.Region, "")
+	hosts, err := self.getHosts()
+	if err != nil {
+		return nil, errors.Wrap(err, "getHosts")
+	}
+	hostIds := make([]string, len(hosts))
+	loads := make([]*rebalanceHost, len(hosts))
+	guests := make(map[string][]*rebalanceGuest)
+	guestObjs := make(map[string]*SGuest)
+	for i := range hosts {
+		hostIds[i] = hosts[i].Id
+		loads[i] = self.getHostLoad(s, &hosts[i])
+		movable, err := self.getMovableGuests(userCred, hosts[i].Id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getMovableGuests of host %s", hosts[i].Name)
+		}
+		for j := range movable {
+			guest := &movable[j]
+			groups := make([]SGroupguest, 0)
+			err := GroupguestManager.Query().Equals("guest_id", guest.Id).All(&groups)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fetch groups of guest %s", guest.Name)
+			}
+			groupIds := make([]string, len(groups))
+			for k := range groups {
+				groupIds[k] = groups[k].GroupId
+			}
+			guests[hosts[i].Id] = append(guests[hosts[i].Id], &rebalanceGuest{
+				Id:         guest.Id,
+				Name:       guest.Name,
+				HostId:     guest.HostId,
+				VcpuDemand: int(guest.VcpuCount),
+				VmemDemand: guest.VmemSize,
+				GroupIds:   groupIds,
+			})
+			guestObjs[guest.Id] = guest
+		}
+	}
+
+	output := &api.RebalancerEvaluateOutput{
+		AverageLoad: averageLoad(loads),
+		EvaluatedAt: time.Now().UTC(),
+	}
+	origLoads := make(map[string]float64, len(loads))
+	for _, h := range loads {
+		origLoads[h.Id] = h.load()
+	}
+
+	targetsFunc := func(g *rebalanceGuest) ([]string, error) {
+		targets, err := self.getMigrateTargets(s, guestObjs[g.Id], hostIds)
+		if err != nil {
+			log.Debugf("rebalancer %s: skip guest %s: %v", self.Name, g.Name, err)
+		}
+		return targets, err
+	}
+	moves := planRebalance(loads, guests, float64(self.Threshold)/100, self.MaxMigrations, targetsFunc)
+
+	for _, h := range loads {
+		output.Hosts = append(output.Hosts, api.RebalancerHostLoad{
+			Id:          h.Id,
+			Name:        h.Name,
+			Load:        origLoads[h.Id],
+			PlannedLoad: h.load(),
+		})
+	}
+
+	if !dryRun {
+		err = self.expireMigrations(ctx, userCred)
+		if err != nil {
+			return nil, errors.Wrap(err, "expireMigrations")
+		}
+		_, err = db.Update(self, func() error {
+			self.LastEvaluateAt = output.EvaluatedAt
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "db.Update")
+		}
+	}
+
+	status := api.REBALANCE_MIGRATION_STATUS_PENDING
+	if self.Mode == api.REBALANCER_MODE_AUTO {
+		status = api.REBALANCE_MIGRATION_STATUS_APPROVED
+	}
+	for _, move := range moves {
+		migration := SRebalanceMigration{
+			RebalancerId: self.Id,
+			GuestId:      move.Guest.Id,
+			SourceHostId: move.Source.Id,
+			TargetHostId: move.Target.Id,
+			SourceLoad:   move.SourceLoad,
+			TargetLoad:   move.TargetLoad,
+			Reason:       move.Reason,
+		}
+		migration.Status = status
+		if !dryRun {
+			err = RebalanceMigrationManager.createMigration(ctx, userCred, &migration, move.Guest.Name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "create migration of guest %s", move.Guest.Name)
+			}
+		}
+		details := api.RebalanceMigrationDetails{
+			Rebalancer: self.Name,
+			Guest:      move.Guest.Name,
+			SourceHost: move.Source.Name,
+			TargetHost: move.Target.Name,
+		}
+		jsonutils.Update(&details.SRebalanceMigration, migration)
+		output.Migrations = append(output.Migrations, details)
+	}
+
+	if !dryRun {
+		db.OpsLog.LogEvent(self, db.ACT_REBALANCE_EVALUATE, output, userCred)
+	}
+	return output, nil
+}
+
+// startMigrations 在并发上限内开始执行已批准的迁移
+func (self *SRebalancer) startMigrations(ctx context.Context, userCred mcclient.TokenCredential) {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	running, err := self.getMigrationsQuery(api.REBALANCE_MIGRATION_STATUS_MIGRATING).CountWithError()
+	if err != nil {
+		log.Errorf("rebalancer %s count running migrations: %v", self.Name, err)
+		return
+	}
+	if running >= self.MaxConcurrency {
+		return
+	}
+	approved, err := self.getMigrations(api.REBALANCE_MIGRATION_STATUS_APPROVED)
+	if err != nil {
+		log.Errorf("rebalancer %s fetch approved migrations: %v", self.Name, err)
+		return
+	}
+	for i := 0; i < len(approved) && running < self.MaxConcurrency; i++ {
+		if approved[i].start(ctx, userCred) {
+			running++
+		}
+	}
+}
+
+func (self *SRebalancer) isEvaluateDue() bool {
+	if self.LastEvaluateAt.IsZero() {
+		return true
+	}
+	return time.Now().After(self.LastEvaluateAt.Add(time.Duration(self.EvaluateInterval) * time.Minute))
+}
+
+func (self *SRebalancer) AllowPerformEvaluate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.RebalancerEvaluateInput) bool {
+	return db.IsAdminAllowPerform(userCred, self, "evaluate")
+}
+
+// 立即评估宿主机负载并生成迁移计划，dry_run时只返回计划不记录
+func (self *SRebalancer) PerformEvaluate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.RebalancerEvaluateInput) (jsonutils.JSONObject, error) {
+	if !input.DryRun && !self.GetEnabled() {
+		return nil, httperrors.NewInvalidStatusError("rebalancer %s is disabled", self.Name)
+	}
+	output, err := self.evaluate(ctx, userCred, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	if !input.DryRun {
+		self.startMigrations(ctx, userCred)
+	}
+	return jsonutils.Marshal(output), nil
+}
+
+// RebalanceHosts 同步迁移执行结果，评估到期的均衡器并执行已批准的迁移
+func (manager *SRebalancerManager) RebalanceHosts(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	RebalanceMigrationManager.syncMigrations(ctx, userCred)
+
+	q := manager.Query().IsTrue("enabled")
+	rebalancers := make([]SRebalancer, 0)
+	err := db.FetchModelObjects(manager, q, &rebalancers)
+	if err != nil {
+		log.Errorf("RebalanceHosts fetch rebalancers: %v", err)
+		return
+	}
+	for i := range rebalancers {
+		r := &rebalancers[i]
+		if cnt, _ := r.getRunningPlanCount(); cnt == 0 && r.isEvaluateDue() {
+			_, err := r.evaluate(ctx, userCred, false)
+			if err != nil {
+				log.Warningf("rebalancer %s evaluate: %v", r.Name, err)
+			}
+		}
+		r.startMigrations(ctx, userCred)
+	}
+}
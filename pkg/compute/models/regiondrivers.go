@@ -135,6 +135,7 @@ type IRegionDriver interface {
 	GetSecurityGroupRuleMinPriority() int
 	IsOnlySupportAllowRules() bool
 	IsSupportClassicSecurityGroup() bool
+	IsSupportSecurityRuleIPv6() bool
 	IsSecurityGroupBelongVpc() bool
 	IsVpcBelongGlobalVpc() bool
 	IsSecurityGroupBelongGlobalVpc() bool //安全组子账号范围内可用
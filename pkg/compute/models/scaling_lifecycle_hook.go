@@ -0,0 +1,359 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	LIFECYCLE_HOOK_MIN_TIMEOUT = 30
+	LIFECYCLE_HOOK_MAX_TIMEOUT = 7200
+
+	lifecycleHookEvent = "scaling_group.lifecycle_hook"
+)
+
+type SScalingLifecycleHookManager struct {
+	db.SVirtualResourceBaseManager
+	SScalingGroupResourceBaseManager
+}
+
+// SScalingLifecycleHook pauses instances in pending:wait state when scaling group scales out or in,
+// until the lifecycle action is completed or timeout
+type SScalingLifecycleHook struct {
+	db.SVirtualResourceBase
+	SScalingGroupResourceBase
+
+	Transition    string `width:"16" charset:"ascii" nullable:"false" create:"required" list:"user" get:"user"`
+	WebhookUrl    string `width:"512" charset:"utf8" nullable:"true" create:"optional" list:"user" get:"user" update:"user"`
+	Timeout       int    `nullable:"false" default:"300" create:"optional" list:"user" get:"user" update:"user"`
+	DefaultResult string `width:"16" charset:"ascii" nullable:"false" default:"continue" create:"optional" list:"user" get:"user" update:"user"`
+}
+
+var ScalingLifecycleHookManager *SScalingLifecycleHookManager
+
+func init() {
+	ScalingLifecycleHookManager = &SScalingLifecycleHookManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SScalingLifecycleHook{},
+			"scalinglifecyclehooks_tbl",
+			"scalinglifecyclehook",
+			"scalinglifecyclehooks",
+		),
+	}
+	ScalingLifecycleHookManager.SetVirtualObject(ScalingLifecycleHookManager)
+}
+
+func (lhm *SScalingLifecycleHookManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.ScalingLifecycleHookListInput) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = lhm.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.VirtualResourceListInput)
+	if err != nil {
+		return q, err
+	}
+	q, err = lhm.SScalingGroupResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ScalingGroupFilterListInput)
+	if err != nil {
+		return q, err
+	}
+	if len(input.Transition) != 0 {
+		q = q.Equals("transition", input.Transition)
+	}
+	return q, nil
+}
+
+func (lhm *SScalingLifecycleHookManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := lhm.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return lhm.SScalingGroupResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (lhm *SScalingLifecycleHookManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, query api.ScalingLifecycleHookListInput) (*sqlchemy.SQuery, error) {
+	return lhm.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+}
+
+func (lh *SScalingLifecycleHook) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, isList bool) (api.ScalingLifecycleHookDetails, error) {
+	return api.ScalingLifecycleHookDetails{}, nil
+}
+
+func (lhm *SScalingLifecycleHookManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ScalingLifecycleHookDetails {
+	rows := make([]api.ScalingLifecycleHookDetails, len(objs))
+	virtRows := lhm.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	sgRows := lhm.SScalingGroupResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].VirtualResourceDetails = virtRows[i]
+		rows[i].ScalingGroupResourceInfo = sgRows[i]
+	}
+	return rows
+}
+
+func validateLifecycleHookWebhook(webhookUrl string) error {
+	if len(webhookUrl) == 0 {
+		return nil
+	}
+	u, err := url.Parse(webhookUrl)
+	if err != nil || !utils.IsInStringArray(u.Scheme, []string{"http", "https"}) || len(u.Host) == 0 {
+		return httperrors.NewInputParameterError("invalid webhook_url %s", webhookUrl)
+	}
+	return nil
+}
+
+func validateLifecycleHookTimeout(timeout int) error {
+	if timeout < LIFECYCLE_HOOK_MIN_TIMEOUT || timeout > LIFECYCLE_HOOK_MAX_TIMEOUT {
+		return httperrors.NewOutOfRangeError("timeout should be between %d and %d", LIFECYCLE_HOOK_MIN_TIMEOUT, LIFECYCLE_HOOK_MAX_TIMEOUT)
+	}
+	return nil
+}
+
+func validateLifecycleResult(result string) error {
+	if !utils.IsInStringArray(result, []string{api.LIFECYCLE_RESULT_CONTINUE, api.LIFECYCLE_RESULT_ABANDON}) {
+		return httperrors.NewInputParameterError("unkown lifecycle result %s", result)
+	}
+	return nil
+}
+
+func (lhm *SScalingLifecycleHookManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ScalingLifecycleHookCreateInput) (
+	api.ScalingLifecycleHookCreateInput, error) {
+	var err error
+	input.VirtualResourceCreateInput, err = lhm.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query,
+		input.VirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	idOrName := input.ScalingGroup
+	if len(input.ScalingGroupId) != 0 {
+		idOrName = input.ScalingGroupId
+	}
+	model, err := ScalingGroupManager.FetchByIdOrName(userCred, idOrName)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return input, httperrors.NewInputParameterError("no such scaling group %s", idOrName)
+	}
+	if err != nil {
+		return input, errors.Wrap(err, "ScalingGroupManager.FetchByIdOrName")
+	}
+	input.ScalingGroupId = model.GetId()
+
+	if !utils.IsInStringArray(input.Transition, []string{api.LIFECYCLE_TRANSITION_SCALE_OUT, api.LIFECYCLE_TRANSITION_SCALE_IN}) {
+		return input, httperrors.NewInputParameterError("unkown transition %s", input.Transition)
+	}
+	hook, err := model.(*SScalingGroup).LifecycleHook(input.Transition)
+	if err != nil {
+		return input, errors.Wrap(err, "ScalingGroup.LifecycleHook")
+	}
+	if hook != nil {
+		return input, httperrors.NewDuplicateResourceError("scaling group already has a lifecycle hook for %s", input.Transition)
+	}
+	err = validateLifecycleHookWebhook(input.WebhookUrl)
+	if err != nil {
+		return input, err
+	}
+	if input.Timeout == 0 {
+		input.Timeout = 300
+	}
+	err = validateLifecycleHookTimeout(input.Timeout)
+	if err != nil {
+		return input, err
+	}
+	if len(input.DefaultResult) == 0 {
+		input.DefaultResult = api.LIFECYCLE_RESULT_CONTINUE
+	}
+	err = validateLifecycleResult(input.DefaultResult)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (lh *SScalingLifecycleHook) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	// hook.Project must be same with hook.ScalingGroup
+	sg := lh.GetScalingGroup()
+	if sg == nil {
+		return errors.Wrapf(errors.ErrNotFound, "scaling group %s", lh.ScalingGroupId)
+	}
+	ownerId = sg.GetOwnerId()
+	lh.Status = api.SP_STATUS_READY
+	return lh.SVirtualResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (lh *SScalingLifecycleHook) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingLifecycleHookUpdateInput) (api.ScalingLifecycleHookUpdateInput, error) {
+	var err error
+	input.VirtualResourceBaseUpdateInput, err = lh.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.VirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	err = validateLifecycleHookWebhook(input.WebhookUrl)
+	if err != nil {
+		return input, err
+	}
+	if input.Timeout != nil {
+		err = validateLifecycleHookTimeout(*input.Timeout)
+		if err != nil {
+			return input, err
+		}
+	}
+	if len(input.DefaultResult) > 0 {
+		err = validateLifecycleResult(input.DefaultResult)
+		if err != nil {
+			return input, err
+		}
+	}
+	return input, nil
+}
+
+// Notify posts the lifecycle notification to webhook url, the instance keeps waiting even if notify failed
+func (lh *SScalingLifecycleHook) Notify(ctx context.Context, sg *SScalingGroup, sgg *SScalingGroupGuest, guestName string) {
+	if len(lh.WebhookUrl) == 0 {
+		return
+	}
+	body := api.ScalingLifecycleHookNotification{
+		Event:            lifecycleHookEvent,
+		ScalingGroupId:   sg.Id,
+		ScalingGroupName: sg.Name,
+		LifecycleHookId:  lh.Id,
+		Transition:       lh.Transition,
+		GuestId:          sgg.GuestId,
+		GuestName:        guestName,
+		LifecycleToken:   sgg.LifecycleToken,
+		Deadline:         sgg.LifecycleDeadline,
+		DefaultResult:    lh.DefaultResult,
+	}
+	_, _, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, httputils.POST, lh.WebhookUrl, nil, jsonutils.Marshal(body), false)
+	if err != nil {
+		log.Errorf("notify lifecycle hook %s of guest %s to %s failed: %s", lh.Id, sgg.GuestId, lh.WebhookUrl, err)
+	}
+}
+
+// LifecycleHook returns the lifecycle hook of transition, nil if not exists
+func (sg *SScalingGroup) LifecycleHook(transition string) (*SScalingLifecycleHook, error) {
+	q := ScalingLifecycleHookManager.Query().Equals("scaling_group_id", sg.Id).Equals("transition", transition)
+	hooks := make([]SScalingLifecycleHook, 0, 1)
+	err := db.FetchModelObjects(ScalingLifecycleHookManager, q, &hooks)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	return &hooks[0], nil
+}
+
+// EnterLifecycleWait set ScalingGroupGuest to pending:wait state
+func (sgg *SScalingGroupGuest) EnterLifecycleWait(hook *SScalingLifecycleHook) error {
+	_, err := db.Update(sgg, func() error {
+		sgg.GuestStatus = api.SG_GUEST_STATUS_PENDING_WAIT
+		sgg.LifecycleHookId = hook.Id
+		sgg.LifecycleToken = stringutils.UUID4()
+		sgg.LifecycleDeadline = time.Now().Add(time.Duration(hook.Timeout) * time.Second)
+		sgg.LifecycleResult = ""
+		sgg.UpdatedAt = time.Now()
+		sgg.UpdateVersion += 1
+		return nil
+	})
+	return err
+}
+
+// LeaveLifecycleWait clears lifecycle state and set guest status to status
+func (sgg *SScalingGroupGuest) LeaveLifecycleWait(status string) error {
+	_, err := db.Update(sgg, func() error {
+		sgg.GuestStatus = status
+		sgg.LifecycleHookId = ""
+		sgg.LifecycleToken = ""
+		sgg.LifecycleDeadline = time.Time{}
+		sgg.LifecycleResult = ""
+		sgg.UpdatedAt = time.Now()
+		sgg.UpdateVersion += 1
+		return nil
+	})
+	return err
+}
+
+func (sg *SScalingGroup) AllowPerformCompleteLifecycleAction(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupCompleteLifecycleActionInput) bool {
+	return sg.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, sg, "complete-lifecycle-action")
+}
+
+// 完成处于pending:wait状态实例的生命周期挂钩
+func (sg *SScalingGroup) PerformCompleteLifecycleAction(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupCompleteLifecycleActionInput) (jsonutils.JSONObject, error) {
+	if len(input.Result) == 0 {
+		input.Result = api.LIFECYCLE_RESULT_CONTINUE
+	}
+	err := validateLifecycleResult(input.Result)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Guest) == 0 {
+		return nil, httperrors.NewMissingParameterError("guest")
+	}
+	guest, err := GuestManager.FetchByIdOrName(userCred, input.Guest)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), input.Guest)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "GuestManager.FetchByIdOrName")
+	}
+	sggs, err := ScalingGroupGuestManager.Fetch(sg.Id, guest.GetId())
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingGroupGuestManager.Fetch")
+	}
+	if len(sggs) == 0 {
+		return nil, httperrors.NewInputParameterError("Guest '%s' don't belong to ScalingGroup '%s'", guest.GetId(), sg.Id)
+	}
+	sgg := &sggs[0]
+	if sgg.GuestStatus != api.SG_GUEST_STATUS_PENDING_WAIT {
+		return nil, httperrors.NewInvalidStatusError("guest %s is not in %s state", guest.GetName(), api.SG_GUEST_STATUS_PENDING_WAIT)
+	}
+	if len(input.LifecycleToken) > 0 && input.LifecycleToken != sgg.LifecycleToken {
+		return nil, httperrors.NewInputParameterError("mismatched lifecycle token")
+	}
+	_, err = db.Update(sgg, func() error {
+		sgg.LifecycleResult = input.Result
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Update")
+	}
+	return nil, nil
+}
@@ -193,6 +193,15 @@ func (sp *SScalingPolicy) getMoreDetails(ctx context.Context, userCred mcclient.
 			return out, errors.Wrap(err, "ScalingTimerManager.FetchById")
 		}
 		out.CycleTimer = model.(*SScalingTimer).CycleTimerDetails()
+	case api.TRIGGER_TARGET_TRACKING:
+		model, err := ScalingTargetTrackingManager.FetchById(sp.TriggerId)
+		if errors.Cause(err) == sql.ErrNoRows {
+			return out, nil
+		}
+		if err != nil {
+			return out, errors.Wrap(err, "ScalingTargetTrackingManager.FetchById")
+		}
+		out.TargetTracking = model.(*SScalingTargetTracking).TargetTrackingDetails()
 	}
 
 	return out, nil
@@ -223,9 +232,29 @@ func (spm *SScalingPolicyManager) ValidateCreateData(ctx context.Context, userCr
 	}
 	input.ScalingGroupId = model.GetId()
 
-	if !utils.IsInStringArray(input.TriggerType, []string{api.TRIGGER_TIMING, api.TRIGGER_CYCLE, api.TRIGGER_ALARM}) {
+	if !utils.IsInStringArray(input.TriggerType, []string{api.TRIGGER_TIMING, api.TRIGGER_CYCLE, api.TRIGGER_ALARM,
+		api.TRIGGER_TARGET_TRACKING}) {
 		return input, httperrors.NewInputParameterError("unkown trigger type %s", input.TriggerType)
 	}
+	if input.TriggerType == api.TRIGGER_TARGET_TRACKING {
+		sg := model.(*SScalingGroup)
+		if sg.MinInstanceNumber < 1 {
+			return input, httperrors.NewInputParameterError("target tracking policy requires the min instance number of scaling group to be at least 1")
+		}
+		q := ScalingPolicyManager.Query().Equals("scaling_group_id", sg.Id).Equals("trigger_type", api.TRIGGER_TARGET_TRACKING)
+		cnt, err := q.CountWithError()
+		if err != nil {
+			return input, errors.Wrap(err, "CountWithError")
+		}
+		if cnt > 0 {
+			return input, httperrors.NewDuplicateResourceError("scaling group %s already has a target tracking policy", sg.Name)
+		}
+		trigger, err := ScalingPolicyManager.Trigger(&input)
+		if err != nil {
+			return input, errors.Wrap(err, "ScalingPolicyManager.Trigger")
+		}
+		return trigger.ValidateCreateData(input)
+	}
 	if !utils.IsInStringArray(input.Action, []string{api.ACTION_ADD, api.ACTION_REMOVE, api.ACTION_SET}) {
 		return input, httperrors.NewInputParameterError("unkown scaling policy action %s", input.Action)
 	}
@@ -345,6 +374,17 @@ func (sp *SScalingPolicy) Trigger(input *api.ScalingPolicyCreateInput) (IScaling
 				RealCumulate:       0,
 				LastTriggerTime:    time.Now(),
 			}, nil
+		case api.TRIGGER_TARGET_TRACKING:
+			return &SScalingTargetTracking{
+				SScalingPolicyBase: SScalingPolicyBase{sp.GetId()},
+				Indicator:          input.TargetTracking.Indicator,
+				CustomQuery:        input.TargetTracking.CustomQuery,
+				TargetValue:        input.TargetTracking.TargetValue,
+				Cycle:              input.TargetTracking.Cycle,
+				ScaleOutCooldown:   input.TargetTracking.ScaleOutCooldown,
+				ScaleInCooldown:    input.TargetTracking.ScaleInCooldown,
+				DisableScaleIn:     input.TargetTracking.DisableScaleIn,
+			}, nil
 		default:
 			return nil, fmt.Errorf("unkown trigger type %s", sp.TriggerType)
 		}
@@ -365,6 +405,12 @@ func (sp *SScalingPolicy) Trigger(input *api.ScalingPolicyCreateInput) (IScaling
 			return nil, errors.Wrap(err, "SScalingAlarmManager.FetchById")
 		}
 		return model.(*SScalingAlarm), nil
+	case api.TRIGGER_TARGET_TRACKING:
+		model, err := ScalingTargetTrackingManager.FetchById(sp.TriggerId)
+		if err != nil {
+			return nil, errors.Wrap(err, "SScalingTargetTrackingManager.FetchById")
+		}
+		return model.(*SScalingTargetTracking), nil
 	default:
 		return nil, fmt.Errorf("unkown trigger type %s", sp.TriggerType)
 	}
@@ -401,6 +447,9 @@ func (sp *SScalingPolicy) PerformTrigger(ctx context.Context, userCred mcclient.
 	}
 
 	manual, _ := data.Bool("manual")
+	if manual && sp.TriggerType == api.TRIGGER_TARGET_TRACKING {
+		return nil, httperrors.NewUnsupportOperationError("target tracking policy can't be triggered manually")
+	}
 	if manual {
 		triggerDesc = SScalingManual{SScalingPolicyBase{sp.Id}}
 	} else {
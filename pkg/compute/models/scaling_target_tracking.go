@@ -0,0 +1,272 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/monitor"
+)
+
+// the metric is considered on target when value/target is within the tolerance
+const targetTrackingTolerance = 0.1
+
+type SScalingTargetTrackingManager struct {
+	db.SStandaloneResourceBaseManager
+}
+
+type SScalingTargetTracking struct {
+	db.SStandaloneResourceBase
+
+	SScalingPolicyBase
+
+	Indicator   string               `width:"32" charset:"ascii"`
+	CustomQuery jsonutils.JSONObject `nullable:"true"`
+	TargetValue float64
+
+	// Cycle of metric evaluation, unit: s
+	Cycle            int `default:"300"`
+	ScaleOutCooldown int `default:"300"`
+	ScaleInCooldown  int `default:"300"`
+	DisableScaleIn   bool
+
+	LastValue        float64
+	LastCheckTime    time.Time
+	LastScaleOutTime time.Time
+	LastScaleInTime  time.Time
+}
+
+var ScalingTargetTrackingManager *SScalingTargetTrackingManager
+
+func init() {
+	ScalingTargetTrackingManager = &SScalingTargetTrackingManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SScalingTargetTracking{},
+			"scalingtargettrackings_tbl",
+			"scalingtargettracking",
+			"scalingtargettrackings",
+		),
+	}
+	ScalingTargetTrackingManager.SetVirtualObject(ScalingTargetTrackingManager)
+}
+
+func (st *SScalingTargetTracking) TargetTrackingDetails() api.ScalingTargetTrackingDetails {
+	return api.ScalingTargetTrackingDetails{
+		Indicator:        st.Indicator,
+		CustomQuery:      st.CustomQuery,
+		TargetValue:      st.TargetValue,
+		Cycle:            st.Cycle,
+		ScaleOutCooldown: st.ScaleOutCooldown,
+		ScaleInCooldown:  st.ScaleInCooldown,
+		DisableScaleIn:   st.DisableScaleIn,
+		LastValue:        st.LastValue,
+		LastCheckTime:    st.LastCheckTime,
+	}
+}
+
+func (st *SScalingTargetTracking) ValidateCreateData(input api.ScalingPolicyCreateInput) (api.ScalingPolicyCreateInput, error) {
+	tt := &input.TargetTracking
+	if !utils.IsInStringArray(tt.Indicator, []string{api.INDICATOR_CPU, api.INDICATOR_MEM, api.INDICATOR_CUSTOM}) {
+		return input, httperrors.NewInputParameterError("unkown indicator in target tracking %s", tt.Indicator)
+	}
+	if tt.Indicator == api.INDICATOR_CUSTOM {
+		if tt.CustomQuery == nil {
+			return input, httperrors.NewMissingParameterError("custom_query")
+		}
+		for _, key := range []string{"measurement", "selects"} {
+			if !tt.CustomQuery.Contains(key) {
+				return input, httperrors.NewInputParameterError("custom_query should contain %s", key)
+			}
+		}
+	} else {
+		tt.CustomQuery = nil
+	}
+	if tt.TargetValue <= 0 {
+		return input, httperrors.NewInputParameterError("target_value should be greater than 0")
+	}
+	if tt.Cycle == 0 {
+		tt.Cycle = 300
+	}
+	if tt.Cycle < 60 {
+		return input, httperrors.NewInputParameterError("the min value of cycle in target tracking is 60")
+	}
+	if tt.ScaleOutCooldown < 0 || tt.ScaleInCooldown < 0 {
+		return input, httperrors.NewInputParameterError("cooldown should not be negative")
+	}
+	if tt.ScaleOutCooldown == 0 {
+		tt.ScaleOutCooldown = 300
+	}
+	if tt.ScaleInCooldown == 0 {
+		tt.ScaleInCooldown = 300
+	}
+	// the desired instance number is computed from the metric
+	input.Action = api.ACTION_SET
+	input.Unit = api.UNIT_ONE
+	input.Number = 0
+	input.CoolingTime = 0
+	return input, nil
+}
+
+func (st *SScalingTargetTracking) Register(ctx context.Context, userCred mcclient.TokenCredential) error {
+	err := ScalingTargetTrackingManager.TableSpec().Insert(ctx, st)
+	if err != nil {
+		return errors.Wrap(err, "STableSpec.Insert")
+	}
+	return nil
+}
+
+func (st *SScalingTargetTracking) UnRegister(ctx context.Context, userCred mcclient.TokenCredential) error {
+	err := st.Delete(ctx, userCred)
+	if err != nil {
+		return errors.Wrap(err, "SScalingTargetTracking.Delete")
+	}
+	return nil
+}
+
+func (st *SScalingTargetTracking) TriggerId() string {
+	return st.GetId()
+}
+
+// IsTrigger always return false, target tracking policy is evaluated by the scaling controller
+func (st *SScalingTargetTracking) IsTrigger() bool {
+	return false
+}
+
+func (st *SScalingTargetTracking) TriggerDescription() string {
+	name := st.ScalingPolicyId
+	sp, _ := st.ScalingPolicy()
+	if sp != nil {
+		name = sp.Name
+	}
+	return fmt.Sprintf(
+		`Target tracking task(the average %s of the instance is %f%s, the target is %f%s) execute scaling policy "%s"`,
+		st.indicatorDesc(), st.LastValue, units[st.Indicator], st.TargetValue, units[st.Indicator], name,
+	)
+}
+
+func (st *SScalingTargetTracking) indicatorDesc() string {
+	if desc, ok := descs[st.Indicator]; ok {
+		return desc
+	}
+	return "custom metric"
+}
+
+// MetricQuery returns the monitor metric query whose result is the indicator of instances in the scaling group
+func (st *SScalingTargetTracking) MetricQuery(scalingGroupId string) (jsonutils.JSONObject, error) {
+	from := fmt.Sprintf("%ds", st.Cycle)
+	if st.Indicator == api.INDICATOR_CUSTOM {
+		if st.CustomQuery == nil {
+			return nil, errors.Wrap(httperrors.ErrMissingParameter, "custom_query")
+		}
+		return jsonutils.Marshal(map[string]interface{}{
+			"model": st.CustomQuery,
+			"from":  from,
+		}), nil
+	}
+	field, ok := indicatorMap[st.Indicator]
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrNotSupported, "indicator %s", st.Indicator)
+	}
+	q := monitor.NewAlertQuery("telegraf", field.Table).From(from).Interval(from)
+	q.Selects().Select(field.Field).MEAN()
+	q.Where().Equal("vm_scaling_group_id", scalingGroupId)
+	q.GroupBy().TAG("vm_id").FILL_NULL()
+	return jsonutils.Marshal(q.ToAlertQuery()), nil
+}
+
+// Desired computes the instance number which makes the metric near the target value
+func (st *SScalingTargetTracking) Desired(current int, value float64) int {
+	if current <= 0 || st.TargetValue <= 0 {
+		return current
+	}
+	ratio := value / st.TargetValue
+	if math.Abs(ratio-1) <= targetTrackingTolerance {
+		return current
+	}
+	desired := int(math.Ceil(float64(current) * ratio))
+	if desired < current && st.DisableScaleIn {
+		return current
+	}
+	if desired < 1 {
+		desired = 1
+	}
+	return desired
+}
+
+// InCooldown check whether the scaling from current to desired should wait for the cooldown
+func (st *SScalingTargetTracking) InCooldown(current, desired int, now time.Time) bool {
+	if desired > current {
+		return st.LastScaleOutTime.Add(time.Duration(st.ScaleOutCooldown) * time.Second).After(now)
+	}
+	// scale in should also wait for the cooldown of the last scale out
+	if st.LastScaleOutTime.Add(time.Duration(st.ScaleOutCooldown) * time.Second).After(now) {
+		return true
+	}
+	return st.LastScaleInTime.Add(time.Duration(st.ScaleInCooldown) * time.Second).After(now)
+}
+
+func (st *SScalingTargetTracking) NeedCheck(now time.Time) bool {
+	return !st.LastCheckTime.Add(time.Duration(st.Cycle) * time.Second).After(now)
+}
+
+func (st *SScalingTargetTracking) SetLastValue(value float64, now time.Time) {
+	_, err := db.Update(st, func() error {
+		st.LastValue = value
+		st.LastCheckTime = now
+		return nil
+	})
+	if err != nil {
+		log.Errorf("db.Update in ScalingTargetTracking.SetLastValue failed: %s", err.Error())
+	}
+}
+
+func (st *SScalingTargetTracking) SetScaleTime(scaleOut bool, now time.Time) {
+	_, err := db.Update(st, func() error {
+		if scaleOut {
+			st.LastScaleOutTime = now
+		} else {
+			st.LastScaleInTime = now
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("db.Update in ScalingTargetTracking.SetScaleTime failed: %s", err.Error())
+	}
+}
+
+// SScalingTargetTrackingAction set the desired instance number to Target
+type SScalingTargetTrackingAction struct {
+	Target int
+}
+
+func (action SScalingTargetTrackingAction) Exec(from int) int {
+	return action.Target
+}
+
+func (action SScalingTargetTrackingAction) CheckCoolTime() bool {
+	return false
+}
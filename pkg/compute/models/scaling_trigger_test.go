@@ -106,3 +106,25 @@ func TestSTimer_Update(t *testing.T) {
 		}
 	}
 }
+
+func TestSScalingTargetTracking_Desired(t *testing.T) {
+	cases := []struct {
+		tt      SScalingTargetTracking
+		current int
+		value   float64
+		want    int
+	}{
+		{SScalingTargetTracking{TargetValue: 50}, 2, 100, 4},
+		{SScalingTargetTracking{TargetValue: 50}, 3, 52, 3},
+		{SScalingTargetTracking{TargetValue: 50}, 4, 20, 2},
+		{SScalingTargetTracking{TargetValue: 50, DisableScaleIn: true}, 4, 20, 4},
+		{SScalingTargetTracking{TargetValue: 60}, 3, 0, 1},
+		{SScalingTargetTracking{TargetValue: 60}, 0, 90, 0},
+	}
+	for _, c := range cases {
+		got := c.tt.Desired(c.current, c.value)
+		if got != c.want {
+			t.Errorf("target %f current %d value %f: want %d, got %d", c.tt.TargetValue, c.current, c.value, c.want, got)
+		}
+	}
+}
@@ -53,6 +53,12 @@ type SScalingGroupGuest struct {
 	ScalingGroupId string            `width:"36" charset:"ascii" nullable:"false"`
 	GuestStatus    string            `width:"36" charset:"ascii" nullable:"false" index:"true"`
 	Manual         tristate.TriState `nullable:"false" default:"false"`
+
+	// lifecycle hook which the guest is waiting for
+	LifecycleHookId   string    `width:"36" charset:"ascii" nullable:"true"`
+	LifecycleToken    string    `width:"36" charset:"ascii" nullable:"true"`
+	LifecycleDeadline time.Time `nullable:"true"`
+	LifecycleResult   string    `width:"16" charset:"ascii" nullable:"true"`
 }
 
 func (sggm *SScalingGroupGuestManager) GetSlaveFieldName() string {
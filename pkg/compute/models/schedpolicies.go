@@ -62,6 +62,9 @@ type SSchedpolicy struct {
 	Strategy  string `width:"32" charset:"ascii" nullable:"false" list:"user" create:"required" update:"user"`
 
 	Enabled tristate.TriState `nullable:"false" default:"true" create:"optional" list:"user" update:"user"`
+
+	// 匹配该策略时主机实际利用率打分的权重
+	UtilizationWeights *api.SchedUtilizationWeights `nullable:"true" list:"user" create:"optional" update:"user"`
 }
 
 func validateSchedpolicyInputData(data *jsonutils.JSONDict, create bool) error {
@@ -79,6 +82,18 @@ func validateSchedpolicyInputData(data *jsonutils.JSONDict, create bool) error {
 		return httperrors.NewInputParameterError("invalid strategy %s", strategyStr)
 	}
 
+	if data.Contains("utilization_weights") {
+		weights := api.SchedUtilizationWeights{}
+		err := data.Unmarshal(&weights, "utilization_weights")
+		if err != nil {
+			return httperrors.NewInputParameterError("invalid utilization_weights: %s", err)
+		}
+		err = weights.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -291,6 +306,22 @@ func applyServerSchedtags(policies []SSchedpolicy, input *schedapi.ScheduleInput
 	applyResourceSchedPolicy(policies, input.Schedtags, inputCond, setFunc)
 }
 
+// 请求中未指定利用率权重时，使用最后一个匹配且设置了权重的主机调度策略
+func applyServerUtilizationWeights(policies []SSchedpolicy, input *schedapi.ScheduleInput) {
+	if input.UtilizationWeights != nil {
+		return
+	}
+	inputCond := GetDynamicConditionInput(GuestManager, input.ToConditionInput())
+	for i := range policies {
+		if policies[i].UtilizationWeights == nil || policies[i].UtilizationWeights.IsZero() {
+			continue
+		}
+		if matchResourceSchedPolicy(policies[i], inputCond) {
+			input.UtilizationWeights = policies[i].UtilizationWeights
+		}
+	}
+}
+
 func applyDiskSchedtags(policies []SSchedpolicy, input *api.DiskConfig) {
 	inputCond := GetDynamicConditionInput(DiskManager, jsonutils.Marshal(input).(*jsonutils.JSONDict))
 	setFunc := func(tags []*api.SchedtagConfig) {
@@ -316,6 +347,7 @@ func ApplySchedPolicies(input *schedapi.ScheduleInput) *schedapi.ScheduleInput {
 	config := input.ServerConfigs
 
 	applyServerSchedtags(hostPolicies, input)
+	applyServerUtilizationWeights(hostPolicies, input)
 	for _, disk := range config.Disks {
 		applyDiskSchedtags(storagePolicies, disk)
 	}
@@ -30,6 +30,7 @@ import (
 
 	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	cop "yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
@@ -72,6 +73,15 @@ type SScheduledTask struct {
 
 	STimer
 
+	// cron 表达式, 仅 cron 类型有效
+	CronExpr string `width:"128" charset:"ascii" nullable:"true" list:"user" get:"user"`
+	// cron 表达式使用的时区
+	TimeZone string `width:"64" charset:"ascii" nullable:"true" list:"user" get:"user"`
+	// 随机延迟执行的最大秒数
+	Jitter int `nullable:"true" default:"0" list:"user" get:"user"`
+	// 错过执行时间后的补跑策略
+	CatchUp string `width:"16" charset:"ascii" nullable:"true" list:"user" get:"user"`
+
 	ResourceType string `width:"32" charset:"ascii" create:"required" list:"user" get:"user"`
 	Operation    string `width:"32" charset:"ascii" create:"required" list:"user" get:"user"`
 	LabelType    string `width:"4" charset:"ascii" create:"required" list:"user" get:"user"`
@@ -137,8 +147,14 @@ func (st *SScheduledTask) getMoreDetails(ctx context.Context, userCred mcclient.
 		out.Timer = st.STimer.TimerDetails()
 	case api.ST_TYPE_CYCLE:
 		out.CycleTimer = st.STimer.CycleTimerDetails()
+	case api.ST_TYPE_CRON:
+		out.CronTimer = st.cronTimerDetails()
+	}
+	if st.ScheduledType == api.ST_TYPE_CRON {
+		out.TimerDesc = st.cronDescription(ctx)
+	} else {
+		out.TimerDesc = st.Description(ctx)
 	}
-	out.TimerDesc = st.Description(ctx)
 	// fill label
 	stLabels, err := st.STLabels()
 	if err != nil {
@@ -160,7 +176,7 @@ func (stm *SScheduledTaskManager) ValidateCreateData(ctx context.Context, userCr
 	if err != nil {
 		return input, err
 	}
-	if !utils.IsInStringArray(input.ScheduledType, []string{api.ST_TYPE_TIMING, api.ST_TYPE_CYCLE}) {
+	if !utils.IsInStringArray(input.ScheduledType, []string{api.ST_TYPE_TIMING, api.ST_TYPE_CYCLE, api.ST_TYPE_CRON}) {
 		return input, httperrors.NewInputParameterError("unkown scheduled type '%s'", input.ScheduledType)
 	}
 	if !utils.IsInStringArray(input.ResourceType, []string{api.ST_RESOURCE_SERVER}) {
@@ -172,11 +188,14 @@ func (stm *SScheduledTaskManager) ValidateCreateData(ctx context.Context, userCr
 	if !utils.IsInStringArray(input.LabelType, []string{api.ST_LABEL_ID, api.ST_LABEL_TAG}) {
 		return input, httperrors.NewInputParameterError("unkown label type '%s'", input.LabelType)
 	}
-	// check timer or cycletimer
-	if input.ScheduledType == api.ST_TYPE_TIMING {
+	// check timer, cycletimer or crontimer
+	switch input.ScheduledType {
+	case api.ST_TYPE_TIMING:
 		input.Timer, err = checkTimerCreateInput(input.Timer)
-	} else {
+	case api.ST_TYPE_CYCLE:
 		input.CycleTimer, err = checkCycleTimerCreateInput(input.CycleTimer)
+	case api.ST_TYPE_CRON:
+		input.CronTimer, err = checkCronTimerCreateInput(input.CronTimer)
 	}
 	if err != nil {
 		return input, httperrors.NewInputParameterError("%v", err)
@@ -195,6 +214,17 @@ func (st *SScheduledTask) PerformEnable(ctx context.Context, userCred mcclient.T
 	if err != nil {
 		return nil, errors.Wrap(err, "EnabledPerformEnable")
 	}
+	if st.ScheduledType == api.ST_TYPE_CRON {
+		// 禁用期间错过的执行不需要补跑
+		_, err = db.Update(st, func() error {
+			st.NextTime = time.Time{}
+			st.updateNextTime(time.Now())
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "db.Update")
+		}
+	}
 	return nil, nil
 }
 
@@ -254,8 +284,18 @@ func (st *SScheduledTask) PostCreate(ctx context.Context, userCred mcclient.Toke
 		}
 		st.SetWeekDays(input.CycleTimer.WeekDays)
 		st.SetMonthDays(input.CycleTimer.MonthDays)
+	case api.ST_TYPE_CRON:
+		st.STimer = STimer{
+			Type:      api.ST_TYPE_CRON,
+			StartTime: input.CronTimer.StartTime,
+			EndTime:   input.CronTimer.EndTime,
+		}
+		st.CronExpr = input.CronTimer.Expr
+		st.TimeZone = input.CronTimer.TimeZone
+		st.Jitter = input.CronTimer.Jitter
+		st.CatchUp = input.CronTimer.CatchUp
 	}
-	st.Update(time.Time{})
+	st.updateNextTime(time.Time{})
 	st.Status = api.ST_STATUS_READY
 	st.Enabled = tristate.True
 	// st.TimerDesc = st.Description(ctx)
@@ -536,6 +576,91 @@ func (st *SScheduledTask) ResourceOperation() ResourceOperation {
 	return ResourceOperationMap[fmt.Sprintf("%s.%s", st.ResourceType, st.Operation)]
 }
 
+// cron 类型补跑全部时单次最多补跑的次数
+const scheduledTaskMaxCatchUpRuns = 10
+
+func checkCronTimerCreateInput(in api.CronTimerCreateInput) (api.CronTimerCreateInput, error) {
+	if len(in.CatchUp) == 0 {
+		in.CatchUp = cronman.CRON_CATCH_UP_SKIP
+	}
+	_, err := cronman.NewCronTimer(in.Expr, in.TimeZone, time.Duration(in.Jitter)*time.Second, in.CatchUp, time.Time{})
+	if err != nil {
+		return in, err
+	}
+	expr, _ := cronman.ParseCronExpr(in.Expr)
+	if expr.MinInterval() < time.Minute {
+		return in, fmt.Errorf("cron expression %q should not fire more than once per minute", in.Expr)
+	}
+	if !in.EndTime.IsZero() {
+		if time.Now().After(in.EndTime) {
+			return in, fmt.Errorf("end_time is earlier than now")
+		}
+		if in.EndTime.Before(in.StartTime) {
+			return in, fmt.Errorf("end_time is earlier than start_time")
+		}
+	}
+	return in, nil
+}
+
+func (st *SScheduledTask) catchUpMissed() bool {
+	return st.ScheduledType == api.ST_TYPE_CRON && utils.IsInStringArray(st.CatchUp, []string{cronman.CRON_CATCH_UP_ONCE, cronman.CRON_CATCH_UP_ALL})
+}
+
+// updateNextTime 计算下次执行时间, cron 类型按表达式计算, 其余类型由 STimer 计算
+func (st *SScheduledTask) updateNextTime(now time.Time) {
+	if st.ScheduledType != api.ST_TYPE_CRON {
+		st.Update(now)
+		return
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Before(st.StartTime) {
+		now = st.StartTime.Add(-time.Second)
+	}
+	// 补跑全部时从上次执行时间依次往后计算, 否则跳过错过的执行
+	catchUp := cronman.CRON_CATCH_UP_SKIP
+	if st.CatchUp == cronman.CRON_CATCH_UP_ALL {
+		catchUp = cronman.CRON_CATCH_UP_ALL
+	}
+	timer, err := cronman.NewCronTimer(st.CronExpr, st.TimeZone, time.Duration(st.Jitter)*time.Second, catchUp, st.NextTime)
+	if err != nil {
+		log.Errorf("invalid cron timer of scheduled task %s: %s", st.Id, err)
+		st.IsExpired = true
+		return
+	}
+	st.NextTime = timer.Next(now)
+	if st.NextTime.IsZero() || (!st.EndTime.IsZero() && st.NextTime.After(st.EndTime)) {
+		st.IsExpired = true
+	}
+}
+
+func (st *SScheduledTask) cronTimerDetails() api.CronTimerDetails {
+	return api.CronTimerDetails{
+		Expr:      st.CronExpr,
+		TimeZone:  st.TimeZone,
+		Jitter:    st.Jitter,
+		CatchUp:   st.CatchUp,
+		StartTime: st.StartTime,
+		EndTime:   st.EndTime,
+		NextTime:  st.NextTime,
+	}
+}
+
+func (st *SScheduledTask) cronDescription(ctx context.Context) string {
+	tz := st.TimeZone
+	if len(tz) == 0 {
+		tz = zone.String()
+	}
+	switch timerDescTable.Lookup(ctx, TIMERLANG) {
+	case "en":
+		return fmt.Sprintf("cron expression '%s' in time zone %s", st.CronExpr, tz)
+	case "cn":
+		return fmt.Sprintf("按 cron 表达式【%s】触发, 时区 %s", st.CronExpr, tz)
+	}
+	return ""
+}
+
 type STimeScope struct {
 	Start  time.Time
 	End    time.Time
@@ -575,9 +700,9 @@ func (stm *SScheduledTaskManager) Timer(ctx context.Context, userCred mcclient.T
 			defer func() {
 				<-timerQueue
 			}()
-			if st.NextTime.Before(timeScope.Start) {
+			if st.NextTime.Before(timeScope.Start) && !st.catchUpMissed() {
 				// For unknown reasons, the scalingTimer did not execute at the specified time
-				st.Update(timeScope.Start)
+				st.updateNextTime(timeScope.Start)
 				// scalingTimer should not exec for now.
 				if st.NextTime.After(timeScope.End) || st.IsExpired {
 					err = stm.TableSpec().InsertOrUpdate(ctx, &st)
@@ -591,7 +716,25 @@ func (stm *SScheduledTaskManager) Timer(ctx context.Context, userCred mcclient.T
 			if err != nil {
 				log.Errorf("unable to execute scheduled task '%s'", st.Id)
 			}
-			st.Update(timeScope.End)
+			next := timeScope.End
+			if st.ScheduledType == api.ST_TYPE_CRON {
+				// cron 表达式最快每分钟触发一次, 从本次执行时间往后计算
+				next = time.Now()
+			}
+			st.updateNextTime(next)
+			for i := 0; st.CatchUp == cronman.CRON_CATCH_UP_ALL && st.NextTime.Before(timeScope.Start) && !st.IsExpired; i++ {
+				if i >= scheduledTaskMaxCatchUpRuns {
+					// 超过补跑次数上限, 跳过剩余错过的执行
+					st.NextTime = time.Time{}
+					st.updateNextTime(time.Now())
+					break
+				}
+				err := st.Execute(ctx, userCred)
+				if err != nil {
+					log.Errorf("unable to execute scheduled task '%s'", st.Id)
+				}
+				st.updateNextTime(time.Now())
+			}
 			err = stm.TableSpec().InsertOrUpdate(ctx, &st)
 			if err != nil {
 				log.Errorf("update Scheduled task whose id is %s error: %s", st.Id, err.Error())
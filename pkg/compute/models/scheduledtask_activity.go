@@ -93,6 +93,10 @@ func (sam *SScheduledTaskActivityManager) FetchCustomizeColumns(
 	statusRows := sam.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
 	for i := range rows {
 		rows[i].StatusStandaloneResourceDetails = statusRows[i]
+		activity := objs[i].(*SScheduledTaskActivity)
+		if !activity.EndTime.IsZero() {
+			rows[i].Duration = activity.EndTime.Sub(activity.StartTime).Seconds()
+		}
 	}
 	return rows
 }
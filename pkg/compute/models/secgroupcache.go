@@ -648,9 +648,9 @@ func (self *SSecurityGroupCache) SyncRules() error {
 		return errors.Wrapf(err, "iSecgroup.GetRules")
 	}
 
-	localRules, err := secgroup.GetSecuritRuleSet()
+	localRules, err := secgroup.GetSecuritRuleSetForProvider(region.GetDriver())
 	if err != nil {
-		return errors.Wrapf(err, "GetSecuritRuleSet")
+		return errors.Wrapf(err, "GetSecuritRuleSetForProvider")
 	}
 
 	src := cloudprovider.NewSecRuleInfo(GetRegionDriver(api.CLOUD_PROVIDER_ONECLOUD))
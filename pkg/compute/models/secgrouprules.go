@@ -16,6 +16,7 @@ package models
 
 import (
 	"context"
+	"database/sql"
 	"net"
 	"strings"
 
@@ -36,6 +37,7 @@ import (
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
@@ -68,9 +70,12 @@ type SSecurityGroupRule struct {
 	Protocol    string `width:"5" charset:"ascii" nullable:"false" list:"user" update:"user" create:"required"`
 	Ports       string `width:"256" charset:"ascii" list:"user" update:"user" create:"optional"`
 	Direction   string `width:"3" charset:"ascii" list:"user" create:"required"`
-	CIDR        string `width:"256" charset:"ascii" list:"user" update:"user" create:"required"`
+	CIDR        string `width:"256" charset:"ascii" list:"user" update:"user" create:"optional"`
 	Action      string `width:"5" charset:"ascii" nullable:"false" list:"user" update:"user" create:"required"`
 	Description string `width:"256" charset:"utf8" list:"user" update:"user" create:"optional"`
+
+	// 引用的地址组, 设置时忽略CIDR
+	AddressGroupId string `width:"36" charset:"ascii" index:"true" list:"user" update:"user" create:"optional"`
 	// SecgroupID  string `width:"128" charset:"ascii" create:"required"`
 }
 
@@ -78,6 +83,29 @@ func (self *SSecurityGroupRule) GetId() string {
 	return self.Id
 }
 
+// 记录字段级变更历史
+func (manager *SSecurityGroupRuleManager) IsHistoryRecorded() bool {
+	return true
+}
+
+func (self *SSecurityGroupRule) AllowGetDetailsHistory(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return self.AllowGetDetails(ctx, userCred, query)
+}
+
+// 获取规则在某一时刻的状态
+func (self *SSecurityGroupRule) GetDetailsHistory(ctx context.Context, userCred mcclient.TokenCredential, input apis.GetHistoryInput) (*apis.GetHistoryOutput, error) {
+	return db.GetModelHistory(self, userCred, input)
+}
+
+func (self *SSecurityGroupRule) AllowGetDetailsDiff(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return self.AllowGetDetails(ctx, userCred, query)
+}
+
+// 获取规则在一段时间内的字段变更
+func (self *SSecurityGroupRule) GetDetailsDiff(ctx context.Context, userCred mcclient.TokenCredential, input apis.GetDiffInput) (*apis.GetDiffOutput, error) {
+	return db.GetModelDiff(self, userCred, input)
+}
+
 type SecurityGroupRuleSet []SSecurityGroupRule
 
 func (v SecurityGroupRuleSet) Len() int {
@@ -323,6 +351,11 @@ func (manager *SSecurityGroupRuleManager) ValidateCreateData(ctx context.Context
 		return input, httperrors.NewInputParameterError("Failed to unmarshal input: %v", err)
 	}
 
+	err = validateSecgroupRuleAddressGroup(userCred, &input)
+	if err != nil {
+		return input, err
+	}
+
 	err = input.Check()
 	if err != nil {
 		return input, err
@@ -343,16 +376,12 @@ func (self *SSecurityGroupRule) ValidateUpdateData(ctx context.Context, userCred
 		return nil, err
 	}
 
-	input := &api.SSecgroupRuleCreateInput{
-		Direction: self.Direction,
-		Action:    self.Action,
-		CIDR:      self.CIDR,
-		Protocol:  self.Protocol,
-		Ports:     self.Ports,
-		Priority:  int(self.Priority),
+	input, err := self.getUpdateInput(data)
+	if err != nil {
+		return nil, err
 	}
 
-	err = jsonutils.Update(input, data)
+	err = validateSecgroupRuleAddressGroup(userCred, input)
 	if err != nil {
 		return nil, err
 	}
@@ -361,6 +390,8 @@ func (self *SSecurityGroupRule) ValidateUpdateData(ctx context.Context, userCred
 	if err != nil {
 		return nil, err
 	}
+	data.Set("cidr", jsonutils.NewString(input.CIDR))
+	data.Set("address_group_id", jsonutils.NewString(input.AddressGroupId))
 
 	// 更新操作日志: 对比可以知道改了原有规则哪些内容
 	data.Add(jsonutils.Marshal(self), "origin")
@@ -379,12 +410,97 @@ func (self *SSecurityGroupRule) ValidateUpdateData(ctx context.Context, userCred
 	return data, nil
 }
 
+// getUpdateInput 以现有规则为基础合并更新内容
+func (self *SSecurityGroupRule) getUpdateInput(data *jsonutils.JSONDict) (*api.SSecgroupRuleCreateInput, error) {
+	input := &api.SSecgroupRuleCreateInput{
+		Direction:      self.Direction,
+		Action:         self.Action,
+		CIDR:           self.CIDR,
+		AddressGroupId: self.AddressGroupId,
+		Protocol:       self.Protocol,
+		Ports:          self.Ports,
+		Priority:       int(self.Priority),
+	}
+
+	// cidr与地址组互斥, 更新其中之一时清空另一个
+	if data.Contains("address_group") || data.Contains("address_group_id") {
+		input.CIDR = ""
+	} else if data.Contains("cidr") {
+		input.AddressGroupId = ""
+	}
+
+	err := jsonutils.Update(input, data)
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// validateSecgroupRuleAddressGroup 解析规则引用的地址组(ID或Name)
+func validateSecgroupRuleAddressGroup(userCred mcclient.TokenCredential, input *api.SSecgroupRuleCreateInput) error {
+	agStr := input.AddressGroup
+	if len(agStr) == 0 {
+		agStr = input.AddressGroupId
+	}
+	if len(agStr) == 0 {
+		return nil
+	}
+	ag, err := AddressGroupManager.FetchByIdOrName(userCred, agStr)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return httperrors.NewResourceNotFoundError2(AddressGroupManager.Keyword(), agStr)
+		}
+		return httperrors.NewGeneralError(err)
+	}
+	err = ag.(*SAddressGroup).validateRuleEntries()
+	if err != nil {
+		return err
+	}
+	input.AddressGroupId = ag.GetId()
+	return nil
+}
+
 func (self *SSecurityGroupRule) String() string {
+	rules, rules6 := self.ruleStrings()
+	return strings.Join(append(rules, rules6...), SECURITY_GROUP_SEPARATOR)
+}
+
+// ruleStrings 分别返回IPv4与IPv6规则, secrules无法解析IPv6地址, 两者需分开下发
+func (self *SSecurityGroupRule) ruleStrings() ([]string, []string) {
+	rules, err := self.toRules()
+	if err != nil {
+		log.Errorf("secgroup rule %s(%s) toRules: %v", self.Id, self.SecgroupId, err)
+		return nil, nil
+	}
+	ret, ret6 := []string{}, []string{}
+	for i := range rules {
+		if netutils2.IsSecurityRule6(&rules[i]) {
+			ret6 = append(ret6, netutils2.FormatSecurityRule6(rules[i]))
+		} else {
+			ret = append(ret, rules[i].String())
+		}
+	}
+	return ret, ret6
+}
+
+func (self *SSecurityGroupRule) GetAddressGroup() (*SAddressGroup, error) {
+	return AddressGroupManager.FetchAddressGroupById(self.AddressGroupId)
+}
+
+// toRules 引用地址组的规则按地址组内每个CIDR展开, 空地址组不产生任何规则
+func (self *SSecurityGroupRule) toRules() ([]secrules.SecurityRule, error) {
 	rule, err := self.toRule()
 	if err != nil {
-		return ""
+		return nil, err
+	}
+	if len(self.AddressGroupId) == 0 {
+		return []secrules.SecurityRule{*rule}, nil
+	}
+	ag, err := self.GetAddressGroup()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetAddressGroup")
 	}
-	return rule.String()
+	return ag.ExpandRule(*rule)
 }
 
 func (self *SSecurityGroupRule) toRule() (*secrules.SecurityRule, error) {
@@ -395,13 +511,18 @@ func (self *SSecurityGroupRule) toRule() (*secrules.SecurityRule, error) {
 		Protocol:    self.Protocol,
 		Description: self.Description,
 	}
-	if regutils.MatchCIDR(self.CIDR) {
-		_, rule.IPNet, _ = net.ParseCIDR(self.CIDR)
-	} else if regutils.MatchIPAddr(self.CIDR) {
+	if _, ipnet, err := net.ParseCIDR(self.CIDR); err == nil {
+		rule.IPNet = ipnet
+	} else if regutils.MatchIP4Addr(self.CIDR) {
 		rule.IPNet = &net.IPNet{
 			IP:   net.ParseIP(self.CIDR),
 			Mask: net.CIDRMask(32, 32),
 		}
+	} else if regutils.MatchIP6Addr(self.CIDR) {
+		rule.IPNet = &net.IPNet{
+			IP:   net.ParseIP(self.CIDR),
+			Mask: net.CIDRMask(128, 128),
+		}
 	} else {
 		rule.IPNet = &net.IPNet{
 			IP:   net.IPv4zero,
@@ -0,0 +1,180 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/util/secrules"
+
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+)
+
+func TestSecurityGroupRuleStrings(t *testing.T) {
+	cases := []struct {
+		name   string
+		rule   SSecurityGroupRule
+		rules  []string
+		rules6 []string
+	}{
+		{
+			name:   "ipv4 cidr",
+			rule:   SSecurityGroupRule{Priority: 1, Direction: "in", Action: "allow", Protocol: "tcp", Ports: "22", CIDR: "10.0.0.0/8"},
+			rules:  []string{"in:allow 10.0.0.0/8 tcp 22"},
+			rules6: []string{},
+		},
+		{
+			name:   "any address",
+			rule:   SSecurityGroupRule{Priority: 1, Direction: "out", Action: "allow", Protocol: "any"},
+			rules:  []string{"out:allow any"},
+			rules6: []string{},
+		},
+		{
+			name:   "ipv6 cidr",
+			rule:   SSecurityGroupRule{Priority: 1, Direction: "in", Action: "allow", Protocol: "tcp", Ports: "22", CIDR: "2001:db8::/64"},
+			rules:  []string{},
+			rules6: []string{"in:allow 2001:db8::/64 tcp 22"},
+		},
+		{
+			name:   "ipv6 address",
+			rule:   SSecurityGroupRule{Priority: 1, Direction: "in", Action: "deny", Protocol: "udp", Ports: "53", CIDR: "2001:db8::1"},
+			rules:  []string{},
+			rules6: []string{"in:deny 2001:db8::1 udp 53"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, rules6 := c.rule.ruleStrings()
+			if !reflect.DeepEqual(rules, c.rules) {
+				t.Errorf("want rules %v got %v", c.rules, rules)
+			}
+			if !reflect.DeepEqual(rules6, c.rules6) {
+				t.Errorf("want ipv6 rules %v got %v", c.rules6, rules6)
+			}
+			// 下发给宿主机的规则需能被原样解析
+			for _, r := range rules {
+				if _, err := secrules.ParseSecurityRule(r); err != nil {
+					t.Errorf("ParseSecurityRule %q: %s", r, err)
+				}
+			}
+			for _, r := range rules6 {
+				rule, err := netutils2.ParseSecurityRule6(r)
+				if err != nil {
+					t.Errorf("ParseSecurityRule6 %q: %s", r, err)
+					continue
+				}
+				if got := netutils2.FormatSecurityRule6(*rule); got != r {
+					t.Errorf("round trip of %q got %q", r, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterSecurityRules6(t *testing.T) {
+	rules := []secrules.SecurityRule{
+		*secrules.MustParseSecurityRule("in:allow 10.0.0.0/8 tcp 22"),
+		*secrules.MustParseSecurityRule("in:allow any"),
+	}
+	rule6, err := netutils2.ParseSecurityRule6("in:allow 2001:db8::/64 tcp 22")
+	if err != nil {
+		t.Fatalf("ParseSecurityRule6: %s", err)
+	}
+	rules = append(rules, *rule6)
+
+	if got := filterSecurityRules6(rules, true); len(got) != 3 {
+		t.Errorf("provider supporting ipv6 should keep all rules, got %d", len(got))
+	}
+	got := filterSecurityRules6(rules, false)
+	if len(got) != 2 {
+		t.Fatalf("provider without ipv6 should drop ipv6 rules, got %d", len(got))
+	}
+	for i := range got {
+		if netutils2.IsSecurityRule6(&got[i]) {
+			t.Errorf("ipv6 rule %s not filtered", got[i].String())
+		}
+	}
+}
+
+func TestSecurityGroupRuleUpdateCidrAddressGroup(t *testing.T) {
+	cidrRule := &SSecurityGroupRule{Priority: 1, Direction: "in", Action: "allow", Protocol: "tcp", Ports: "22", CIDR: "10.0.0.0/8"}
+	agRule := &SSecurityGroupRule{Priority: 1, Direction: "in", Action: "allow", Protocol: "tcp", Ports: "22", AddressGroupId: "ag-1"}
+	cases := []struct {
+		name           string
+		rule           *SSecurityGroupRule
+		data           string
+		cidr           string
+		addressGroupId string
+		isErr          bool
+	}{
+		{
+			name:           "cidr rule switch to address group",
+			rule:           cidrRule,
+			data:           `{"address_group_id":"ag-2"}`,
+			addressGroupId: "ag-2",
+		},
+		{
+			name: "address group rule switch to cidr",
+			rule: agRule,
+			data: `{"cidr":"192.168.0.0/16"}`,
+			cidr: "192.168.0.0/16",
+		},
+		{
+			name:           "address group rule keeps group",
+			rule:           agRule,
+			data:           `{"ports":"80"}`,
+			addressGroupId: "ag-1",
+		},
+		{
+			name: "cidr rule keeps cidr",
+			rule: cidrRule,
+			data: `{"description":"ssh"}`,
+			cidr: "10.0.0.0/8",
+		},
+		{
+			name:  "both cidr and address group",
+			rule:  cidrRule,
+			data:  `{"cidr":"192.168.0.0/16","address_group_id":"ag-2"}`,
+			isErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := jsonutils.ParseString(c.data)
+			if err != nil {
+				t.Fatalf("parse %s: %s", c.data, err)
+			}
+			input, err := c.rule.getUpdateInput(data.(*jsonutils.JSONDict))
+			if err != nil {
+				t.Fatalf("getUpdateInput: %s", err)
+			}
+			err = input.Check()
+			if c.isErr {
+				if err == nil {
+					t.Errorf("want error, got cidr %q address group %q", input.CIDR, input.AddressGroupId)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check: %s", err)
+			}
+			if input.CIDR != c.cidr || input.AddressGroupId != c.addressGroupId {
+				t.Errorf("want cidr %q address group %q, got %q %q", c.cidr, c.addressGroupId, input.CIDR, input.AddressGroupId)
+			}
+		})
+	}
+}
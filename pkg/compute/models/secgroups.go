@@ -43,6 +43,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
@@ -76,6 +77,11 @@ type SSecurityGroup struct {
 	IsDirty bool `nullable:"false" default:"false"`
 }
 
+// 记录字段级变更历史
+func (manager *SSecurityGroupManager) IsHistoryRecorded() bool {
+	return true
+}
+
 // 安全组列表
 func (manager *SSecurityGroupManager) ListItemFilter(
 	ctx context.Context,
@@ -100,6 +106,10 @@ func (manager *SSecurityGroupManager) ListItemFilter(
 		if err != nil {
 			return q, httperrors.NewGeneralError(errors.Wrapf(err, "GetAllowList"))
 		}
+		rules6, err := secgroup.getSecurityRules6()
+		if err != nil {
+			return q, httperrors.NewGeneralError(errors.Wrapf(err, "getSecurityRules6"))
+		}
 		sq := manager.Query().NotEquals("id", secgroup.Id)
 		secgroups := []SSecurityGroup{}
 		err = db.FetchModelObjects(manager, sq, &secgroups)
@@ -115,6 +125,13 @@ func (manager *SSecurityGroupManager) ListItemFilter(
 			if !inAllowList.Equals(_inAllowList) || !outAllowList.Equals(_outAllowList) {
 				continue
 			}
+			_rules6, err := secgroups[i].getSecurityRules6()
+			if err != nil {
+				return nil, httperrors.NewGeneralError(errors.Wrapf(err, "getSecurityRules6"))
+			}
+			if rules6 != _rules6 {
+				continue
+			}
 			secgroupIds = append(secgroupIds, secgroups[i].Id)
 		}
 		q = q.In("id", secgroupIds)
@@ -490,6 +507,10 @@ func (manager *SSecurityGroupManager) ValidateCreateData(
 	input.Status = api.SECGROUP_STATUS_READY
 
 	for i := range input.Rules {
+		err = validateSecgroupRuleAddressGroup(userCred, &input.Rules[i])
+		if err != nil {
+			return input, err
+		}
 		err = input.Rules[i].Check()
 		if err != nil {
 			return input, httperrors.NewInputParameterError("rule %d is invalid: %s", i, err)
@@ -535,6 +556,7 @@ func (self *SSecurityGroup) PostCreate(ctx context.Context, userCred mcclient.To
 			Action:      r.Action,
 			Description: r.Description,
 		}
+		rule.AddressGroupId = r.AddressGroupId
 		rule.SecgroupId = self.Id
 
 		SecurityGroupRuleManager.TableSpec().Insert(ctx, rule)
@@ -568,11 +590,14 @@ func (self *SSecurityGroup) GetSecuritRuleSet() (cloudprovider.SecurityRuleSet,
 	}
 	for i := range rules {
 		//这里没必要拆分为单个单个的端口,到公有云那边适配
-		rule, err := rules[i].toRule()
+		//地址组规则展开为多条CIDR规则, 与云上地址模板展开后的规则一致, 便于对比
+		_rules, err := rules[i].toRules()
 		if err != nil {
-			return nil, errors.Wrapf(err, "toRule")
+			return nil, errors.Wrapf(err, "toRules")
+		}
+		for j := range _rules {
+			ruleSet = append(ruleSet, cloudprovider.SecurityRule{SecurityRule: _rules[j], ExternalId: rules[i].Id})
 		}
-		ruleSet = append(ruleSet, cloudprovider.SecurityRule{SecurityRule: *rule, ExternalId: rules[i].Id})
 	}
 	return ruleSet, nil
 }
@@ -585,25 +610,72 @@ func (self *SSecurityGroup) GetSecRules() ([]secrules.SecurityRule, error) {
 	}
 	for _, _rule := range _rules {
 		//这里没必要拆分为单个单个的端口,到公有云那边适配
-		rule, err := _rule.toRule()
+		_rules, err := _rule.toRules()
 		if err != nil {
-			return nil, errors.Wrapf(err, "toRule")
+			return nil, errors.Wrapf(err, "toRules")
 		}
-		rules = append(rules, *rule)
+		rules = append(rules, _rules...)
 	}
 	return rules, nil
 }
 
-func (self *SSecurityGroup) getSecurityRuleString() (string, error) {
+// GetSecuritRuleSetForProvider 同步到云上的规则, 云上不支持时不包含IPv6规则
+func (self *SSecurityGroup) GetSecuritRuleSetForProvider(driver IRegionDriver) (cloudprovider.SecurityRuleSet, error) {
+	rules, err := self.GetSecuritRuleSet()
+	if err != nil {
+		return nil, err
+	}
+	return filterSecurityRuleSet6(rules, driver.IsSupportSecurityRuleIPv6()), nil
+}
+
+// GetSecRulesForProvider 在云上创建安全组时的规则, 云上不支持时不包含IPv6规则
+func (self *SSecurityGroup) GetSecRulesForProvider(driver IRegionDriver) ([]secrules.SecurityRule, error) {
+	rules, err := self.GetSecRules()
+	if err != nil {
+		return nil, err
+	}
+	return filterSecurityRules6(rules, driver.IsSupportSecurityRuleIPv6()), nil
+}
+
+func (self *SSecurityGroup) getSecurityRuleString() (string, string, error) {
 	secgrouprules, err := self.getSecurityRules()
 	if err != nil {
-		return "", errors.Wrapf(err, "getSecurityRules()")
+		return "", "", errors.Wrapf(err, "getSecurityRules()")
 	}
-	var rules []string
+	var rules, rules6 []string
 	for _, rule := range secgrouprules {
-		rules = append(rules, rule.String())
+		r, r6 := rule.ruleStrings()
+		rules = append(rules, r...)
+		rules6 = append(rules6, r6...)
 	}
-	return strings.Join(rules, SECURITY_GROUP_SEPARATOR), nil
+	return strings.Join(rules, SECURITY_GROUP_SEPARATOR), strings.Join(rules6, SECURITY_GROUP_SEPARATOR), nil
+}
+
+// filterSecurityRules6 云上不支持IPv6规则时去掉IPv6规则, 避免同步失败或反复对比出差异
+func filterSecurityRules6(rules []secrules.SecurityRule, support bool) []secrules.SecurityRule {
+	if support {
+		return rules
+	}
+	ret := []secrules.SecurityRule{}
+	for i := range rules {
+		if !netutils2.IsSecurityRule6(&rules[i]) {
+			ret = append(ret, rules[i])
+		}
+	}
+	return ret
+}
+
+func filterSecurityRuleSet6(rules cloudprovider.SecurityRuleSet, support bool) cloudprovider.SecurityRuleSet {
+	if support {
+		return rules
+	}
+	ret := cloudprovider.SecurityRuleSet{}
+	for i := range rules {
+		if !netutils2.IsSecurityRule6(&rules[i].SecurityRule) {
+			ret = append(ret, rules[i])
+		}
+	}
+	return ret
 }
 
 func totalSecurityGroupCount(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider) (int, error) {
@@ -810,6 +882,10 @@ func (self *SSecurityGroup) PerformMerge(ctx context.Context, userCred mcclient.
 	if err != nil {
 		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "GetAllowList"))
 	}
+	rules6, err := self.getSecurityRules6()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "getSecurityRules6"))
+	}
 	secgroups := []*SSecurityGroup{}
 	for _, secgroupId := range input.SecgroupIds {
 		_secgroup, err := SecurityGroupManager.FetchByIdOrName(userCred, secgroupId)
@@ -831,6 +907,13 @@ func (self *SSecurityGroup) PerformMerge(ctx context.Context, userCred mcclient.
 		if !outAllowList.Equals(_outAllowList) {
 			return nil, httperrors.NewUnsupportOperationError("secgroup %s rules not equals %s rules", secgroup.Name, self.Name)
 		}
+		_rules6, err := secgroup.getSecurityRules6()
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "getSecurityRules6"))
+		}
+		if rules6 != _rules6 {
+			return nil, httperrors.NewUnsupportOperationError("secgroup %s ipv6 rules not equals %s ipv6 rules", secgroup.Name, self.Name)
+		}
 		secgroups = append(secgroups, secgroup)
 	}
 
@@ -851,12 +934,14 @@ func (self *SSecurityGroup) PerformMerge(ctx context.Context, userCred mcclient.
 	return nil, nil
 }
 
+// GetAllowList 只计算IPv4规则, secrules无法对IPv6地址段求交, IPv6规则由getSecurityRules6单独比较
 func (self *SSecurityGroup) GetAllowList() (secrules.SecurityRuleSet, secrules.SecurityRuleSet, error) {
 	in, out := secrules.SecurityRuleSet{*secrules.MustParseSecurityRule("in:deny any")}, secrules.SecurityRuleSet{*secrules.MustParseSecurityRule("out:allow any")}
 	rules, err := self.GetSecRules()
 	if err != nil {
 		return in, out, errors.Wrapf(err, "GetSecRules")
 	}
+	rules = filterSecurityRules6(rules, false)
 	for i := range rules {
 		if rules[i].Direction == secrules.DIR_IN {
 			in = append(in, rules[i])
@@ -867,6 +952,11 @@ func (self *SSecurityGroup) GetAllowList() (secrules.SecurityRuleSet, secrules.S
 	return in.AllowList(), out.AllowList(), nil
 }
 
+func (self *SSecurityGroup) getSecurityRules6() (string, error) {
+	_, rules6, err := self.getSecurityRuleString()
+	return rules6, err
+}
+
 func (self *SSecurityGroup) mergeSecurityGroupCache(secgroup *SSecurityGroup) error {
 	caches, err := secgroup.GetSecurityGroupCaches()
 	if err != nil {
@@ -1254,7 +1344,11 @@ func (self *SSecurityGroup) AllowPerformImportRules(ctx context.Context, userCre
 
 func (self *SSecurityGroup) PerformImportRules(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecgroupImportRulesInput) (jsonutils.JSONObject, error) {
 	for i := range input.Rules {
-		err := input.Rules[i].Check()
+		err := validateSecgroupRuleAddressGroup(userCred, &input.Rules[i])
+		if err != nil {
+			return nil, err
+		}
+		err = input.Rules[i].Check()
 		if err != nil {
 			return nil, httperrors.NewInputParameterError("rule %d is invalid: %s", i+1, err)
 		}
@@ -1269,6 +1363,7 @@ func (self *SSecurityGroup) PerformImportRules(ctx context.Context, userCred mcc
 			Action:      r.Action,
 			Description: r.Description,
 		}
+		rule.AddressGroupId = r.AddressGroupId
 		rule.SecgroupId = self.Id
 
 		err := SecurityGroupRuleManager.TableSpec().Insert(ctx, rule)
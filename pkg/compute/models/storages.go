@@ -32,6 +32,7 @@ import (
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
 	"yunion.io/x/onecloud/pkg/compute/options"
@@ -1104,9 +1105,9 @@ type StoragesCapacityStat struct {
 	CountDetached    int
 }
 
-func (manager *SStorageManager) calculateCapacity(q *sqlchemy.SQuery) StoragesCapacityStat {
+func (manager *SStorageManager) calculateCapacity(ctx context.Context, q *sqlchemy.SQuery) StoragesCapacityStat {
 	stats := make([]StorageStat, 0)
-	err := q.All(&stats)
+	err := replica.All(ctx, q, &stats)
 	if err != nil {
 		log.Errorf("calculateCapacity: %v", err)
 	}
@@ -1152,6 +1153,7 @@ func (manager *SStorageManager) calculateCapacity(q *sqlchemy.SQuery) StoragesCa
 }
 
 func (manager *SStorageManager) TotalCapacity(
+	ctx context.Context,
 	rangeObjs []db.IStandaloneModel,
 	hostTypes []string,
 	resourceTypes []string,
@@ -1162,6 +1164,7 @@ func (manager *SStorageManager) TotalCapacity(
 	storageOwnership bool,
 ) StoragesCapacityStat {
 	res1 := manager.calculateCapacity(
+		ctx,
 		manager.totalCapacityQ(
 			rangeObjs,
 			hostTypes,
@@ -88,6 +88,11 @@ type SVpc struct {
 	ExternalAccessMode string `width:"16" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
 }
 
+// 记录字段级变更历史
+func (manager *SVpcManager) IsHistoryRecorded() bool {
+	return true
+}
+
 func (manager *SVpcManager) GetContextManagers() [][]db.IModelManager {
 	return [][]db.IModelManager{
 		{CloudregionManager},
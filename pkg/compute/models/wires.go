@@ -34,6 +34,7 @@ import (
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -687,6 +688,7 @@ func (wstat WiresCountStat) NicCount() int {
 }
 
 func (manager *SWireManager) TotalCount(
+	ctx context.Context,
 	rangeObjs []db.IStandaloneModel,
 	hostTypes []string,
 	providers []string, brands []string, cloudEnv string,
@@ -694,12 +696,13 @@ func (manager *SWireManager) TotalCount(
 	ownerId mcclient.IIdentityProvider,
 ) WiresCountStat {
 	stat := WiresCountStat{}
-	err := manager.totalCountQ(
+	q := manager.totalCountQ(
 		rangeObjs,
 		hostTypes,
 		providers, brands, cloudEnv,
 		scope, ownerId,
-	).First(&stat)
+	)
+	err := replica.First(ctx, q, &stat)
 	if err != nil {
 		log.Errorf("Wire total count: %v", err)
 	}
@@ -140,12 +140,27 @@ type ComputeOptions struct {
 	EnableHostHealthCheck bool `help:"enable host health check" default:"true"`
 	HostHealthTimeout     int  `help:"second of wait host reconnect" default:"60"`
 
+	HostFencePolicy         string `help:"default fencing policy before evacuating servers of a down host, can be overridden per host; only BMC power off is supported, storage level fencing such as RBD blocklist is not" default:"none" choices:"none|power_off"`
+	HostFenceTimeoutSeconds int    `help:"seconds to wait for BMC to confirm host is powered off" default:"120"`
+
 	FetchEtcdServiceInfoAndUseEtcdLock bool `default:"true" help:"fetch etcd service info and use etcd lock"`
 
 	GuestTemplateCheckInterval int `help:"interval between two consecutive inspections of Guest Template in hour unit" default:"12"`
 
 	ScheduledTaskQueueSize int `help:"the maximum number of scheduled tasks that are being executed simultaneously" default:"100"`
 
+	TaskStageTimeoutCheckSeconds  int `help:"interval to check tasks whose stage deadline has passed" default:"60"`
+	TaskRecoverGracePeriodMinutes int `help:"open tasks not updated within this period are considered left by a dead process and recovered by the leader on start" default:"60"`
+	TaskDefaultStageTimeoutHours  int `help:"stages of tasks without explicit timeout fail after this period, 0 means never" default:"24"`
+	TaskRecoverLookbackHours      int `help:"open tasks not updated within this period are not recovered" default:"24"`
+	GuestStartTimeoutMinutes      int `help:"start of server not confirmed by host in this period is considered failed" default:"30"`
+	GuestDeployTimeoutMinutes     int `help:"deploy of server not confirmed by host in this period is considered failed" default:"60"`
+
+	WebhookDeliveryIntervalSeconds int `help:"interval to send queued webhook events" default:"5"`
+	WebhookDeliveryRetentionDays   int `help:"days to keep history of succeeded webhook deliveries, 0 to keep forever" default:"7"`
+
+	EnableOpsLogHistory bool `help:"record field level changes of servers, hosts, disks and networks to serve history and diff queries" default:"true"`
+
 	ReconcileGuestBackupIntervalSeconds int `help:"interval reconcile guest bakcups" default:"30"`
 
 	EnableAutoRenameProject bool `help:"when it set true, auto create project will rename when cloud project name changed" default:"false"`
@@ -156,6 +171,9 @@ type ComputeOptions struct {
 	SyncExtDiskSnapshotIntervalMinutes int  `help:"sync snapshot for external disk" default:"20"`
 	AutoReconcileBackupServers         bool `help:"auto reconcile backup servers" default:"false"`
 
+	RebalancerCheckIntervalSeconds   int `help:"interval to evaluate host rebalancers and start approved rebalance migrations" default:"60"`
+	RebalanceMigrationTimeoutMinutes int `help:"rebalance migration not finished in this period is considered failed" default:"120"`
+
 	SCapabilityOptions
 	SASControllerOptions
 	common_options.CommonOptions
@@ -182,10 +200,11 @@ type SCapabilityOptions struct {
 }
 
 type SASControllerOptions struct {
-	TimerInterval       int `help:"The interval between the tow checks about timer, unit: s" default:"60"`
-	ConcurrentUpper     int `help:"This represents the upper limit of concurrent sacling sctivities" default:"500"`
-	CheckScaleInterval  int `help:"The interval between the two checks about scaling, unit: s" default:"60"`
-	CheckHealthInterval int `help:"The interval bewteen the two check about instance's health unit: m" default:"1"`
+	TimerInterval               int `help:"The interval between the tow checks about timer, unit: s" default:"60"`
+	ConcurrentUpper             int `help:"This represents the upper limit of concurrent sacling sctivities" default:"500"`
+	CheckScaleInterval          int `help:"The interval between the two checks about scaling, unit: s" default:"60"`
+	CheckHealthInterval         int `help:"The interval bewteen the two check about instance's health unit: m" default:"1"`
+	CheckTargetTrackingInterval int `help:"The interval between the two checks about target tracking policies, unit: s" default:"30"`
 }
 
 var (
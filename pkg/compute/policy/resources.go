@@ -35,6 +35,8 @@ var (
 		// "reservedips",
 		"policy_definitions",
 		"schedtags",
+		"webhooks",
+		"webhook_deliveries",
 	}
 	computeDomainResources = []string{
 		"cloudaccounts",
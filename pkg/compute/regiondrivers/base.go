@@ -235,6 +235,10 @@ func (self *SBaseRegionDriver) IsSupportClassicSecurityGroup() bool {
 	return false
 }
 
+func (self *SBaseRegionDriver) IsSupportSecurityRuleIPv6() bool {
+	return false
+}
+
 func (self *SBaseRegionDriver) IsSecurityGroupBelongVpc() bool {
 	return false
 }
@@ -68,6 +68,11 @@ func (self *SKVMRegionDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_ONECLOUD
 }
 
+// IsSupportSecurityRuleIPv6 本地IPv6规则由vpcagent渲染为OVN ACL
+func (self *SKVMRegionDriver) IsSupportSecurityRuleIPv6() bool {
+	return true
+}
+
 func GetDefaultSecurityGroupInRule() cloudprovider.SecurityRule {
 	return cloudprovider.SecurityRule{SecurityRule: *secrules.MustParseSecurityRule("in:deny any")}
 }
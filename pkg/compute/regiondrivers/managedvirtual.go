@@ -1656,9 +1656,9 @@ func (self *SManagedVirtualizationRegionDriver) RequestSyncSecurityGroup(ctx con
 					VpcId:     vpcId,
 					ProjectId: remoteProjectId,
 				}
-				conf.Rules, err = secgroup.GetSecRules()
+				conf.Rules, err = secgroup.GetSecRulesForProvider(region.GetDriver())
 				if err != nil {
-					return errors.Wrapf(err, "GetSecRules")
+					return errors.Wrapf(err, "GetSecRulesForProvider")
 				}
 				iSecgroup, err = iRegion.CreateISecurityGroup(conf)
 				if err != nil {
@@ -1682,9 +1682,9 @@ func (self *SManagedVirtualizationRegionDriver) RequestSyncSecurityGroup(ctx con
 				return errors.Wrapf(err, "iSecgroup.GetRules")
 			}
 
-			localRules, err := secgroup.GetSecuritRuleSet()
+			localRules, err := secgroup.GetSecuritRuleSetForProvider(region.GetDriver())
 			if err != nil {
-				return errors.Wrapf(err, "GetSecuritRuleSet")
+				return errors.Wrapf(err, "GetSecuritRuleSetForProvider")
 			}
 
 			src := cloudprovider.NewSecRuleInfo(&SKVMRegionDriver{})
@@ -21,6 +21,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/proxy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/webhook"
 	"yunion.io/x/onecloud/pkg/compute/capabilities"
 	"yunion.io/x/onecloud/pkg/compute/misc"
 	"yunion.io/x/onecloud/pkg/compute/models"
@@ -58,6 +59,7 @@ func InitHandlers(app *appsrv.Application) {
 		db.TenantCacheManager,
 		db.SharedResourceManager,
 		db.I18nManager,
+		db.OpsLogDiff,
 		models.GuestcdromManager,
 		models.NetInterfaceManager,
 		models.VCenterManager,
@@ -87,6 +89,7 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.ScalingTimerManager,
 		models.ScalingAlarmManager,
+		models.ScalingTargetTrackingManager,
 		models.ScalingGroupGuestManager,
 		models.ScalingGroupNetworkManager,
 
@@ -103,6 +106,9 @@ func InitHandlers(app *appsrv.Application) {
 
 		proxy.ProxySettingManager,
 
+		webhook.WebhookManager,
+		webhook.WebhookDeliveryManager,
+
 		models.BucketManager,
 		models.CloudaccountManager,
 		models.CloudproviderManager,
@@ -126,6 +132,8 @@ func InitHandlers(app *appsrv.Application) {
 		models.SecurityGroupManager,
 		models.SecurityGroupCacheManager,
 		models.SecurityGroupRuleManager,
+		models.AddressGroupManager,
+		models.FlowLogManager,
 		// models.VCenterManager,
 		models.DnsRecordManager,
 		models.ElasticipManager,
@@ -163,6 +171,8 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.SchedpolicyManager,
 		models.DynamicschedtagManager,
+		models.RebalancerManager,
+		models.RebalanceMigrationManager,
 
 		models.ServerSkuManager,
 		models.ExternalProjectManager,
@@ -190,6 +200,7 @@ func InitHandlers(app *appsrv.Application) {
 		models.ScalingGroupManager,
 		models.ScalingPolicyManager,
 		models.ScalingActivityManager,
+		models.ScalingLifecycleHookManager,
 		models.PolicyDefinitionManager,
 		models.PolicyAssignmentManager,
 
@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -31,6 +32,8 @@ import (
 	app_common "yunion.io/x/onecloud/pkg/cloudcommon/app"
 	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/webhook"
 	"yunion.io/x/onecloud/pkg/cloudcommon/elect"
 	"yunion.io/x/onecloud/pkg/cloudcommon/etcd"
 	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
@@ -89,6 +92,14 @@ func StartService() {
 
 	models.InitSyncWorkers(options.Options.CloudSyncWorkerCount)
 
+	webhook.Init()
+
+	taskman.SetDefaultStageTimeout(time.Duration(opts.TaskDefaultStageTimeoutHours) * time.Hour)
+
+	if opts.EnableOpsLogHistory {
+		db.EnableOpsLogHistory()
+	}
+
 	var (
 		electObj        *elect.Elect
 		ctx, cancelFunc = context.WithCancel(context.Background())
@@ -158,8 +169,14 @@ func StartService() {
 
 		cron.AddJobEveryFewHour("InspectAllTemplate", 1, 0, 0, models.GuestTemplateManager.InspectAllTemplate, true)
 
+		cron.AddJobAtIntervals("RebalanceHosts", time.Duration(opts.RebalancerCheckIntervalSeconds)*time.Second, models.RebalancerManager.RebalanceHosts)
 		cron.AddJobAtIntervalsWithStartRun("ScheduledTaskCheck", time.Duration(60)*time.Second, models.ScheduledTaskManager.Timer, true)
+
+		cron.AddJobAtIntervals("CheckTaskStageTimeout", time.Duration(opts.TaskStageTimeoutCheckSeconds)*time.Second, taskman.TaskManager.CheckStageTimeout)
+		cron.AddJobAtIntervals("DeliverWebhookEvents", time.Duration(opts.WebhookDeliveryIntervalSeconds)*time.Second, webhook.DeliverWebhookEvents)
+		cron.AddJobEveryFewHour("CleanupWebhookDeliveries", 1, 45, 0, webhook.CleanupWebhookDeliveries(opts.WebhookDeliveryRetentionDays), false)
 		go cron.Start2(ctx, electObj)
+		recoverTasksOnStart(ctx, electObj, opts)
 
 		// init auto scaling controller
 		autoscaling.ASController.Init(options.Options.SASControllerOptions, cron)
@@ -168,6 +185,23 @@ func StartService() {
 	app_common.ServeForever(app, baseOpts)
 }
 
+// recoverTasksOnStart 仅在启动后首次成为leader时恢复遗留任务, 避免重复执行存活进程中的任务
+func recoverTasksOnStart(ctx context.Context, electObj *elect.Elect, opts *options.ComputeOptions) {
+	var once sync.Once
+	onWin := func() {
+		once.Do(func() {
+			go taskman.TaskManager.RecoverTasks(ctx,
+				time.Duration(opts.TaskRecoverGracePeriodMinutes)*time.Minute,
+				time.Duration(opts.TaskRecoverLookbackHours)*time.Hour)
+		})
+	}
+	if electObj == nil {
+		onWin()
+		return
+	}
+	electObj.SubscribeWithAction(ctx, onWin, func() {})
+}
+
 func initDefaultEtcdClient(opts *common_options.DBOptions) error {
 	if etcd.Default() != nil {
 		return nil
@@ -19,9 +19,12 @@ import (
 
 	"yunion.io/x/jsonutils"
 
+	notifyapi "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/notify"
 )
 
 type SGuestBaseTask struct {
@@ -38,6 +41,11 @@ func (self *SGuestBaseTask) SetStageFailed(ctx context.Context, reason jsonutils
 	self.STask.SetStageFailed(ctx, reason)
 }
 
+// publishGuestTaskFailure 向订阅了虚拟机失败事件的主题发布任务失败原因
+func publishGuestTaskFailure(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	notifyclient.PublishResourceEvent(ctx, guest, notifyapi.TOPIC_EVENT_CLASS_FAILURE, notify.NotifyPriorityImportant, reason.String())
+}
+
 func (self *SGuestBaseTask) finalReleasePendingUsage(ctx context.Context) {
 	pendingUsage := models.SQuota{}
 	err := self.GetPendingUsage(&pendingUsage, 0)
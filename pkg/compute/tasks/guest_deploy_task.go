@@ -17,6 +17,7 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -25,6 +26,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 )
 
@@ -54,7 +56,7 @@ func (self *GuestDeployTask) OnGuestNetworkReady(ctx context.Context, guest *mod
 }
 
 func (self *GuestDeployTask) OnDeployWaitServerStop(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
-	self.SetStage("OnDeployGuestComplete", nil)
+	self.SetStageWithTimeout("OnDeployGuestComplete", nil, time.Duration(options.Options.GuestDeployTimeoutMinutes)*time.Minute)
 	targetHostId, _ := self.Params.GetString("target_host_id")
 	if len(targetHostId) == 0 {
 		targetHostId = guest.HostId
@@ -142,6 +144,13 @@ func (self *GuestDeployTask) OnDeployGuestCompleteFailed(ctx context.Context, ob
 	db.OpsLog.LogEvent(guest, db.ACT_VM_DEPLOY_FAIL, data, self.UserCred)
 }
 
+// OnTimeout 宿主机未回调时标记失败，并同步一次真实状态
+func (self *GuestDeployTask) OnTimeout(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	self.OnDeployGuestCompleteFailed(ctx, guest, data)
+	guest.StartSyncstatus(ctx, self.UserCred, "")
+}
+
 func (self *GuestDeployTask) OnDeployStartGuestComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	self.SetStageComplete(ctx, nil)
 }
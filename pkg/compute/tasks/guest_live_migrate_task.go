@@ -477,6 +477,7 @@ func (self *GuestMigrateTask) TaskFailed(ctx context.Context, guest *models.SGue
 	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_MIGRATE, reason, self.UserCred, false)
 	self.SetStageFailed(ctx, reason)
 	notifyclient.NotifySystemErrorWithCtx(ctx, guest.Id, guest.Name, api.VM_MIGRATE_FAILED, reason.String())
+	publishGuestTaskFailure(ctx, guest, reason)
 }
 
 //ManagedGuestMigrateTask
@@ -529,6 +530,7 @@ func (self *ManagedGuestMigrateTask) OnMigrateCompleteFailed(ctx context.Context
 	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_MIGRATE, data, self.UserCred, false)
 	self.SetStageFailed(ctx, data)
 	notifyclient.NotifySystemErrorWithCtx(ctx, guest.Id, guest.Name, api.VM_MIGRATE_FAILED, data.String())
+	publishGuestTaskFailure(ctx, guest, data)
 }
 
 //ManagedGuestLiveMigrateTask
@@ -567,4 +569,5 @@ func (self *ManagedGuestLiveMigrateTask) OnMigrateCompleteFailed(ctx context.Con
 	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_MIGRATE, data, self.UserCred, false)
 	self.SetStageFailed(ctx, data)
 	notifyclient.NotifySystemErrorWithCtx(ctx, guest.Id, guest.Name, api.VM_MIGRATE_FAILED, data.String())
+	publishGuestTaskFailure(ctx, guest, data)
 }
@@ -16,6 +16,7 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"yunion.io/x/jsonutils"
 
@@ -23,6 +24,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 )
 
@@ -42,7 +44,7 @@ func (self *GuestStartTask) OnInit(ctx context.Context, obj db.IStandaloneModel,
 }
 
 func (self *GuestStartTask) RequestStart(ctx context.Context, guest *models.SGuest) {
-	self.SetStage("OnStartComplete", nil)
+	self.SetStageWithTimeout("OnStartComplete", nil, time.Duration(options.Options.GuestStartTimeoutMinutes)*time.Minute)
 	host := guest.GetHost()
 	guest.SetStatus(self.UserCred, api.VM_STARTING, "")
 	result, err := guest.GetDriver().RequestStartOnHost(ctx, guest, host, self.UserCred, self)
@@ -81,6 +83,14 @@ func (self *GuestStartTask) OnStartCompleteFailed(ctx context.Context, obj db.IS
 	db.OpsLog.LogEvent(guest, db.ACT_START_FAIL, err, self.UserCred)
 	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_VM_START, err, self.UserCred, false)
 	self.SetStageFailed(ctx, err)
+	publishGuestTaskFailure(ctx, guest, err)
+}
+
+// OnTimeout 宿主机未回调时标记失败，并同步一次真实状态
+func (self *GuestStartTask) OnTimeout(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	self.OnStartCompleteFailed(ctx, guest, data)
+	guest.StartSyncstatus(ctx, self.UserCred, "")
 }
 
 func (self *GuestStartTask) taskComplete(ctx context.Context, guest *models.SGuest) {
@@ -135,4 +145,5 @@ func (self *GuestSchedStartTask) TaskFailed(ctx context.Context, guest *models.S
 	db.OpsLog.LogEvent(guest, db.ACT_START_FAIL, reason, self.UserCred)
 	logclient.AddActionLogWithStartable(
 		self, guest, logclient.ACT_VM_START, reason, self.UserCred, false)
+	publishGuestTaskFailure(ctx, guest, reason)
 }
@@ -22,10 +22,13 @@ import (
 
 	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	notifyapi "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
 	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/notify"
 )
 
 type GuestSyncstatusTask struct {
@@ -82,7 +85,12 @@ func (self *GuestSyncstatusTask) OnGetStatusSucc(ctx context.Context, guest *mod
 }
 
 func (self *GuestSyncstatusTask) OnGetStatusFail(ctx context.Context, guest *models.SGuest, err error) {
+	// 只在首次同步失败时发布, 避免周期同步重复通知
+	firstFailure := guest.Status != api.VM_UNKNOWN
 	guest.SetStatus(self.UserCred, api.VM_UNKNOWN, err.Error())
 	self.SetStageComplete(ctx, nil)
+	if firstFailure {
+		notifyclient.PublishResourceEvent(ctx, guest, notifyapi.TOPIC_EVENT_CLASS_SYNC_FAILURE, notify.NotifyPriorityNormal, err.Error())
+	}
 	// logclient.AddActionLog(guest, logclient.ACT_VM_SYNC_STATUS, err, self.UserCred, false)
 }
@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// HostFenceTask 宿主机失联后先隔离宿主机, 确认隔离成功后再切换备机与迁移虚拟机
+type HostFenceTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(HostFenceTask{})
+}
+
+func (self *HostFenceTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	host := obj.(*models.SHost)
+	policy, _ := self.Params.GetString("fence_policy")
+
+	db.OpsLog.LogEvent(host, db.ACT_HOST_FENCE, "start fencing with policy "+policy, self.UserCred)
+	self.SetStage("OnHostFenced", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		err := host.Fence(ctx, policy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fence with policy %s", policy)
+		}
+		return nil, nil
+	})
+}
+
+func (self *HostFenceTask) OnHostFenced(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	policy, _ := self.Params.GetString("fence_policy")
+	msg := "host fenced with policy " + policy
+	db.OpsLog.LogEvent(host, db.ACT_HOST_FENCE, msg, self.UserCred)
+	logclient.AddActionLogWithStartable(self, host, logclient.ACT_HOST_FENCE, msg, self.UserCred, true)
+	host.OnHostFenced(ctx, self.UserCred, host)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *HostFenceTask) OnHostFencedFailed(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	host.OnHostFenceFailed(ctx, self.UserCred, errors.Error(data.String()))
+	self.SetStageFailed(ctx, data)
+}
@@ -89,6 +89,7 @@ func (asc *SASController) Init(options options.SASControllerOptions, cronm *cron
 	cronm.AddJobAtIntervalsWithStartRun("CheckTimer", time.Duration(options.TimerInterval)*time.Second, asc.Timer, true)
 	cronm.AddJobAtIntervalsWithStartRun("CheckScale", time.Duration(options.CheckScaleInterval)*time.Second, asc.CheckScale, true)
 	cronm.AddJobAtIntervalsWithStartRun("CheckInstanceHealth", time.Duration(options.CheckHealthInterval)*time.Minute, asc.CheckInstanceHealth, true)
+	cronm.AddJobAtIntervalsWithStartRun("CheckTargetTracking", time.Duration(options.CheckTargetTrackingInterval)*time.Second, asc.CheckTargetTracking, false)
 	asc.timerQueue = make(chan struct{}, 20)
 	asc.scalingQueue = make(chan struct{}, options.ConcurrentUpper)
 	asc.scalingGroupSet = &SLockedSet{set: sets.NewString()}
@@ -117,6 +118,7 @@ func (asc *SASController) Init(options options.SASControllerOptions, cronm *cron
 		sas[i].SetFailed("", "As the service restarts, the status becomes unknown")
 	}
 	log.Infof("check and update scalngactivities complete")
+	go asc.resumeLifecycleWait(context.Background())
 }
 
 func (asc *SASController) PreScale(group *models.SScalingGroup, userCred mcclient.TokenCredential) bool {
@@ -327,6 +329,12 @@ func (asc *SASController) DetachInstances(ctx context.Context, userCred mcclient
 	failedList := make([]string, 0)
 	waitList := make([]string, 0, len(instances))
 	instanceMap := make(map[string]SInstance, len(instances))
+	// wait for lifecycle hook, the instances are removed whatever the result is
+	hookInstances := make([]SInstance, len(instances))
+	for i := range instances {
+		hookInstances[i] = SInstance{instances[i].Id, instances[i].Name}
+	}
+	asc.waitLifecycle(ctx, sg, compute.LIFECYCLE_TRANSITION_SCALE_IN, hookInstances)
 	// request to detach instances with scaling group
 	for i := range instances {
 		instanceMap[instances[i].Id] = SInstance{instances[i].Id, instances[i].Name}
@@ -640,35 +648,8 @@ func (asc *SASController) actionAfterCreate(
 	failRecord *SFailRecord,
 ) (succeed bool) {
 	log.Debugf("start to action After create")
-	deleteParams := jsonutils.NewDict()
-	deleteParams.Set("override_pending_delete", jsonutils.JSONTrue)
-	updateParams := jsonutils.NewDict()
-	updateParams.Set("disable_delete", jsonutils.JSONFalse)
 	rollback := func(failedReason string) {
-		failRecord.Append(failedReason)
-		// get scalingguest
-		sggs, err := models.ScalingGroupGuestManager.Fetch(sg.GetId(), ret.Id)
-		if err != nil || len(sggs) == 0 {
-			log.Errorf("ScalingGroupGuestManager.Fetch failed: %s", err.Error())
-			return
-		}
-		// cancel delete project
-		_, e := modules.Servers.Update(session, ret.Id, updateParams)
-		if err != nil {
-			sggs[0].SetGuestStatus(compute.SG_GUEST_STATUS_READY)
-			log.Errorf("cancel delete project of instance '%s' failed: %s", ret.Id, e.Error())
-			return
-		}
-		// delete corresponding instance
-		_, e = modules.Servers.Delete(session, ret.Id, deleteParams)
-		if e != nil {
-			// delete failed
-			sggs[0].SetGuestStatus(compute.SG_GUEST_STATUS_READY)
-			log.Errorf("delete instance '%s' failed: %s", ret.Id, e.Error())
-			return
-		}
-		sggs[0].Detach(ctx, userCred)
-		return
+		asc.rollbackCreate(ctx, userCred, session, sg, ret.Id, failRecord, failedReason)
 	}
 	if ret.Status != compute.VM_RUNNING {
 		if ret.Status == "timeout" {
@@ -699,31 +680,91 @@ func (asc *SASController) actionAfterCreate(
 		}
 		return
 	}
+	// wait for lifecycle hook before the instance serves
+	results := asc.waitLifecycle(ctx, sg, compute.LIFECYCLE_TRANSITION_SCALE_OUT, []SInstance{{ID: ret.Id}})
+	return asc.joinAfterLifecycle(ctx, userCred, session, sg, ret.Id, results[ret.Id], failRecord)
+}
+
+// joinAfterLifecycle finishes joining the instance into scaling group after its scale out lifecycle wait,
+// the instance is rolled back if the lifecycle action was abandoned.
+func (asc *SASController) joinAfterLifecycle(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	session *mcclient.ClientSession,
+	sg *models.SScalingGroup,
+	guestId string,
+	result string,
+	failRecord *SFailRecord,
+) bool {
+	if result == compute.LIFECYCLE_RESULT_ABANDON {
+		asc.rollbackCreate(ctx, userCred, session, sg, guestId, failRecord, fmt.Sprintf("the lifecycle action for instance '%s' was abandoned", guestId))
+		return false
+	}
 	// bind lb
 	if len(sg.BackendGroupId) != 0 {
 		params := jsonutils.NewDict()
-		params.Set("backend", jsonutils.NewString(ret.Id))
+		params.Set("backend", jsonutils.NewString(guestId))
 		params.Set("backend_type", jsonutils.NewString("guest"))
 		params.Set("port", jsonutils.NewInt(int64(sg.LoadbalancerBackendPort)))
 		params.Set("weight", jsonutils.NewInt(int64(sg.LoadbalancerBackendWeight)))
 		params.Set("backend_group", jsonutils.NewString(sg.BackendGroupId))
 		_, err := modules.LoadbalancerBackends.Create(session, params)
 		if err != nil {
-			rollback(fmt.Sprintf("bind instance '%s' to loadbalancer backend gropu '%s' failed: %s", ret.Id, sg.BackendGroupId, err.Error()))
+			asc.rollbackCreate(ctx, userCred, session, sg, guestId, failRecord, fmt.Sprintf("bind instance '%s' to loadbalancer backend gropu '%s' failed: %s", guestId, sg.BackendGroupId, err.Error()))
+			return false
 		}
 	}
 	// todo bind bd
 
 	// fifth stage: join scaling group finished
-	sggs, err := models.ScalingGroupGuestManager.Fetch(sg.GetId(), ret.Id)
+	sggs, err := models.ScalingGroupGuestManager.Fetch(sg.GetId(), guestId)
 	if err != nil || sggs == nil || len(sggs) == 0 {
-		log.Errorf("ScalingGroupGuestManager.Fetch failed; ScalingGroup '%s', Guest '%s'", sg.Id, ret.Id)
-		return
+		log.Errorf("ScalingGroupGuestManager.Fetch failed; ScalingGroup '%s', Guest '%s'", sg.Id, guestId)
+		return false
 	}
 	sggs[0].SetGuestStatus(compute.SG_GUEST_STATUS_READY)
 	return true
 }
 
+// rollbackCreate deletes the instance created by scaling group and records the failed reason
+func (asc *SASController) rollbackCreate(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	session *mcclient.ClientSession,
+	sg *models.SScalingGroup,
+	guestId string,
+	failRecord *SFailRecord,
+	failedReason string,
+) {
+	failRecord.Append(failedReason)
+	// get scalingguest
+	sggs, err := models.ScalingGroupGuestManager.Fetch(sg.GetId(), guestId)
+	if err != nil || len(sggs) == 0 {
+		log.Errorf("ScalingGroupGuestManager.Fetch failed: %v", err)
+		return
+	}
+	// cancel delete project
+	updateParams := jsonutils.NewDict()
+	updateParams.Set("disable_delete", jsonutils.JSONFalse)
+	_, err = modules.Servers.Update(session, guestId, updateParams)
+	if err != nil {
+		sggs[0].SetGuestStatus(compute.SG_GUEST_STATUS_READY)
+		log.Errorf("cancel delete project of instance '%s' failed: %s", guestId, err.Error())
+		return
+	}
+	// delete corresponding instance
+	deleteParams := jsonutils.NewDict()
+	deleteParams.Set("override_pending_delete", jsonutils.JSONTrue)
+	_, err = modules.Servers.Delete(session, guestId, deleteParams)
+	if err != nil {
+		// delete failed
+		sggs[0].SetGuestStatus(compute.SG_GUEST_STATUS_READY)
+		log.Errorf("delete instance '%s' failed: %s", guestId, err.Error())
+		return
+	}
+	sggs[0].Detach(ctx, userCred)
+}
+
 func (asc *SASController) randStringRunes(n int) string {
 	var letterRunes = []rune("abcdefghijklmnopqrstuvwxyz1234567890")
 	b := make([]rune, n)
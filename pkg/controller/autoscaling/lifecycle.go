@@ -0,0 +1,254 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+const lifecycleCheckInterval = 5 * time.Second
+
+// sLifecycleWaiter polls the instances in pending:wait state until their lifecycle actions are completed or timeout
+type sLifecycleWaiter struct {
+	hook       *models.SScalingLifecycleHook
+	nextStatus string
+	interval   time.Duration
+	// fetch returns the ScalingGroupGuests of guestIds which are still in the scaling group
+	fetch func(guestIds []string) ([]models.SScalingGroupGuest, error)
+	// leave releases the ScalingGroupGuest from pending:wait state and sets its guest status to status
+	leave func(sgg *models.SScalingGroupGuest, status string) error
+}
+
+func newLifecycleWaiter(sg *models.SScalingGroup, hook *models.SScalingLifecycleHook, nextStatus string) *sLifecycleWaiter {
+	return &sLifecycleWaiter{
+		hook:       hook,
+		nextStatus: nextStatus,
+		interval:   lifecycleCheckInterval,
+		fetch:      sg.ScalingGroupGuests,
+		leave: func(sgg *models.SScalingGroupGuest, status string) error {
+			return sgg.LeaveLifecycleWait(status)
+		},
+	}
+}
+
+// wait blocks until all guests in waiting leave pending:wait state and records their lifecycle results.
+// If ctx is done before that, the guests whose lifecycle actions are not completed take the default result of hook.
+func (w *sLifecycleWaiter) wait(ctx context.Context, waiting map[string]bool, results map[string]string) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for len(waiting) > 0 {
+		select {
+		case <-ctx.Done():
+			log.Warningf("lifecycle wait of hook '%s' is canceled: %s", w.hook.Id, ctx.Err())
+			w.check(waiting, results, true)
+			for id := range waiting {
+				results[id] = w.hook.DefaultResult
+			}
+			return
+		case <-ticker.C:
+			w.check(waiting, results, false)
+		}
+	}
+}
+
+// check releases the guests whose lifecycle actions are completed or timeout. If expire is true,
+// all guests are released and those not completed take the default result of hook.
+func (w *sLifecycleWaiter) check(waiting map[string]bool, results map[string]string, expire bool) {
+	ids := make([]string, 0, len(waiting))
+	for id := range waiting {
+		ids = append(ids, id)
+	}
+	sggs, err := w.fetch(ids)
+	if err != nil {
+		log.Errorf("ScalingGroup.ScalingGroupGuests error: %s", err)
+		return
+	}
+	found := make(map[string]bool, len(sggs))
+	now := time.Now()
+	for i := range sggs {
+		sgg := &sggs[i]
+		found[sgg.GuestId] = true
+		result := sgg.LifecycleResult
+		if len(result) == 0 {
+			if !expire && sgg.LifecycleDeadline.After(now) {
+				continue
+			}
+			log.Infof("lifecycle hook of guest '%s' timeout, use default result %s", sgg.GuestId, w.hook.DefaultResult)
+			result = w.hook.DefaultResult
+		}
+		err := w.leave(sgg, w.nextStatus)
+		if err != nil {
+			log.Errorf("guest '%s' leave lifecycle wait: %s", sgg.GuestId, err)
+			continue
+		}
+		results[sgg.GuestId] = result
+		delete(waiting, sgg.GuestId)
+	}
+	// the guest has been removed from scaling group during waiting
+	for _, id := range ids {
+		if !found[id] {
+			results[id] = compute.LIFECYCLE_RESULT_ABANDON
+			delete(waiting, id)
+		}
+	}
+}
+
+// waitLifecycle puts instances into pending:wait state if the scaling group has a lifecycle hook for transition,
+// and waits until the lifecycle actions are completed or timeout. It returns the lifecycle result of each instance.
+func (asc *SASController) waitLifecycle(ctx context.Context, sg *models.SScalingGroup, transition string,
+	instances []SInstance) map[string]string {
+	results := make(map[string]string, len(instances))
+	for _, ins := range instances {
+		results[ins.ID] = compute.LIFECYCLE_RESULT_CONTINUE
+	}
+	hook, err := sg.LifecycleHook(transition)
+	if err != nil {
+		log.Errorf("fetch lifecycle hook of ScalingGroup '%s': %s", sg.Id, err)
+		return results
+	}
+	if hook == nil {
+		return results
+	}
+	nextStatus := lifecycleNextStatus(transition)
+
+	ids := make([]string, 0, len(instances))
+	names := make(map[string]string, len(instances))
+	for _, ins := range instances {
+		ids = append(ids, ins.ID)
+		names[ins.ID] = ins.Name
+	}
+	sggs, err := sg.ScalingGroupGuests(ids)
+	if err != nil {
+		log.Errorf("ScalingGroup.ScalingGroupGuests error: %s", err)
+		return results
+	}
+	waiting := make(map[string]bool, len(sggs))
+	for i := range sggs {
+		err := sggs[i].EnterLifecycleWait(hook)
+		if err != nil {
+			log.Errorf("guest '%s' enter lifecycle wait: %s", sggs[i].GuestId, err)
+			continue
+		}
+		waiting[sggs[i].GuestId] = true
+		hook.Notify(ctx, sg, &sggs[i], asc.guestName(sggs[i].GuestId, names))
+	}
+	newLifecycleWaiter(sg, hook, nextStatus).wait(ctx, waiting, results)
+	return results
+}
+
+func (asc *SASController) guestName(id string, names map[string]string) string {
+	if name := names[id]; len(name) > 0 {
+		return name
+	}
+	guest, err := models.GuestManager.FetchById(id)
+	if err != nil {
+		return ""
+	}
+	return guest.GetName()
+}
+
+// lifecycleNextStatus returns the status of the instance after its lifecycle wait completes,
+// a resumed wait uses the same one so that the instance goes through the same post-wait steps as a fresh one.
+func lifecycleNextStatus(transition string) string {
+	if transition == compute.LIFECYCLE_TRANSITION_SCALE_IN {
+		return compute.SG_GUEST_STATUS_READY
+	}
+	return compute.SG_GUEST_STATUS_JOINING
+}
+
+// resumeLifecycleWait resumes the lifecycle waits left in pending:wait state by the last run.
+// The waits keep their original deadlines, so those expired during the restart time out at once.
+func (asc *SASController) resumeLifecycleWait(ctx context.Context) {
+	sggs := make([]models.SScalingGroupGuest, 0)
+	q := models.ScalingGroupGuestManager.Query().Equals("guest_status", compute.SG_GUEST_STATUS_PENDING_WAIT)
+	err := db.FetchModelObjects(models.ScalingGroupGuestManager, q, &sggs)
+	if err != nil {
+		log.Errorf("unable to fetch scaling group guests in %s state: %s", compute.SG_GUEST_STATUS_PENDING_WAIT, err)
+		return
+	}
+	hookGuests := make(map[string][]models.SScalingGroupGuest)
+	for i := range sggs {
+		hookGuests[sggs[i].LifecycleHookId] = append(hookGuests[sggs[i].LifecycleHookId], sggs[i])
+	}
+	for hookId, sggs := range hookGuests {
+		go asc.resumeLifecycleHook(ctx, hookId, sggs)
+	}
+}
+
+func (asc *SASController) resumeLifecycleHook(ctx context.Context, hookId string, sggs []models.SScalingGroupGuest) {
+	model, err := models.ScalingLifecycleHookManager.FetchById(hookId)
+	if err != nil {
+		// the hook has been deleted, nothing to wait for
+		log.Errorf("fetch lifecycle hook '%s': %s, release its waiting guests", hookId, err)
+		for i := range sggs {
+			err := sggs[i].LeaveLifecycleWait(compute.SG_GUEST_STATUS_READY)
+			if err != nil {
+				log.Errorf("guest '%s' leave lifecycle wait: %s", sggs[i].GuestId, err)
+			}
+		}
+		return
+	}
+	hook := model.(*models.SScalingLifecycleHook)
+	sg := hook.GetScalingGroup()
+	if sg == nil {
+		log.Errorf("unable to fetch ScalingGroup of lifecycle hook '%s'", hookId)
+		return
+	}
+	if asc.scalingGroupSet.CheckAndInsert(sg.Id) {
+		defer asc.scalingGroupSet.Delete(sg.Id)
+	}
+
+	waiting := make(map[string]bool, len(sggs))
+	results := make(map[string]string, len(sggs))
+	for i := range sggs {
+		waiting[sggs[i].GuestId] = true
+	}
+	newLifecycleWaiter(sg, hook, lifecycleNextStatus(hook.Transition)).wait(ctx, waiting, results)
+
+	userCred := auth.AdminCredential()
+	session := auth.GetSession(ctx, userCred, "", "")
+	if hook.Transition != compute.LIFECYCLE_TRANSITION_SCALE_IN {
+		// the scale out activity was interrupted after the instances had been created
+		failRecord := &SFailRecord{}
+		for id, result := range results {
+			asc.joinAfterLifecycle(ctx, userCred, session, sg, id, result, failRecord)
+		}
+		if len(failRecord.recordList) > 0 {
+			log.Errorf("resume scale out of ScalingGroup '%s': %s", sg.Id, failRecord.String())
+		}
+		return
+	}
+	// the scale in activity was interrupted, so the instances are removed whatever the results are
+	removeParams := jsonutils.NewDict()
+	removeParams.Set("scaling_group", jsonutils.NewString(sg.Id))
+	removeParams.Set("delete_server", jsonutils.JSONTrue)
+	removeParams.Set("auto", jsonutils.JSONTrue)
+	for id := range results {
+		_, err := modules.Servers.PerformAction(session, id, "detach-scaling-group", removeParams)
+		if err != nil {
+			log.Errorf("remove instance '%s' from ScalingGroup '%s' failed: %s", id, sg.Id, err)
+		}
+	}
+}
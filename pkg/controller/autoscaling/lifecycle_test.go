@@ -0,0 +1,163 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+type fakeLifecycleGuests struct {
+	sggs   map[string]*models.SScalingGroupGuest
+	status map[string]string
+}
+
+func newFakeLifecycleGuests(sggs ...models.SScalingGroupGuest) *fakeLifecycleGuests {
+	f := &fakeLifecycleGuests{
+		sggs:   make(map[string]*models.SScalingGroupGuest, len(sggs)),
+		status: make(map[string]string, len(sggs)),
+	}
+	for i := range sggs {
+		f.sggs[sggs[i].GuestId] = &sggs[i]
+	}
+	return f
+}
+
+func (f *fakeLifecycleGuests) waiter(hook *models.SScalingLifecycleHook, interval time.Duration) *sLifecycleWaiter {
+	return &sLifecycleWaiter{
+		hook:       hook,
+		nextStatus: compute.SG_GUEST_STATUS_READY,
+		interval:   interval,
+		fetch: func(guestIds []string) ([]models.SScalingGroupGuest, error) {
+			ret := make([]models.SScalingGroupGuest, 0, len(guestIds))
+			for _, id := range guestIds {
+				if sgg, ok := f.sggs[id]; ok {
+					ret = append(ret, *sgg)
+				}
+			}
+			return ret, nil
+		},
+		leave: func(sgg *models.SScalingGroupGuest, status string) error {
+			f.status[sgg.GuestId] = status
+			return nil
+		},
+	}
+}
+
+func lifecycleGuest(id, result string, deadline time.Time) models.SScalingGroupGuest {
+	sgg := models.SScalingGroupGuest{}
+	sgg.GuestId = id
+	sgg.LifecycleResult = result
+	sgg.LifecycleDeadline = deadline
+	return sgg
+}
+
+func TestLifecycleWaiterCheck(t *testing.T) {
+	hook := &models.SScalingLifecycleHook{DefaultResult: compute.LIFECYCLE_RESULT_ABANDON}
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+	f := newFakeLifecycleGuests(
+		lifecycleGuest("completed", compute.LIFECYCLE_RESULT_CONTINUE, future),
+		lifecycleGuest("pending", "", future),
+		lifecycleGuest("expired", "", past),
+	)
+	waiting := map[string]bool{"completed": true, "pending": true, "expired": true, "removed": true}
+	results := make(map[string]string)
+	f.waiter(hook, time.Millisecond).check(waiting, results, false)
+
+	want := map[string]string{
+		"completed": compute.LIFECYCLE_RESULT_CONTINUE,
+		"expired":   compute.LIFECYCLE_RESULT_ABANDON,
+		"removed":   compute.LIFECYCLE_RESULT_ABANDON,
+	}
+	for id, result := range want {
+		if results[id] != result {
+			t.Errorf("guest %s: want result %q, got %q", id, result, results[id])
+		}
+	}
+	if len(waiting) != 1 || !waiting["pending"] {
+		t.Errorf("want only pending guest left waiting, got %v", waiting)
+	}
+	if _, ok := f.status["pending"]; ok {
+		t.Errorf("pending guest should not leave lifecycle wait")
+	}
+	if f.status["completed"] != compute.SG_GUEST_STATUS_READY {
+		t.Errorf("completed guest should leave lifecycle wait to %s, got %q", compute.SG_GUEST_STATUS_READY, f.status["completed"])
+	}
+}
+
+func TestLifecycleWaiterCanceled(t *testing.T) {
+	hook := &models.SScalingLifecycleHook{DefaultResult: compute.LIFECYCLE_RESULT_CONTINUE}
+	f := newFakeLifecycleGuests(lifecycleGuest("pending", "", time.Now().Add(time.Hour)))
+	waiting := map[string]bool{"pending": true}
+	results := make(map[string]string)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		f.waiter(hook, time.Hour).wait(ctx, waiting, results)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait does not return after context is canceled")
+	}
+	if results["pending"] != compute.LIFECYCLE_RESULT_CONTINUE {
+		t.Errorf("want default result %q, got %q", compute.LIFECYCLE_RESULT_CONTINUE, results["pending"])
+	}
+	if f.status["pending"] != compute.SG_GUEST_STATUS_READY {
+		t.Errorf("canceled guest should leave lifecycle wait, got status %q", f.status["pending"])
+	}
+}
+
+func TestLifecycleWaiterResumeExpired(t *testing.T) {
+	// the deadline passed while the service was down
+	hook := &models.SScalingLifecycleHook{DefaultResult: compute.LIFECYCLE_RESULT_ABANDON}
+	f := newFakeLifecycleGuests(
+		lifecycleGuest("expired", "", time.Now().Add(-time.Hour)),
+		lifecycleGuest("completed", compute.LIFECYCLE_RESULT_CONTINUE, time.Now().Add(-time.Hour)),
+	)
+	waiting := map[string]bool{"expired": true, "completed": true}
+	results := make(map[string]string)
+	f.waiter(hook, time.Millisecond).wait(context.Background(), waiting, results)
+	if results["expired"] != compute.LIFECYCLE_RESULT_ABANDON {
+		t.Errorf("expired guest: want %q, got %q", compute.LIFECYCLE_RESULT_ABANDON, results["expired"])
+	}
+	if results["completed"] != compute.LIFECYCLE_RESULT_CONTINUE {
+		t.Errorf("completed guest: want %q, got %q", compute.LIFECYCLE_RESULT_CONTINUE, results["completed"])
+	}
+}
+
+func TestLifecycleNextStatus(t *testing.T) {
+	cases := []struct {
+		transition string
+		want       string
+	}{
+		// scale out instances are set ready only after joinAfterLifecycle binds them
+		{compute.LIFECYCLE_TRANSITION_SCALE_OUT, compute.SG_GUEST_STATUS_JOINING},
+		{compute.LIFECYCLE_TRANSITION_SCALE_IN, compute.SG_GUEST_STATUS_READY},
+	}
+	for _, c := range cases {
+		if got := lifecycleNextStatus(c.transition); got != c.want {
+			t.Errorf("lifecycleNextStatus(%s) = %s, want %s", c.transition, got, c.want)
+		}
+	}
+}
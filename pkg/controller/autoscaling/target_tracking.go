@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+// CheckTargetTracking evaluates all target tracking policies and adjusts the desire instance number of
+// scaling groups to keep the metric near the target value
+func (asc *SASController) CheckTargetTracking(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := models.ScalingPolicyManager.Query().Equals("trigger_type", compute.TRIGGER_TARGET_TRACKING).
+		Equals("status", compute.SP_STATUS_READY).IsTrue("enabled")
+	policies := make([]models.SScalingPolicy, 0)
+	err := db.FetchModelObjects(models.ScalingPolicyManager, q, &policies)
+	if err != nil {
+		log.Errorf("fetch target tracking scaling policies: %s", err)
+		return
+	}
+	session := auth.GetSession(ctx, userCred, "", "")
+	for i := range policies {
+		err := asc.checkTargetTracking(ctx, session, &policies[i])
+		if err != nil {
+			log.Errorf("check target tracking policy %s: %s", policies[i].Name, err)
+		}
+	}
+}
+
+func (asc *SASController) checkTargetTracking(ctx context.Context, session *mcclient.ClientSession, sp *models.SScalingPolicy) error {
+	trigger, err := sp.Trigger(nil)
+	if err != nil {
+		return errors.Wrap(err, "ScalingPolicy.Trigger")
+	}
+	tt, ok := trigger.(*models.SScalingTargetTracking)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if !tt.NeedCheck(now) {
+		return nil
+	}
+	sg, err := sp.ScalingGroup()
+	if err != nil {
+		return errors.Wrap(err, "ScalingPolicy.ScalingGroup")
+	}
+	if sg.Enabled.IsFalse() {
+		return nil
+	}
+	// skip when a scaling activity is in progress
+	if asc.scalingGroupSet.Has(sg.Id) {
+		return nil
+	}
+	current, err := sg.GuestNumber()
+	if err != nil {
+		return errors.Wrap(err, "ScalingGroup.GuestNumber")
+	}
+	if current != sg.DesireInstanceNumber {
+		return nil
+	}
+	value, ok, err := asc.queryTargetTrackingMetric(session, sg, tt)
+	if err != nil {
+		return errors.Wrap(err, "queryTargetTrackingMetric")
+	}
+	if !ok {
+		log.Debugf("no metric data for target tracking policy %s", sp.Name)
+		return nil
+	}
+	tt.SetLastValue(value, now)
+	desired := tt.Desired(current, value)
+	if desired == current || tt.InCooldown(current, desired, now) {
+		return nil
+	}
+	err = sg.Scale(ctx, tt, models.SScalingTargetTrackingAction{Target: desired}, 0)
+	if err != nil {
+		return errors.Wrap(err, "ScalingGroup.Scale")
+	}
+	tt.SetScaleTime(desired > current, now)
+	return nil
+}
+
+// queryTargetTrackingMetric returns the average of all metric points of instances in the scaling group
+func (asc *SASController) queryTargetTrackingMetric(session *mcclient.ClientSession, sg *models.SScalingGroup,
+	tt *models.SScalingTargetTracking) (float64, bool, error) {
+	metricQuery, err := tt.MetricQuery(sg.Id)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "MetricQuery")
+	}
+	input := jsonutils.NewDict()
+	input.Set("scope", jsonutils.NewString("system"))
+	input.Set("metric_query", jsonutils.NewArray(metricQuery))
+	ret, err := modules.UnifiedMonitorManager.PerformQuery(session, input)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "UnifiedMonitorManager.PerformQuery")
+	}
+	series, _ := ret.GetArray("series")
+	var (
+		sum   float64
+		count int
+	)
+	for i := range series {
+		points, _ := series[i].GetArray("points")
+		for j := range points {
+			point, _ := points[j].GetArray()
+			if len(point) == 0 {
+				continue
+			}
+			v, err := point[0].Float()
+			if err != nil {
+				// null value
+				continue
+			}
+			sum += v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false, nil
+	}
+	return sum / float64(count), true, nil
+}
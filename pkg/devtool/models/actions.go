@@ -169,6 +169,10 @@ func (obj *SDevtoolTemplate) Binding(ctx context.Context, userCred mcclient.Toke
 	newCronjobParams.Add(jsonutils.NewInt(int64(template.Interval)), "interval")
 	newCronjobParams.Add(jsonutils.NewBool(template.Start), "start")
 	newCronjobParams.Add(jsonutils.NewBool(template.Enabled), "enabled")
+	newCronjobParams.Add(jsonutils.NewString(template.CronExpr), "cron_expr")
+	newCronjobParams.Add(jsonutils.NewString(template.TimeZone), "time_zone")
+	newCronjobParams.Add(jsonutils.NewInt(int64(template.Jitter)), "jitter")
+	newCronjobParams.Add(jsonutils.NewString(template.CatchUp), "catch_up")
 	newCronjobParams.Add(jsonutils.NewString(ansibleId), "ansible_playbook_id")
 	newCronjobParams.Add(jsonutils.NewString(template.Id), "template_id")
 	newCronjobParams.Add(jsonutils.NewString(ServerID), "server_id")
@@ -0,0 +1,216 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/devtool"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// 定时任务运行记录
+type SCronjobRun struct {
+	db.SStatusStandaloneResourceBase
+
+	CronjobId string    `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	StartTime time.Time `list:"user"`
+	EndTime   time.Time `list:"user"`
+	// 运行时长, 单位秒
+	Duration float64 `list:"user"`
+	Reason   string  `charset:"utf8" list:"user"`
+}
+
+type SCronjobRunManager struct {
+	db.SStatusStandaloneResourceBaseManager
+}
+
+var CronjobRunManager *SCronjobRunManager
+
+func init() {
+	CronjobRunManager = &SCronjobRunManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SCronjobRun{},
+			"devtool_cronjob_runs_tbl",
+			"devtool_cronjob_run",
+			"devtool_cronjob_runs",
+		),
+	}
+	CronjobRunManager.SetVirtualObject(CronjobRunManager)
+}
+
+func (manager *SCronjobRunManager) InitializeData() error {
+	runs := make([]SCronjobRun, 0)
+	q := manager.Query().Equals("status", api.CRONJOB_RUN_STATUS_RUNNING)
+	err := db.FetchModelObjects(manager, q, &runs)
+	if err != nil {
+		return err
+	}
+	for i := range runs {
+		err := runs[i].SetResult(api.CRONJOB_RUN_STATUS_FAILED, "As the service restarts, the status becomes unknown")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (manager *SCronjobRunManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.CronjobRunListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(input.Cronjob) == 0 {
+		return nil, httperrors.NewInputParameterError("need cronjob")
+	}
+	job, err := CronjobManager.FetchByIdOrName(userCred, input.Cronjob)
+	if err != nil {
+		if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+			return nil, httperrors.NewResourceNotFoundError2(CronjobManager.Keyword(), input.Cronjob)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+	q = q.Equals("cronjob_id", job.GetId()).Desc("start_time")
+	return q, nil
+}
+
+func (manager *SCronjobRunManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.CronjobRunDetails {
+	rows := make([]api.CronjobRunDetails, len(objs))
+	statusRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	jobIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i].StatusStandaloneResourceDetails = statusRows[i]
+		jobIds[i] = objs[i].(*SCronjobRun).CronjobId
+	}
+	jobs := make(map[string]SCronjob)
+	err := db.FetchStandaloneObjectsByIds(CronjobManager, jobIds, &jobs)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds cronjobs fail %s", err)
+		return rows
+	}
+	for i := range rows {
+		if job, ok := jobs[jobIds[i]]; ok {
+			rows[i].Cronjob = job.Name
+		}
+	}
+	return rows
+}
+
+func (manager *SCronjobRunManager) NamespaceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeProject
+}
+
+func (manager *SCronjobRunManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeProject
+}
+
+func (manager *SCronjobRunManager) FilterByOwner(q *sqlchemy.SQuery, owner mcclient.IIdentityProvider, scope rbacutils.TRbacScope) *sqlchemy.SQuery {
+	if owner == nil {
+		return q
+	}
+	jobQ := CronjobManager.Query("id")
+	switch scope {
+	case rbacutils.ScopeProject:
+		jobQ = jobQ.Equals("tenant_id", owner.GetProjectId())
+	case rbacutils.ScopeDomain:
+		jobQ = jobQ.Equals("domain_id", owner.GetProjectDomainId())
+	default:
+		return q
+	}
+	return q.In("cronjob_id", jobQ.SubQuery())
+}
+
+func (manager *SCronjobRunManager) FetchOwnerId(ctx context.Context, data jsonutils.JSONObject) (mcclient.IIdentityProvider, error) {
+	return db.FetchProjectInfo(ctx, data)
+}
+
+func (run *SCronjobRun) GetOwnerId() mcclient.IIdentityProvider {
+	obj, _ := CronjobManager.FetchById(run.CronjobId)
+	if obj == nil {
+		return nil
+	}
+	return obj.GetOwnerId()
+}
+
+func (manager *SCronjobRunManager) newRun(job *SCronjob) (*SCronjobRun, error) {
+	run := &SCronjobRun{
+		CronjobId: job.Id,
+		StartTime: time.Now(),
+	}
+	run.SetModelManager(manager, run)
+	run.Name = job.Name
+	run.Status = api.CRONJOB_RUN_STATUS_RUNNING
+	err := manager.TableSpec().Insert(context.Background(), run)
+	if err != nil {
+		return nil, errors.Wrap(err, "Insert")
+	}
+	return run, nil
+}
+
+// 最近一次运行的开始时间, 用于服务重启后的补跑
+func (manager *SCronjobRunManager) getLastRunTime(jobId string) time.Time {
+	run := &SCronjobRun{}
+	run.SetModelManager(manager, run)
+	q := manager.Query().Equals("cronjob_id", jobId).Desc("start_time")
+	err := q.First(run)
+	if err != nil {
+		return time.Time{}
+	}
+	return run.StartTime
+}
+
+func (run *SCronjobRun) SetResult(status, reason string) error {
+	_, err := db.Update(run, func() error {
+		run.Status = status
+		run.Reason = reason
+		run.EndTime = time.Now()
+		run.Duration = run.EndTime.Sub(run.StartTime).Seconds()
+		return nil
+	})
+	return err
+}
+
+func (manager *SCronjobRunManager) purgeRuns(ctx context.Context, userCred mcclient.TokenCredential, jobId string) {
+	runs := make([]SCronjobRun, 0)
+	q := manager.Query().Equals("cronjob_id", jobId)
+	err := db.FetchModelObjects(manager, q, &runs)
+	if err != nil {
+		log.Errorf("fetch runs of cronjob %s fail %s", jobId, err)
+		return
+	}
+	for i := range runs {
+		err := runs[i].Delete(ctx, userCred)
+		if err != nil {
+			log.Errorf("delete cronjob run %s fail %s", runs[i].Id, err)
+		}
+	}
+}
@@ -21,8 +21,11 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/devtool"
 	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
@@ -36,6 +39,35 @@ type SVSCronjob struct {
 	Interval int64 `nullable:"true" create:"optional" list:"user" update:"user" default:"0"`
 	Start    bool  `nullable:"true" create:"optional" list:"user" update:"user" default:"false"`
 	Enabled  bool  `nullable:"true" create:"optional" list:"user" update:"user" default:"false"`
+
+	// 标准 cron 表达式, 支持 5 段或 6 段(首段为秒), 设置后忽略 Day/Hour/Min/Sec/Interval
+	CronExpr string `width:"128" charset:"ascii" nullable:"true" create:"optional" list:"user" update:"user"`
+	// cron 表达式使用的时区, 如 Asia/Shanghai, 默认为服务所在时区
+	TimeZone string `width:"64" charset:"ascii" nullable:"true" create:"optional" list:"user" update:"user"`
+	// 随机延迟运行的最大秒数
+	Jitter int `nullable:"true" create:"optional" list:"user" update:"user" default:"0"`
+	// 错过运行时间后的补跑策略: skip, once, all
+	CatchUp string `width:"16" charset:"ascii" nullable:"true" create:"optional" list:"user" update:"user" default:"skip"`
+}
+
+func (job *SVSCronjob) newCronTimer(lastRun time.Time) (*cronman.CronTimer, error) {
+	return cronman.NewCronTimer(job.CronExpr, job.TimeZone, time.Duration(job.Jitter)*time.Second, job.CatchUp, lastRun)
+}
+
+// 将更新数据合并到当前配置后校验 cron 表达式, 时区, 抖动和补跑策略
+func (job SVSCronjob) validateSchedule(data *jsonutils.JSONDict) error {
+	err := data.Unmarshal(&job)
+	if err != nil {
+		return httperrors.NewInputParameterError("unmarshal cronjob schedule fail %s", err)
+	}
+	if len(job.CronExpr) == 0 {
+		return nil
+	}
+	_, err = job.newCronTimer(time.Time{})
+	if err != nil {
+		return httperrors.NewInputParameterError("%v", err)
+	}
+	return nil
 }
 
 type SCronjob struct {
@@ -77,12 +109,25 @@ func RunAnsibleCronjob(id string, s *mcclient.ClientSession) cronman.TCronJobFun
 		log.Debugf("[RunAnsibleCronjob] %+v: ", obj)
 		item := obj.(*SCronjob)
 
+		run, err := CronjobRunManager.newRun(item)
+		if err != nil {
+			log.Errorf("record run of cronjob %s error: %s", item.Id, err)
+		}
+
 		log.Debugf("[RunAnsibleCronjob] perform ansible cronjob run: %s", item.AnsiblePlaybookID)
 		ret, err := modules.AnsiblePlaybooks.PerformAction(s, item.AnsiblePlaybookID, "run", nil)
+		status, reason := api.CRONJOB_RUN_STATUS_SUCCEED, ""
 		if err != nil {
 			log.Errorf("AnsiblePlaybooks.PerformAction error: %s", err)
+			status, reason = api.CRONJOB_RUN_STATUS_FAILED, err.Error()
 		}
 		log.Debugf("AnsiblePlaybooks.PerformAction ret: %+v", ret)
+		if run != nil {
+			err = run.SetResult(status, reason)
+			if err != nil {
+				log.Errorf("update run %s of cronjob %s error: %s", run.Id, item.Id, err)
+			}
+		}
 	}
 }
 
@@ -92,7 +137,17 @@ func AddOneCronjob(item *SCronjob, s *mcclient.ClientSession) error {
 		log.Debugf("ansible cronjob %s (devtool item.Id: %s) is not enabled", item.Name, item.Id)
 		return nil
 	}
-	if item.Interval > 0 {
+	if len(item.CronExpr) > 0 {
+		timer, err := item.newCronTimer(CronjobRunManager.getLastRunTime(item.Id))
+		if err == nil {
+			err = DevToolCronManager.AddJobByCronTimer(item.Id, timer, RunAnsibleCronjob(item.Id, s), item.Start)
+		}
+		if err != nil {
+			log.Errorf("ansible cronjob %s (devtool item.Id: %s) error! %s", item.Name, item.Id, err)
+			return err
+		}
+		log.Infof("ansible cronjob %s (devtool item.Id: %s) registered at cron expression %q time zone %q", item.Name, item.Id, item.CronExpr, item.TimeZone)
+	} else if item.Interval > 0 {
 		err := DevToolCronManager.AddJobAtIntervalsWithStartRun(item.Id, time.Duration(item.Interval)*time.Second, RunAnsibleCronjob(item.Id, s), item.Start)
 		if err != nil {
 			log.Errorf("ansible cronjob %s (devtool item.Id: %s) error! %s", item.Name, item.Id, err)
@@ -130,6 +185,42 @@ func InitializeCronjobs() error {
 	return nil
 }
 
+func (manager *SCronjobManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data *jsonutils.JSONDict) (*jsonutils.JSONDict, error) {
+	err := SVSCronjob{}.validateSchedule(data)
+	if err != nil {
+		return nil, err
+	}
+	input := apis.VirtualResourceCreateInput{}
+	err = data.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInternalServerError("unmarshal VirtualResourceCreateInput fail %s", err)
+	}
+	input, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input)
+	if err != nil {
+		return nil, err
+	}
+	data.Update(jsonutils.Marshal(input))
+	return data, nil
+}
+
+func (job *SCronjob) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data *jsonutils.JSONDict) (*jsonutils.JSONDict, error) {
+	err := job.SVSCronjob.validateSchedule(data)
+	if err != nil {
+		return nil, err
+	}
+	input := apis.VirtualResourceBaseUpdateInput{}
+	err = data.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInternalServerError("unmarshal VirtualResourceBaseUpdateInput fail %s", err)
+	}
+	input, err = job.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input)
+	if err != nil {
+		return nil, err
+	}
+	data.Update(jsonutils.Marshal(input))
+	return data, nil
+}
+
 func (job *SCronjob) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerID mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	Session := auth.GetAdminSession(nil, "", "")
 	job.SStandaloneResourceBase.PostCreate(ctx, userCred, nil, query, data)
@@ -138,6 +229,7 @@ func (job *SCronjob) PostCreate(ctx context.Context, userCred mcclient.TokenCred
 
 func (job *SCronjob) PostDelete(ctx context.Context, userCred mcclient.TokenCredential) {
 	DevToolCronManager.Remove(job.Id)
+	CronjobRunManager.purgeRuns(ctx, userCred, job.Id)
 }
 
 func (job *SCronjob) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
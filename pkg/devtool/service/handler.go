@@ -41,6 +41,7 @@ func InitHandlers(app *appsrv.Application) {
 		db.Metadata,
 
 		models.CronjobManager,
+		models.CronjobRunManager,
 		models.DevtoolTemplateManager,
 	} {
 		db.RegisterModelManager(manager)
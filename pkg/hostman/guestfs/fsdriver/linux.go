@@ -642,6 +642,21 @@ func (d *sDebianLikeRootFs) DeployNetworkingScripts(rootFs IDiskPartition, nics
 			}
 			cmds.WriteString("\n")
 		}
+		if len(nicDesc.Ip6) > 0 && nicDesc.TeamingMaster == nil && !nicDesc.Virtual {
+			if nicDesc.Manual {
+				cmds.WriteString(fmt.Sprintf("iface %s inet6 static\n", nicDesc.Name))
+				cmds.WriteString(fmt.Sprintf("    address %s/%d\n", nicDesc.Ip6, nicDesc.Masklen6))
+				if len(nicDesc.Gateway6) > 0 && nicDesc.Ip == mainIp {
+					cmds.WriteString(fmt.Sprintf("    gateway %s\n", nicDesc.Gateway6))
+				}
+				if len(nicDesc.Dns6) > 0 {
+					cmds.WriteString(fmt.Sprintf("    dns-nameservers %s\n", nicDesc.Dns6))
+				}
+			} else {
+				cmds.WriteString(fmt.Sprintf("iface %s inet6 dhcp\n", nicDesc.Name))
+			}
+			cmds.WriteString("\n")
+		}
 	}
 	log.Debugf("%s", cmds.String())
 	return rootFs.FilePutContents(fn, cmds.String(), false, false)
@@ -1058,6 +1073,21 @@ func (r *sRedhatLikeRootFs) deployNetworkingScripts(rootFs IDiskPartition, nics
 		} else {
 			cmds.WriteString("BOOTPROTO=dhcp\n")
 		}
+		if len(nicDesc.Ip6) > 0 && nicDesc.TeamingMaster == nil && !nicDesc.Virtual {
+			cmds.WriteString("IPV6INIT=yes\n")
+			if nicDesc.Manual {
+				cmds.WriteString("IPV6_AUTOCONF=no\n")
+				cmds.WriteString(fmt.Sprintf("IPV6ADDR=%s/%d\n", nicDesc.Ip6, nicDesc.Masklen6))
+				if len(nicDesc.Gateway6) > 0 && nicDesc.Ip == mainIp {
+					cmds.WriteString(fmt.Sprintf("IPV6_DEFAULTGW=%s\n", nicDesc.Gateway6))
+				}
+				if len(nicDesc.Dns6) > 0 {
+					cmds.WriteString(fmt.Sprintf("DNS%d=%s\n", len(netutils2.GetNicDns(nicDesc))+1, nicDesc.Dns6))
+				}
+			} else {
+				cmds.WriteString("DHCPV6C=yes\n")
+			}
+		}
 		var fn = fmt.Sprintf("/etc/sysconfig/network-scripts/ifcfg-%s", nicDesc.Name)
 		log.Debugf("%s: %s", fn, cmds.String())
 		if err := rootFs.FilePutContents(fn, cmds.String(), false, false); err != nil {
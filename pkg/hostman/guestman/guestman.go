@@ -223,9 +223,13 @@ func (m *SGuestManager) verifyDirtyServers() {
 func (m *SGuestManager) ClenaupCpuset() {
 	m.Servers.Range(func(k, v interface{}) bool {
 		guest := v.(*SKVMGuestInstance)
-		guest.CleanupCpuset()
+		if !guest.IsNumaStrict() {
+			guest.CleanupCpuset()
+		}
 		return true
 	})
+	// 回到根 cpuset 的虚拟机不能使用 NUMA 绑定虚拟机独占的 CPU
+	m.excludePinnedCpus()
 }
 
 func (m *SGuestManager) StartCpusetBalancer() {
@@ -251,7 +255,13 @@ func (m *SGuestManager) StartCpusetBalancer() {
 
 func (m *SGuestManager) cpusetBalance() {
 	if !options.HostOptions.DisableSetCgroup {
-		cgrouputils.RebalanceProcesses(nil)
+		if !m.hasNumaStrictGuest() {
+			cgrouputils.RebalanceProcesses(nil, nil)
+		} else if pids := m.getUnpinnedGuestPids(); len(pids) > 0 {
+			// NUMA 绑定的虚拟机不参与均衡, 其独占的 CPU 也不分给其他虚拟机
+			m.excludePinnedCpus()
+			cgrouputils.RebalanceProcesses(pids, m.getPinnedCpus())
+		}
 	}
 }
 
@@ -1213,6 +1213,11 @@ func (s *SKVMGuestInstance) SetCgroup() {
 	s.cgroupPid = s.GetPid()
 	s.setCgroupIo()
 	s.setCgroupCpu()
+	if s.IsNumaStrict() {
+		s.setNumaPin()
+	} else if cpus := s.manager.getPinnedCpus(); len(cpus) > 0 {
+		cgrouputils.CgroupCpusetExclude(strconv.Itoa(s.cgroupPid), cpus)
+	}
 }
 
 func (s *SKVMGuestInstance) setCgroupIo() {
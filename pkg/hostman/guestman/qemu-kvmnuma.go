@@ -0,0 +1,272 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"sync"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/cgrouputils"
+)
+
+// 分配绑定时需要读取其他虚拟机的绑定结果, 避免同时启动的虚拟机分到相同的 CPU
+var numaPinLock = &sync.Mutex{}
+
+func (s *SKVMGuestInstance) IsNumaStrict() bool {
+	policy, _ := s.Desc.GetString("numa_policy")
+	return policy == compute.GUEST_NUMA_POLICY_STRICT
+}
+
+func (s *SKVMGuestInstance) getNumaPin() *compute.GuestNumaPin {
+	if !s.Desc.Contains("cpu_numa_pin") {
+		return nil
+	}
+	pin := &compute.GuestNumaPin{}
+	if err := s.Desc.Unmarshal(pin, "cpu_numa_pin"); err != nil {
+		log.Errorf("unmarshal cpu_numa_pin of %s: %s", s.Id, err)
+		return nil
+	}
+	return pin
+}
+
+// allocNumaPin 为虚拟机在本机选定 NUMA 节点和独占 CPU,
+// 本机已有且未与其他虚拟机冲突的绑定结果会被沿用, 迁移到本机的虚拟机重新分配
+func (s *SKVMGuestInstance) allocNumaPin() (*compute.GuestNumaPin, error) {
+	numaPinLock.Lock()
+	defer numaPinLock.Unlock()
+
+	hostId, _ := s.Desc.GetString("host_id")
+	pinned := []cgrouputils.NumaPin{}
+	usedCpus := map[int]bool{}
+	s.manager.rangeNumaPins(func(guest *SKVMGuestInstance, pin *compute.GuestNumaPin) {
+		if guest.Id == s.Id || pin.HostId != hostId {
+			return
+		}
+		pinned = append(pinned, cgrouputils.NumaPin{
+			NodeId:    pin.NodeId,
+			VcpuPin:   pin.VcpuPin,
+			MemSizeMb: pin.MemSizeMb,
+		})
+		for _, cpu := range pin.VcpuPin {
+			usedCpus[cpu] = true
+		}
+	})
+
+	if pin := s.getNumaPin(); pin != nil && pin.HostId == hostId {
+		conflict := false
+		for _, cpu := range pin.VcpuPin {
+			if usedCpus[cpu] {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			return pin, nil
+		}
+	}
+
+	nodes, err := cgrouputils.GetNumaNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetNumaNodes")
+	}
+	cpu, _ := s.Desc.Int("cpu")
+	mem, _ := s.Desc.Int("mem")
+	ret, err := cgrouputils.AllocNumaPin(nodes, pinned, int(cpu), int(mem))
+	if err != nil {
+		return nil, errors.Wrap(err, "AllocNumaPin")
+	}
+	pin := &compute.GuestNumaPin{
+		HostId:       hostId,
+		NodeId:       ret.NodeId,
+		VcpuPin:      ret.VcpuPin,
+		EmulatorCpus: ret.EmulatorCpus,
+		MemSizeMb:    ret.MemSizeMb,
+	}
+	s.Desc.Set("cpu_numa_pin", jsonutils.Marshal(pin))
+	if err := s.SaveDesc(s.Desc); err != nil {
+		return nil, errors.Wrap(err, "SaveDesc")
+	}
+	return pin, nil
+}
+
+// setNumaPin 将虚拟机进程限定在所分配的 NUMA 节点上并绑定本地内存,
+// 每个 vCPU 线程独占一个物理 CPU, 其余模拟器线程使用节点内非独占的 CPU
+func (s *SKVMGuestInstance) setNumaPin() {
+	pin, err := s.allocNumaPin()
+	if err != nil {
+		log.Errorf("guest %s alloc numa pin failed: %s", s.Id, err)
+		return
+	}
+	s.applyNumaPin(pin)
+	s.manager.isolatePinnedCpus(s.Id, pin.VcpuPin)
+}
+
+func (s *SKVMGuestInstance) applyNumaPin(pin *compute.GuestNumaPin) {
+	pid := strconv.Itoa(s.cgroupPid)
+	cpus := append(append([]int{}, pin.VcpuPin...), pin.EmulatorCpus...)
+	if !cgrouputils.CgroupNumaSet(pid, cpus, pin.NodeId) {
+		log.Errorf("guest %s set numa cpuset failed", s.Id)
+		return
+	}
+	if s.Monitor == nil {
+		return
+	}
+	s.Monitor.GetCpuThreadIds(func(tids []int) {
+		if len(tids) != len(pin.VcpuPin) {
+			log.Errorf("guest %s got %d vcpu threads, expect %d", s.Id, len(tids), len(pin.VcpuPin))
+			return
+		}
+		vcpuTids := map[int]bool{}
+		for i, tid := range tids {
+			vcpuTids[tid] = true
+			if err := cgrouputils.SetThreadAffinity(tid, pin.VcpuPin[i:i+1]); err != nil {
+				log.Errorf("guest %s pin vcpu %d to cpu %d: %s", s.Id, i, pin.VcpuPin[i], err)
+			}
+		}
+		files, err := ioutil.ReadDir("/proc/" + pid + "/task")
+		if err != nil {
+			log.Errorf("guest %s read threads: %s", s.Id, err)
+			return
+		}
+		for _, f := range files {
+			tid, err := strconv.Atoi(f.Name())
+			if err != nil || vcpuTids[tid] {
+				continue
+			}
+			if err := cgrouputils.SetThreadAffinity(tid, pin.EmulatorCpus); err != nil {
+				log.Errorf("guest %s pin emulator thread %d: %s", s.Id, tid, err)
+			}
+		}
+		log.Infof("guest %s pinned to numa node %d, vcpus %s, emulator %s", s.Id, pin.NodeId,
+			cgrouputils.FormatCpuList(pin.VcpuPin), cgrouputils.FormatCpuList(pin.EmulatorCpus))
+		s.syncNumaPin(pin)
+	})
+}
+
+func (s *SKVMGuestInstance) syncNumaPin(pin *compute.GuestNumaPin) {
+	params := jsonutils.NewDict()
+	params.Set("cpu_numa_pin", jsonutils.Marshal(pin))
+	_, err := modules.Servers.Update(hostutils.GetComputeSession(context.Background()), s.Id, params)
+	if err != nil {
+		log.Errorf("guest %s sync numa pin: %s", s.Id, err)
+	}
+}
+
+// rangeNumaPins 遍历本机运行中且已分配绑定的 NUMA 虚拟机
+func (m *SGuestManager) rangeNumaPins(f func(guest *SKVMGuestInstance, pin *compute.GuestNumaPin)) {
+	m.Servers.Range(func(k, v interface{}) bool {
+		guest := v.(*SKVMGuestInstance)
+		if !guest.IsNumaStrict() || !guest.IsRunning() {
+			return true
+		}
+		if pin := guest.getNumaPin(); pin != nil {
+			f(guest, pin)
+		}
+		return true
+	})
+}
+
+// getPinnedCpus 返回本机 NUMA 绑定虚拟机的 vCPU 独占的 CPU
+func (m *SGuestManager) getPinnedCpus() []int {
+	cpus := []int{}
+	m.rangeNumaPins(func(guest *SKVMGuestInstance, pin *compute.GuestNumaPin) {
+		hostId, _ := guest.Desc.GetString("host_id")
+		if pin.HostId == hostId {
+			cpus = append(cpus, pin.VcpuPin...)
+		}
+	})
+	return cpus
+}
+
+// isolatePinnedCpus 使虚拟机 guestId 新独占的 vcpus 不再被其他虚拟机使用:
+// 其他 NUMA 绑定虚拟机的模拟器线程让出这些 CPU, 未绑定虚拟机的 cpuset 去除所有独占的 CPU
+func (m *SGuestManager) isolatePinnedCpus(guestId string, vcpus []int) {
+	numaPinLock.Lock()
+	guests := []*SKVMGuestInstance{}
+	pins := []*compute.GuestNumaPin{}
+	m.rangeNumaPins(func(guest *SKVMGuestInstance, pin *compute.GuestNumaPin) {
+		if guest.Id == guestId {
+			return
+		}
+		emulatorCpus := cgrouputils.ExcludeCpus(pin.EmulatorCpus, vcpus)
+		if len(emulatorCpus) == len(pin.EmulatorCpus) {
+			return
+		}
+		if len(emulatorCpus) == 0 {
+			emulatorCpus = pin.VcpuPin
+		}
+		pin.EmulatorCpus = emulatorCpus
+		guest.Desc.Set("cpu_numa_pin", jsonutils.Marshal(pin))
+		if err := guest.SaveDesc(guest.Desc); err != nil {
+			log.Errorf("guest %s save numa pin: %s", guest.Id, err)
+		}
+		guests = append(guests, guest)
+		pins = append(pins, pin)
+	})
+	numaPinLock.Unlock()
+
+	for i := range guests {
+		guests[i].applyNumaPin(pins[i])
+	}
+	m.excludePinnedCpus()
+}
+
+// excludePinnedCpus 将独占的 CPU 从未做 NUMA 绑定的运行中虚拟机的 cpuset 中去除,
+// 包括从未被均衡过, 仍使用全部 CPU 的虚拟机
+func (m *SGuestManager) excludePinnedCpus() {
+	cpus := m.getPinnedCpus()
+	if len(cpus) == 0 {
+		return
+	}
+	for _, pid := range m.getUnpinnedGuestPids() {
+		if !cgrouputils.CgroupCpusetExclude(pid, cpus) {
+			log.Errorf("exclude pinned cpus %s from process %s failed", cgrouputils.FormatCpuList(cpus), pid)
+		}
+	}
+}
+
+// getUnpinnedGuestPids 返回未做 NUMA 绑定的运行中虚拟机进程, 供 cpuset 均衡使用
+func (m *SGuestManager) getUnpinnedGuestPids() []string {
+	pids := []string{}
+	m.Servers.Range(func(k, v interface{}) bool {
+		guest := v.(*SKVMGuestInstance)
+		if guest.IsNumaStrict() || !guest.IsRunning() {
+			return true
+		}
+		if pid := guest.GetPid(); pid > 0 {
+			pids = append(pids, strconv.Itoa(pid))
+		}
+		return true
+	})
+	return pids
+}
+
+func (m *SGuestManager) hasNumaStrictGuest() bool {
+	found := false
+	m.Servers.Range(func(k, v interface{}) bool {
+		found = v.(*SKVMGuestInstance).IsNumaStrict()
+		return !found
+	})
+	return found
+}
@@ -31,6 +31,7 @@ import (
 	"yunion.io/x/onecloud/pkg/hostman/guestman"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/guesthandlers"
 	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/deployclient"
+	"yunion.io/x/onecloud/pkg/hostman/hostflowlog"
 	"yunion.io/x/onecloud/pkg/hostman/hosthandler"
 	"yunion.io/x/onecloud/pkg/hostman/hostinfo"
 	"yunion.io/x/onecloud/pkg/hostman/hostmetrics"
@@ -103,6 +104,10 @@ func (host *SHostService) RunService() {
 			// hostmetrics after guestmanager bootstrap
 			hostmetrics.Init()
 			hostmetrics.Start()
+			if options.HostOptions.EnableFlowLog && hostinfo.HasOvnSupport() {
+				hostflowlog.Init(hostInstance.GetHostId())
+				hostflowlog.Start()
+			}
 		})
 	})
 
@@ -131,6 +136,7 @@ func (host *SHostService) RunService() {
 		hostinfo.Stop()
 		storageman.Stop()
 		hostmetrics.Stop()
+		hostflowlog.Stop()
 		guestman.Stop()
 		hostutils.GetWorkManager().Stop()
 	})
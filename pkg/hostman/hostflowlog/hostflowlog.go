@@ -0,0 +1,284 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostflowlog
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+const (
+	refreshInterval = time.Minute
+	flushInterval   = 10 * time.Second
+	flushBatchSize  = 1000
+	tailInterval    = 500 * time.Millisecond
+	reopenInterval  = 5 * time.Second
+)
+
+type sFlowLogTarget struct {
+	flowLog api.SFlowLog
+	sink    IFlowLogSink
+	limiter *sRateLimiter
+	records []*api.FlowLogRecord
+	dropped int
+}
+
+// SFlowLogCollector 跟踪ovn-controller日志, 解析流日志ACL记录并投递到各流日志的目标
+type SFlowLogCollector struct {
+	hostId  string
+	logPath string
+	running bool
+
+	lock        sync.Mutex
+	targets     map[string]*sFlowLogTarget
+	lastRefresh time.Time
+	lastFlush   time.Time
+
+	// 首次打开时从文件末尾开始, 日志轮转后从新文件开头开始
+	fromStart bool
+}
+
+var flowLogCollector *SFlowLogCollector
+
+func Init(hostId string) {
+	if flowLogCollector == nil {
+		flowLogCollector = NewFlowLogCollector(hostId, options.HostOptions.OvnControllerLogPath)
+	}
+}
+
+func Start() {
+	if flowLogCollector != nil {
+		go flowLogCollector.Start()
+	}
+}
+
+func Stop() {
+	if flowLogCollector != nil {
+		flowLogCollector.Stop()
+	}
+}
+
+func NewFlowLogCollector(hostId, logPath string) *SFlowLogCollector {
+	return &SFlowLogCollector{
+		hostId:  hostId,
+		logPath: logPath,
+		targets: map[string]*sFlowLogTarget{},
+	}
+}
+
+func (c *SFlowLogCollector) Start() {
+	c.running = true
+	for c.running {
+		if err := c.tail(); err != nil {
+			log.Errorf("flowlog: tail %s: %v", c.logPath, err)
+		}
+		if c.running {
+			time.Sleep(reopenInterval)
+		}
+	}
+	c.flush(context.Background(), true)
+}
+
+func (c *SFlowLogCollector) Stop() {
+	c.running = false
+}
+
+func (c *SFlowLogCollector) tail() error {
+	f, err := os.Open(c.logPath)
+	if err != nil {
+		return errors.Wrap(err, "open")
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat")
+	}
+	if !c.fromStart {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			return errors.Wrap(err, "seek")
+		}
+	}
+	c.fromStart = true
+
+	var (
+		ctx     = context.Background()
+		reader  = bufio.NewReader(f)
+		partial string
+	)
+	for c.running {
+		c.periodic(ctx)
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			partial += line
+			if c.rotated(f, fi) {
+				return nil
+			}
+			time.Sleep(tailInterval)
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "read")
+		}
+		c.handleLine(partial + line)
+		partial = ""
+	}
+	return nil
+}
+
+// rotated 文件被替换或截断时需要重新打开
+func (c *SFlowLogCollector) rotated(f *os.File, fi os.FileInfo) bool {
+	st, err := os.Stat(c.logPath)
+	if err != nil || !os.SameFile(fi, st) {
+		return true
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return true
+	}
+	return st.Size() < offset
+}
+
+func (c *SFlowLogCollector) handleLine(line string) {
+	rec, err := parseAclLog(strings.TrimSpace(line))
+	if err != nil {
+		if errors.Cause(err) == errBadAclLogFmt {
+			log.Debugf("flowlog: %v: %s", err, line)
+		}
+		return
+	}
+	rec.HostId = c.hostId
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	target, ok := c.targets[rec.FlowLogId]
+	if !ok {
+		return
+	}
+	if !target.limiter.Allow(time.Now()) {
+		target.dropped += 1
+		return
+	}
+	target.records = append(target.records, rec)
+}
+
+func (c *SFlowLogCollector) periodic(ctx context.Context) {
+	now := time.Now()
+	if now.Sub(c.lastRefresh) >= refreshInterval {
+		c.lastRefresh = now
+		if err := c.refresh(ctx); err != nil {
+			log.Errorf("flowlog: refresh: %v", err)
+		}
+	}
+	force := now.Sub(c.lastFlush) >= flushInterval
+	if force {
+		c.lastFlush = now
+	}
+	c.flush(ctx, force)
+}
+
+// refresh 拉取已启用的流日志配置, 已删除或禁用的流日志在投递剩余记录后移除
+func (c *SFlowLogCollector) refresh(ctx context.Context) error {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.JSONTrue, "enabled")
+	params.Add(jsonutils.NewString("system"), "scope")
+	params.Add(jsonutils.NewInt(0), "limit")
+	s := hostutils.GetComputeSession(ctx)
+	result, err := modules.FlowLogs.List(s, params)
+	if err != nil {
+		return errors.Wrap(err, "list flowlogs")
+	}
+	flowLogs := map[string]api.SFlowLog{}
+	for _, obj := range result.Data {
+		fl := api.SFlowLog{}
+		if err := obj.Unmarshal(&fl); err != nil {
+			log.Errorf("flowlog: unmarshal %s: %v", obj, err)
+			continue
+		}
+		flowLogs[fl.Id] = fl
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for id, target := range c.targets {
+		if _, ok := flowLogs[id]; !ok {
+			c.flushTarget(ctx, target)
+			delete(c.targets, id)
+		}
+	}
+	for id, fl := range flowLogs {
+		sink, err := getSink(fl.SinkType)
+		if err != nil {
+			log.Errorf("flowlog %s: %v", fl.Name, err)
+			continue
+		}
+		rate := fl.RateLimit
+		if rate <= 0 {
+			rate = api.FLOW_LOG_DEFAULT_RATE_LIMIT
+		}
+		if target, ok := c.targets[id]; ok {
+			if target.flowLog.SinkType != fl.SinkType || target.flowLog.SinkPath != fl.SinkPath || target.flowLog.BucketId != fl.BucketId {
+				c.flushTarget(ctx, target)
+			}
+			target.flowLog = fl
+			target.sink = sink
+			target.limiter.SetRate(rate)
+			continue
+		}
+		c.targets[id] = &sFlowLogTarget{
+			flowLog: fl,
+			sink:    sink,
+			limiter: newRateLimiter(rate),
+		}
+	}
+	return nil
+}
+
+func (c *SFlowLogCollector) flush(ctx context.Context, force bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, target := range c.targets {
+		if force || len(target.records) >= flushBatchSize {
+			c.flushTarget(ctx, target)
+		}
+	}
+}
+
+func (c *SFlowLogCollector) flushTarget(ctx context.Context, target *sFlowLogTarget) {
+	if target.dropped > 0 {
+		log.Warningf("flowlog %s: %d records dropped by rate limit %d/s", target.flowLog.Name, target.dropped, target.flowLog.RateLimit)
+		target.dropped = 0
+	}
+	if len(target.records) == 0 {
+		return
+	}
+	recs := target.records
+	target.records = nil
+	if err := target.sink.Write(ctx, &target.flowLog, recs); err != nil {
+		log.Errorf("flowlog %s: write %d records to %s: %v", target.flowLog.Name, len(recs), target.flowLog.SinkType, err)
+	}
+}
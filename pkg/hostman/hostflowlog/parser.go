@@ -0,0 +1,127 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostflowlog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+const (
+	errNotAclLog    = errors.Error("not an acl log line")
+	errNotFlowLog   = errors.Error("acl log not generated by flow log")
+	errBadAclLogFmt = errors.Error("bad acl log format")
+)
+
+const (
+	aclLogModule   = "|acl_log"
+	flowLogNamePfx = "fl/"
+)
+
+// parseAclLog 解析ovn-controller的acl_log日志, 格式如下
+//
+//	2020-06-01T10:00:00.123Z|00005|acl_log(ovn_pinctrl0)|INFO|name="fl/<id>/in", verdict=drop, severity=info: tcp,vlan_tci=0x0000,dl_src=..,dl_dst=..,nw_src=..,nw_dst=..,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=..,tp_dst=..,tcp_flags=syn
+func parseAclLog(line string) (*api.FlowLogRecord, error) {
+	if !strings.Contains(line, aclLogModule) {
+		return nil, errNotAclLog
+	}
+	parts := strings.SplitN(line, "|", 5)
+	if len(parts) != 5 {
+		return nil, errors.Wrap(errBadAclLogFmt, "missing fields")
+	}
+	rec := &api.FlowLogRecord{}
+	if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+		rec.Timestamp = ts
+	} else {
+		rec.Timestamp = time.Now().UTC()
+	}
+
+	msg := parts[4]
+	i := strings.Index(msg, ": ")
+	if i < 0 {
+		return nil, errors.Wrap(errBadAclLogFmt, "missing flow")
+	}
+	head, flow := msg[:i], msg[i+2:]
+	for _, kv := range strings.Split(head, ", ") {
+		k, v := splitKv(kv)
+		switch k {
+		case "name":
+			v = strings.Trim(v, `"`)
+			if !strings.HasPrefix(v, flowLogNamePfx) {
+				return nil, errNotFlowLog
+			}
+			segs := strings.Split(v[len(flowLogNamePfx):], "/")
+			if len(segs) != 2 || segs[0] == "" {
+				return nil, errors.Wrapf(errBadAclLogFmt, "name %q", v)
+			}
+			rec.FlowLogId = segs[0]
+			rec.Direction = segs[1]
+		case "verdict":
+			// reject与drop同样表示流量被拒绝
+			if v == "allow" {
+				rec.Verdict = api.FLOW_LOG_VERDICT_ALLOW
+			} else {
+				rec.Verdict = api.FLOW_LOG_VERDICT_DROP
+			}
+		case "severity":
+			rec.Severity = v
+		}
+	}
+	if rec.FlowLogId == "" {
+		return nil, errNotFlowLog
+	}
+
+	for j, kv := range strings.Split(strings.TrimSpace(flow), ",") {
+		k, v := splitKv(kv)
+		if j == 0 && v == "" {
+			rec.Protocol = k
+			continue
+		}
+		switch k {
+		case "dl_src":
+			rec.SrcMac = v
+		case "dl_dst":
+			rec.DstMac = v
+		case "nw_src", "ipv6_src":
+			rec.SrcIp = v
+		case "nw_dst", "ipv6_dst":
+			rec.DstIp = v
+		case "tp_src":
+			rec.SrcPort, _ = strconv.Atoi(v)
+		case "tp_dst":
+			rec.DstPort, _ = strconv.Atoi(v)
+		case "icmp_type", "icmpv6_type":
+			rec.IcmpType, _ = strconv.Atoi(v)
+		case "icmp_code", "icmpv6_code":
+			rec.IcmpCode, _ = strconv.Atoi(v)
+		case "tcp_flags":
+			rec.TcpFlags = v
+		}
+	}
+	return rec, nil
+}
+
+func splitKv(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "="); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostflowlog
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestParseAclLog(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		want *api.FlowLogRecord
+		err  bool
+	}{
+		{
+			name: "tcp drop",
+			line: `2020-06-01T10:00:00.123Z|00005|acl_log(ovn_pinctrl0)|INFO|name="fl/f0a1/in", verdict=drop, severity=info: tcp,vlan_tci=0x0000,dl_src=00:22:01:00:00:01,dl_dst=00:22:01:00:00:02,nw_src=10.0.0.1,nw_dst=10.0.0.2,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=34567,tp_dst=22,tcp_flags=syn`,
+			want: &api.FlowLogRecord{
+				FlowLogId: "f0a1",
+				Direction: "in",
+				Verdict:   api.FLOW_LOG_VERDICT_DROP,
+				Severity:  "info",
+				Protocol:  "tcp",
+				SrcMac:    "00:22:01:00:00:01",
+				DstMac:    "00:22:01:00:00:02",
+				SrcIp:     "10.0.0.1",
+				DstIp:     "10.0.0.2",
+				SrcPort:   34567,
+				DstPort:   22,
+				TcpFlags:  "syn",
+			},
+		},
+		{
+			name: "icmp6 allow",
+			line: `2020-06-01T10:00:00.123Z|00006|acl_log(ovn_pinctrl0)|INFO|name="fl/f0a1/out", verdict=allow, severity=info: icmp6,vlan_tci=0x0000,dl_src=00:22:01:00:00:01,dl_dst=00:22:01:00:00:02,ipv6_src=fd00::1,ipv6_dst=fd00::2,ipv6_label=0x00000,nw_tos=0,nw_ecn=0,nw_ttl=64,icmp_type=128,icmp_code=0`,
+			want: &api.FlowLogRecord{
+				FlowLogId: "f0a1",
+				Direction: "out",
+				Verdict:   api.FLOW_LOG_VERDICT_ALLOW,
+				Severity:  "info",
+				Protocol:  "icmp6",
+				SrcMac:    "00:22:01:00:00:01",
+				DstMac:    "00:22:01:00:00:02",
+				SrcIp:     "fd00::1",
+				DstIp:     "fd00::2",
+				IcmpType:  128,
+			},
+		},
+		{
+			name: "unnamed acl",
+			line: `2020-06-01T10:00:00.123Z|00007|acl_log(ovn_pinctrl0)|INFO|name="<unnamed>", verdict=drop, severity=alert: udp,nw_src=10.0.0.1,nw_dst=10.0.0.2`,
+			err:  true,
+		},
+		{
+			name: "other module",
+			line: `2020-06-01T10:00:00.123Z|00008|binding|INFO|Claiming lport`,
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseAclLog(c.line)
+			if c.err {
+				if err == nil {
+					t.Fatalf("want error, got %#v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Timestamp.IsZero() {
+				t.Errorf("timestamp not parsed")
+			}
+			got.Timestamp = c.want.Timestamp
+			if *got != *c.want {
+				t.Errorf("got %#v\nwant %#v", got, c.want)
+			}
+		})
+	}
+}
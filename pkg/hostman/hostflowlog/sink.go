@@ -0,0 +1,148 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostflowlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+type IFlowLogSink interface {
+	Write(ctx context.Context, fl *api.SFlowLog, recs []*api.FlowLogRecord) error
+}
+
+func getSink(sinkType string) (IFlowLogSink, error) {
+	switch sinkType {
+	case api.FLOW_LOG_SINK_FILE, "":
+		return &sFileSink{}, nil
+	case api.FLOW_LOG_SINK_BUCKET:
+		return &sBucketSink{}, nil
+	case api.FLOW_LOG_SINK_LOGGER:
+		return &sLoggerSink{}, nil
+	}
+	return nil, errors.Wrapf(errors.ErrNotSupported, "sink type %s", sinkType)
+}
+
+func marshalRecords(recs []*api.FlowLogRecord) []byte {
+	buf := &bytes.Buffer{}
+	for _, rec := range recs {
+		buf.WriteString(jsonutils.Marshal(rec).String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// sFileSink 以JSON Lines格式追加写入宿主机本地文件
+type sFileSink struct{}
+
+func (sink *sFileSink) Write(ctx context.Context, fl *api.SFlowLog, recs []*api.FlowLogRecord) error {
+	fpath := fl.SinkPath
+	if len(fpath) == 0 {
+		fpath = filepath.Join(options.HostOptions.FlowLogDir, fl.Id+".log")
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return errors.Wrapf(err, "mkdir %s", filepath.Dir(fpath))
+	}
+	f, err := os.OpenFile(fpath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "open %s", fpath)
+	}
+	defer f.Close()
+	if _, err := f.Write(marshalRecords(recs)); err != nil {
+		return errors.Wrapf(err, "write %s", fpath)
+	}
+	return nil
+}
+
+// sBucketSink 每批记录上传为存储桶中的一个对象
+type sBucketSink struct{}
+
+func (sink *sBucketSink) Write(ctx context.Context, fl *api.SFlowLog, recs []*api.FlowLogRecord) error {
+	prefix := fl.SinkPath
+	if len(prefix) == 0 {
+		prefix = path.Join("flowlogs", fl.Id)
+	}
+	hostId := ""
+	if len(recs) > 0 {
+		hostId = recs[0].HostId
+	}
+	key := path.Join(prefix, hostId, fmt.Sprintf("%s.json", time.Now().UTC().Format("20060102T150405.000000Z")))
+	body := marshalRecords(recs)
+	s := hostutils.GetComputeSession(ctx)
+	err := modules.Buckets.Upload(s, fl.BucketId, key, bytes.NewReader(body), int64(len(body)), "", "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "upload %s to bucket %s", key, fl.BucketId)
+	}
+	return nil
+}
+
+// 日志服务中单条流日志记录的records字段为TEXT(64KB), 每块远小于此上限
+const loggerSinkChunkBytes = 32 * 1024
+
+// chunkRecords 将记录按JSON Lines切分为不超过maxBytes的块, 超长的单条记录独占一块
+func chunkRecords(recs []*api.FlowLogRecord, maxBytes int) [][]*api.FlowLogRecord {
+	chunks := [][]*api.FlowLogRecord{}
+	chunk := []*api.FlowLogRecord{}
+	size := 0
+	for _, rec := range recs {
+		recSize := len(jsonutils.Marshal(rec).String()) + 1
+		if len(chunk) > 0 && size+recSize > maxBytes {
+			chunks = append(chunks, chunk)
+			chunk, size = []*api.FlowLogRecord{}, 0
+		}
+		chunk = append(chunk, rec)
+		size += recSize
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// sLoggerSink 每批记录切块写入日志服务的流日志记录, 不写入操作日志
+type sLoggerSink struct{}
+
+func (sink *sLoggerSink) Write(ctx context.Context, fl *api.SFlowLog, recs []*api.FlowLogRecord) error {
+	s := hostutils.GetComputeSession(ctx)
+	for _, chunk := range chunkRecords(recs, loggerSinkChunkBytes) {
+		params := jsonutils.NewDict()
+		params.Add(jsonutils.NewString(fl.Id), "flow_log_id")
+		params.Add(jsonutils.NewString(fl.Name), "flow_log_name")
+		params.Add(jsonutils.NewString(chunk[0].HostId), "host_id")
+		params.Add(jsonutils.NewString(fl.ProjectId), "owner_tenant_id")
+		params.Add(jsonutils.NewString(fl.DomainId), "owner_domain_id")
+		params.Add(jsonutils.NewTimeString(chunk[0].Timestamp), "start_time")
+		params.Add(jsonutils.NewTimeString(chunk[len(chunk)-1].Timestamp), "end_time")
+		params.Add(jsonutils.NewInt(int64(len(chunk))), "count")
+		params.Add(jsonutils.NewString(string(marshalRecords(chunk))), "records")
+		if _, err := modules.FlowLogRecords.Create(s, params); err != nil {
+			return errors.Wrap(err, "create flow log records")
+		}
+	}
+	return nil
+}
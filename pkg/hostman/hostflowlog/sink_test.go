@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostflowlog
+
+import (
+	"strings"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestChunkRecords(t *testing.T) {
+	recs := []*api.FlowLogRecord{}
+	for i := 0; i < 1000; i++ {
+		recs = append(recs, &api.FlowLogRecord{
+			FlowLogId: "fl",
+			HostId:    "host",
+			Verdict:   "allow",
+			Protocol:  "tcp",
+			SrcIp:     "10.0.0.1",
+			DstIp:     "10.0.0.2",
+			SrcPort:   1024 + i,
+			DstPort:   22,
+		})
+	}
+	chunks := chunkRecords(recs, loggerSinkChunkBytes)
+	if len(chunks) < 2 {
+		t.Fatalf("1000 records should be split into chunks, got %d", len(chunks))
+	}
+	total := 0
+	for i, chunk := range chunks {
+		if size := len(marshalRecords(chunk)); size > loggerSinkChunkBytes {
+			t.Errorf("chunk %d has %d bytes, exceeds %d", i, size, loggerSinkChunkBytes)
+		}
+		total += len(chunk)
+	}
+	if total != len(recs) || chunks[1][0] != recs[len(chunks[0])] {
+		t.Errorf("records lost or reordered when chunking")
+	}
+
+	// 超长的单条记录独占一块
+	big := &api.FlowLogRecord{FlowLogId: strings.Repeat("x", 100)}
+	chunks = chunkRecords([]*api.FlowLogRecord{recs[0], big, recs[1]}, 100)
+	if len(chunks) != 3 || chunks[1][0] != big {
+		t.Errorf("oversize record should be in its own chunk, got %d chunks", len(chunks))
+	}
+	if len(chunkRecords(nil, loggerSinkChunkBytes)) != 0 {
+		t.Errorf("no chunk expected for empty records")
+	}
+}
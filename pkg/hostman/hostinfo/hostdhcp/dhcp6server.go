@@ -0,0 +1,353 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostdhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/mdlayher/raw"
+	"golang.org/x/net/bpf"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	guestman "yunion.io/x/onecloud/pkg/hostman/guestman/types"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+)
+
+const (
+	DHCP6_SERVER_PORT = 547
+	DHCP6_CLIENT_PORT = 546
+
+	// RA中的M和O标志，告知客户端通过DHCPv6获取地址及DNS
+	RA_FLAG_MANAGED = 0x80
+	RA_FLAG_OTHER   = 0x40
+
+	RA_PREFIX_FLAG_ONLINK = 0x80
+)
+
+// SGuestDHCP6Server 在经典网络的网桥上应答虚拟机的Router Solicitation与DHCPv6请求，
+// 地址来自region为网卡分配的ip6，不做SLAAC自动配置
+type SGuestDHCP6Server struct {
+	conn *raw.Conn
+	ifi  *net.Interface
+
+	linkLocal net.IP
+	iface     string
+}
+
+func NewGuestDHCP6Server(iface string) (*SGuestDHCP6Server, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, errors.Wrapf(err, "InterfaceByName %s", iface)
+	}
+	// ip6 and ((icmp6 and ip6[40] == 133) or (udp and dst port 547))
+	filter, err := bpf.Assemble([]bpf.Instruction{
+		bpf.LoadAbsolute{Off: 12, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.EthernetTypeIPv6), SkipFalse: 8},
+		bpf.LoadAbsolute{Off: 20, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.IPProtocolICMPv6), SkipFalse: 2},
+		bpf.LoadAbsolute{Off: 54, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: layers.ICMPv6TypeRouterSolicitation, SkipTrue: 3, SkipFalse: 4},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.IPProtocolUDP), SkipFalse: 3},
+		bpf.LoadAbsolute{Off: 56, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: DHCP6_SERVER_PORT, SkipFalse: 1},
+		bpf.RetConstant{Val: 0x00040000},
+		bpf.RetConstant{Val: 0},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "assemble bpf filter")
+	}
+	linkLocal := getLinkLocalAddr(ifi)
+	if linkLocal == nil {
+		return nil, errors.Errorf("no link local address for %s", iface)
+	}
+	conn, err := raw.ListenPacket(ifi, uint16(layers.EthernetTypeIPv6), &raw.Config{
+		NoCumulativeStats: true,
+		Filter:            filter,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen ipv6 packet on %s", iface)
+	}
+	return &SGuestDHCP6Server{
+		conn:      conn,
+		ifi:       ifi,
+		linkLocal: linkLocal,
+		iface:     iface,
+	}, nil
+}
+
+func getLinkLocalAddr(ifi *net.Interface) net.IP {
+	addrs, _ := ifi.Addrs()
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() == nil && ipnet.IP.IsLinkLocalUnicast() {
+			return ipnet.IP
+		}
+	}
+	prefix, _ := netutils2.NewIPV6Prefix("fe80::/64")
+	addr, err := netutils2.MacToEUI64(prefix, ifi.HardwareAddr.String())
+	if err != nil {
+		return nil
+	}
+	return addr.ToIP()
+}
+
+func (s *SGuestDHCP6Server) Start() {
+	log.Infof("SGuestDHCP6Server starting on %s ...", s.iface)
+	go func() {
+		defer s.conn.Close()
+		var buf [1500]byte
+		for {
+			n, _, err := s.conn.ReadFrom(buf[:])
+			if err != nil {
+				log.Errorf("DHCPv6 read error: %s", err)
+				return
+			}
+			if err := s.serve(buf[:n]); err != nil {
+				log.Errorf("DHCPv6 serve error: %s", err)
+			}
+		}
+	}()
+}
+
+func (s *SGuestDHCP6Server) getNicDesc(mac string) (jsonutils.JSONObject, *types.SServerNic) {
+	guestDesc, guestNic := guestman.GuestDescGetter.GetGuestNicDesc(mac, "", "", s.iface, false)
+	if guestNic == nil {
+		guestDesc, guestNic = guestman.GuestDescGetter.GetGuestNicDesc(mac, "", "", s.iface, true)
+	}
+	if guestNic == nil || jsonutils.QueryBoolean(guestNic, "virtual", false) {
+		return nil, nil
+	}
+	nic := new(types.SServerNic)
+	if err := guestNic.Unmarshal(nic); err != nil {
+		log.Errorf("unmarshal guest nic %s: %s", guestNic, err)
+		return nil, nil
+	}
+	if len(nic.Ip6) == 0 {
+		return nil, nil
+	}
+	return guestDesc, nic
+}
+
+func (s *SGuestDHCP6Server) serve(data []byte) error {
+	pkt := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.Default)
+	ethLayer, ok := pkt.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	if !ok {
+		return nil
+	}
+	ip6Layer, ok := pkt.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	if !ok {
+		return nil
+	}
+	guestDesc, nic := s.getNicDesc(ethLayer.SrcMAC.String())
+	if nic == nil {
+		return nil
+	}
+	if pkt.Layer(layers.LayerTypeICMPv6RouterSolicitation) != nil {
+		return s.sendRouterAdvertisement(ethLayer, ip6Layer, nic)
+	}
+	if dhcp6, ok := pkt.Layer(layers.LayerTypeDHCPv6).(*layers.DHCPv6); ok {
+		return s.replyDHCPv6(ethLayer, ip6Layer, dhcp6, guestDesc, nic)
+	}
+	return nil
+}
+
+func (s *SGuestDHCP6Server) send(eth *layers.Ethernet, ip6 *layers.IPv6, ls ...gopacket.SerializableLayer) error {
+	var (
+		buf  = gopacket.NewSerializeBuffer()
+		opts = gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}
+	)
+	if err := gopacket.SerializeLayers(buf, opts, append([]gopacket.SerializableLayer{eth, ip6}, ls...)...); err != nil {
+		return errors.Wrap(err, "SerializeLayers")
+	}
+	if _, err := s.conn.WriteTo(buf.Bytes(), &raw.Addr{HardwareAddr: eth.DstMAC}); err != nil {
+		return errors.Wrap(err, "send ipv6 packet")
+	}
+	return nil
+}
+
+func (s *SGuestDHCP6Server) replyHeaders(reqEth *layers.Ethernet, reqIp6 *layers.IPv6, nextHeader layers.IPProtocol, hopLimit uint8) (*layers.Ethernet, *layers.IPv6) {
+	dstIp := reqIp6.SrcIP
+	if dstIp.IsUnspecified() {
+		dstIp = net.IPv6linklocalallnodes
+	}
+	eth := &layers.Ethernet{
+		EthernetType: layers.EthernetTypeIPv6,
+		SrcMAC:       s.ifi.HardwareAddr,
+		DstMAC:       reqEth.SrcMAC,
+	}
+	ip6 := &layers.IPv6{
+		Version:    6,
+		NextHeader: nextHeader,
+		HopLimit:   hopLimit,
+		SrcIP:      s.linkLocal,
+		DstIP:      dstIp,
+	}
+	return eth, ip6
+}
+
+// sendRouterAdvertisement 应答RS，宣告on-link前缀并要求使用DHCPv6；
+// 宿主机并非路由器，RouterLifetime为0，默认路由仍由网关自身的RA提供
+func (s *SGuestDHCP6Server) sendRouterAdvertisement(reqEth *layers.Ethernet, reqIp6 *layers.IPv6, nic *types.SServerNic) error {
+	ip6, err := netutils2.NewIPV6Addr(nic.Ip6)
+	if err != nil {
+		return errors.Wrapf(err, "invalid nic ip6 %s", nic.Ip6)
+	}
+	lifetime := uint32(options.HostOptions.DhcpLeaseTime)
+	prefixInfo := make([]byte, 30)
+	prefixInfo[0] = byte(nic.Masklen6)
+	prefixInfo[1] = RA_PREFIX_FLAG_ONLINK
+	binary.BigEndian.PutUint32(prefixInfo[2:6], lifetime)
+	binary.BigEndian.PutUint32(prefixInfo[6:10], lifetime)
+	copy(prefixInfo[14:], ip6.NetAddr(uint8(nic.Masklen6)).ToIP())
+
+	raOpts := layers.ICMPv6Options{
+		{Type: layers.ICMPv6OptSourceAddress, Data: s.ifi.HardwareAddr},
+		{Type: layers.ICMPv6OptPrefixInfo, Data: prefixInfo},
+	}
+	if nic.Mtu > 0 {
+		mtu := make([]byte, 6)
+		binary.BigEndian.PutUint32(mtu[2:], uint32(nic.Mtu))
+		raOpts = append(raOpts, layers.ICMPv6Option{Type: layers.ICMPv6OptMTU, Data: mtu})
+	}
+
+	eth, ipLayer := s.replyHeaders(reqEth, reqIp6, layers.IPProtocolICMPv6, 255)
+	icmp := &layers.ICMPv6{
+		TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeRouterAdvertisement, 0),
+	}
+	icmp.SetNetworkLayerForChecksum(ipLayer)
+	ra := &layers.ICMPv6RouterAdvertisement{
+		HopLimit: 64,
+		Flags:    RA_FLAG_MANAGED | RA_FLAG_OTHER,
+		Options:  raOpts,
+	}
+	log.Debugf("Make RA for %s prefix %s/%d", reqEth.SrcMAC, ip6.NetAddr(uint8(nic.Masklen6)), nic.Masklen6)
+	return s.send(eth, ipLayer, icmp, ra)
+}
+
+func (s *SGuestDHCP6Server) serverDUID() []byte {
+	duid := &layers.DHCPv6DUID{
+		Type:             layers.DHCPv6DUIDTypeLL,
+		HardwareType:     []byte{0, 1},
+		LinkLayerAddress: s.ifi.HardwareAddr,
+	}
+	return duid.Encode()
+}
+
+func encodeDomainList(domain string) []byte {
+	data := make([]byte, 0, len(domain)+2)
+	for _, label := range strings.Split(strings.Trim(domain, "."), ".") {
+		if len(label) == 0 {
+			continue
+		}
+		data = append(data, byte(len(label)))
+		data = append(data, label...)
+	}
+	return append(data, 0)
+}
+
+func (s *SGuestDHCP6Server) iaNAOption(iaid []byte, nic *types.SServerNic) layers.DHCPv6Option {
+	var (
+		lease   = uint32(options.HostOptions.DhcpLeaseTime)
+		renewal = uint32(options.HostOptions.DhcpRenewalTime)
+		ip6, _  = netutils2.NewIPV6Addr(nic.Ip6)
+	)
+	iaAddr := make([]byte, 24)
+	copy(iaAddr[:16], ip6.ToIP())
+	binary.BigEndian.PutUint32(iaAddr[16:20], lease)
+	binary.BigEndian.PutUint32(iaAddr[20:24], lease)
+	addrOpt := layers.NewDHCPv6Option(layers.DHCPv6OptIAAddr, iaAddr)
+
+	data := make([]byte, 12, 12+4+len(iaAddr))
+	copy(data[:4], iaid)
+	binary.BigEndian.PutUint32(data[4:8], renewal)
+	binary.BigEndian.PutUint32(data[8:12], lease/5*4)
+	hdr := make([]byte, 4)
+	binary.BigEndian.PutUint16(hdr[:2], uint16(addrOpt.Code))
+	binary.BigEndian.PutUint16(hdr[2:], addrOpt.Length)
+	data = append(append(data, hdr...), addrOpt.Data...)
+	return layers.NewDHCPv6Option(layers.DHCPv6OptIANA, data)
+}
+
+func (s *SGuestDHCP6Server) replyDHCPv6(reqEth *layers.Ethernet, reqIp6 *layers.IPv6, req *layers.DHCPv6, guestDesc jsonutils.JSONObject, nic *types.SServerNic) error {
+	var (
+		msgType  layers.DHCPv6MsgType
+		clientId []byte
+		iaid     []byte
+		rapid    bool
+	)
+	for _, opt := range req.Options {
+		switch opt.Code {
+		case layers.DHCPv6OptClientID:
+			clientId = opt.Data
+		case layers.DHCPv6OptIANA:
+			if len(opt.Data) >= 4 {
+				iaid = opt.Data[:4]
+			}
+		case layers.DHCPv6OptRapidCommit:
+			rapid = true
+		}
+	}
+	switch req.MsgType {
+	case layers.DHCPv6MsgTypeSolicit:
+		msgType = layers.DHCPv6MsgTypeAdverstise
+		if rapid {
+			msgType = layers.DHCPv6MsgTypeReply
+		}
+	case layers.DHCPv6MsgTypeRequest, layers.DHCPv6MsgTypeRenew, layers.DHCPv6MsgTypeRebind,
+		layers.DHCPv6MsgTypeConfirm, layers.DHCPv6MsgTypeInformationRequest:
+		msgType = layers.DHCPv6MsgTypeReply
+	default:
+		return nil
+	}
+
+	reply := &layers.DHCPv6{
+		MsgType:       msgType,
+		TransactionID: req.TransactionID,
+	}
+	reply.Options = append(reply.Options, layers.NewDHCPv6Option(layers.DHCPv6OptServerID, s.serverDUID()))
+	if len(clientId) > 0 {
+		reply.Options = append(reply.Options, layers.NewDHCPv6Option(layers.DHCPv6OptClientID, clientId))
+	}
+	if iaid != nil && req.MsgType != layers.DHCPv6MsgTypeInformationRequest {
+		reply.Options = append(reply.Options, s.iaNAOption(iaid, nic))
+	}
+	if rapid && msgType == layers.DHCPv6MsgTypeReply && req.MsgType == layers.DHCPv6MsgTypeSolicit {
+		reply.Options = append(reply.Options, layers.NewDHCPv6Option(layers.DHCPv6OptRapidCommit, nil))
+	}
+	if dns6 := net.ParseIP(nic.Dns6); dns6 != nil {
+		reply.Options = append(reply.Options, layers.NewDHCPv6Option(layers.DHCPv6OptDNSServers, dns6.To16()))
+	}
+	if len(nic.Domain) > 0 {
+		reply.Options = append(reply.Options, layers.NewDHCPv6Option(layers.DHCPv6OptDomainList, encodeDomainList(nic.Domain)))
+	}
+
+	eth, ipLayer := s.replyHeaders(reqEth, reqIp6, layers.IPProtocolUDP, 64)
+	udp := &layers.UDP{
+		SrcPort: DHCP6_SERVER_PORT,
+		DstPort: DHCP6_CLIENT_PORT,
+	}
+	udp.SetNetworkLayerForChecksum(ipLayer)
+	name, _ := guestDesc.GetString("name")
+	log.Infof("Make DHCPv6 %s %s TO %s(%s)", msgType, nic.Ip6, reqEth.SrcMAC, name)
+	return s.send(eth, ipLayer, udp, reply)
+}
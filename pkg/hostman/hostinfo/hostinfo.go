@@ -118,6 +118,9 @@ func (h *SHostInfo) GetBridgeDev(bridge string) hostbridge.IBridgeDriver {
 func (h *SHostInfo) StartDHCPServer() {
 	for _, nic := range h.Nics {
 		nic.dhcpServer.Start()
+		if nic.dhcp6Server != nil {
+			nic.dhcp6Server.Start()
+		}
 	}
 }
 
@@ -963,6 +966,23 @@ func (h *SHostInfo) getSysInfo() *SSysInfo {
 	return h.sysinfo
 }
 
+func (h *SHostInfo) getTopology() *api.HostTopology {
+	nodes, err := cgrouputils.GetNumaNodes()
+	if err != nil {
+		log.Errorf("get numa topology: %s", err)
+		return nil
+	}
+	topology := &api.HostTopology{}
+	for _, node := range nodes {
+		topology.Nodes = append(topology.Nodes, api.HostNumaNode{
+			NodeId:    node.NodeId,
+			Cpus:      node.Cpus,
+			MemSizeMb: node.MemSizeMb,
+		})
+	}
+	return topology
+}
+
 func (h *SHostInfo) updateHostRecord(hostId string) {
 	if len(hostId) == 0 {
 		h.isInit = true
@@ -990,6 +1010,9 @@ func (h *SHostInfo) updateHostRecord(hostId string) {
 	} else {
 		content.Set("node_count", jsonutils.NewInt(int64(h.Cpu.cpuInfoDmi.Nodes)))
 	}
+	if topology := h.getTopology(); topology != nil {
+		content.Set("topology", jsonutils.Marshal(topology))
+	}
 	content.Set("cpu_desc", jsonutils.NewString(h.Cpu.cpuInfoProc.Model))
 	content.Set("cpu_microcode", jsonutils.NewString(h.Cpu.cpuInfoProc.Microcode))
 	content.Set("cpu_architecture", jsonutils.NewString(h.Cpu.CpuArchitecture))
@@ -240,6 +240,8 @@ type SNIC struct {
 	Bandwidth  int
 	BridgeDev  hostbridge.IBridgeDriver
 	dhcpServer *hostdhcp.SGuestDHCPServer
+	// 可能为nil，宿主机网桥未启用IPv6时不提供DHCPv6
+	dhcp6Server *hostdhcp.SGuestDHCP6Server
 }
 
 func (n *SNIC) EnableDHCPRelay() bool {
@@ -363,6 +365,12 @@ func NewNIC(desc string) (*SNIC, error) {
 	if err != nil {
 		return nil, err
 	}
+	if options.HostOptions.EnableDhcp6 {
+		nic.dhcp6Server, err = hostdhcp.NewGuestDHCP6Server(nic.Bridge)
+		if err != nil {
+			log.Warningf("create dhcpv6 server on %s: %s", nic.Bridge, err)
+		}
+	}
 	// dhcp server start after guest manager init
 	return nic, nil
 }
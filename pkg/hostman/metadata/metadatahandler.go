@@ -126,11 +126,11 @@ func (s *Service) metaData(ctx context.Context, w http.ResponseWriter, r *http.R
 			"instance-id", "instance-type",
 			"local-hostname", "local-ipv4", "mac",
 			"public-hostname", "public-ipv4",
-			"network_config/",
+			"network_config/", "network/",
 			"local-sub-ipv4s",
 			//"amiid", "ami-manifest-path",
 			//"instance-action", "kernel-id",
-			//"ipv4-associations", "network/",
+			//"ipv4-associations",
 			//"placement/", "public-keys/",
 			//"reservation-id", "security-groups", "password",
 		}
@@ -257,6 +257,11 @@ func (s *Service) metaData(ctx context.Context, w http.ResponseWriter, r *http.R
 					return
 				}
 			}
+		case "network":
+			if resp, ok := networkInterfacesMetaData(guestDesc, req[1:]); ok {
+				hostutils.Response(ctx, w, resp)
+				return
+			}
 		case "block-device-mapping":
 			guestDisks, _ := guestDesc.GetArray("disks")
 			swapDisks := make([]string, 0)
@@ -304,3 +309,57 @@ func (s *Service) metaData(ctx context.Context, w http.ResponseWriter, r *http.R
 	}
 	hostutils.Response(ctx, w, httperrors.NewNotFoundError("Resource not handled"))
 }
+
+// networkInterfacesMetaData 按EC2格式输出network/interfaces/macs/，
+// cloud-init根据ipv6s是否存在决定是否在该网卡上开启dhcp6
+func networkInterfacesMetaData(guestDesc jsonutils.JSONObject, req []string) (string, bool) {
+	prefix := []string{"interfaces", "macs"}
+	for i := range prefix {
+		if len(req) == i {
+			return prefix[i] + "/", true
+		}
+		if req[i] != prefix[i] {
+			return "", false
+		}
+	}
+	req = req[len(prefix):]
+	guestNics, _ := guestDesc.GetArray("nics")
+	if len(req) == 0 {
+		macs := make([]string, 0, len(guestNics))
+		for _, nic := range guestNics {
+			nicMac, _ := nic.GetString("mac")
+			macs = append(macs, nicMac+"/")
+		}
+		return strings.Join(macs, "\n"), true
+	}
+	for _, nic := range guestNics {
+		nicMac, _ := nic.GetString("mac")
+		if nicMac != req[0] {
+			continue
+		}
+		nicIp, _ := nic.GetString("ip")
+		nicIp6, _ := nic.GetString("ip6")
+		index, _ := nic.Int("index")
+		if len(req) == 1 {
+			keys := []string{"device-number", "local-ipv4s", "mac"}
+			if len(nicIp6) > 0 {
+				keys = append(keys, "ipv6s")
+			}
+			return strings.Join(keys, "\n"), true
+		}
+		switch req[1] {
+		case "device-number":
+			return strconv.Itoa(int(index)), true
+		case "local-ipv4s":
+			return nicIp, true
+		case "mac":
+			return nicMac, true
+		case "ipv6s":
+			if len(nicIp6) > 0 {
+				return nicIp6, true
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
@@ -436,6 +436,13 @@ func (m *HmpMonitor) GetCpuCount(callback func(count int)) {
 	m.Query("info cpus", cb)
 }
 
+func (m *HmpMonitor) GetCpuThreadIds(callback func(tids []int)) {
+	var cb = func(output string) {
+		callback(parseCpuThreadIds(output))
+	}
+	m.Query("info cpus", cb)
+}
+
 func (m *HmpMonitor) AddCpu(cpuIndex int, callback StringCallback) {
 	m.Query(fmt.Sprintf("cpu-add %d", cpuIndex), callback)
 }
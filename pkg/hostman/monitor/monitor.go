@@ -17,6 +17,8 @@ package monitor
 import (
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
 	"sync"
 	"time"
 
@@ -44,6 +46,7 @@ type Monitor interface {
 	GetBlockJobs(func(*jsonutils.JSONArray))
 
 	GetCpuCount(func(count int))
+	GetCpuThreadIds(func(tids []int))
 	AddCpu(cpuIndex int, callback StringCallback)
 	GeMemtSlotIndex(func(index int))
 
@@ -163,3 +166,19 @@ func (m *SBaseMonitor) checkWriting() bool {
 	}
 	return true
 }
+
+var cpuThreadIdRegexp = regexp.MustCompile(`CPU #(\d+):.*?thread_id=(\d+)`)
+
+// parseCpuThreadIds 解析 info cpus 输出, 按 vCPU 序号返回对应的线程号
+func parseCpuThreadIds(output string) []int {
+	matches := cpuThreadIdRegexp.FindAllStringSubmatch(output, -1)
+	tids := make([]int, len(matches))
+	for _, m := range matches {
+		idx, _ := strconv.Atoi(m[1])
+		tid, _ := strconv.Atoi(m[2])
+		if idx < len(tids) {
+			tids[idx] = tid
+		}
+	}
+	return tids
+}
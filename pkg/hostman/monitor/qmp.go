@@ -783,6 +783,13 @@ func (m *QmpMonitor) GetCpuCount(callback func(count int)) {
 	m.HumanMonitorCommand("info cpus", cb)
 }
 
+func (m *QmpMonitor) GetCpuThreadIds(callback func(tids []int)) {
+	var cb = func(res string) {
+		callback(parseCpuThreadIds(res))
+	}
+	m.HumanMonitorCommand("info cpus", cb)
+}
+
 func (m *QmpMonitor) AddCpu(cpuIndex int, callback StringCallback) {
 	var (
 		cb = func(res *Response) {
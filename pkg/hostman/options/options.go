@@ -73,6 +73,7 @@ type SHostOptions struct {
 	DhcpRelay       []string `help:"DHCP relay upstream"`
 	DhcpLeaseTime   int      `default:"100663296" help:"DHCP lease time in seconds"`
 	DhcpRenewalTime int      `default:"67108864" help:"DHCP renewal time in seconds"`
+	EnableDhcp6     bool     `default:"true" help:"Answer DHCPv6 and router solicitation of guests on classic networks"`
 
 	TunnelPaddingBytes int64 `help:"Specify tunnel padding bytes" default:"0"`
 
@@ -132,6 +133,10 @@ type SHostOptions struct {
 	OvnEipBridge              string `help:"name of bridge for eip traffic management" default:"$HOST_OVN_EIP_BRIDGE|breip"`
 	OvnUnderlayMtu            int    `help:"mtu of ovn underlay network" default:"1500"`
 
+	EnableFlowLog        bool   `help:"collect vpc flow logs from ovn-controller acl logs" default:"$HOST_ENABLE_FLOW_LOG|false"`
+	OvnControllerLogPath string `help:"path of ovn-controller log file" default:"$HOST_OVN_CONTROLLER_LOG_PATH|/var/log/openvswitch/ovn-controller.log"`
+	FlowLogDir           string `help:"directory of flow log files when sink type is file" default:"/var/log/onecloud/flowlogs"`
+
 	EnableRemoteExecutor bool   `help:"Enable remote executor" default:"false"`
 	EnableHealthChecker  bool   `help:"enable host health checker" default:"true"`
 	HealthDriver         string `help:"Component save host health state" default:"etcd"`
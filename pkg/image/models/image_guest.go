@@ -36,6 +36,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/image/options"
@@ -620,9 +621,9 @@ func (manager *SGuestImageManager) QueryDistinctExtraField(q *sqlchemy.SQuery, f
 	return q, httperrors.ErrNotFound
 }
 
-func (manager *SGuestImageManager) Usage(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, prefix string) map[string]int64 {
+func (manager *SGuestImageManager) Usage(ctx context.Context, scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, prefix string) map[string]int64 {
 	usages := make(map[string]int64)
-	count := ImageManager.count(scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.False, false, tristate.True)
+	count := ImageManager.count(ctx, scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.False, false, tristate.True)
 	expandUsageCount(usages, prefix, "guest_image", "", count)
 	sq := manager.Query()
 	switch scope {
@@ -633,7 +634,7 @@ func (manager *SGuestImageManager) Usage(scope rbacutils.TRbacScope, ownerId mcc
 	case rbacutils.ScopeProject:
 		sq = sq.Equals("tenant_id", ownerId.GetProjectId())
 	}
-	cnt, _ := sq.CountWithError()
+	cnt, _ := replica.Count(ctx, sq)
 	key := []string{}
 	if len(prefix) > 0 {
 		key = append(key, prefix)
@@ -41,6 +41,7 @@ import (
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/image/options"
@@ -903,7 +904,7 @@ type SImageUsage struct {
 	Size  int64
 }
 
-func (manager *SImageManager) count(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, status string, isISO tristate.TriState, pendingDelete bool, guestImage tristate.TriState) map[string]SImageUsage {
+func (manager *SImageManager) count(ctx context.Context, scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, status string, isISO tristate.TriState, pendingDelete bool, guestImage tristate.TriState) map[string]SImageUsage {
 	sq := manager.Query("id")
 	switch scope {
 	case rbacutils.ScopeSystem:
@@ -933,7 +934,7 @@ func (manager *SImageManager) count(scope rbacutils.TRbacScope, ownerId mcclient
 	} else if isISO.IsFalse() {
 		sq = sq.NotEquals("disk_format", "iso")
 	}
-	cnt, _ := sq.CountWithError()
+	cnt, _ := replica.Count(ctx, sq)
 
 	subimages := ImageSubformatManager.Query().SubQuery()
 	q := subimages.Query(subimages.Field("format"),
@@ -947,7 +948,7 @@ func (manager *SImageManager) count(scope rbacutils.TRbacScope, ownerId mcclient
 		Size   int64
 	}
 	var usages []sFormatImageUsage
-	err := q.All(&usages)
+	err := replica.All(ctx, q, &usages)
 	if err != nil {
 		log.Errorf("query usage fail %s", err)
 		return nil
@@ -980,19 +981,19 @@ func expandUsageCount(usages map[string]int64, prefix, imgType, state string, co
 	}
 }
 
-func (manager *SImageManager) Usage(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, prefix string) map[string]int64 {
+func (manager *SImageManager) Usage(ctx context.Context, scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, prefix string) map[string]int64 {
 	usages := make(map[string]int64)
-	count := manager.count(scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.False, false, tristate.False)
+	count := manager.count(ctx, scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.False, false, tristate.False)
 	expandUsageCount(usages, prefix, "img", "", count)
-	count = manager.count(scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.True, false, tristate.False)
+	count = manager.count(ctx, scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.True, false, tristate.False)
 	expandUsageCount(usages, prefix, "iso", "", count)
-	count = manager.count(scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.None, false, tristate.False)
+	count = manager.count(ctx, scope, ownerId, api.IMAGE_STATUS_ACTIVE, tristate.None, false, tristate.False)
 	expandUsageCount(usages, prefix, "imgiso", "", count)
-	count = manager.count(scope, ownerId, "", tristate.False, true, tristate.False)
+	count = manager.count(ctx, scope, ownerId, "", tristate.False, true, tristate.False)
 	expandUsageCount(usages, prefix, "img", "pending_delete", count)
-	count = manager.count(scope, ownerId, "", tristate.True, true, tristate.False)
+	count = manager.count(ctx, scope, ownerId, "", tristate.True, true, tristate.False)
 	expandUsageCount(usages, prefix, "iso", "pending_delete", count)
-	count = manager.count(scope, ownerId, "", tristate.None, true, tristate.False)
+	count = manager.count(ctx, scope, ownerId, "", tristate.None, true, tristate.False)
 	expandUsageCount(usages, prefix, "imgiso", "pending_delete", count)
 	return usages
 }
@@ -135,7 +135,7 @@ func (self *SQuota) FetchUsage(ctx context.Context) error {
 		isISO = tristate.None
 	}
 
-	count := ImageManager.count(scope, ownerId, "", isISO, false, tristate.None)
+	count := ImageManager.count(ctx, scope, ownerId, "", isISO, false, tristate.None)
 	self.Image = int(count["total"].Count)
 	return nil
 }
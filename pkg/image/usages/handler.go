@@ -23,6 +23,7 @@ import (
 
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/replica"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/image/models"
@@ -45,25 +46,27 @@ func ReportGeneralUsage(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// 用量统计只读, 可以在只读库上执行
+	ctx = replica.WithReadReplica(ctx)
 	usages := jsonutils.NewDict()
 	if scope == rbacutils.ScopeSystem {
-		adminUsage := models.ImageManager.Usage(rbacutils.ScopeSystem, ownerId, "all")
+		adminUsage := models.ImageManager.Usage(ctx, rbacutils.ScopeSystem, ownerId, "all")
 		usages.Update(jsonutils.Marshal(adminUsage))
-		adminUsage = models.GuestImageManager.Usage(rbacutils.ScopeSystem, ownerId, "all")
+		adminUsage = models.GuestImageManager.Usage(ctx, rbacutils.ScopeSystem, ownerId, "all")
 		usages.Update(jsonutils.Marshal(adminUsage))
 	}
 
 	if scope.HigherEqual(rbacutils.ScopeDomain) {
-		domainUsage := models.ImageManager.Usage(rbacutils.ScopeDomain, ownerId, "domain")
+		domainUsage := models.ImageManager.Usage(ctx, rbacutils.ScopeDomain, ownerId, "domain")
 		usages.Update(jsonutils.Marshal(domainUsage))
-		domainUsage = models.GuestImageManager.Usage(rbacutils.ScopeDomain, ownerId, "domain")
+		domainUsage = models.GuestImageManager.Usage(ctx, rbacutils.ScopeDomain, ownerId, "domain")
 		usages.Update(jsonutils.Marshal(domainUsage))
 	}
 
 	if scope.HigherEqual(rbacutils.ScopeProject) {
-		projectUsage := models.ImageManager.Usage(rbacutils.ScopeProject, ownerId, "")
+		projectUsage := models.ImageManager.Usage(ctx, rbacutils.ScopeProject, ownerId, "")
 		usages.Update(jsonutils.Marshal(projectUsage))
-		projectUsage = models.GuestImageManager.Usage(rbacutils.ScopeProject, ownerId, "")
+		projectUsage = models.GuestImageManager.Usage(ctx, rbacutils.ScopeProject, ownerId, "")
 		usages.Update(jsonutils.Marshal(projectUsage))
 	}
 
@@ -51,6 +51,10 @@ type SActionlog struct {
 	StartTime time.Time `nullable:"true" list:"user" create:"optional"`
 	Success   bool      `list:"user" create:"required"`
 	Service   string    `width:"32" charset:"utf8" nullable:"true" list:"user" create:"optional"`
+
+	// hash chain, see actionlogchain.go
+	PrevHash string `width:"64" charset:"ascii" nullable:"true" list:"user"`
+	Hash     string `width:"64" charset:"ascii" nullable:"true" list:"user"`
 }
 
 var ActionLog *SActionlogManager
@@ -81,9 +85,14 @@ func (action *SActionlog) CustomizeCreate(ctx context.Context, userCred mcclient
 	if action.StartTime.IsZero() {
 		action.StartTime = now
 	}
+	// the action log is chained on insert, see sActionlogTableSpec
 	return action.SOpsLog.CustomizeCreate(ctx, userCred, ownerId, query, data)
 }
 
+func (manager *SActionlogManager) TableSpec() db.ITableSpec {
+	return &sActionlogTableSpec{manager.SOpsLogManager.TableSpec()}
+}
+
 func (action *SActionlog) GetI18N(ctx context.Context) *jsonutils.JSONDict {
 	r := jsonutils.NewDict()
 	act18 := logclient.OpsActionI18nTable.Lookup(ctx, action.Action)
@@ -0,0 +1,550 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/reflectutils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/logger"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/logger/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+// Action logs are chained by sha256, every entry carries the hash of its
+// predecessor (ordered by id) and the hash of its own content plus
+// PrevHash. Altering or deleting any entry breaks the chain from there.
+// Checkpoints signed by ed25519 key of logger service are created
+// periodically, so that truncating the tail of the chain or rewriting the
+// whole chain can be detected as well.
+
+const (
+	actionlogFetchBatchSize = 1000
+
+	// MySQL named lock serializing the chain among all logger replicas
+	actionlogChainLockName    = "onecloud_actionlog_chain"
+	actionlogChainLockTimeout = 30
+)
+
+var actionlogSigningKey ed25519.PrivateKey
+
+func InitActionlogChain() error {
+	var seed []byte
+	if len(options.Options.ActionlogSigningKey) > 0 {
+		var err error
+		seed, err = base64.StdEncoding.DecodeString(options.Options.ActionlogSigningKey)
+		if err != nil {
+			return errors.Wrap(err, "decode actionlog_signing_key")
+		}
+		if len(seed) != ed25519.SeedSize {
+			return errors.Errorf("actionlog_signing_key should be %d bytes, got %d", ed25519.SeedSize, len(seed))
+		}
+	} else {
+		log.Warningf("actionlog_signing_key not set, checkpoints are signed by an ephemeral key")
+		seed = make([]byte, ed25519.SeedSize)
+		_, err := rand.Read(seed)
+		if err != nil {
+			return errors.Wrap(err, "generate signing key")
+		}
+	}
+	actionlogSigningKey = ed25519.NewKeyFromSeed(seed)
+	return nil
+}
+
+func getActionlogPublicKey() string {
+	if actionlogSigningKey == nil {
+		return ""
+	}
+	return hex.EncodeToString(actionlogSigningKey.Public().(ed25519.PublicKey))
+}
+
+func actionlogDigest(action *SActionlog) string {
+	fields := []string{
+		action.PrevHash,
+		action.ObjType,
+		action.ObjId,
+		action.ObjName,
+		action.Action,
+		action.Notes,
+		action.ProjectId,
+		action.Project,
+		action.ProjectDomainId,
+		action.ProjectDomain,
+		action.UserId,
+		action.User,
+		action.DomainId,
+		action.Domain,
+		action.Roles,
+		action.OpsTime.UTC().Format(time.RFC3339),
+		action.OwnerDomainId,
+		action.OwnerProjectId,
+		action.StartTime.UTC().Format(time.RFC3339),
+		fmt.Sprintf("%v", action.Success),
+		action.Service,
+	}
+	h := sha256.New()
+	for _, f := range fields {
+		// length prefixed to avoid ambiguity between adjacent fields
+		fmt.Fprintf(h, "%d:%s\n", len(f), f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sActionlogTableSpec chains action logs on insert, reading the tail and
+// inserting the new entry under a database lock
+type sActionlogTableSpec struct {
+	db.ITableSpec
+}
+
+func (ts *sActionlogTableSpec) Insert(ctx context.Context, dt interface{}) error {
+	return ts.chainAndInsert(ctx, dt, ts.ITableSpec.Insert)
+}
+
+func (ts *sActionlogTableSpec) InsertOrUpdate(ctx context.Context, dt interface{}) error {
+	return ts.chainAndInsert(ctx, dt, ts.ITableSpec.InsertOrUpdate)
+}
+
+func (ts *sActionlogTableSpec) chainAndInsert(ctx context.Context, dt interface{}, insert func(ctx context.Context, dt interface{}) error) error {
+	action, ok := dt.(*SActionlog)
+	if !ok {
+		return insert(ctx, dt)
+	}
+	release, err := lockActionlogChain(ctx)
+	if err != nil {
+		return errors.Wrap(err, "lockActionlogChain")
+	}
+	defer release()
+	err = action.chain()
+	if err != nil {
+		return errors.Wrap(err, "chain")
+	}
+	return insert(ctx, dt)
+}
+
+// lockActionlogChain takes a named lock on a dedicated connection, as
+// MySQL named locks belong to the session
+func lockActionlogChain(ctx context.Context) (func(), error) {
+	conn, err := sqlchemy.GetDB().Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Conn")
+	}
+	var got sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", actionlogChainLockName, actionlogChainLockTimeout).Scan(&got)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "GET_LOCK")
+	}
+	if !got.Valid || got.Int64 != 1 {
+		conn.Close()
+		return nil, errors.Errorf("timeout waiting for lock %s", actionlogChainLockName)
+	}
+	return func() {
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", actionlogChainLockName)
+		if err != nil {
+			log.Errorf("release lock %s: %s", actionlogChainLockName, err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// fillActionlogDefaults sets empty columns to their defaults as sqlchemy
+// does on insert, so that the hash covers the stored values
+func fillActionlogDefaults(action *SActionlog, columns []sqlchemy.IColumnSpec) {
+	fields := reflectutils.FetchStructFieldValueSet(reflect.ValueOf(action).Elem())
+	for _, col := range columns {
+		if !col.IsSupportDefault() || len(col.Default()) == 0 {
+			continue
+		}
+		val, ok := fields.GetValue(col.Name())
+		if !ok || val.Kind() != reflect.String || val.Len() > 0 || !val.CanSet() {
+			continue
+		}
+		val.SetString(col.Default())
+	}
+}
+
+// chain links a new action log to the last one, the caller must hold the
+// chain lock until the action log is inserted
+func (action *SActionlog) chain() error {
+	// database keeps time in seconds, hash must be computed on stored value
+	action.OpsTime = action.OpsTime.Truncate(time.Second)
+	action.StartTime = action.StartTime.Truncate(time.Second)
+	fillActionlogDefaults(action, ActionLog.TableSpec().Columns())
+	last, err := fetchLastActionlog()
+	if err != nil {
+		return errors.Wrap(err, "fetchLastActionlog")
+	}
+	if last != nil {
+		action.PrevHash = last.Hash
+	}
+	action.Hash = actionlogDigest(action)
+	return nil
+}
+
+// actionlogQueries returns queries on each segment of splitable action log
+// table in ascending order
+func actionlogQueries(afterId int64) ([]*sqlchemy.SQuery, error) {
+	split := ActionLog.GetSplitTable()
+	if split == nil {
+		return []*sqlchemy.SQuery{ActionLog.Query()}, nil
+	}
+	metas, err := split.GetTableMetas()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetTableMetas")
+	}
+	queries := make([]*sqlchemy.SQuery, 0, len(metas))
+	for _, meta := range metas {
+		if meta.End > 0 && meta.End <= afterId {
+			continue
+		}
+		queries = append(queries, split.GetTableSpec(meta).Query())
+	}
+	return queries, nil
+}
+
+func fetchLastActionlog() (*SActionlog, error) {
+	queries, err := actionlogQueries(0)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(queries) - 1; i >= 0; i-- {
+		action := SActionlog{}
+		err := queries[i].Desc("id").Limit(1).First(&action)
+		if err == nil {
+			return &action, nil
+		}
+		if errors.Cause(err) != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// fetchActionlogsAfter returns at most limit action logs with id greater than afterId
+func fetchActionlogsAfter(afterId int64, limit int) ([]SActionlog, error) {
+	queries, err := actionlogQueries(afterId)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range queries {
+		actions := make([]SActionlog, 0)
+		err := q.GT("id", afterId).Asc("id").Limit(limit).All(&actions)
+		if err != nil {
+			return nil, errors.Wrap(err, "query action logs")
+		}
+		if len(actions) > 0 {
+			return actions, nil
+		}
+	}
+	return nil, nil
+}
+
+type SActionlogCheckpointManager struct {
+	db.SModelBaseManager
+}
+
+// SActionlogCheckpoint is a signed statement of the tail of action log chain
+type SActionlogCheckpoint struct {
+	db.SModelBase
+
+	Id           int64     `primary:"true" auto_increment:"true" list:"user"`
+	LastActionId int64     `nullable:"false" list:"user"`
+	LastHash     string    `width:"64" charset:"ascii" nullable:"false" list:"user"`
+	SignedAt     time.Time `nullable:"false" list:"user"`
+	PublicKey    string    `width:"64" charset:"ascii" nullable:"false" list:"user"`
+	Signature    string    `width:"128" charset:"ascii" nullable:"false" list:"user"`
+}
+
+var ActionlogCheckpointManager *SActionlogCheckpointManager
+
+func init() {
+	ActionlogCheckpointManager = &SActionlogCheckpointManager{
+		SModelBaseManager: db.NewModelBaseManager(
+			SActionlogCheckpoint{},
+			"actionlog_checkpoint_tbl",
+			"actionlog_checkpoint",
+			"actionlog_checkpoints",
+		),
+	}
+	ActionlogCheckpointManager.SetVirtualObject(ActionlogCheckpointManager)
+}
+
+func (cp *SActionlogCheckpoint) GetId() string {
+	return fmt.Sprintf("%d", cp.Id)
+}
+
+func (cp *SActionlogCheckpoint) GetName() string {
+	return fmt.Sprintf("checkpoint-%d", cp.LastActionId)
+}
+
+func (cp *SActionlogCheckpoint) GetModelManager() db.IModelManager {
+	return ActionlogCheckpointManager
+}
+
+func (cp *SActionlogCheckpoint) message() []byte {
+	return []byte(fmt.Sprintf("%d\n%s\n%d", cp.LastActionId, cp.LastHash, cp.SignedAt.Unix()))
+}
+
+func (cp *SActionlogCheckpoint) verifySignature() bool {
+	pubKey, err := hex.DecodeString(cp.PublicKey)
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(cp.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pubKey), cp.message(), sig)
+}
+
+func (manager *SActionlogCheckpointManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (manager *SActionlogCheckpointManager) GetPagingConfig() *db.SPagingConfig {
+	return &db.SPagingConfig{
+		Order:        sqlchemy.SQL_ORDER_DESC,
+		MarkerFields: []string{"id"},
+		DefaultLimit: 20,
+	}
+}
+
+func (manager *SActionlogCheckpointManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowList(userCred, manager)
+}
+
+func (manager *SActionlogCheckpointManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return false
+}
+
+func (cp *SActionlogCheckpoint) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGet(userCred, cp)
+}
+
+func (cp *SActionlogCheckpoint) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	return false
+}
+
+func (cp *SActionlogCheckpoint) AllowDeleteItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return false
+}
+
+func (manager *SActionlogCheckpointManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ActionlogCheckpointListInput,
+) (*sqlchemy.SQuery, error) {
+	return manager.SModelBaseManager.ListItemFilter(ctx, q, userCred, query.ModelBaseListInput)
+}
+
+func (manager *SActionlogCheckpointManager) fetchLastCheckpoint() (*SActionlogCheckpoint, error) {
+	cp := SActionlogCheckpoint{}
+	err := manager.Query().Desc("id").First(&cp)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (manager *SActionlogCheckpointManager) createCheckpoint(ctx context.Context) error {
+	last, err := fetchLastActionlog()
+	if err != nil {
+		return errors.Wrap(err, "fetchLastActionlog")
+	}
+	if last == nil || len(last.Hash) == 0 {
+		return nil
+	}
+	lastCp, err := manager.fetchLastCheckpoint()
+	if err != nil {
+		return errors.Wrap(err, "fetchLastCheckpoint")
+	}
+	if lastCp != nil && lastCp.LastActionId == last.Id && lastCp.PublicKey == getActionlogPublicKey() {
+		return nil
+	}
+	cp := &SActionlogCheckpoint{
+		LastActionId: last.Id,
+		LastHash:     last.Hash,
+		SignedAt:     time.Now().UTC().Truncate(time.Second),
+		PublicKey:    getActionlogPublicKey(),
+	}
+	cp.SetModelManager(manager, cp)
+	cp.Signature = hex.EncodeToString(ed25519.Sign(actionlogSigningKey, cp.message()))
+	err = manager.TableSpec().Insert(ctx, cp)
+	if err != nil {
+		return errors.Wrap(err, "insert checkpoint")
+	}
+	log.Infof("action log checkpoint created at %d", cp.LastActionId)
+	return nil
+}
+
+func StartActionlogCheckpointWorker(ctx context.Context) {
+	interval := time.Duration(options.Options.ActionlogCheckpointIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			err := ActionlogCheckpointManager.createCheckpoint(ctx)
+			if err != nil {
+				log.Errorf("create action log checkpoint: %s", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (manager *SActionlogManager) AllowGetPropertyVerify(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, manager, "verify")
+}
+
+// 校验操作日志哈希链及签名检查点
+func (manager *SActionlogManager) GetPropertyVerify(ctx context.Context, userCred mcclient.TokenCredential, query api.ActionlogVerifyInput) (api.ActionlogVerifyOutput, error) {
+	output := api.ActionlogVerifyOutput{PublicKey: getActionlogPublicKey()}
+	if !userCred.HasSystemAdminPrivilege() {
+		return output, httperrors.NewForbiddenError("only system admin can verify action logs")
+	}
+	if query.EndId > 0 && query.EndId < query.StartId {
+		return output, httperrors.NewInputParameterError("end_id should not be less than start_id")
+	}
+	hashes := make(map[int64]string)
+	checkpoints := make([]SActionlogCheckpoint, 0)
+	q := ActionlogCheckpointManager.Query().GE("last_action_id", query.StartId)
+	if query.EndId > 0 {
+		q = q.LE("last_action_id", query.EndId)
+	}
+	err := q.Asc("id").All(&checkpoints)
+	if err != nil {
+		return output, errors.Wrap(err, "query checkpoints")
+	}
+	for i := range checkpoints {
+		hashes[checkpoints[i].LastActionId] = ""
+	}
+
+	prevHash := ""
+	chained := false
+	afterId := query.StartId - 1
+	if afterId < 0 {
+		afterId = 0
+	}
+	done := false
+	for !done {
+		actions, err := fetchActionlogsAfter(afterId, actionlogFetchBatchSize)
+		if err != nil {
+			return output, err
+		}
+		if len(actions) == 0 {
+			break
+		}
+		for i := range actions {
+			action := &actions[i]
+			if query.EndId > 0 && action.Id > query.EndId {
+				done = true
+				break
+			}
+			afterId = action.Id
+			if len(action.Hash) == 0 {
+				if chained {
+					output.BrokenId = action.Id
+					output.Reason = "hash missing"
+					done = true
+					break
+				}
+				// logs before hash chain is enabled
+				continue
+			}
+			if output.FirstId == 0 {
+				output.FirstId = action.Id
+			} else if action.PrevHash != prevHash {
+				output.BrokenId = action.Id
+				output.Reason = "prev_hash mismatch, previous entry removed or modified"
+				done = true
+				break
+			}
+			if actionlogDigest(action) != action.Hash {
+				output.BrokenId = action.Id
+				output.Reason = "hash mismatch, entry modified"
+				done = true
+				break
+			}
+			chained = true
+			prevHash = action.Hash
+			output.LastId = action.Id
+			output.Count++
+			if _, ok := hashes[action.Id]; ok {
+				hashes[action.Id] = action.Hash
+			}
+		}
+		if query.EndId > 0 && afterId >= query.EndId {
+			done = true
+		}
+	}
+
+	reasons := make([]string, 0)
+	if len(output.Reason) > 0 {
+		reasons = append(reasons, output.Reason)
+	}
+	for i := range checkpoints {
+		cp := &checkpoints[i]
+		if output.FirstId == 0 || cp.LastActionId < output.FirstId {
+			// entries purged by table rotation
+			continue
+		}
+		if output.BrokenId > 0 && cp.LastActionId >= output.BrokenId {
+			continue
+		}
+		output.Checkpoints++
+		if !cp.verifySignature() {
+			output.CheckpointsFailed++
+			reasons = append(reasons, fmt.Sprintf("checkpoint %d bad signature", cp.Id))
+			continue
+		}
+		if hashes[cp.LastActionId] != cp.LastHash {
+			output.CheckpointsFailed++
+			reasons = append(reasons, fmt.Sprintf("checkpoint %d mismatch action log %d, entries truncated or rewritten", cp.Id, cp.LastActionId))
+		}
+	}
+	output.Reason = strings.Join(reasons, "; ")
+	output.Verified = output.BrokenId == 0 && output.CheckpointsFailed == 0
+	return output, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestFillActionlogDefaults(t *testing.T) {
+	action := &SActionlog{}
+	action.ObjType = "server"
+	action.ProjectDomainId = "domain1"
+	fillActionlogDefaults(action, ActionLog.TableSpec().Columns())
+	if action.OwnerDomainId != "default" {
+		t.Errorf("owner_domain_id got %q want default", action.OwnerDomainId)
+	}
+	if action.ProjectDomain != "Default" {
+		t.Errorf("project_domain got %q want Default", action.ProjectDomain)
+	}
+	if action.ProjectDomainId != "domain1" {
+		t.Errorf("project_domain_id should be kept, got %q", action.ProjectDomainId)
+	}
+	if action.ObjType != "server" || len(action.OwnerProjectId) > 0 {
+		t.Errorf("columns without default should be kept, got %q %q", action.ObjType, action.OwnerProjectId)
+	}
+
+	hash := actionlogDigest(action)
+	fillActionlogDefaults(action, ActionLog.TableSpec().Columns())
+	if actionlogDigest(action) != hash {
+		t.Errorf("filling defaults twice should not change the hash")
+	}
+}
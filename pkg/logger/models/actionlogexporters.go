@@ -0,0 +1,455 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/logger"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/logger/options"
+	"yunion.io/x/onecloud/pkg/logger/siem"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	exporterAppName       = "onecloud-logger"
+	exporterMaxBackoff    = 10 * time.Minute
+	exporterMaxBatchRound = 10
+)
+
+type SActionlogExporterManager struct {
+	db.SEnabledStatusStandaloneResourceBaseManager
+}
+
+// SActionlogExporter streams action logs to a SIEM collector, Cursor is the
+// id of last handled action log
+type SActionlogExporter struct {
+	db.SEnabledStatusStandaloneResourceBase
+
+	Format      string                     `width:"16" charset:"ascii" nullable:"false" list:"admin" create:"admin_required"`
+	Transport   string                     `width:"16" charset:"ascii" nullable:"false" list:"admin" create:"admin_required"`
+	Address     string                     `width:"256" charset:"ascii" nullable:"false" list:"admin" create:"admin_required" update:"admin"`
+	TlsCaCert   string                     `charset:"ascii" nullable:"true" get:"admin" create:"admin_optional" update:"admin"`
+	TlsInsecure bool                       `nullable:"false" default:"false" list:"admin" create:"admin_optional" update:"admin"`
+	Filter      *api.ActionlogExportFilter `length:"long" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+
+	Cursor        int64     `nullable:"false" default:"0" list:"admin"`
+	ExportedCount int64     `nullable:"false" default:"0" list:"admin"`
+	FailCount     int       `nullable:"false" default:"0" list:"admin"`
+	LastError     string    `width:"256" charset:"utf8" nullable:"true" list:"admin"`
+	NextRetryAt   time.Time `nullable:"true" list:"admin"`
+}
+
+var ActionlogExporterManager *SActionlogExporterManager
+
+func init() {
+	ActionlogExporterManager = &SActionlogExporterManager{
+		SEnabledStatusStandaloneResourceBaseManager: db.NewEnabledStatusStandaloneResourceBaseManager(
+			SActionlogExporter{},
+			"actionlog_exporters_tbl",
+			"actionlog_exporter",
+			"actionlog_exporters",
+		),
+	}
+	ActionlogExporterManager.SetVirtualObject(ActionlogExporterManager)
+}
+
+func (manager *SActionlogExporterManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func validateExporterTls(caCert string) error {
+	if len(caCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return httperrors.NewInputParameterError("invalid tls_ca_cert")
+		}
+	}
+	return nil
+}
+
+func validateExporterAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil || len(port) == 0 {
+		return httperrors.NewInputParameterError("invalid address %s, should be host:port", addr)
+	}
+	return nil
+}
+
+func (manager *SActionlogExporterManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ActionlogExporterCreateInput) (api.ActionlogExporterCreateInput, error) {
+	var err error
+	input.EnabledStatusStandaloneResourceCreateInput, err = manager.SEnabledStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if !utils.IsInStringArray(input.Format, api.EXPORTER_FORMATS) {
+		return input, httperrors.NewInputParameterError("invalid format %s, choices %s", input.Format, api.EXPORTER_FORMATS)
+	}
+	if !utils.IsInStringArray(input.Transport, api.EXPORTER_TRANSPORTS) {
+		return input, httperrors.NewInputParameterError("invalid transport %s, choices %s", input.Transport, api.EXPORTER_TRANSPORTS)
+	}
+	err = validateExporterAddress(input.Address)
+	if err != nil {
+		return input, err
+	}
+	err = validateExporterTls(input.TlsCaCert)
+	if err != nil {
+		return input, err
+	}
+	if input.StartId != nil && *input.StartId < 0 {
+		return input, httperrors.NewInputParameterError("start_id should not be negative")
+	}
+	input.Status = api.EXPORTER_STATUS_READY
+	if input.Enabled == nil {
+		enabled := true
+		input.Enabled = &enabled
+	}
+	return input, nil
+}
+
+func (exporter *SActionlogExporter) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	input := api.ActionlogExporterCreateInput{}
+	data.Unmarshal(&input)
+	if input.StartId != nil {
+		exporter.Cursor = *input.StartId
+	} else {
+		// export from now on by default
+		last, err := fetchLastActionlog()
+		if err != nil {
+			return errors.Wrap(err, "fetchLastActionlog")
+		}
+		if last != nil {
+			exporter.Cursor = last.Id
+		}
+	}
+	return exporter.SEnabledStatusStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (exporter *SActionlogExporter) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ActionlogExporterUpdateInput) (api.ActionlogExporterUpdateInput, error) {
+	var err error
+	input.EnabledStatusStandaloneResourceBaseUpdateInput, err = exporter.SEnabledStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Address) > 0 {
+		err = validateExporterAddress(input.Address)
+		if err != nil {
+			return input, err
+		}
+	}
+	err = validateExporterTls(input.TlsCaCert)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+// 操作日志导出器列表
+func (manager *SActionlogExporterManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ActionlogExporterListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Format) > 0 {
+		q = q.In("format", query.Format)
+	}
+	if len(query.Transport) > 0 {
+		q = q.In("transport", query.Transport)
+	}
+	return q, nil
+}
+
+func (manager *SActionlogExporterManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ActionlogExporterDetails {
+	rows := make([]api.ActionlogExporterDetails, len(objs))
+	stdRows := manager.SEnabledStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.ActionlogExporterDetails{
+			EnabledStatusStandaloneResourceDetails: stdRows[i],
+		}
+	}
+	return rows
+}
+
+func (exporter *SActionlogExporter) AllowPerformResetCursor(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, exporter, "reset-cursor")
+}
+
+// 重置导出位置，重新导出id大于cursor的日志
+func (exporter *SActionlogExporter) PerformResetCursor(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ActionlogExporterResetCursorInput) (jsonutils.JSONObject, error) {
+	if input.Cursor < 0 {
+		return nil, httperrors.NewInputParameterError("cursor should not be negative")
+	}
+	_, err := db.Update(exporter, func() error {
+		exporter.Cursor = input.Cursor
+		exporter.FailCount = 0
+		exporter.NextRetryAt = time.Time{}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Update")
+	}
+	return nil, nil
+}
+
+func (exporter *SActionlogExporter) senderConfig() siem.SSenderConfig {
+	return siem.SSenderConfig{
+		Format:      exporter.Format,
+		Transport:   exporter.Transport,
+		Address:     exporter.Address,
+		TlsCaCert:   exporter.TlsCaCert,
+		TlsInsecure: exporter.TlsInsecure,
+	}
+}
+
+func (exporter *SActionlogExporter) match(action *SActionlog) bool {
+	filter := exporter.Filter
+	if filter == nil {
+		return true
+	}
+	if len(filter.Services) > 0 && !utils.IsInStringArray(action.Service, filter.Services) {
+		return false
+	}
+	if len(filter.Actions) > 0 && !utils.IsInStringArray(action.Action, filter.Actions) {
+		return false
+	}
+	if len(filter.ObjTypes) > 0 && !utils.IsInStringArray(action.ObjType, filter.ObjTypes) {
+		return false
+	}
+	if filter.FailedOnly && action.Success {
+		return false
+	}
+	return true
+}
+
+func (action *SActionlog) toAuditRecord() *siem.SAuditRecord {
+	return &siem.SAuditRecord{
+		Id:        action.Id,
+		OpsTime:   action.OpsTime,
+		Service:   action.Service,
+		Action:    action.Action,
+		Success:   action.Success,
+		ObjType:   action.ObjType,
+		ObjId:     action.ObjId,
+		ObjName:   action.ObjName,
+		UserId:    action.UserId,
+		User:      action.User,
+		ProjectId: action.ProjectId,
+		Project:   action.Project,
+		DomainId:  action.DomainId,
+		Domain:    action.Domain,
+		Notes:     action.Notes,
+		Hash:      action.Hash,
+		PrevHash:  action.PrevHash,
+	}
+}
+
+// exportOnce sends one batch, returns whether there may be more action logs
+func (exporter *SActionlogExporter) exportOnce(sender *siem.SSender, hostname string) (bool, error) {
+	batchSize := options.Options.ActionlogExportBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	actions, err := fetchActionlogsAfter(exporter.Cursor, batchSize)
+	if err != nil {
+		return false, errors.Wrap(err, "fetchActionlogsAfter")
+	}
+	if len(actions) == 0 {
+		return false, nil
+	}
+	msgs := make([]string, 0, len(actions))
+	msgIds := make([]int64, 0, len(actions))
+	for i := range actions {
+		if !exporter.match(&actions[i]) {
+			continue
+		}
+		msg, err := siem.Format(exporter.Format, actions[i].toAuditRecord(), hostname, exporterAppName)
+		if err != nil {
+			return false, err
+		}
+		msgs = append(msgs, msg)
+		msgIds = append(msgIds, actions[i].Id)
+	}
+	cursor := actions[len(actions)-1].Id
+	var sent int
+	var sendErr error
+	if len(msgs) > 0 {
+		sent, sendErr = sender.Send(msgs)
+	}
+	if sendErr != nil {
+		// action logs before the first unsent one are handled
+		cursor = msgIds[sent] - 1
+	}
+	_, err = db.Update(exporter, func() error {
+		exporter.Cursor = cursor
+		exporter.ExportedCount += int64(sent)
+		if sendErr != nil {
+			exporter.FailCount += 1
+			exporter.LastError = utils.TruncateString(sendErr.Error(), 250)
+			exporter.NextRetryAt = time.Now().Add(exporter.backoff())
+			exporter.Status = api.EXPORTER_STATUS_ERROR
+		} else {
+			exporter.FailCount = 0
+			exporter.LastError = ""
+			exporter.NextRetryAt = time.Time{}
+			exporter.Status = api.EXPORTER_STATUS_READY
+		}
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "update cursor")
+	}
+	if sendErr != nil {
+		return false, sendErr
+	}
+	return len(actions) >= batchSize, nil
+}
+
+func (exporter *SActionlogExporter) backoff() time.Duration {
+	backoff := time.Duration(options.Options.ActionlogExportIntervalSeconds) * time.Second
+	for i := 1; i < exporter.FailCount && backoff < exporterMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > exporterMaxBackoff {
+		backoff = exporterMaxBackoff
+	}
+	return backoff
+}
+
+type sExporterSender struct {
+	config siem.SSenderConfig
+	sender *siem.SSender
+}
+
+// exportWorker is only accessed from the export goroutine
+type exportWorker struct {
+	hostname string
+	senders  map[string]*sExporterSender
+}
+
+func (w *exportWorker) getSender(exporter *SActionlogExporter) (*siem.SSender, error) {
+	conf := exporter.senderConfig()
+	if s, ok := w.senders[exporter.Id]; ok {
+		if s.config == conf {
+			return s.sender, nil
+		}
+		s.sender.Close()
+		delete(w.senders, exporter.Id)
+	}
+	sender, err := siem.NewSender(conf)
+	if err != nil {
+		return nil, err
+	}
+	w.senders[exporter.Id] = &sExporterSender{config: conf, sender: sender}
+	return sender, nil
+}
+
+func (w *exportWorker) run() {
+	exporters := make([]SActionlogExporter, 0)
+	q := ActionlogExporterManager.Query().IsTrue("enabled")
+	err := db.FetchModelObjects(ActionlogExporterManager, q, &exporters)
+	if err != nil {
+		log.Errorf("fetch actionlog exporters: %s", err)
+		return
+	}
+	active := make(map[string]bool)
+	now := time.Now()
+	for i := range exporters {
+		exporter := &exporters[i]
+		active[exporter.Id] = true
+		if !exporter.NextRetryAt.IsZero() && exporter.NextRetryAt.After(now) {
+			continue
+		}
+		sender, err := w.getSender(exporter)
+		if err != nil {
+			log.Errorf("exporter %s: %s", exporter.Name, err)
+			continue
+		}
+		for round := 0; round < exporterMaxBatchRound; round++ {
+			more, err := exporter.exportOnce(sender, w.hostname)
+			if err != nil {
+				log.Errorf("exporter %s export fail: %s", exporter.Name, err)
+				break
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	for id, s := range w.senders {
+		if !active[id] {
+			s.sender.Close()
+			delete(w.senders, id)
+		}
+	}
+}
+
+func StartActionlogExportWorker(ctx context.Context) {
+	interval := time.Duration(options.Options.ActionlogExportIntervalSeconds) * time.Second
+	if interval <= 0 {
+		return
+	}
+	hostname, _ := os.Hostname()
+	w := &exportWorker{
+		hostname: hostname,
+		senders:  make(map[string]*sExporterSender),
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.run()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (exporter *SActionlogExporter) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.ActionlogExporterDetails, error) {
+	return api.ActionlogExporterDetails{}, nil
+}
+
+func (exporter *SActionlogExporter) String() string {
+	return fmt.Sprintf("%s(%s %s://%s)", exporter.Name, exporter.Format, exporter.Transport, exporter.Address)
+}
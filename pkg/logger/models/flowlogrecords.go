@@ -0,0 +1,121 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/logger"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+// SFlowLogRecordManager 宿主机投递的流日志, 与操作日志分开存放, 不参与操作日志的哈希链
+type SFlowLogRecordManager struct {
+	db.SModelBaseManager
+}
+
+// SFlowLogRecord 一条记录保存一批流日志中的若干条, 以JSON Lines格式存放
+type SFlowLogRecord struct {
+	db.SModelBase
+
+	Id            int64     `primary:"true" auto_increment:"true" list:"user"`
+	FlowLogId     string    `width:"128" charset:"ascii" nullable:"false" list:"user" create:"required" index:"true"`
+	FlowLogName   string    `width:"128" charset:"utf8" nullable:"true" list:"user" create:"optional"`
+	HostId        string    `width:"128" charset:"ascii" nullable:"true" list:"user" create:"optional" index:"true"`
+	OwnerTenantId string    `width:"128" charset:"ascii" nullable:"true" list:"user" create:"optional"`
+	OwnerDomainId string    `width:"128" charset:"ascii" nullable:"true" list:"user" create:"optional"`
+	StartTime     time.Time `nullable:"true" list:"user" create:"optional"`
+	EndTime       time.Time `nullable:"true" list:"user" create:"optional"`
+	Count         int       `nullable:"false" default:"0" list:"user" create:"optional"`
+	Records       string    `nullable:"false" list:"user" create:"required"`
+	Created       time.Time `nullable:"false" created_at:"true" list:"user"`
+}
+
+var FlowLogRecordManager *SFlowLogRecordManager
+
+func init() {
+	FlowLogRecordManager = &SFlowLogRecordManager{
+		SModelBaseManager: db.NewModelBaseManagerWithSplitable(
+			SFlowLogRecord{},
+			"flow_log_record_tbl",
+			"flowlogrecord",
+			"flowlogrecords",
+			"id",
+			"created",
+			consts.SplitableMaxDuration(),
+			consts.SplitableMaxKeepSegments(),
+		),
+	}
+	FlowLogRecordManager.SetVirtualObject(FlowLogRecordManager)
+}
+
+func (record *SFlowLogRecord) GetId() string {
+	return fmt.Sprintf("%d", record.Id)
+}
+
+func (record *SFlowLogRecord) GetName() string {
+	return record.FlowLogName
+}
+
+func (record *SFlowLogRecord) GetModelManager() db.IModelManager {
+	return FlowLogRecordManager
+}
+
+func (manager *SFlowLogRecordManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (manager *SFlowLogRecordManager) GetPagingConfig() *db.SPagingConfig {
+	return &db.SPagingConfig{
+		Order:        sqlchemy.SQL_ORDER_DESC,
+		MarkerFields: []string{"id"},
+		DefaultLimit: 20,
+	}
+}
+
+// 流日志列表
+func (manager *SFlowLogRecordManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.FlowLogRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SModelBaseManager.ListItemFilter(ctx, q, userCred, query.ModelBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SModelBaseManager.ListItemFilter")
+	}
+
+	if len(query.FlowLogId) > 0 {
+		q = q.Equals("flow_log_id", query.FlowLogId)
+	}
+	if len(query.HostId) > 0 {
+		q = q.Equals("host_id", query.HostId)
+	}
+	if !query.Since.IsZero() {
+		q = q.GT("created", query.Since)
+	}
+	if !query.Until.IsZero() {
+		q = q.LE("created", query.Until)
+	}
+	return q, nil
+}
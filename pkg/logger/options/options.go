@@ -22,6 +22,12 @@ type SLoggerOptions struct {
 	common_options.CommonOptions
 
 	common_options.DBOptions
+
+	ActionlogSigningKey                string `help:"base64 encoded ed25519 private key seed used to sign action log checkpoints, an ephemeral key is used if empty"`
+	ActionlogCheckpointIntervalMinutes int    `help:"interval to create signed checkpoint of action log hash chain" default:"60"`
+
+	ActionlogExportIntervalSeconds int `help:"interval to export action logs to SIEM collectors" default:"10"`
+	ActionlogExportBatchSize       int `help:"max number of action logs exported in one round for each exporter" default:"500"`
 }
 
 var (
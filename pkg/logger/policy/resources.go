@@ -20,7 +20,10 @@ import (
 )
 
 var (
-	loggerSystemResources = []string{}
+	loggerSystemResources = []string{
+		"actionlog_checkpoints",
+		"actionlog_exporters",
+	}
 	loggerDomainResources = []string{}
 	loggerUserResources   = []string{}
 )
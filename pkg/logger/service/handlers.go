@@ -27,6 +27,7 @@ func initHandlers(app *appsrv.Application) {
 	for _, manager := range []db.IModelManager{
 		db.UserCacheManager,
 		db.TenantCacheManager,
+		db.Metadata,
 	} {
 		db.RegisterModelManager(manager)
 	}
@@ -34,6 +35,9 @@ func initHandlers(app *appsrv.Application) {
 	for _, manager := range []db.IModelManager{
 		models.ActionLog,
 		models.BaremetalEventManager,
+		models.FlowLogRecordManager,
+		models.ActionlogCheckpointManager,
+		models.ActionlogExporterManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
@@ -15,6 +15,7 @@
 package service
 
 import (
+	"context"
 	"os"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -52,7 +53,14 @@ func StartService() {
 	db.EnsureAppInitSyncDB(app, dbOpts, nil)
 	defer cloudcommon.CloseDB()
 
+	err := models.InitActionlogChain()
+	if err != nil {
+		log.Fatalf("init actionlog chain: %s", err)
+	}
+
 	models.StartNotifyToWebsocketWorker()
+	models.StartActionlogCheckpointWorker(context.Background())
+	models.StartActionlogExportWorker(context.Background())
 
 	app_common.ServeForever(app, baseOpts)
 }
@@ -0,0 +1,208 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+const (
+	// facility log audit
+	syslogFacilityAudit = 13
+
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+
+	// structured data id, 32473 is the private enterprise number reserved
+	// for documentation (RFC 5612)
+	syslogSDID = "audit@32473"
+
+	syslogNilValue = "-"
+
+	cefVendor  = "Yunion"
+	cefProduct = "OneCloud"
+	cefVersion = "1"
+)
+
+// SAuditRecord is an action log entry to be exported
+type SAuditRecord struct {
+	Id        int64     `json:"id"`
+	OpsTime   time.Time `json:"ops_time"`
+	Service   string    `json:"service"`
+	Action    string    `json:"action"`
+	Success   bool      `json:"success"`
+	ObjType   string    `json:"obj_type"`
+	ObjId     string    `json:"obj_id"`
+	ObjName   string    `json:"obj_name"`
+	UserId    string    `json:"user_id"`
+	User      string    `json:"user"`
+	ProjectId string    `json:"tenant_id"`
+	Project   string    `json:"tenant"`
+	DomainId  string    `json:"domain_id"`
+	Domain    string    `json:"domain"`
+	Notes     string    `json:"notes"`
+	Hash      string    `json:"hash"`
+	PrevHash  string    `json:"prev_hash"`
+}
+
+func (rec *SAuditRecord) outcome() string {
+	if rec.Success {
+		return "success"
+	}
+	return "failure"
+}
+
+func (rec *SAuditRecord) summary() string {
+	return fmt.Sprintf("%s %s %s(%s) by %s: %s", rec.Action, rec.ObjType, rec.ObjName, rec.ObjId, rec.User, rec.outcome())
+}
+
+// FormatRFC5424 formats record as syslog message defined in RFC 5424
+func FormatRFC5424(rec *SAuditRecord, hostname string, appName string) string {
+	severity := syslogSeverityInfo
+	if !rec.Success {
+		severity = syslogSeverityWarning
+	}
+	params := map[string]string{
+		"id":         fmt.Sprintf("%d", rec.Id),
+		"service":    rec.Service,
+		"action":     rec.Action,
+		"outcome":    rec.outcome(),
+		"obj_type":   rec.ObjType,
+		"obj_id":     rec.ObjId,
+		"obj_name":   rec.ObjName,
+		"user_id":    rec.UserId,
+		"user":       rec.User,
+		"project_id": rec.ProjectId,
+		"project":    rec.Project,
+		"hash":       rec.Hash,
+		"prev_hash":  rec.PrevHash,
+	}
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if len(v) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	sd := strings.Builder{}
+	sd.WriteString("[")
+	sd.WriteString(syslogSDID)
+	for _, k := range keys {
+		sd.WriteString(fmt.Sprintf(" %s=\"%s\"", k, escapeSDParam(params[k])))
+	}
+	sd.WriteString("]")
+
+	msg := rec.summary()
+	if len(rec.Notes) > 0 {
+		msg = fmt.Sprintf("%s %s", msg, rec.Notes)
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s \xEF\xBB\xBF%s",
+		syslogFacilityAudit*8+severity,
+		rec.OpsTime.UTC().Format(time.RFC3339),
+		syslogHeaderValue(hostname, 255),
+		syslogHeaderValue(appName, 48),
+		syslogNilValue,
+		syslogHeaderValue(rec.Action, 32),
+		sd.String(),
+		msg,
+	)
+}
+
+// header fields are printable US-ASCII without space
+func syslogHeaderValue(v string, maxLen int) string {
+	out := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, v)
+	if len(out) == 0 {
+		return syslogNilValue
+	}
+	if len(out) > maxLen {
+		out = out[:maxLen]
+	}
+	return out
+}
+
+func escapeSDParam(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return r.Replace(v)
+}
+
+// FormatCEF formats record as ArcSight Common Event Format
+func FormatCEF(rec *SAuditRecord) string {
+	severity := 3
+	if !rec.Success {
+		severity = 7
+	}
+	ext := [][2]string{
+		{"externalId", fmt.Sprintf("%d", rec.Id)},
+		{"rt", fmt.Sprintf("%d", rec.OpsTime.UnixNano()/int64(time.Millisecond))},
+		{"act", rec.Action},
+		{"outcome", rec.outcome()},
+		{"suid", rec.UserId},
+		{"suser", rec.User},
+		{"cs1Label", "service"},
+		{"cs1", rec.Service},
+		{"cs2Label", "objType"},
+		{"cs2", rec.ObjType},
+		{"cs3Label", "objId"},
+		{"cs3", rec.ObjId},
+		{"cs4Label", "objName"},
+		{"cs4", rec.ObjName},
+		{"cs5Label", "project"},
+		{"cs5", rec.Project},
+		{"cs6Label", "hash"},
+		{"cs6", rec.Hash},
+		{"msg", rec.Notes},
+	}
+	parts := make([]string, 0, len(ext))
+	for _, kv := range ext {
+		if len(kv[1]) == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", kv[0], escapeCEFExtension(kv[1])))
+	}
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		escapeCEFHeader(cefVendor),
+		escapeCEFHeader(cefProduct),
+		escapeCEFHeader(cefVersion),
+		escapeCEFHeader(rec.Action),
+		escapeCEFHeader(fmt.Sprintf("%s %s", rec.Action, rec.ObjType)),
+		severity,
+		strings.Join(parts, " "),
+	)
+}
+
+func escapeCEFHeader(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	return r.Replace(v)
+}
+
+func escapeCEFExtension(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`)
+	return r.Replace(v)
+}
+
+// FormatJSON formats record as single line JSON
+func FormatJSON(rec *SAuditRecord) string {
+	return jsonutils.Marshal(rec).String()
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/logger"
+)
+
+const (
+	dialTimeout  = 10 * time.Second
+	writeTimeout = 30 * time.Second
+)
+
+type SSenderConfig struct {
+	Format    string
+	Transport string
+	Address   string
+
+	TlsCaCert   string
+	TlsInsecure bool
+}
+
+// SSender keeps a stream connection to SIEM collector
+type SSender struct {
+	config SSenderConfig
+	conn   net.Conn
+}
+
+func NewSender(config SSenderConfig) (*SSender, error) {
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return nil, errors.Wrapf(err, "invalid address %s", config.Address)
+	}
+	switch config.Transport {
+	case api.EXPORTER_TRANSPORT_TCP, api.EXPORTER_TRANSPORT_TLS:
+	default:
+		return nil, errors.Wrapf(errors.ErrNotSupported, "transport %s", config.Transport)
+	}
+	return &SSender{config: config}, nil
+}
+
+func (s *SSender) tlsConfig() (*tls.Config, error) {
+	host, _, _ := net.SplitHostPort(s.config.Address)
+	conf := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: s.config.TlsInsecure,
+	}
+	if len(s.config.TlsCaCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(s.config.TlsCaCert)) {
+			return nil, errors.Error("invalid tls_ca_cert")
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+func (s *SSender) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if s.config.Transport == api.EXPORTER_TRANSPORT_TLS {
+		conf, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.config.Address, conf)
+		if err != nil {
+			return errors.Wrapf(err, "tls dial %s", s.config.Address)
+		}
+	} else {
+		conn, err = dialer.Dial("tcp", s.config.Address)
+		if err != nil {
+			return errors.Wrapf(err, "dial %s", s.config.Address)
+		}
+	}
+	s.conn = conn
+	return nil
+}
+
+// frame uses octet counting for syslog (RFC 6587), newline delimited for others
+func (s *SSender) frame(msg string) []byte {
+	if s.config.Format == api.EXPORTER_FORMAT_RFC5424 {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	}
+	return []byte(msg + "\n")
+}
+
+// Send writes messages in order, connection is reset on any error so that
+// caller can retry from the first unsent message
+func (s *SSender) Send(msgs []string) (int, error) {
+	err := s.connect()
+	if err != nil {
+		return 0, err
+	}
+	for i, msg := range msgs {
+		s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		_, err := s.conn.Write(s.frame(msg))
+		if err != nil {
+			s.Close()
+			return i, errors.Wrapf(err, "write to %s", s.config.Address)
+		}
+	}
+	return len(msgs), nil
+}
+
+func (s *SSender) Close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func Format(format string, rec *SAuditRecord, hostname string, appName string) (string, error) {
+	switch format {
+	case api.EXPORTER_FORMAT_RFC5424:
+		return FormatRFC5424(rec, hostname, appName), nil
+	case api.EXPORTER_FORMAT_CEF:
+		return FormatCEF(rec), nil
+	case api.EXPORTER_FORMAT_JSON:
+		return FormatJSON(rec), nil
+	}
+	return "", errors.Wrapf(errors.ErrNotSupported, "format %s", format)
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/logger"
+)
+
+func testRecord() *SAuditRecord {
+	return &SAuditRecord{
+		Id:       42,
+		OpsTime:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Service:  "compute",
+		Action:   "delete",
+		Success:  false,
+		ObjType:  "server",
+		ObjId:    "abc",
+		ObjName:  `vm"1]`,
+		User:     "admin",
+		Notes:    "a=b|c\nd",
+		Hash:     "h1",
+		PrevHash: "h0",
+	}
+}
+
+func TestFormatRFC5424(t *testing.T) {
+	msg := FormatRFC5424(testRecord(), "host 1", "app")
+	// facility 13, severity warning
+	prefix := "<108>1 2020-01-02T03:04:05Z host_1 app - delete [audit@32473 "
+	if !strings.HasPrefix(msg, prefix) {
+		t.Fatalf("unexpected header: %s", msg)
+	}
+	if !strings.Contains(msg, `obj_name="vm\"1\]"`) {
+		t.Errorf("sd param not escaped: %s", msg)
+	}
+	if !strings.Contains(msg, "] \xEF\xBB\xBFdelete server") {
+		t.Errorf("msg should start with BOM: %s", msg)
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	msg := FormatCEF(testRecord())
+	if !strings.HasPrefix(msg, "CEF:0|Yunion|OneCloud|1|delete|delete server|7|externalId=42 ") {
+		t.Fatalf("unexpected header: %s", msg)
+	}
+	if !strings.Contains(msg, `msg=a\=b|c\nd`) {
+		t.Errorf("extension not escaped: %s", msg)
+	}
+	if strings.Contains(msg, "\n") {
+		t.Errorf("message should be single line: %q", msg)
+	}
+}
+
+func TestSendOctetCounting(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	defer l.Close()
+
+	msgs := []string{
+		FormatRFC5424(testRecord(), "host", "app"),
+		"second message",
+	}
+	recv := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			recv <- nil
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		got := []string{}
+		for range msgs {
+			lenStr, err := reader.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(lenStr))
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				break
+			}
+			got = append(got, string(buf))
+		}
+		recv <- got
+	}()
+
+	sender, err := NewSender(SSenderConfig{
+		Format:    api.EXPORTER_FORMAT_RFC5424,
+		Transport: api.EXPORTER_TRANSPORT_TCP,
+		Address:   l.Addr().String(),
+	})
+	if err != nil {
+		t.Fatalf("NewSender: %s", err)
+	}
+	defer sender.Close()
+	sent, err := sender.Send(msgs)
+	if err != nil || sent != len(msgs) {
+		t.Fatalf("Send: sent %d err %v", sent, err)
+	}
+	got := <-recv
+	if len(got) != len(msgs) {
+		t.Fatalf("received %d messages, want %d", len(got), len(msgs))
+	}
+	for i := range msgs {
+		if got[i] != msgs[i] {
+			t.Errorf("message %d mismatch: %q != %q", i, got[i], msgs[i])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
+
+var (
+	ActionlogExporters   modulebase.ResourceManager
+	ActionlogCheckpoints modulebase.ResourceManager
+)
+
+func init() {
+	ActionlogExporters = NewActionManager("actionlog_exporter", "actionlog_exporters",
+		[]string{"id", "name", "enabled", "status",
+			"format", "transport", "address",
+			"cursor", "exported_count", "fail_count",
+			"last_error", "next_retry_at",
+		},
+		[]string{})
+	register(&ActionlogExporters)
+
+	ActionlogCheckpoints = NewActionManager("actionlog_checkpoint", "actionlog_checkpoints",
+		[]string{"id", "last_action_id", "last_hash",
+			"signed_at", "public_key", "signature",
+		},
+		[]string{})
+	register(&ActionlogCheckpoints)
+}
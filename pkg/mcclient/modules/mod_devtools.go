@@ -19,8 +19,9 @@ import (
 )
 
 var (
-	DevToolCronjobs  modulebase.ResourceManager
-	DevToolTemplates modulebase.ResourceManager
+	DevToolCronjobs    modulebase.ResourceManager
+	DevToolCronjobRuns modulebase.ResourceManager
+	DevToolTemplates   modulebase.ResourceManager
 )
 
 func init() {
@@ -28,15 +29,23 @@ func init() {
 	DevToolCronjobs = NewDevtoolManager(
 		"devtool_cronjob",
 		"devtool_cronjobs",
-		[]string{"id", "ansible_playbook_id", "template_id", "server_id", "name", "day", "hour", "min", "sec", "interval", "start", "enabled", "created_at"},
+		[]string{"id", "ansible_playbook_id", "template_id", "server_id", "name", "day", "hour", "min", "sec", "interval", "start", "enabled", "cron_expr", "time_zone", "jitter", "catch_up", "created_at"},
 		[]string{},
 	)
 	registerCompute(&DevToolCronjobs)
 
+	DevToolCronjobRuns = NewDevtoolManager(
+		"devtool_cronjob_run",
+		"devtool_cronjob_runs",
+		[]string{"id", "name", "cronjob_id", "status", "start_time", "end_time", "duration", "reason"},
+		[]string{},
+	)
+	registerCompute(&DevToolCronjobRuns)
+
 	DevToolTemplates = NewDevtoolManager(
 		"devtool_template",
 		"devtool_templates",
-		[]string{"id", "name", "domain_id", "tenant_id", "day", "hour", "min", "sec", "interval", "start", "enabled", "description"},
+		[]string{"id", "name", "domain_id", "tenant_id", "day", "hour", "min", "sec", "interval", "start", "enabled", "cron_expr", "time_zone", "jitter", "catch_up", "description"},
 		[]string{"is_system"},
 	)
 	registerCompute(&DevToolTemplates)
@@ -26,6 +26,10 @@ var (
 	Notification   modulebase.ResourceManager
 	NotifyTemplate modulebase.ResourceManager
 	Configs        ConfigsManager
+
+	NotifyTopic        modulebase.ResourceManager
+	NotifySubscription modulebase.ResourceManager
+	NotifyEscalation   modulebase.ResourceManager
 )
 
 func init() {
@@ -60,4 +64,28 @@ func init() {
 		[]string{},
 	)
 	register(&NotifyTemplate)
+
+	NotifyTopic = NewNotifyv2Manager(
+		"topic",
+		"topics",
+		[]string{"ID", "Name", "Resource_Type", "Event_Class", "Template_Topic", "Enabled", "Subscription_Count"},
+		[]string{},
+	)
+	register(&NotifyTopic)
+
+	NotifySubscription = NewNotifyv2Manager(
+		"subscription",
+		"subscriptions",
+		[]string{"ID", "Name", "Topic", "Subscriber_Type", "Subscriber_Id", "Project_Id", "Contact_Types", "Min_Priority", "Quiet_Hours_Start", "Quiet_Hours_End", "Escalation", "Enabled"},
+		[]string{"Resource_Domain_Id", "Project_Domain"},
+	)
+	register(&NotifySubscription)
+
+	NotifyEscalation = NewNotifyv2Manager(
+		"escalation",
+		"escalations",
+		[]string{"ID", "Name", "Receivers", "Contact_Type", "Ack_Timeout_Minutes", "Min_Priority", "Enabled"},
+		[]string{},
+	)
+	register(&NotifyEscalation)
 }
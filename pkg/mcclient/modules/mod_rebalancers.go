@@ -0,0 +1,38 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
+
+var (
+	Rebalancers         modulebase.ResourceManager
+	RebalanceMigrations modulebase.ResourceManager
+)
+
+func init() {
+	Rebalancers = NewComputeManager("rebalancer", "rebalancers",
+		[]string{"ID", "Name", "Status", "Enabled", "Zone", "Schedtag", "Mode", "Threshold",
+			"Use_Utilization", "Max_Concurrency", "Max_Migrations", "Evaluate_Interval",
+			"Last_Evaluate_At", "Active_Migration_Count"},
+		[]string{},
+	)
+	RebalanceMigrations = NewComputeManager("rebalancemigration", "rebalancemigrations",
+		[]string{"ID", "Name", "Status", "Rebalancer", "Guest", "Source_Host", "Target_Host",
+			"Source_Load", "Target_Load", "Started_At", "Finished_At", "Reason"},
+		[]string{},
+	)
+	registerComputeV2(&Rebalancers)
+	registerComputeV2(&RebalanceMigrations)
+}
@@ -20,6 +20,8 @@ var (
 	ScalingGroup    modulebase.ResourceManager
 	ScalingPolicy   modulebase.ResourceManager
 	ScalingActivity modulebase.ResourceManager
+
+	ScalingLifecycleHook modulebase.ResourceManager
 )
 
 func init() {
@@ -30,7 +32,7 @@ func init() {
 		[]string{},
 	)
 	ScalingPolicy = NewComputeManager("scalingpolicy", "scalingpolicies",
-		[]string{"ID", "Name", "Timer", "Cycle_Timer", "Alarm", "Action", "Number", "Unit", "Cooling_Time"},
+		[]string{"ID", "Name", "Timer", "Cycle_Timer", "Alarm", "Target_Tracking", "Action", "Number", "Unit", "Cooling_Time"},
 		[]string{},
 	)
 	ScalingActivity = NewComputeManager("scalingactivity", "scalingactivities",
@@ -38,7 +40,12 @@ func init() {
 			"End_Time", "Reason"},
 		[]string{},
 	)
+	ScalingLifecycleHook = NewComputeManager("scalinglifecyclehook", "scalinglifecyclehooks",
+		[]string{"ID", "Name", "Scaling_Group_ID", "Transition", "Webhook_Url", "Timeout", "Default_Result"},
+		[]string{},
+	)
 	registerCompute(&ScalingGroup)
 	registerCompute(&ScalingPolicy)
 	registerCompute(&ScalingActivity)
+	registerCompute(&ScalingLifecycleHook)
 }
@@ -24,7 +24,7 @@ func init() {
 	SecGroupRules = NewComputeManager("secgrouprule", "secgrouprules",
 		[]string{"ID", "Name", "Direction",
 			"Action", "Protocol", "Ports", "Priority",
-			"Cidr", "Secgroup", "Tenant", "Description"},
+			"Cidr", "Address_Group_Id", "Secgroup", "Tenant", "Description"},
 		[]string{"SecGroups"})
 
 	registerCompute(&SecGroupRules)
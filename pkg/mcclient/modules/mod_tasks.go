@@ -42,7 +42,7 @@ func init() {
 	ComputeTasks = ComputeTasksManager{
 		ResourceManager: NewComputeManager("task", "tasks",
 			[]string{},
-			[]string{"Id", "Obj_name", "Obj_Id", "Task_name", "Stage", "Created_at"}),
+			[]string{"Id", "Obj_name", "Obj_Id", "Task_name", "Stage", "Stage_deadline", "Created_at"}),
 	}
 	registerCompute(&ComputeTasks)
 }
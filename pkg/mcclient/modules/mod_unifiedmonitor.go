@@ -1,6 +1,14 @@
 package modules
 
-import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+)
 
 var (
 	UnifiedMonitorManager *SUnifiedMonitorManager
@@ -27,3 +35,11 @@ func NewUnifiedMonitorManager() *SUnifiedMonitorManager {
 		ResourceManager: &man,
 	}
 }
+
+// PerformQuery signs the metric query input and performs it
+func (self *SUnifiedMonitorManager) PerformQuery(s *mcclient.ClientSession, input jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	data := input.(*jsonutils.JSONDict).Copy()
+	data.Remove("signature")
+	data.Set("signature", jsonutils.NewString(fmt.Sprintf("%x", sha256.Sum256([]byte(data.String())))))
+	return self.PerformClassAction(s, "query", data)
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
+
+var (
+	Webhooks          modulebase.ResourceManager
+	WebhookDeliveries modulebase.ResourceManager
+)
+
+func init() {
+	Webhooks = NewComputeManager("webhook", "webhooks",
+		[]string{"id", "name", "enabled", "status",
+			"url", "resource_types", "actions", "project",
+			"max_attempts", "pending_count", "dead_count",
+			"delivered_count", "last_error", "last_delivery_at",
+		},
+		[]string{})
+	registerCompute(&Webhooks)
+
+	WebhookDeliveries = NewComputeManager("webhook_delivery", "webhook_deliveries",
+		[]string{"id", "webhook", "event_id", "event_type",
+			"subject", "status", "attempts", "next_attempt_at",
+			"last_status_code", "last_error", "delivered_at", "created_at",
+		},
+		[]string{})
+	registerCompute(&WebhookDeliveries)
+}
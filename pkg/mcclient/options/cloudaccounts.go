@@ -294,6 +294,76 @@ func (opts *SZStackCloudAccountCreateOptions) Params() (jsonutils.JSONObject, er
 	return params, nil
 }
 
+type SProxmoxCloudAccountCreateOptions struct {
+	SCloudAccountCreateBaseOptions
+	SUserPasswordCredential
+	AuthURL string `help:"Proxmox VE api url, e.g. https://192.168.1.2:8006" positional:"true" json:"auth_url"`
+}
+
+func (opts *SProxmoxCloudAccountCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts)
+	params.(*jsonutils.JSONDict).Add(jsonutils.NewString("Proxmox"), "provider")
+	return params, nil
+}
+
+type SOVirtCloudAccountCreateOptions struct {
+	SCloudAccountCreateBaseOptions
+	SUserPasswordCredential
+	AuthURL string `help:"oVirt engine url, e.g. https://engine.example.com/ovirt-engine/api" positional:"true" json:"auth_url"`
+}
+
+func (opts *SOVirtCloudAccountCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts)
+	params.(*jsonutils.JSONDict).Add(jsonutils.NewString("oVirt"), "provider")
+	return params, nil
+}
+
+type SSimulatorCloudAccountCreateOptions struct {
+	SCloudAccountCreateBaseOptions
+	AuthURL  string `help:"Simulator store url, e.g. memory://default or file:///tmp/simulator.json" json:"auth_url"`
+	Username string `help:"Account name of simulator" json:"username"`
+}
+
+func (opts *SSimulatorCloudAccountCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts)
+	params.(*jsonutils.JSONDict).Add(jsonutils.NewString("Simulator"), "provider")
+	return params, nil
+}
+
+type SLibvirtCredential struct {
+	Username       string `help:"Ssh username, default root" json:"username"`
+	Password       string `help:"Ssh password" json:"password"`
+	PrivateKeyFile string `help:"Ssh private key file, overrides password" json:"-"`
+}
+
+// 私钥内容通过密码字段传递
+func (cred *SLibvirtCredential) params(params jsonutils.JSONObject) error {
+	if len(cred.PrivateKeyFile) > 0 {
+		data, err := ioutil.ReadFile(cred.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		params.(*jsonutils.JSONDict).Set("password", jsonutils.NewString(string(data)))
+	}
+	return nil
+}
+
+type SLibvirtCloudAccountCreateOptions struct {
+	SCloudAccountCreateBaseOptions
+	SLibvirtCredential
+	AuthURL string `help:"Comma separated libvirt uris, e.g. qemu+ssh://10.0.0.1/system,qemu+tls://10.0.0.2/system" positional:"true" json:"auth_url"`
+}
+
+func (opts *SLibvirtCloudAccountCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts)
+	err := opts.SLibvirtCredential.params(params)
+	if err != nil {
+		return nil, err
+	}
+	params.(*jsonutils.JSONDict).Add(jsonutils.NewString("Libvirt"), "provider")
+	return params, nil
+}
+
 type SS3CloudAccountCreateOptions struct {
 	SCloudAccountCreateBaseOptions
 	SAccessKeyCredential
@@ -432,6 +502,47 @@ func (opts *SZStackCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSON
 	return jsonutils.Marshal(opts), nil
 }
 
+type SProxmoxCloudAccountUpdateCredentialOptions struct {
+	SCloudAccountIdOptions
+	SUserPasswordCredential
+}
+
+func (opts *SProxmoxCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type SOVirtCloudAccountUpdateCredentialOptions struct {
+	SCloudAccountIdOptions
+	SUserPasswordCredential
+}
+
+func (opts *SOVirtCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type SSimulatorCloudAccountUpdateCredentialOptions struct {
+	SCloudAccountIdOptions
+	Username string `help:"Account name of simulator" json:"username"`
+}
+
+func (opts *SSimulatorCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type SLibvirtCloudAccountUpdateCredentialOptions struct {
+	SCloudAccountIdOptions
+	SLibvirtCredential
+}
+
+func (opts *SLibvirtCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts)
+	err := opts.SLibvirtCredential.params(params)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
 type SS3CloudAccountUpdateCredentialOptions struct {
 	SCloudAccountIdOptions
 	SAccessKeyCredential
@@ -733,6 +844,38 @@ func (opts *SZStackCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, er
 	return jsonutils.Marshal(opts), nil
 }
 
+type SProxmoxCloudAccountUpdateOptions struct {
+	SCloudAccountUpdateBaseOptions
+}
+
+func (opts *SProxmoxCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type SOVirtCloudAccountUpdateOptions struct {
+	SCloudAccountUpdateBaseOptions
+}
+
+func (opts *SOVirtCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type SSimulatorCloudAccountUpdateOptions struct {
+	SCloudAccountUpdateBaseOptions
+}
+
+func (opts *SSimulatorCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type SLibvirtCloudAccountUpdateOptions struct {
+	SCloudAccountUpdateBaseOptions
+}
+
+func (opts *SLibvirtCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
 type SS3CloudAccountUpdateOptions struct {
 	SCloudAccountUpdateBaseOptions
 }
@@ -46,6 +46,26 @@ type DevtoolTemplateCronjobOptions struct {
 	Interval int64 `help:"Cronjob runs at given interval" default:"0"`
 	Start    bool  `help:"start job when created" default:"false"`
 	Enabled  bool  `help:"Set job status enabled" default:"false"`
+
+	CronExpr string `help:"Standard cron expression with 5 or 6 (leading seconds) fields, e.g. '30 2 * * mon-fri'"`
+	TimeZone string `help:"Time zone of cron expression, e.g. Asia/Shanghai"`
+	Jitter   int    `help:"Max random delay seconds of each run"`
+	CatchUp  string `help:"Policy of missed runs" choices:"skip|once|all"`
+}
+
+func (opts *DevtoolTemplateCronjobOptions) cronParams(params *jsonutils.JSONDict) {
+	if len(opts.CronExpr) > 0 {
+		params.Add(jsonutils.NewString(opts.CronExpr), "cron_expr")
+	}
+	if len(opts.TimeZone) > 0 {
+		params.Add(jsonutils.NewString(opts.TimeZone), "time_zone")
+	}
+	if opts.Jitter > 0 {
+		params.Add(jsonutils.NewInt(int64(opts.Jitter)), "jitter")
+	}
+	if len(opts.CatchUp) > 0 {
+		params.Add(jsonutils.NewString(opts.CatchUp), "catch_up")
+	}
 }
 
 type DevtoolTemplateCommonOptions struct {
@@ -127,6 +147,7 @@ func (opts *DevtoolTemplateCreateOptions) Params() (*jsonutils.JSONDict, error)
 	params.Add(jsonutils.NewInt(opts.Interval), "interval")
 	params.Add(jsonutils.NewBool(opts.Start), "start")
 	params.Add(jsonutils.NewBool(opts.Enabled), "enabled")
+	opts.cronParams(params)
 	return params, nil
 }
 
@@ -155,5 +176,6 @@ func (opts *DevtoolTemplateUpdateOptions) Params() (*jsonutils.JSONDict, error)
 	params.Add(jsonutils.NewInt(opts.Interval), "interval")
 	params.Add(jsonutils.NewBool(opts.Start), "start")
 	params.Add(jsonutils.NewBool(opts.Enabled), "enabled")
+	opts.cronParams(params)
 	return params, nil
 }
@@ -0,0 +1,222 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type TopicListOptions struct {
+	options.BaseListOptions
+	ResourceType string `help:"resource type of topic"`
+	EventClass   string `help:"event class of topic"`
+}
+
+func (tl *TopicListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(tl)
+}
+
+type TopicCreateOptions struct {
+	NAME          string `help:"name of topic"`
+	RESOURCETYPE  string `help:"resource type of topic, e.g. server" json:"resource_type"`
+	EVENTCLASS    string `help:"event class of topic, e.g. failure" json:"event_class"`
+	TemplateTopic string `help:"template topic used to render message"`
+}
+
+func (tc *TopicCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(tc), nil
+}
+
+type TopicOptions struct {
+	ID string `help:"Id or Name of topic"`
+}
+
+func (t *TopicOptions) GetId() string {
+	return t.ID
+}
+
+func (t *TopicOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type TopicUpdateOptions struct {
+	TopicOptions
+	TemplateTopic string `help:"template topic used to render message"`
+}
+
+func (tu *TopicUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	if len(tu.TemplateTopic) > 0 {
+		d.Set("template_topic", jsonutils.NewString(tu.TemplateTopic))
+	}
+	return d, nil
+}
+
+type TopicPublishOptions struct {
+	Topic        string `help:"Id or Name of topic"`
+	ResourceType string `help:"resource type, used when topic is not specified"`
+	EventClass   string `help:"event class, used when topic is not specified"`
+	ProjectId    string `help:"project of resource"`
+	DomainId     string `help:"domain of resource"`
+	ResourceId   string `help:"id of resource"`
+	ResourceName string `help:"name of resource"`
+	Priority     string `help:"priority of notification" choices:"normal|important|fatal"`
+	MESSAGE      string `help:"message content"`
+	Tag          string `help:"tag of notification"`
+	DedupKey     string `help:"dedup key of notifications"`
+}
+
+func (tp *TopicPublishOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(tp), nil
+}
+
+type SubscriptionListOptions struct {
+	options.BaseListOptions
+	Topic          string `help:"Id or Name of topic"`
+	SubscriberType string `help:"subscriber type" choices:"user|group"`
+	SubscriberId   string `help:"id of user or group"`
+	Escalation     string `help:"Id or Name of escalation"`
+}
+
+func (sl *SubscriptionListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(sl)
+}
+
+type SubscriptionCreateOptions struct {
+	NAME             string   `help:"name of subscription"`
+	TOPIC            string   `help:"Id or Name of topic"`
+	SubscriberType   string   `help:"subscriber type, default to user" choices:"user|group"`
+	Subscriber       string   `help:"Id or Name of user or group, default to current user"`
+	ProjectId        string   `help:"only receive events of resources in this project"`
+	ResourceDomainId string   `help:"only receive events of resources in this domain"`
+	ContactTypes     []string `help:"channels to receive notifications, default to webconsole"`
+	MinPriority      string   `help:"ignore notifications whose priority is lower" choices:"normal|important|fatal"`
+	QuietHoursStart  string   `help:"start of quiet hours, HH:MM"`
+	QuietHoursEnd    string   `help:"end of quiet hours, HH:MM"`
+	Escalation       string   `help:"Id or Name of escalation"`
+}
+
+func (sc *SubscriptionCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(sc), nil
+}
+
+type SubscriptionOptions struct {
+	ID string `help:"Id or Name of subscription"`
+}
+
+func (s *SubscriptionOptions) GetId() string {
+	return s.ID
+}
+
+func (s *SubscriptionOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type SubscriptionUpdateOptions struct {
+	SubscriptionOptions
+	ContactTypes    []string `help:"channels to receive notifications"`
+	MinPriority     string   `help:"ignore notifications whose priority is lower" choices:"normal|important|fatal"`
+	QuietHoursStart string   `help:"start of quiet hours, HH:MM"`
+	QuietHoursEnd   string   `help:"end of quiet hours, HH:MM"`
+	NoQuietHours    bool     `help:"clear quiet hours"`
+	Escalation      string   `help:"Id or Name of escalation"`
+	NoEscalation    bool     `help:"clear escalation"`
+}
+
+func (su *SubscriptionUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	if len(su.ContactTypes) > 0 {
+		d.Set("contact_types", jsonutils.NewStringArray(su.ContactTypes))
+	}
+	if len(su.MinPriority) > 0 {
+		d.Set("min_priority", jsonutils.NewString(su.MinPriority))
+	}
+	if su.NoQuietHours {
+		d.Set("quiet_hours_start", jsonutils.NewString(""))
+		d.Set("quiet_hours_end", jsonutils.NewString(""))
+	} else {
+		if len(su.QuietHoursStart) > 0 {
+			d.Set("quiet_hours_start", jsonutils.NewString(su.QuietHoursStart))
+		}
+		if len(su.QuietHoursEnd) > 0 {
+			d.Set("quiet_hours_end", jsonutils.NewString(su.QuietHoursEnd))
+		}
+	}
+	if su.NoEscalation {
+		d.Set("escalation", jsonutils.NewString(""))
+	} else if len(su.Escalation) > 0 {
+		d.Set("escalation", jsonutils.NewString(su.Escalation))
+	}
+	return d, nil
+}
+
+type EscalationListOptions struct {
+	options.BaseListOptions
+}
+
+func (el *EscalationListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(el)
+}
+
+type EscalationCreateOptions struct {
+	NAME              string   `help:"name of escalation"`
+	RECEIVERS         []string `help:"Id or Name of receivers, notified in order"`
+	ContactType       string   `help:"contact type used to notify escalation receivers"`
+	AckTimeoutMinutes int      `help:"minutes to wait for ack before notifying next receiver"`
+	MinPriority       string   `help:"only escalate notifications whose priority is not lower" choices:"normal|important|fatal"`
+}
+
+func (ec *EscalationCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(ec), nil
+}
+
+type EscalationOptions struct {
+	ID string `help:"Id or Name of escalation"`
+}
+
+func (e *EscalationOptions) GetId() string {
+	return e.ID
+}
+
+func (e *EscalationOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type EscalationUpdateOptions struct {
+	EscalationOptions
+	Receivers         []string `help:"Id or Name of receivers, notified in order"`
+	ContactType       string   `help:"contact type used to notify escalation receivers"`
+	AckTimeoutMinutes int      `help:"minutes to wait for ack before notifying next receiver"`
+	MinPriority       string   `help:"only escalate notifications whose priority is not lower" choices:"normal|important|fatal"`
+}
+
+func (eu *EscalationUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	if len(eu.Receivers) > 0 {
+		d.Set("receivers", jsonutils.NewStringArray(eu.Receivers))
+	}
+	if len(eu.ContactType) > 0 {
+		d.Set("contact_type", jsonutils.NewString(eu.ContactType))
+	}
+	if eu.AckTimeoutMinutes > 0 {
+		d.Set("ack_timeout_minutes", jsonutils.NewInt(int64(eu.AckTimeoutMinutes)))
+	}
+	if len(eu.MinPriority) > 0 {
+		d.Set("min_priority", jsonutils.NewString(eu.MinPriority))
+	}
+	return d, nil
+}
@@ -378,6 +378,7 @@ type ServerCreateOptionalOptions struct {
 	DryRun           *bool    `help:"Dry run to test scheduler" json:"-"`
 	UserDataFile     string   `help:"user_data file path" json:"-"`
 	InstanceSnapshot string   `help:"instance snapshot" json:"instance_snapshot"`
+	NumaPolicy       string   `help:"Confine guest to a single NUMA node with pinned vcpus, KVM only" choices:"strict"`
 	Secgroups        []string `help:"secgroups" json:"secgroups"`
 
 	OsType string `help:"os type, e.g. Linux, Windows, etc."`
@@ -534,6 +535,7 @@ func (opts *ServerCreateOptionalOptions) OptionalParams() (*computeapi.ServerCre
 
 	// group
 	params.InstanceGroupIds = opts.Group
+	params.NumaPolicy = opts.NumaPolicy
 	// set description
 	params.Description = opts.Desc
 
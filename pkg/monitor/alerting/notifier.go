@@ -25,7 +25,9 @@ import (
 
 	"yunion.io/x/onecloud/pkg/apis"
 	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/monitor/models"
 	"yunion.io/x/onecloud/pkg/monitor/notifydrivers"
@@ -39,6 +41,8 @@ func newNotificationService() *notificationService {
 }
 
 func (n *notificationService) SendIfNeeded(evalCtx *EvalContext) error {
+	n.publishToTopic(evalCtx)
+
 	notifierStates, err := n.getNeededNotifiers(evalCtx.Rule.Notifications, evalCtx)
 	if err != nil {
 		return errors.Wrap(err, "failed to get alert notifiers")
@@ -51,6 +55,43 @@ func (n *notificationService) SendIfNeeded(evalCtx *EvalContext) error {
 	return n.sendNotifications(evalCtx, notifierStates)
 }
 
+// 告警进入告警状态时发布到通知主题, 订阅者按项目和级别接收
+func (n *notificationService) publishToTopic(evalCtx *EvalContext) {
+	if evalCtx.IsTestRun || !evalCtx.Firing {
+		return
+	}
+	if evalCtx.Rule.State != monitor.AlertStateAlerting || evalCtx.PrevAlertState == monitor.AlertStateAlerting {
+		return
+	}
+	input := notify.TopicPublishInput{
+		ResourceType: models.CommonAlertManager.Keyword(),
+		EventClass:   notify.TOPIC_EVENT_CLASS_ALERT,
+		ResourceId:   evalCtx.Rule.Id,
+		ResourceName: evalCtx.GetRuleTitle(),
+		Priority:     alertPriority(evalCtx.Rule.Level),
+		Message:      evalCtx.GetNotificationTitle(),
+	}
+	alert, err := models.CommonAlertManager.FetchById(evalCtx.Rule.Id)
+	if err != nil {
+		log.Errorf("fetch alert %s: %v", evalCtx.Rule.Id, err)
+	} else if owner := alert.GetOwnerId(); owner != nil {
+		input.ProjectId = owner.GetProjectId()
+		input.DomainId = owner.GetProjectDomainId()
+	}
+	notifyclient.PublishTopicEvent(evalCtx.Ctx, input)
+}
+
+func alertPriority(level string) string {
+	switch level {
+	case "important":
+		return notify.NOTIFICATION_PRIORITY_IMPORTANT
+	case "fatal", "critical":
+		return notify.NOTIFICATION_PRIORITY_CRITICAL
+	default:
+		return notify.NOTIFICATION_PRIORITY_NORMAL
+	}
+}
+
 type notifierState struct {
 	notifier Notifier
 	state    *models.SAlertnotification
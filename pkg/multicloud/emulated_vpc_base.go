@@ -0,0 +1,73 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicloud
+
+import (
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+// 没有VPC概念的平台, 整个平台模拟为一个默认VPC, 不支持安全组与路由表
+type SEmulatedVpcBase struct {
+	SVpc
+}
+
+func (self *SEmulatedVpcBase) IsEmulated() bool {
+	return true
+}
+
+func (self *SEmulatedVpcBase) GetIsDefault() bool {
+	return true
+}
+
+func (self *SEmulatedVpcBase) GetCidrBlock() string {
+	return ""
+}
+
+func (self *SEmulatedVpcBase) GetStatus() string {
+	return api.VPC_STATUS_AVAILABLE
+}
+
+func (self *SEmulatedVpcBase) GetISecurityGroups() ([]cloudprovider.ICloudSecurityGroup, error) {
+	return []cloudprovider.ICloudSecurityGroup{}, nil
+}
+
+func (self *SEmulatedVpcBase) GetIRouteTables() ([]cloudprovider.ICloudRouteTable, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (self *SEmulatedVpcBase) GetIRouteTableById(routeTableId string) (cloudprovider.ICloudRouteTable, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (self *SEmulatedVpcBase) Delete() error {
+	return cloudprovider.ErrNotSupported
+}
+
+// 在vpc的二层网络中按全局ID查找
+func GetIWireById(vpc cloudprovider.ICloudVpc, wireId string) (cloudprovider.ICloudWire, error) {
+	wires, err := vpc.GetIWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		if wires[i].GetGlobalId() == wireId {
+			return wires[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "wire %s", wireId)
+}
@@ -0,0 +1,393 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 虚拟机磁盘与存储卷的关联关系
+type sDiskAttachment struct {
+	InstanceId string
+	Target     string
+	Bus        string
+	Bootable   bool
+}
+
+// 每个存储卷对应一块磁盘, Id为 存储池UUID/卷名
+type SDisk struct {
+	multicloud.SDisk
+	storage *SStorage
+
+	Name   string
+	Path   string
+	Volume libvirtxml.StorageVolume
+
+	// 挂载信息, 由所在主机上虚拟机的磁盘配置填充
+	InstanceId string
+	Target     string
+	Bus        string
+	Bootable   bool
+}
+
+func parseDiskId(diskId string) (string, string, error) {
+	parts := strings.SplitN(diskId, "/", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", errors.Wrapf(cloudprovider.ErrNotFound, "invalid disk id %s", diskId)
+	}
+	return parts[0], parts[1], nil
+}
+
+func volumeKey(pool, name string) string {
+	return fmt.Sprintf("volume:%s/%s", pool, name)
+}
+
+// 虚拟机磁盘可能以文件、块设备或存储卷的形式引用
+func diskSourceKeys(disk *libvirtxml.DomainDisk) []string {
+	keys := []string{}
+	if disk.Source == nil {
+		return keys
+	}
+	if disk.Source.File != nil && len(disk.Source.File.File) > 0 {
+		keys = append(keys, disk.Source.File.File)
+	}
+	if disk.Source.Block != nil && len(disk.Source.Block.Dev) > 0 {
+		keys = append(keys, disk.Source.Block.Dev)
+	}
+	if disk.Source.Volume != nil {
+		keys = append(keys, volumeKey(disk.Source.Volume.Pool, disk.Source.Volume.Volume))
+	}
+	if disk.Source.Network != nil && len(disk.Source.Network.Name) > 0 {
+		keys = append(keys, disk.Source.Network.Name)
+	}
+	return keys
+}
+
+// 存储卷本身不包含挂载信息, 需从主机上所有虚拟机的磁盘配置中获取
+func (host *SHost) getDiskAttachments() (map[string]sDiskAttachment, error) {
+	instances, err := host.GetInstances()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetInstances")
+	}
+	attachments := map[string]sDiskAttachment{}
+	for i := range instances {
+		for j, disk := range instances[i].getDisks() {
+			attachment := sDiskAttachment{
+				InstanceId: instances[i].GetGlobalId(),
+				Bootable:   j == 0,
+			}
+			if disk.Target != nil {
+				attachment.Target = disk.Target.Dev
+				attachment.Bus = disk.Target.Bus
+			}
+			for _, key := range diskSourceKeys(&disk) {
+				attachments[key] = attachment
+			}
+		}
+	}
+	return attachments, nil
+}
+
+func (disk *SDisk) attach(attachments map[string]sDiskAttachment) {
+	for _, key := range []string{disk.Path, volumeKey(disk.storage.Name, disk.Name)} {
+		if attachment, ok := attachments[key]; ok {
+			disk.InstanceId = attachment.InstanceId
+			disk.Target = attachment.Target
+			disk.Bus = attachment.Bus
+			disk.Bootable = attachment.Bootable
+			return
+		}
+	}
+	// rbd卷在虚拟机中以 pool/image 形式引用
+	if disk.storage.Pool.Source != nil && len(disk.storage.Pool.Source.Name) > 0 {
+		if attachment, ok := attachments[fmt.Sprintf("%s/%s", disk.storage.Pool.Source.Name, disk.Name)]; ok {
+			disk.InstanceId = attachment.InstanceId
+			disk.Target = attachment.Target
+			disk.Bus = attachment.Bus
+			disk.Bootable = attachment.Bootable
+		}
+	}
+}
+
+func (storage *SStorage) getDisk(name string) (*SDisk, error) {
+	disk := &SDisk{storage: storage, Name: name}
+	err := storage.host.conn.dumpxml(&disk.Volume, "vol-dumpxml", "--pool", storage.Name, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "vol-dumpxml %s", name)
+	}
+	if disk.Volume.Target != nil {
+		disk.Path = disk.Volume.Target.Path
+	}
+	return disk, nil
+}
+
+// 解析 virsh vol-list 的表格输出, 最后一列为卷路径
+func parseVolumeList(output string) []string {
+	names := []string{}
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i < 2 || len(line) == 0 || strings.HasPrefix(line, "---") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 1 {
+			fields = fields[:len(fields)-1]
+		}
+		names = append(names, strings.Join(fields, " "))
+	}
+	return names
+}
+
+func (storage *SStorage) GetDisks() ([]SDisk, error) {
+	if storage.State != "running" {
+		return []SDisk{}, nil
+	}
+	output, err := storage.host.conn.virsh("vol-list", "--pool", storage.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "vol-list %s", storage.Name)
+	}
+	attachments, err := storage.host.getDiskAttachments()
+	if err != nil {
+		return nil, err
+	}
+	disks := []SDisk{}
+	for _, name := range parseVolumeList(output) {
+		disk, err := storage.getDisk(name)
+		if err != nil {
+			return nil, err
+		}
+		disk.attach(attachments)
+		disks = append(disks, *disk)
+	}
+	return disks, nil
+}
+
+func (storage *SStorage) GetDisk(name string) (*SDisk, error) {
+	disk, err := storage.getDisk(name)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := storage.host.getDiskAttachments()
+	if err != nil {
+		return nil, err
+	}
+	disk.attach(attachments)
+	return disk, nil
+}
+
+// 目录类存储池使用qcow2格式, 其他存储池由libvirt决定卷格式
+func (storage *SStorage) CreateDisk(name string, sizeGb int) (*SDisk, error) {
+	args := []string{"vol-create-as", storage.Name}
+	switch storage.Pool.Type {
+	case "dir", "fs", "netfs", "gluster":
+		if !strings.HasSuffix(name, ".qcow2") {
+			name = name + ".qcow2"
+		}
+		args = append(args, name, fmt.Sprintf("%dG", sizeGb), "--format", "qcow2")
+	default:
+		args = append(args, name, fmt.Sprintf("%dG", sizeGb))
+	}
+	_, err := storage.host.conn.virsh(args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "vol-create-as %s", name)
+	}
+	return storage.GetDisk(name)
+}
+
+func (host *SHost) getDiskById(diskId string) (*SDisk, error) {
+	poolId, name, err := parseDiskId(diskId)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := host.GetStorage(poolId)
+	if err != nil {
+		return nil, err
+	}
+	return storage.GetDisk(name)
+}
+
+func (region *SRegion) GetDisk(diskId string) (*SDisk, error) {
+	poolId, name, err := parseDiskId(diskId)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := region.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range hosts {
+		storage, err := host.GetStorage(poolId)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		return storage.GetDisk(name)
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s", diskId)
+}
+
+func (disk *SDisk) GetId() string {
+	return fmt.Sprintf("%s/%s", disk.storage.Id, disk.Name)
+}
+
+func (disk *SDisk) GetName() string {
+	return disk.Name
+}
+
+func (disk *SDisk) GetGlobalId() string {
+	return disk.GetId()
+}
+
+func (disk *SDisk) IsEmulated() bool {
+	return false
+}
+
+func (disk *SDisk) GetStatus() string {
+	return api.DISK_READY
+}
+
+func (disk *SDisk) Refresh() error {
+	newDisk, err := disk.storage.GetDisk(disk.Name)
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(disk, newDisk)
+}
+
+func (disk *SDisk) GetIStorage() (cloudprovider.ICloudStorage, error) {
+	return disk.storage, nil
+}
+
+func (disk *SDisk) GetIStorageId() string {
+	return disk.storage.Id
+}
+
+func (disk *SDisk) GetDiskFormat() string {
+	if disk.Volume.Target != nil && disk.Volume.Target.Format != nil && len(disk.Volume.Target.Format.Type) > 0 {
+		return disk.Volume.Target.Format.Type
+	}
+	return "raw"
+}
+
+func (disk *SDisk) GetDiskSizeMB() int {
+	if disk.Volume.Capacity == nil {
+		return 0
+	}
+	return int(int64(disk.Volume.Capacity.Value) * unitBytes(disk.Volume.Capacity.Unit) / 1024 / 1024)
+}
+
+func (disk *SDisk) GetIsAutoDelete() bool {
+	return false
+}
+
+func (disk *SDisk) GetTemplateId() string {
+	return ""
+}
+
+func (disk *SDisk) GetDiskType() string {
+	if disk.Bootable {
+		return api.DISK_TYPE_SYS
+	}
+	return api.DISK_TYPE_DATA
+}
+
+func (disk *SDisk) GetFsFormat() string {
+	return ""
+}
+
+func (disk *SDisk) GetIsNonPersistent() bool {
+	return false
+}
+
+func (disk *SDisk) GetDriver() string {
+	switch disk.Bus {
+	case "ide", "sata", "scsi":
+		return disk.Bus
+	default:
+		return "virtio"
+	}
+}
+
+func (disk *SDisk) GetCacheMode() string {
+	return "none"
+}
+
+func (disk *SDisk) GetMountpoint() string {
+	return ""
+}
+
+func (disk *SDisk) GetAccessPath() string {
+	return disk.Path
+}
+
+func (disk *SDisk) Delete(ctx context.Context) error {
+	if len(disk.InstanceId) > 0 {
+		return fmt.Errorf("disk %s is attached to vm %s", disk.GetName(), disk.InstanceId)
+	}
+	_, err := disk.storage.host.conn.virsh("vol-delete", "--pool", disk.storage.Name, disk.Name)
+	return err
+}
+
+// 已挂载到运行中虚拟机的磁盘需通过blockresize在线扩容
+func (disk *SDisk) Resize(ctx context.Context, sizeMb int64) error {
+	conn := disk.storage.host.conn
+	if len(disk.InstanceId) > 0 {
+		instance, err := disk.storage.host.GetInstance(disk.InstanceId)
+		if err != nil {
+			return errors.Wrapf(err, "GetInstance %s", disk.InstanceId)
+		}
+		if instance.GetStatus() == api.VM_RUNNING {
+			_, err = conn.virsh("blockresize", instance.GetGlobalId(), disk.Target, fmt.Sprintf("%dM", sizeMb))
+			return err
+		}
+	}
+	_, err := conn.virsh("vol-resize", "--pool", disk.storage.Name, disk.Name, fmt.Sprintf("%dM", sizeMb))
+	return err
+}
+
+// libvirt的快照为虚拟机级别, 不支持单独的磁盘快照
+func (disk *SDisk) CreateISnapshot(ctx context.Context, name, desc string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (disk *SDisk) Reset(ctx context.Context, snapshotId string) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) Rebuild(ctx context.Context) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetProjectId() string {
+	return ""
+}
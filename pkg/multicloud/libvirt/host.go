@@ -0,0 +1,320 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SSysInfo struct {
+	XMLName xml.Name `xml:"sysinfo"`
+	System  struct {
+		Entries []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"entry"`
+	} `xml:"system"`
+}
+
+func (info *SSysInfo) get(name string) string {
+	for _, entry := range info.System.Entries {
+		if entry.Name == name {
+			return strings.TrimSpace(entry.Value)
+		}
+	}
+	return ""
+}
+
+// 每个libvirt连接对应一台主机, 以capabilities中的主机UUID作为Id
+type SHost struct {
+	multicloud.SHostBase
+	zone *SZone
+	conn *SConnection
+
+	Id           string
+	Name         string
+	Uri          string
+	Version      string
+	AccessIp     string
+	CpuModel     string
+	CpuCount     int
+	CpuSockets   int
+	CpuMhz       int
+	MemSizeMb    int
+	Manufacturer string
+	Model        string
+	SN           string
+
+	// 同步过程中缓存, Refresh时清空
+	storages  []SStorage
+	instances []SInstance
+}
+
+func (region *SRegion) getHost(conn *SConnection) (*SHost, error) {
+	host := &SHost{conn: conn, Uri: conn.Uri}
+	caps := libvirtxml.Caps{}
+	err := conn.dumpxml(&caps, "capabilities")
+	if err != nil {
+		return nil, errors.Wrapf(err, "capabilities")
+	}
+	host.Id = caps.Host.UUID
+	if caps.Host.CPU != nil {
+		host.CpuModel = caps.Host.CPU.Model
+		if caps.Host.CPU.Topology != nil {
+			host.CpuSockets = caps.Host.CPU.Topology.Sockets
+		}
+	}
+	nodeinfo, err := conn.info("nodeinfo")
+	if err != nil {
+		return nil, errors.Wrapf(err, "nodeinfo")
+	}
+	host.CpuCount, _ = strconv.Atoi(nodeinfo["CPU(s)"])
+	host.CpuMhz, _ = strconv.Atoi(strings.TrimSuffix(nodeinfo["CPU frequency"], " MHz"))
+	if memory, err := strconv.Atoi(strings.TrimSuffix(nodeinfo["Memory size"], " KiB")); err == nil {
+		host.MemSizeMb = memory / 1024
+	}
+	if len(host.CpuModel) == 0 {
+		host.CpuModel = nodeinfo["CPU model"]
+	}
+	if host.CpuSockets == 0 {
+		host.CpuSockets, _ = strconv.Atoi(nodeinfo["CPU socket(s)"])
+	}
+	hostname, err := conn.virsh("hostname")
+	if err != nil {
+		return nil, errors.Wrapf(err, "hostname")
+	}
+	host.Name = strings.TrimSpace(hostname)
+	host.Version, _ = conn.GetVersion()
+	// 部分平台不支持sysinfo
+	sysinfo := SSysInfo{}
+	if err := conn.dumpxml(&sysinfo, "sysinfo"); err == nil {
+		host.Manufacturer = sysinfo.get("manufacturer")
+		host.Model = sysinfo.get("product")
+		host.SN = sysinfo.get("serial")
+		if len(host.Id) == 0 {
+			host.Id = sysinfo.get("uuid")
+		}
+	}
+	if len(host.Id) == 0 {
+		return nil, errors.Errorf("empty host uuid of %s", conn.Uri)
+	}
+	if u, err := url.Parse(conn.Uri); err == nil {
+		host.AccessIp = u.Hostname()
+		if ip := net.ParseIP(host.AccessIp); ip == nil && len(host.AccessIp) > 0 {
+			if addrs, err := net.LookupHost(host.AccessIp); err == nil && len(addrs) > 0 {
+				host.AccessIp = addrs[0]
+			}
+		}
+	}
+	return host, nil
+}
+
+func (region *SRegion) GetHosts() ([]*SHost, error) {
+	return region.getZone().getHosts()
+}
+
+func (region *SRegion) GetHost(id string) (*SHost, error) {
+	hosts, err := region.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		if hosts[i].Id == id {
+			return hosts[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "host %s", id)
+}
+
+func (host *SHost) getRegion() *SRegion {
+	return host.zone.region
+}
+
+func (host *SHost) GetConnection() *SConnection {
+	return host.conn
+}
+
+func (host *SHost) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	wires, err := host.GetWires()
+	if err != nil {
+		return nil, err
+	}
+	iwires := []cloudprovider.ICloudWire{}
+	for i := 0; i < len(wires); i++ {
+		iwires = append(iwires, &wires[i])
+	}
+	return iwires, nil
+}
+
+func (host *SHost) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	storages, err := host.GetStorages()
+	if err != nil {
+		return nil, err
+	}
+	istorages := []cloudprovider.ICloudStorage{}
+	for i := range storages {
+		istorages = append(istorages, &storages[i])
+	}
+	return istorages, nil
+}
+
+func (host *SHost) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	storage, err := host.GetStorage(id)
+	if err != nil {
+		return nil, err
+	}
+	return storage, nil
+}
+
+func (host *SHost) GetIVMs() ([]cloudprovider.ICloudVM, error) {
+	instances, err := host.GetInstances()
+	if err != nil {
+		return nil, err
+	}
+	ivms := []cloudprovider.ICloudVM{}
+	for i := 0; i < len(instances); i++ {
+		ivms = append(ivms, &instances[i])
+	}
+	return ivms, nil
+}
+
+func (host *SHost) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	return host.GetInstance(id)
+}
+
+func (host *SHost) GetId() string {
+	return host.Id
+}
+
+func (host *SHost) GetName() string {
+	return host.Name
+}
+
+func (host *SHost) GetGlobalId() string {
+	return host.GetId()
+}
+
+func (host *SHost) IsEmulated() bool {
+	return false
+}
+
+func (host *SHost) GetStatus() string {
+	return api.HOST_STATUS_RUNNING
+}
+
+func (host *SHost) Refresh() error {
+	newHost, err := host.getRegion().getHost(host.conn)
+	if err != nil {
+		return err
+	}
+	host.storages, host.instances = nil, nil
+	return jsonutils.Update(host, newHost)
+}
+
+// 能获取到主机信息即说明libvirtd在线
+func (host *SHost) GetHostStatus() string {
+	return api.HOST_ONLINE
+}
+
+func (host *SHost) GetEnabled() bool {
+	return true
+}
+
+func (host *SHost) GetAccessIp() string {
+	return host.AccessIp
+}
+
+func (host *SHost) GetAccessMac() string {
+	return ""
+}
+
+func (host *SHost) GetSysInfo() jsonutils.JSONObject {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString(host.Manufacturer), "manufacture")
+	info.Add(jsonutils.NewString(host.Model), "model")
+	info.Add(jsonutils.NewString(host.Uri), "libvirt_uri")
+	return info
+}
+
+func (host *SHost) GetSN() string {
+	return host.SN
+}
+
+func (host *SHost) GetCpuCount() int {
+	return host.CpuCount
+}
+
+func (host *SHost) GetNodeCount() int8 {
+	return int8(host.CpuSockets)
+}
+
+func (host *SHost) GetCpuDesc() string {
+	return host.CpuModel
+}
+
+func (host *SHost) GetCpuMhz() int {
+	return host.CpuMhz
+}
+
+func (host *SHost) GetMemSizeMB() int {
+	return host.MemSizeMb
+}
+
+func (host *SHost) GetStorageSizeMB() int {
+	return 0
+}
+
+func (host *SHost) GetStorageType() string {
+	return api.DISK_TYPE_HYBRID
+}
+
+func (host *SHost) GetHostType() string {
+	return api.HOST_TYPE_LIBVIRT
+}
+
+func (host *SHost) GetIsMaintenance() bool {
+	return false
+}
+
+func (host *SHost) GetVersion() string {
+	return host.Version
+}
+
+func (host *SHost) GetIHostNics() ([]cloudprovider.ICloudHostNetInterface, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+// 仅纳管已有虚拟机, 不支持创建
+func (host *SHost) CreateVM(desc *cloudprovider.SManagedVMCreateConfig) (cloudprovider.ICloudVM, error) {
+	return nil, errors.Wrapf(cloudprovider.ErrNotSupported, "create vm on libvirt host %s", host.Name)
+}
+
+func (host *SHost) String() string {
+	return fmt.Sprintf("%s(%s)", host.Name, host.Uri)
+}
@@ -0,0 +1,568 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/osprofile"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/billing"
+)
+
+// libvirt中的domain, 以domain UUID作为Id
+type SInstance struct {
+	multicloud.SInstanceBase
+	host *SHost
+
+	State  string
+	Domain libvirtxml.Domain
+}
+
+func (host *SHost) getInstance(id string) (*SInstance, error) {
+	state, err := host.conn.virsh("domstate", id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "domstate %s", id)
+	}
+	instance := &SInstance{host: host, State: strings.TrimSpace(state)}
+	err = host.conn.dumpxml(&instance.Domain, "dumpxml", id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dumpxml %s", id)
+	}
+	return instance, nil
+}
+
+func (host *SHost) GetInstances() ([]SInstance, error) {
+	if host.instances != nil {
+		return host.instances, nil
+	}
+	ids, err := host.conn.list("list", "--all", "--uuid")
+	if err != nil {
+		return nil, errors.Wrapf(err, "list")
+	}
+	instances := []SInstance{}
+	for _, id := range ids {
+		instance, err := host.getInstance(id)
+		if err != nil {
+			// 列举与查询之间domain可能已被删除
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		instances = append(instances, *instance)
+	}
+	host.instances = instances
+	return instances, nil
+}
+
+func (host *SHost) GetInstance(id string) (*SInstance, error) {
+	return host.getInstance(id)
+}
+
+func (region *SRegion) GetInstance(id string) (*SInstance, error) {
+	hosts, err := region.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range hosts {
+		instance, err := host.getInstance(id)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		return instance, nil
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s", id)
+}
+
+func (instance *SInstance) getRegion() *SRegion {
+	return instance.host.getRegion()
+}
+
+func (instance *SInstance) virsh(args ...string) (string, error) {
+	return instance.host.conn.virsh(args...)
+}
+
+// 仅包含磁盘设备, 不含光驱和软驱
+func (instance *SInstance) getDisks() []libvirtxml.DomainDisk {
+	disks := []libvirtxml.DomainDisk{}
+	if instance.Domain.Devices == nil {
+		return disks
+	}
+	for _, disk := range instance.Domain.Devices.Disks {
+		if disk.Device == "" || disk.Device == "disk" {
+			disks = append(disks, disk)
+		}
+	}
+	return disks
+}
+
+// 不在存储池中的磁盘无法纳管
+func (instance *SInstance) GetDisks() ([]SDisk, error) {
+	storages, err := instance.host.GetStorages()
+	if err != nil {
+		return nil, err
+	}
+	ret := []SDisk{}
+	for i := range storages {
+		disks, err := storages[i].GetDisks()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetDisks of storage %s", storages[i].Name)
+		}
+		for j := range disks {
+			if disks[j].InstanceId == instance.GetGlobalId() {
+				ret = append(ret, disks[j])
+			}
+		}
+	}
+	// 系统盘排在最前
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Bootable != ret[j].Bootable {
+			return ret[i].Bootable
+		}
+		return ret[i].Target < ret[j].Target
+	})
+	return ret, nil
+}
+
+func (instance *SInstance) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := instance.GetDisks()
+	if err != nil {
+		return nil, err
+	}
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (instance *SInstance) GetINics() ([]cloudprovider.ICloudNic, error) {
+	nics, err := instance.GetNics()
+	if err != nil {
+		return nil, err
+	}
+	inics := []cloudprovider.ICloudNic{}
+	for i := range nics {
+		inics = append(inics, &nics[i])
+	}
+	return inics, nil
+}
+
+func (instance *SInstance) GetIEIP() (cloudprovider.ICloudEIP, error) {
+	return nil, nil
+}
+
+func (instance *SInstance) GetIHost() cloudprovider.ICloudHost {
+	return instance.host
+}
+
+func (instance *SInstance) GetIHostId() string {
+	return instance.host.GetGlobalId()
+}
+
+func (instance *SInstance) GetId() string {
+	return instance.Domain.UUID
+}
+
+func (instance *SInstance) GetName() string {
+	return instance.Domain.Name
+}
+
+func (instance *SInstance) GetGlobalId() string {
+	return instance.GetId()
+}
+
+func (instance *SInstance) IsEmulated() bool {
+	return false
+}
+
+func (instance *SInstance) GetCreatedAt() time.Time {
+	return time.Time{}
+}
+
+func (instance *SInstance) GetInstanceType() string {
+	return ""
+}
+
+func (instance *SInstance) GetVcpuCount() int {
+	vcpu := instance.Domain.VCPU
+	if vcpu == nil {
+		return 1
+	}
+	if current, err := strconv.Atoi(vcpu.Current); err == nil && current > 0 {
+		return current
+	}
+	return vcpu.Value
+}
+
+// 内存缺省单位为KiB
+func (instance *SInstance) GetVmemSizeMB() int {
+	value, unit := uint(0), "KiB"
+	if instance.Domain.CurrentMemory != nil {
+		value, unit = instance.Domain.CurrentMemory.Value, instance.Domain.CurrentMemory.Unit
+	} else if instance.Domain.Memory != nil {
+		value, unit = instance.Domain.Memory.Value, instance.Domain.Memory.Unit
+	}
+	if len(unit) == 0 {
+		unit = "KiB"
+	}
+	return int(int64(value) * unitBytes(unit) / 1024 / 1024)
+}
+
+func (instance *SInstance) GetBootOrder() string {
+	order := ""
+	if instance.Domain.OS != nil {
+		for _, dev := range instance.Domain.OS.BootDevices {
+			c := ""
+			switch dev.Dev {
+			case "hd":
+				c = "c"
+			case "cdrom":
+				c = "d"
+			case "network":
+				c = "n"
+			}
+			if len(c) > 0 && !strings.Contains(order, c) {
+				order += c
+			}
+		}
+	}
+	if len(order) == 0 {
+		return "cdn"
+	}
+	return order
+}
+
+func (instance *SInstance) GetVga() string {
+	if instance.Domain.Devices != nil && len(instance.Domain.Devices.Videos) > 0 {
+		switch instance.Domain.Devices.Videos[0].Model.Type {
+		case "qxl":
+			return "qxl"
+		case "cirrus":
+			return "cirrus"
+		}
+	}
+	return "std"
+}
+
+func (instance *SInstance) GetVdi() string {
+	if instance.Domain.Devices != nil {
+		for _, graphic := range instance.Domain.Devices.Graphics {
+			if graphic.Spice != nil {
+				return "spice"
+			}
+		}
+	}
+	return "vnc"
+}
+
+// virt-install会在metadata中记录libosinfo的系统标识
+func (instance *SInstance) GetOSType() string {
+	if instance.Domain.Metadata != nil {
+		metadata := strings.ToLower(instance.Domain.Metadata.XML)
+		if strings.Contains(metadata, "windows") || strings.Contains(metadata, "microsoft.com/win") {
+			return osprofile.OS_TYPE_WINDOWS
+		}
+	}
+	return osprofile.OS_TYPE_LINUX
+}
+
+func (instance *SInstance) GetOSName() string {
+	return ""
+}
+
+func (instance *SInstance) GetBios() string {
+	os := instance.Domain.OS
+	if os != nil && (os.Firmware == "efi" || (os.Loader != nil && os.Loader.Type == "pflash")) {
+		return "UEFI"
+	}
+	return "BIOS"
+}
+
+func (instance *SInstance) GetMachine() string {
+	os := instance.Domain.OS
+	if os != nil && os.Type != nil && strings.Contains(os.Type.Machine, "q35") {
+		return "q35"
+	}
+	return "pc"
+}
+
+func (instance *SInstance) GetStatus() string {
+	switch instance.State {
+	case "running", "idle", "blocked":
+		return api.VM_RUNNING
+	case "shut off", "crashed":
+		return api.VM_READY
+	case "paused", "pmsuspended":
+		return api.VM_SUSPEND
+	case "in shutdown":
+		return api.VM_STOPPING
+	default:
+		log.Errorf("Unknown instance %s state %s", instance.GetName(), instance.State)
+		return api.VM_UNKNOWN
+	}
+}
+
+func (instance *SInstance) Refresh() error {
+	newInstance, err := instance.host.getInstance(instance.GetId())
+	if err != nil {
+		return err
+	}
+	instance.State = newInstance.State
+	instance.Domain = newInstance.Domain
+	// 磁盘挂载关系依赖主机缓存的虚拟机配置
+	instance.host.instances = nil
+	return nil
+}
+
+func (instance *SInstance) GetHypervisor() string {
+	return api.HYPERVISOR_LIBVIRT
+}
+
+func (instance *SInstance) GetSecurityGroupIds() ([]string, error) {
+	return []string{}, nil
+}
+
+func (instance *SInstance) AssignSecurityGroup(secgroupId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) SetSecurityGroups(secgroupIds []string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) StartVM(ctx context.Context) error {
+	_, err := instance.virsh("start", instance.GetId())
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_RUNNING, StatusPollInterval, StatusTimeout)
+}
+
+// 正常关机依赖虚拟机响应ACPI事件, 强制关机直接断电
+func (instance *SInstance) StopVM(ctx context.Context, opts *cloudprovider.ServerStopOptions) error {
+	action := "shutdown"
+	if opts.IsForce {
+		action = "destroy"
+	}
+	_, err := instance.virsh(action, instance.GetId())
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_READY, StatusPollInterval, StatusTimeout)
+}
+
+func (instance *SInstance) RebootVM(ctx context.Context) error {
+	_, err := instance.virsh("reboot", instance.GetId())
+	return err
+}
+
+// 仅删除domain定义, 保留磁盘存储卷
+func (instance *SInstance) DeleteVM(ctx context.Context) error {
+	if instance.GetStatus() != api.VM_READY {
+		_, err := instance.virsh("destroy", instance.GetId())
+		if err != nil {
+			return errors.Wrapf(err, "destroy")
+		}
+	}
+	args := []string{"undefine", instance.GetId(), "--managed-save", "--snapshots-metadata"}
+	if instance.Domain.OS != nil && instance.Domain.OS.NVRam != nil {
+		args = append(args, "--nvram")
+	}
+	_, err := instance.virsh(args...)
+	return err
+}
+
+// libvirt仅支持重命名关机状态的domain
+func (instance *SInstance) UpdateVM(ctx context.Context, name string) error {
+	if name == instance.GetName() {
+		return nil
+	}
+	if instance.GetStatus() != api.VM_READY {
+		return errors.Wrapf(cloudprovider.ErrNotSupported, "rename instance %s in state %s", instance.GetName(), instance.State)
+	}
+	_, err := instance.virsh("domrename", instance.GetId(), name)
+	return err
+}
+
+func (instance *SInstance) UpdateUserData(userData string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) DeployVM(ctx context.Context, name string, username string, password string, publicKey string, deleteKeypair bool, description string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) RebuildRoot(ctx context.Context, desc *cloudprovider.SManagedVMRebuildRootConfig) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+// 修改持久化配置, 下次启动后生效
+func (instance *SInstance) ChangeConfig(ctx context.Context, config *cloudprovider.SManagedVMChangeConfig) error {
+	id := instance.GetId()
+	if config.Cpu > 0 {
+		cpu := fmt.Sprintf("%d", config.Cpu)
+		_, err := instance.virsh("setvcpus", id, cpu, "--config", "--maximum")
+		if err != nil {
+			return errors.Wrapf(err, "setvcpus --maximum")
+		}
+		_, err = instance.virsh("setvcpus", id, cpu, "--config")
+		if err != nil {
+			return errors.Wrapf(err, "setvcpus")
+		}
+	}
+	if config.MemoryMB > 0 {
+		memory := fmt.Sprintf("%dM", config.MemoryMB)
+		_, err := instance.virsh("setmaxmem", id, memory, "--config")
+		if err != nil {
+			return errors.Wrapf(err, "setmaxmem")
+		}
+		_, err = instance.virsh("setmem", id, memory, "--config")
+		if err != nil {
+			return errors.Wrapf(err, "setmem")
+		}
+	}
+	return nil
+}
+
+// VNC密码仅在 --security-info 时输出
+func (instance *SInstance) GetVNCInfo() (jsonutils.JSONObject, error) {
+	domain := libvirtxml.Domain{}
+	err := instance.host.conn.dumpxml(&domain, "dumpxml", instance.GetId(), "--security-info")
+	if err != nil {
+		return nil, err
+	}
+	if domain.Devices != nil {
+		for _, graphic := range domain.Devices.Graphics {
+			vnc := graphic.VNC
+			if vnc == nil || vnc.Port <= 0 {
+				continue
+			}
+			host := instance.host.AccessIp
+			if len(vnc.Listen) > 0 && vnc.Listen != "0.0.0.0" && vnc.Listen != "::" && !strings.HasPrefix(vnc.Listen, "127.") {
+				host = vnc.Listen
+			}
+			ret := jsonutils.NewDict()
+			ret.Add(jsonutils.NewString(host), "host")
+			ret.Add(jsonutils.NewInt(int64(vnc.Port)), "port")
+			ret.Add(jsonutils.NewString("vnc"), "protocol")
+			ret.Add(jsonutils.NewString(vnc.Passwd), "password")
+			return ret, nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "no vnc graphics of instance %s", instance.GetName())
+}
+
+// 选择第一个未被使用的virtio磁盘设备名
+func (instance *SInstance) nextDiskTarget() string {
+	used := map[string]bool{}
+	if instance.Domain.Devices != nil {
+		for _, disk := range instance.Domain.Devices.Disks {
+			if disk.Target != nil {
+				used[disk.Target.Dev] = true
+			}
+		}
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		target := fmt.Sprintf("vd%c", c)
+		if !used[target] {
+			return target
+		}
+	}
+	return ""
+}
+
+func (instance *SInstance) diskArgs(action string, args ...string) []string {
+	ret := append([]string{action, instance.GetId()}, args...)
+	ret = append(ret, "--config")
+	if instance.GetStatus() == api.VM_RUNNING {
+		ret = append(ret, "--live")
+	}
+	return ret
+}
+
+func (instance *SInstance) AttachDisk(ctx context.Context, diskId string) error {
+	disk, err := instance.host.getDiskById(diskId)
+	if err != nil {
+		return errors.Wrapf(err, "disk %s on host %s", diskId, instance.host.Name)
+	}
+	if disk.storage.Pool.Type == "rbd" {
+		return errors.Wrapf(cloudprovider.ErrNotSupported, "attach rbd volume")
+	}
+	if len(disk.InstanceId) > 0 {
+		return fmt.Errorf("disk %s is attached to vm %s", disk.GetName(), disk.InstanceId)
+	}
+	target := instance.nextDiskTarget()
+	if len(target) == 0 {
+		return fmt.Errorf("no available disk target for instance %s", instance.GetName())
+	}
+	_, err = instance.virsh(instance.diskArgs("attach-disk", disk.Path, target,
+		"--driver", "qemu", "--subdriver", disk.GetDiskFormat(), "--targetbus", "virtio")...)
+	if err != nil {
+		return err
+	}
+	return instance.Refresh()
+}
+
+func (instance *SInstance) DetachDisk(ctx context.Context, diskId string) error {
+	disk, err := instance.host.getDiskById(diskId)
+	if err != nil {
+		if errors.Cause(err) == cloudprovider.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if disk.InstanceId != instance.GetGlobalId() {
+		return nil
+	}
+	_, err = instance.virsh(instance.diskArgs("detach-disk", disk.Target)...)
+	if err != nil {
+		return err
+	}
+	return instance.Refresh()
+}
+
+func (instance *SInstance) CreateDisk(ctx context.Context, sizeMb int, uuid string, driver string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) Renew(bc billing.SBillingCycle) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) GetProjectId() string {
+	return ""
+}
+
+func (instance *SInstance) GetError() error {
+	return nil
+}
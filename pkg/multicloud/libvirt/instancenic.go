@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"strings"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+// 网卡通过libvirt网络或网桥接入, IP地址来自DHCP租约或静态绑定
+type SInstanceNic struct {
+	instance *SInstance
+
+	Interface libvirtxml.DomainInterface
+	ip        string
+
+	cloudprovider.DummyICloudNic
+}
+
+// 解析 virsh domifaddr 输出, 返回 MAC -> IPv4 地址
+func parseDomIfAddr(output string) map[string]string {
+	ret := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] != "ipv4" {
+			continue
+		}
+		ret[netutils.FormatMacAddr(fields[1])] = strings.SplitN(fields[3], "/", 2)[0]
+	}
+	return ret
+}
+
+func (instance *SInstance) GetNics() ([]SInstanceNic, error) {
+	nics := []SInstanceNic{}
+	if instance.Domain.Devices == nil {
+		return nics, nil
+	}
+	addrs := map[string]string{}
+	// 关机状态下无法查询租约
+	if instance.GetStatus() == api.VM_RUNNING {
+		output, err := instance.virsh("domifaddr", instance.GetId())
+		if err != nil {
+			log.Warningf("domifaddr %s error: %v", instance.GetName(), err)
+		} else {
+			addrs = parseDomIfAddr(output)
+		}
+	}
+	for i := range instance.Domain.Devices.Interfaces {
+		nic := SInstanceNic{instance: instance, Interface: instance.Domain.Devices.Interfaces[i]}
+		nic.ip = addrs[nic.GetMAC()]
+		nics = append(nics, nic)
+	}
+	return nics, nil
+}
+
+func (nic *SInstanceNic) GetId() string {
+	return ""
+}
+
+func (nic *SInstanceNic) GetIP() string {
+	if len(nic.ip) > 0 {
+		return nic.ip
+	}
+	network := nic.getNetwork()
+	if network != nil {
+		return network.getDhcpIp(nic.GetMAC())
+	}
+	return ""
+}
+
+func (nic *SInstanceNic) GetMAC() string {
+	if nic.Interface.MAC != nil {
+		return netutils.FormatMacAddr(nic.Interface.MAC.Address)
+	}
+	return ""
+}
+
+func (nic *SInstanceNic) GetDriver() string {
+	if nic.Interface.Model != nil && len(nic.Interface.Model.Type) > 0 {
+		return nic.Interface.Model.Type
+	}
+	return "virtio"
+}
+
+func (nic *SInstanceNic) InClassicNetwork() bool {
+	return false
+}
+
+// 接入libvirt网络或该网络所用网桥的网卡才能对应到网络
+func (nic *SInstanceNic) getWire() *SWire {
+	source := nic.Interface.Source
+	if source == nil {
+		return nil
+	}
+	netName, bridge := "", ""
+	if source.Network != nil {
+		netName = source.Network.Network
+	}
+	if source.Bridge != nil {
+		bridge = source.Bridge.Bridge
+	}
+	wires, err := nic.instance.host.GetWires()
+	if err != nil {
+		log.Errorf("failed to get wires of host %s error: %v", nic.instance.host.Name, err)
+		return nil
+	}
+	for i := range wires {
+		if (len(netName) > 0 && wires[i].Network.Name == netName) || (len(bridge) > 0 && wires[i].getBridge() == bridge) {
+			return &wires[i]
+		}
+	}
+	return nil
+}
+
+func (nic *SInstanceNic) getNetwork() *SNetwork {
+	wire := nic.getWire()
+	if wire == nil {
+		return nil
+	}
+	for i := range wire.networks {
+		if len(nic.ip) == 0 || wire.networks[i].Contains(nic.ip) {
+			return &wire.networks[i]
+		}
+	}
+	return nil
+}
+
+func (nic *SInstanceNic) GetINetwork() cloudprovider.ICloudNetwork {
+	network := nic.getNetwork()
+	if network == nil {
+		return nil
+	}
+	return network
+}
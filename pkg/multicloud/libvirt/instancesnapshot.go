@@ -0,0 +1,143 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// libvirt内部快照, 以快照名称作为Id
+type SInstanceSnapshot struct {
+	multicloud.SVirtualResourceBase
+	instance *SInstance
+
+	Snapshot libvirtxml.DomainSnapshot
+}
+
+func (instance *SInstance) getInstanceSnapshot(name string) (*SInstanceSnapshot, error) {
+	snapshot := &SInstanceSnapshot{instance: instance}
+	err := instance.host.conn.dumpxml(&snapshot.Snapshot, "snapshot-dumpxml", instance.GetId(), name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "snapshot-dumpxml %s", name)
+	}
+	// 快照中保存的虚拟机配置无需保留
+	snapshot.Snapshot.Domain = nil
+	return snapshot, nil
+}
+
+func (instance *SInstance) GetSnapshots() ([]SInstanceSnapshot, error) {
+	names, err := instance.host.conn.list("snapshot-list", instance.GetId(), "--name")
+	if err != nil {
+		return nil, errors.Wrapf(err, "snapshot-list")
+	}
+	snapshots := []SInstanceSnapshot{}
+	for _, name := range names {
+		snapshot, err := instance.getInstanceSnapshot(name)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	return snapshots, nil
+}
+
+func (instance *SInstance) GetInstanceSnapshots() ([]cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshots, err := instance.GetSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudInstanceSnapshot{}
+	for i := range snapshots {
+		ret = append(ret, &snapshots[i])
+	}
+	return ret, nil
+}
+
+func (instance *SInstance) GetInstanceSnapshot(idStr string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	return instance.getInstanceSnapshot(idStr)
+}
+
+func (instance *SInstance) CreateInstanceSnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	args := []string{"snapshot-create-as", instance.GetId(), name}
+	if len(desc) > 0 {
+		args = append(args, desc)
+	}
+	args = append(args, "--atomic")
+	_, err := instance.virsh(args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "snapshot-create-as")
+	}
+	return instance.getInstanceSnapshot(name)
+}
+
+func (instance *SInstance) ResetToInstanceSnapshot(ctx context.Context, idStr string) error {
+	_, err := instance.virsh("snapshot-revert", instance.GetId(), idStr)
+	return err
+}
+
+func (snapshot *SInstanceSnapshot) GetId() string {
+	return snapshot.Snapshot.Name
+}
+
+func (snapshot *SInstanceSnapshot) GetName() string {
+	return snapshot.Snapshot.Name
+}
+
+func (snapshot *SInstanceSnapshot) GetGlobalId() string {
+	return snapshot.GetId()
+}
+
+func (snapshot *SInstanceSnapshot) GetStatus() string {
+	return api.INSTANCE_SNAPSHOT_READY
+}
+
+func (snapshot *SInstanceSnapshot) GetCreatedAt() time.Time {
+	if sec, err := strconv.ParseInt(snapshot.Snapshot.CreationTime, 10, 64); err == nil {
+		return time.Unix(sec, 0)
+	}
+	return time.Time{}
+}
+
+func (snapshot *SInstanceSnapshot) Refresh() error {
+	newSnapshot, err := snapshot.instance.getInstanceSnapshot(snapshot.GetId())
+	if err != nil {
+		return err
+	}
+	snapshot.Snapshot = newSnapshot.Snapshot
+	return nil
+}
+
+func (snapshot *SInstanceSnapshot) IsEmulated() bool {
+	return false
+}
+
+func (snapshot *SInstanceSnapshot) GetDescription() string {
+	return snapshot.Snapshot.Description
+}
+
+func (snapshot *SInstanceSnapshot) Delete() error {
+	_, err := snapshot.instance.virsh("snapshot-delete", snapshot.instance.GetId(), snapshot.GetId())
+	return err
+}
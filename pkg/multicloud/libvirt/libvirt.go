@@ -0,0 +1,402 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/util/ssh"
+)
+
+const (
+	CLOUD_PROVIDER_LIBVIRT = api.CLOUD_PROVIDER_LIBVIRT
+	LIBVIRT_DEFAULT_REGION = "Libvirt"
+	LIBVIRT_DEFAULT_ZONE   = "default"
+)
+
+var (
+	StatusPollInterval = 5 * time.Second
+	StatusTimeout      = 10 * time.Minute
+)
+
+// 执行virsh命令, args不含 -c 连接参数
+type IVirshExecutor interface {
+	Run(args ...string) (string, error)
+	Close()
+}
+
+// qemu+ssh:// 通过ssh在目标主机上执行virsh, 不依赖本地libvirt客户端
+type sSshExecutor struct {
+	config ssh.ClientConfig
+	uri    string
+
+	lock   sync.Mutex
+	client *ssh.Client
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+func (e *sSshExecutor) Run(args ...string) (string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.client == nil {
+		client, err := e.config.NewClient()
+		if err != nil {
+			return "", errors.Wrapf(err, "ssh %s@%s:%d", e.config.Username, e.config.Host, e.config.Port)
+		}
+		e.client = client
+	}
+	cmd := []string{"virsh", "-c", shellQuote(e.uri)}
+	for _, arg := range args {
+		cmd = append(cmd, shellQuote(arg))
+	}
+	output, err := e.client.RawRun(strings.Join(cmd, " "))
+	if err != nil {
+		// 连接可能已断开, 下次重新建立
+		e.client.Close()
+		e.client = nil
+		return "", err
+	}
+	return strings.Join(output, ""), nil
+}
+
+func (e *sSshExecutor) Close() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.client != nil {
+		e.client.Close()
+		e.client = nil
+	}
+}
+
+// qemu+tls:// 等其他传输方式由本地virsh直接连接
+type sLocalExecutor struct {
+	uri string
+}
+
+func (e *sLocalExecutor) Run(args ...string) (string, error) {
+	cmd := exec.Command("virsh", append([]string{"-c", e.uri}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", errors.Wrapf(errors.Error(strings.TrimSpace(stderr.String())), "virsh %s: %v", strings.Join(args, " "), err)
+	}
+	return stdout.String(), nil
+}
+
+func (e *sLocalExecutor) Close() {
+}
+
+// 根据连接地址选择执行方式, ssh方式在远端使用本机连接地址
+func newExecutor(uri string, username string, password string) (IVirshExecutor, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid libvirt uri %s", uri)
+	}
+	driver := strings.SplitN(u.Scheme, "+", 2)
+	transport := ""
+	if len(driver) > 1 {
+		transport = driver[1]
+	}
+	switch transport {
+	case "ssh":
+		config := ssh.ClientConfig{
+			Host:     u.Hostname(),
+			Port:     22,
+			Username: username,
+		}
+		if port := u.Port(); len(port) > 0 {
+			config.Port, _ = strconv.Atoi(port)
+		}
+		if u.User != nil && len(u.User.Username()) > 0 {
+			config.Username = u.User.Username()
+		}
+		if len(config.Username) == 0 {
+			config.Username = "root"
+		}
+		// 密钥以PEM格式保存在密码字段
+		if strings.HasPrefix(strings.TrimSpace(password), "-----BEGIN") {
+			config.PrivateKey = password
+		} else {
+			config.Password = password
+		}
+		path := u.Path
+		if len(path) == 0 {
+			path = "/system"
+		}
+		return &sSshExecutor{config: config, uri: fmt.Sprintf("%s://%s", driver[0], path)}, nil
+	case "", "tls", "tcp":
+		return &sLocalExecutor{uri: uri}, nil
+	default:
+		return nil, errors.Wrapf(cloudprovider.ErrNotSupported, "libvirt transport %s", transport)
+	}
+}
+
+type LibvirtClientConfig struct {
+	cpcfg cloudprovider.ProviderConfig
+
+	uris     []string
+	username string
+	password string
+
+	debug bool
+}
+
+// uris为逗号分隔的libvirt连接地址, 每个地址对应一台主机, 如 qemu+ssh://root@10.0.0.1/system
+func NewLibvirtClientConfig(uris string, username, password string) *LibvirtClientConfig {
+	cfg := &LibvirtClientConfig{
+		username: username,
+		password: password,
+	}
+	for _, uri := range strings.Split(uris, ",") {
+		uri = strings.TrimSpace(uri)
+		if len(uri) > 0 {
+			cfg.uris = append(cfg.uris, uri)
+		}
+	}
+	return cfg
+}
+
+func (cfg *LibvirtClientConfig) CloudproviderConfig(cpcfg cloudprovider.ProviderConfig) *LibvirtClientConfig {
+	cfg.cpcfg = cpcfg
+	return cfg
+}
+
+func (cfg *LibvirtClientConfig) Debug(debug bool) *LibvirtClientConfig {
+	cfg.debug = debug
+	return cfg
+}
+
+type SLibvirtClient struct {
+	*LibvirtClientConfig
+
+	conns []*SConnection
+
+	iregions []cloudprovider.ICloudRegion
+}
+
+// 单台libvirt主机的连接
+type SConnection struct {
+	Uri      string
+	executor IVirshExecutor
+	debug    bool
+}
+
+func NewLibvirtClient(cfg *LibvirtClientConfig) (*SLibvirtClient, error) {
+	if len(cfg.uris) == 0 {
+		return nil, errors.Wrap(cloudprovider.ErrNotFound, "no libvirt uri")
+	}
+	cli := &SLibvirtClient{LibvirtClientConfig: cfg}
+	for _, uri := range cfg.uris {
+		executor, err := newExecutor(uri, cfg.username, cfg.password)
+		if err != nil {
+			return nil, err
+		}
+		cli.conns = append(cli.conns, &SConnection{Uri: uri, executor: executor, debug: cfg.debug})
+	}
+	return cli.init()
+}
+
+// 测试时注入执行器
+func NewLibvirtClientWithExecutors(cfg *LibvirtClientConfig, executors map[string]IVirshExecutor) (*SLibvirtClient, error) {
+	cli := &SLibvirtClient{LibvirtClientConfig: cfg}
+	for _, uri := range cfg.uris {
+		executor, ok := executors[uri]
+		if !ok {
+			return nil, errors.Wrapf(cloudprovider.ErrNotFound, "executor for %s", uri)
+		}
+		cli.conns = append(cli.conns, &SConnection{Uri: uri, executor: executor, debug: cfg.debug})
+	}
+	return cli.init()
+}
+
+func (cli *SLibvirtClient) init() (*SLibvirtClient, error) {
+	for _, conn := range cli.conns {
+		_, err := conn.GetVersion()
+		if err != nil {
+			return nil, errors.Wrapf(err, "connect %s", conn.Uri)
+		}
+	}
+	cli.iregions = []cloudprovider.ICloudRegion{&SRegion{client: cli}}
+	return cli, nil
+}
+
+func (cli *SLibvirtClient) Close() {
+	for _, conn := range cli.conns {
+		conn.executor.Close()
+	}
+}
+
+func (cli *SLibvirtClient) GetConnections() []*SConnection {
+	return cli.conns
+}
+
+func (cli *SLibvirtClient) GetCloudRegionExternalIdPrefix() string {
+	return fmt.Sprintf("%s/%s", CLOUD_PROVIDER_LIBVIRT, cli.cpcfg.Id)
+}
+
+func (cli *SLibvirtClient) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	subAccount := cloudprovider.SSubAccount{
+		Account:      cli.username,
+		Name:         cli.cpcfg.Name,
+		HealthStatus: api.CLOUD_PROVIDER_HEALTH_NORMAL,
+	}
+	return []cloudprovider.SSubAccount{subAccount}, nil
+}
+
+func (cli *SLibvirtClient) GetAccountId() string {
+	return strings.Join(cli.uris, ",")
+}
+
+func (cli *SLibvirtClient) GetIRegions() []cloudprovider.ICloudRegion {
+	return cli.iregions
+}
+
+func (cli *SLibvirtClient) GetRegion(regionId string) *SRegion {
+	if len(regionId) == 0 {
+		regionId = LIBVIRT_DEFAULT_REGION
+	}
+	for i := 0; i < len(cli.iregions); i++ {
+		if cli.iregions[i].GetId() == regionId {
+			return cli.iregions[i].(*SRegion)
+		}
+	}
+	return nil
+}
+
+func (cli *SLibvirtClient) GetIRegionById(id string) (cloudprovider.ICloudRegion, error) {
+	for i := 0; i < len(cli.iregions); i++ {
+		if cli.iregions[i].GetGlobalId() == id {
+			return cli.iregions[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (cli *SLibvirtClient) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (cli *SLibvirtClient) GetCapabilities() []string {
+	caps := []string{
+		cloudprovider.CLOUD_CAPABILITY_COMPUTE,
+		cloudprovider.CLOUD_CAPABILITY_NETWORK,
+	}
+	return caps
+}
+
+var notFoundErrors = []string{
+	"failed to get domain",
+	"Domain not found",
+	"failed to get pool",
+	"Storage pool not found",
+	"failed to get vol",
+	"Storage volume not found",
+	"failed to get network",
+	"Network not found",
+	"Domain snapshot not found",
+	"failed to get snapshot",
+}
+
+func (conn *SConnection) virsh(args ...string) (string, error) {
+	if conn.debug {
+		log.Debugf("%s: virsh %s", conn.Uri, strings.Join(args, " "))
+	}
+	output, err := conn.executor.Run(args...)
+	if err != nil {
+		for _, msg := range notFoundErrors {
+			if strings.Contains(err.Error(), msg) {
+				return "", errors.Wrapf(cloudprovider.ErrNotFound, "%s", err.Error())
+			}
+		}
+		return "", errors.Wrapf(err, "%s", conn.Uri)
+	}
+	return output, nil
+}
+
+// 解析 virsh ... --name 或 --uuid 的输出
+func (conn *SConnection) list(args ...string) ([]string, error) {
+	output, err := conn.virsh(args...)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			ret = append(ret, line)
+		}
+	}
+	return ret, nil
+}
+
+func (conn *SConnection) dumpxml(retVal interface{}, args ...string) error {
+	output, err := conn.virsh(args...)
+	if err != nil {
+		return err
+	}
+	err = xml.Unmarshal([]byte(output), retVal)
+	if err != nil {
+		return errors.Wrapf(err, "xml.Unmarshal %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+// 解析 virsh nodeinfo、dominfo 等 "键: 值" 格式的输出
+func (conn *SConnection) info(args ...string) (map[string]string, error) {
+	output, err := conn.virsh(args...)
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			ret[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return ret, nil
+}
+
+func (conn *SConnection) GetVersion() (string, error) {
+	output, err := conn.virsh("version", "--daemon")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Running against daemon:") {
+			fields := strings.Fields(line)
+			return fields[len(fields)-1], nil
+		}
+	}
+	return "", nil
+}
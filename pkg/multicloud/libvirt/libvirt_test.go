@@ -0,0 +1,171 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+const (
+	testUri        = "qemu+ssh://root@10.0.0.1/system"
+	testHostId     = "4c4c4544-0035-4810-8058-b4c04f4d3232"
+	testPoolId     = "a1b2c3d4-0000-4000-8000-000000000001"
+	testInstanceId = "6f2c1a9e-4b1d-4c55-9d3e-2a7b8c9d0e11"
+)
+
+// 以参数拼接的文件名回放 testdata 下录制的virsh输出, 修改类命令仅记录
+type sFixtureExecutor struct {
+	commands []string
+}
+
+func (e *sFixtureExecutor) Run(args ...string) (string, error) {
+	name := strings.Join(args, "_")
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err == nil {
+		return string(data), nil
+	}
+	switch args[0] {
+	case "start", "shutdown", "destroy", "reboot", "attach-disk", "detach-disk", "snapshot-create-as":
+		e.commands = append(e.commands, strings.Join(args, " "))
+		return "", nil
+	}
+	return "", fmt.Errorf("error: failed to get domain '%s'", name)
+}
+
+func (e *sFixtureExecutor) Close() {
+}
+
+func newTestRegion(t *testing.T) (*SRegion, *sFixtureExecutor) {
+	executor := &sFixtureExecutor{}
+	cfg := NewLibvirtClientConfig(testUri, "root", "password")
+	cli, err := NewLibvirtClientWithExecutors(cfg, map[string]IVirshExecutor{testUri: executor})
+	if err != nil {
+		t.Fatalf("NewLibvirtClientWithExecutors: %v", err)
+	}
+	return cli.GetRegion(""), executor
+}
+
+func TestHostStoragesAndDisks(t *testing.T) {
+	region, _ := newTestRegion(t)
+
+	ihosts, err := region.GetIHosts()
+	if err != nil {
+		t.Fatalf("GetIHosts: %v", err)
+	}
+	if len(ihosts) != 1 || ihosts[0].GetGlobalId() != testHostId {
+		t.Fatalf("unexpected hosts %v", ihosts)
+	}
+	host := ihosts[0].(*SHost)
+	if host.GetAccessIp() != "10.0.0.1" || host.GetCpuCount() != 32 || host.GetMemSizeMB() != 128000 || host.GetNodeCount() != 2 || host.GetSN() != "7XK2M33" {
+		t.Errorf("unexpected host: ip %s cpu %d mem %d sockets %d sn %s", host.GetAccessIp(), host.GetCpuCount(), host.GetMemSizeMB(), host.GetNodeCount(), host.GetSN())
+	}
+
+	storage, err := host.GetStorage(testPoolId)
+	if err != nil {
+		t.Fatalf("GetStorage: %v", err)
+	}
+	if storage.GetStorageType() != api.STORAGE_LIBVIRT_DIR || storage.GetCapacityMB() != 500*1024 || storage.GetCapacityUsedMB() != 100*1024 || storage.GetStatus() != api.STORAGE_ONLINE {
+		t.Errorf("unexpected storage: type %s capacity %d used %d", storage.GetStorageType(), storage.GetCapacityMB(), storage.GetCapacityUsedMB())
+	}
+
+	disks, err := storage.GetDisks()
+	if err != nil {
+		t.Fatalf("GetDisks: %v", err)
+	}
+	attached := map[string]string{}
+	for i := range disks {
+		attached[disks[i].GetGlobalId()] = fmt.Sprintf("%s/%s/%d", disks[i].InstanceId, disks[i].GetDiskType(), disks[i].GetDiskSizeMB())
+	}
+	expect := map[string]string{
+		testPoolId + "/web01.qcow2":  testInstanceId + "/sys/40960",
+		testPoolId + "/data01.qcow2": "/data/10240",
+	}
+	for id, v := range expect {
+		if attached[id] != v {
+			t.Errorf("disk %s: expect %s got %s", id, v, attached[id])
+		}
+	}
+
+	_, err = region.GetDisk("no-such-pool/web01.qcow2")
+	if errors.Cause(err) != cloudprovider.ErrNotFound {
+		t.Errorf("expect ErrNotFound, got %v", err)
+	}
+}
+
+func TestInstance(t *testing.T) {
+	region, executor := newTestRegion(t)
+
+	instance, err := region.GetInstance(testInstanceId)
+	if err != nil {
+		t.Fatalf("GetInstance: %v", err)
+	}
+	if instance.GetName() != "web01" || instance.GetStatus() != api.VM_RUNNING || instance.GetVcpuCount() != 2 || instance.GetVmemSizeMB() != 4096 {
+		t.Errorf("unexpected instance: name %s status %s cpu %d mem %d", instance.GetName(), instance.GetStatus(), instance.GetVcpuCount(), instance.GetVmemSizeMB())
+	}
+	if instance.GetMachine() != "q35" || instance.GetVga() != "qxl" || instance.GetBootOrder() != "c" {
+		t.Errorf("unexpected instance machine %s vga %s boot %s", instance.GetMachine(), instance.GetVga(), instance.GetBootOrder())
+	}
+
+	disks, err := instance.GetDisks()
+	if err != nil {
+		t.Fatalf("GetDisks: %v", err)
+	}
+	// 光驱不作为磁盘同步
+	if len(disks) != 1 || disks[0].Target != "vda" || disks[0].GetDriver() != "virtio" {
+		t.Errorf("unexpected instance disks %v", disks)
+	}
+
+	nics, err := instance.GetNics()
+	if err != nil {
+		t.Fatalf("GetNics: %v", err)
+	}
+	if len(nics) != 1 || nics[0].GetIP() != "192.168.122.45" || nics[0].GetMAC() != "52:54:00:6b:3c:58" {
+		t.Fatalf("unexpected nics %v", nics)
+	}
+	network := nics[0].GetINetwork()
+	if network == nil || network.GetGlobalId() != testHostId+"/default/192.168.122.0/24" || network.GetIpStart() != "192.168.122.2" {
+		t.Errorf("unexpected nic network %v", network)
+	}
+
+	vnc, err := instance.GetVNCInfo()
+	if err != nil {
+		t.Fatalf("GetVNCInfo: %v", err)
+	}
+	if host, _ := vnc.GetString("host"); host != "10.0.0.1" {
+		t.Errorf("unexpected vnc host %s", host)
+	}
+	if password, _ := vnc.GetString("password"); password != "secret" {
+		t.Errorf("unexpected vnc password %s", password)
+	}
+
+	err = instance.AttachDisk(context.Background(), testPoolId+"/data01.qcow2")
+	if err != nil {
+		t.Fatalf("AttachDisk: %v", err)
+	}
+	expect := "attach-disk " + testInstanceId + " /var/lib/libvirt/images/data01.qcow2 vdb --driver qemu --subdriver qcow2 --targetbus virtio --config --live"
+	if len(executor.commands) != 1 || executor.commands[0] != expect {
+		t.Errorf("unexpected commands %v", executor.commands)
+	}
+}
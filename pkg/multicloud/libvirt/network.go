@@ -0,0 +1,136 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"fmt"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SNetwork struct {
+	multicloud.SResourceBase
+	wire *SWire
+
+	Gateway string
+	MaskLen int8
+
+	netAddr   netutils.IPV4Addr
+	ipRange   netutils.IPV4AddrRange
+	dhcpHosts []libvirtxml.NetworkDHCPHost
+}
+
+// 未配置DHCP地址池时, 可分配地址从网关之后开始
+func (network *SNetwork) fixRange() {
+	start := network.netAddr.StepUp()
+	end := network.netAddr.BroadcastAddr(network.MaskLen).StepDown()
+	if gw, err := netutils.NewIPV4Addr(network.Gateway); err == nil && gw >= start && gw < end {
+		start = gw.StepUp()
+	}
+	network.ipRange = netutils.NewIPV4AddrRange(start, end)
+}
+
+// 根据DHCP静态绑定查找网卡地址
+func (network *SNetwork) getDhcpIp(mac string) string {
+	for _, host := range network.dhcpHosts {
+		if netutils.FormatMacAddr(host.MAC) == netutils.FormatMacAddr(mac) {
+			return host.IP
+		}
+	}
+	return ""
+}
+
+func (network *SNetwork) GetId() string {
+	return fmt.Sprintf("%s/%s/%d", network.wire.GetId(), network.netAddr.String(), network.MaskLen)
+}
+
+func (network *SNetwork) GetName() string {
+	return fmt.Sprintf("%s-%s", network.wire.GetName(), network.netAddr.String())
+}
+
+func (network *SNetwork) GetGlobalId() string {
+	return network.GetId()
+}
+
+func (network *SNetwork) IsEmulated() bool {
+	return false
+}
+
+func (network *SNetwork) GetStatus() string {
+	return api.NETWORK_STATUS_AVAILABLE
+}
+
+func (network *SNetwork) Refresh() error {
+	return nil
+}
+
+func (network *SNetwork) Delete() error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (network *SNetwork) GetIWire() cloudprovider.ICloudWire {
+	return network.wire
+}
+
+func (network *SNetwork) GetAllocTimeoutSeconds() int {
+	return 120 // 2 minutes
+}
+
+func (network *SNetwork) GetGateway() string {
+	return network.Gateway
+}
+
+func (network *SNetwork) GetIpStart() string {
+	return network.ipRange.StartIp().String()
+}
+
+func (network *SNetwork) GetIpEnd() string {
+	return network.ipRange.EndIp().String()
+}
+
+func (network *SNetwork) GetIpMask() int8 {
+	return network.MaskLen
+}
+
+func (network *SNetwork) Contains(ipAddr string) bool {
+	ip, err := netutils.NewIPV4Addr(ipAddr)
+	if err != nil {
+		return false
+	}
+	return ip.NetAddr(network.MaskLen) == network.netAddr
+}
+
+func (network *SNetwork) GetIsPublic() bool {
+	return true
+}
+
+func (network *SNetwork) GetPublicScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (network *SNetwork) GetServerType() string {
+	return api.NETWORK_TYPE_GUEST
+}
+
+func (network *SNetwork) GetProjectId() string {
+	return ""
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/multicloud/libvirt"
+)
+
+type SLibvirtProviderFactory struct {
+	cloudprovider.SPrivateCloudBaseProviderFactory
+}
+
+func (self *SLibvirtProviderFactory) GetId() string {
+	return libvirt.CLOUD_PROVIDER_LIBVIRT
+}
+
+func (self *SLibvirtProviderFactory) GetName() string {
+	return libvirt.CLOUD_PROVIDER_LIBVIRT
+}
+
+func validateUris(uris string) error {
+	count := 0
+	for _, uri := range strings.Split(uris, ",") {
+		uri = strings.TrimSpace(uri)
+		if len(uri) == 0 {
+			continue
+		}
+		u, err := url.Parse(uri)
+		if err != nil || !strings.HasPrefix(u.Scheme, "qemu") || len(u.Hostname()) == 0 {
+			return httperrors.NewInputParameterError("invalid libvirt uri %s", uri)
+		}
+		count++
+	}
+	if count == 0 {
+		return errors.Wrap(httperrors.ErrMissingParameter, "auth_url")
+	}
+	return nil
+}
+
+// auth_url为逗号分隔的libvirt连接地址, 如 qemu+ssh://10.0.0.1/system,qemu+tls://10.0.0.2/system
+// 密码字段可填写ssh密码或PEM格式私钥, 用户名缺省为root
+func (self *SLibvirtProviderFactory) ValidateCreateCloudaccountData(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	err := validateUris(input.AuthUrl)
+	if err != nil {
+		return output, err
+	}
+	output.AccessUrl = input.AuthUrl
+	output.Account = input.Username
+	if len(output.Account) == 0 {
+		output.Account = "root"
+	}
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SLibvirtProviderFactory) ValidateUpdateCloudaccountCredential(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential, cloudaccount string) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	output.Account = input.Username
+	if len(output.Account) == 0 {
+		output.Account = "root"
+	}
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SLibvirtProviderFactory) GetProvider(cfg cloudprovider.ProviderConfig) (cloudprovider.ICloudProvider, error) {
+	client, err := libvirt.NewLibvirtClient(
+		libvirt.NewLibvirtClientConfig(
+			cfg.URL, cfg.Account, cfg.Secret,
+		).CloudproviderConfig(cfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &SLibvirtProvider{
+		SClientBaseProvider: multicloud.NewClientBaseProvider(self, client),
+		client:              client,
+	}, nil
+}
+
+func (self *SLibvirtProviderFactory) GetClientRC(info cloudprovider.SProviderInfo) (map[string]string, error) {
+	return map[string]string{
+		"LIBVIRT_URIS":      info.Url,
+		"LIBVIRT_USERNAME":  info.Account,
+		"LIBVIRT_PASSWORD":  info.Secret,
+		"LIBVIRT_REGION_ID": libvirt.LIBVIRT_DEFAULT_REGION,
+	}, nil
+}
+
+func init() {
+	factory := SLibvirtProviderFactory{}
+	cloudprovider.RegisterFactory(&factory)
+}
+
+type SLibvirtProvider struct {
+	multicloud.SClientBaseProvider
+	client *libvirt.SLibvirtClient
+}
+
+func (self *SLibvirtProvider) GetVersion() string {
+	for _, conn := range self.client.GetConnections() {
+		version, err := conn.GetVersion()
+		if err == nil {
+			return version
+		}
+	}
+	return ""
+}
+
+func (self *SLibvirtProvider) GetSysInfo() (jsonutils.JSONObject, error) {
+	info := jsonutils.NewDict()
+	for _, conn := range self.client.GetConnections() {
+		version, err := conn.GetVersion()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetVersion %s", conn.Uri)
+		}
+		info.Add(jsonutils.NewString(version), conn.Uri)
+	}
+	return info, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/libvirt"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type InstanceListOptions struct {
+		HOST string
+	}
+	shellutils.R(&InstanceListOptions{}, "instance-list", "List instances of host", func(cli *libvirt.SRegion, args *InstanceListOptions) error {
+		host, err := cli.GetHost(args.HOST)
+		if err != nil {
+			return err
+		}
+		instances, err := host.GetInstances()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(instances, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceIdOptions struct {
+		ID string
+	}
+	shellutils.R(&InstanceIdOptions{}, "instance-show", "Show instance", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(instance)
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-disk-list", "List instance disks", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		disks, err := instance.GetDisks()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(disks, 0, 0, 0, []string{})
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-nic-list", "List instance nics", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		nics, err := instance.GetNics()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(nics, 0, 0, 0, []string{})
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-start", "Start instance", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.StartVM(context.Background())
+	})
+
+	type InstanceStopOptions struct {
+		ID    string
+		Force bool
+	}
+	shellutils.R(&InstanceStopOptions{}, "instance-stop", "Stop instance", func(cli *libvirt.SRegion, args *InstanceStopOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.StopVM(context.Background(), &cloudprovider.ServerStopOptions{IsForce: args.Force})
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-reboot", "Reboot instance", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.RebootVM(context.Background())
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-delete", "Undefine instance, volumes are kept", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.DeleteVM(context.Background())
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-vnc", "Show instance vnc info", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		info, err := instance.GetVNCInfo()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(info)
+		return nil
+	})
+
+	type InstanceDiskOptions struct {
+		ID   string
+		DISK string `help:"Disk id, format: <pool uuid>/<volume name>"`
+	}
+	shellutils.R(&InstanceDiskOptions{}, "instance-attach-disk", "Attach disk to instance", func(cli *libvirt.SRegion, args *InstanceDiskOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.AttachDisk(context.Background(), args.DISK)
+	})
+
+	shellutils.R(&InstanceDiskOptions{}, "instance-detach-disk", "Detach disk from instance", func(cli *libvirt.SRegion, args *InstanceDiskOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.DetachDisk(context.Background(), args.DISK)
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-snapshot-list", "List instance snapshots", func(cli *libvirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		snapshots, err := instance.GetSnapshots()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(snapshots, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceSnapshotCreateOptions struct {
+		ID   string
+		NAME string
+		Desc string
+	}
+	shellutils.R(&InstanceSnapshotCreateOptions{}, "instance-snapshot-create", "Create instance snapshot", func(cli *libvirt.SRegion, args *InstanceSnapshotCreateOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		snapshot, err := instance.CreateInstanceSnapshot(context.Background(), args.NAME, args.Desc)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(snapshot)
+		return nil
+	})
+
+	type InstanceSnapshotOptions struct {
+		ID       string
+		SNAPSHOT string
+	}
+	shellutils.R(&InstanceSnapshotOptions{}, "instance-snapshot-revert", "Revert instance to snapshot", func(cli *libvirt.SRegion, args *InstanceSnapshotOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.ResetToInstanceSnapshot(context.Background(), args.SNAPSHOT)
+	})
+
+	shellutils.R(&InstanceSnapshotOptions{}, "instance-snapshot-delete", "Delete instance snapshot", func(cli *libvirt.SRegion, args *InstanceSnapshotOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		snapshot, err := instance.GetInstanceSnapshot(args.SNAPSHOT)
+		if err != nil {
+			return err
+		}
+		return snapshot.Delete()
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+
+	"yunion.io/x/onecloud/pkg/multicloud/libvirt"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type StorageListOptions struct {
+		HOST string
+	}
+	shellutils.R(&StorageListOptions{}, "storage-list", "List storage pools of host", func(cli *libvirt.SRegion, args *StorageListOptions) error {
+		host, err := cli.GetHost(args.HOST)
+		if err != nil {
+			return err
+		}
+		storages, err := host.GetStorages()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(storages, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskListOptions struct {
+		HOST    string
+		STORAGE string `help:"Storage pool uuid"`
+	}
+	shellutils.R(&DiskListOptions{}, "disk-list", "List volumes in storage pool", func(cli *libvirt.SRegion, args *DiskListOptions) error {
+		host, err := cli.GetHost(args.HOST)
+		if err != nil {
+			return err
+		}
+		storage, err := host.GetStorage(args.STORAGE)
+		if err != nil {
+			return err
+		}
+		disks, err := storage.GetDisks()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(disks, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskIdOptions struct {
+		ID string `help:"Disk id, format: <pool uuid>/<volume name>"`
+	}
+	shellutils.R(&DiskIdOptions{}, "disk-show", "Show disk", func(cli *libvirt.SRegion, args *DiskIdOptions) error {
+		disk, err := cli.GetDisk(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(disk)
+		return nil
+	})
+
+	shellutils.R(&DiskIdOptions{}, "disk-delete", "Delete disk", func(cli *libvirt.SRegion, args *DiskIdOptions) error {
+		disk, err := cli.GetDisk(args.ID)
+		if err != nil {
+			return err
+		}
+		return disk.Delete(context.Background())
+	})
+
+	type DiskCreateOptions struct {
+		HOST    string
+		STORAGE string `help:"Storage pool uuid"`
+		NAME    string
+		SIZE    int `help:"Disk size in GB"`
+	}
+	shellutils.R(&DiskCreateOptions{}, "disk-create", "Create disk", func(cli *libvirt.SRegion, args *DiskCreateOptions) error {
+		host, err := cli.GetHost(args.HOST)
+		if err != nil {
+			return err
+		}
+		storage, err := host.GetStorage(args.STORAGE)
+		if err != nil {
+			return err
+		}
+		disk, err := storage.CreateDisk(args.NAME, args.SIZE)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(disk)
+		return nil
+	})
+
+	type DiskResizeOptions struct {
+		ID   string
+		SIZE int64 `help:"New size in MB"`
+	}
+	shellutils.R(&DiskResizeOptions{}, "disk-resize", "Resize disk", func(cli *libvirt.SRegion, args *DiskResizeOptions) error {
+		disk, err := cli.GetDisk(args.ID)
+		if err != nil {
+			return err
+		}
+		return disk.Resize(context.Background(), args.SIZE)
+	})
+}
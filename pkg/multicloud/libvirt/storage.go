@@ -0,0 +1,258 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"fmt"
+	"strings"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 每个存储池对应一个存储, 存储池仅对所在主机可见
+type SStorage struct {
+	multicloud.SResourceBase
+	host *SHost
+
+	Id    string
+	Name  string
+	State string
+	Pool  libvirtxml.StoragePool
+}
+
+var storageTypes = map[string]string{
+	"dir":     api.STORAGE_LIBVIRT_DIR,
+	"fs":      api.STORAGE_LIBVIRT_DIR,
+	"logical": api.STORAGE_LIBVIRT_LOGICAL,
+	"netfs":   api.STORAGE_LIBVIRT_NETFS,
+	"gluster": api.STORAGE_LIBVIRT_NETFS,
+	"rbd":     api.STORAGE_LIBVIRT_RBD,
+}
+
+func poolSizeBytes(size *libvirtxml.StoragePoolSize) int64 {
+	if size == nil {
+		return 0
+	}
+	return int64(size.Value) * unitBytes(size.Unit)
+}
+
+// libvirt的容量单位, 缺省为字节
+func unitBytes(unit string) int64 {
+	switch strings.ToLower(unit) {
+	case "k", "kib":
+		return 1 << 10
+	case "kb":
+		return 1000
+	case "m", "mib":
+		return 1 << 20
+	case "mb":
+		return 1000 * 1000
+	case "g", "gib":
+		return 1 << 30
+	case "gb":
+		return 1000 * 1000 * 1000
+	case "t", "tib":
+		return 1 << 40
+	case "tb":
+		return 1000 * 1000 * 1000 * 1000
+	default:
+		return 1
+	}
+}
+
+func (host *SHost) getStorage(name string) (*SStorage, error) {
+	info, err := host.conn.info("pool-info", name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pool-info %s", name)
+	}
+	storage := &SStorage{host: host, Name: name, Id: info["UUID"], State: info["State"]}
+	err = host.conn.dumpxml(&storage.Pool, "pool-dumpxml", name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pool-dumpxml %s", name)
+	}
+	if len(storage.Id) == 0 {
+		storage.Id = storage.Pool.UUID
+	}
+	return storage, nil
+}
+
+func (host *SHost) GetStorages() ([]SStorage, error) {
+	if host.storages != nil {
+		return host.storages, nil
+	}
+	names, err := host.conn.list("pool-list", "--all", "--name")
+	if err != nil {
+		return nil, errors.Wrapf(err, "pool-list")
+	}
+	storages := []SStorage{}
+	for _, name := range names {
+		storage, err := host.getStorage(name)
+		if err != nil {
+			return nil, err
+		}
+		storages = append(storages, *storage)
+	}
+	host.storages = storages
+	return storages, nil
+}
+
+func (host *SHost) GetStorage(id string) (*SStorage, error) {
+	storages, err := host.GetStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range storages {
+		if storages[i].Id == id {
+			return &storages[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", id)
+}
+
+func (host *SHost) getStorageByName(name string) (*SStorage, error) {
+	storages, err := host.GetStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range storages {
+		if storages[i].Name == name {
+			return &storages[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", name)
+}
+
+func (storage *SStorage) GetId() string {
+	return storage.Id
+}
+
+func (storage *SStorage) GetName() string {
+	return fmt.Sprintf("%s-%s", storage.host.Name, storage.Name)
+}
+
+func (storage *SStorage) GetGlobalId() string {
+	return storage.GetId()
+}
+
+func (storage *SStorage) IsEmulated() bool {
+	return false
+}
+
+func (storage *SStorage) GetStatus() string {
+	if storage.State == "running" {
+		return api.STORAGE_ONLINE
+	}
+	return api.STORAGE_OFFLINE
+}
+
+func (storage *SStorage) Refresh() error {
+	newStorage, err := storage.host.getStorage(storage.Name)
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(storage, newStorage)
+}
+
+func (storage *SStorage) GetIZone() cloudprovider.ICloudZone {
+	return storage.host.zone
+}
+
+func (storage *SStorage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return storage.host.getRegion().getStoragecache()
+}
+
+func (storage *SStorage) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := storage.GetDisks()
+	if err != nil {
+		return nil, err
+	}
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (storage *SStorage) GetIDiskById(diskId string) (cloudprovider.ICloudDisk, error) {
+	poolId, name, err := parseDiskId(diskId)
+	if err != nil {
+		return nil, err
+	}
+	if poolId != storage.Id {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s not in storage %s", diskId, storage.Name)
+	}
+	return storage.GetDisk(name)
+}
+
+func (storage *SStorage) CreateIDisk(conf *cloudprovider.DiskCreateConfig) (cloudprovider.ICloudDisk, error) {
+	disk, err := storage.CreateDisk(conf.Name, conf.SizeGb)
+	if err != nil {
+		return nil, err
+	}
+	return disk, nil
+}
+
+func (storage *SStorage) GetStorageType() string {
+	if storageType, ok := storageTypes[storage.Pool.Type]; ok {
+		return storageType
+	}
+	return api.STORAGE_LIBVIRT_DIR
+}
+
+func (storage *SStorage) GetMediumType() string {
+	return api.DISK_TYPE_ROTATE
+}
+
+func (storage *SStorage) GetCapacityMB() int64 {
+	return poolSizeBytes(storage.Pool.Capacity) / 1024 / 1024
+}
+
+func (storage *SStorage) GetCapacityUsedMB() int64 {
+	return poolSizeBytes(storage.Pool.Allocation) / 1024 / 1024
+}
+
+func (storage *SStorage) getPath() string {
+	if storage.Pool.Target != nil {
+		return storage.Pool.Target.Path
+	}
+	return ""
+}
+
+func (storage *SStorage) GetStorageConf() jsonutils.JSONObject {
+	conf := jsonutils.NewDict()
+	conf.Add(jsonutils.NewString(storage.Pool.Type), "type")
+	conf.Add(jsonutils.NewString(storage.Name), "pool")
+	conf.Add(jsonutils.NewString(storage.getPath()), "path")
+	return conf
+}
+
+func (storage *SStorage) GetEnabled() bool {
+	return true
+}
+
+func (storage *SStorage) GetMountPoint() string {
+	return storage.getPath()
+}
+
+func (storage *SStorage) IsSysDiskStore() bool {
+	return true
+}
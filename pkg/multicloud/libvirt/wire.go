@@ -0,0 +1,221 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"fmt"
+
+	libvirtxml "github.com/libvirt/libvirt-go-xml"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// libvirt网络对应二层网络, 各主机的网络相互独立(如默认的NAT网络), 因此按主机区分
+type SWire struct {
+	multicloud.SResourceBase
+	host *SHost
+	vpc  *SVpc
+
+	Network libvirtxml.Network
+
+	networks []SNetwork
+}
+
+func (host *SHost) getWire(name string) (*SWire, error) {
+	wire := &SWire{host: host}
+	err := host.conn.dumpxml(&wire.Network, "net-dumpxml", name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "net-dumpxml %s", name)
+	}
+	for _, ip := range wire.Network.IPs {
+		if ip.Family != "" && ip.Family != "ipv4" {
+			continue
+		}
+		wire.addNetwork(ip)
+	}
+	return wire, nil
+}
+
+func (host *SHost) GetWires() ([]SWire, error) {
+	names, err := host.conn.list("net-list", "--all", "--name")
+	if err != nil {
+		return nil, errors.Wrapf(err, "net-list")
+	}
+	wires := []SWire{}
+	for _, name := range names {
+		wire, err := host.getWire(name)
+		if err != nil {
+			return nil, err
+		}
+		wires = append(wires, *wire)
+	}
+	for i := range wires {
+		wires[i].vpc = host.getRegion().getVpc()
+		for j := range wires[i].networks {
+			wires[i].networks[j].wire = &wires[i]
+		}
+	}
+	return wires, nil
+}
+
+// 网段取自libvirt网络的地址配置, 可分配地址优先使用DHCP地址池
+func (wire *SWire) addNetwork(ip libvirtxml.NetworkIP) {
+	gateway, err := netutils.NewIPV4Addr(ip.Address)
+	if err != nil {
+		return
+	}
+	maskLen := int8(ip.Prefix)
+	if len(ip.Netmask) > 0 {
+		_, maskLen, err = netutils.ParsePrefix(fmt.Sprintf("%s/%s", ip.Address, ip.Netmask))
+		if err != nil {
+			return
+		}
+	}
+	if maskLen <= 0 || maskLen > 32 {
+		return
+	}
+	network := SNetwork{
+		Gateway: ip.Address,
+		MaskLen: maskLen,
+		netAddr: gateway.NetAddr(maskLen),
+	}
+	if ip.DHCP != nil {
+		network.dhcpHosts = ip.DHCP.Hosts
+		if len(ip.DHCP.Ranges) > 0 {
+			start, err1 := netutils.NewIPV4Addr(ip.DHCP.Ranges[0].Start)
+			end, err2 := netutils.NewIPV4Addr(ip.DHCP.Ranges[0].End)
+			if err1 == nil && err2 == nil {
+				network.ipRange = netutils.NewIPV4AddrRange(start, end)
+				wire.networks = append(wire.networks, network)
+				return
+			}
+		}
+	}
+	network.fixRange()
+	wire.networks = append(wire.networks, network)
+}
+
+func (region *SRegion) getWires() ([]SWire, error) {
+	hosts, err := region.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	ret := []SWire{}
+	for _, host := range hosts {
+		wires, err := host.GetWires()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetWires of host %s", host.Name)
+		}
+		ret = append(ret, wires...)
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetWire(wireId string) (*SWire, error) {
+	wires, err := region.getWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		if wires[i].GetGlobalId() == wireId {
+			return &wires[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "wire %s", wireId)
+}
+
+func (region *SRegion) GetNetwork(networkId string) (*SNetwork, error) {
+	wires, err := region.getWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		for j := range wires[i].networks {
+			if wires[i].networks[j].GetGlobalId() == networkId {
+				return &wires[i].networks[j], nil
+			}
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", networkId)
+}
+
+func (wire *SWire) getBridge() string {
+	if wire.Network.Bridge != nil {
+		return wire.Network.Bridge.Name
+	}
+	return ""
+}
+
+func (wire *SWire) GetId() string {
+	return fmt.Sprintf("%s/%s", wire.host.Id, wire.Network.Name)
+}
+
+func (wire *SWire) GetName() string {
+	return fmt.Sprintf("%s-%s", wire.host.Name, wire.Network.Name)
+}
+
+func (wire *SWire) GetGlobalId() string {
+	return wire.GetId()
+}
+
+func (wire *SWire) IsEmulated() bool {
+	return false
+}
+
+func (wire *SWire) GetStatus() string {
+	return "available"
+}
+
+func (wire *SWire) Refresh() error {
+	return nil
+}
+
+func (wire *SWire) GetIVpc() cloudprovider.ICloudVpc {
+	return wire.vpc
+}
+
+func (wire *SWire) GetIZone() cloudprovider.ICloudZone {
+	return wire.host.zone
+}
+
+func (wire *SWire) GetINetworks() ([]cloudprovider.ICloudNetwork, error) {
+	inetworks := []cloudprovider.ICloudNetwork{}
+	for i := range wire.networks {
+		inetworks = append(inetworks, &wire.networks[i])
+	}
+	return inetworks, nil
+}
+
+func (wire *SWire) GetINetworkById(netid string) (cloudprovider.ICloudNetwork, error) {
+	for i := range wire.networks {
+		if wire.networks[i].GetGlobalId() == netid {
+			return &wire.networks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", netid)
+}
+
+func (wire *SWire) GetBandwidth() int {
+	return 10000
+}
+
+// 网段来自libvirt网络配置, 不支持通过接口创建
+func (wire *SWire) CreateINetwork(opts *cloudprovider.SNetworkCreateOptions) (cloudprovider.ICloudNetwork, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
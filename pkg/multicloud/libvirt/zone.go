@@ -0,0 +1,151 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// libvirt没有可用区概念, 同一云账号下的所有主机归属一个可用区
+type SZone struct {
+	multicloud.SResourceBase
+	region *SRegion
+
+	ihosts []cloudprovider.ICloudHost
+}
+
+func (zone *SZone) GetId() string {
+	return LIBVIRT_DEFAULT_ZONE
+}
+
+func (zone *SZone) GetName() string {
+	return fmt.Sprintf("%s-%s", zone.region.client.cpcfg.Name, LIBVIRT_DEFAULT_ZONE)
+}
+
+func (zone *SZone) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(zone.GetName()).CN(zone.GetName())
+	return table
+}
+
+func (zone *SZone) GetGlobalId() string {
+	return fmt.Sprintf("%s/%s", zone.region.GetGlobalId(), LIBVIRT_DEFAULT_ZONE)
+}
+
+func (zone *SZone) IsEmulated() bool {
+	return true
+}
+
+func (zone *SZone) GetStatus() string {
+	return api.ZONE_ENABLE
+}
+
+func (zone *SZone) Refresh() error {
+	// do nothing
+	return nil
+}
+
+func (zone *SZone) GetIRegion() cloudprovider.ICloudRegion {
+	return zone.region
+}
+
+// 无法连接的主机不影响其他主机同步
+func (zone *SZone) fetchHosts() error {
+	zone.ihosts = []cloudprovider.ICloudHost{}
+	for _, conn := range zone.region.client.conns {
+		host, err := zone.region.getHost(conn)
+		if err != nil {
+			log.Errorf("failed to get libvirt host %s error: %v", conn.Uri, err)
+			continue
+		}
+		host.zone = zone
+		zone.ihosts = append(zone.ihosts, host)
+	}
+	if len(zone.ihosts) == 0 && len(zone.region.client.conns) > 0 {
+		return errors.Wrap(cloudprovider.ErrNotFound, "no available libvirt host")
+	}
+	return nil
+}
+
+func (zone *SZone) getHosts() ([]*SHost, error) {
+	if len(zone.ihosts) == 0 {
+		err := zone.fetchHosts()
+		if err != nil {
+			return nil, err
+		}
+	}
+	hosts := []*SHost{}
+	for i := range zone.ihosts {
+		hosts = append(hosts, zone.ihosts[i].(*SHost))
+	}
+	return hosts, nil
+}
+
+func (zone *SZone) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	_, err := zone.getHosts()
+	if err != nil {
+		return nil, err
+	}
+	return zone.ihosts, nil
+}
+
+func (zone *SZone) GetIHostById(hostId string) (cloudprovider.ICloudHost, error) {
+	hosts, err := zone.GetIHosts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		if hosts[i].GetGlobalId() == hostId {
+			return hosts[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "host %s", hostId)
+}
+
+func (zone *SZone) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	hosts, err := zone.getHosts()
+	if err != nil {
+		return nil, err
+	}
+	istorages := []cloudprovider.ICloudStorage{}
+	for _, host := range hosts {
+		storages, err := host.GetIStorages()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetIStorages of host %s", host.Name)
+		}
+		istorages = append(istorages, storages...)
+	}
+	return istorages, nil
+}
+
+func (zone *SZone) GetIStorageById(storageId string) (cloudprovider.ICloudStorage, error) {
+	storages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(storages); i++ {
+		if storages[i].GetGlobalId() == storageId {
+			return storages[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", storageId)
+}
@@ -25,10 +25,13 @@ import (
 	_ "yunion.io/x/onecloud/pkg/multicloud/esxi/provider"   // private clouds
 	_ "yunion.io/x/onecloud/pkg/multicloud/google/provider" // public clouds
 	_ "yunion.io/x/onecloud/pkg/multicloud/huawei/provider"
+	_ "yunion.io/x/onecloud/pkg/multicloud/libvirt/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/objectstore/ceph/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/objectstore/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/objectstore/xsky/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/openstack/provider"
+	_ "yunion.io/x/onecloud/pkg/multicloud/ovirt/provider"
+	_ "yunion.io/x/onecloud/pkg/multicloud/proxmox/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/qcloud/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/ucloud/provider" // object storages
 	_ "yunion.io/x/onecloud/pkg/multicloud/zstack/provider" // public clouds
@@ -0,0 +1,330 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 虚拟机与磁盘的关联关系, 其Id与磁盘Id相同
+type SDiskAttachment struct {
+	Id        string
+	Active    bool
+	Bootable  bool
+	Interface string
+	Disk      SDisk
+	Vm        SRef
+}
+
+type SDisk struct {
+	multicloud.SDisk
+	region *SRegion
+
+	Id              string
+	Name            string
+	Alias           string
+	Description     string
+	ProvisionedSize int64
+	ActualSize      int64
+	Format          string
+	Status          string
+	StorageType     string
+	StorageDomains  struct {
+		StorageDomain []SRef
+	}
+
+	// 挂载信息, 由所属虚拟机的disk_attachments填充
+	VmId      string
+	Bootable  bool
+	Interface string
+}
+
+func (disk *SDisk) attach(attachment *SDiskAttachment) {
+	disk.VmId = attachment.Vm.Id
+	disk.Bootable = attachment.Bootable
+	disk.Interface = attachment.Interface
+}
+
+// 磁盘本身不包含挂载信息, 需从虚拟机的disk_attachments中获取
+func (region *SRegion) getDiskAttachments() (map[string]SDiskAttachment, error) {
+	instances, err := region.GetInstances("")
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetInstances")
+	}
+	attachments := map[string]SDiskAttachment{}
+	for i := range instances {
+		for _, attachment := range instances[i].DiskAttachments.DiskAttachment {
+			attachment.Vm.Id = instances[i].Id
+			attachments[attachment.Id] = attachment
+		}
+	}
+	return attachments, nil
+}
+
+func (region *SRegion) GetDisks(storageId string) ([]SDisk, error) {
+	disks := []SDisk{}
+	err := region.client.get(fmt.Sprintf("/storagedomains/%s/disks", storageId), "disk", &disks)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := region.getDiskAttachments()
+	if err != nil {
+		return nil, err
+	}
+	for i := range disks {
+		disks[i].region = region
+		if attachment, ok := attachments[disks[i].Id]; ok {
+			disks[i].attach(&attachment)
+		}
+	}
+	return disks, nil
+}
+
+func (region *SRegion) GetDisk(diskId string) (*SDisk, error) {
+	disk := &SDisk{region: region}
+	err := region.client.get(fmt.Sprintf("/disks/%s", diskId), "", disk)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := region.getDiskAttachments()
+	if err != nil {
+		return nil, err
+	}
+	if attachment, ok := attachments[disk.Id]; ok {
+		disk.attach(&attachment)
+	}
+	return disk, nil
+}
+
+func (region *SRegion) CreateDisk(storageId string, name string, sizeGb int, desc string) (*SDisk, error) {
+	params := map[string]interface{}{
+		"disk": region.diskParams(storageId, name, sizeGb, desc),
+	}
+	resp, err := region.client.post("/disks", params)
+	if err != nil {
+		return nil, err
+	}
+	disk := &SDisk{region: region}
+	err = resp.Unmarshal(disk)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resp.Unmarshal")
+	}
+	return disk, region.waitDiskReady(disk.Id)
+}
+
+func (region *SRegion) diskParams(storageId string, name string, sizeGb int, desc string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             name,
+		"description":      desc,
+		"provisioned_size": int64(sizeGb) * 1024 * 1024 * 1024,
+		"format":           "cow",
+		"sparse":           true,
+		"storage_domains": map[string]interface{}{
+			"storage_domain": []map[string]string{{"id": storageId}},
+		},
+	}
+}
+
+// 新建或扩容的磁盘在完成前处于locked状态
+func (region *SRegion) waitDiskReady(diskId string) error {
+	return cloudprovider.Wait(region.client.pollInterval, StatusTimeout, func() (bool, error) {
+		disk := SDisk{}
+		err := region.client.get(fmt.Sprintf("/disks/%s", diskId), "", &disk)
+		if err != nil {
+			return false, err
+		}
+		switch disk.Status {
+		case "ok":
+			return true, nil
+		case "illegal":
+			return false, fmt.Errorf("disk %s status illegal", diskId)
+		}
+		return false, nil
+	})
+}
+
+func (region *SRegion) DeleteDisk(diskId string) error {
+	return region.client.delete(fmt.Sprintf("/disks/%s", diskId))
+}
+
+func (region *SRegion) ResizeDisk(vmId string, diskId string, sizeMb int64) error {
+	size := sizeMb * 1024 * 1024
+	var err error
+	if len(vmId) > 0 {
+		params := map[string]interface{}{
+			"disk_attachment": map[string]interface{}{
+				"disk": map[string]interface{}{"provisioned_size": size},
+			},
+		}
+		_, err = region.client.put(fmt.Sprintf("/vms/%s/diskattachments/%s", vmId, diskId), params)
+	} else {
+		params := map[string]interface{}{
+			"disk": map[string]interface{}{"provisioned_size": size},
+		}
+		_, err = region.client.put(fmt.Sprintf("/disks/%s", diskId), params)
+	}
+	if err != nil {
+		return err
+	}
+	return region.waitDiskReady(diskId)
+}
+
+func (disk *SDisk) GetId() string {
+	return disk.Id
+}
+
+func (disk *SDisk) GetName() string {
+	if len(disk.Alias) > 0 {
+		return disk.Alias
+	}
+	return disk.Name
+}
+
+func (disk *SDisk) GetGlobalId() string {
+	return disk.Id
+}
+
+func (disk *SDisk) IsEmulated() bool {
+	return false
+}
+
+func (disk *SDisk) GetStatus() string {
+	switch disk.Status {
+	case "ok":
+		return api.DISK_READY
+	case "locked":
+		return api.DISK_ALLOCATING
+	default:
+		return api.DISK_UNKNOWN
+	}
+}
+
+func (disk *SDisk) Refresh() error {
+	newDisk, err := disk.region.GetDisk(disk.Id)
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(disk, newDisk)
+}
+
+func (disk *SDisk) GetIStorage() (cloudprovider.ICloudStorage, error) {
+	return disk.region.GetIStorageById(disk.GetIStorageId())
+}
+
+func (disk *SDisk) GetIStorageId() string {
+	if len(disk.StorageDomains.StorageDomain) > 0 {
+		return disk.StorageDomains.StorageDomain[0].Id
+	}
+	return ""
+}
+
+func (disk *SDisk) GetDiskFormat() string {
+	if disk.Format == "cow" {
+		return "qcow2"
+	}
+	return "raw"
+}
+
+func (disk *SDisk) GetDiskSizeMB() int {
+	return int(disk.ProvisionedSize / 1024 / 1024)
+}
+
+func (disk *SDisk) GetIsAutoDelete() bool {
+	return false
+}
+
+func (disk *SDisk) GetTemplateId() string {
+	return ""
+}
+
+func (disk *SDisk) GetDiskType() string {
+	if disk.Bootable {
+		return api.DISK_TYPE_SYS
+	}
+	return api.DISK_TYPE_DATA
+}
+
+func (disk *SDisk) GetFsFormat() string {
+	return ""
+}
+
+func (disk *SDisk) GetIsNonPersistent() bool {
+	return false
+}
+
+func (disk *SDisk) GetDriver() string {
+	switch disk.Interface {
+	case "virtio":
+		return "virtio"
+	case "ide":
+		return "ide"
+	case "sata":
+		return "sata"
+	default:
+		return "scsi"
+	}
+}
+
+func (disk *SDisk) GetCacheMode() string {
+	return "none"
+}
+
+func (disk *SDisk) GetMountpoint() string {
+	return ""
+}
+
+func (disk *SDisk) GetAccessPath() string {
+	return ""
+}
+
+func (disk *SDisk) Delete(ctx context.Context) error {
+	if len(disk.VmId) > 0 {
+		return fmt.Errorf("disk %s is attached to vm %s", disk.GetName(), disk.VmId)
+	}
+	return disk.region.DeleteDisk(disk.Id)
+}
+
+func (disk *SDisk) Resize(ctx context.Context, sizeMb int64) error {
+	return disk.region.ResizeDisk(disk.VmId, disk.Id, sizeMb)
+}
+
+// oVirt的快照为虚拟机级别, 不支持单独的磁盘快照
+func (disk *SDisk) CreateISnapshot(ctx context.Context, name, desc string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (disk *SDisk) Reset(ctx context.Context, snapshotId string) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) Rebuild(ctx context.Context) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetProjectId() string {
+	return ""
+}
@@ -0,0 +1,284 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SHost struct {
+	multicloud.SHostBase
+	zone *SZone
+
+	Id          string
+	Name        string
+	Description string
+	Address     string
+	Status      string
+	Type        string
+	Cluster     SRef
+	Memory      int64
+	Cpu         struct {
+		Name     string
+		Speed    int
+		Topology struct {
+			Sockets int
+			Cores   int
+			Threads int
+		}
+	}
+	Version struct {
+		FullVersion string
+	}
+	HardwareInformation struct {
+		Manufacturer string
+		ProductName  string
+		SerialNumber string
+		Uuid         string
+	}
+	Summary struct {
+		Active int
+		Total  int
+	}
+}
+
+func (region *SRegion) getClusterDatacenters() (map[string]string, error) {
+	clusters, err := region.GetClusters()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetClusters")
+	}
+	ret := map[string]string{}
+	for _, cluster := range clusters {
+		ret[cluster.Id] = cluster.DataCenter.Id
+	}
+	return ret, nil
+}
+
+// dcId为空时返回所有数据中心的主机
+func (region *SRegion) GetHosts(dcId string) ([]SHost, error) {
+	hosts := []SHost{}
+	err := region.client.get("/hosts", "host", &hosts)
+	if err != nil {
+		return nil, err
+	}
+	if len(dcId) == 0 {
+		return hosts, nil
+	}
+	clusterDcs, err := region.getClusterDatacenters()
+	if err != nil {
+		return nil, err
+	}
+	ret := []SHost{}
+	for i := range hosts {
+		if clusterDcs[hosts[i].Cluster.Id] == dcId {
+			ret = append(ret, hosts[i])
+		}
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetHost(id string) (*SHost, error) {
+	host := &SHost{}
+	err := region.client.get(fmt.Sprintf("/hosts/%s", id), "", host)
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := region.GetCluster(host.Cluster.Id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetCluster(%s)", host.Cluster.Id)
+	}
+	host.zone, err = region.getZone(cluster.DataCenter.Id)
+	if err != nil {
+		return nil, err
+	}
+	return host, nil
+}
+
+func (host *SHost) getRegion() *SRegion {
+	return host.zone.region
+}
+
+func (host *SHost) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	wires, err := host.getRegion().GetWires(host.zone.Id)
+	if err != nil {
+		return nil, err
+	}
+	iwires := []cloudprovider.ICloudWire{}
+	for i := 0; i < len(wires); i++ {
+		wires[i].vpc = host.getRegion().getVpc()
+		iwires = append(iwires, &wires[i])
+	}
+	return iwires, nil
+}
+
+func (host *SHost) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	return host.zone.GetIStorages()
+}
+
+func (host *SHost) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	return host.zone.GetIStorageById(id)
+}
+
+func (host *SHost) GetIVMs() ([]cloudprovider.ICloudVM, error) {
+	instances, err := host.getRegion().GetInstances(host.Id)
+	if err != nil {
+		return nil, err
+	}
+	ivms := []cloudprovider.ICloudVM{}
+	for i := 0; i < len(instances); i++ {
+		instances[i].host = host
+		ivms = append(ivms, &instances[i])
+	}
+	return ivms, nil
+}
+
+func (host *SHost) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	instance, err := host.getRegion().GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	if instance.host.Id != host.Id {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s not on host %s", id, host.Name)
+	}
+	instance.host = host
+	return instance, nil
+}
+
+func (host *SHost) GetId() string {
+	return host.Id
+}
+
+func (host *SHost) GetName() string {
+	return host.Name
+}
+
+func (host *SHost) GetGlobalId() string {
+	return host.GetId()
+}
+
+func (host *SHost) IsEmulated() bool {
+	return false
+}
+
+func (host *SHost) GetStatus() string {
+	switch host.Status {
+	case "up", "maintenance", "preparing_for_maintenance":
+		return api.HOST_STATUS_RUNNING
+	default:
+		return api.HOST_STATUS_UNKNOWN
+	}
+}
+
+func (host *SHost) Refresh() error {
+	newHost, err := host.getRegion().GetHost(host.Id)
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(host, newHost)
+}
+
+func (host *SHost) GetHostStatus() string {
+	if host.Status == "up" {
+		return api.HOST_ONLINE
+	}
+	return api.HOST_OFFLINE
+}
+
+func (host *SHost) GetEnabled() bool {
+	return host.Status != "maintenance"
+}
+
+func (host *SHost) GetAccessIp() string {
+	return host.Address
+}
+
+func (host *SHost) GetAccessMac() string {
+	return ""
+}
+
+func (host *SHost) GetSysInfo() jsonutils.JSONObject {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString(host.HardwareInformation.Manufacturer), "manufacture")
+	info.Add(jsonutils.NewString(host.HardwareInformation.ProductName), "model")
+	return info
+}
+
+func (host *SHost) GetSN() string {
+	return host.HardwareInformation.SerialNumber
+}
+
+func (host *SHost) GetCpuCount() int {
+	topology := host.Cpu.Topology
+	threads := topology.Threads
+	if threads == 0 {
+		threads = 1
+	}
+	return topology.Sockets * topology.Cores * threads
+}
+
+func (host *SHost) GetNodeCount() int8 {
+	return int8(host.Cpu.Topology.Sockets)
+}
+
+func (host *SHost) GetCpuDesc() string {
+	return host.Cpu.Name
+}
+
+func (host *SHost) GetCpuMhz() int {
+	return host.Cpu.Speed
+}
+
+func (host *SHost) GetMemSizeMB() int {
+	return int(host.Memory / 1024 / 1024)
+}
+
+func (host *SHost) GetStorageSizeMB() int {
+	return 0
+}
+
+func (host *SHost) GetStorageType() string {
+	return api.DISK_TYPE_HYBRID
+}
+
+func (host *SHost) GetHostType() string {
+	return api.HOST_TYPE_OVIRT
+}
+
+func (host *SHost) GetIsMaintenance() bool {
+	return host.Status == "maintenance"
+}
+
+func (host *SHost) GetVersion() string {
+	return host.Version.FullVersion
+}
+
+func (host *SHost) GetIHostNics() ([]cloudprovider.ICloudHostNetInterface, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (host *SHost) CreateVM(desc *cloudprovider.SManagedVMCreateConfig) (cloudprovider.ICloudVM, error) {
+	vmId, err := host.getRegion().CreateVM(host, desc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CreateVM")
+	}
+	return host.GetIVMById(vmId)
+}
@@ -0,0 +1,199 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/util/osprofile"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SImage struct {
+	multicloud.SImageBase
+	storageCache *SStoragecache
+
+	Id           string
+	Name         string
+	Description  string
+	Status       string
+	CreationTime int64
+	Memory       int64
+	Os           struct {
+		Type string
+	}
+	Bios struct {
+		Type string
+	}
+	Cpu struct {
+		Architecture string
+	}
+	DiskAttachments struct {
+		DiskAttachment []SDiskAttachment
+	}
+}
+
+func (region *SRegion) GetImages() ([]SImage, error) {
+	templates := []SImage{}
+	err := region.client.get("/templates?follow=disk_attachments.disk", "template", &templates)
+	if err != nil {
+		return nil, err
+	}
+	images := []SImage{}
+	for i := range templates {
+		if templates[i].Id != OVIRT_BLANK_TEMPLATE_ID {
+			images = append(images, templates[i])
+		}
+	}
+	return images, nil
+}
+
+func (region *SRegion) GetImage(imageId string) (*SImage, error) {
+	image := &SImage{}
+	err := region.client.get(fmt.Sprintf("/templates/%s?follow=disk_attachments.disk", imageId), "", image)
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+func (image *SImage) GetId() string {
+	return image.Id
+}
+
+func (image *SImage) GetName() string {
+	return image.Name
+}
+
+func (image *SImage) GetGlobalId() string {
+	return image.Id
+}
+
+func (image *SImage) IsEmulated() bool {
+	return false
+}
+
+func (image *SImage) GetStatus() string {
+	switch image.Status {
+	case "ok":
+		return api.CACHED_IMAGE_STATUS_ACTIVE
+	case "locked":
+		return api.CACHED_IMAGE_STATUS_CACHING
+	default:
+		return api.CACHED_IMAGE_STATUS_CACHE_FAILED
+	}
+}
+
+func (image *SImage) GetImageStatus() string {
+	switch image.Status {
+	case "ok":
+		return cloudprovider.IMAGE_STATUS_ACTIVE
+	case "locked":
+		return cloudprovider.IMAGE_STATUS_SAVING
+	default:
+		return cloudprovider.IMAGE_STATUS_KILLED
+	}
+}
+
+func (image *SImage) Refresh() error {
+	newImage, err := image.storageCache.region.GetImage(image.Id)
+	if err != nil {
+		return err
+	}
+	image.DiskAttachments = newImage.DiskAttachments
+	return jsonutils.Update(image, newImage)
+}
+
+func (image *SImage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return image.storageCache
+}
+
+func (image *SImage) Delete(ctx context.Context) error {
+	return image.storageCache.region.client.delete(fmt.Sprintf("/templates/%s", image.Id))
+}
+
+func (image *SImage) GetImageType() cloudprovider.TImageType {
+	return cloudprovider.ImageTypeSystem
+}
+
+func (image *SImage) GetSizeByte() int64 {
+	size := int64(0)
+	for _, attachment := range image.DiskAttachments.DiskAttachment {
+		size += attachment.Disk.ProvisionedSize
+	}
+	return size
+}
+
+func (image *SImage) GetOsType() string {
+	if strings.Contains(strings.ToLower(image.Os.Type), "windows") {
+		return osprofile.OS_TYPE_WINDOWS
+	}
+	return osprofile.OS_TYPE_LINUX
+}
+
+func (image *SImage) GetOsDist() string {
+	return ""
+}
+
+func (image *SImage) GetOsVersion() string {
+	return ""
+}
+
+func (image *SImage) GetOsArch() string {
+	if image.Cpu.Architecture == "aarch64" {
+		return "aarch64"
+	}
+	return "x86_64"
+}
+
+// 以系统盘大小作为最小磁盘大小
+func (image *SImage) GetMinOsDiskSizeGb() int {
+	for _, attachment := range image.DiskAttachments.DiskAttachment {
+		if attachment.Bootable {
+			return int(attachment.Disk.ProvisionedSize / 1024 / 1024 / 1024)
+		}
+	}
+	return int(image.GetSizeByte() / 1024 / 1024 / 1024)
+}
+
+func (image *SImage) GetMinRamSizeMb() int {
+	return 0
+}
+
+func (image *SImage) GetImageFormat() string {
+	return "qcow2"
+}
+
+func (image *SImage) GetCreatedAt() time.Time {
+	if image.CreationTime > 0 {
+		return time.Unix(image.CreationTime/1000, 0)
+	}
+	return time.Time{}
+}
+
+func (image *SImage) UEFI() bool {
+	return strings.Contains(image.Bios.Type, "ovmf")
+}
+
+func (image *SImage) GetMetadata() *jsonutils.JSONDict {
+	return nil
+}
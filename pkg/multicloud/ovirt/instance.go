@@ -0,0 +1,766 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/osprofile"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/billing"
+)
+
+const (
+	// 一并返回磁盘、网卡及guest agent上报的设备, 避免逐台查询
+	OVIRT_VM_FOLLOW = "follow=disk_attachments.disk,nics,reported_devices"
+)
+
+type SReportedDevice struct {
+	Name string
+	Type string
+	Mac  struct {
+		Address string
+	}
+	Ips struct {
+		Ip []struct {
+			Address string
+			Version string
+		}
+	}
+}
+
+type SInstance struct {
+	multicloud.SInstanceBase
+	host *SHost
+
+	Id           string
+	Name         string
+	Description  string
+	Status       string
+	Memory       int64
+	CreationTime int64
+	Type         string
+	Cpu          struct {
+		Architecture string
+		Topology     struct {
+			Sockets int
+			Cores   int
+			Threads int
+		}
+	}
+	Os struct {
+		Type string
+		Boot struct {
+			Devices struct {
+				Device []string
+			}
+		}
+	}
+	Bios struct {
+		Type string
+	}
+	Display struct {
+		Type string
+	}
+	Host            SRef
+	Cluster         SRef
+	Template        SRef
+	PlacementPolicy struct {
+		Affinity string
+		Hosts    struct {
+			Host []SRef
+		}
+	}
+	DiskAttachments struct {
+		DiskAttachment []SDiskAttachment
+	}
+	Nics struct {
+		Nic []SInstanceNic
+	}
+	ReportedDevices struct {
+		ReportedDevice []SReportedDevice
+	}
+
+	// 虚拟机所在或关机后归属的主机
+	HostId string
+}
+
+// 运行中的虚拟机直接取host, 关机的虚拟机先按固定主机, 再取所属集群中按名称排序的第一台在线主机, 保证同步结果稳定
+func (region *SRegion) placeInstances(instances []SInstance) error {
+	hosts, err := region.GetHosts("")
+	if err != nil {
+		return errors.Wrapf(err, "GetHosts")
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	for i := range instances {
+		instance := &instances[i]
+		if len(instance.Host.Id) > 0 {
+			instance.HostId = instance.Host.Id
+			continue
+		}
+		if len(instance.PlacementPolicy.Hosts.Host) > 0 {
+			instance.HostId = instance.PlacementPolicy.Hosts.Host[0].Id
+			continue
+		}
+		for _, host := range hosts {
+			if host.Cluster.Id == instance.Cluster.Id && host.Status == "up" {
+				instance.HostId = host.Id
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// hostId为空时返回所有虚拟机
+func (region *SRegion) GetInstances(hostId string) ([]SInstance, error) {
+	instances := []SInstance{}
+	err := region.client.get("/vms?"+OVIRT_VM_FOLLOW, "vm", &instances)
+	if err != nil {
+		return nil, err
+	}
+	err = region.placeInstances(instances)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostId) == 0 {
+		return instances, nil
+	}
+	ret := []SInstance{}
+	for i := range instances {
+		if instances[i].HostId == hostId {
+			ret = append(ret, instances[i])
+		}
+	}
+	return ret, nil
+}
+
+func (region *SRegion) getInstance(id string) (*SInstance, error) {
+	instances := []SInstance{{}}
+	err := region.client.get(fmt.Sprintf("/vms/%s?%s", id, OVIRT_VM_FOLLOW), "", &instances[0])
+	if err != nil {
+		return nil, err
+	}
+	err = region.placeInstances(instances)
+	if err != nil {
+		return nil, err
+	}
+	return &instances[0], nil
+}
+
+func (region *SRegion) GetInstance(id string) (*SInstance, error) {
+	instance, err := region.getInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(instance.HostId) == 0 {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "no available host for instance %s", instance.Name)
+	}
+	instance.host, err = region.GetHost(instance.HostId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetHost(%s)", instance.HostId)
+	}
+	return instance, nil
+}
+
+func (instance *SInstance) getRegion() *SRegion {
+	return instance.host.zone.region
+}
+
+func (instance *SInstance) vmPath() string {
+	return fmt.Sprintf("/vms/%s", instance.Id)
+}
+
+func (instance *SInstance) GetDisks() []SDisk {
+	disks := []SDisk{}
+	for i := range instance.DiskAttachments.DiskAttachment {
+		attachment := instance.DiskAttachments.DiskAttachment[i]
+		attachment.Vm.Id = instance.Id
+		disk := attachment.Disk
+		disk.attach(&attachment)
+		disks = append(disks, disk)
+	}
+	// 系统盘排在最前
+	sort.SliceStable(disks, func(i, j int) bool { return disks[i].Bootable && !disks[j].Bootable })
+	return disks
+}
+
+func (instance *SInstance) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks := instance.GetDisks()
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		disks[i].region = instance.getRegion()
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (instance *SInstance) GetNics() ([]SInstanceNic, error) {
+	nics := []SInstanceNic{}
+	for i := range instance.Nics.Nic {
+		nic := instance.Nics.Nic[i]
+		nic.instance = instance
+		nics = append(nics, nic)
+	}
+	sort.Slice(nics, func(i, j int) bool { return nics[i].Name < nics[j].Name })
+	return nics, nil
+}
+
+func (instance *SInstance) GetINics() ([]cloudprovider.ICloudNic, error) {
+	nics, err := instance.GetNics()
+	if err != nil {
+		return nil, err
+	}
+	inics := []cloudprovider.ICloudNic{}
+	for i := range nics {
+		inics = append(inics, &nics[i])
+	}
+	return inics, nil
+}
+
+func (instance *SInstance) GetIEIP() (cloudprovider.ICloudEIP, error) {
+	return nil, nil
+}
+
+func (instance *SInstance) GetIHost() cloudprovider.ICloudHost {
+	return instance.host
+}
+
+func (instance *SInstance) GetIHostId() string {
+	return instance.HostId
+}
+
+func (instance *SInstance) GetId() string {
+	return instance.Id
+}
+
+func (instance *SInstance) GetName() string {
+	return instance.Name
+}
+
+func (instance *SInstance) GetGlobalId() string {
+	return instance.Id
+}
+
+func (instance *SInstance) IsEmulated() bool {
+	return false
+}
+
+func (instance *SInstance) GetCreatedAt() time.Time {
+	if instance.CreationTime > 0 {
+		return time.Unix(instance.CreationTime/1000, 0)
+	}
+	return time.Time{}
+}
+
+func (instance *SInstance) GetInstanceType() string {
+	return ""
+}
+
+func (instance *SInstance) GetVcpuCount() int {
+	topology := instance.Cpu.Topology
+	count := topology.Sockets * topology.Cores * topology.Threads
+	if count == 0 {
+		return 1
+	}
+	return count
+}
+
+func (instance *SInstance) GetVmemSizeMB() int {
+	return int(instance.Memory / 1024 / 1024)
+}
+
+func (instance *SInstance) GetBootOrder() string {
+	order := ""
+	for _, dev := range instance.Os.Boot.Devices.Device {
+		c := ""
+		switch dev {
+		case "hd":
+			c = "c"
+		case "cdrom":
+			c = "d"
+		case "network":
+			c = "n"
+		}
+		if len(c) > 0 && !strings.Contains(order, c) {
+			order += c
+		}
+	}
+	if len(order) == 0 {
+		return "cdn"
+	}
+	return order
+}
+
+func (instance *SInstance) GetVga() string {
+	return "std"
+}
+
+func (instance *SInstance) GetVdi() string {
+	if len(instance.Display.Type) > 0 {
+		return instance.Display.Type
+	}
+	return "vnc"
+}
+
+func (instance *SInstance) GetOSType() string {
+	if strings.Contains(strings.ToLower(instance.Os.Type), "windows") {
+		return osprofile.OS_TYPE_WINDOWS
+	}
+	return osprofile.OS_TYPE_LINUX
+}
+
+func (instance *SInstance) GetOSName() string {
+	return instance.Os.Type
+}
+
+func (instance *SInstance) GetBios() string {
+	if strings.Contains(instance.Bios.Type, "ovmf") {
+		return "UEFI"
+	}
+	return "BIOS"
+}
+
+func (instance *SInstance) GetMachine() string {
+	if strings.HasPrefix(instance.Bios.Type, "q35") {
+		return "q35"
+	}
+	return "pc"
+}
+
+func (instance *SInstance) GetStatus() string {
+	switch instance.Status {
+	case "up":
+		return api.VM_RUNNING
+	case "down":
+		return api.VM_READY
+	case "powering_up", "wait_for_launch", "reboot_in_progress":
+		return api.VM_STARTING
+	case "powering_down":
+		return api.VM_STOPPING
+	case "suspended", "paused", "saving_state", "restoring_state":
+		return api.VM_SUSPEND
+	case "migrating":
+		return api.VM_MIGRATING
+	case "image_locked":
+		return api.VM_DEPLOYING
+	default:
+		log.Errorf("Unknown instance %s status %s", instance.Name, instance.Status)
+		return api.VM_UNKNOWN
+	}
+}
+
+func (instance *SInstance) Refresh() error {
+	newInstance, err := instance.getRegion().getInstance(instance.Id)
+	if err != nil {
+		return err
+	}
+	// 列表字段在JSON更新时不会被清空
+	instance.DiskAttachments = newInstance.DiskAttachments
+	instance.Nics = newInstance.Nics
+	instance.ReportedDevices = newInstance.ReportedDevices
+	return jsonutils.Update(instance, newInstance)
+}
+
+func (instance *SInstance) GetHypervisor() string {
+	return api.HYPERVISOR_OVIRT
+}
+
+func (instance *SInstance) GetSecurityGroupIds() ([]string, error) {
+	return []string{}, nil
+}
+
+func (instance *SInstance) AssignSecurityGroup(secgroupId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) SetSecurityGroups(secgroupIds []string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) StartVM(vmId string, params map[string]interface{}) error {
+	return region.client.action(fmt.Sprintf("/vms/%s", vmId), "start", params)
+}
+
+func (region *SRegion) StopVM(vmId string, isForce bool) error {
+	action := "shutdown"
+	if isForce {
+		action = "stop"
+	}
+	return region.client.action(fmt.Sprintf("/vms/%s", vmId), action, nil)
+}
+
+func (region *SRegion) DeleteVM(vmId string) error {
+	return region.client.delete(fmt.Sprintf("/vms/%s", vmId))
+}
+
+func (region *SRegion) UpdateVM(vmId string, params map[string]interface{}) error {
+	_, err := region.client.put(fmt.Sprintf("/vms/%s", vmId), map[string]interface{}{"vm": params})
+	return err
+}
+
+func (instance *SInstance) StartVM(ctx context.Context) error {
+	err := instance.getRegion().StartVM(instance.Id, nil)
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_RUNNING, instance.getRegion().client.pollInterval, StatusTimeout)
+}
+
+func (instance *SInstance) StopVM(ctx context.Context, opts *cloudprovider.ServerStopOptions) error {
+	err := instance.getRegion().StopVM(instance.Id, opts.IsForce)
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_READY, instance.getRegion().client.pollInterval, StatusTimeout)
+}
+
+func (instance *SInstance) DeleteVM(ctx context.Context) error {
+	if instance.Status != "down" {
+		err := instance.StopVM(ctx, &cloudprovider.ServerStopOptions{IsForce: true})
+		if err != nil {
+			return errors.Wrapf(err, "StopVM")
+		}
+	}
+	return instance.getRegion().DeleteVM(instance.Id)
+}
+
+func (instance *SInstance) UpdateVM(ctx context.Context, name string) error {
+	return instance.getRegion().UpdateVM(instance.Id, map[string]interface{}{"name": name})
+}
+
+func (instance *SInstance) UpdateUserData(userData string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func initialization(hostname, username, password, publicKey string) map[string]interface{} {
+	init := map[string]interface{}{}
+	if len(hostname) > 0 {
+		init["host_name"] = hostname
+	}
+	if len(username) > 0 {
+		init["user_name"] = username
+	}
+	if len(password) > 0 {
+		init["root_password"] = password
+	}
+	if len(publicKey) > 0 {
+		init["authorized_ssh_keys"] = publicKey
+	}
+	return init
+}
+
+// 通过cloud-init设置登录信息, 在虚拟机下次以cloud-init方式启动时生效
+func (instance *SInstance) DeployVM(ctx context.Context, name string, username string, password string, publicKey string, deleteKeypair bool, description string) error {
+	params := map[string]interface{}{}
+	if len(name) > 0 && name != instance.Name {
+		params["name"] = name
+	}
+	if len(description) > 0 {
+		params["description"] = description
+	}
+	init := initialization("", username, password, publicKey)
+	if len(publicKey) == 0 && deleteKeypair {
+		init["authorized_ssh_keys"] = ""
+	}
+	if len(init) > 0 {
+		params["initialization"] = init
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return instance.getRegion().UpdateVM(instance.Id, params)
+}
+
+func (instance *SInstance) RebuildRoot(ctx context.Context, desc *cloudprovider.SManagedVMRebuildRootConfig) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) ChangeConfig(ctx context.Context, config *cloudprovider.SManagedVMChangeConfig) error {
+	params := map[string]interface{}{}
+	if config.Cpu > 0 {
+		params["cpu"] = map[string]interface{}{
+			"topology": map[string]interface{}{"sockets": config.Cpu, "cores": 1, "threads": 1},
+		}
+	}
+	if config.MemoryMB > 0 {
+		memory := int64(config.MemoryMB) * 1024 * 1024
+		params["memory"] = memory
+		params["memory_policy"] = map[string]interface{}{"guaranteed": memory}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return instance.getRegion().UpdateVM(instance.Id, params)
+}
+
+func (instance *SInstance) GetVNCInfo() (jsonutils.JSONObject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) AttachDisk(vmId string, diskId string, bootable bool) error {
+	params := map[string]interface{}{
+		"disk_attachment": map[string]interface{}{
+			"disk":      map[string]string{"id": diskId},
+			"interface": "virtio_scsi",
+			"active":    true,
+			"bootable":  bootable,
+		},
+	}
+	_, err := region.client.post(fmt.Sprintf("/vms/%s/diskattachments", vmId), params)
+	return err
+}
+
+// 仅解除挂载, 不删除磁盘
+func (region *SRegion) DetachDisk(vmId string, diskId string) error {
+	return region.client.delete(fmt.Sprintf("/vms/%s/diskattachments/%s?detach_only=true", vmId, diskId))
+}
+
+func (instance *SInstance) AttachDisk(ctx context.Context, diskId string) error {
+	err := instance.getRegion().AttachDisk(instance.Id, diskId, false)
+	if err != nil {
+		return err
+	}
+	return instance.getRegion().waitDiskReady(diskId)
+}
+
+func (instance *SInstance) DetachDisk(ctx context.Context, diskId string) error {
+	err := instance.getRegion().DetachDisk(instance.Id, diskId)
+	if err != nil {
+		if errors.Cause(err) == cloudprovider.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (instance *SInstance) CreateDisk(ctx context.Context, sizeMb int, uuid string, driver string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) Renew(bc billing.SBillingCycle) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) GetProjectId() string {
+	return ""
+}
+
+func (instance *SInstance) GetError() error {
+	return nil
+}
+
+func (region *SRegion) GetTemplateDiskAttachments(templateId string) ([]SDiskAttachment, error) {
+	attachments := []SDiskAttachment{}
+	err := region.client.get(fmt.Sprintf("/templates/%s/diskattachments", templateId), "disk_attachment", &attachments)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (region *SRegion) waitVMStatus(vmId string, status string) error {
+	return cloudprovider.Wait(region.client.pollInterval, StatusTimeout, func() (bool, error) {
+		instance := SInstance{}
+		err := region.client.get(fmt.Sprintf("/vms/%s", vmId), "", &instance)
+		if err != nil {
+			return false, err
+		}
+		return instance.Status == status, nil
+	})
+}
+
+// 指定镜像时从模板克隆, 磁盘放置在系统盘所在存储域; 否则基于Blank模板创建并新建系统盘
+// 之后添加数据盘与网卡, 并以cloud-init方式开机注入登录信息及静态IP
+func (region *SRegion) CreateVM(host *SHost, desc *cloudprovider.SManagedVMCreateConfig) (string, error) {
+	memory := int64(desc.MemoryMB) * 1024 * 1024
+	vm := map[string]interface{}{
+		"name":        desc.Name,
+		"description": desc.Description,
+		"cluster":     map[string]string{"id": host.Cluster.Id},
+		"memory":      memory,
+		"memory_policy": map[string]interface{}{
+			"guaranteed": memory,
+		},
+		"cpu": map[string]interface{}{
+			"topology": map[string]interface{}{"sockets": desc.Cpu, "cores": 1, "threads": 1},
+		},
+		"placement_policy": map[string]interface{}{
+			"affinity": "migratable",
+			"hosts":    map[string]interface{}{"host": []map[string]string{{"id": host.Id}}},
+		},
+	}
+	if desc.OsType == osprofile.OS_TYPE_WINDOWS {
+		vm["os"] = map[string]string{"type": "windows_2016x64"}
+	} else {
+		vm["os"] = map[string]string{"type": "other_linux"}
+	}
+	path := "/vms"
+	if len(desc.ExternalImageId) > 0 {
+		vm["template"] = map[string]string{"id": desc.ExternalImageId}
+		attachments, err := region.GetTemplateDiskAttachments(desc.ExternalImageId)
+		if err != nil {
+			return "", errors.Wrapf(err, "GetTemplateDiskAttachments(%s)", desc.ExternalImageId)
+		}
+		disks := []map[string]interface{}{}
+		for _, attachment := range attachments {
+			disks = append(disks, map[string]interface{}{
+				"disk": map[string]interface{}{
+					"id":     attachment.Id,
+					"format": "cow",
+					"storage_domains": map[string]interface{}{
+						"storage_domain": []map[string]string{{"id": desc.SysDisk.StorageExternalId}},
+					},
+				},
+			})
+		}
+		vm["disk_attachments"] = map[string]interface{}{"disk_attachment": disks}
+		path += "?clone=true"
+	} else {
+		vm["template"] = map[string]string{"id": OVIRT_BLANK_TEMPLATE_ID}
+	}
+	resp, err := region.client.post(path, map[string]interface{}{"vm": vm})
+	if err != nil {
+		return "", errors.Wrapf(err, "create vm")
+	}
+	vmId, err := resp.GetString("id")
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid create vm response %s", resp)
+	}
+
+	cleanup := func() {
+		if err := region.DeleteVM(vmId); err != nil {
+			log.Errorf("clean vm %s error: %v", vmId, err)
+		}
+	}
+
+	// 克隆模板磁盘时虚拟机处于image_locked状态
+	err = region.waitVMStatus(vmId, "down")
+	if err != nil {
+		defer cleanup()
+		return "", errors.Wrapf(err, "wait vm %s down", vmId)
+	}
+
+	if len(desc.ExternalImageId) == 0 {
+		err = region.addVMDisk(vmId, desc.SysDisk, fmt.Sprintf("%s-sys", desc.Name), true)
+		if err != nil {
+			defer cleanup()
+			return "", errors.Wrapf(err, "add sys disk")
+		}
+	} else if desc.SysDisk.SizeGB > 0 {
+		instance, err := region.getInstance(vmId)
+		if err != nil {
+			defer cleanup()
+			return "", errors.Wrapf(err, "getInstance(%s)", vmId)
+		}
+		for _, disk := range instance.GetDisks() {
+			if disk.Bootable && disk.GetDiskSizeMB() < desc.SysDisk.SizeGB*1024 {
+				err = region.ResizeDisk(vmId, disk.Id, int64(desc.SysDisk.SizeGB)*1024)
+				if err != nil {
+					log.Warningf("failed to resize system disk of vm %s error: %v", vmId, err)
+				}
+			}
+		}
+	}
+	for i, disk := range desc.DataDisks {
+		if len(disk.StorageExternalId) == 0 {
+			disk.StorageExternalId = desc.SysDisk.StorageExternalId
+		}
+		err = region.addVMDisk(vmId, disk, fmt.Sprintf("%s-data-%d", desc.Name, i), false)
+		if err != nil {
+			defer cleanup()
+			return "", errors.Wrapf(err, "add data disk %d", i)
+		}
+	}
+
+	init := initialization(desc.Name, desc.Account, desc.Password, desc.PublicKey)
+	if len(desc.ExternalNetworkId) > 0 {
+		network, err := region.GetNetwork(desc.ExternalNetworkId)
+		if err != nil {
+			defer cleanup()
+			return "", errors.Wrapf(err, "GetNetwork(%s)", desc.ExternalNetworkId)
+		}
+		err = region.addVMNic(vmId, network.wire.Id)
+		if err != nil {
+			defer cleanup()
+			return "", errors.Wrapf(err, "add nic")
+		}
+		if len(desc.IpAddr) > 0 {
+			init["nic_configurations"] = map[string]interface{}{
+				"nic_configuration": []map[string]interface{}{network.nicConfiguration("eth0", desc.IpAddr)},
+			}
+		}
+	}
+
+	params := map[string]interface{}{
+		"use_cloud_init": true,
+		"vm":             map[string]interface{}{"initialization": init},
+	}
+	err = region.StartVM(vmId, params)
+	if err != nil {
+		return "", errors.Wrapf(err, "StartVM")
+	}
+	return vmId, nil
+}
+
+func (region *SRegion) addVMDisk(vmId string, disk cloudprovider.SDiskInfo, name string, bootable bool) error {
+	if len(disk.Name) > 0 {
+		name = disk.Name
+	}
+	params := map[string]interface{}{
+		"disk_attachment": map[string]interface{}{
+			"disk":      region.diskParams(disk.StorageExternalId, name, disk.SizeGB, ""),
+			"interface": "virtio_scsi",
+			"active":    true,
+			"bootable":  bootable,
+		},
+	}
+	resp, err := region.client.post(fmt.Sprintf("/vms/%s/diskattachments", vmId), params)
+	if err != nil {
+		return err
+	}
+	diskId, err := resp.GetString("id")
+	if err != nil {
+		return errors.Wrapf(err, "invalid disk attachment response %s", resp)
+	}
+	return region.waitDiskReady(diskId)
+}
+
+func (region *SRegion) addVMNic(vmId string, networkId string) error {
+	profile, err := region.getVnicProfileByNetwork(networkId)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"nic": map[string]interface{}{
+			"name":         "nic1",
+			"interface":    "virtio",
+			"vnic_profile": map[string]string{"id": profile.Id},
+		},
+	}
+	_, err = region.client.post(fmt.Sprintf("/vms/%s/nics", vmId), params)
+	return err
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"strings"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+// 网卡通过vNIC配置集关联逻辑网络, IP地址需guest agent上报
+type SInstanceNic struct {
+	instance *SInstance
+
+	Id        string
+	Name      string
+	Interface string
+	Linked    bool
+	Plugged   bool
+	Mac       struct {
+		Address string
+	}
+	VnicProfile SRef
+
+	cloudprovider.DummyICloudNic
+}
+
+func (nic *SInstanceNic) GetId() string {
+	return nic.Id
+}
+
+func (nic *SInstanceNic) GetIP() string {
+	for _, dev := range nic.instance.ReportedDevices.ReportedDevice {
+		if !strings.EqualFold(dev.Mac.Address, nic.Mac.Address) {
+			continue
+		}
+		for _, ip := range dev.Ips.Ip {
+			if ip.Version == "v4" {
+				return ip.Address
+			}
+		}
+	}
+	return ""
+}
+
+func (nic *SInstanceNic) GetMAC() string {
+	return strings.ToLower(nic.Mac.Address)
+}
+
+func (nic *SInstanceNic) GetDriver() string {
+	return nic.Interface
+}
+
+func (nic *SInstanceNic) InClassicNetwork() bool {
+	return false
+}
+
+func (nic *SInstanceNic) GetINetwork() cloudprovider.ICloudNetwork {
+	if len(nic.VnicProfile.Id) == 0 {
+		return nil
+	}
+	region := nic.instance.getRegion()
+	profile, err := region.GetVnicProfile(nic.VnicProfile.Id)
+	if err != nil {
+		log.Errorf("failed to get vnic profile %s for nic %s error: %v", nic.VnicProfile.Id, nic.GetMAC(), err)
+		return nil
+	}
+	wire, err := region.GetWire(profile.Network.Id)
+	if err != nil {
+		log.Errorf("failed to get wire %s for nic %s error: %v", profile.Network.Id, nic.GetMAC(), err)
+		return nil
+	}
+	ip := nic.GetIP()
+	for i := range wire.networks {
+		if len(ip) == 0 || wire.networks[i].Contains(ip) {
+			return &wire.networks[i]
+		}
+	}
+	return nil
+}
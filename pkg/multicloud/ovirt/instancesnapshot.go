@@ -0,0 +1,176 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SInstanceSnapshot struct {
+	multicloud.SVirtualResourceBase
+	instance *SInstance
+
+	Id                 string
+	Description        string
+	SnapshotType       string
+	SnapshotStatus     string
+	Date               int64
+	PersistMemorystate bool
+}
+
+func (region *SRegion) GetInstanceSnapshots(vmId string) ([]SInstanceSnapshot, error) {
+	snapshots := []SInstanceSnapshot{}
+	err := region.client.get(fmt.Sprintf("/vms/%s/snapshots", vmId), "snapshot", &snapshots)
+	if err != nil {
+		return nil, err
+	}
+	ret := []SInstanceSnapshot{}
+	for i := range snapshots {
+		// active表示虚拟机当前状态, 不是真正的快照
+		if snapshots[i].SnapshotType != "active" {
+			ret = append(ret, snapshots[i])
+		}
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetInstanceSnapshot(vmId string, snapshotId string) (*SInstanceSnapshot, error) {
+	snapshot := &SInstanceSnapshot{}
+	err := region.client.get(fmt.Sprintf("/vms/%s/snapshots/%s", vmId, snapshotId), "", snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// oVirt快照只有描述没有名称
+func (region *SRegion) CreateInstanceSnapshot(vmId string, name string) (*SInstanceSnapshot, error) {
+	params := map[string]interface{}{
+		"snapshot": map[string]interface{}{
+			"description":         name,
+			"persist_memorystate": false,
+		},
+	}
+	resp, err := region.client.post(fmt.Sprintf("/vms/%s/snapshots", vmId), params)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &SInstanceSnapshot{}
+	err = resp.Unmarshal(snapshot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resp.Unmarshal")
+	}
+	return snapshot, nil
+}
+
+func (region *SRegion) DeleteInstanceSnapshot(vmId string, snapshotId string) error {
+	return region.client.delete(fmt.Sprintf("/vms/%s/snapshots/%s", vmId, snapshotId))
+}
+
+func (region *SRegion) RestoreInstanceSnapshot(vmId string, snapshotId string) error {
+	params := map[string]interface{}{"restore_memory": false}
+	return region.client.action(fmt.Sprintf("/vms/%s/snapshots/%s", vmId, snapshotId), "restore", params)
+}
+
+func (instance *SInstance) GetInstanceSnapshots() ([]cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshots, err := instance.getRegion().GetInstanceSnapshots(instance.Id)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudInstanceSnapshot{}
+	for i := range snapshots {
+		snapshots[i].instance = instance
+		ret = append(ret, &snapshots[i])
+	}
+	return ret, nil
+}
+
+func (instance *SInstance) GetInstanceSnapshot(idStr string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshot, err := instance.getRegion().GetInstanceSnapshot(instance.Id, idStr)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.instance = instance
+	return snapshot, nil
+}
+
+func (instance *SInstance) CreateInstanceSnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshot, err := instance.getRegion().CreateInstanceSnapshot(instance.Id, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CreateInstanceSnapshot")
+	}
+	snapshot.instance = instance
+	err = cloudprovider.WaitStatus(snapshot, api.INSTANCE_SNAPSHOT_READY, instance.getRegion().client.pollInterval, StatusTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "wait snapshot ready")
+	}
+	return snapshot, nil
+}
+
+// 恢复快照前虚拟机需处于关机状态
+func (instance *SInstance) ResetToInstanceSnapshot(ctx context.Context, idStr string) error {
+	return instance.getRegion().RestoreInstanceSnapshot(instance.Id, idStr)
+}
+
+func (snapshot *SInstanceSnapshot) GetId() string {
+	return snapshot.Id
+}
+
+func (snapshot *SInstanceSnapshot) GetName() string {
+	return snapshot.Description
+}
+
+func (snapshot *SInstanceSnapshot) GetGlobalId() string {
+	return snapshot.Id
+}
+
+func (snapshot *SInstanceSnapshot) GetStatus() string {
+	switch snapshot.SnapshotStatus {
+	case "ok":
+		return api.INSTANCE_SNAPSHOT_READY
+	case "locked":
+		return api.SNAPSHOT_CREATING
+	default:
+		return api.INSTANCE_SNAPSHOT_UNKNOWN
+	}
+}
+
+func (snapshot *SInstanceSnapshot) Refresh() error {
+	newSnapshot, err := snapshot.instance.getRegion().GetInstanceSnapshot(snapshot.instance.Id, snapshot.Id)
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(snapshot, newSnapshot)
+}
+
+func (snapshot *SInstanceSnapshot) IsEmulated() bool {
+	return false
+}
+
+func (snapshot *SInstanceSnapshot) GetDescription() string {
+	return snapshot.Description
+}
+
+func (snapshot *SInstanceSnapshot) Delete() error {
+	return snapshot.instance.getRegion().DeleteInstanceSnapshot(snapshot.instance.Id, snapshot.Id)
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SNetwork struct {
+	multicloud.SResourceBase
+	wire *SWire
+
+	Gateway string
+	MaskLen int8
+
+	netAddr netutils.IPV4Addr
+	// 各主机在该网段上的地址
+	addrs   []netutils.IPV4Addr
+	ipRange netutils.IPV4AddrRange
+}
+
+// 可分配地址从网关及主机地址之后开始, 避免与其冲突
+func (network *SNetwork) fixRange() {
+	start := network.netAddr.StepUp()
+	end := network.netAddr.BroadcastAddr(network.MaskLen).StepDown()
+	used := append([]netutils.IPV4Addr{}, network.addrs...)
+	if gw, err := netutils.NewIPV4Addr(network.Gateway); err == nil {
+		used = append(used, gw)
+	}
+	for _, addr := range used {
+		if addr >= start && addr < end {
+			start = addr.StepUp()
+		}
+	}
+	network.ipRange = netutils.NewIPV4AddrRange(start, end)
+}
+
+// cloud-init静态地址配置
+func (network *SNetwork) nicConfiguration(name string, ipAddr string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          name,
+		"on_boot":       true,
+		"boot_protocol": "static",
+		"ip": map[string]string{
+			"address": ipAddr,
+			"netmask": netutils.Masklen2Mask(network.MaskLen).String(),
+			"gateway": network.GetGateway(),
+		},
+	}
+}
+
+func (network *SNetwork) GetId() string {
+	return fmt.Sprintf("%s/%s/%d", network.wire.Id, network.netAddr.String(), network.MaskLen)
+}
+
+func (network *SNetwork) GetName() string {
+	return fmt.Sprintf("%s-%s", network.wire.Name, network.netAddr.String())
+}
+
+func (network *SNetwork) GetGlobalId() string {
+	return network.GetId()
+}
+
+func (network *SNetwork) IsEmulated() bool {
+	return false
+}
+
+func (network *SNetwork) GetStatus() string {
+	return api.NETWORK_STATUS_AVAILABLE
+}
+
+func (network *SNetwork) Refresh() error {
+	return nil
+}
+
+func (network *SNetwork) Delete() error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (network *SNetwork) GetIWire() cloudprovider.ICloudWire {
+	return network.wire
+}
+
+func (network *SNetwork) GetAllocTimeoutSeconds() int {
+	return 120 // 2 minutes
+}
+
+func (network *SNetwork) GetGateway() string {
+	if len(network.Gateway) > 0 {
+		return network.Gateway
+	}
+	return network.netAddr.StepUp().String()
+}
+
+func (network *SNetwork) GetIpStart() string {
+	return network.ipRange.StartIp().String()
+}
+
+func (network *SNetwork) GetIpEnd() string {
+	return network.ipRange.EndIp().String()
+}
+
+func (network *SNetwork) GetIpMask() int8 {
+	return network.MaskLen
+}
+
+func (network *SNetwork) Contains(ipAddr string) bool {
+	ip, err := netutils.NewIPV4Addr(ipAddr)
+	if err != nil {
+		return false
+	}
+	return ip.NetAddr(network.MaskLen) == network.netAddr
+}
+
+func (network *SNetwork) GetIsPublic() bool {
+	return true
+}
+
+func (network *SNetwork) GetPublicScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (network *SNetwork) GetServerType() string {
+	return api.NETWORK_TYPE_GUEST
+}
+
+func (network *SNetwork) GetProjectId() string {
+	return ""
+}
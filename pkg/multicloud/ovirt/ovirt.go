@@ -0,0 +1,246 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	CLOUD_PROVIDER_OVIRT = api.CLOUD_PROVIDER_OVIRT
+	OVIRT_DEFAULT_REGION = "oVirt"
+	OVIRT_API_PREFIX     = "/ovirt-engine/api"
+
+	// Blank模板为oVirt内置空模板, 不作为镜像同步
+	OVIRT_BLANK_TEMPLATE_ID = "00000000-0000-0000-0000-000000000000"
+
+	// 虚拟机及磁盘异步操作的默认状态轮询间隔
+	DEFAULT_STATUS_POLL_INTERVAL = 5 * time.Second
+)
+
+var (
+	// 虚拟机及磁盘异步操作的超时时间
+	StatusTimeout = 30 * time.Minute
+)
+
+type OVirtClientConfig struct {
+	cpcfg cloudprovider.ProviderConfig
+
+	authURL  string
+	username string
+	password string
+
+	pollInterval time.Duration
+
+	debug bool
+}
+
+// authURL可为engine地址, 也可为完整的 https://engine/ovirt-engine/api
+func NewOVirtClientConfig(authURL, username, password string) *OVirtClientConfig {
+	authURL = strings.TrimSuffix(authURL, "/")
+	if !strings.HasSuffix(authURL, OVIRT_API_PREFIX) {
+		authURL += OVIRT_API_PREFIX
+	}
+	cfg := &OVirtClientConfig{
+		authURL:      authURL,
+		username:     username,
+		password:     password,
+		pollInterval: DEFAULT_STATUS_POLL_INTERVAL,
+	}
+	return cfg
+}
+
+func (cfg *OVirtClientConfig) CloudproviderConfig(cpcfg cloudprovider.ProviderConfig) *OVirtClientConfig {
+	cfg.cpcfg = cpcfg
+	return cfg
+}
+
+func (cfg *OVirtClientConfig) Debug(debug bool) *OVirtClientConfig {
+	cfg.debug = debug
+	return cfg
+}
+
+// PollInterval 设置异步操作状态轮询间隔
+func (cfg *OVirtClientConfig) PollInterval(interval time.Duration) *OVirtClientConfig {
+	cfg.pollInterval = interval
+	return cfg
+}
+
+type SOVirtClient struct {
+	*OVirtClientConfig
+
+	httpClient *http.Client
+
+	iregions []cloudprovider.ICloudRegion
+}
+
+type SProductInfo struct {
+	Name    string
+	Vendor  string
+	Version struct {
+		FullVersion string
+		Major       int
+		Minor       int
+	}
+}
+
+func NewOVirtClient(cfg *OVirtClientConfig) (*SOVirtClient, error) {
+	cli := &SOVirtClient{
+		OVirtClientConfig: cfg,
+		httpClient:        cfg.cpcfg.AdaptiveTimeoutHttpClient(),
+	}
+	_, err := cli.GetProductInfo()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetProductInfo")
+	}
+	cli.iregions = []cloudprovider.ICloudRegion{&SRegion{client: cli}}
+	return cli, nil
+}
+
+func (cli *SOVirtClient) GetProductInfo() (*SProductInfo, error) {
+	info := &SProductInfo{}
+	return info, cli.get("", "product_info", info)
+}
+
+func (cli *SOVirtClient) GetCloudRegionExternalIdPrefix() string {
+	return fmt.Sprintf("%s/%s", CLOUD_PROVIDER_OVIRT, cli.cpcfg.Id)
+}
+
+func (cli *SOVirtClient) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	subAccount := cloudprovider.SSubAccount{
+		Account:      cli.username,
+		Name:         cli.cpcfg.Name,
+		HealthStatus: api.CLOUD_PROVIDER_HEALTH_NORMAL,
+	}
+	return []cloudprovider.SSubAccount{subAccount}, nil
+}
+
+func (cli *SOVirtClient) GetAccountId() string {
+	u, err := url.Parse(cli.authURL)
+	if err != nil {
+		return cli.authURL
+	}
+	return u.Host
+}
+
+func (cli *SOVirtClient) GetIRegions() []cloudprovider.ICloudRegion {
+	return cli.iregions
+}
+
+func (cli *SOVirtClient) GetRegion(regionId string) *SRegion {
+	if len(regionId) == 0 {
+		regionId = OVIRT_DEFAULT_REGION
+	}
+	for i := 0; i < len(cli.iregions); i++ {
+		if cli.iregions[i].GetId() == regionId {
+			return cli.iregions[i].(*SRegion)
+		}
+	}
+	return nil
+}
+
+func (cli *SOVirtClient) GetIRegionById(id string) (cloudprovider.ICloudRegion, error) {
+	for i := 0; i < len(cli.iregions); i++ {
+		if cli.iregions[i].GetGlobalId() == id {
+			return cli.iregions[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (cli *SOVirtClient) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (cli *SOVirtClient) GetCapabilities() []string {
+	caps := []string{
+		cloudprovider.CLOUD_CAPABILITY_COMPUTE,
+		cloudprovider.CLOUD_CAPABILITY_NETWORK,
+	}
+	return caps
+}
+
+func (cli *SOVirtClient) jsonRequest(method httputils.THttpMethod, path string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Version", "4")
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cli.username+":"+cli.password)))
+	_, resp, err := httputils.JSONRequest(cli.httpClient, context.Background(), method, cli.authURL+path, header, params, cli.debug)
+	if err != nil {
+		if e, ok := err.(*httputils.JSONClientError); ok && e.Code == 404 {
+			return nil, errors.Wrapf(cloudprovider.ErrNotFound, "%s %s", method, path)
+		}
+		return nil, errors.Wrapf(err, "%s %s", method, path)
+	}
+	if resp == nil {
+		resp = jsonutils.NewDict()
+	}
+	return resp, nil
+}
+
+// oVirt的列表接口以资源名为键返回数组, 列表为空时不包含该键; key为空时解析整个响应
+func (cli *SOVirtClient) get(path string, key string, retVal interface{}) error {
+	resp, err := cli.jsonRequest(httputils.GET, path, nil)
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return resp.Unmarshal(retVal)
+	}
+	if !resp.Contains(key) {
+		return nil
+	}
+	return resp.Unmarshal(retVal, key)
+}
+
+func (cli *SOVirtClient) post(path string, params interface{}) (jsonutils.JSONObject, error) {
+	return cli.jsonRequest(httputils.POST, path, jsonutils.Marshal(params))
+}
+
+func (cli *SOVirtClient) put(path string, params interface{}) (jsonutils.JSONObject, error) {
+	return cli.jsonRequest(httputils.PUT, path, jsonutils.Marshal(params))
+}
+
+func (cli *SOVirtClient) delete(path string) error {
+	_, err := cli.jsonRequest(httputils.DELETE, path, nil)
+	return err
+}
+
+// 执行虚拟机等资源上的动作, 如 /vms/{id}/start
+func (cli *SOVirtClient) action(path string, action string, params map[string]interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	_, err := cli.post(fmt.Sprintf("%s/%s", path, action), params)
+	return err
+}
+
+type SRef struct {
+	Id   string
+	Href string
+}
@@ -0,0 +1,226 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/util/osprofile"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/test/replay"
+)
+
+// 请求需带有Basic认证及API版本头
+func checkAuth(r *http.Request) bool {
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin@internal:password"))
+	return r.Header.Get("Authorization") == auth && r.Header.Get("Version") == "4"
+}
+
+func newTestRegion(t *testing.T) (*SRegion, func()) {
+	srv := replay.NewReplayServer(t, replay.SReplayConfig{
+		ApiPrefix:      OVIRT_API_PREFIX,
+		NotFoundFormat: `{"detail":"%s not found","reason":"Operation Failed"}`,
+		Auth:           checkAuth,
+	})
+	cfg := NewOVirtClientConfig(srv.URL, "admin@internal", "password").PollInterval(time.Millisecond)
+	cli, err := NewOVirtClient(cfg)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("NewOVirtClient: %v", err)
+	}
+	return cli.GetRegion(""), srv.Close
+}
+
+func TestZonesHostsAndStorages(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	izones, err := region.GetIZones()
+	if err != nil {
+		t.Fatalf("GetIZones: %v", err)
+	}
+	if len(izones) != 1 || izones[0].GetId() != "dc-1" || izones[0].GetStatus() != api.ZONE_ENABLE {
+		t.Fatalf("unexpected zones %v", izones)
+	}
+	ihost, err := izones[0].GetIHostById("h-1")
+	if err != nil {
+		t.Fatalf("GetIHostById: %v", err)
+	}
+	host := ihost.(*SHost)
+	if host.GetAccessIp() != "192.168.20.11" || host.GetCpuCount() != 40 || host.GetMemSizeMB() != 128*1024 {
+		t.Errorf("unexpected host01: ip %s cpu %d mem %d", host.GetAccessIp(), host.GetCpuCount(), host.GetMemSizeMB())
+	}
+
+	istorages, err := host.GetIStorages()
+	if err != nil {
+		t.Fatalf("GetIStorages: %v", err)
+	}
+	// iso域不作为存储同步
+	if len(istorages) != 2 {
+		t.Fatalf("expect 2 data storages, got %d", len(istorages))
+	}
+	storage := istorages[1].(*SStorage)
+	if storage.GetStorageType() != api.STORAGE_OVIRT_ISCSI || storage.GetCapacityMB() != 500*1024 || storage.GetCapacityUsedMB() != 100*1024 {
+		t.Errorf("unexpected storage %s: type %s capacity %d used %d", storage.Name, storage.GetStorageType(), storage.GetCapacityMB(), storage.GetCapacityUsedMB())
+	}
+
+	disks, err := region.GetDisks("sd-1")
+	if err != nil {
+		t.Fatalf("GetDisks: %v", err)
+	}
+	attached := map[string]string{}
+	for i := range disks {
+		attached[disks[i].GetName()] = disks[i].VmId + "/" + disks[i].GetDiskType()
+	}
+	if attached["web01_Disk1"] != "vm-1/sys" || attached["db01_Disk1"] != "vm-2/sys" || attached["spare"] != "/data" {
+		t.Errorf("unexpected disk attachments %v", attached)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	host, err := region.GetHost("h-2")
+	if err != nil {
+		t.Fatalf("GetHost: %v", err)
+	}
+	ivms, err := host.GetIVMs()
+	if err != nil {
+		t.Fatalf("GetIVMs: %v", err)
+	}
+	if len(ivms) != 1 || ivms[0].GetGlobalId() != "vm-1" {
+		t.Fatalf("host02 should only have web01, got %d vms", len(ivms))
+	}
+	vm := ivms[0].(*SInstance)
+	if vm.GetStatus() != api.VM_RUNNING || vm.GetVcpuCount() != 2 || vm.GetVmemSizeMB() != 4096 || vm.GetBios() != "UEFI" || vm.GetBootOrder() != "cn" {
+		t.Errorf("unexpected web01 status %s cpu %d mem %d bios %s boot %s", vm.GetStatus(), vm.GetVcpuCount(), vm.GetVmemSizeMB(), vm.GetBios(), vm.GetBootOrder())
+	}
+	idisks, err := vm.GetIDisks()
+	if err != nil {
+		t.Fatalf("GetIDisks: %v", err)
+	}
+	if len(idisks) != 2 || idisks[0].GetGlobalId() != "d-1" || idisks[0].GetDiskType() != api.DISK_TYPE_SYS || idisks[1].GetDiskSizeMB() != 100*1024 {
+		t.Errorf("unexpected web01 disks")
+	}
+	if idisks[1].GetIStorageId() != "sd-2" || idisks[1].GetDiskFormat() != "qcow2" {
+		t.Errorf("unexpected data disk storage %s format %s", idisks[1].GetIStorageId(), idisks[1].GetDiskFormat())
+	}
+	inics, err := vm.GetINics()
+	if err != nil {
+		t.Fatalf("GetINics: %v", err)
+	}
+	if len(inics) != 1 || inics[0].GetIP() != "192.168.30.21" || inics[0].GetMAC() != "56:6f:1a:2b:00:01" {
+		t.Fatalf("unexpected web01 nics")
+	}
+	network := inics[0].GetINetwork()
+	if network == nil || network.GetGlobalId() != "net-vm/192.168.30.0/24" {
+		t.Errorf("unexpected nic network %v", network)
+	}
+
+	// 关机的虚拟机归属到集群中按名称排序的第一台在线主机
+	instances, err := region.GetInstances("h-1")
+	if err != nil {
+		t.Fatalf("GetInstances: %v", err)
+	}
+	if len(instances) != 1 || instances[0].Name != "db01" || instances[0].GetStatus() != api.VM_READY || instances[0].GetOSType() != osprofile.OS_TYPE_WINDOWS {
+		t.Errorf("unexpected instances on host01 %v", instances)
+	}
+}
+
+func TestNetworks(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	wires, err := region.GetWires("dc-1")
+	if err != nil {
+		t.Fatalf("GetWires: %v", err)
+	}
+	// migration网络不承载虚拟机
+	if len(wires) != 2 {
+		t.Fatalf("expect 2 wires, got %d", len(wires))
+	}
+	network, err := region.GetNetwork("net-vm/192.168.30.0/24")
+	if err != nil {
+		t.Fatalf("GetNetwork: %v", err)
+	}
+	if network.GetGateway() != "192.168.30.1" || network.GetIpStart() != "192.168.30.13" || network.GetIpEnd() != "192.168.30.254" || network.GetIpMask() != 24 {
+		t.Errorf("unexpected network gateway %s range %s-%s mask %d", network.GetGateway(), network.GetIpStart(), network.GetIpEnd(), network.GetIpMask())
+	}
+	conf := network.nicConfiguration("eth0", "192.168.30.50")
+	if ip := conf["ip"].(map[string]string); ip["netmask"] != "255.255.255.0" || ip["gateway"] != "192.168.30.1" {
+		t.Errorf("unexpected nic configuration %v", conf)
+	}
+}
+
+func TestImages(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	images, err := region.getStoragecache().GetICloudImages()
+	if err != nil {
+		t.Fatalf("GetICloudImages: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("Blank template should be skipped, got %d images", len(images))
+	}
+	image := images[0]
+	if image.GetGlobalId() != "tpl-1" || image.GetMinOsDiskSizeGb() != 20 || !image.UEFI() || image.GetImageStatus() != cloudprovider.IMAGE_STATUS_ACTIVE {
+		t.Errorf("unexpected image %s size %d", image.GetGlobalId(), image.GetMinOsDiskSizeGb())
+	}
+}
+
+func TestSnapshotAndAttachDisk(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	instance, err := region.GetInstance("vm-1")
+	if err != nil {
+		t.Fatalf("GetInstance: %v", err)
+	}
+	if instance.GetIHostId() != "h-2" {
+		t.Errorf("web01 should be on host02, got %s", instance.GetIHostId())
+	}
+	snapshots, err := instance.GetInstanceSnapshots()
+	if err != nil {
+		t.Fatalf("GetInstanceSnapshots: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].GetName() != "before-upgrade" {
+		t.Errorf("active snapshot should be skipped")
+	}
+	snapshot, err := instance.CreateInstanceSnapshot(context.Background(), "nightly", "")
+	if err != nil {
+		t.Fatalf("CreateInstanceSnapshot: %v", err)
+	}
+	if snapshot.GetGlobalId() != "snap-2" || snapshot.GetStatus() != api.INSTANCE_SNAPSHOT_READY {
+		t.Errorf("unexpected snapshot %s status %s", snapshot.GetGlobalId(), snapshot.GetStatus())
+	}
+
+	err = instance.AttachDisk(context.Background(), "d-4")
+	if err != nil {
+		t.Fatalf("AttachDisk: %v", err)
+	}
+	// 卸载接口没有录制响应, 按磁盘已卸载处理
+	err = instance.DetachDisk(context.Background(), "d-4")
+	if err != nil {
+		t.Fatalf("DetachDisk: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+)
+
+type SOVirtProviderFactory struct {
+	cloudprovider.SPrivateCloudBaseProviderFactory
+}
+
+func (self *SOVirtProviderFactory) GetId() string {
+	return ovirt.CLOUD_PROVIDER_OVIRT
+}
+
+func (self *SOVirtProviderFactory) GetName() string {
+	return ovirt.CLOUD_PROVIDER_OVIRT
+}
+
+// 用户名需带认证域, 如 admin@internal
+func (self *SOVirtProviderFactory) ValidateCreateCloudaccountData(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	if len(input.AuthUrl) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "auth_url")
+	}
+	output.AccessUrl = input.AuthUrl
+	if len(input.Username) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "username")
+	}
+	if len(input.Password) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "password")
+	}
+	output.Account = input.Username
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SOVirtProviderFactory) ValidateUpdateCloudaccountCredential(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential, cloudaccount string) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	if len(input.Username) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "username")
+	}
+	if len(input.Password) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "password")
+	}
+	output.Account = input.Username
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SOVirtProviderFactory) GetProvider(cfg cloudprovider.ProviderConfig) (cloudprovider.ICloudProvider, error) {
+	client, err := ovirt.NewOVirtClient(
+		ovirt.NewOVirtClientConfig(
+			cfg.URL, cfg.Account, cfg.Secret,
+		).CloudproviderConfig(cfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &SOVirtProvider{
+		SClientBaseProvider: multicloud.NewClientBaseProvider(self, client),
+		client:              client,
+	}, nil
+}
+
+func (self *SOVirtProviderFactory) GetClientRC(info cloudprovider.SProviderInfo) (map[string]string, error) {
+	return map[string]string{
+		"OVIRT_AUTH_URL":  info.Url,
+		"OVIRT_USERNAME":  info.Account,
+		"OVIRT_PASSWORD":  info.Secret,
+		"OVIRT_REGION_ID": ovirt.OVIRT_DEFAULT_REGION,
+	}, nil
+}
+
+func init() {
+	factory := SOVirtProviderFactory{}
+	cloudprovider.RegisterFactory(&factory)
+}
+
+type SOVirtProvider struct {
+	multicloud.SClientBaseProvider
+	client *ovirt.SOVirtClient
+}
+
+func (self *SOVirtProvider) GetVersion() string {
+	info, err := self.client.GetProductInfo()
+	if err != nil {
+		return ""
+	}
+	return info.Version.FullVersion
+}
+
+func (self *SOVirtProvider) GetSysInfo() (jsonutils.JSONObject, error) {
+	info, err := self.client.GetProductInfo()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetProductInfo")
+	}
+	return jsonutils.Marshal(info), nil
+}
@@ -0,0 +1,313 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SRegion struct {
+	multicloud.SRegion
+	multicloud.SNoObjectStorageRegion
+
+	client *SOVirtClient
+
+	izones []cloudprovider.ICloudZone
+	ivpcs  []cloudprovider.ICloudVpc
+}
+
+func (region *SRegion) GetClient() *SOVirtClient {
+	return region.client
+}
+
+func (region *SRegion) GetId() string {
+	return OVIRT_DEFAULT_REGION
+}
+
+func (region *SRegion) GetName() string {
+	return region.client.cpcfg.Name
+}
+
+func (region *SRegion) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(region.GetName()).CN(region.GetName())
+	return table
+}
+
+func (region *SRegion) GetGlobalId() string {
+	return fmt.Sprintf("%s/%s", CLOUD_PROVIDER_OVIRT, region.client.cpcfg.Id)
+}
+
+func (region *SRegion) IsEmulated() bool {
+	return false
+}
+
+func (region *SRegion) GetProvider() string {
+	return CLOUD_PROVIDER_OVIRT
+}
+
+func (region *SRegion) GetCloudEnv() string {
+	return ""
+}
+
+func (region *SRegion) GetGeographicInfo() cloudprovider.SGeographicInfo {
+	return cloudprovider.SGeographicInfo{}
+}
+
+func (region *SRegion) GetStatus() string {
+	return api.CLOUD_REGION_STATUS_INSERVER
+}
+
+func (region *SRegion) Refresh() error {
+	// do nothing
+	return nil
+}
+
+func (region *SRegion) GetCapabilities() []string {
+	return region.client.GetCapabilities()
+}
+
+func (region *SRegion) fetchZones() error {
+	if len(region.izones) > 0 {
+		return nil
+	}
+	zones, err := region.GetZones()
+	if err != nil {
+		return errors.Wrapf(err, "GetZones")
+	}
+	region.izones = []cloudprovider.ICloudZone{}
+	for i := range zones {
+		region.izones = append(region.izones, &zones[i])
+	}
+	return nil
+}
+
+func (region *SRegion) getZone(dcId string) (*SZone, error) {
+	err := region.fetchZones()
+	if err != nil {
+		return nil, err
+	}
+	for i := range region.izones {
+		if zone := region.izones[i].(*SZone); zone.Id == dcId {
+			return zone, nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "datacenter %s", dcId)
+}
+
+func (region *SRegion) GetIZones() ([]cloudprovider.ICloudZone, error) {
+	err := region.fetchZones()
+	if err != nil {
+		return nil, err
+	}
+	return region.izones, nil
+}
+
+func (region *SRegion) GetIZoneById(id string) (cloudprovider.ICloudZone, error) {
+	izones, err := region.GetIZones()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(izones); i++ {
+		if izones[i].GetGlobalId() == id {
+			return izones[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) getVpc() *SVpc {
+	if len(region.ivpcs) == 0 {
+		region.ivpcs = []cloudprovider.ICloudVpc{&SVpc{region: region}}
+	}
+	return region.ivpcs[0].(*SVpc)
+}
+
+func (region *SRegion) GetIVpcs() ([]cloudprovider.ICloudVpc, error) {
+	region.getVpc()
+	return region.ivpcs, nil
+}
+
+func (region *SRegion) GetIVpcById(vpcId string) (cloudprovider.ICloudVpc, error) {
+	vpc := region.getVpc()
+	if vpc.GetGlobalId() != vpcId {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return vpc, nil
+}
+
+func (region *SRegion) CreateIVpc(name string, desc string, cidr string) (cloudprovider.ICloudVpc, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	izones, err := region.GetIZones()
+	if err != nil {
+		return nil, err
+	}
+	ihosts := []cloudprovider.ICloudHost{}
+	for i := range izones {
+		hosts, err := izones[i].GetIHosts()
+		if err != nil {
+			return nil, err
+		}
+		ihosts = append(ihosts, hosts...)
+	}
+	return ihosts, nil
+}
+
+func (region *SRegion) GetIHostById(id string) (cloudprovider.ICloudHost, error) {
+	host, err := region.GetHost(id)
+	if err != nil {
+		return nil, err
+	}
+	return host, nil
+}
+
+func (region *SRegion) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	izones, err := region.GetIZones()
+	if err != nil {
+		return nil, err
+	}
+	istorages := []cloudprovider.ICloudStorage{}
+	for i := range izones {
+		storages, err := izones[i].GetIStorages()
+		if err != nil {
+			return nil, err
+		}
+		istorages = append(istorages, storages...)
+	}
+	return istorages, nil
+}
+
+func (region *SRegion) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	izones, err := region.GetIZones()
+	if err != nil {
+		return nil, err
+	}
+	for i := range izones {
+		storage, err := izones[i].GetIStorageById(id)
+		if err == nil {
+			return storage, nil
+		}
+		if errors.Cause(err) != cloudprovider.ErrNotFound {
+			return nil, err
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", id)
+}
+
+func (region *SRegion) getStoragecache() *SStoragecache {
+	return &SStoragecache{region: region}
+}
+
+func (region *SRegion) GetIStoragecaches() ([]cloudprovider.ICloudStoragecache, error) {
+	return []cloudprovider.ICloudStoragecache{region.getStoragecache()}, nil
+}
+
+func (region *SRegion) GetIStoragecacheById(id string) (cloudprovider.ICloudStoragecache, error) {
+	cache := region.getStoragecache()
+	if cache.GetGlobalId() != id {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return cache, nil
+}
+
+func (region *SRegion) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	return region.GetInstance(id)
+}
+
+func (region *SRegion) GetIDiskById(id string) (cloudprovider.ICloudDisk, error) {
+	return region.GetDisk(id)
+}
+
+func (region *SRegion) GetIEips() ([]cloudprovider.ICloudEIP, error) {
+	return []cloudprovider.ICloudEIP{}, nil
+}
+
+func (region *SRegion) GetIEipById(eipId string) (cloudprovider.ICloudEIP, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) CreateEIP(eip *cloudprovider.SEip) (cloudprovider.ICloudEIP, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+// 快照为虚拟机快照, 不单独同步磁盘快照
+func (region *SRegion) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (region *SRegion) GetISnapshotById(snapshotId string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetISecurityGroupById(secgroupId string) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetISecurityGroupByName(opts *cloudprovider.SecurityGroupFilterOptions) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) CreateISecurityGroup(conf *cloudprovider.SecurityGroupCreateInput) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancers() ([]cloudprovider.ICloudLoadbalancer, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) GetILoadBalancerById(loadbalancerId string) (cloudprovider.ICloudLoadbalancer, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) GetILoadBalancerAclById(aclId string) (cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) GetILoadBalancerCertificateById(certId string) (cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) CreateILoadBalancerCertificate(cert *cloudprovider.SLoadbalancerCertificate) (cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) GetILoadBalancerAcls() ([]cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) GetILoadBalancerCertificates() ([]cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) GetILoadBalancerBackendGroups() ([]cloudprovider.ICloudLoadbalancerBackendGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) CreateILoadBalancer(loadbalancer *cloudprovider.SLoadbalancer) (cloudprovider.ICloudLoadbalancer, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (region *SRegion) CreateILoadBalancerAcl(acl *cloudprovider.SLoadbalancerAccessControlList) (cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type InstanceListOptions struct {
+		Host string
+	}
+	shellutils.R(&InstanceListOptions{}, "instance-list", "List instances", func(cli *ovirt.SRegion, args *InstanceListOptions) error {
+		instances, err := cli.GetInstances(args.Host)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(instances, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceIdOptions struct {
+		ID string
+	}
+	shellutils.R(&InstanceIdOptions{}, "instance-show", "Show instance", func(cli *ovirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(instance)
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-disk-list", "List instance disks", func(cli *ovirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(instance.GetDisks(), 0, 0, 0, []string{})
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-nic-list", "List instance nics", func(cli *ovirt.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		nics, err := instance.GetNics()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(nics, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceCreateOptions struct {
+		HOST      string `help:"Host to create instance on"`
+		NAME      string
+		STORAGE   string `help:"Storage domain of system disk"`
+		Image     string `help:"Template id to clone from"`
+		Network   string `help:"Network id"`
+		Ip        string
+		Cpu       int   `default:"1"`
+		MemoryMb  int   `default:"1024"`
+		DiskGb    int   `default:"30"`
+		DataDisks []int `help:"Data disk sizes in GB"`
+		Password  string
+	}
+	shellutils.R(&InstanceCreateOptions{}, "instance-create", "Create instance", func(cli *ovirt.SRegion, args *InstanceCreateOptions) error {
+		host, err := cli.GetHost(args.HOST)
+		if err != nil {
+			return err
+		}
+		desc := &cloudprovider.SManagedVMCreateConfig{
+			Name:              args.NAME,
+			ExternalImageId:   args.Image,
+			Cpu:               args.Cpu,
+			MemoryMB:          args.MemoryMb,
+			ExternalNetworkId: args.Network,
+			IpAddr:            args.Ip,
+			Password:          args.Password,
+			SysDisk: cloudprovider.SDiskInfo{
+				StorageExternalId: args.STORAGE,
+				SizeGB:            args.DiskGb,
+			},
+		}
+		for _, size := range args.DataDisks {
+			desc.DataDisks = append(desc.DataDisks, cloudprovider.SDiskInfo{SizeGB: size})
+		}
+		vmId, err := cli.CreateVM(host, desc)
+		if err != nil {
+			return err
+		}
+		instance, err := cli.GetInstance(vmId)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(instance)
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-start", "Start instance", func(cli *ovirt.SRegion, args *InstanceIdOptions) error {
+		return cli.StartVM(args.ID, nil)
+	})
+
+	type InstanceStopOptions struct {
+		ID    string
+		Force bool
+	}
+	shellutils.R(&InstanceStopOptions{}, "instance-stop", "Stop instance", func(cli *ovirt.SRegion, args *InstanceStopOptions) error {
+		return cli.StopVM(args.ID, args.Force)
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-delete", "Delete instance", func(cli *ovirt.SRegion, args *InstanceIdOptions) error {
+		return cli.DeleteVM(args.ID)
+	})
+
+	type InstanceDiskOptions struct {
+		ID   string
+		DISK string
+	}
+	shellutils.R(&InstanceDiskOptions{}, "instance-attach-disk", "Attach disk to instance", func(cli *ovirt.SRegion, args *InstanceDiskOptions) error {
+		return cli.AttachDisk(args.ID, args.DISK, false)
+	})
+
+	shellutils.R(&InstanceDiskOptions{}, "instance-detach-disk", "Detach disk from instance", func(cli *ovirt.SRegion, args *InstanceDiskOptions) error {
+		return cli.DetachDisk(args.ID, args.DISK)
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type WireListOptions struct {
+		DATACENTER string
+	}
+	shellutils.R(&WireListOptions{}, "wire-list", "List logical networks", func(cli *ovirt.SRegion, args *WireListOptions) error {
+		wires, err := cli.GetWires(args.DATACENTER)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(wires, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type HostNetworkAttachmentListOptions struct {
+		HOST string
+	}
+	shellutils.R(&HostNetworkAttachmentListOptions{}, "host-network-attachment-list", "List network attachments of host", func(cli *ovirt.SRegion, args *HostNetworkAttachmentListOptions) error {
+		attachments, err := cli.GetHostNetworkAttachments(args.HOST)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(attachments, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type NetworkShowOptions struct {
+		ID string `help:"Network id, e.g. <logical network id>/192.168.1.0/24"`
+	}
+	shellutils.R(&NetworkShowOptions{}, "network-show", "Show network", func(cli *ovirt.SRegion, args *NetworkShowOptions) error {
+		network, err := cli.GetNetwork(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(network)
+		return nil
+	})
+
+	type VnicProfileShowOptions struct {
+		ID string
+	}
+	shellutils.R(&VnicProfileShowOptions{}, "vnic-profile-show", "Show vnic profile", func(cli *ovirt.SRegion, args *VnicProfileShowOptions) error {
+		profile, err := cli.GetVnicProfile(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(profile)
+		return nil
+	})
+}
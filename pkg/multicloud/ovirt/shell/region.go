@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+	"yunion.io/x/onecloud/pkg/multicloud/test"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	test.TestShell()
+
+	type ProductInfoShowOptions struct {
+	}
+	shellutils.R(&ProductInfoShowOptions{}, "product-info-show", "Show oVirt engine version", func(cli *ovirt.SRegion, args *ProductInfoShowOptions) error {
+		info, err := cli.GetClient().GetProductInfo()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(info)
+		return nil
+	})
+
+	type ZoneListOptions struct {
+	}
+	shellutils.R(&ZoneListOptions{}, "zone-list", "List data centers", func(cli *ovirt.SRegion, args *ZoneListOptions) error {
+		zones, err := cli.GetZones()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(zones, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type ClusterListOptions struct {
+	}
+	shellutils.R(&ClusterListOptions{}, "cluster-list", "List clusters", func(cli *ovirt.SRegion, args *ClusterListOptions) error {
+		clusters, err := cli.GetClusters()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(clusters, 0, 0, 0, []string{})
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type InstanceSnapshotListOptions struct {
+		VM string
+	}
+	shellutils.R(&InstanceSnapshotListOptions{}, "instance-snapshot-list", "List instance snapshots", func(cli *ovirt.SRegion, args *InstanceSnapshotListOptions) error {
+		snapshots, err := cli.GetInstanceSnapshots(args.VM)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(snapshots, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceSnapshotCreateOptions struct {
+		VM   string
+		NAME string
+	}
+	shellutils.R(&InstanceSnapshotCreateOptions{}, "instance-snapshot-create", "Create instance snapshot", func(cli *ovirt.SRegion, args *InstanceSnapshotCreateOptions) error {
+		snapshot, err := cli.CreateInstanceSnapshot(args.VM, args.NAME)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(snapshot)
+		return nil
+	})
+
+	type InstanceSnapshotOptions struct {
+		VM       string
+		SNAPSHOT string
+	}
+	shellutils.R(&InstanceSnapshotOptions{}, "instance-snapshot-delete", "Delete instance snapshot", func(cli *ovirt.SRegion, args *InstanceSnapshotOptions) error {
+		return cli.DeleteInstanceSnapshot(args.VM, args.SNAPSHOT)
+	})
+
+	shellutils.R(&InstanceSnapshotOptions{}, "instance-snapshot-restore", "Restore instance to snapshot", func(cli *ovirt.SRegion, args *InstanceSnapshotOptions) error {
+		return cli.RestoreInstanceSnapshot(args.VM, args.SNAPSHOT)
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/ovirt"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type StorageListOptions struct {
+		DATACENTER string
+	}
+	shellutils.R(&StorageListOptions{}, "storage-list", "List data storage domains", func(cli *ovirt.SRegion, args *StorageListOptions) error {
+		storages, err := cli.GetStorages(args.DATACENTER)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(storages, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskListOptions struct {
+		STORAGE string
+	}
+	shellutils.R(&DiskListOptions{}, "disk-list", "List disks in storage domain", func(cli *ovirt.SRegion, args *DiskListOptions) error {
+		disks, err := cli.GetDisks(args.STORAGE)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(disks, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskIdOptions struct {
+		ID string
+	}
+	shellutils.R(&DiskIdOptions{}, "disk-show", "Show disk", func(cli *ovirt.SRegion, args *DiskIdOptions) error {
+		disk, err := cli.GetDisk(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(disk)
+		return nil
+	})
+
+	shellutils.R(&DiskIdOptions{}, "disk-delete", "Delete disk", func(cli *ovirt.SRegion, args *DiskIdOptions) error {
+		return cli.DeleteDisk(args.ID)
+	})
+
+	type DiskCreateOptions struct {
+		STORAGE string
+		NAME    string
+		SIZE    int `help:"Disk size in GB"`
+		Desc    string
+	}
+	shellutils.R(&DiskCreateOptions{}, "disk-create", "Create disk", func(cli *ovirt.SRegion, args *DiskCreateOptions) error {
+		disk, err := cli.CreateDisk(args.STORAGE, args.NAME, args.SIZE, args.Desc)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(disk)
+		return nil
+	})
+
+	type DiskResizeOptions struct {
+		ID   string
+		SIZE int64  `help:"New size in MB"`
+		Vm   string `help:"Vm the disk attached to"`
+	}
+	shellutils.R(&DiskResizeOptions{}, "disk-resize", "Resize disk", func(cli *ovirt.SRegion, args *DiskResizeOptions) error {
+		return cli.ResizeDisk(args.Vm, args.ID, args.SIZE)
+	})
+}
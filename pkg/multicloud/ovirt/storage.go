@@ -0,0 +1,186 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SStorage struct {
+	multicloud.SResourceBase
+	zone *SZone
+
+	Id             string
+	Name           string
+	Description    string
+	Type           string
+	Status         string
+	ExternalStatus string
+	Master         bool
+	Available      int64
+	Used           int64
+	Committed      int64
+	Storage        struct {
+		Type    string
+		Address string
+		Path    string
+	}
+}
+
+var storageTypes = map[string]string{
+	"nfs":       api.STORAGE_OVIRT_NFS,
+	"iscsi":     api.STORAGE_OVIRT_ISCSI,
+	"fcp":       api.STORAGE_OVIRT_FCP,
+	"glusterfs": api.STORAGE_OVIRT_GLUSTERFS,
+	"localfs":   api.STORAGE_OVIRT_LOCALFS,
+	"posixfs":   api.STORAGE_OVIRT_POSIXFS,
+}
+
+// 只同步挂载到数据中心的数据域, ISO域和导出域不存放虚拟机磁盘
+func (region *SRegion) GetStorages(dcId string) ([]SStorage, error) {
+	domains := []SStorage{}
+	err := region.client.get(fmt.Sprintf("/datacenters/%s/storagedomains", dcId), "storage_domain", &domains)
+	if err != nil {
+		return nil, err
+	}
+	storages := []SStorage{}
+	for i := range domains {
+		if domains[i].Type == "data" {
+			storages = append(storages, domains[i])
+		}
+	}
+	return storages, nil
+}
+
+func (storage *SStorage) GetId() string {
+	return storage.Id
+}
+
+func (storage *SStorage) GetName() string {
+	return storage.Name
+}
+
+func (storage *SStorage) GetGlobalId() string {
+	return storage.GetId()
+}
+
+func (storage *SStorage) IsEmulated() bool {
+	return false
+}
+
+func (storage *SStorage) GetStatus() string {
+	if storage.Status == "active" {
+		return api.STORAGE_ONLINE
+	}
+	return api.STORAGE_OFFLINE
+}
+
+func (storage *SStorage) Refresh() error {
+	storages, err := storage.zone.region.GetStorages(storage.zone.Id)
+	if err != nil {
+		return err
+	}
+	for i := range storages {
+		if storages[i].Id == storage.Id {
+			return jsonutils.Update(storage, storages[i])
+		}
+	}
+	return errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", storage.Id)
+}
+
+func (storage *SStorage) GetIZone() cloudprovider.ICloudZone {
+	return storage.zone
+}
+
+func (storage *SStorage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return storage.zone.region.getStoragecache()
+}
+
+func (storage *SStorage) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := storage.zone.region.GetDisks(storage.Id)
+	if err != nil {
+		return nil, err
+	}
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (storage *SStorage) GetIDiskById(diskId string) (cloudprovider.ICloudDisk, error) {
+	disk, err := storage.zone.region.GetDisk(diskId)
+	if err != nil {
+		return nil, err
+	}
+	if disk.GetIStorageId() != storage.Id {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s not in storage %s", diskId, storage.Name)
+	}
+	return disk, nil
+}
+
+func (storage *SStorage) CreateIDisk(conf *cloudprovider.DiskCreateConfig) (cloudprovider.ICloudDisk, error) {
+	disk, err := storage.zone.region.CreateDisk(storage.Id, conf.Name, conf.SizeGb, conf.Desc)
+	if err != nil {
+		return nil, err
+	}
+	return disk, nil
+}
+
+func (storage *SStorage) GetStorageType() string {
+	if storageType, ok := storageTypes[storage.Storage.Type]; ok {
+		return storageType
+	}
+	return api.STORAGE_OVIRT_NFS
+}
+
+func (storage *SStorage) GetMediumType() string {
+	return api.DISK_TYPE_ROTATE
+}
+
+func (storage *SStorage) GetCapacityMB() int64 {
+	return (storage.Available + storage.Used) / 1024 / 1024
+}
+
+func (storage *SStorage) GetCapacityUsedMB() int64 {
+	return storage.Used / 1024 / 1024
+}
+
+func (storage *SStorage) GetStorageConf() jsonutils.JSONObject {
+	conf := jsonutils.NewDict()
+	conf.Add(jsonutils.NewString(storage.Storage.Type), "type")
+	conf.Add(jsonutils.NewString(storage.Storage.Address), "address")
+	conf.Add(jsonutils.NewString(storage.Storage.Path), "path")
+	return conf
+}
+
+func (storage *SStorage) GetEnabled() bool {
+	return true
+}
+
+func (storage *SStorage) GetMountPoint() string {
+	return ""
+}
+
+func (storage *SStorage) IsSysDiskStore() bool {
+	return true
+}
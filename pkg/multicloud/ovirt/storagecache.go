@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 以oVirt模板作为镜像, 创建虚拟机时从模板克隆
+type SStoragecache struct {
+	multicloud.SStoragecacheBase
+	region *SRegion
+}
+
+func (scache *SStoragecache) GetId() string {
+	return fmt.Sprintf("%s-%s", scache.region.client.cpcfg.Id, scache.region.GetId())
+}
+
+func (scache *SStoragecache) GetName() string {
+	return fmt.Sprintf("%s-%s", scache.region.client.cpcfg.Name, scache.region.GetId())
+}
+
+func (scache *SStoragecache) GetGlobalId() string {
+	return scache.GetId()
+}
+
+func (scache *SStoragecache) GetICloudImages() ([]cloudprovider.ICloudImage, error) {
+	images, err := scache.region.GetImages()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudImage{}
+	for i := 0; i < len(images); i++ {
+		images[i].storageCache = scache
+		ret = append(ret, &images[i])
+	}
+	return ret, nil
+}
+
+func (scache *SStoragecache) GetIImageById(extId string) (cloudprovider.ICloudImage, error) {
+	image, err := scache.region.GetImage(extId)
+	if err != nil {
+		return nil, err
+	}
+	image.storageCache = scache
+	return image, nil
+}
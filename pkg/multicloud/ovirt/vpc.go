@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// oVirt没有VPC概念, 整个engine模拟为一个VPC
+type SVpc struct {
+	multicloud.SEmulatedVpcBase
+
+	region *SRegion
+}
+
+func (vpc *SVpc) GetId() string {
+	return fmt.Sprintf("%s/vpc", vpc.region.GetGlobalId())
+}
+
+func (vpc *SVpc) GetName() string {
+	return fmt.Sprintf("%s-VPC", vpc.region.client.cpcfg.Name)
+}
+
+func (vpc *SVpc) GetGlobalId() string {
+	return vpc.GetId()
+}
+
+func (vpc *SVpc) GetRegion() cloudprovider.ICloudRegion {
+	return vpc.region
+}
+
+func (vpc *SVpc) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	wires, err := vpc.region.getWires()
+	if err != nil {
+		return nil, err
+	}
+	iwires := []cloudprovider.ICloudWire{}
+	for i := 0; i < len(wires); i++ {
+		wires[i].vpc = vpc
+		iwires = append(iwires, &wires[i])
+	}
+	return iwires, nil
+}
+
+func (vpc *SVpc) GetIWireById(wireId string) (cloudprovider.ICloudWire, error) {
+	return multicloud.GetIWireById(vpc, wireId)
+}
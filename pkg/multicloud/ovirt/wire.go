@@ -0,0 +1,283 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 逻辑网络对应二层网络, 仅同步用途包含vm的逻辑网络
+type SWire struct {
+	multicloud.SResourceBase
+	zone *SZone
+	vpc  *SVpc
+
+	Id          string
+	Name        string
+	Description string
+	Vlan        struct {
+		Id int
+	}
+	DataCenter SRef
+	Usages     struct {
+		Usage []string
+	}
+
+	networks []SNetwork
+}
+
+type SVnicProfile struct {
+	Id      string
+	Name    string
+	Network SRef
+}
+
+type SNetworkAttachment struct {
+	Id                   string
+	Network              SRef
+	Host                 SRef
+	IpAddressAssignments struct {
+		IpAddressAssignment []struct {
+			AssignmentMethod string
+			Ip               struct {
+				Address string
+				Netmask string
+				Gateway string
+				Version string
+			}
+		}
+	}
+}
+
+func (region *SRegion) GetHostNetworkAttachments(hostId string) ([]SNetworkAttachment, error) {
+	attachments := []SNetworkAttachment{}
+	err := region.client.get(fmt.Sprintf("/hosts/%s/networkattachments", hostId), "network_attachment", &attachments)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (region *SRegion) GetWires(dcId string) ([]SWire, error) {
+	zone, err := region.getZone(dcId)
+	if err != nil {
+		return nil, err
+	}
+	networks := []SWire{}
+	err = region.client.get(fmt.Sprintf("/datacenters/%s/networks", dcId), "network", &networks)
+	if err != nil {
+		return nil, err
+	}
+	wires := []SWire{}
+	for i := range networks {
+		if utils.IsInStringArray("vm", networks[i].Usages.Usage) {
+			networks[i].zone = zone
+			wires = append(wires, networks[i])
+		}
+	}
+
+	// oVirt不管理虚拟机地址, 以主机在逻辑网络上的静态地址推断网段
+	hosts, err := region.GetHosts(dcId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetHosts")
+	}
+	for _, host := range hosts {
+		attachments, err := region.GetHostNetworkAttachments(host.Id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetHostNetworkAttachments(%s)", host.Name)
+		}
+		for _, attachment := range attachments {
+			for i := range wires {
+				if wires[i].Id != attachment.Network.Id {
+					continue
+				}
+				for _, assignment := range attachment.IpAddressAssignments.IpAddressAssignment {
+					ip := assignment.Ip
+					if assignment.AssignmentMethod != "static" || ip.Version != "v4" || len(ip.Address) == 0 {
+						continue
+					}
+					wires[i].addNetwork(ip.Address, ip.Netmask, ip.Gateway)
+				}
+			}
+		}
+	}
+	for i := range wires {
+		for j := range wires[i].networks {
+			wires[i].networks[j].wire = &wires[i]
+			wires[i].networks[j].fixRange()
+		}
+	}
+	return wires, nil
+}
+
+func (wire *SWire) addNetwork(address, netmask, gateway string) {
+	addr, err := netutils.NewIPV4Addr(address)
+	if err != nil {
+		return
+	}
+	netAddr, maskLen, err := netutils.ParsePrefix(fmt.Sprintf("%s/%s", address, netmask))
+	if err != nil {
+		return
+	}
+	for i := range wire.networks {
+		if wire.networks[i].netAddr == netAddr && wire.networks[i].MaskLen == maskLen {
+			wire.networks[i].addrs = append(wire.networks[i].addrs, addr)
+			if len(wire.networks[i].Gateway) == 0 {
+				wire.networks[i].Gateway = gateway
+			}
+			return
+		}
+	}
+	network := SNetwork{
+		Gateway: gateway,
+		MaskLen: maskLen,
+		netAddr: netAddr,
+		addrs:   []netutils.IPV4Addr{addr},
+	}
+	wire.networks = append(wire.networks, network)
+}
+
+func (region *SRegion) getWires() ([]SWire, error) {
+	err := region.fetchZones()
+	if err != nil {
+		return nil, err
+	}
+	ret := []SWire{}
+	for _, izone := range region.izones {
+		wires, err := region.GetWires(izone.GetId())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, wires...)
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetWire(wireId string) (*SWire, error) {
+	wires, err := region.getWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		if wires[i].Id == wireId {
+			wires[i].vpc = region.getVpc()
+			return &wires[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "wire %s", wireId)
+}
+
+func (region *SRegion) GetNetwork(networkId string) (*SNetwork, error) {
+	wires, err := region.getWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		for j := range wires[i].networks {
+			if wires[i].networks[j].GetGlobalId() == networkId {
+				wires[i].vpc = region.getVpc()
+				return &wires[i].networks[j], nil
+			}
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", networkId)
+}
+
+func (region *SRegion) GetVnicProfile(id string) (*SVnicProfile, error) {
+	profile := &SVnicProfile{}
+	err := region.client.get(fmt.Sprintf("/vnicprofiles/%s", id), "", profile)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// 使用逻辑网络的第一个vNIC配置集, 默认配置集与逻辑网络同名
+func (region *SRegion) getVnicProfileByNetwork(networkId string) (*SVnicProfile, error) {
+	profiles := []SVnicProfile{}
+	err := region.client.get(fmt.Sprintf("/networks/%s/vnicprofiles", networkId), "vnic_profile", &profiles)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "no vnic profile for network %s", networkId)
+	}
+	return &profiles[0], nil
+}
+
+func (wire *SWire) GetId() string {
+	return wire.Id
+}
+
+func (wire *SWire) GetName() string {
+	return wire.Name
+}
+
+func (wire *SWire) GetGlobalId() string {
+	return wire.Id
+}
+
+func (wire *SWire) IsEmulated() bool {
+	return false
+}
+
+func (wire *SWire) GetStatus() string {
+	return "available"
+}
+
+func (wire *SWire) Refresh() error {
+	return nil
+}
+
+func (wire *SWire) GetIVpc() cloudprovider.ICloudVpc {
+	return wire.vpc
+}
+
+func (wire *SWire) GetIZone() cloudprovider.ICloudZone {
+	return wire.zone
+}
+
+func (wire *SWire) GetINetworks() ([]cloudprovider.ICloudNetwork, error) {
+	inetworks := []cloudprovider.ICloudNetwork{}
+	for i := range wire.networks {
+		inetworks = append(inetworks, &wire.networks[i])
+	}
+	return inetworks, nil
+}
+
+func (wire *SWire) GetINetworkById(netid string) (cloudprovider.ICloudNetwork, error) {
+	for i := range wire.networks {
+		if wire.networks[i].GetGlobalId() == netid {
+			return &wire.networks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", netid)
+}
+
+func (wire *SWire) GetBandwidth() int {
+	return 10000
+}
+
+// 网段来自主机网络配置, 不支持通过接口创建
+func (wire *SWire) CreateINetwork(opts *cloudprovider.SNetworkCreateOptions) (cloudprovider.ICloudNetwork, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
@@ -0,0 +1,183 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovirt
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SCluster struct {
+	Id          string
+	Name        string
+	Description string
+	DataCenter  SRef
+	Cpu         struct {
+		Type         string
+		Architecture string
+	}
+}
+
+func (region *SRegion) GetClusters() ([]SCluster, error) {
+	clusters := []SCluster{}
+	return clusters, region.client.get("/clusters", "cluster", &clusters)
+}
+
+func (region *SRegion) GetCluster(id string) (*SCluster, error) {
+	cluster := &SCluster{}
+	return cluster, region.client.get(fmt.Sprintf("/clusters/%s", id), "", cluster)
+}
+
+// 数据中心对应可用区, 存储域和逻辑网络均属于数据中心; 集群仅用于虚拟机放置
+type SZone struct {
+	multicloud.SResourceBase
+	region *SRegion
+
+	Id          string
+	Name        string
+	Description string
+	Status      string
+	Local       bool
+
+	ihosts    []cloudprovider.ICloudHost
+	istorages []cloudprovider.ICloudStorage
+}
+
+func (region *SRegion) GetZones() ([]SZone, error) {
+	zones := []SZone{}
+	err := region.client.get("/datacenters", "data_center", &zones)
+	if err != nil {
+		return nil, err
+	}
+	for i := range zones {
+		zones[i].region = region
+	}
+	return zones, nil
+}
+
+func (zone *SZone) GetId() string {
+	return zone.Id
+}
+
+func (zone *SZone) GetName() string {
+	return zone.Name
+}
+
+func (zone *SZone) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(zone.GetName()).CN(zone.GetName())
+	return table
+}
+
+func (zone *SZone) GetGlobalId() string {
+	return zone.GetId()
+}
+
+func (zone *SZone) IsEmulated() bool {
+	return false
+}
+
+func (zone *SZone) GetStatus() string {
+	if zone.Status == "up" {
+		return api.ZONE_ENABLE
+	}
+	return api.ZONE_DISABLE
+}
+
+func (zone *SZone) Refresh() error {
+	// do nothing
+	return nil
+}
+
+func (zone *SZone) GetIRegion() cloudprovider.ICloudRegion {
+	return zone.region
+}
+
+func (zone *SZone) fetchHosts() error {
+	hosts, err := zone.region.GetHosts(zone.Id)
+	if err != nil {
+		return err
+	}
+	zone.ihosts = []cloudprovider.ICloudHost{}
+	for i := 0; i < len(hosts); i++ {
+		hosts[i].zone = zone
+		zone.ihosts = append(zone.ihosts, &hosts[i])
+	}
+	return nil
+}
+
+func (zone *SZone) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	if len(zone.ihosts) == 0 {
+		err := zone.fetchHosts()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return zone.ihosts, nil
+}
+
+func (zone *SZone) GetIHostById(hostId string) (cloudprovider.ICloudHost, error) {
+	hosts, err := zone.GetIHosts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		if hosts[i].GetGlobalId() == hostId {
+			return hosts[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "host %s", hostId)
+}
+
+func (zone *SZone) fetchStorages() error {
+	storages, err := zone.region.GetStorages(zone.Id)
+	if err != nil {
+		return err
+	}
+	zone.istorages = []cloudprovider.ICloudStorage{}
+	for i := 0; i < len(storages); i++ {
+		storages[i].zone = zone
+		zone.istorages = append(zone.istorages, &storages[i])
+	}
+	return nil
+}
+
+func (zone *SZone) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	if len(zone.istorages) == 0 {
+		err := zone.fetchStorages()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return zone.istorages, nil
+}
+
+func (zone *SZone) GetIStorageById(storageId string) (cloudprovider.ICloudStorage, error) {
+	storages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(storages); i++ {
+		if storages[i].GetGlobalId() == storageId {
+			return storages[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", storageId)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicloud
+
+import (
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+// 由单个客户端提供账号, 区域与项目信息的私有云平台客户端
+type ICloudClient interface {
+	GetSubAccounts() ([]cloudprovider.SSubAccount, error)
+	GetAccountId() string
+	GetIRegions() []cloudprovider.ICloudRegion
+	GetIRegionById(id string) (cloudprovider.ICloudRegion, error)
+	GetCloudRegionExternalIdPrefix() string
+	GetIProjects() ([]cloudprovider.ICloudProject, error)
+	GetCapabilities() []string
+}
+
+// 将账号与区域相关的接口转发给平台客户端, 不支持余额查询与对象存储
+type SClientBaseProvider struct {
+	cloudprovider.SBaseProvider
+	client ICloudClient
+}
+
+func NewClientBaseProvider(factory cloudprovider.ICloudProviderFactory, client ICloudClient) SClientBaseProvider {
+	return SClientBaseProvider{
+		SBaseProvider: cloudprovider.NewBaseProvider(factory),
+		client:        client,
+	}
+}
+
+func (self *SClientBaseProvider) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	return self.client.GetSubAccounts()
+}
+
+func (self *SClientBaseProvider) GetAccountId() string {
+	return self.client.GetAccountId()
+}
+
+func (self *SClientBaseProvider) GetIRegions() []cloudprovider.ICloudRegion {
+	return self.client.GetIRegions()
+}
+
+func (self *SClientBaseProvider) GetIRegionById(extId string) (cloudprovider.ICloudRegion, error) {
+	return self.client.GetIRegionById(extId)
+}
+
+func (self *SClientBaseProvider) GetBalance() (float64, string, error) {
+	return 0.0, api.CLOUD_PROVIDER_HEALTH_UNKNOWN, cloudprovider.ErrNotSupported
+}
+
+func (self *SClientBaseProvider) GetCloudRegionExternalIdPrefix() string {
+	return self.client.GetCloudRegionExternalIdPrefix()
+}
+
+func (self *SClientBaseProvider) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return self.client.GetIProjects()
+}
+
+func (self *SClientBaseProvider) GetStorageClasses(regionId string) []string {
+	return nil
+}
+
+func (self *SClientBaseProvider) GetBucketCannedAcls(regionId string) []string {
+	return nil
+}
+
+func (self *SClientBaseProvider) GetObjectCannedAcls(regionId string) []string {
+	return nil
+}
+
+func (self *SClientBaseProvider) GetCapabilities() []string {
+	return self.client.GetCapabilities()
+}
@@ -0,0 +1,249 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+// Proxmox的磁盘是虚拟机配置中的一个槽位, 如 scsi0: local-lvm:vm-100-disk-0,size=32G
+type SDisk struct {
+	multicloud.SDisk
+	region *SRegion
+
+	Volid   string
+	Storage string
+	Node    string
+	Vmid    int
+	Slot    string
+	Format  string
+	Cache   string
+	SizeMB  int
+	IsSys   bool
+}
+
+func newDisk(instance *SInstance, slot, value string) SDisk {
+	volid, opts := parseOptions(value)
+	if file, ok := opts["file"]; ok {
+		volid = file
+	}
+	disk := SDisk{
+		Volid:  volid,
+		Node:   instance.Node,
+		Vmid:   instance.Vmid,
+		Slot:   slot,
+		Format: opts["format"],
+		Cache:  opts["cache"],
+		SizeMB: parseSizeMB(opts["size"]),
+	}
+	if idx := strings.Index(volid, ":"); idx > 0 {
+		disk.Storage = volid[:idx]
+	}
+	if len(disk.Format) == 0 {
+		switch strings.TrimPrefix(path.Ext(volid), ".") {
+		case "qcow2":
+			disk.Format = "qcow2"
+		case "vmdk":
+			disk.Format = "vmdk"
+		default:
+			disk.Format = "raw"
+		}
+	}
+	return disk
+}
+
+func (region *SRegion) GetDisks() ([]SDisk, error) {
+	instances, err := region.GetInstances("")
+	if err != nil {
+		return nil, err
+	}
+	disks := []SDisk{}
+	for i := range instances {
+		for _, disk := range instances[i].GetDisks() {
+			disk.region = region
+			disks = append(disks, disk)
+		}
+	}
+	return disks, nil
+}
+
+func (region *SRegion) GetDisk(diskId string) (*SDisk, error) {
+	disks, err := region.GetDisks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range disks {
+		if disks[i].GetGlobalId() == diskId {
+			return &disks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s", diskId)
+}
+
+func (region *SRegion) ResizeDisk(node string, vmid int, slot string, sizeMb int) error {
+	params := map[string]interface{}{
+		"disk": slot,
+		"size": fmt.Sprintf("%dM", sizeMb),
+	}
+	return region.client.doTask(httputils.PUT, fmt.Sprintf("/nodes/%s/qemu/%d/resize", node, vmid), jsonutils.Marshal(params))
+}
+
+func (disk *SDisk) GetId() string {
+	return disk.Volid
+}
+
+func (disk *SDisk) GetName() string {
+	return fmt.Sprintf("%d-%s", disk.Vmid, disk.Slot)
+}
+
+func (disk *SDisk) GetGlobalId() string {
+	return disk.Volid
+}
+
+func (disk *SDisk) IsEmulated() bool {
+	return false
+}
+
+func (disk *SDisk) GetStatus() string {
+	return api.DISK_READY
+}
+
+func (disk *SDisk) Refresh() error {
+	newDisk, err := disk.region.GetDisk(disk.GetGlobalId())
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(disk, newDisk)
+}
+
+func (disk *SDisk) getStorage() (*SStorage, error) {
+	zone, err := disk.region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	return zone.getStorage(disk.Node, disk.Storage)
+}
+
+func (disk *SDisk) GetIStorage() (cloudprovider.ICloudStorage, error) {
+	return disk.getStorage()
+}
+
+func (disk *SDisk) GetIStorageId() string {
+	storage, err := disk.getStorage()
+	if err != nil {
+		return ""
+	}
+	return storage.GetGlobalId()
+}
+
+func (disk *SDisk) GetDiskFormat() string {
+	return disk.Format
+}
+
+func (disk *SDisk) GetDiskSizeMB() int {
+	return disk.SizeMB
+}
+
+func (disk *SDisk) GetIsAutoDelete() bool {
+	return true
+}
+
+func (disk *SDisk) GetTemplateId() string {
+	return ""
+}
+
+func (disk *SDisk) GetDiskType() string {
+	if disk.IsSys {
+		return api.DISK_TYPE_SYS
+	}
+	return api.DISK_TYPE_DATA
+}
+
+func (disk *SDisk) GetFsFormat() string {
+	return ""
+}
+
+func (disk *SDisk) GetIsNonPersistent() bool {
+	return false
+}
+
+func (disk *SDisk) GetDriver() string {
+	m := diskSlotReg.FindStringSubmatch(disk.Slot)
+	if m == nil {
+		return "scsi"
+	}
+	return m[1]
+}
+
+func (disk *SDisk) GetCacheMode() string {
+	if len(disk.Cache) == 0 {
+		return "none"
+	}
+	return disk.Cache
+}
+
+func (disk *SDisk) GetMountpoint() string {
+	return ""
+}
+
+func (disk *SDisk) GetAccessPath() string {
+	return ""
+}
+
+// 从虚拟机上卸载并删除磁盘
+func (disk *SDisk) Delete(ctx context.Context) error {
+	params := map[string]interface{}{
+		"idlist": disk.Slot,
+		"force":  1,
+	}
+	_, err := disk.region.client.put(fmt.Sprintf("/nodes/%s/qemu/%d/unlink", disk.Node, disk.Vmid), jsonutils.Marshal(params))
+	return err
+}
+
+func (disk *SDisk) Resize(ctx context.Context, sizeMb int64) error {
+	return disk.region.ResizeDisk(disk.Node, disk.Vmid, disk.Slot, int(sizeMb))
+}
+
+// Proxmox只支持整机快照
+func (disk *SDisk) CreateISnapshot(ctx context.Context, name, desc string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (disk *SDisk) Reset(ctx context.Context, snapshotId string) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) Rebuild(ctx context.Context) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetProjectId() string {
+	return ""
+}
@@ -0,0 +1,296 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+	"strconv"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SNodeStatus struct {
+	Cpuinfo struct {
+		Model   string
+		Cpus    int
+		Sockets int
+		Cores   int
+		Mhz     string
+	}
+	Memory struct {
+		Total int64
+		Used  int64
+		Free  int64
+	}
+	Pveversion string
+	Kversion   string
+}
+
+type SHost struct {
+	multicloud.SHostBase
+	zone *SZone
+
+	Node           string
+	Status         string
+	Maxcpu         int
+	Maxmem         int64
+	Maxdisk        int64
+	Uptime         int64
+	SslFingerprint string `json:"ssl_fingerprint"`
+
+	ip         string
+	nodeStatus *SNodeStatus
+}
+
+func (region *SRegion) GetHosts() ([]SHost, error) {
+	hosts := []SHost{}
+	err := region.client.get("/nodes", &hosts)
+	if err != nil {
+		return nil, err
+	}
+	status, err := region.GetClusterStatus()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		for _, s := range status {
+			if s.Type == "node" && s.Name == hosts[i].Node {
+				hosts[i].ip = s.Ip
+			}
+		}
+	}
+	return hosts, nil
+}
+
+func (region *SRegion) GetHost(node string) (*SHost, error) {
+	hosts, err := region.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		if hosts[i].Node == node {
+			return &hosts[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "node %s", node)
+}
+
+func (region *SRegion) GetNodeStatus(node string) (*SNodeStatus, error) {
+	status := &SNodeStatus{}
+	return status, region.client.get(fmt.Sprintf("/nodes/%s/status", node), status)
+}
+
+func (host *SHost) getRegion() *SRegion {
+	return host.zone.region
+}
+
+func (host *SHost) fetchNodeStatus() *SNodeStatus {
+	if host.nodeStatus == nil {
+		status, err := host.getRegion().GetNodeStatus(host.Node)
+		if err != nil {
+			log.Errorf("get node %s status error: %v", host.Node, err)
+			return &SNodeStatus{}
+		}
+		host.nodeStatus = status
+	}
+	return host.nodeStatus
+}
+
+func (host *SHost) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	wires, err := host.getRegion().GetWires(host.Node)
+	if err != nil {
+		return nil, err
+	}
+	iwires := []cloudprovider.ICloudWire{}
+	for i := 0; i < len(wires); i++ {
+		wires[i].vpc = host.getRegion().getVpc()
+		iwires = append(iwires, &wires[i])
+	}
+	return iwires, nil
+}
+
+func (host *SHost) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	storages, err := host.zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	istorages := []cloudprovider.ICloudStorage{}
+	for i := 0; i < len(storages); i++ {
+		if storages[i].(*SStorage).isAvailableOn(host.Node) {
+			istorages = append(istorages, storages[i])
+		}
+	}
+	return istorages, nil
+}
+
+func (host *SHost) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	return host.zone.GetIStorageById(id)
+}
+
+func (host *SHost) GetIVMs() ([]cloudprovider.ICloudVM, error) {
+	instances, err := host.getRegion().GetInstances(host.Node)
+	if err != nil {
+		return nil, err
+	}
+	ivms := []cloudprovider.ICloudVM{}
+	for i := 0; i < len(instances); i++ {
+		instances[i].host = host
+		ivms = append(ivms, &instances[i])
+	}
+	return ivms, nil
+}
+
+func (host *SHost) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	instance, err := host.getRegion().GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Node != host.Node {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s not on node %s", id, host.Node)
+	}
+	instance.host = host
+	return instance, nil
+}
+
+func (host *SHost) GetId() string {
+	return host.Node
+}
+
+func (host *SHost) GetName() string {
+	return host.Node
+}
+
+func (host *SHost) GetGlobalId() string {
+	return host.GetId()
+}
+
+func (host *SHost) IsEmulated() bool {
+	return false
+}
+
+func (host *SHost) GetStatus() string {
+	if host.Status == "online" {
+		return api.HOST_STATUS_RUNNING
+	}
+	return api.HOST_STATUS_UNKNOWN
+}
+
+func (host *SHost) Refresh() error {
+	newHost, err := host.getRegion().GetHost(host.Node)
+	if err != nil {
+		return err
+	}
+	host.nodeStatus = nil
+	host.ip = newHost.ip
+	return jsonutils.Update(host, newHost)
+}
+
+func (host *SHost) GetHostStatus() string {
+	if host.Status == "online" {
+		return api.HOST_ONLINE
+	}
+	return api.HOST_OFFLINE
+}
+
+func (host *SHost) GetEnabled() bool {
+	return true
+}
+
+func (host *SHost) GetAccessIp() string {
+	return host.ip
+}
+
+func (host *SHost) GetAccessMac() string {
+	return ""
+}
+
+func (host *SHost) GetSysInfo() jsonutils.JSONObject {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString(CLOUD_PROVIDER_PROXMOX), "manufacture")
+	return info
+}
+
+func (host *SHost) GetSN() string {
+	return ""
+}
+
+func (host *SHost) GetCpuCount() int {
+	return host.Maxcpu
+}
+
+func (host *SHost) GetNodeCount() int8 {
+	return int8(host.fetchNodeStatus().Cpuinfo.Sockets)
+}
+
+func (host *SHost) GetCpuDesc() string {
+	return host.fetchNodeStatus().Cpuinfo.Model
+}
+
+func (host *SHost) GetCpuMhz() int {
+	mhz, _ := strconv.ParseFloat(host.fetchNodeStatus().Cpuinfo.Mhz, 64)
+	return int(mhz)
+}
+
+func (host *SHost) GetMemSizeMB() int {
+	return int(host.Maxmem / 1024 / 1024)
+}
+
+func (host *SHost) GetStorageSizeMB() int {
+	storages, err := host.GetIStorages()
+	if err != nil {
+		return 0
+	}
+	size := int64(0)
+	for i := range storages {
+		if storages[i].GetStorageType() == api.STORAGE_PROXMOX_LOCAL {
+			size += storages[i].GetCapacityMB()
+		}
+	}
+	return int(size)
+}
+
+func (host *SHost) GetStorageType() string {
+	return api.DISK_TYPE_HYBRID
+}
+
+func (host *SHost) GetHostType() string {
+	return api.HOST_TYPE_PROXMOX
+}
+
+func (host *SHost) GetIsMaintenance() bool {
+	return false
+}
+
+func (host *SHost) GetVersion() string {
+	return host.fetchNodeStatus().Pveversion
+}
+
+func (host *SHost) GetIHostNics() ([]cloudprovider.ICloudHostNetInterface, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (host *SHost) CreateVM(desc *cloudprovider.SManagedVMCreateConfig) (cloudprovider.ICloudVM, error) {
+	vmid, err := host.getRegion().CreateVM(host.Node, desc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CreateVM")
+	}
+	return host.GetIVMById(vmid)
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/util/osprofile"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SImage struct {
+	multicloud.SImageBase
+	storageCache *SStoragecache
+
+	Vmid    int
+	Node    string
+	Name    string
+	Maxdisk int64
+
+	config map[string]string
+}
+
+func newImage(instance *SInstance) SImage {
+	return SImage{
+		Vmid:    instance.Vmid,
+		Node:    instance.Node,
+		Name:    instance.Name,
+		Maxdisk: instance.Maxdisk,
+		config:  instance.config,
+	}
+}
+
+func (region *SRegion) GetImages() ([]SImage, error) {
+	templates, err := region.getVms("", true)
+	if err != nil {
+		return nil, err
+	}
+	images := []SImage{}
+	for i := range templates {
+		images = append(images, newImage(&templates[i]))
+	}
+	return images, nil
+}
+
+func (region *SRegion) GetImage(imageId string) (*SImage, error) {
+	template, err := region.getVm(imageId, true)
+	if err != nil {
+		return nil, err
+	}
+	image := newImage(template)
+	return &image, nil
+}
+
+func (image *SImage) GetId() string {
+	return strconv.Itoa(image.Vmid)
+}
+
+func (image *SImage) GetName() string {
+	return image.Name
+}
+
+func (image *SImage) GetGlobalId() string {
+	return image.GetId()
+}
+
+func (image *SImage) IsEmulated() bool {
+	return false
+}
+
+func (image *SImage) GetStatus() string {
+	return api.CACHED_IMAGE_STATUS_ACTIVE
+}
+
+func (image *SImage) GetImageStatus() string {
+	return cloudprovider.IMAGE_STATUS_ACTIVE
+}
+
+func (image *SImage) Refresh() error {
+	newImage, err := image.storageCache.region.GetImage(image.GetId())
+	if err != nil {
+		return err
+	}
+	image.config = newImage.config
+	return jsonutils.Update(image, newImage)
+}
+
+func (image *SImage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return image.storageCache
+}
+
+func (image *SImage) Delete(ctx context.Context) error {
+	return image.storageCache.region.DeleteVM(image.Node, image.Vmid)
+}
+
+func (image *SImage) GetImageType() cloudprovider.TImageType {
+	return cloudprovider.ImageTypeSystem
+}
+
+func (image *SImage) GetSizeByte() int64 {
+	return image.Maxdisk
+}
+
+func (image *SImage) GetOsType() string {
+	if strings.HasPrefix(image.config["ostype"], "w") {
+		return osprofile.OS_TYPE_WINDOWS
+	}
+	return osprofile.OS_TYPE_LINUX
+}
+
+func (image *SImage) GetOsDist() string {
+	return ""
+}
+
+func (image *SImage) GetOsVersion() string {
+	return ""
+}
+
+func (image *SImage) GetOsArch() string {
+	return "x86_64"
+}
+
+func (image *SImage) GetMinOsDiskSizeGb() int {
+	return int(image.Maxdisk / 1024 / 1024 / 1024)
+}
+
+func (image *SImage) GetMinRamSizeMb() int {
+	return 0
+}
+
+func (image *SImage) GetImageFormat() string {
+	return "raw"
+}
+
+func (image *SImage) GetCreatedAt() time.Time {
+	return time.Time{}
+}
+
+func (image *SImage) UEFI() bool {
+	return image.config["bios"] == "ovmf"
+}
+
+func (image *SImage) GetMetadata() *jsonutils.JSONDict {
+	data := jsonutils.NewDict()
+	data.Add(jsonutils.NewString(image.Node), "proxmox_node")
+	return data
+}
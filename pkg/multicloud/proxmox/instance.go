@@ -0,0 +1,745 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/osprofile"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/billing"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+var (
+	diskSlotReg = regexp.MustCompile(`^(scsi|virtio|sata|ide)(\d+)$`)
+	nicSlotReg  = regexp.MustCompile(`^net(\d+)$`)
+
+	diskBuses = []string{"scsi", "virtio", "sata", "ide"}
+)
+
+type SInstance struct {
+	multicloud.SInstanceBase
+	host *SHost
+
+	Vmid     int
+	Node     string
+	Name     string
+	Status   string
+	Template int
+	Maxcpu   int
+	Maxmem   int64
+	Maxdisk  int64
+	Uptime   int64
+
+	config map[string]string
+}
+
+// 解析形如 "local-lvm:vm-100-disk-0,size=32G,cache=writeback" 的配置项, 返回首个字段及其余键值
+func parseOptions(value string) (string, map[string]string) {
+	opts := map[string]string{}
+	parts := strings.Split(value, ",")
+	for _, part := range parts {
+		if idx := strings.Index(part, "="); idx > 0 {
+			opts[part[:idx]] = part[idx+1:]
+		}
+	}
+	return parts[0], opts
+}
+
+func parseSizeMB(size string) int {
+	if len(size) == 0 {
+		return 0
+	}
+	unit := size[len(size)-1]
+	num, err := strconv.ParseFloat(strings.TrimRight(size, "KMGTkmgt"), 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case 'K', 'k':
+		return int(num / 1024)
+	case 'M', 'm':
+		return int(num)
+	case 'G', 'g':
+		return int(num * 1024)
+	case 'T', 't':
+		return int(num * 1024 * 1024)
+	}
+	return int(num / 1024 / 1024)
+}
+
+func parseConfig(obj jsonutils.JSONObject) (map[string]string, error) {
+	dict, ok := obj.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, fmt.Errorf("invalid config %s", obj)
+	}
+	values, err := dict.GetMap()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetMap")
+	}
+	config := map[string]string{}
+	for k, v := range values {
+		if s, err := v.GetString(); err == nil {
+			config[k] = s
+		} else {
+			config[k] = v.String()
+		}
+	}
+	return config, nil
+}
+
+func (region *SRegion) GetInstanceConfig(node string, vmid int) (map[string]string, error) {
+	resp, err := region.client.jsonRequest(httputils.GET, fmt.Sprintf("/nodes/%s/qemu/%d/config", node, vmid), nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := resp.Get("data")
+	if err != nil {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "vm %d config", vmid)
+	}
+	return parseConfig(data)
+}
+
+func (region *SRegion) getVms(node string, template bool) ([]SInstance, error) {
+	resources, err := region.GetClusterResources("vm")
+	if err != nil {
+		return nil, err
+	}
+	instances := []SInstance{}
+	for _, res := range resources {
+		if res.Type != "qemu" || (res.Template == 1) != template {
+			continue
+		}
+		if len(node) > 0 && res.Node != node {
+			continue
+		}
+		instance := SInstance{
+			Vmid:     res.Vmid,
+			Node:     res.Node,
+			Name:     res.Name,
+			Status:   res.Status,
+			Template: res.Template,
+			Maxcpu:   res.Maxcpu,
+			Maxmem:   res.Maxmem,
+			Maxdisk:  res.Maxdisk,
+			Uptime:   res.Uptime,
+		}
+		instance.config, err = region.GetInstanceConfig(res.Node, res.Vmid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetInstanceConfig %d", res.Vmid)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (region *SRegion) getVm(id string, template bool) (*SInstance, error) {
+	vmid, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "invalid vmid %s", id)
+	}
+	resources, err := region.GetClusterResources("vm")
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range resources {
+		if res.Type == "qemu" && res.Vmid == vmid && (res.Template == 1) == template {
+			instance := &SInstance{
+				Vmid:     res.Vmid,
+				Node:     res.Node,
+				Name:     res.Name,
+				Status:   res.Status,
+				Template: res.Template,
+				Maxcpu:   res.Maxcpu,
+				Maxmem:   res.Maxmem,
+				Maxdisk:  res.Maxdisk,
+				Uptime:   res.Uptime,
+			}
+			instance.config, err = region.GetInstanceConfig(res.Node, res.Vmid)
+			if err != nil {
+				return nil, errors.Wrapf(err, "GetInstanceConfig %d", res.Vmid)
+			}
+			return instance, nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "vm %s", id)
+}
+
+// 模板虚拟机作为镜像同步, 不计入虚拟机
+func (region *SRegion) GetInstances(node string) ([]SInstance, error) {
+	return region.getVms(node, false)
+}
+
+func (region *SRegion) GetInstance(id string) (*SInstance, error) {
+	instance, err := region.getVm(id, false)
+	if err != nil {
+		return nil, err
+	}
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	host, err := zone.GetIHostById(instance.Node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetIHostById(%s)", instance.Node)
+	}
+	instance.host = host.(*SHost)
+	return instance, nil
+}
+
+func (instance *SInstance) getRegion() *SRegion {
+	return instance.host.zone.region
+}
+
+func (instance *SInstance) vmPath(spec string) string {
+	return fmt.Sprintf("/nodes/%s/qemu/%d/%s", instance.Node, instance.Vmid, spec)
+}
+
+// 按总线及序号排序的磁盘槽位, 不包括光驱与cloud-init盘
+func (instance *SInstance) diskSlots() []string {
+	slots := []string{}
+	for k, v := range instance.config {
+		if !diskSlotReg.MatchString(k) {
+			continue
+		}
+		volid, opts := parseOptions(v)
+		if opts["media"] == "cdrom" || volid == "none" || strings.Contains(volid, "cloudinit") {
+			continue
+		}
+		slots = append(slots, k)
+	}
+	busIndex := func(slot string) (int, int) {
+		m := diskSlotReg.FindStringSubmatch(slot)
+		idx, _ := strconv.Atoi(m[2])
+		for i, bus := range diskBuses {
+			if bus == m[1] {
+				return i, idx
+			}
+		}
+		return len(diskBuses), idx
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		bi, ii := busIndex(slots[i])
+		bj, ij := busIndex(slots[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return ii < ij
+	})
+	return slots
+}
+
+func (instance *SInstance) bootDisk() string {
+	slots := instance.diskSlots()
+	if len(slots) == 0 {
+		return ""
+	}
+	boot := instance.config["boot"]
+	if strings.HasPrefix(boot, "order=") {
+		for _, dev := range strings.Split(strings.TrimPrefix(boot, "order="), ";") {
+			for _, slot := range slots {
+				if slot == dev {
+					return slot
+				}
+			}
+		}
+	}
+	if bootdisk, ok := instance.config["bootdisk"]; ok {
+		for _, slot := range slots {
+			if slot == bootdisk {
+				return slot
+			}
+		}
+	}
+	return slots[0]
+}
+
+func (instance *SInstance) GetDisks() []SDisk {
+	disks := []SDisk{}
+	boot := instance.bootDisk()
+	for _, slot := range instance.diskSlots() {
+		disk := newDisk(instance, slot, instance.config[slot])
+		disk.IsSys = slot == boot
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+func (instance *SInstance) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks := instance.GetDisks()
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		disks[i].region = instance.getRegion()
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (instance *SInstance) GetNics() ([]SInstanceNic, error) {
+	nics := []SInstanceNic{}
+	for k, v := range instance.config {
+		m := nicSlotReg.FindStringSubmatch(k)
+		if m == nil {
+			continue
+		}
+		nic := newInstanceNic(instance, k, v, instance.config["ipconfig"+m[1]])
+		nics = append(nics, nic)
+	}
+	sort.Slice(nics, func(i, j int) bool { return nics[i].Slot < nics[j].Slot })
+	return nics, nil
+}
+
+func (instance *SInstance) GetINics() ([]cloudprovider.ICloudNic, error) {
+	nics, err := instance.GetNics()
+	if err != nil {
+		return nil, err
+	}
+	inics := []cloudprovider.ICloudNic{}
+	for i := range nics {
+		inics = append(inics, &nics[i])
+	}
+	return inics, nil
+}
+
+func (instance *SInstance) GetIEIP() (cloudprovider.ICloudEIP, error) {
+	return nil, nil
+}
+
+func (instance *SInstance) GetIHost() cloudprovider.ICloudHost {
+	return instance.host
+}
+
+func (instance *SInstance) GetIHostId() string {
+	return instance.Node
+}
+
+func (instance *SInstance) GetId() string {
+	return strconv.Itoa(instance.Vmid)
+}
+
+func (instance *SInstance) GetName() string {
+	return instance.Name
+}
+
+func (instance *SInstance) GetGlobalId() string {
+	return instance.GetId()
+}
+
+func (instance *SInstance) IsEmulated() bool {
+	return false
+}
+
+func (instance *SInstance) GetInstanceType() string {
+	return ""
+}
+
+func (instance *SInstance) GetVcpuCount() int {
+	if vcpus, err := strconv.Atoi(instance.config["vcpus"]); err == nil && vcpus > 0 {
+		return vcpus
+	}
+	cores, sockets := 1, 1
+	if v, err := strconv.Atoi(instance.config["cores"]); err == nil && v > 0 {
+		cores = v
+	}
+	if v, err := strconv.Atoi(instance.config["sockets"]); err == nil && v > 0 {
+		sockets = v
+	}
+	return cores * sockets
+}
+
+func (instance *SInstance) GetVmemSizeMB() int {
+	if memory, err := strconv.Atoi(instance.config["memory"]); err == nil {
+		return memory
+	}
+	return int(instance.Maxmem / 1024 / 1024)
+}
+
+func (instance *SInstance) GetBootOrder() string {
+	boot := instance.config["boot"]
+	if len(boot) == 0 {
+		return "cdn"
+	}
+	if !strings.HasPrefix(boot, "order=") {
+		return boot
+	}
+	order := ""
+	for _, dev := range strings.Split(strings.TrimPrefix(boot, "order="), ";") {
+		c := "c"
+		if strings.HasPrefix(dev, "net") {
+			c = "n"
+		} else if _, opts := parseOptions(instance.config[dev]); opts["media"] == "cdrom" {
+			c = "d"
+		}
+		if !strings.Contains(order, c) {
+			order += c
+		}
+	}
+	return order
+}
+
+func (instance *SInstance) GetVga() string {
+	vga, _ := parseOptions(instance.config["vga"])
+	if len(vga) == 0 {
+		return "std"
+	}
+	return vga
+}
+
+func (instance *SInstance) GetVdi() string {
+	return "vnc"
+}
+
+func (instance *SInstance) GetOSType() string {
+	if strings.HasPrefix(instance.config["ostype"], "w") {
+		return osprofile.OS_TYPE_WINDOWS
+	}
+	return osprofile.OS_TYPE_LINUX
+}
+
+func (instance *SInstance) GetOSName() string {
+	return instance.config["ostype"]
+}
+
+func (instance *SInstance) GetBios() string {
+	if instance.config["bios"] == "ovmf" {
+		return "UEFI"
+	}
+	return "BIOS"
+}
+
+func (instance *SInstance) GetMachine() string {
+	if strings.Contains(instance.config["machine"], "q35") {
+		return "q35"
+	}
+	return "pc"
+}
+
+func (instance *SInstance) GetStatus() string {
+	switch instance.Status {
+	case "running":
+		return api.VM_RUNNING
+	case "stopped":
+		return api.VM_READY
+	default:
+		log.Errorf("Unknown instance %s status %s", instance.Name, instance.Status)
+		return api.VM_UNKNOWN
+	}
+}
+
+func (instance *SInstance) Refresh() error {
+	newInstance, err := instance.getRegion().getVm(instance.GetId(), false)
+	if err != nil {
+		return err
+	}
+	instance.config = newInstance.config
+	return jsonutils.Update(instance, newInstance)
+}
+
+func (instance *SInstance) GetHypervisor() string {
+	return api.HYPERVISOR_PROXMOX
+}
+
+func (instance *SInstance) GetSecurityGroupIds() ([]string, error) {
+	return []string{}, nil
+}
+
+func (instance *SInstance) AssignSecurityGroup(secgroupId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) SetSecurityGroups(secgroupIds []string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) StartVM(ctx context.Context) error {
+	err := instance.getRegion().client.doTask(httputils.POST, instance.vmPath("status/start"), nil)
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_RUNNING, 5*time.Second, 5*time.Minute)
+}
+
+func (instance *SInstance) StopVM(ctx context.Context, opts *cloudprovider.ServerStopOptions) error {
+	action := "status/shutdown"
+	if opts.IsForce {
+		action = "status/stop"
+	}
+	err := instance.getRegion().client.doTask(httputils.POST, instance.vmPath(action), nil)
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_READY, 5*time.Second, 5*time.Minute)
+}
+
+func (instance *SInstance) DeleteVM(ctx context.Context) error {
+	if instance.Status == "running" {
+		err := instance.StopVM(ctx, &cloudprovider.ServerStopOptions{IsForce: true})
+		if err != nil {
+			return errors.Wrapf(err, "StopVM")
+		}
+	}
+	return instance.getRegion().DeleteVM(instance.Node, instance.Vmid)
+}
+
+func (region *SRegion) DeleteVM(node string, vmid int) error {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d?purge=1&destroy-unreferenced-disks=1", node, vmid)
+	return region.client.doTask(httputils.DELETE, path, nil)
+}
+
+func (region *SRegion) StartVM(node string, vmid int) error {
+	return region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu/%d/status/start", node, vmid), nil)
+}
+
+func (region *SRegion) StopVM(node string, vmid int, isForce bool) error {
+	action := "shutdown"
+	if isForce {
+		action = "stop"
+	}
+	return region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu/%d/status/%s", node, vmid, action), nil)
+}
+
+func (region *SRegion) UpdateVMConfig(node string, vmid int, params map[string]interface{}) error {
+	return region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu/%d/config", node, vmid), jsonutils.Marshal(params))
+}
+
+func (instance *SInstance) UpdateVM(ctx context.Context, name string) error {
+	return instance.getRegion().UpdateVMConfig(instance.Node, instance.Vmid, map[string]interface{}{"name": name})
+}
+
+func (instance *SInstance) UpdateUserData(userData string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+// 通过cloud-init设置登录信息, 需虚拟机配置了cloud-init驱动器
+func (instance *SInstance) DeployVM(ctx context.Context, name string, username string, password string, publicKey string, deleteKeypair bool, description string) error {
+	params := map[string]interface{}{}
+	if len(name) > 0 && name != instance.Name {
+		params["name"] = name
+	}
+	if len(description) > 0 {
+		params["description"] = description
+	}
+	if len(username) > 0 {
+		params["ciuser"] = username
+	}
+	if len(password) > 0 {
+		params["cipassword"] = password
+	}
+	if len(publicKey) > 0 {
+		params["sshkeys"] = url.PathEscape(publicKey)
+	} else if deleteKeypair {
+		params["delete"] = "sshkeys"
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return instance.getRegion().UpdateVMConfig(instance.Node, instance.Vmid, params)
+}
+
+func (instance *SInstance) RebuildRoot(ctx context.Context, desc *cloudprovider.SManagedVMRebuildRootConfig) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) ChangeConfig(ctx context.Context, config *cloudprovider.SManagedVMChangeConfig) error {
+	params := map[string]interface{}{}
+	if config.Cpu > 0 {
+		params["cores"] = config.Cpu
+		params["sockets"] = 1
+	}
+	if config.MemoryMB > 0 {
+		params["memory"] = config.MemoryMB
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return instance.getRegion().UpdateVMConfig(instance.Node, instance.Vmid, params)
+}
+
+func (instance *SInstance) GetVNCInfo() (jsonutils.JSONObject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (instance *SInstance) AttachDisk(ctx context.Context, diskId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) DetachDisk(ctx context.Context, diskId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) CreateDisk(ctx context.Context, sizeMb int, uuid string, driver string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) Renew(bc billing.SBillingCycle) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) GetProjectId() string {
+	return ""
+}
+
+func (instance *SInstance) GetError() error {
+	return nil
+}
+
+func (region *SRegion) GetNextVmid() (int, error) {
+	resp, err := region.client.jsonRequest(httputils.GET, "/cluster/nextid", nil)
+	if err != nil {
+		return 0, err
+	}
+	if vmid, err := resp.Int("data"); err == nil {
+		return int(vmid), nil
+	}
+	idStr, err := resp.GetString("data")
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid nextid response %s", resp)
+	}
+	return strconv.Atoi(idStr)
+}
+
+// 指定镜像时从模板完整克隆, 否则新建空白虚拟机; 之后调整配置、添加数据盘并开机
+func (region *SRegion) CreateVM(node string, desc *cloudprovider.SManagedVMCreateConfig) (string, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return "", err
+	}
+	sysStorage, err := zone.getStorage(node, storageNameOf(desc.SysDisk.StorageExternalId))
+	if err != nil {
+		return "", errors.Wrapf(err, "sys disk storage")
+	}
+	vmid, err := region.GetNextVmid()
+	if err != nil {
+		return "", errors.Wrapf(err, "GetNextVmid")
+	}
+	params := map[string]interface{}{
+		"name":    desc.Name,
+		"cores":   desc.Cpu,
+		"sockets": 1,
+		"memory":  desc.MemoryMB,
+	}
+	if len(desc.Description) > 0 {
+		params["description"] = desc.Description
+	}
+	if len(desc.ExternalNetworkId) > 0 {
+		network, err := region.GetNetwork(desc.ExternalNetworkId)
+		if err != nil {
+			return "", errors.Wrapf(err, "GetNetwork(%s)", desc.ExternalNetworkId)
+		}
+		params["net0"] = network.nicConfig("virtio")
+		if len(desc.IpAddr) > 0 {
+			params["ipconfig0"] = network.ipConfig(desc.IpAddr)
+		}
+	}
+
+	if len(desc.ExternalImageId) > 0 {
+		image, err := region.GetImage(desc.ExternalImageId)
+		if err != nil {
+			return "", errors.Wrapf(err, "GetImage(%s)", desc.ExternalImageId)
+		}
+		clone := map[string]interface{}{
+			"newid":   vmid,
+			"name":    desc.Name,
+			"target":  node,
+			"full":    1,
+			"storage": sysStorage.Storage,
+		}
+		err = region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu/%d/clone", image.Node, image.Vmid), jsonutils.Marshal(clone))
+		if err != nil {
+			return "", errors.Wrapf(err, "clone template %d", image.Vmid)
+		}
+	} else {
+		create := map[string]interface{}{
+			"vmid":   vmid,
+			"name":   desc.Name,
+			"scsihw": "virtio-scsi-pci",
+			"scsi0":  fmt.Sprintf("%s:%d", sysStorage.Storage, desc.SysDisk.SizeGB),
+			"boot":   "order=scsi0;net0",
+		}
+		if desc.OsType == osprofile.OS_TYPE_WINDOWS {
+			create["ostype"] = "win10"
+		} else {
+			create["ostype"] = "l26"
+		}
+		err = region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu", node), jsonutils.Marshal(create))
+		if err != nil {
+			return "", errors.Wrapf(err, "create vm")
+		}
+	}
+
+	cleanup := func() {
+		if err := region.DeleteVM(node, vmid); err != nil {
+			log.Errorf("clean vm %d error: %v", vmid, err)
+		}
+	}
+
+	if len(desc.Account) > 0 {
+		params["ciuser"] = desc.Account
+	}
+	if len(desc.Password) > 0 {
+		params["cipassword"] = desc.Password
+	}
+	if len(desc.PublicKey) > 0 {
+		params["sshkeys"] = url.PathEscape(desc.PublicKey)
+	}
+	for i, disk := range desc.DataDisks {
+		storageName := sysStorage.Storage
+		if len(disk.StorageExternalId) > 0 {
+			storageName = storageNameOf(disk.StorageExternalId)
+		}
+		params[fmt.Sprintf("scsi%d", i+1)] = fmt.Sprintf("%s:%d", storageName, disk.SizeGB)
+	}
+	err = region.UpdateVMConfig(node, vmid, params)
+	if err != nil {
+		defer cleanup()
+		return "", errors.Wrapf(err, "UpdateVMConfig")
+	}
+
+	if len(desc.ExternalImageId) > 0 && desc.SysDisk.SizeGB > 0 {
+		instance, err := region.getVm(strconv.Itoa(vmid), false)
+		if err != nil {
+			defer cleanup()
+			return "", errors.Wrapf(err, "get vm %d", vmid)
+		}
+		for _, disk := range instance.GetDisks() {
+			if disk.IsSys && disk.SizeMB < desc.SysDisk.SizeGB*1024 {
+				err = region.ResizeDisk(node, vmid, disk.Slot, desc.SysDisk.SizeGB*1024)
+				if err != nil {
+					log.Warningf("failed to resize system disk of vm %d error: %v", vmid, err)
+				}
+			}
+		}
+	}
+
+	err = region.StartVM(node, vmid)
+	if err != nil {
+		return "", errors.Wrapf(err, "StartVM")
+	}
+	return strconv.Itoa(vmid), nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"strconv"
+	"strings"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+// 网卡配置如 net0: virtio=BC:24:11:2E:6F:0A,bridge=vmbr0,tag=10, 静态地址来自cloud-init的ipconfigN
+type SInstanceNic struct {
+	instance *SInstance
+
+	Slot    string
+	Model   string
+	Mac     string
+	Bridge  string
+	Tag     int
+	IP      string
+	Gateway string
+
+	cloudprovider.DummyICloudNic
+}
+
+func newInstanceNic(instance *SInstance, slot, value, ipconfig string) SInstanceNic {
+	first, opts := parseOptions(value)
+	nic := SInstanceNic{instance: instance, Slot: slot, Bridge: opts["bridge"]}
+	if idx := strings.Index(first, "="); idx > 0 {
+		nic.Model, nic.Mac = first[:idx], strings.ToLower(first[idx+1:])
+	} else {
+		nic.Model, nic.Mac = first, strings.ToLower(opts["macaddr"])
+	}
+	nic.Tag, _ = strconv.Atoi(opts["tag"])
+	if len(ipconfig) > 0 {
+		_, ipOpts := parseOptions(ipconfig)
+		if ip := ipOpts["ip"]; len(ip) > 0 && ip != "dhcp" {
+			nic.IP = strings.Split(ip, "/")[0]
+		}
+		nic.Gateway = ipOpts["gw"]
+	}
+	return nic
+}
+
+func (nic *SInstanceNic) GetId() string {
+	return ""
+}
+
+func (nic *SInstanceNic) GetIP() string {
+	return nic.IP
+}
+
+func (nic *SInstanceNic) GetMAC() string {
+	return nic.Mac
+}
+
+func (nic *SInstanceNic) GetDriver() string {
+	return nic.Model
+}
+
+func (nic *SInstanceNic) InClassicNetwork() bool {
+	return false
+}
+
+func (nic *SInstanceNic) GetINetwork() cloudprovider.ICloudNetwork {
+	region := nic.instance.getRegion()
+	wire, err := region.GetWire(nic.Bridge)
+	if err != nil {
+		log.Errorf("failed to found wire %s for nic %s error: %v", nic.Bridge, nic.Mac, err)
+		return nil
+	}
+	for i := range wire.networks {
+		network := &wire.networks[i]
+		if network.Tag != nic.Tag {
+			continue
+		}
+		if len(nic.IP) > 0 && !network.Contains(nic.IP) {
+			continue
+		}
+		return network
+	}
+	return nil
+}
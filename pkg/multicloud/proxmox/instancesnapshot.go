@@ -0,0 +1,146 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+// Proxmox的快照是整机快照, 以快照名称作为Id
+type SInstanceSnapshot struct {
+	multicloud.SVirtualResourceBase
+	instance *SInstance
+
+	Name        string
+	Description string
+	Parent      string
+	Snaptime    int64
+	Vmstate     int
+}
+
+func (region *SRegion) GetInstanceSnapshots(node string, vmid int) ([]SInstanceSnapshot, error) {
+	snapshots := []SInstanceSnapshot{}
+	err := region.client.get(fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", node, vmid), &snapshots)
+	if err != nil {
+		return nil, err
+	}
+	ret := []SInstanceSnapshot{}
+	for i := range snapshots {
+		// current表示当前运行状态, 不是真正的快照
+		if snapshots[i].Name != "current" {
+			ret = append(ret, snapshots[i])
+		}
+	}
+	return ret, nil
+}
+
+func (region *SRegion) CreateInstanceSnapshot(node string, vmid int, name, desc string) error {
+	params := map[string]interface{}{
+		"snapname":    name,
+		"description": desc,
+	}
+	return region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", node, vmid), jsonutils.Marshal(params))
+}
+
+func (region *SRegion) DeleteInstanceSnapshot(node string, vmid int, name string) error {
+	return region.client.doTask(httputils.DELETE, fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s", node, vmid, name), nil)
+}
+
+func (region *SRegion) RollbackInstanceSnapshot(node string, vmid int, name string) error {
+	return region.client.doTask(httputils.POST, fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s/rollback", node, vmid, name), nil)
+}
+
+func (instance *SInstance) GetInstanceSnapshots() ([]cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshots, err := instance.getRegion().GetInstanceSnapshots(instance.Node, instance.Vmid)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudInstanceSnapshot{}
+	for i := range snapshots {
+		snapshots[i].instance = instance
+		ret = append(ret, &snapshots[i])
+	}
+	return ret, nil
+}
+
+func (instance *SInstance) GetInstanceSnapshot(idStr string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshots, err := instance.GetInstanceSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		if snapshots[i].GetGlobalId() == idStr {
+			return snapshots[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance snapshot %s", idStr)
+}
+
+func (instance *SInstance) CreateInstanceSnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	err := instance.getRegion().CreateInstanceSnapshot(instance.Node, instance.Vmid, name, desc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CreateInstanceSnapshot")
+	}
+	return instance.GetInstanceSnapshot(name)
+}
+
+func (instance *SInstance) ResetToInstanceSnapshot(ctx context.Context, idStr string) error {
+	return instance.getRegion().RollbackInstanceSnapshot(instance.Node, instance.Vmid, idStr)
+}
+
+func (snapshot *SInstanceSnapshot) GetId() string {
+	return snapshot.Name
+}
+
+func (snapshot *SInstanceSnapshot) GetName() string {
+	return snapshot.Name
+}
+
+func (snapshot *SInstanceSnapshot) GetGlobalId() string {
+	return snapshot.Name
+}
+
+func (snapshot *SInstanceSnapshot) GetStatus() string {
+	return api.INSTANCE_SNAPSHOT_READY
+}
+
+func (snapshot *SInstanceSnapshot) Refresh() error {
+	newSnapshot, err := snapshot.instance.GetInstanceSnapshot(snapshot.Name)
+	if err != nil {
+		return err
+	}
+	return jsonutils.Update(snapshot, newSnapshot)
+}
+
+func (snapshot *SInstanceSnapshot) IsEmulated() bool {
+	return false
+}
+
+func (snapshot *SInstanceSnapshot) GetDescription() string {
+	return snapshot.Description
+}
+
+func (snapshot *SInstanceSnapshot) Delete() error {
+	return snapshot.instance.getRegion().DeleteInstanceSnapshot(snapshot.instance.Node, snapshot.instance.Vmid, snapshot.Name)
+}
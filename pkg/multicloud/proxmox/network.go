@@ -0,0 +1,149 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SNetwork struct {
+	multicloud.SResourceBase
+	wire *SWire
+
+	Iface   string
+	Bridge  string
+	Tag     int
+	Gateway string
+	MaskLen int8
+
+	netAddr netutils.IPV4Addr
+	// 各节点在该网段上的地址
+	addrs   []netutils.IPV4Addr
+	ipRange netutils.IPV4AddrRange
+}
+
+// 可分配地址从网关及节点地址之后开始, 避免与其冲突
+func (network *SNetwork) fixRange() {
+	start := network.netAddr.StepUp()
+	end := network.netAddr.BroadcastAddr(network.MaskLen).StepDown()
+	used := append([]netutils.IPV4Addr{}, network.addrs...)
+	if gw, err := netutils.NewIPV4Addr(network.Gateway); err == nil {
+		used = append(used, gw)
+	}
+	for _, addr := range used {
+		if addr >= start && addr < end {
+			start = addr.StepUp()
+		}
+	}
+	network.ipRange = netutils.NewIPV4AddrRange(start, end)
+}
+
+func (network *SNetwork) nicConfig(model string) string {
+	config := fmt.Sprintf("%s,bridge=%s", model, network.Bridge)
+	if network.Tag > 0 {
+		config = fmt.Sprintf("%s,tag=%d", config, network.Tag)
+	}
+	return config
+}
+
+func (network *SNetwork) ipConfig(ipAddr string) string {
+	return fmt.Sprintf("ip=%s/%d,gw=%s", ipAddr, network.MaskLen, network.GetGateway())
+}
+
+func (network *SNetwork) GetId() string {
+	return network.Iface
+}
+
+func (network *SNetwork) GetName() string {
+	return network.Iface
+}
+
+func (network *SNetwork) GetGlobalId() string {
+	return network.Iface
+}
+
+func (network *SNetwork) IsEmulated() bool {
+	return false
+}
+
+func (network *SNetwork) GetStatus() string {
+	return api.NETWORK_STATUS_AVAILABLE
+}
+
+func (network *SNetwork) Refresh() error {
+	return nil
+}
+
+func (network *SNetwork) Delete() error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (network *SNetwork) GetIWire() cloudprovider.ICloudWire {
+	return network.wire
+}
+
+func (network *SNetwork) GetAllocTimeoutSeconds() int {
+	return 120 // 2 minutes
+}
+
+func (network *SNetwork) GetGateway() string {
+	if len(network.Gateway) > 0 {
+		return network.Gateway
+	}
+	return network.netAddr.StepUp().String()
+}
+
+func (network *SNetwork) GetIpStart() string {
+	return network.ipRange.StartIp().String()
+}
+
+func (network *SNetwork) GetIpEnd() string {
+	return network.ipRange.EndIp().String()
+}
+
+func (network *SNetwork) GetIpMask() int8 {
+	return network.MaskLen
+}
+
+func (network *SNetwork) Contains(ipAddr string) bool {
+	ip, err := netutils.NewIPV4Addr(ipAddr)
+	if err != nil {
+		return false
+	}
+	return ip.NetAddr(network.MaskLen) == network.netAddr
+}
+
+func (network *SNetwork) GetIsPublic() bool {
+	return true
+}
+
+func (network *SNetwork) GetPublicScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (network *SNetwork) GetServerType() string {
+	return api.NETWORK_TYPE_GUEST
+}
+
+func (network *SNetwork) GetProjectId() string {
+	return ""
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+)
+
+type SProxmoxProviderFactory struct {
+	cloudprovider.SPrivateCloudBaseProviderFactory
+}
+
+func (self *SProxmoxProviderFactory) GetId() string {
+	return proxmox.CLOUD_PROVIDER_PROXMOX
+}
+
+func (self *SProxmoxProviderFactory) GetName() string {
+	return proxmox.CLOUD_PROVIDER_PROXMOX
+}
+
+// 用户名可为 root@pam 形式的账号, 也可为 root@pam!tokenid 形式的API Token
+func (self *SProxmoxProviderFactory) ValidateCreateCloudaccountData(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	if len(input.AuthUrl) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "auth_url")
+	}
+	output.AccessUrl = input.AuthUrl
+	if len(input.Username) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "username")
+	}
+	if len(input.Password) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "password")
+	}
+	output.Account = input.Username
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SProxmoxProviderFactory) ValidateUpdateCloudaccountCredential(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential, cloudaccount string) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	if len(input.Username) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "username")
+	}
+	if len(input.Password) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "password")
+	}
+	output.Account = input.Username
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SProxmoxProviderFactory) GetProvider(cfg cloudprovider.ProviderConfig) (cloudprovider.ICloudProvider, error) {
+	client, err := proxmox.NewProxmoxClient(
+		proxmox.NewProxmoxClientConfig(
+			cfg.URL, cfg.Account, cfg.Secret,
+		).CloudproviderConfig(cfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &SProxmoxProvider{
+		SClientBaseProvider: multicloud.NewClientBaseProvider(self, client),
+		client:              client,
+	}, nil
+}
+
+func (self *SProxmoxProviderFactory) GetClientRC(info cloudprovider.SProviderInfo) (map[string]string, error) {
+	return map[string]string{
+		"PROXMOX_AUTH_URL":  info.Url,
+		"PROXMOX_USERNAME":  info.Account,
+		"PROXMOX_PASSWORD":  info.Secret,
+		"PROXMOX_REGION_ID": proxmox.PROXMOX_DEFAULT_REGION,
+	}, nil
+}
+
+func init() {
+	factory := SProxmoxProviderFactory{}
+	cloudprovider.RegisterFactory(&factory)
+}
+
+type SProxmoxProvider struct {
+	multicloud.SClientBaseProvider
+	client *proxmox.SProxmoxClient
+}
+
+func (self *SProxmoxProvider) GetVersion() string {
+	version, err := self.client.GetVersion()
+	if err != nil {
+		return ""
+	}
+	return version.Version
+}
+
+func (self *SProxmoxProvider) GetSysInfo() (jsonutils.JSONObject, error) {
+	version, err := self.client.GetVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersion")
+	}
+	return jsonutils.Marshal(version), nil
+}
@@ -0,0 +1,319 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	CLOUD_PROVIDER_PROXMOX = api.CLOUD_PROVIDER_PROXMOX
+	PROXMOX_DEFAULT_REGION = "Proxmox"
+	PROXMOX_API_PREFIX     = "/api2/json"
+
+	// 异步任务(UPID)默认状态轮询间隔
+	DEFAULT_TASK_POLL_INTERVAL = 2 * time.Second
+)
+
+var (
+	// 异步任务(UPID)超时时间
+	TaskTimeout = 30 * time.Minute
+)
+
+type ProxmoxClientConfig struct {
+	cpcfg cloudprovider.ProviderConfig
+
+	authURL  string
+	username string
+	password string
+
+	pollInterval time.Duration
+
+	debug bool
+}
+
+func NewProxmoxClientConfig(authURL, username, password string) *ProxmoxClientConfig {
+	cfg := &ProxmoxClientConfig{
+		authURL:      strings.TrimSuffix(strings.TrimSuffix(authURL, "/"), PROXMOX_API_PREFIX),
+		username:     username,
+		password:     password,
+		pollInterval: DEFAULT_TASK_POLL_INTERVAL,
+	}
+	return cfg
+}
+
+func (cfg *ProxmoxClientConfig) CloudproviderConfig(cpcfg cloudprovider.ProviderConfig) *ProxmoxClientConfig {
+	cfg.cpcfg = cpcfg
+	return cfg
+}
+
+func (cfg *ProxmoxClientConfig) Debug(debug bool) *ProxmoxClientConfig {
+	cfg.debug = debug
+	return cfg
+}
+
+// PollInterval 设置异步任务状态轮询间隔
+func (cfg *ProxmoxClientConfig) PollInterval(interval time.Duration) *ProxmoxClientConfig {
+	cfg.pollInterval = interval
+	return cfg
+}
+
+type SProxmoxClient struct {
+	*ProxmoxClientConfig
+
+	httpClient *http.Client
+
+	ticket    string
+	csrfToken string
+
+	iregions []cloudprovider.ICloudRegion
+}
+
+type SVersion struct {
+	Version string
+	Release string
+	Repoid  string
+}
+
+func NewProxmoxClient(cfg *ProxmoxClientConfig) (*SProxmoxClient, error) {
+	cli := &SProxmoxClient{
+		ProxmoxClientConfig: cfg,
+		httpClient:          cfg.cpcfg.AdaptiveTimeoutHttpClient(),
+	}
+	if err := cli.auth(); err != nil {
+		return nil, errors.Wrap(err, "auth")
+	}
+	cli.iregions = []cloudprovider.ICloudRegion{&SRegion{client: cli}}
+	return cli, nil
+}
+
+// 用户名形如 user@realm!tokenid 时使用API Token认证, 否则使用用户名密码换取ticket
+func (cli *SProxmoxClient) isApiToken() bool {
+	return strings.Contains(cli.username, "!")
+}
+
+func (cli *SProxmoxClient) auth() error {
+	if cli.isApiToken() {
+		_, err := cli.GetVersion()
+		return err
+	}
+	cli.ticket, cli.csrfToken = "", ""
+	params := jsonutils.Marshal(map[string]string{
+		"username": cli.username,
+		"password": cli.password,
+	})
+	resp, err := cli._jsonRequest(httputils.POST, "/access/ticket", params)
+	if err != nil {
+		return errors.Wrap(err, "access/ticket")
+	}
+	ticket := struct {
+		Ticket              string `json:"ticket"`
+		CSRFPreventionToken string `json:"CSRFPreventionToken"`
+	}{}
+	err = resp.Unmarshal(&ticket, "data")
+	if err != nil {
+		return errors.Wrap(err, "resp.Unmarshal")
+	}
+	if len(ticket.Ticket) == 0 {
+		return fmt.Errorf("empty ticket for user %s", cli.username)
+	}
+	cli.ticket, cli.csrfToken = ticket.Ticket, ticket.CSRFPreventionToken
+	return nil
+}
+
+func (cli *SProxmoxClient) GetVersion() (*SVersion, error) {
+	version := &SVersion{}
+	return version, cli.get("/version", version)
+}
+
+func (cli *SProxmoxClient) GetCloudRegionExternalIdPrefix() string {
+	return fmt.Sprintf("%s/%s", CLOUD_PROVIDER_PROXMOX, cli.cpcfg.Id)
+}
+
+func (cli *SProxmoxClient) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	subAccount := cloudprovider.SSubAccount{
+		Account:      cli.username,
+		Name:         cli.cpcfg.Name,
+		HealthStatus: api.CLOUD_PROVIDER_HEALTH_NORMAL,
+	}
+	return []cloudprovider.SSubAccount{subAccount}, nil
+}
+
+func (cli *SProxmoxClient) GetAccountId() string {
+	u, err := url.Parse(cli.authURL)
+	if err != nil {
+		return cli.authURL
+	}
+	return u.Host
+}
+
+func (cli *SProxmoxClient) GetIRegions() []cloudprovider.ICloudRegion {
+	return cli.iregions
+}
+
+func (cli *SProxmoxClient) GetRegion(regionId string) *SRegion {
+	for i := 0; i < len(cli.iregions); i++ {
+		if len(regionId) == 0 || cli.iregions[i].GetId() == regionId {
+			return cli.iregions[i].(*SRegion)
+		}
+	}
+	return nil
+}
+
+func (cli *SProxmoxClient) GetIRegionById(id string) (cloudprovider.ICloudRegion, error) {
+	for i := 0; i < len(cli.iregions); i++ {
+		if cli.iregions[i].GetGlobalId() == id {
+			return cli.iregions[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (cli *SProxmoxClient) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (cli *SProxmoxClient) GetCapabilities() []string {
+	caps := []string{
+		cloudprovider.CLOUD_CAPABILITY_COMPUTE,
+		cloudprovider.CLOUD_CAPABILITY_NETWORK,
+	}
+	return caps
+}
+
+func (cli *SProxmoxClient) _jsonRequest(method httputils.THttpMethod, path string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	header := http.Header{}
+	if cli.isApiToken() {
+		header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s=%s", cli.username, cli.password))
+	} else if len(cli.ticket) > 0 {
+		header.Set("Cookie", "PVEAuthCookie="+cli.ticket)
+		if method != httputils.GET {
+			header.Set("CSRFPreventionToken", cli.csrfToken)
+		}
+	}
+	requestURL := cli.authURL + PROXMOX_API_PREFIX + path
+	_, resp, err := httputils.JSONRequest(cli.httpClient, context.Background(), method, requestURL, header, params, cli.debug)
+	return resp, err
+}
+
+func (cli *SProxmoxClient) jsonRequest(method httputils.THttpMethod, path string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	resp, err := cli._jsonRequest(method, path, params)
+	if err != nil {
+		e, ok := err.(*httputils.JSONClientError)
+		// ticket有效期为2小时, 过期后重新认证一次
+		if ok && e.Code == 401 && !cli.isApiToken() {
+			if err := cli.auth(); err != nil {
+				return nil, errors.Wrap(err, "reauth")
+			}
+			resp, err = cli._jsonRequest(method, path, params)
+			e, ok = err.(*httputils.JSONClientError)
+		}
+		if err != nil {
+			if ok && (e.Code == 404 || strings.Contains(e.Details, "does not exist")) {
+				return nil, errors.Wrapf(cloudprovider.ErrNotFound, "%s %s", method, path)
+			}
+			return nil, errors.Wrapf(err, "%s %s", method, path)
+		}
+	}
+	if resp == nil {
+		resp = jsonutils.NewDict()
+	}
+	return resp, nil
+}
+
+func (cli *SProxmoxClient) get(path string, retVal interface{}) error {
+	resp, err := cli.jsonRequest(httputils.GET, path, nil)
+	if err != nil {
+		return err
+	}
+	if !resp.Contains("data") {
+		return errors.Wrapf(cloudprovider.ErrNotFound, "GET %s", path)
+	}
+	return resp.Unmarshal(retVal, "data")
+}
+
+func (cli *SProxmoxClient) post(path string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return cli.jsonRequest(httputils.POST, path, params)
+}
+
+func (cli *SProxmoxClient) put(path string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return cli.jsonRequest(httputils.PUT, path, params)
+}
+
+func (cli *SProxmoxClient) delete(path string) (jsonutils.JSONObject, error) {
+	return cli.jsonRequest(httputils.DELETE, path, nil)
+}
+
+// 修改类接口大多返回任务UPID, 需等待任务结束才能确认执行结果
+func (cli *SProxmoxClient) doTask(method httputils.THttpMethod, path string, params jsonutils.JSONObject) error {
+	resp, err := cli.jsonRequest(method, path, params)
+	if err != nil {
+		return err
+	}
+	upid, _ := resp.GetString("data")
+	if !strings.HasPrefix(upid, "UPID:") {
+		return nil
+	}
+	return cli.waitTask(upid)
+}
+
+type STaskStatus struct {
+	Upid       string
+	Node       string
+	Type       string
+	Status     string
+	Exitstatus string
+}
+
+func (cli *SProxmoxClient) GetTaskStatus(upid string) (*STaskStatus, error) {
+	// UPID:$node:$pid:$pstart:$starttime:$type:$id:$user:
+	parts := strings.Split(upid, ":")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid upid %s", upid)
+	}
+	status := &STaskStatus{}
+	path := fmt.Sprintf("/nodes/%s/tasks/%s/status", parts[1], url.PathEscape(upid))
+	return status, cli.get(path, status)
+}
+
+func (cli *SProxmoxClient) waitTask(upid string) error {
+	startTime := time.Now()
+	for time.Now().Sub(startTime) < TaskTimeout {
+		status, err := cli.GetTaskStatus(upid)
+		if err != nil {
+			return errors.Wrapf(err, "GetTaskStatus")
+		}
+		if status.Status == "stopped" {
+			if status.Exitstatus != "OK" {
+				return fmt.Errorf("task %s failed: %s", upid, status.Exitstatus)
+			}
+			return nil
+		}
+		time.Sleep(cli.pollInterval)
+	}
+	return errors.Wrapf(cloudprovider.ErrTimeout, "wait task %s", upid)
+}
@@ -0,0 +1,257 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/test/replay"
+)
+
+// 除获取票据外, 请求需带有票据cookie, 写操作还需带有CSRF令牌
+func checkAuth(r *http.Request) bool {
+	if r.URL.Path == PROXMOX_API_PREFIX+"/access/ticket" {
+		return true
+	}
+	if cookie, err := r.Cookie("PVEAuthCookie"); err != nil || !strings.Contains(cookie.Value, "fixture-ticket") {
+		return false
+	}
+	return r.Method == http.MethodGet || r.Header.Get("CSRFPreventionToken") == "6529A1B2:fixture-csrf"
+}
+
+func newTestRegion(t *testing.T) (*SRegion, func()) {
+	srv := replay.NewReplayServer(t, replay.SReplayConfig{
+		ApiPrefix:      PROXMOX_API_PREFIX,
+		NotFoundFormat: `{"data":null,"message":"%s does not exist"}`,
+		Auth:           checkAuth,
+	})
+	cfg := NewProxmoxClientConfig(srv.URL+PROXMOX_API_PREFIX, "root@pam", "password").PollInterval(time.Millisecond)
+	cli, err := NewProxmoxClient(cfg)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("NewProxmoxClient: %v", err)
+	}
+	return cli.GetRegion(""), srv.Close
+}
+
+func TestHostsAndStorages(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	zone, err := region.getZone()
+	if err != nil {
+		t.Fatalf("getZone: %v", err)
+	}
+	if zone.GetId() != "pvelab" {
+		t.Errorf("zone id got %s want pvelab", zone.GetId())
+	}
+	ihost, err := zone.GetIHostById("pve1")
+	if err != nil {
+		t.Fatalf("GetIHostById: %v", err)
+	}
+	host := ihost.(*SHost)
+	if host.GetAccessIp() != "192.168.10.11" || host.GetCpuCount() != 16 || host.GetNodeCount() != 2 {
+		t.Errorf("unexpected host pve1: ip %s cpu %d sockets %d", host.GetAccessIp(), host.GetCpuCount(), host.GetNodeCount())
+	}
+	if host.GetStatus() != api.HOST_STATUS_RUNNING {
+		t.Errorf("host status got %s", host.GetStatus())
+	}
+
+	storages, err := region.GetStorages("")
+	if err != nil {
+		t.Fatalf("GetStorages: %v", err)
+	}
+	ids := []string{}
+	for i := range storages {
+		ids = append(ids, storages[i].GetGlobalId())
+	}
+	// local 不存放磁盘镜像, 共享存储 ceph 合并为一个
+	if strings.Join(ids, ",") != "pve1/local-lvm,pve2/local-lvm,ceph" {
+		t.Errorf("unexpected storages %v", ids)
+	}
+	istorages, err := host.GetIStorages()
+	if err != nil {
+		t.Fatalf("GetIStorages: %v", err)
+	}
+	if len(istorages) != 2 {
+		t.Errorf("pve1 should have 2 storages, got %d", len(istorages))
+	}
+}
+
+func TestInstanceDisksAndNics(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	instances, err := region.GetInstances("")
+	if err != nil {
+		t.Fatalf("GetInstances: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("templates and containers should be skipped, got %d instances", len(instances))
+	}
+
+	vm, err := region.GetInstance("100")
+	if err != nil {
+		t.Fatalf("GetInstance: %v", err)
+	}
+	if vm.GetStatus() != api.VM_RUNNING || vm.GetVcpuCount() != 2 || vm.GetVmemSizeMB() != 4096 {
+		t.Errorf("unexpected vm: status %s cpu %d mem %d", vm.GetStatus(), vm.GetVcpuCount(), vm.GetVmemSizeMB())
+	}
+	if vm.GetBios() != "UEFI" || vm.GetMachine() != "q35" || vm.GetBootOrder() != "cdn" {
+		t.Errorf("unexpected vm: bios %s machine %s boot %s", vm.GetBios(), vm.GetMachine(), vm.GetBootOrder())
+	}
+	disks := vm.GetDisks()
+	if len(disks) != 2 {
+		t.Fatalf("cdrom and cloudinit should be skipped, got %d disks", len(disks))
+	}
+	for i := range disks {
+		disks[i].region = region
+	}
+	if !disks[0].IsSys || disks[0].GetDiskSizeMB() != 32*1024 || disks[0].GetCacheMode() != "writeback" || disks[0].GetIStorageId() != "pve1/local-lvm" {
+		t.Errorf("unexpected sys disk %+v storage %s", disks[0], disks[0].GetIStorageId())
+	}
+	if disks[1].IsSys || disks[1].GetDiskSizeMB() != 100*1024 || disks[1].GetIStorageId() != "ceph" {
+		t.Errorf("unexpected data disk %+v storage %s", disks[1], disks[1].GetIStorageId())
+	}
+
+	nics, err := vm.GetNics()
+	if err != nil {
+		t.Fatalf("GetNics: %v", err)
+	}
+	if len(nics) != 2 {
+		t.Fatalf("want 2 nics, got %d", len(nics))
+	}
+	if nics[0].GetMAC() != "bc:24:11:2e:6f:0a" || nics[0].GetIP() != "192.168.10.50" || nics[0].GetDriver() != "virtio" {
+		t.Errorf("unexpected nic0 %+v", nics[0])
+	}
+	if network := nics[0].GetINetwork(); network == nil || network.GetGlobalId() != "vmbr0" {
+		t.Errorf("nic0 should be in network vmbr0, got %v", network)
+	}
+	if network := nics[1].GetINetwork(); network == nil || network.GetGlobalId() != "vmbr0.100" {
+		t.Errorf("nic1 should be in network vmbr0.100, got %v", network)
+	}
+
+	db, err := region.GetInstance("101")
+	if err != nil {
+		t.Fatalf("GetInstance: %v", err)
+	}
+	if db.GetVcpuCount() != 4 || db.GetOSType() != "Windows" || db.GetStatus() != api.VM_READY {
+		t.Errorf("unexpected vm 101: cpu %d os %s status %s", db.GetVcpuCount(), db.GetOSType(), db.GetStatus())
+	}
+	if disks := db.GetDisks(); len(disks) != 1 || !disks[0].IsSys || disks[0].GetDriver() != "virtio" {
+		t.Errorf("unexpected disks of vm 101 %+v", disks)
+	}
+}
+
+func TestWiresAndNetworks(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	wires, err := region.GetWires("")
+	if err != nil {
+		t.Fatalf("GetWires: %v", err)
+	}
+	if len(wires) != 2 {
+		t.Fatalf("want bridges vmbr0 and vmbr1, got %d", len(wires))
+	}
+	if wires[0].Bridge != "vmbr0" || len(wires[0].Nodes) != 2 || len(wires[0].networks) != 3 {
+		t.Errorf("unexpected wire %+v", wires[0])
+	}
+	network, err := region.GetNetwork("vmbr0")
+	if err != nil {
+		t.Fatalf("GetNetwork: %v", err)
+	}
+	// 网关及两台节点地址之后开始分配
+	if network.GetIpStart() != "192.168.10.13" || network.GetIpEnd() != "192.168.10.254" || network.GetGateway() != "192.168.10.1" || network.GetIpMask() != 24 {
+		t.Errorf("unexpected network %s-%s gw %s mask %d", network.GetIpStart(), network.GetIpEnd(), network.GetGateway(), network.GetIpMask())
+	}
+	vlan, err := region.GetNetwork("vmbr0.100")
+	if err != nil {
+		t.Fatalf("GetNetwork: %v", err)
+	}
+	if vlan.Tag != 100 || vlan.nicConfig("virtio") != "virtio,bridge=vmbr0,tag=100" || vlan.ipConfig("10.100.0.20") != "ip=10.100.0.20/24,gw=10.100.0.1" {
+		t.Errorf("unexpected vlan network %s %s", vlan.nicConfig("virtio"), vlan.ipConfig("10.100.0.20"))
+	}
+	other, err := region.GetNetwork("vlan100")
+	if err != nil {
+		t.Fatalf("GetNetwork: %v", err)
+	}
+	if other.Bridge != "vmbr0" || other.Tag != 100 {
+		t.Errorf("vlan-raw-device not parsed: %+v", other)
+	}
+}
+
+func TestImagesAndSnapshots(t *testing.T) {
+	region, closeFunc := newTestRegion(t)
+	defer closeFunc()
+
+	images, err := region.GetImages()
+	if err != nil {
+		t.Fatalf("GetImages: %v", err)
+	}
+	if len(images) != 1 || images[0].GetGlobalId() != "9000" || images[0].GetMinOsDiskSizeGb() != 10 {
+		t.Errorf("unexpected images %+v", images)
+	}
+
+	vm, err := region.GetInstance("100")
+	if err != nil {
+		t.Fatalf("GetInstance: %v", err)
+	}
+	snapshot, err := vm.CreateInstanceSnapshot(context.Background(), "before-upgrade", "snapshot before upgrade")
+	if err != nil {
+		t.Fatalf("CreateInstanceSnapshot: %v", err)
+	}
+	if snapshot.GetGlobalId() != "before-upgrade" || snapshot.GetDescription() != "snapshot before upgrade" {
+		t.Errorf("unexpected snapshot %s", snapshot.GetGlobalId())
+	}
+	snapshots, err := vm.GetInstanceSnapshots()
+	if err != nil {
+		t.Fatalf("GetInstanceSnapshots: %v", err)
+	}
+	if len(snapshots) != 1 {
+		t.Errorf("current state should be skipped, got %d snapshots", len(snapshots))
+	}
+
+	_, err = region.GetInstance("999")
+	if errors.Cause(err) != cloudprovider.ErrNotFound {
+		t.Errorf("want not found error, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		In   string
+		Want int
+	}{
+		{"32G", 32768},
+		{"1440M", 1440},
+		{"1T", 1048576},
+		{"2048K", 2},
+		{"1.5G", 1536},
+	}
+	for _, c := range cases {
+		if got := parseSizeMB(c.In); got != c.Want {
+			t.Errorf("parseSizeMB(%s) got %d want %d", c.In, got, c.Want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type HostListOptions struct {
+	}
+	shellutils.R(&HostListOptions{}, "host-list", "List nodes", func(cli *proxmox.SRegion, args *HostListOptions) error {
+		hosts, err := cli.GetHosts()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(hosts, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type HostShowOptions struct {
+		NODE string
+	}
+	shellutils.R(&HostShowOptions{}, "host-show", "Show node status", func(cli *proxmox.SRegion, args *HostShowOptions) error {
+		status, err := cli.GetNodeStatus(args.NODE)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(status)
+		return nil
+	})
+}
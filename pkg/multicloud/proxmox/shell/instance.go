@@ -0,0 +1,123 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type InstanceListOptions struct {
+		Node string
+	}
+	shellutils.R(&InstanceListOptions{}, "instance-list", "List instances", func(cli *proxmox.SRegion, args *InstanceListOptions) error {
+		instances, err := cli.GetInstances(args.Node)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(instances, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceIdOptions struct {
+		ID string `help:"Instance vmid"`
+	}
+	shellutils.R(&InstanceIdOptions{}, "instance-show", "Show instance", func(cli *proxmox.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(instance)
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-nic-list", "List instance nics", func(cli *proxmox.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		nics, err := instance.GetNics()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(nics, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceCreateOptions struct {
+		NODE      string `help:"Node to create instance on"`
+		NAME      string
+		STORAGE   string `help:"Storage of system disk"`
+		Image     string `help:"Template vmid to clone from"`
+		Network   string `help:"Network id, e.g. vmbr0 or vmbr0.100"`
+		Ip        string
+		Cpu       int   `default:"1"`
+		MemoryMb  int   `default:"1024"`
+		DiskGb    int   `default:"30"`
+		DataDisks []int `help:"Data disk sizes in GB"`
+		Password  string
+	}
+	shellutils.R(&InstanceCreateOptions{}, "instance-create", "Create instance", func(cli *proxmox.SRegion, args *InstanceCreateOptions) error {
+		desc := &cloudprovider.SManagedVMCreateConfig{
+			Name:              args.NAME,
+			ExternalImageId:   args.Image,
+			Cpu:               args.Cpu,
+			MemoryMB:          args.MemoryMb,
+			ExternalNetworkId: args.Network,
+			IpAddr:            args.Ip,
+			Password:          args.Password,
+			SysDisk: cloudprovider.SDiskInfo{
+				StorageExternalId: args.STORAGE,
+				SizeGB:            args.DiskGb,
+			},
+		}
+		for _, size := range args.DataDisks {
+			desc.DataDisks = append(desc.DataDisks, cloudprovider.SDiskInfo{SizeGB: size})
+		}
+		vmid, err := cli.CreateVM(args.NODE, desc)
+		if err != nil {
+			return err
+		}
+		instance, err := cli.GetInstance(vmid)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(instance)
+		return nil
+	})
+
+	type InstanceOperationOptions struct {
+		NODE string
+		VMID int
+	}
+	shellutils.R(&InstanceOperationOptions{}, "instance-start", "Start instance", func(cli *proxmox.SRegion, args *InstanceOperationOptions) error {
+		return cli.StartVM(args.NODE, args.VMID)
+	})
+
+	type InstanceStopOptions struct {
+		InstanceOperationOptions
+		Force bool
+	}
+	shellutils.R(&InstanceStopOptions{}, "instance-stop", "Stop instance", func(cli *proxmox.SRegion, args *InstanceStopOptions) error {
+		return cli.StopVM(args.NODE, args.VMID, args.Force)
+	})
+
+	shellutils.R(&InstanceOperationOptions{}, "instance-delete", "Delete instance", func(cli *proxmox.SRegion, args *InstanceOperationOptions) error {
+		return cli.DeleteVM(args.NODE, args.VMID)
+	})
+}
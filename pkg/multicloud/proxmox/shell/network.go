@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type WireListOptions struct {
+		Node string
+	}
+	shellutils.R(&WireListOptions{}, "wire-list", "List bridges", func(cli *proxmox.SRegion, args *WireListOptions) error {
+		wires, err := cli.GetWires(args.Node)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(wires, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type NodeNetworkListOptions struct {
+		NODE string
+	}
+	shellutils.R(&NodeNetworkListOptions{}, "node-network-list", "List network interfaces of node", func(cli *proxmox.SRegion, args *NodeNetworkListOptions) error {
+		ifaces, err := cli.GetNodeNetworks(args.NODE)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(ifaces, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type NetworkShowOptions struct {
+		ID string `help:"Network id, e.g. vmbr0 or vmbr0.100"`
+	}
+	shellutils.R(&NetworkShowOptions{}, "network-show", "Show network", func(cli *proxmox.SRegion, args *NetworkShowOptions) error {
+		network, err := cli.GetNetwork(args.ID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(network)
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	"yunion.io/x/onecloud/pkg/multicloud/test"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	test.TestShell()
+
+	type ClusterStatusOptions struct {
+	}
+	shellutils.R(&ClusterStatusOptions{}, "cluster-status", "Show cluster status", func(cli *proxmox.SRegion, args *ClusterStatusOptions) error {
+		status, err := cli.GetClusterStatus()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(status, 0, 0, 0, nil)
+		return nil
+	})
+
+	type ClusterResourceListOptions struct {
+		Type string `choices:"vm|storage|node|sdn"`
+	}
+	shellutils.R(&ClusterResourceListOptions{}, "cluster-resource-list", "List cluster resources", func(cli *proxmox.SRegion, args *ClusterResourceListOptions) error {
+		resources, err := cli.GetClusterResources(args.Type)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(resources, 0, 0, 0, nil)
+		return nil
+	})
+
+	type VersionShowOptions struct {
+	}
+	shellutils.R(&VersionShowOptions{}, "version-show", "Show Proxmox VE version", func(cli *proxmox.SRegion, args *VersionShowOptions) error {
+		version, err := cli.GetClient().GetVersion()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(version)
+		return nil
+	})
+
+	type TaskShowOptions struct {
+		UPID string
+	}
+	shellutils.R(&TaskShowOptions{}, "task-show", "Show task status", func(cli *proxmox.SRegion, args *TaskShowOptions) error {
+		status, err := cli.GetClient().GetTaskStatus(args.UPID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceObject(status)
+		return nil
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type InstanceSnapshotListOptions struct {
+		NODE string
+		VMID int
+	}
+	shellutils.R(&InstanceSnapshotListOptions{}, "instance-snapshot-list", "List instance snapshots", func(cli *proxmox.SRegion, args *InstanceSnapshotListOptions) error {
+		snapshots, err := cli.GetInstanceSnapshots(args.NODE, args.VMID)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(snapshots, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceSnapshotCreateOptions struct {
+		NODE string
+		VMID int
+		NAME string
+		Desc string
+	}
+	shellutils.R(&InstanceSnapshotCreateOptions{}, "instance-snapshot-create", "Create instance snapshot", func(cli *proxmox.SRegion, args *InstanceSnapshotCreateOptions) error {
+		return cli.CreateInstanceSnapshot(args.NODE, args.VMID, args.NAME, args.Desc)
+	})
+
+	type InstanceSnapshotOptions struct {
+		NODE string
+		VMID int
+		NAME string
+	}
+	shellutils.R(&InstanceSnapshotOptions{}, "instance-snapshot-delete", "Delete instance snapshot", func(cli *proxmox.SRegion, args *InstanceSnapshotOptions) error {
+		return cli.DeleteInstanceSnapshot(args.NODE, args.VMID, args.NAME)
+	})
+
+	shellutils.R(&InstanceSnapshotOptions{}, "instance-snapshot-rollback", "Rollback instance to snapshot", func(cli *proxmox.SRegion, args *InstanceSnapshotOptions) error {
+		return cli.RollbackInstanceSnapshot(args.NODE, args.VMID, args.NAME)
+	})
+}
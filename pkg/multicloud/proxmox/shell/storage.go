@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+	"yunion.io/x/onecloud/pkg/util/printutils"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type StorageListOptions struct {
+		Node string `help:"Only show storages available on this node"`
+	}
+	shellutils.R(&StorageListOptions{}, "storage-list", "List storages", func(cli *proxmox.SRegion, args *StorageListOptions) error {
+		storages, err := cli.GetStorages(args.Node)
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(storages, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskListOptions struct {
+	}
+	shellutils.R(&DiskListOptions{}, "disk-list", "List disks", func(cli *proxmox.SRegion, args *DiskListOptions) error {
+		disks, err := cli.GetDisks()
+		if err != nil {
+			return err
+		}
+		printutils.PrintInterfaceList(disks, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskResizeOptions struct {
+		NODE string
+		VMID int
+		SLOT string `help:"Disk slot, e.g. scsi0"`
+		SIZE int    `help:"New size in MB"`
+	}
+	shellutils.R(&DiskResizeOptions{}, "disk-resize", "Resize disk", func(cli *proxmox.SRegion, args *DiskResizeOptions) error {
+		return cli.ResizeDisk(args.NODE, args.VMID, args.SLOT, args.SIZE)
+	})
+}
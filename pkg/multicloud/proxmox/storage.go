@@ -0,0 +1,230 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SStorage struct {
+	multicloud.SResourceBase
+	zone *SZone
+
+	Storage    string
+	Plugintype string
+	Content    string
+	Shared     int
+	Status     string
+	Disk       int64
+	Maxdisk    int64
+
+	// 本地存储所在节点, 共享存储为空
+	Node  string
+	Nodes []string
+}
+
+// 只有可存放虚拟机磁盘(content包含images)的存储才会同步, 共享存储在各节点上的条目合并为一个
+func (region *SRegion) GetStorages(node string) ([]SStorage, error) {
+	resources, err := region.GetClusterResources("storage")
+	if err != nil {
+		return nil, err
+	}
+	storages := []SStorage{}
+	shared := map[string]int{}
+	for _, res := range resources {
+		if !utils.IsInStringArray("images", strings.Split(res.Content, ",")) {
+			continue
+		}
+		if len(node) > 0 && res.Node != node && res.Shared == 0 {
+			continue
+		}
+		if res.Shared == 1 {
+			if idx, ok := shared[res.Storage]; ok {
+				storages[idx].Nodes = append(storages[idx].Nodes, res.Node)
+				if storages[idx].Status != "available" && res.Status == "available" {
+					storages[idx].Status, storages[idx].Disk, storages[idx].Maxdisk = res.Status, res.Disk, res.Maxdisk
+				}
+				continue
+			}
+			shared[res.Storage] = len(storages)
+		}
+		storage := SStorage{
+			Storage:    res.Storage,
+			Plugintype: res.Plugintype,
+			Content:    res.Content,
+			Shared:     res.Shared,
+			Status:     res.Status,
+			Disk:       res.Disk,
+			Maxdisk:    res.Maxdisk,
+			Nodes:      []string{res.Node},
+		}
+		if res.Shared == 0 {
+			storage.Node = res.Node
+		}
+		storages = append(storages, storage)
+	}
+	return storages, nil
+}
+
+func storageGlobalId(node, storage string, shared bool) string {
+	if shared {
+		return storage
+	}
+	return fmt.Sprintf("%s/%s", node, storage)
+}
+
+// 外部存储Id中最后一段为Proxmox的存储名称
+func storageNameOf(storageId string) string {
+	return storageId[strings.LastIndex(storageId, "/")+1:]
+}
+
+func (zone *SZone) getStorage(node, name string) (*SStorage, error) {
+	storages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range storages {
+		storage := storages[i].(*SStorage)
+		if storage.Storage == name && storage.isAvailableOn(node) {
+			return storage, nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s on node %s", name, node)
+}
+
+func (storage *SStorage) isAvailableOn(node string) bool {
+	return utils.IsInStringArray(node, storage.Nodes)
+}
+
+func (storage *SStorage) GetId() string {
+	return storageGlobalId(storage.Node, storage.Storage, storage.Shared == 1)
+}
+
+func (storage *SStorage) GetName() string {
+	return storage.GetId()
+}
+
+func (storage *SStorage) GetGlobalId() string {
+	return storage.GetId()
+}
+
+func (storage *SStorage) IsEmulated() bool {
+	return false
+}
+
+func (storage *SStorage) GetStatus() string {
+	if storage.Status == "available" {
+		return api.STORAGE_ONLINE
+	}
+	return api.STORAGE_OFFLINE
+}
+
+func (storage *SStorage) Refresh() error {
+	storages, err := storage.zone.region.GetStorages(storage.Node)
+	if err != nil {
+		return err
+	}
+	for i := range storages {
+		if storages[i].GetGlobalId() == storage.GetGlobalId() {
+			return jsonutils.Update(storage, storages[i])
+		}
+	}
+	return cloudprovider.ErrNotFound
+}
+
+func (storage *SStorage) GetIZone() cloudprovider.ICloudZone {
+	return storage.zone
+}
+
+func (storage *SStorage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return storage.zone.region.getStoragecache()
+}
+
+func (storage *SStorage) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := storage.zone.region.GetDisks()
+	if err != nil {
+		return nil, err
+	}
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		if disks[i].GetIStorageId() == storage.GetGlobalId() {
+			idisks = append(idisks, &disks[i])
+		}
+	}
+	return idisks, nil
+}
+
+func (storage *SStorage) GetIDiskById(diskId string) (cloudprovider.ICloudDisk, error) {
+	disk, err := storage.zone.region.GetDisk(diskId)
+	if err != nil {
+		return nil, err
+	}
+	if disk.GetIStorageId() != storage.GetGlobalId() {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s not in storage %s", diskId, storage.GetGlobalId())
+	}
+	return disk, nil
+}
+
+// Proxmox的磁盘必须归属某台虚拟机, 不支持单独创建
+func (storage *SStorage) CreateIDisk(conf *cloudprovider.DiskCreateConfig) (cloudprovider.ICloudDisk, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (storage *SStorage) GetStorageType() string {
+	if storage.Shared == 1 {
+		return api.STORAGE_PROXMOX_SHARED
+	}
+	return api.STORAGE_PROXMOX_LOCAL
+}
+
+func (storage *SStorage) GetMediumType() string {
+	return api.DISK_TYPE_ROTATE
+}
+
+func (storage *SStorage) GetCapacityMB() int64 {
+	return storage.Maxdisk / 1024 / 1024
+}
+
+func (storage *SStorage) GetCapacityUsedMB() int64 {
+	return storage.Disk / 1024 / 1024
+}
+
+func (storage *SStorage) GetStorageConf() jsonutils.JSONObject {
+	conf := jsonutils.NewDict()
+	conf.Add(jsonutils.NewString(storage.Storage), "storage")
+	conf.Add(jsonutils.NewString(storage.Plugintype), "plugintype")
+	return conf
+}
+
+func (storage *SStorage) GetEnabled() bool {
+	return true
+}
+
+func (storage *SStorage) GetMountPoint() string {
+	return ""
+}
+
+func (storage *SStorage) IsSysDiskStore() bool {
+	return true
+}
@@ -0,0 +1,257 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SNodeNetwork struct {
+	Iface           string
+	Type            string
+	Active          int
+	Cidr            string
+	Address         string
+	Netmask         string
+	Gateway         string
+	BridgePorts     string `json:"bridge_ports"`
+	BridgeVlanAware int    `json:"bridge_vlan_aware"`
+	VlanId          string `json:"vlan-id"`
+	VlanRawDevice   string `json:"vlan-raw-device"`
+}
+
+func (iface *SNodeNetwork) isBridge() bool {
+	return iface.Type == "bridge" || iface.Type == "OVSBridge"
+}
+
+func (iface *SNodeNetwork) getCidr() string {
+	if len(iface.Cidr) > 0 {
+		return iface.Cidr
+	}
+	if len(iface.Address) > 0 && len(iface.Netmask) > 0 {
+		return fmt.Sprintf("%s/%s", iface.Address, iface.Netmask)
+	}
+	return ""
+}
+
+// vlan接口的上联设备及vlan号, 支持 vmbr0.100 及 vlan100(vlan-raw-device=vmbr0) 两种写法
+func (iface *SNodeNetwork) vlanInfo() (string, int) {
+	device, tag := iface.VlanRawDevice, iface.VlanId
+	if idx := strings.LastIndex(iface.Iface, "."); idx > 0 {
+		if len(device) == 0 {
+			device = iface.Iface[:idx]
+		}
+		if len(tag) == 0 {
+			tag = iface.Iface[idx+1:]
+		}
+	}
+	vlanId, _ := strconv.Atoi(tag)
+	return device, vlanId
+}
+
+type SWire struct {
+	multicloud.SResourceBase
+	vpc *SVpc
+
+	Bridge string
+	Nodes  []string
+
+	networks []SNetwork
+}
+
+func (region *SRegion) GetNodeNetworks(node string) ([]SNodeNetwork, error) {
+	ifaces := []SNodeNetwork{}
+	return ifaces, region.client.get(fmt.Sprintf("/nodes/%s/network", node), &ifaces)
+}
+
+// 各节点上的同名网桥视为同一个二层网络, 网桥或其vlan子接口上配置的网段作为网络
+func (region *SRegion) GetWires(node string) ([]SWire, error) {
+	hosts, err := region.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	wires := []SWire{}
+	wireIdx := map[string]int{}
+	for _, host := range hosts {
+		if len(node) > 0 && host.Node != node {
+			continue
+		}
+		if host.Status != "online" {
+			continue
+		}
+		ifaces, err := region.GetNodeNetworks(host.Node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetNodeNetworks(%s)", host.Node)
+		}
+		for _, iface := range ifaces {
+			if !iface.isBridge() {
+				continue
+			}
+			if idx, ok := wireIdx[iface.Iface]; ok {
+				wires[idx].Nodes = append(wires[idx].Nodes, host.Node)
+				continue
+			}
+			wireIdx[iface.Iface] = len(wires)
+			wires = append(wires, SWire{Bridge: iface.Iface, Nodes: []string{host.Node}})
+		}
+		for _, iface := range ifaces {
+			cidr := iface.getCidr()
+			if len(cidr) == 0 {
+				continue
+			}
+			network := SNetwork{Iface: iface.Iface, Gateway: iface.Gateway}
+			if iface.isBridge() {
+				network.Bridge = iface.Iface
+			} else if iface.Type == "vlan" {
+				network.Bridge, network.Tag = iface.vlanInfo()
+			}
+			idx, ok := wireIdx[network.Bridge]
+			if !ok {
+				continue
+			}
+			wires[idx].addNetwork(network, cidr)
+		}
+	}
+	for i := range wires {
+		for j := range wires[i].networks {
+			wires[i].networks[j].wire = &wires[i]
+			wires[i].networks[j].fixRange()
+		}
+	}
+	return wires, nil
+}
+
+func (wire *SWire) addNetwork(network SNetwork, cidr string) {
+	addr, err := netutils.NewIPV4Addr(strings.Split(cidr, "/")[0])
+	if err != nil {
+		return
+	}
+	netAddr, maskLen, err := netutils.ParsePrefix(cidr)
+	if err != nil {
+		return
+	}
+	for i := range wire.networks {
+		if wire.networks[i].Iface == network.Iface {
+			wire.networks[i].addrs = append(wire.networks[i].addrs, addr)
+			if len(wire.networks[i].Gateway) == 0 {
+				wire.networks[i].Gateway = network.Gateway
+			}
+			return
+		}
+	}
+	network.netAddr, network.MaskLen = netAddr, maskLen
+	network.addrs = []netutils.IPV4Addr{addr}
+	wire.networks = append(wire.networks, network)
+}
+
+func (region *SRegion) GetWire(bridge string) (*SWire, error) {
+	wires, err := region.GetWires("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		if wires[i].Bridge == bridge {
+			wires[i].vpc = region.getVpc()
+			return &wires[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "wire %s", bridge)
+}
+
+func (region *SRegion) GetNetwork(networkId string) (*SNetwork, error) {
+	wires, err := region.GetWires("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		for j := range wires[i].networks {
+			if wires[i].networks[j].GetGlobalId() == networkId {
+				wires[i].vpc = region.getVpc()
+				return &wires[i].networks[j], nil
+			}
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", networkId)
+}
+
+func (wire *SWire) GetId() string {
+	return wire.Bridge
+}
+
+func (wire *SWire) GetName() string {
+	return wire.Bridge
+}
+
+func (wire *SWire) GetGlobalId() string {
+	return fmt.Sprintf("%s/%s", wire.vpc.GetGlobalId(), wire.Bridge)
+}
+
+func (wire *SWire) IsEmulated() bool {
+	return false
+}
+
+func (wire *SWire) GetStatus() string {
+	return "available"
+}
+
+func (wire *SWire) Refresh() error {
+	return nil
+}
+
+func (wire *SWire) GetIVpc() cloudprovider.ICloudVpc {
+	return wire.vpc
+}
+
+func (wire *SWire) GetIZone() cloudprovider.ICloudZone {
+	zone, err := wire.vpc.region.getZone()
+	if err != nil {
+		return nil
+	}
+	return zone
+}
+
+func (wire *SWire) GetINetworks() ([]cloudprovider.ICloudNetwork, error) {
+	inetworks := []cloudprovider.ICloudNetwork{}
+	for i := range wire.networks {
+		inetworks = append(inetworks, &wire.networks[i])
+	}
+	return inetworks, nil
+}
+
+func (wire *SWire) GetINetworkById(netid string) (cloudprovider.ICloudNetwork, error) {
+	for i := range wire.networks {
+		if wire.networks[i].GetGlobalId() == netid {
+			return &wire.networks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", netid)
+}
+
+func (wire *SWire) GetBandwidth() int {
+	return 10000
+}
+
+// 网段来自节点网卡配置, 不支持通过接口创建
+func (wire *SWire) CreateINetwork(opts *cloudprovider.SNetworkCreateOptions) (cloudprovider.ICloudNetwork, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
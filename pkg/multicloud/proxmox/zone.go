@@ -0,0 +1,207 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SClusterStatus struct {
+	Type    string
+	Id      string
+	Name    string
+	Ip      string
+	Nodeid  int
+	Nodes   int
+	Online  int
+	Local   int
+	Quorate int
+}
+
+// /cluster/resources 返回的通用资源条目
+type SClusterResource struct {
+	Id         string
+	Type       string
+	Node       string
+	Status     string
+	Name       string
+	Vmid       int
+	Storage    string
+	Plugintype string
+	Content    string
+	Shared     int
+	Template   int
+	Maxcpu     int
+	Maxmem     int64
+	Mem        int64
+	Maxdisk    int64
+	Disk       int64
+	Uptime     int64
+}
+
+func (region *SRegion) GetClusterStatus() ([]SClusterStatus, error) {
+	status := []SClusterStatus{}
+	return status, region.client.get("/cluster/status", &status)
+}
+
+func (region *SRegion) GetClusterResources(resType string) ([]SClusterResource, error) {
+	resources := []SClusterResource{}
+	path := "/cluster/resources"
+	if len(resType) > 0 {
+		path = fmt.Sprintf("%s?type=%s", path, resType)
+	}
+	return resources, region.client.get(path, &resources)
+}
+
+type SZone struct {
+	multicloud.SResourceBase
+	region *SRegion
+
+	Name string
+
+	ihosts    []cloudprovider.ICloudHost
+	istorages []cloudprovider.ICloudStorage
+}
+
+// 一个Proxmox集群对应一个可用区, 未组建集群的单节点以节点名作为可用区
+func (region *SRegion) GetZone() (*SZone, error) {
+	status, err := region.GetClusterStatus()
+	if err != nil {
+		return nil, err
+	}
+	zone := &SZone{region: region}
+	for _, s := range status {
+		if s.Type == "cluster" {
+			zone.Name = s.Name
+			return zone, nil
+		}
+	}
+	for _, s := range status {
+		if s.Type == "node" && (s.Local == 1 || len(zone.Name) == 0) {
+			zone.Name = s.Name
+		}
+	}
+	if len(zone.Name) == 0 {
+		zone.Name = PROXMOX_DEFAULT_REGION
+	}
+	return zone, nil
+}
+
+func (zone *SZone) GetId() string {
+	return zone.Name
+}
+
+func (zone *SZone) GetName() string {
+	return zone.Name
+}
+
+func (zone *SZone) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(zone.GetName()).CN(zone.GetName())
+	return table
+}
+
+func (zone *SZone) GetGlobalId() string {
+	return zone.GetId()
+}
+
+func (zone *SZone) IsEmulated() bool {
+	return false
+}
+
+func (zone *SZone) GetStatus() string {
+	return api.ZONE_ENABLE
+}
+
+func (zone *SZone) Refresh() error {
+	// do nothing
+	return nil
+}
+
+func (zone *SZone) GetIRegion() cloudprovider.ICloudRegion {
+	return zone.region
+}
+
+func (zone *SZone) fetchHosts() error {
+	hosts, err := zone.region.GetHosts()
+	if err != nil {
+		return err
+	}
+	zone.ihosts = []cloudprovider.ICloudHost{}
+	for i := 0; i < len(hosts); i++ {
+		hosts[i].zone = zone
+		zone.ihosts = append(zone.ihosts, &hosts[i])
+	}
+	return nil
+}
+
+func (zone *SZone) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	if len(zone.ihosts) == 0 {
+		err := zone.fetchHosts()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return zone.ihosts, nil
+}
+
+func (zone *SZone) GetIHostById(hostId string) (cloudprovider.ICloudHost, error) {
+	host, err := zone.region.GetHost(hostId)
+	if err != nil {
+		return nil, err
+	}
+	host.zone = zone
+	return host, nil
+}
+
+func (zone *SZone) fetchStorages() error {
+	storages, err := zone.region.GetStorages("")
+	if err != nil {
+		return err
+	}
+	zone.istorages = []cloudprovider.ICloudStorage{}
+	for i := 0; i < len(storages); i++ {
+		storages[i].zone = zone
+		zone.istorages = append(zone.istorages, &storages[i])
+	}
+	return nil
+}
+
+func (zone *SZone) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	if len(zone.istorages) == 0 {
+		err := zone.fetchStorages()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return zone.istorages, nil
+}
+
+func (zone *SZone) GetIStorageById(storageId string) (cloudprovider.ICloudStorage, error) {
+	storages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(storages); i++ {
+		if storages[i].GetGlobalId() == storageId {
+			return storages[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
@@ -0,0 +1,471 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 存储桶对象内容直接保存在状态中, 仅用于少量小对象的测试, 不支持分片上传
+type SBucket struct {
+	multicloud.SBaseBucket
+	SBucketRecord
+
+	region *SRegion
+}
+
+func (state *SSimulatorState) getBucket(name string) (*SBucketRecord, error) {
+	for i := range state.Buckets {
+		if state.Buckets[i].Id == name {
+			return &state.Buckets[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "bucket %s", name)
+}
+
+func (region *SRegion) GetBuckets() ([]SBucket, error) {
+	buckets := []SBucket{}
+	err := region.getStore().view("list:bucket", func(state *SSimulatorState) error {
+		for i := range state.Buckets {
+			if state.Buckets[i].RegionId == region.Id {
+				buckets = append(buckets, SBucket{region: region, SBucketRecord: state.Buckets[i]})
+			}
+		}
+		return nil
+	})
+	return buckets, err
+}
+
+func (region *SRegion) GetIBuckets() ([]cloudprovider.ICloudBucket, error) {
+	buckets, err := region.GetBuckets()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudBucket{}
+	for i := range buckets {
+		ret = append(ret, &buckets[i])
+	}
+	return ret, nil
+}
+
+// 存储桶名称全局唯一
+func (region *SRegion) CreateIBucket(name string, storageClassStr string, acl string) error {
+	if len(acl) == 0 {
+		acl = string(cloudprovider.ACLPrivate)
+	}
+	return region.getStore().update("create:bucket", func(state *SSimulatorState) error {
+		if _, err := state.getBucket(name); err == nil {
+			return errors.Wrapf(cloudprovider.ErrDuplicateId, "bucket %s", name)
+		}
+		state.Buckets = append(state.Buckets, SBucketRecord{
+			SRecordBase:  SRecordBase{Id: name, Name: name, Status: "available"},
+			RegionId:     region.Id,
+			StorageClass: storageClassStr,
+			Acl:          acl,
+			CreatedAt:    time.Now().UTC(),
+		})
+		return nil
+	})
+}
+
+func (region *SRegion) DeleteIBucket(name string) error {
+	return region.getStore().update("delete:bucket", func(state *SSimulatorState) error {
+		for i := range state.Buckets {
+			if state.Buckets[i].Id == name {
+				if len(state.Buckets[i].Objects) > 0 {
+					return errors.Wrapf(cloudprovider.ErrInvalidStatus, "bucket %s not empty", name)
+				}
+				state.Buckets = append(state.Buckets[:i], state.Buckets[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (region *SRegion) IBucketExist(name string) (bool, error) {
+	exist := false
+	err := region.getStore().view("get:bucket", func(state *SSimulatorState) error {
+		_, err := state.getBucket(name)
+		exist = err == nil
+		return nil
+	})
+	return exist, err
+}
+
+func (region *SRegion) GetIBucketById(name string) (cloudprovider.ICloudBucket, error) {
+	return cloudprovider.GetIBucketById(region, name)
+}
+
+func (region *SRegion) GetIBucketByName(name string) (cloudprovider.ICloudBucket, error) {
+	return region.GetIBucketById(name)
+}
+
+func (bucket *SBucket) view(op string, f func(record *SBucketRecord) error) error {
+	return bucket.region.getStore().view(op, func(state *SSimulatorState) error {
+		record, err := state.getBucket(bucket.Id)
+		if err != nil {
+			return err
+		}
+		return f(record)
+	})
+}
+
+func (bucket *SBucket) update(op string, f func(record *SBucketRecord) error) error {
+	return bucket.region.getStore().update(op, func(state *SSimulatorState) error {
+		record, err := state.getBucket(bucket.Id)
+		if err != nil {
+			return err
+		}
+		return f(record)
+	})
+}
+
+func (bucket *SBucket) GetId() string {
+	return bucket.Id
+}
+
+func (bucket *SBucket) GetName() string {
+	return bucket.Name
+}
+
+func (bucket *SBucket) GetGlobalId() string {
+	return bucket.Id
+}
+
+func (bucket *SBucket) GetSysTags() map[string]string {
+	return bucket.Tags
+}
+
+func (bucket *SBucket) GetProjectId() string {
+	return ""
+}
+
+func (bucket *SBucket) GetStatus() string {
+	return "available"
+}
+
+func (bucket *SBucket) Refresh() error {
+	return bucket.view("get:bucket", func(record *SBucketRecord) error {
+		bucket.SBucketRecord = *record
+		return nil
+	})
+}
+
+func (bucket *SBucket) GetAcl() cloudprovider.TBucketACLType {
+	return cloudprovider.TBucketACLType(bucket.Acl)
+}
+
+func (bucket *SBucket) SetAcl(acl cloudprovider.TBucketACLType) error {
+	return bucket.update("update:bucket", func(record *SBucketRecord) error {
+		record.Acl = string(acl)
+		bucket.Acl = record.Acl
+		return nil
+	})
+}
+
+func (bucket *SBucket) GetLocation() string {
+	return bucket.RegionId
+}
+
+func (bucket *SBucket) GetIRegion() cloudprovider.ICloudRegion {
+	return bucket.region
+}
+
+func (bucket *SBucket) GetCreateAt() time.Time {
+	return bucket.CreatedAt
+}
+
+func (bucket *SBucket) GetStorageClass() string {
+	return bucket.StorageClass
+}
+
+func (bucket *SBucket) GetAccessUrls() []cloudprovider.SBucketAccessUrl {
+	return []cloudprovider.SBucketAccessUrl{
+		{
+			Url:         fmt.Sprintf("%s/%s", bucket.region.client.url, bucket.Name),
+			Description: bucket.RegionId,
+			Primary:     true,
+		},
+	}
+}
+
+func (bucket *SBucket) GetStats() cloudprovider.SBucketStats {
+	stats := cloudprovider.SBucketStats{}
+	for i := range bucket.Objects {
+		stats.SizeBytes += int64(len(bucket.Objects[i].Content))
+		stats.ObjectCount++
+	}
+	return stats
+}
+
+func (bucket *SBucket) newObject(record *SObjectRecord) *SObject {
+	meta := http.Header{}
+	for k, v := range record.Meta {
+		meta.Set(k, v)
+	}
+	return &SObject{
+		bucket: bucket,
+		SBaseCloudObject: cloudprovider.SBaseCloudObject{
+			Key:          record.Key,
+			SizeBytes:    int64(len(record.Content)),
+			StorageClass: record.StorageClass,
+			ETag:         record.ETag,
+			LastModified: record.LastModified,
+			Meta:         meta,
+		},
+		acl: record.Acl,
+	}
+}
+
+func (bucket *SBucket) ListObjects(prefix string, marker string, delimiter string, maxCount int) (cloudprovider.SListObjectResult, error) {
+	ret := cloudprovider.SListObjectResult{}
+	err := bucket.view("list:object", func(record *SBucketRecord) error {
+		objects := make([]SObjectRecord, len(record.Objects))
+		copy(objects, record.Objects)
+		sort.Slice(objects, func(i, j int) bool {
+			return objects[i].Key < objects[j].Key
+		})
+		prefixes := map[string]bool{}
+		count := 0
+		for i := range objects {
+			key := objects[i].Key
+			if !strings.HasPrefix(key, prefix) || (len(marker) > 0 && key <= marker) {
+				continue
+			}
+			if maxCount > 0 && count >= maxCount {
+				ret.IsTruncated = true
+				break
+			}
+			if len(delimiter) > 0 {
+				if pos := strings.Index(key[len(prefix):], delimiter); pos >= 0 {
+					commonPrefix := key[:len(prefix)+pos+len(delimiter)]
+					if !prefixes[commonPrefix] {
+						prefixes[commonPrefix] = true
+						ret.CommonPrefixes = append(ret.CommonPrefixes, &SObject{bucket: bucket, SBaseCloudObject: cloudprovider.SBaseCloudObject{Key: commonPrefix}})
+						count++
+					}
+					ret.NextMarker = key
+					continue
+				}
+			}
+			ret.Objects = append(ret.Objects, bucket.newObject(&objects[i]))
+			ret.NextMarker = key
+			count++
+		}
+		if !ret.IsTruncated {
+			ret.NextMarker = ""
+		}
+		return nil
+	})
+	return ret, err
+}
+
+func (bucket *SBucket) putObject(record *SBucketRecord, object SObjectRecord) {
+	object.LastModified = time.Now().UTC()
+	object.ETag = fmt.Sprintf("%x", md5.Sum(object.Content))
+	for i := range record.Objects {
+		if record.Objects[i].Key == object.Key {
+			record.Objects[i] = object
+			return
+		}
+	}
+	record.Objects = append(record.Objects, object)
+}
+
+func metaToMap(meta http.Header) map[string]string {
+	ret := map[string]string{}
+	for k := range meta {
+		ret[k] = meta.Get(k)
+	}
+	return ret
+}
+
+func (bucket *SBucket) PutObject(ctx context.Context, key string, input io.Reader, sizeBytes int64, cannedAcl cloudprovider.TBucketACLType, storageClassStr string, meta http.Header) error {
+	content, err := ioutil.ReadAll(input)
+	if err != nil {
+		return errors.Wrap(err, "read input")
+	}
+	if sizeBytes >= 0 && int64(len(content)) != sizeBytes {
+		return errors.Errorf("object %s size mismatch, expect %d got %d", key, sizeBytes, len(content))
+	}
+	return bucket.update("put:object", func(record *SBucketRecord) error {
+		bucket.putObject(record, SObjectRecord{
+			Key:          key,
+			Content:      content,
+			StorageClass: storageClassStr,
+			Acl:          string(cannedAcl),
+			Meta:         metaToMap(meta),
+		})
+		return nil
+	})
+}
+
+func (bucket *SBucket) GetObject(ctx context.Context, key string, rangeOpt *cloudprovider.SGetObjectRange) (io.ReadCloser, error) {
+	var content []byte
+	err := bucket.view("get:object", func(record *SBucketRecord) error {
+		for i := range record.Objects {
+			if record.Objects[i].Key == key {
+				content = record.Objects[i].Content
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "object %s", key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rangeOpt != nil {
+		start, end := rangeOpt.Start, rangeOpt.End
+		if end <= 0 || end >= int64(len(content)) {
+			end = int64(len(content)) - 1
+		}
+		if start > end {
+			content = []byte{}
+		} else {
+			content = content[start : end+1]
+		}
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (bucket *SBucket) DeleteObject(ctx context.Context, key string) error {
+	return bucket.update("delete:object", func(record *SBucketRecord) error {
+		for i := range record.Objects {
+			if record.Objects[i].Key == key {
+				record.Objects = append(record.Objects[:i], record.Objects[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (bucket *SBucket) CopyObject(ctx context.Context, destKey string, srcBucket, srcKey string, cannedAcl cloudprovider.TBucketACLType, storageClassStr string, meta http.Header) error {
+	return bucket.region.getStore().update("copy:object", func(state *SSimulatorState) error {
+		src, err := state.getBucket(srcBucket)
+		if err != nil {
+			return err
+		}
+		dest, err := state.getBucket(bucket.Id)
+		if err != nil {
+			return err
+		}
+		for i := range src.Objects {
+			if src.Objects[i].Key == srcKey {
+				object := SObjectRecord{
+					Key:          destKey,
+					Content:      append([]byte{}, src.Objects[i].Content...),
+					StorageClass: storageClassStr,
+					Acl:          string(cannedAcl),
+					Meta:         metaToMap(meta),
+				}
+				bucket.putObject(dest, object)
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "object %s/%s", srcBucket, srcKey)
+	})
+}
+
+func (bucket *SBucket) GetTempUrl(method string, key string, expire time.Duration) (string, error) {
+	return fmt.Sprintf("%s/%s/%s?method=%s&expires=%d", bucket.region.client.url, bucket.Name, key, method, time.Now().Add(expire).Unix()), nil
+}
+
+func (bucket *SBucket) NewMultipartUpload(ctx context.Context, key string, cannedAcl cloudprovider.TBucketACLType, storageClassStr string, meta http.Header) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (bucket *SBucket) UploadPart(ctx context.Context, key string, uploadId string, partIndex int, input io.Reader, partSize int64, offset, totalSize int64) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (bucket *SBucket) CopyPart(ctx context.Context, key string, uploadId string, partIndex int, srcBucketName string, srcKey string, srcOffset int64, srcLength int64) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (bucket *SBucket) CompleteMultipartUpload(ctx context.Context, key string, uploadId string, partEtags []string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (bucket *SBucket) AbortMultipartUpload(ctx context.Context, key string, uploadId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+type SObject struct {
+	cloudprovider.SBaseCloudObject
+
+	bucket *SBucket
+	acl    string
+}
+
+func (o *SObject) GetIBucket() cloudprovider.ICloudBucket {
+	return o.bucket
+}
+
+func (o *SObject) GetAcl() cloudprovider.TBucketACLType {
+	if len(o.acl) == 0 {
+		return o.bucket.GetAcl()
+	}
+	return cloudprovider.TBucketACLType(o.acl)
+}
+
+func (o *SObject) updateRecord(op string, f func(object *SObjectRecord)) error {
+	return o.bucket.update(op, func(record *SBucketRecord) error {
+		for i := range record.Objects {
+			if record.Objects[i].Key == o.Key {
+				f(&record.Objects[i])
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "object %s", o.Key)
+	})
+}
+
+func (o *SObject) SetAcl(acl cloudprovider.TBucketACLType) error {
+	err := o.updateRecord("update:object", func(object *SObjectRecord) {
+		object.Acl = string(acl)
+	})
+	if err != nil {
+		return err
+	}
+	o.acl = string(acl)
+	return nil
+}
+
+func (o *SObject) SetMeta(ctx context.Context, meta http.Header) error {
+	err := o.updateRecord("update:object", func(object *SObjectRecord) {
+		object.Meta = metaToMap(meta)
+	})
+	if err != nil {
+		return err
+	}
+	o.Meta = meta
+	return nil
+}
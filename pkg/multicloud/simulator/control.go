@@ -0,0 +1,300 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+// 以下为模拟云的控制接口, 用于在测试中直接修改远端状态, 不受延迟及故障注入影响
+
+func (store *SStore) control(f func(state *SSimulatorState) error, save bool) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	err := store.reload()
+	if err != nil {
+		return err
+	}
+	err = f(store.state)
+	if err != nil {
+		return err
+	}
+	if save {
+		return store.save()
+	}
+	return nil
+}
+
+// GetState 返回当前状态的副本
+func (store *SStore) GetState() (*SSimulatorState, error) {
+	ret := &SSimulatorState{}
+	err := store.control(func(state *SSimulatorState) error {
+		return jsonutils.Marshal(state).Unmarshal(ret)
+	}, false)
+	return ret, err
+}
+
+func (store *SStore) SetState(newState *SSimulatorState) error {
+	return store.control(func(state *SSimulatorState) error {
+		fresh := SSimulatorState{}
+		err := jsonutils.Marshal(newState).Unmarshal(&fresh)
+		if err != nil {
+			return errors.Wrap(err, "unmarshal state")
+		}
+		*state = fresh
+		return nil
+	}, true)
+}
+
+// Reset 恢复为默认拓扑, 并清除延迟及故障注入
+func (store *SStore) Reset() error {
+	return store.SetState(NewDefaultState())
+}
+
+func (store *SStore) SetLatency(latencyMs int) error {
+	return store.control(func(state *SSimulatorState) error {
+		state.LatencyMs = latencyMs
+		return nil
+	}, true)
+}
+
+func (store *SStore) InjectFault(fault SFault) error {
+	if len(fault.Op) == 0 {
+		return fmt.Errorf("empty fault op")
+	}
+	if fault.Count == 0 {
+		fault.Count = 1
+	}
+	return store.control(func(state *SSimulatorState) error {
+		state.Faults = append(state.Faults, fault)
+		return nil
+	}, true)
+}
+
+func (store *SStore) ClearFaults() error {
+	return store.control(func(state *SSimulatorState) error {
+		state.Faults = []SFault{}
+		return nil
+	}, true)
+}
+
+func (store *SStore) List(kind string) ([]jsonutils.JSONObject, error) {
+	ret := []jsonutils.JSONObject{}
+	err := store.control(func(state *SSimulatorState) error {
+		records, err := state.records(kind)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < records.Len(); i++ {
+			ret = append(ret, jsonutils.Marshal(records.Index(i).Interface()))
+		}
+		return nil
+	}, false)
+	return ret, err
+}
+
+func (store *SStore) Get(kind string, id string) (jsonutils.JSONObject, error) {
+	var ret jsonutils.JSONObject
+	err := store.control(func(state *SSimulatorState) error {
+		records, err := state.records(kind)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < records.Len(); i++ {
+			if recordId(records.Index(i)) == id {
+				ret = jsonutils.Marshal(records.Index(i).Interface())
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "%s %s", kind, id)
+	}, false)
+	return ret, err
+}
+
+// Upsert 创建或更新资源, 已存在时仅覆盖data中包含的字段; 未指定id时自动生成
+func (store *SStore) Upsert(kind string, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	input, ok := data.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s record %s", kind, data)
+	}
+	var ret jsonutils.JSONObject
+	err := store.control(func(state *SSimulatorState) error {
+		records, err := state.records(kind)
+		if err != nil {
+			return err
+		}
+		id, _ := input.GetString("id")
+		if len(id) == 0 {
+			id = newId(kind)
+		}
+		for i := 0; i < records.Len(); i++ {
+			if recordId(records.Index(i)) == id {
+				dict := jsonutils.Marshal(records.Index(i).Interface()).(*jsonutils.JSONDict)
+				dict.Update(input)
+				err := dict.Unmarshal(records.Index(i).Addr().Interface())
+				if err != nil {
+					return errors.Wrapf(err, "update %s %s", kind, id)
+				}
+				ret = jsonutils.Marshal(records.Index(i).Interface())
+				return nil
+			}
+		}
+		record := reflect.New(records.Type().Elem())
+		err = input.Unmarshal(record.Interface())
+		if err != nil {
+			return errors.Wrapf(err, "unmarshal %s", kind)
+		}
+		record.Elem().FieldByName("Id").SetString(id)
+		records.Set(reflect.Append(records, record.Elem()))
+		ret = jsonutils.Marshal(record.Interface())
+		return nil
+	}, true)
+	return ret, err
+}
+
+func (store *SStore) Delete(kind string, id string) error {
+	return store.control(func(state *SSimulatorState) error {
+		records, err := state.records(kind)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < records.Len(); i++ {
+			if recordId(records.Index(i)) == id {
+				records.Set(reflect.AppendSlice(records.Slice(0, i), records.Slice(i+1, records.Len())))
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "%s %s", kind, id)
+	}, true)
+}
+
+// NewControlHandler 以http方式暴露控制接口:
+//
+//	GET    /state                 获取全部状态
+//	PUT    /state                 替换全部状态
+//	POST   /reset                 恢复默认拓扑
+//	PUT    /latency               设置延迟, {"latency_ms": 100}
+//	POST   /faults                注入故障, {"op": "create:instance", "error": "Timeout", "count": 1}
+//	DELETE /faults                清除故障
+//	GET    /resources/<kind>      列出资源
+//	GET    /resources/<kind>/<id> 获取资源
+//	POST   /resources/<kind>      创建或更新资源
+//	DELETE /resources/<kind>/<id> 删除资源
+func NewControlHandler(store *SStore) http.Handler {
+	return &sControlHandler{store: store}
+}
+
+type sControlHandler struct {
+	store *SStore
+}
+
+func (h *sControlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ret, err := h.handle(r)
+	if err != nil {
+		code := http.StatusBadRequest
+		switch errors.Cause(err) {
+		case cloudprovider.ErrNotFound:
+			code = http.StatusNotFound
+		case cloudprovider.ErrNotSupported:
+			code = http.StatusNotImplemented
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if ret == nil {
+		ret = jsonutils.NewDict()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(ret.String()))
+}
+
+func (h *sControlHandler) body(r *http.Request) (jsonutils.JSONObject, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	return jsonutils.Parse(data)
+}
+
+func (h *sControlHandler) handle(r *http.Request) (jsonutils.JSONObject, error) {
+	segs := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case segs[0] == "state" && r.Method == http.MethodGet:
+		state, err := h.store.GetState()
+		if err != nil {
+			return nil, err
+		}
+		return jsonutils.Marshal(state), nil
+	case segs[0] == "state" && r.Method == http.MethodPut:
+		body, err := h.body(r)
+		if err != nil {
+			return nil, err
+		}
+		state := &SSimulatorState{}
+		err = body.Unmarshal(state)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal state")
+		}
+		return nil, h.store.SetState(state)
+	case segs[0] == "reset" && r.Method == http.MethodPost:
+		return nil, h.store.Reset()
+	case segs[0] == "latency" && r.Method == http.MethodPut:
+		body, err := h.body(r)
+		if err != nil {
+			return nil, err
+		}
+		latency, _ := body.Int("latency_ms")
+		return nil, h.store.SetLatency(int(latency))
+	case segs[0] == "faults" && r.Method == http.MethodPost:
+		body, err := h.body(r)
+		if err != nil {
+			return nil, err
+		}
+		fault := SFault{}
+		err = body.Unmarshal(&fault)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal fault")
+		}
+		return nil, h.store.InjectFault(fault)
+	case segs[0] == "faults" && r.Method == http.MethodDelete:
+		return nil, h.store.ClearFaults()
+	case segs[0] == "resources" && len(segs) == 2 && r.Method == http.MethodGet:
+		records, err := h.store.List(segs[1])
+		if err != nil {
+			return nil, err
+		}
+		return jsonutils.NewArray(records...), nil
+	case segs[0] == "resources" && len(segs) == 3 && r.Method == http.MethodGet:
+		return h.store.Get(segs[1], segs[2])
+	case segs[0] == "resources" && len(segs) == 2 && r.Method == http.MethodPost:
+		body, err := h.body(r)
+		if err != nil {
+			return nil, err
+		}
+		return h.store.Upsert(segs[1], body)
+	case segs[0] == "resources" && len(segs) == 3 && r.Method == http.MethodDelete:
+		return nil, h.store.Delete(segs[1], segs[2])
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "%s %s", r.Method, r.URL.Path)
+}
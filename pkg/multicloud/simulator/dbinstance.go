@@ -0,0 +1,277 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 数据库实例仅模拟实例本身及其规格, 不包含数据库, 账号及备份
+type SDBInstance struct {
+	multicloud.SDBInstanceBase
+	SDBInstanceRecord
+
+	region *SRegion
+}
+
+func (state *SSimulatorState) getDBInstance(id string) (*SDBInstanceRecord, error) {
+	for i := range state.Dbinstances {
+		if state.Dbinstances[i].Id == id {
+			return &state.Dbinstances[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "dbinstance %s", id)
+}
+
+func (region *SRegion) GetDBInstances() ([]SDBInstance, error) {
+	instances := []SDBInstance{}
+	err := region.getStore().view("list:dbinstance", func(state *SSimulatorState) error {
+		for i := range state.Dbinstances {
+			if state.Dbinstances[i].RegionId == region.Id {
+				instances = append(instances, SDBInstance{region: region, SDBInstanceRecord: state.Dbinstances[i]})
+			}
+		}
+		return nil
+	})
+	return instances, err
+}
+
+func (region *SRegion) GetDBInstance(id string) (*SDBInstance, error) {
+	instance := &SDBInstance{region: region}
+	err := region.getStore().view("get:dbinstance", func(state *SSimulatorState) error {
+		record, err := state.getDBInstance(id)
+		if err != nil {
+			return err
+		}
+		instance.SDBInstanceRecord = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+func (region *SRegion) CreateDBInstance(desc *cloudprovider.SManagedDBInstanceCreateConfig) (*SDBInstance, error) {
+	record := SDBInstanceRecord{
+		SRecordBase:   SRecordBase{Id: newId(KIND_DBINSTANCE), Name: desc.Name, Status: api.DBINSTANCE_RUNNING, Tags: desc.Tags},
+		RegionId:      region.Id,
+		ZoneId:        desc.ZoneId,
+		VpcId:         desc.VpcId,
+		NetworkId:     desc.NetworkId,
+		Port:          desc.Port,
+		Engine:        desc.Engine,
+		EngineVersion: desc.EngineVersion,
+		Category:      desc.Category,
+		InstanceType:  desc.InstanceType,
+		StorageType:   desc.StorageType,
+		VcpuCount:     desc.VcpuCount,
+		VmemSizeMb:    desc.VmemSizeMb,
+		DiskSizeGb:    desc.DiskSizeGB,
+		ProjectId:     desc.ProjectId,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if len(record.ZoneId) == 0 {
+		record.ZoneId = desc.Zone1
+	}
+	err := region.getStore().update("create:dbinstance", func(state *SSimulatorState) error {
+		if len(desc.NetworkId) > 0 {
+			ip, err := state.allocateIp(desc.NetworkId, desc.Address)
+			if err != nil {
+				return errors.Wrapf(err, "allocateIp")
+			}
+			record.Address = ip
+		}
+		state.Dbinstances = append(state.Dbinstances, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SDBInstance{region: region, SDBInstanceRecord: record}, nil
+}
+
+func (instance *SDBInstance) updateRecord(op string, f func(record *SDBInstanceRecord) error) error {
+	return instance.region.getStore().update(op, func(state *SSimulatorState) error {
+		record, err := state.getDBInstance(instance.Id)
+		if err != nil {
+			return err
+		}
+		err = f(record)
+		if err != nil {
+			return err
+		}
+		instance.SDBInstanceRecord = *record
+		return nil
+	})
+}
+
+func (instance *SDBInstance) GetId() string {
+	return instance.Id
+}
+
+func (instance *SDBInstance) GetName() string {
+	return instance.Name
+}
+
+func (instance *SDBInstance) GetGlobalId() string {
+	return instance.Id
+}
+
+func (instance *SDBInstance) GetSysTags() map[string]string {
+	return instance.Tags
+}
+
+func (instance *SDBInstance) GetProjectId() string {
+	return instance.ProjectId
+}
+
+func (instance *SDBInstance) GetCreatedAt() time.Time {
+	return instance.CreatedAt
+}
+
+func (instance *SDBInstance) GetStatus() string {
+	if len(instance.Status) > 0 {
+		return instance.Status
+	}
+	return api.DBINSTANCE_UNKNOWN
+}
+
+func (instance *SDBInstance) Refresh() error {
+	_instance, err := instance.region.GetDBInstance(instance.Id)
+	if err != nil {
+		return err
+	}
+	instance.SDBInstanceRecord = _instance.SDBInstanceRecord
+	return nil
+}
+
+func (instance *SDBInstance) GetPort() int {
+	return instance.Port
+}
+
+func (instance *SDBInstance) GetEngine() string {
+	return instance.Engine
+}
+
+func (instance *SDBInstance) GetEngineVersion() string {
+	return instance.EngineVersion
+}
+
+func (instance *SDBInstance) GetInstanceType() string {
+	return instance.InstanceType
+}
+
+func (instance *SDBInstance) GetVcpuCount() int {
+	return instance.VcpuCount
+}
+
+func (instance *SDBInstance) GetVmemSizeMB() int {
+	return instance.VmemSizeMb
+}
+
+func (instance *SDBInstance) GetDiskSizeGB() int {
+	return instance.DiskSizeGb
+}
+
+func (instance *SDBInstance) GetCategory() string {
+	return instance.Category
+}
+
+func (instance *SDBInstance) GetStorageType() string {
+	return instance.StorageType
+}
+
+func (instance *SDBInstance) GetMaintainTime() string {
+	return ""
+}
+
+func (instance *SDBInstance) GetInternalConnectionStr() string {
+	if len(instance.Address) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", instance.Address, instance.Port)
+}
+
+func (instance *SDBInstance) GetZone1Id() string {
+	return instance.ZoneId
+}
+
+func (instance *SDBInstance) GetZone2Id() string {
+	return ""
+}
+
+func (instance *SDBInstance) GetZone3Id() string {
+	return ""
+}
+
+func (instance *SDBInstance) GetIVpcId() string {
+	return instance.VpcId
+}
+
+func (instance *SDBInstance) GetDBNetworks() ([]cloudprovider.SDBInstanceNetwork, error) {
+	if len(instance.NetworkId) == 0 {
+		return []cloudprovider.SDBInstanceNetwork{}, nil
+	}
+	return []cloudprovider.SDBInstanceNetwork{{IP: instance.Address, NetworkId: instance.NetworkId}}, nil
+}
+
+func (instance *SDBInstance) Reboot() error {
+	return instance.updateRecord("reboot:dbinstance", func(record *SDBInstanceRecord) error {
+		record.Status = api.DBINSTANCE_RUNNING
+		return nil
+	})
+}
+
+func (instance *SDBInstance) ChangeConfig(ctx context.Context, config *cloudprovider.SManagedDBInstanceChangeConfig) error {
+	return instance.updateRecord("change-config:dbinstance", func(record *SDBInstanceRecord) error {
+		if config.DiskSizeGB > 0 {
+			record.DiskSizeGb = config.DiskSizeGB
+		}
+		if len(config.StorageType) > 0 {
+			record.StorageType = config.StorageType
+		}
+		if len(config.InstanceType) > 0 {
+			record.InstanceType = config.InstanceType
+		}
+		if config.VcpuCount > 0 {
+			record.VcpuCount = config.VcpuCount
+		}
+		if config.VmemSizeMb > 0 {
+			record.VmemSizeMb = config.VmemSizeMb
+		}
+		return nil
+	})
+}
+
+func (instance *SDBInstance) Delete() error {
+	return instance.region.getStore().update("delete:dbinstance", func(state *SSimulatorState) error {
+		for i := range state.Dbinstances {
+			if state.Dbinstances[i].Id == instance.Id {
+				state.Dbinstances = append(state.Dbinstances[:i], state.Dbinstances[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
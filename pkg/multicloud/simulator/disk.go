@@ -0,0 +1,293 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+const (
+	DISK_STATUS_READY = "ready"
+	DISK_STATUS_ERROR = "error"
+)
+
+type SDisk struct {
+	multicloud.SDisk
+	SDiskRecord
+
+	storage *SStorage
+}
+
+func (state *SSimulatorState) getDisk(id string) (*SDiskRecord, error) {
+	for i := range state.Disks {
+		if state.Disks[i].Id == id {
+			return &state.Disks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s", id)
+}
+
+// 按存储或虚拟机过滤磁盘, 挂载到虚拟机的磁盘按挂载顺序排列
+func (region *SRegion) GetDisks(storageId string, instanceId string) ([]SDisk, error) {
+	storages, err := region.GetStorages("")
+	if err != nil {
+		return nil, err
+	}
+	storageMap := map[string]*SStorage{}
+	for i := range storages {
+		storageMap[storages[i].Id] = &storages[i]
+	}
+	disks := []SDisk{}
+	err = region.getStore().view("list:disk", func(state *SSimulatorState) error {
+		for i := range state.Disks {
+			disk := state.Disks[i]
+			storage, ok := storageMap[disk.StorageId]
+			if !ok {
+				continue
+			}
+			if len(storageId) > 0 && disk.StorageId != storageId {
+				continue
+			}
+			if len(instanceId) > 0 && disk.InstanceId != instanceId {
+				continue
+			}
+			disks = append(disks, SDisk{storage: storage, SDiskRecord: disk})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(disks, func(i, j int) bool {
+		return disks[i].Index < disks[j].Index
+	})
+	return disks, nil
+}
+
+func (region *SRegion) GetDisk(id string) (*SDisk, error) {
+	disk := &SDisk{}
+	err := region.getStore().view("get:disk", func(state *SSimulatorState) error {
+		record, err := state.getDisk(id)
+		if err != nil {
+			return err
+		}
+		disk.SDiskRecord = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	disk.storage, err = region.GetStorage(disk.StorageId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "disk %s storage", id)
+	}
+	return disk, nil
+}
+
+func (region *SRegion) CreateDisk(storageId string, name string, sizeMb int, desc string, projectId string) (*SDisk, error) {
+	storage, err := region.GetStorage(storageId)
+	if err != nil {
+		return nil, err
+	}
+	record := SDiskRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_DISK), Name: name, Status: DISK_STATUS_READY},
+		StorageId:   storageId,
+		DiskType:    api.DISK_TYPE_DATA,
+		SizeMb:      sizeMb,
+		ProjectId:   projectId,
+		Description: desc,
+		CreatedAt:   time.Now().UTC(),
+	}
+	err = region.getStore().update("create:disk", func(state *SSimulatorState) error {
+		state.Disks = append(state.Disks, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SDisk{storage: storage, SDiskRecord: record}, nil
+}
+
+func (disk *SDisk) getRegion() *SRegion {
+	return disk.storage.zone.region
+}
+
+func (disk *SDisk) updateRecord(op string, f func(record *SDiskRecord) error) error {
+	return disk.getRegion().getStore().update(op, func(state *SSimulatorState) error {
+		record, err := state.getDisk(disk.Id)
+		if err != nil {
+			return err
+		}
+		err = f(record)
+		if err != nil {
+			return err
+		}
+		disk.SDiskRecord = *record
+		return nil
+	})
+}
+
+func (disk *SDisk) GetId() string {
+	return disk.Id
+}
+
+func (disk *SDisk) GetName() string {
+	return disk.Name
+}
+
+func (disk *SDisk) GetGlobalId() string {
+	return disk.Id
+}
+
+func (disk *SDisk) GetSysTags() map[string]string {
+	return disk.Tags
+}
+
+func (disk *SDisk) GetProjectId() string {
+	return disk.ProjectId
+}
+
+func (disk *SDisk) GetCreatedAt() time.Time {
+	return disk.CreatedAt
+}
+
+func (disk *SDisk) GetStatus() string {
+	switch disk.Status {
+	case DISK_STATUS_READY:
+		return api.DISK_READY
+	case DISK_STATUS_ERROR:
+		return api.DISK_ALLOC_FAILED
+	default:
+		return api.DISK_UNKNOWN
+	}
+}
+
+func (disk *SDisk) Refresh() error {
+	_disk, err := disk.getRegion().GetDisk(disk.Id)
+	if err != nil {
+		return err
+	}
+	disk.SDiskRecord = _disk.SDiskRecord
+	disk.storage = _disk.storage
+	return nil
+}
+
+func (disk *SDisk) GetIStorage() (cloudprovider.ICloudStorage, error) {
+	return disk.storage, nil
+}
+
+func (disk *SDisk) GetIStorageId() string {
+	return disk.StorageId
+}
+
+func (disk *SDisk) GetDiskFormat() string {
+	return "qcow2"
+}
+
+func (disk *SDisk) GetDiskSizeMB() int {
+	return disk.SizeMb
+}
+
+// 系统盘随虚拟机删除
+func (disk *SDisk) GetIsAutoDelete() bool {
+	return disk.DiskType == api.DISK_TYPE_SYS
+}
+
+func (disk *SDisk) GetTemplateId() string {
+	return disk.TemplateId
+}
+
+func (disk *SDisk) GetDiskType() string {
+	if len(disk.DiskType) > 0 {
+		return disk.DiskType
+	}
+	return api.DISK_TYPE_DATA
+}
+
+func (disk *SDisk) GetFsFormat() string {
+	return ""
+}
+
+func (disk *SDisk) GetIsNonPersistent() bool {
+	return false
+}
+
+func (disk *SDisk) GetDriver() string {
+	return "virtio"
+}
+
+func (disk *SDisk) GetCacheMode() string {
+	return "none"
+}
+
+func (disk *SDisk) GetMountpoint() string {
+	return ""
+}
+
+func (disk *SDisk) GetAccessPath() string {
+	return ""
+}
+
+func (disk *SDisk) Delete(ctx context.Context) error {
+	return disk.getRegion().getStore().update("delete:disk", func(state *SSimulatorState) error {
+		for i := range state.Disks {
+			if state.Disks[i].Id == disk.Id {
+				if len(state.Disks[i].InstanceId) > 0 {
+					return errors.Wrapf(cloudprovider.ErrInvalidStatus, "disk %s attached to %s", disk.Id, state.Disks[i].InstanceId)
+				}
+				state.Disks = append(state.Disks[:i], state.Disks[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (disk *SDisk) CreateISnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (disk *SDisk) Resize(ctx context.Context, newSizeMB int64) error {
+	return disk.updateRecord("resize:disk", func(record *SDiskRecord) error {
+		if int(newSizeMB) < record.SizeMb {
+			return errors.Errorf("can not shrink disk %s from %dMB to %dMB", disk.Id, record.SizeMb, newSizeMB)
+		}
+		record.SizeMb = int(newSizeMB)
+		return nil
+	})
+}
+
+func (disk *SDisk) Reset(ctx context.Context, snapshotId string) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) Rebuild(ctx context.Context) error {
+	return disk.updateRecord("rebuild:disk", func(record *SDiskRecord) error {
+		record.Status = DISK_STATUS_READY
+		return nil
+	})
+}
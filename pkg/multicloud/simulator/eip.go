@@ -0,0 +1,269 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+const (
+	EIP_STATUS_AVAILABLE = "available"
+
+	// 未指定子网时从保留测试网段中分配公网地址
+	EIP_PUBLIC_IP_POOL = "203.0.113.%d"
+)
+
+type SEip struct {
+	multicloud.SEipBase
+	SEipRecord
+
+	region *SRegion
+}
+
+func (state *SSimulatorState) getEip(id string) (*SEipRecord, error) {
+	for i := range state.Eips {
+		if state.Eips[i].Id == id {
+			return &state.Eips[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "eip %s", id)
+}
+
+func (state *SSimulatorState) allocatePublicIp() (string, error) {
+	used := map[string]bool{}
+	for i := range state.Eips {
+		used[state.Eips[i].Ip] = true
+	}
+	for i := 1; i < 255; i++ {
+		ip := fmt.Sprintf(EIP_PUBLIC_IP_POOL, i)
+		if !used[ip] {
+			return ip, nil
+		}
+	}
+	return "", errors.Errorf("public ip pool exhausted")
+}
+
+func (region *SRegion) GetEips() ([]SEip, error) {
+	eips := []SEip{}
+	err := region.getStore().view("list:eip", func(state *SSimulatorState) error {
+		for i := range state.Eips {
+			if state.Eips[i].RegionId == region.Id {
+				eips = append(eips, SEip{region: region, SEipRecord: state.Eips[i]})
+			}
+		}
+		return nil
+	})
+	return eips, err
+}
+
+func (region *SRegion) GetEip(id string) (*SEip, error) {
+	eip := &SEip{region: region}
+	err := region.getStore().view("get:eip", func(state *SSimulatorState) error {
+		record, err := state.getEip(id)
+		if err != nil {
+			return err
+		}
+		eip.SEipRecord = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return eip, nil
+}
+
+func (region *SRegion) CreateEip(opts *cloudprovider.SEip) (*SEip, error) {
+	record := SEipRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_EIP), Name: opts.Name, Status: EIP_STATUS_AVAILABLE},
+		RegionId:    region.Id,
+		NetworkId:   opts.NetworkExternalId,
+		Bandwidth:   opts.BandwidthMbps,
+		ChargeType:  opts.ChargeType,
+		ProjectId:   opts.ProjectId,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if len(record.ChargeType) == 0 {
+		record.ChargeType = api.EIP_CHARGE_TYPE_BY_TRAFFIC
+	}
+	err := region.getStore().update("create:eip", func(state *SSimulatorState) error {
+		var err error
+		if len(opts.NetworkExternalId) > 0 {
+			record.Ip, err = state.allocateIp(opts.NetworkExternalId, opts.IP)
+		} else if len(opts.IP) > 0 {
+			record.Ip = opts.IP
+		} else {
+			record.Ip, err = state.allocatePublicIp()
+		}
+		if err != nil {
+			return err
+		}
+		state.Eips = append(state.Eips, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SEip{region: region, SEipRecord: record}, nil
+}
+
+func (eip *SEip) updateRecord(op string, f func(state *SSimulatorState, record *SEipRecord) error) error {
+	return eip.region.getStore().update(op, func(state *SSimulatorState) error {
+		record, err := state.getEip(eip.Id)
+		if err != nil {
+			return err
+		}
+		err = f(state, record)
+		if err != nil {
+			return err
+		}
+		eip.SEipRecord = *record
+		return nil
+	})
+}
+
+func (eip *SEip) GetId() string {
+	return eip.Id
+}
+
+func (eip *SEip) GetName() string {
+	return eip.Name
+}
+
+func (eip *SEip) GetGlobalId() string {
+	return eip.Id
+}
+
+func (eip *SEip) GetSysTags() map[string]string {
+	return eip.Tags
+}
+
+func (eip *SEip) GetProjectId() string {
+	return eip.ProjectId
+}
+
+func (eip *SEip) GetCreatedAt() time.Time {
+	return eip.CreatedAt
+}
+
+func (eip *SEip) GetStatus() string {
+	if eip.Status == EIP_STATUS_AVAILABLE {
+		return api.EIP_STATUS_READY
+	}
+	return api.EIP_STATUS_UNKNOWN
+}
+
+func (eip *SEip) Refresh() error {
+	_eip, err := eip.region.GetEip(eip.Id)
+	if err != nil {
+		return err
+	}
+	eip.SEipRecord = _eip.SEipRecord
+	return nil
+}
+
+func (eip *SEip) GetIpAddr() string {
+	return eip.Ip
+}
+
+func (eip *SEip) GetMode() string {
+	return api.EIP_MODE_STANDALONE_EIP
+}
+
+func (eip *SEip) GetINetworkId() string {
+	return eip.NetworkId
+}
+
+func (eip *SEip) GetAssociationType() string {
+	return eip.AssociateType
+}
+
+func (eip *SEip) GetAssociationExternalId() string {
+	return eip.AssociateId
+}
+
+func (eip *SEip) GetBandwidth() int {
+	return eip.Bandwidth
+}
+
+func (eip *SEip) GetInternetChargeType() string {
+	return eip.ChargeType
+}
+
+func (eip *SEip) Delete() error {
+	return eip.region.getStore().update("delete:eip", func(state *SSimulatorState) error {
+		for i := range state.Eips {
+			if state.Eips[i].Id == eip.Id {
+				if len(state.Eips[i].AssociateId) > 0 {
+					return errors.Wrapf(cloudprovider.ErrInvalidStatus, "eip %s associated with %s", eip.Id, state.Eips[i].AssociateId)
+				}
+				state.Eips = append(state.Eips[:i], state.Eips[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (eip *SEip) Associate(conf *cloudprovider.AssociateConfig) error {
+	associateType := conf.AssociateType
+	if len(associateType) == 0 {
+		associateType = api.EIP_ASSOCIATE_TYPE_SERVER
+	}
+	return eip.updateRecord("associate:eip", func(state *SSimulatorState, record *SEipRecord) error {
+		if len(record.AssociateId) > 0 && record.AssociateId != conf.InstanceId {
+			return errors.Wrapf(cloudprovider.ErrInvalidStatus, "eip %s already associated with %s", eip.Id, record.AssociateId)
+		}
+		switch associateType {
+		case api.EIP_ASSOCIATE_TYPE_SERVER:
+			_, err := state.getInstance(conf.InstanceId)
+			if err != nil {
+				return err
+			}
+		case api.EIP_ASSOCIATE_TYPE_LOADBALANCER:
+			_, err := state.getLoadbalancer(conf.InstanceId)
+			if err != nil {
+				return err
+			}
+		}
+		record.AssociateType = associateType
+		record.AssociateId = conf.InstanceId
+		if conf.Bandwidth > 0 {
+			record.Bandwidth = conf.Bandwidth
+		}
+		return nil
+	})
+}
+
+func (eip *SEip) Dissociate() error {
+	return eip.updateRecord("dissociate:eip", func(state *SSimulatorState, record *SEipRecord) error {
+		record.AssociateType = ""
+		record.AssociateId = ""
+		return nil
+	})
+}
+
+func (eip *SEip) ChangeBandwidth(bw int) error {
+	return eip.updateRecord("change-bandwidth:eip", func(state *SSimulatorState, record *SEipRecord) error {
+		record.Bandwidth = bw
+		return nil
+	})
+}
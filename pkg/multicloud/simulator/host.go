@@ -0,0 +1,237 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+const (
+	HOST_STATUS_ONLINE      = "online"
+	HOST_STATUS_OFFLINE     = "offline"
+	HOST_STATUS_MAINTENANCE = "maintenance"
+)
+
+type SHost struct {
+	multicloud.SHostBase
+	SHostRecord
+
+	zone *SZone
+}
+
+// zoneId为空时返回区域内所有宿主机
+func (region *SRegion) GetHosts(zoneId string) ([]SHost, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	hosts := []SHost{}
+	err = region.getStore().view("list:host", func(state *SSimulatorState) error {
+		for i := range zones {
+			if len(zoneId) > 0 && zones[i].Id != zoneId {
+				continue
+			}
+			for j := range state.Hosts {
+				if state.Hosts[j].ZoneId == zones[i].Id {
+					hosts = append(hosts, SHost{zone: &zones[i], SHostRecord: state.Hosts[j]})
+				}
+			}
+		}
+		return nil
+	})
+	return hosts, err
+}
+
+func (region *SRegion) GetHost(id string) (*SHost, error) {
+	hosts, err := region.GetHosts("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		if hosts[i].Id == id {
+			return &hosts[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "host %s", id)
+}
+
+func (host *SHost) getRegion() *SRegion {
+	return host.zone.region
+}
+
+func (host *SHost) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	vpcs, err := host.getRegion().GetVpcs()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudWire{}
+	for i := range vpcs {
+		ret = append(ret, &SWire{vpc: &vpcs[i], zone: host.zone})
+	}
+	return ret, nil
+}
+
+func (host *SHost) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	return host.zone.GetIStorages()
+}
+
+func (host *SHost) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	return host.zone.GetIStorageById(id)
+}
+
+func (host *SHost) GetIVMs() ([]cloudprovider.ICloudVM, error) {
+	instances, err := host.getRegion().GetInstances(host.Id)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudVM{}
+	for i := range instances {
+		instances[i].host = host
+		ret = append(ret, &instances[i])
+	}
+	return ret, nil
+}
+
+func (host *SHost) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	instance, err := host.getRegion().GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	if instance.HostId != host.Id {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s not on host %s", id, host.Name)
+	}
+	instance.host = host
+	return instance, nil
+}
+
+func (host *SHost) GetId() string {
+	return host.Id
+}
+
+func (host *SHost) GetName() string {
+	return host.Name
+}
+
+func (host *SHost) GetGlobalId() string {
+	return host.Id
+}
+
+func (host *SHost) GetSysTags() map[string]string {
+	return host.Tags
+}
+
+func (host *SHost) GetStatus() string {
+	if host.Status == HOST_STATUS_OFFLINE {
+		return api.HOST_STATUS_UNKNOWN
+	}
+	return api.HOST_STATUS_RUNNING
+}
+
+func (host *SHost) Refresh() error {
+	_host, err := host.getRegion().GetHost(host.Id)
+	if err != nil {
+		return err
+	}
+	host.SHostRecord = _host.SHostRecord
+	return nil
+}
+
+func (host *SHost) GetHostStatus() string {
+	if host.Status == HOST_STATUS_OFFLINE {
+		return api.HOST_OFFLINE
+	}
+	return api.HOST_ONLINE
+}
+
+func (host *SHost) GetEnabled() bool {
+	return host.Enabled
+}
+
+func (host *SHost) GetAccessIp() string {
+	return host.AccessIp
+}
+
+func (host *SHost) GetAccessMac() string {
+	return host.AccessMac
+}
+
+func (host *SHost) GetSysInfo() jsonutils.JSONObject {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString(CLOUD_PROVIDER_SIMULATOR), "manufacture")
+	info.Add(jsonutils.NewString(CLOUD_PROVIDER_SIMULATOR), "model")
+	return info
+}
+
+func (host *SHost) GetSN() string {
+	return host.SN
+}
+
+func (host *SHost) GetCpuCount() int {
+	return host.CpuCount
+}
+
+func (host *SHost) GetNodeCount() int8 {
+	return 1
+}
+
+func (host *SHost) GetCpuDesc() string {
+	return host.CpuDesc
+}
+
+func (host *SHost) GetCpuMhz() int {
+	return host.CpuMhz
+}
+
+func (host *SHost) GetMemSizeMB() int {
+	return host.MemSizeMb
+}
+
+func (host *SHost) GetStorageSizeMB() int {
+	return 0
+}
+
+func (host *SHost) GetStorageType() string {
+	return api.DISK_TYPE_HYBRID
+}
+
+func (host *SHost) GetHostType() string {
+	return api.HOST_TYPE_SIMULATOR
+}
+
+func (host *SHost) GetIsMaintenance() bool {
+	return host.Status == HOST_STATUS_MAINTENANCE
+}
+
+func (host *SHost) GetVersion() string {
+	return ""
+}
+
+func (host *SHost) GetIHostNics() ([]cloudprovider.ICloudHostNetInterface, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (host *SHost) CreateVM(desc *cloudprovider.SManagedVMCreateConfig) (cloudprovider.ICloudVM, error) {
+	instance, err := host.getRegion().CreateInstance(host, desc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CreateInstance")
+	}
+	instance.host = host
+	return instance, nil
+}
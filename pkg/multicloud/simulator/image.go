@@ -0,0 +1,273 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+const (
+	IMAGE_STATUS_ACTIVE = "active"
+	IMAGE_STATUS_SAVING = "saving"
+	IMAGE_STATUS_KILLED = "killed"
+)
+
+// 每个区域模拟一个镜像缓存, 包含区域内所有镜像
+type SStoragecache struct {
+	multicloud.SResourceBase
+	region *SRegion
+}
+
+func (scache *SStoragecache) GetId() string {
+	return fmt.Sprintf("%s-%s", scache.region.client.cpcfg.Id, scache.region.GetId())
+}
+
+func (scache *SStoragecache) GetName() string {
+	return fmt.Sprintf("%s-%s", scache.region.client.cpcfg.Name, scache.region.GetId())
+}
+
+func (scache *SStoragecache) GetStatus() string {
+	return "available"
+}
+
+func (scache *SStoragecache) GetGlobalId() string {
+	return scache.GetId()
+}
+
+func (scache *SStoragecache) GetICustomizedCloudImages() ([]cloudprovider.ICloudImage, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (scache *SStoragecache) GetICloudImages() ([]cloudprovider.ICloudImage, error) {
+	images, err := scache.region.GetImages()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudImage{}
+	for i := range images {
+		images[i].storageCache = scache
+		ret = append(ret, &images[i])
+	}
+	return ret, nil
+}
+
+func (scache *SStoragecache) GetIImageById(extId string) (cloudprovider.ICloudImage, error) {
+	image, err := scache.region.GetImage(extId)
+	if err != nil {
+		return nil, err
+	}
+	image.storageCache = scache
+	return image, nil
+}
+
+func (scache *SStoragecache) GetPath() string {
+	return ""
+}
+
+// 模拟上传镜像, 仅记录镜像元数据
+func (scache *SStoragecache) UploadImage(ctx context.Context, userCred mcclient.TokenCredential, opts *cloudprovider.SImageCreateOption, isForce bool) (string, error) {
+	if len(opts.ExternalId) > 0 && !isForce {
+		_, err := scache.region.GetImage(opts.ExternalId)
+		if err == nil {
+			return opts.ExternalId, nil
+		}
+		if errors.Cause(err) != cloudprovider.ErrNotFound {
+			return "", err
+		}
+	}
+	image := SImageRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_IMAGE), Name: opts.ImageName, Status: IMAGE_STATUS_ACTIVE},
+		RegionId:    scache.region.Id,
+		OsType:      opts.OsType,
+		OsDist:      opts.OsDistribution,
+		OsVersion:   opts.OsVersion,
+		OsArch:      opts.OsArch,
+		Format:      "qcow2",
+		CreatedAt:   time.Now().UTC(),
+	}
+	err := scache.region.getStore().update("create:image", func(state *SSimulatorState) error {
+		state.Images = append(state.Images, image)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return image.Id, nil
+}
+
+func (scache *SStoragecache) CreateIImage(snapshoutId, imageName, osType, imageDesc string) (cloudprovider.ICloudImage, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (scache *SStoragecache) DownloadImage(userCred mcclient.TokenCredential, imageId string, extId string, path string) (jsonutils.JSONObject, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+type SImage struct {
+	multicloud.SImageBase
+	SImageRecord
+
+	storageCache *SStoragecache
+}
+
+func (region *SRegion) GetImages() ([]SImage, error) {
+	images := []SImage{}
+	err := region.getStore().view("list:image", func(state *SSimulatorState) error {
+		for i := range state.Images {
+			if state.Images[i].RegionId == region.Id {
+				images = append(images, SImage{SImageRecord: state.Images[i]})
+			}
+		}
+		return nil
+	})
+	return images, err
+}
+
+func (region *SRegion) GetImage(imageId string) (*SImage, error) {
+	image := &SImage{storageCache: region.getStoragecache()}
+	err := region.getStore().view("get:image", func(state *SSimulatorState) error {
+		for i := range state.Images {
+			if state.Images[i].Id == imageId && state.Images[i].RegionId == region.Id {
+				image.SImageRecord = state.Images[i]
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "image %s", imageId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+func (image *SImage) GetId() string {
+	return image.Id
+}
+
+func (image *SImage) GetName() string {
+	return image.Name
+}
+
+func (image *SImage) GetGlobalId() string {
+	return image.Id
+}
+
+func (image *SImage) GetSysTags() map[string]string {
+	return image.Tags
+}
+
+func (image *SImage) GetStatus() string {
+	switch image.Status {
+	case IMAGE_STATUS_ACTIVE:
+		return api.CACHED_IMAGE_STATUS_ACTIVE
+	case IMAGE_STATUS_SAVING:
+		return api.CACHED_IMAGE_STATUS_CACHING
+	default:
+		return api.CACHED_IMAGE_STATUS_CACHE_FAILED
+	}
+}
+
+func (image *SImage) GetImageStatus() string {
+	switch image.Status {
+	case IMAGE_STATUS_ACTIVE:
+		return cloudprovider.IMAGE_STATUS_ACTIVE
+	case IMAGE_STATUS_SAVING:
+		return cloudprovider.IMAGE_STATUS_SAVING
+	default:
+		return cloudprovider.IMAGE_STATUS_KILLED
+	}
+}
+
+func (image *SImage) Refresh() error {
+	_image, err := image.storageCache.region.GetImage(image.Id)
+	if err != nil {
+		return err
+	}
+	image.SImageRecord = _image.SImageRecord
+	return nil
+}
+
+func (image *SImage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return image.storageCache
+}
+
+func (image *SImage) Delete(ctx context.Context) error {
+	return image.storageCache.region.getStore().update("delete:image", func(state *SSimulatorState) error {
+		for i := range state.Images {
+			if state.Images[i].Id == image.Id {
+				state.Images = append(state.Images[:i], state.Images[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "image %s", image.Id)
+	})
+}
+
+func (image *SImage) GetImageType() cloudprovider.TImageType {
+	if len(image.InstanceId) > 0 {
+		return cloudprovider.ImageTypeCustomized
+	}
+	return cloudprovider.ImageTypeSystem
+}
+
+func (image *SImage) GetSizeByte() int64 {
+	return image.SizeBytes
+}
+
+func (image *SImage) GetOsType() string {
+	return image.OsType
+}
+
+func (image *SImage) GetOsDist() string {
+	return image.OsDist
+}
+
+func (image *SImage) GetOsVersion() string {
+	return image.OsVersion
+}
+
+func (image *SImage) GetOsArch() string {
+	return image.OsArch
+}
+
+func (image *SImage) GetMinOsDiskSizeGb() int {
+	return image.MinDiskGb
+}
+
+func (image *SImage) GetMinRamSizeMb() int {
+	return image.MinRamMb
+}
+
+func (image *SImage) GetImageFormat() string {
+	return image.Format
+}
+
+func (image *SImage) GetCreatedAt() time.Time {
+	return image.CreatedAt
+}
+
+func (image *SImage) UEFI() bool {
+	return false
+}
@@ -0,0 +1,674 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/billing"
+)
+
+const (
+	INSTANCE_STATUS_RUNNING = "running"
+	INSTANCE_STATUS_STOPPED = "stopped"
+)
+
+type SInstance struct {
+	multicloud.SInstanceBase
+	SInstanceRecord
+
+	host *SHost
+}
+
+func (state *SSimulatorState) getInstance(id string) (*SInstanceRecord, error) {
+	for i := range state.Instances {
+		if state.Instances[i].Id == id {
+			return &state.Instances[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s", id)
+}
+
+// hostId为空时返回区域内所有虚拟机
+func (region *SRegion) GetInstances(hostId string) ([]SInstance, error) {
+	hosts, err := region.GetHosts("")
+	if err != nil {
+		return nil, err
+	}
+	hostMap := map[string]*SHost{}
+	for i := range hosts {
+		hostMap[hosts[i].Id] = &hosts[i]
+	}
+	instances := []SInstance{}
+	err = region.getStore().view("list:instance", func(state *SSimulatorState) error {
+		for i := range state.Instances {
+			host, ok := hostMap[state.Instances[i].HostId]
+			if !ok || (len(hostId) > 0 && host.Id != hostId) {
+				continue
+			}
+			instances = append(instances, SInstance{host: host, SInstanceRecord: state.Instances[i]})
+		}
+		return nil
+	})
+	return instances, err
+}
+
+func (region *SRegion) GetInstance(id string) (*SInstance, error) {
+	instance := &SInstance{}
+	err := region.getStore().view("get:instance", func(state *SSimulatorState) error {
+		record, err := state.getInstance(id)
+		if err != nil {
+			return err
+		}
+		instance.SInstanceRecord = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	instance.host, err = region.GetHost(instance.HostId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "instance %s host", id)
+	}
+	return instance, nil
+}
+
+// CreateInstance 创建虚拟机, 同时创建系统盘及数据盘并分配网卡地址
+func (region *SRegion) CreateInstance(host *SHost, desc *cloudprovider.SManagedVMCreateConfig) (*SInstance, error) {
+	storages, err := host.zone.region.GetStorages(host.ZoneId)
+	if err != nil {
+		return nil, err
+	}
+	if len(storages) == 0 {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "no storage in zone %s", host.ZoneId)
+	}
+	storageIds := map[string]bool{}
+	for i := range storages {
+		storageIds[storages[i].Id] = true
+	}
+	now := time.Now().UTC()
+	record := SInstanceRecord{
+		SRecordBase:  SRecordBase{Id: newId(KIND_INSTANCE), Name: desc.Name, Status: INSTANCE_STATUS_RUNNING, Tags: desc.Tags},
+		HostId:       host.Id,
+		ImageId:      desc.ExternalImageId,
+		OsType:       desc.OsType,
+		OsName:       desc.OsDistribution,
+		InstanceType: desc.InstanceType,
+		Cpu:          desc.Cpu,
+		MemoryMb:     desc.MemoryMB,
+		SecgroupIds:  desc.ExternalSecgroupIds,
+		ProjectId:    desc.ProjectId,
+		UserData:     desc.UserData,
+		Description:  desc.Description,
+		CreatedAt:    now,
+	}
+	if len(record.SecgroupIds) == 0 && len(desc.ExternalSecgroupId) > 0 {
+		record.SecgroupIds = []string{desc.ExternalSecgroupId}
+	}
+	err = region.getStore().update("create:instance", func(state *SSimulatorState) error {
+		if len(desc.ExternalImageId) > 0 {
+			found := false
+			for i := range state.Images {
+				if state.Images[i].Id == desc.ExternalImageId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errors.Wrapf(cloudprovider.ErrNotFound, "image %s", desc.ExternalImageId)
+			}
+		}
+		if len(desc.ExternalNetworkId) > 0 {
+			ip, err := state.allocateIp(desc.ExternalNetworkId, desc.IpAddr)
+			if err != nil {
+				return errors.Wrapf(err, "allocateIp")
+			}
+			record.Nics = []SNicRecord{{Id: newId("nic"), NetworkId: desc.ExternalNetworkId, Ip: ip, Mac: newMac()}}
+		}
+		disks := append([]cloudprovider.SDiskInfo{desc.SysDisk}, desc.DataDisks...)
+		for i, info := range disks {
+			storageId := info.StorageExternalId
+			if len(storageId) == 0 {
+				storageId = storages[0].Id
+			}
+			if !storageIds[storageId] {
+				return errors.Wrapf(cloudprovider.ErrNotFound, "storage %s not in zone %s", storageId, host.ZoneId)
+			}
+			disk := SDiskRecord{
+				SRecordBase: SRecordBase{Id: newId(KIND_DISK), Name: info.Name, Status: DISK_STATUS_READY},
+				StorageId:   storageId,
+				InstanceId:  record.Id,
+				DiskType:    api.DISK_TYPE_DATA,
+				SizeMb:      info.SizeGB * 1024,
+				Index:       i,
+				ProjectId:   desc.ProjectId,
+				CreatedAt:   now,
+			}
+			if i == 0 {
+				disk.DiskType = api.DISK_TYPE_SYS
+				disk.TemplateId = desc.ExternalImageId
+			}
+			if len(disk.Name) == 0 {
+				disk.Name = fmt.Sprintf("%s-disk-%d", desc.Name, i)
+			}
+			state.Disks = append(state.Disks, disk)
+		}
+		state.Instances = append(state.Instances, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SInstance{host: host, SInstanceRecord: record}, nil
+}
+
+func (instance *SInstance) getRegion() *SRegion {
+	return instance.host.zone.region
+}
+
+func (instance *SInstance) getStore() *SStore {
+	return instance.getRegion().getStore()
+}
+
+// updateRecord 修改虚拟机记录并同步到当前对象
+func (instance *SInstance) updateRecord(op string, f func(state *SSimulatorState, record *SInstanceRecord) error) error {
+	return instance.getStore().update(op, func(state *SSimulatorState) error {
+		record, err := state.getInstance(instance.Id)
+		if err != nil {
+			return err
+		}
+		err = f(state, record)
+		if err != nil {
+			return err
+		}
+		instance.SInstanceRecord = *record
+		return nil
+	})
+}
+
+func (instance *SInstance) GetId() string {
+	return instance.Id
+}
+
+func (instance *SInstance) GetName() string {
+	return instance.Name
+}
+
+func (instance *SInstance) GetGlobalId() string {
+	return instance.Id
+}
+
+func (instance *SInstance) GetSysTags() map[string]string {
+	return instance.Tags
+}
+
+func (instance *SInstance) GetTags() (map[string]string, error) {
+	return instance.Tags, nil
+}
+
+func (instance *SInstance) SetTags(tags map[string]string, replace bool) error {
+	return instance.updateRecord("update:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		if replace || record.Tags == nil {
+			record.Tags = map[string]string{}
+		}
+		for k, v := range tags {
+			record.Tags[k] = v
+		}
+		return nil
+	})
+}
+
+func (instance *SInstance) GetProjectId() string {
+	return instance.ProjectId
+}
+
+func (instance *SInstance) GetCreatedAt() time.Time {
+	return instance.CreatedAt
+}
+
+func (instance *SInstance) GetStatus() string {
+	switch instance.Status {
+	case INSTANCE_STATUS_RUNNING:
+		return api.VM_RUNNING
+	case INSTANCE_STATUS_STOPPED:
+		return api.VM_READY
+	default:
+		return api.VM_UNKNOWN
+	}
+}
+
+func (instance *SInstance) Refresh() error {
+	_instance, err := instance.getRegion().GetInstance(instance.Id)
+	if err != nil {
+		return err
+	}
+	instance.SInstanceRecord = _instance.SInstanceRecord
+	instance.host = _instance.host
+	return nil
+}
+
+func (instance *SInstance) GetIHost() cloudprovider.ICloudHost {
+	return instance.host
+}
+
+func (instance *SInstance) GetIHostId() string {
+	return instance.HostId
+}
+
+func (instance *SInstance) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := instance.getRegion().GetDisks("", instance.Id)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		ret = append(ret, &disks[i])
+	}
+	return ret, nil
+}
+
+func (instance *SInstance) GetINics() ([]cloudprovider.ICloudNic, error) {
+	ret := []cloudprovider.ICloudNic{}
+	for i := range instance.Nics {
+		ret = append(ret, &SInstanceNic{instance: instance, SNicRecord: instance.Nics[i]})
+	}
+	return ret, nil
+}
+
+func (instance *SInstance) GetIEIP() (cloudprovider.ICloudEIP, error) {
+	eips, err := instance.getRegion().GetEips()
+	if err != nil {
+		return nil, err
+	}
+	for i := range eips {
+		if eips[i].AssociateType == api.EIP_ASSOCIATE_TYPE_SERVER && eips[i].AssociateId == instance.Id {
+			return &eips[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (instance *SInstance) GetVcpuCount() int {
+	return instance.Cpu
+}
+
+func (instance *SInstance) GetVmemSizeMB() int {
+	return instance.MemoryMb
+}
+
+func (instance *SInstance) GetBootOrder() string {
+	return "dcn"
+}
+
+func (instance *SInstance) GetVga() string {
+	return "std"
+}
+
+func (instance *SInstance) GetVdi() string {
+	return "vnc"
+}
+
+func (instance *SInstance) GetOSType() string {
+	return instance.OsType
+}
+
+func (instance *SInstance) GetOSName() string {
+	return instance.OsName
+}
+
+func (instance *SInstance) GetBios() string {
+	return "BIOS"
+}
+
+func (instance *SInstance) GetMachine() string {
+	return "pc"
+}
+
+func (instance *SInstance) GetInstanceType() string {
+	return instance.InstanceType
+}
+
+func (instance *SInstance) GetHypervisor() string {
+	return api.HYPERVISOR_SIMULATOR
+}
+
+func (instance *SInstance) GetSecurityGroupIds() ([]string, error) {
+	return instance.SecgroupIds, nil
+}
+
+func (instance *SInstance) AssignSecurityGroup(secgroupId string) error {
+	return instance.updateRecord("update:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		if !utils.IsInStringArray(secgroupId, record.SecgroupIds) {
+			record.SecgroupIds = append(record.SecgroupIds, secgroupId)
+		}
+		return nil
+	})
+}
+
+func (instance *SInstance) SetSecurityGroups(secgroupIds []string) error {
+	return instance.updateRecord("update:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		record.SecgroupIds = secgroupIds
+		return nil
+	})
+}
+
+func (instance *SInstance) StartVM(ctx context.Context) error {
+	return instance.updateRecord("start:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		record.Status = INSTANCE_STATUS_RUNNING
+		return nil
+	})
+}
+
+func (instance *SInstance) StopVM(ctx context.Context, opts *cloudprovider.ServerStopOptions) error {
+	return instance.updateRecord("stop:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		record.Status = INSTANCE_STATUS_STOPPED
+		return nil
+	})
+}
+
+// 删除虚拟机时一并删除其磁盘, 并解绑弹性公网IP
+func (instance *SInstance) DeleteVM(ctx context.Context) error {
+	return instance.getStore().update("delete:instance", func(state *SSimulatorState) error {
+		disks := []SDiskRecord{}
+		for i := range state.Disks {
+			if state.Disks[i].InstanceId != instance.Id {
+				disks = append(disks, state.Disks[i])
+			}
+		}
+		state.Disks = disks
+		for i := range state.Eips {
+			if state.Eips[i].AssociateType == api.EIP_ASSOCIATE_TYPE_SERVER && state.Eips[i].AssociateId == instance.Id {
+				state.Eips[i].AssociateType, state.Eips[i].AssociateId = "", ""
+			}
+		}
+		for i := range state.Instances {
+			if state.Instances[i].Id == instance.Id {
+				state.Instances = append(state.Instances[:i], state.Instances[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "instance %s", instance.Id)
+	})
+}
+
+func (instance *SInstance) UpdateVM(ctx context.Context, name string) error {
+	return instance.updateRecord("update:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		record.Name = name
+		return nil
+	})
+}
+
+func (instance *SInstance) UpdateUserData(userData string) error {
+	return instance.updateRecord("update:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		record.UserData = userData
+		return nil
+	})
+}
+
+// RebuildRoot 以新镜像重建系统盘, 系统盘id保持不变
+func (instance *SInstance) RebuildRoot(ctx context.Context, config *cloudprovider.SManagedVMRebuildRootConfig) (string, error) {
+	diskId := ""
+	err := instance.updateRecord("rebuild:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		for i := range state.Disks {
+			disk := &state.Disks[i]
+			if disk.InstanceId == instance.Id && disk.DiskType == api.DISK_TYPE_SYS {
+				disk.TemplateId = config.ImageId
+				if config.SysSizeGB*1024 > disk.SizeMb {
+					disk.SizeMb = config.SysSizeGB * 1024
+				}
+				diskId = disk.Id
+			}
+		}
+		if len(diskId) == 0 {
+			return errors.Wrapf(cloudprovider.ErrNotFound, "instance %s system disk", instance.Id)
+		}
+		record.ImageId = config.ImageId
+		if len(config.OsType) > 0 {
+			record.OsType = config.OsType
+		}
+		return nil
+	})
+	return diskId, err
+}
+
+func (instance *SInstance) DeployVM(ctx context.Context, name string, username string, password string, publicKey string, deleteKeypair bool, description string) error {
+	return instance.updateRecord("deploy:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		if len(description) > 0 {
+			record.Description = description
+		}
+		return nil
+	})
+}
+
+func (instance *SInstance) ChangeConfig(ctx context.Context, config *cloudprovider.SManagedVMChangeConfig) error {
+	return instance.updateRecord("change-config:instance", func(state *SSimulatorState, record *SInstanceRecord) error {
+		if config.Cpu > 0 {
+			record.Cpu = config.Cpu
+		}
+		if config.MemoryMB > 0 {
+			record.MemoryMb = config.MemoryMB
+		}
+		if len(config.InstanceType) > 0 {
+			record.InstanceType = config.InstanceType
+		}
+		return nil
+	})
+}
+
+func (instance *SInstance) GetVNCInfo() (jsonutils.JSONObject, error) {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString("vnc"), "protocol")
+	info.Add(jsonutils.NewString(instance.host.AccessIp), "host")
+	info.Add(jsonutils.NewInt(5900), "port")
+	info.Add(jsonutils.NewString(api.HYPERVISOR_SIMULATOR), "hypervisor")
+	return info, nil
+}
+
+func (instance *SInstance) nextDiskIndex(state *SSimulatorState) int {
+	indexes := []int{}
+	for i := range state.Disks {
+		if state.Disks[i].InstanceId == instance.Id {
+			indexes = append(indexes, state.Disks[i].Index)
+		}
+	}
+	sort.Ints(indexes)
+	if len(indexes) == 0 {
+		return 0
+	}
+	return indexes[len(indexes)-1] + 1
+}
+
+func (instance *SInstance) AttachDisk(ctx context.Context, diskId string) error {
+	return instance.getStore().update("attach:disk", func(state *SSimulatorState) error {
+		disk, err := state.getDisk(diskId)
+		if err != nil {
+			return err
+		}
+		if len(disk.InstanceId) > 0 && disk.InstanceId != instance.Id {
+			return errors.Wrapf(cloudprovider.ErrInvalidStatus, "disk %s already attached to %s", diskId, disk.InstanceId)
+		}
+		if disk.InstanceId != instance.Id {
+			disk.Index = instance.nextDiskIndex(state)
+			disk.InstanceId = instance.Id
+		}
+		return nil
+	})
+}
+
+func (instance *SInstance) DetachDisk(ctx context.Context, diskId string) error {
+	return instance.getStore().update("detach:disk", func(state *SSimulatorState) error {
+		disk, err := state.getDisk(diskId)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				return nil
+			}
+			return err
+		}
+		if disk.InstanceId == instance.Id {
+			if disk.DiskType == api.DISK_TYPE_SYS {
+				return errors.Wrapf(cloudprovider.ErrNotSupported, "detach system disk %s", diskId)
+			}
+			disk.InstanceId = ""
+		}
+		return nil
+	})
+}
+
+// CreateDisk 在系统盘所在存储上创建并挂载数据盘
+func (instance *SInstance) CreateDisk(ctx context.Context, sizeMb int, uuid string, driver string) error {
+	return instance.getStore().update("create:disk", func(state *SSimulatorState) error {
+		storageId := ""
+		for i := range state.Disks {
+			if state.Disks[i].InstanceId == instance.Id && state.Disks[i].DiskType == api.DISK_TYPE_SYS {
+				storageId = state.Disks[i].StorageId
+			}
+		}
+		if len(storageId) == 0 {
+			return errors.Wrapf(cloudprovider.ErrNotFound, "instance %s system disk", instance.Id)
+		}
+		index := instance.nextDiskIndex(state)
+		state.Disks = append(state.Disks, SDiskRecord{
+			SRecordBase: SRecordBase{Id: newId(KIND_DISK), Name: fmt.Sprintf("%s-disk-%d", instance.Name, index), Status: DISK_STATUS_READY},
+			StorageId:   storageId,
+			InstanceId:  instance.Id,
+			DiskType:    api.DISK_TYPE_DATA,
+			SizeMb:      sizeMb,
+			Index:       index,
+			ProjectId:   instance.ProjectId,
+			CreatedAt:   time.Now().UTC(),
+		})
+		return nil
+	})
+}
+
+func (instance *SInstance) Renew(bc billing.SBillingCycle) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) migrate(op string, hostId string) error {
+	host, err := instance.getRegion().GetHost(hostId)
+	if err != nil {
+		return errors.Wrapf(err, "GetHost(%s)", hostId)
+	}
+	err = instance.updateRecord(op, func(state *SSimulatorState, record *SInstanceRecord) error {
+		record.HostId = host.Id
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	instance.host = host
+	return nil
+}
+
+func (instance *SInstance) MigrateVM(hostId string) error {
+	return instance.migrate("migrate:instance", hostId)
+}
+
+func (instance *SInstance) LiveMigrateVM(hostId string) error {
+	return instance.migrate("live-migrate:instance", hostId)
+}
+
+func (instance *SInstance) GetError() error {
+	return nil
+}
+
+// SaveImage 以虚拟机当前状态生成自定义镜像
+func (instance *SInstance) SaveImage(opts *cloudprovider.SaveImageOptions) (cloudprovider.ICloudImage, error) {
+	image := SImageRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_IMAGE), Name: opts.Name, Status: IMAGE_STATUS_ACTIVE},
+		RegionId:    instance.getRegion().Id,
+		OsType:      instance.OsType,
+		OsDist:      instance.OsName,
+		Format:      "qcow2",
+		InstanceId:  instance.Id,
+		Description: opts.Notes,
+		CreatedAt:   time.Now().UTC(),
+	}
+	err := instance.getStore().update("create:image", func(state *SSimulatorState) error {
+		for i := range state.Disks {
+			if state.Disks[i].InstanceId == instance.Id && state.Disks[i].DiskType == api.DISK_TYPE_SYS {
+				image.MinDiskGb = state.Disks[i].SizeMb / 1024
+				image.SizeBytes = int64(state.Disks[i].SizeMb) * 1024 * 1024
+			}
+		}
+		state.Images = append(state.Images, image)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SImage{storageCache: instance.getRegion().getStoragecache(), SImageRecord: image}, nil
+}
+
+type SInstanceNic struct {
+	SNicRecord
+
+	instance *SInstance
+}
+
+func (nic *SInstanceNic) GetId() string {
+	return nic.Id
+}
+
+func (nic *SInstanceNic) GetIP() string {
+	return nic.Ip
+}
+
+func (nic *SInstanceNic) GetMAC() string {
+	return nic.Mac
+}
+
+func (nic *SInstanceNic) InClassicNetwork() bool {
+	return false
+}
+
+func (nic *SInstanceNic) GetDriver() string {
+	return "virtio"
+}
+
+func (nic *SInstanceNic) GetINetwork() cloudprovider.ICloudNetwork {
+	network, err := nic.instance.getRegion().GetNetwork(nic.NetworkId)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
+func (nic *SInstanceNic) GetSubAddress() ([]string, error) {
+	return nil, nil
+}
+
+func (nic *SInstanceNic) AssignNAddress(count int) ([]string, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (nic *SInstanceNic) AssignAddress(ipAddrs []string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (nic *SInstanceNic) UnassignAddress(ipAddrs []string) error {
+	return cloudprovider.ErrNotSupported
+}
@@ -0,0 +1,267 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// 负载均衡仅模拟实例本身, 监听及后端服务器组为空
+type SLoadbalancer struct {
+	multicloud.SLoadbalancerBase
+	SLoadbalancerRecord
+
+	region *SRegion
+}
+
+func (state *SSimulatorState) getLoadbalancer(id string) (*SLoadbalancerRecord, error) {
+	for i := range state.Loadbalancers {
+		if state.Loadbalancers[i].Id == id {
+			return &state.Loadbalancers[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "loadbalancer %s", id)
+}
+
+func (region *SRegion) GetLoadbalancers() ([]SLoadbalancer, error) {
+	lbs := []SLoadbalancer{}
+	err := region.getStore().view("list:loadbalancer", func(state *SSimulatorState) error {
+		for i := range state.Loadbalancers {
+			if state.Loadbalancers[i].RegionId == region.Id {
+				lbs = append(lbs, SLoadbalancer{region: region, SLoadbalancerRecord: state.Loadbalancers[i]})
+			}
+		}
+		return nil
+	})
+	return lbs, err
+}
+
+func (region *SRegion) GetLoadbalancer(id string) (*SLoadbalancer, error) {
+	lb := &SLoadbalancer{region: region}
+	err := region.getStore().view("get:loadbalancer", func(state *SSimulatorState) error {
+		record, err := state.getLoadbalancer(id)
+		if err != nil {
+			return err
+		}
+		lb.SLoadbalancerRecord = *record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lb, nil
+}
+
+func (region *SRegion) CreateLoadbalancer(opts *cloudprovider.SLoadbalancer) (*SLoadbalancer, error) {
+	record := SLoadbalancerRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_LOADBALANCER), Name: opts.Name, Status: api.LB_STATUS_ENABLED, Tags: opts.Tags},
+		RegionId:    region.Id,
+		ZoneId:      opts.ZoneID,
+		VpcId:       opts.VpcID,
+		NetworkIds:  opts.NetworkIDs,
+		AddressType: opts.AddressType,
+		Spec:        opts.LoadbalancerSpec,
+		ChargeType:  opts.ChargeType,
+		EgressMbps:  opts.EgressMbps,
+		ProjectId:   opts.ProjectId,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if len(record.AddressType) == 0 {
+		record.AddressType = api.LB_ADDR_TYPE_INTRANET
+	}
+	err := region.getStore().update("create:loadbalancer", func(state *SSimulatorState) error {
+		if len(opts.NetworkIDs) > 0 {
+			ip, err := state.allocateIp(opts.NetworkIDs[0], opts.Address)
+			if err != nil {
+				return errors.Wrapf(err, "allocateIp")
+			}
+			record.Address = ip
+		}
+		if len(opts.EipID) > 0 {
+			eip, err := state.getEip(opts.EipID)
+			if err != nil {
+				return err
+			}
+			eip.AssociateType = api.EIP_ASSOCIATE_TYPE_LOADBALANCER
+			eip.AssociateId = record.Id
+		}
+		state.Loadbalancers = append(state.Loadbalancers, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SLoadbalancer{region: region, SLoadbalancerRecord: record}, nil
+}
+
+func (lb *SLoadbalancer) setStatus(op, status string) error {
+	return lb.region.getStore().update(op, func(state *SSimulatorState) error {
+		record, err := state.getLoadbalancer(lb.Id)
+		if err != nil {
+			return err
+		}
+		record.Status = status
+		lb.SLoadbalancerRecord = *record
+		return nil
+	})
+}
+
+func (lb *SLoadbalancer) GetId() string {
+	return lb.Id
+}
+
+func (lb *SLoadbalancer) GetName() string {
+	return lb.Name
+}
+
+func (lb *SLoadbalancer) GetGlobalId() string {
+	return lb.Id
+}
+
+func (lb *SLoadbalancer) GetSysTags() map[string]string {
+	return lb.Tags
+}
+
+func (lb *SLoadbalancer) GetProjectId() string {
+	return lb.ProjectId
+}
+
+func (lb *SLoadbalancer) GetStatus() string {
+	if lb.Status == api.LB_STATUS_DISABLED {
+		return api.LB_STATUS_DISABLED
+	}
+	return api.LB_STATUS_ENABLED
+}
+
+func (lb *SLoadbalancer) Refresh() error {
+	_lb, err := lb.region.GetLoadbalancer(lb.Id)
+	if err != nil {
+		return err
+	}
+	lb.SLoadbalancerRecord = _lb.SLoadbalancerRecord
+	return nil
+}
+
+func (lb *SLoadbalancer) GetAddress() string {
+	return lb.Address
+}
+
+func (lb *SLoadbalancer) GetAddressType() string {
+	return lb.AddressType
+}
+
+func (lb *SLoadbalancer) GetNetworkType() string {
+	return api.LB_NETWORK_TYPE_VPC
+}
+
+func (lb *SLoadbalancer) GetNetworkIds() []string {
+	return lb.NetworkIds
+}
+
+func (lb *SLoadbalancer) GetVpcId() string {
+	return lb.VpcId
+}
+
+func (lb *SLoadbalancer) GetZoneId() string {
+	return lb.ZoneId
+}
+
+func (lb *SLoadbalancer) GetZone1Id() string {
+	return ""
+}
+
+func (lb *SLoadbalancer) GetLoadbalancerSpec() string {
+	return lb.Spec
+}
+
+func (lb *SLoadbalancer) GetChargeType() string {
+	if len(lb.ChargeType) > 0 {
+		return lb.ChargeType
+	}
+	return api.LB_CHARGE_TYPE_BY_TRAFFIC
+}
+
+func (lb *SLoadbalancer) GetEgressMbps() int {
+	return lb.EgressMbps
+}
+
+func (lb *SLoadbalancer) GetIEIP() (cloudprovider.ICloudEIP, error) {
+	eips, err := lb.region.GetEips()
+	if err != nil {
+		return nil, err
+	}
+	for i := range eips {
+		if eips[i].AssociateType == api.EIP_ASSOCIATE_TYPE_LOADBALANCER && eips[i].AssociateId == lb.Id {
+			return &eips[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (lb *SLoadbalancer) Delete(ctx context.Context) error {
+	return lb.region.getStore().update("delete:loadbalancer", func(state *SSimulatorState) error {
+		for i := range state.Eips {
+			if state.Eips[i].AssociateType == api.EIP_ASSOCIATE_TYPE_LOADBALANCER && state.Eips[i].AssociateId == lb.Id {
+				state.Eips[i].AssociateType, state.Eips[i].AssociateId = "", ""
+			}
+		}
+		for i := range state.Loadbalancers {
+			if state.Loadbalancers[i].Id == lb.Id {
+				state.Loadbalancers = append(state.Loadbalancers[:i], state.Loadbalancers[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (lb *SLoadbalancer) Start() error {
+	return lb.setStatus("start:loadbalancer", api.LB_STATUS_ENABLED)
+}
+
+func (lb *SLoadbalancer) Stop() error {
+	return lb.setStatus("stop:loadbalancer", api.LB_STATUS_DISABLED)
+}
+
+func (lb *SLoadbalancer) GetILoadBalancerListeners() ([]cloudprovider.ICloudLoadbalancerListener, error) {
+	return []cloudprovider.ICloudLoadbalancerListener{}, nil
+}
+
+func (lb *SLoadbalancer) GetILoadBalancerBackendGroups() ([]cloudprovider.ICloudLoadbalancerBackendGroup, error) {
+	return []cloudprovider.ICloudLoadbalancerBackendGroup{}, nil
+}
+
+func (lb *SLoadbalancer) CreateILoadBalancerBackendGroup(group *cloudprovider.SLoadbalancerBackendGroup) (cloudprovider.ICloudLoadbalancerBackendGroup, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (lb *SLoadbalancer) GetILoadBalancerBackendGroupById(groupId string) (cloudprovider.ICloudLoadbalancerBackendGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (lb *SLoadbalancer) CreateILoadBalancerListener(ctx context.Context, listener *cloudprovider.SLoadbalancerListener) (cloudprovider.ICloudLoadbalancerListener, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (lb *SLoadbalancer) GetILoadBalancerListenerById(listenerId string) (cloudprovider.ICloudLoadbalancerListener, error) {
+	return nil, cloudprovider.ErrNotFound
+}
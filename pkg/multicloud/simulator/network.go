@@ -0,0 +1,222 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SNetwork struct {
+	multicloud.SResourceBase
+	SNetworkRecord
+
+	wire *SWire
+}
+
+func (network *SNetworkRecord) prefix() (netutils.IPV4Prefix, error) {
+	return netutils.NewIPV4Prefix(network.Cidr)
+}
+
+// 可分配地址范围, 除去网络地址, 网关及广播地址
+func (network *SNetworkRecord) ipRange() (netutils.IPV4AddrRange, error) {
+	prefix, err := network.prefix()
+	if err != nil {
+		return netutils.IPV4AddrRange{}, errors.Wrapf(err, "network %s cidr %s", network.Id, network.Cidr)
+	}
+	ipRange := prefix.ToIPRange()
+	start, end := ipRange.StartIp().StepUp(), ipRange.EndIp().StepDown()
+	if gw, err := netutils.NewIPV4Addr(network.Gateway); err == nil && gw == start {
+		start = start.StepUp()
+	}
+	return netutils.NewIPV4AddrRange(start, end), nil
+}
+
+// 已被虚拟机, 负载均衡及数据库实例占用的地址
+func (state *SSimulatorState) usedIps(networkId string) map[string]bool {
+	used := map[string]bool{}
+	for i := range state.Instances {
+		for _, nic := range state.Instances[i].Nics {
+			if nic.NetworkId == networkId {
+				used[nic.Ip] = true
+			}
+		}
+	}
+	for i := range state.Loadbalancers {
+		for _, netId := range state.Loadbalancers[i].NetworkIds {
+			if netId == networkId {
+				used[state.Loadbalancers[i].Address] = true
+			}
+		}
+	}
+	for i := range state.Dbinstances {
+		if state.Dbinstances[i].NetworkId == networkId {
+			used[state.Dbinstances[i].Address] = true
+		}
+	}
+	return used
+}
+
+func (state *SSimulatorState) getNetwork(networkId string) (*SNetworkRecord, error) {
+	for i := range state.Networks {
+		if state.Networks[i].Id == networkId {
+			return &state.Networks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", networkId)
+}
+
+// allocateIp 在子网中分配地址, ipAddr不为空时校验该地址是否可用
+func (state *SSimulatorState) allocateIp(networkId string, ipAddr string) (string, error) {
+	network, err := state.getNetwork(networkId)
+	if err != nil {
+		return "", err
+	}
+	ipRange, err := network.ipRange()
+	if err != nil {
+		return "", err
+	}
+	used := state.usedIps(networkId)
+	if len(ipAddr) > 0 {
+		ip, err := netutils.NewIPV4Addr(ipAddr)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid ip %s", ipAddr)
+		}
+		if !ipRange.Contains(ip) {
+			return "", errors.Errorf("ip %s out of network %s range", ipAddr, network.Cidr)
+		}
+		if used[ipAddr] {
+			return "", errors.Wrapf(cloudprovider.ErrDuplicateId, "ip %s already in use", ipAddr)
+		}
+		return ipAddr, nil
+	}
+	for ip := ipRange.StartIp(); ip <= ipRange.EndIp(); ip = ip.StepUp() {
+		if !used[ip.String()] {
+			return ip.String(), nil
+		}
+	}
+	return "", errors.Errorf("network %s has no free ip", network.Id)
+}
+
+func (region *SRegion) GetNetwork(networkId string) (*SNetwork, error) {
+	record := SNetworkRecord{}
+	err := region.getStore().view("get:network", func(state *SSimulatorState) error {
+		network, err := state.getNetwork(networkId)
+		if err != nil {
+			return err
+		}
+		record = *network
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	wire, err := region.GetWire(record.VpcId + "/" + record.ZoneId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "network %s wire", networkId)
+	}
+	return &SNetwork{wire: wire, SNetworkRecord: record}, nil
+}
+
+func (network *SNetwork) GetId() string {
+	return network.Id
+}
+
+func (network *SNetwork) GetName() string {
+	return network.Name
+}
+
+func (network *SNetwork) GetGlobalId() string {
+	return network.Id
+}
+
+func (network *SNetwork) GetStatus() string {
+	if len(network.Status) > 0 {
+		return network.Status
+	}
+	return api.NETWORK_STATUS_AVAILABLE
+}
+
+func (network *SNetwork) GetSysTags() map[string]string {
+	return network.Tags
+}
+
+func (network *SNetwork) Refresh() error {
+	_network, err := network.wire.vpc.region.GetNetwork(network.Id)
+	if err != nil {
+		return err
+	}
+	network.SNetworkRecord = _network.SNetworkRecord
+	return nil
+}
+
+func (network *SNetwork) Delete() error {
+	return network.wire.vpc.region.getStore().update("delete:network", func(state *SSimulatorState) error {
+		if used := state.usedIps(network.Id); len(used) > 0 {
+			return errors.Wrapf(cloudprovider.ErrInvalidStatus, "network %s has %d ips in use", network.Id, len(used))
+		}
+		for i := range state.Networks {
+			if state.Networks[i].Id == network.Id {
+				state.Networks = append(state.Networks[:i], state.Networks[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "network %s", network.Id)
+	})
+}
+
+func (network *SNetwork) GetIWire() cloudprovider.ICloudWire {
+	return network.wire
+}
+
+func (network *SNetwork) GetAllocTimeoutSeconds() int {
+	return 120 // 2 minutes
+}
+
+func (network *SNetwork) GetGateway() string {
+	return network.Gateway
+}
+
+func (network *SNetwork) GetIpStart() string {
+	ipRange, _ := network.ipRange()
+	return ipRange.StartIp().String()
+}
+
+func (network *SNetwork) GetIpEnd() string {
+	ipRange, _ := network.ipRange()
+	return ipRange.EndIp().String()
+}
+
+func (network *SNetwork) GetIpMask() int8 {
+	prefix, _ := network.prefix()
+	return prefix.MaskLen
+}
+
+func (network *SNetwork) GetPublicScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (network *SNetwork) GetServerType() string {
+	return api.NETWORK_TYPE_GUEST
+}
+
+func (network *SNetwork) GetProjectId() string {
+	return ""
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud/simulator"
+)
+
+type SSimulatorProviderFactory struct {
+	cloudprovider.SPrivateCloudBaseProviderFactory
+}
+
+func (self *SSimulatorProviderFactory) GetId() string {
+	return simulator.CLOUD_PROVIDER_SIMULATOR
+}
+
+func (self *SSimulatorProviderFactory) GetName() string {
+	return simulator.CLOUD_PROVIDER_SIMULATOR
+}
+
+// auth_url 为状态存储地址, 为空时使用进程内的 memory://default
+func (self *SSimulatorProviderFactory) ValidateCreateCloudaccountData(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	output.AccessUrl = input.AuthUrl
+	if len(output.AccessUrl) == 0 {
+		output.AccessUrl = simulator.DEFAULT_STORE_URL
+	}
+	output.Account = input.Username
+	if len(output.Account) == 0 {
+		output.Account = "simulator"
+	}
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SSimulatorProviderFactory) ValidateUpdateCloudaccountCredential(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential, cloudaccount string) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	output.Account = input.Username
+	if len(output.Account) == 0 {
+		output.Account = "simulator"
+	}
+	output.Secret = input.Password
+	return output, nil
+}
+
+func (self *SSimulatorProviderFactory) GetProvider(cfg cloudprovider.ProviderConfig) (cloudprovider.ICloudProvider, error) {
+	client, err := simulator.NewSimulatorClient(
+		simulator.NewSimulatorClientConfig(
+			cfg.URL, cfg.Account,
+		).CloudproviderConfig(cfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &SSimulatorProvider{
+		SBaseProvider: cloudprovider.NewBaseProvider(self),
+		client:        client,
+	}, nil
+}
+
+func (self *SSimulatorProviderFactory) GetClientRC(info cloudprovider.SProviderInfo) (map[string]string, error) {
+	return map[string]string{
+		"SIMULATOR_STORE_URL": info.Url,
+		"SIMULATOR_ACCOUNT":   info.Account,
+		"SIMULATOR_REGION_ID": simulator.SIMULATOR_DEFAULT_REGION,
+	}, nil
+}
+
+func init() {
+	factory := SSimulatorProviderFactory{}
+	cloudprovider.RegisterFactory(&factory)
+}
+
+type SSimulatorProvider struct {
+	cloudprovider.SBaseProvider
+	client *simulator.SSimulatorClient
+}
+
+func (self *SSimulatorProvider) GetVersion() string {
+	return "1.0"
+}
+
+func (self *SSimulatorProvider) GetSysInfo() (jsonutils.JSONObject, error) {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString(self.client.GetStore().GetUrl()), "store_url")
+	return info, nil
+}
+
+func (self *SSimulatorProvider) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	return self.client.GetSubAccounts()
+}
+
+func (self *SSimulatorProvider) GetAccountId() string {
+	return self.client.GetAccountId()
+}
+
+func (self *SSimulatorProvider) GetIRegions() []cloudprovider.ICloudRegion {
+	return self.client.GetIRegions()
+}
+
+func (self *SSimulatorProvider) GetIRegionById(extId string) (cloudprovider.ICloudRegion, error) {
+	return self.client.GetIRegionById(extId)
+}
+
+func (self *SSimulatorProvider) GetBalance() (float64, string, error) {
+	return 0.0, api.CLOUD_PROVIDER_HEALTH_UNKNOWN, cloudprovider.ErrNotSupported
+}
+
+func (self *SSimulatorProvider) GetCloudRegionExternalIdPrefix() string {
+	return self.client.GetCloudRegionExternalIdPrefix()
+}
+
+func (self *SSimulatorProvider) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return self.client.GetIProjects()
+}
+
+func (self *SSimulatorProvider) GetStorageClasses(regionId string) []string {
+	return []string{"STANDARD"}
+}
+
+func (self *SSimulatorProvider) GetBucketCannedAcls(regionId string) []string {
+	return []string{
+		string(cloudprovider.ACLPrivate),
+		string(cloudprovider.ACLPublicRead),
+		string(cloudprovider.ACLPublicReadWrite),
+	}
+}
+
+func (self *SSimulatorProvider) GetObjectCannedAcls(regionId string) []string {
+	return self.GetBucketCannedAcls(regionId)
+}
+
+func (self *SSimulatorProvider) GetCapabilities() []string {
+	return self.client.GetCapabilities()
+}
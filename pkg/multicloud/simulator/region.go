@@ -0,0 +1,352 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SRegion struct {
+	multicloud.SRegion
+	SRegionRecord
+
+	client *SSimulatorClient
+}
+
+func (region *SRegion) GetClient() *SSimulatorClient {
+	return region.client
+}
+
+func (region *SRegion) getStore() *SStore {
+	return region.client.store
+}
+
+func (region *SRegion) GetId() string {
+	return region.Id
+}
+
+func (region *SRegion) GetName() string {
+	return region.Name
+}
+
+func (region *SRegion) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(region.GetName()).CN(region.GetName())
+	return table
+}
+
+func (region *SRegion) GetGlobalId() string {
+	return fmt.Sprintf("%s/%s/%s", CLOUD_PROVIDER_SIMULATOR, region.client.cpcfg.Id, region.Id)
+}
+
+func (region *SRegion) IsEmulated() bool {
+	return false
+}
+
+func (region *SRegion) GetProvider() string {
+	return CLOUD_PROVIDER_SIMULATOR
+}
+
+func (region *SRegion) GetCloudEnv() string {
+	return ""
+}
+
+func (region *SRegion) GetGeographicInfo() cloudprovider.SGeographicInfo {
+	return cloudprovider.SGeographicInfo{}
+}
+
+func (region *SRegion) GetStatus() string {
+	if len(region.Status) > 0 {
+		return region.Status
+	}
+	return api.CLOUD_REGION_STATUS_INSERVER
+}
+
+func (region *SRegion) GetSysTags() map[string]string {
+	return region.Tags
+}
+
+func (region *SRegion) GetTags() (map[string]string, error) {
+	return region.Tags, nil
+}
+
+func (region *SRegion) Refresh() error {
+	return region.getStore().view("get:region", func(state *SSimulatorState) error {
+		for i := range state.Regions {
+			if state.Regions[i].Id == region.Id {
+				region.SRegionRecord = state.Regions[i]
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "region %s", region.Id)
+	})
+}
+
+func (region *SRegion) GetCapabilities() []string {
+	return region.client.GetCapabilities()
+}
+
+func (region *SRegion) GetZones() ([]SZone, error) {
+	zones := []SZone{}
+	err := region.getStore().view("list:zone", func(state *SSimulatorState) error {
+		for i := range state.Zones {
+			if state.Zones[i].RegionId == region.Id {
+				zones = append(zones, SZone{region: region, SZoneRecord: state.Zones[i]})
+			}
+		}
+		return nil
+	})
+	return zones, err
+}
+
+func (region *SRegion) GetZone(id string) (*SZone, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	for i := range zones {
+		if zones[i].Id == id {
+			return &zones[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "zone %s", id)
+}
+
+func (region *SRegion) GetIZones() ([]cloudprovider.ICloudZone, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudZone{}
+	for i := range zones {
+		ret = append(ret, &zones[i])
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetIZoneById(id string) (cloudprovider.ICloudZone, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	for i := range zones {
+		if zones[i].GetGlobalId() == id {
+			return &zones[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetIVpcs() ([]cloudprovider.ICloudVpc, error) {
+	vpcs, err := region.GetVpcs()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudVpc{}
+	for i := range vpcs {
+		ret = append(ret, &vpcs[i])
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetIVpcById(id string) (cloudprovider.ICloudVpc, error) {
+	return region.GetVpc(id)
+}
+
+func (region *SRegion) CreateIVpc(name string, desc string, cidr string) (cloudprovider.ICloudVpc, error) {
+	return region.CreateVpc(name, cidr)
+}
+
+func (region *SRegion) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudHost{}
+	for i := range zones {
+		hosts, err := zones[i].GetIHosts()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, hosts...)
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetIHostById(id string) (cloudprovider.ICloudHost, error) {
+	return region.GetHost(id)
+}
+
+func (region *SRegion) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudStorage{}
+	for i := range zones {
+		storages, err := zones[i].GetIStorages()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, storages...)
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	return region.GetStorage(id)
+}
+
+func (region *SRegion) getStoragecache() *SStoragecache {
+	return &SStoragecache{region: region}
+}
+
+func (region *SRegion) GetIStoragecaches() ([]cloudprovider.ICloudStoragecache, error) {
+	return []cloudprovider.ICloudStoragecache{region.getStoragecache()}, nil
+}
+
+func (region *SRegion) GetIStoragecacheById(id string) (cloudprovider.ICloudStoragecache, error) {
+	cache := region.getStoragecache()
+	if cache.GetGlobalId() != id {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return cache, nil
+}
+
+func (region *SRegion) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	return region.GetInstance(id)
+}
+
+func (region *SRegion) GetIDiskById(id string) (cloudprovider.ICloudDisk, error) {
+	return region.GetDisk(id)
+}
+
+func (region *SRegion) GetIEips() ([]cloudprovider.ICloudEIP, error) {
+	eips, err := region.GetEips()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudEIP{}
+	for i := range eips {
+		ret = append(ret, &eips[i])
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetIEipById(id string) (cloudprovider.ICloudEIP, error) {
+	return region.GetEip(id)
+}
+
+func (region *SRegion) CreateEIP(opts *cloudprovider.SEip) (cloudprovider.ICloudEIP, error) {
+	return region.CreateEip(opts)
+}
+
+func (region *SRegion) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (region *SRegion) GetISnapshotById(snapshotId string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetISecurityGroupById(secgroupId string) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetISecurityGroupByName(opts *cloudprovider.SecurityGroupFilterOptions) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) CreateISecurityGroup(conf *cloudprovider.SecurityGroupCreateInput) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancers() ([]cloudprovider.ICloudLoadbalancer, error) {
+	lbs, err := region.GetLoadbalancers()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudLoadbalancer{}
+	for i := range lbs {
+		ret = append(ret, &lbs[i])
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetILoadBalancerById(loadbalancerId string) (cloudprovider.ICloudLoadbalancer, error) {
+	return region.GetLoadbalancer(loadbalancerId)
+}
+
+func (region *SRegion) CreateILoadBalancer(loadbalancer *cloudprovider.SLoadbalancer) (cloudprovider.ICloudLoadbalancer, error) {
+	return region.CreateLoadbalancer(loadbalancer)
+}
+
+func (region *SRegion) GetILoadBalancerAclById(aclId string) (cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetILoadBalancerCertificateById(certId string) (cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) CreateILoadBalancerCertificate(cert *cloudprovider.SLoadbalancerCertificate) (cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerAcls() ([]cloudprovider.ICloudLoadbalancerAcl, error) {
+	return []cloudprovider.ICloudLoadbalancerAcl{}, nil
+}
+
+func (region *SRegion) GetILoadBalancerCertificates() ([]cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return []cloudprovider.ICloudLoadbalancerCertificate{}, nil
+}
+
+func (region *SRegion) GetILoadBalancerBackendGroups() ([]cloudprovider.ICloudLoadbalancerBackendGroup, error) {
+	return []cloudprovider.ICloudLoadbalancerBackendGroup{}, nil
+}
+
+func (region *SRegion) CreateILoadBalancerAcl(acl *cloudprovider.SLoadbalancerAccessControlList) (cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetIDBInstances() ([]cloudprovider.ICloudDBInstance, error) {
+	instances, err := region.GetDBInstances()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudDBInstance{}
+	for i := range instances {
+		ret = append(ret, &instances[i])
+	}
+	return ret, nil
+}
+
+func (region *SRegion) GetIDBInstanceById(instanceId string) (cloudprovider.ICloudDBInstance, error) {
+	return region.GetDBInstance(instanceId)
+}
+
+func (region *SRegion) GetIDBInstanceBackups() ([]cloudprovider.ICloudDBInstanceBackup, error) {
+	return []cloudprovider.ICloudDBInstanceBackup{}, nil
+}
+
+func (region *SRegion) CreateIDBInstance(desc *cloudprovider.SManagedDBInstanceCreateConfig) (cloudprovider.ICloudDBInstance, error) {
+	return region.CreateDBInstance(desc)
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/multicloud/simulator"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type StateShowOptions struct {
+	}
+	shellutils.R(&StateShowOptions{}, "state-show", "Show simulator state", func(cli *simulator.SRegion, args *StateShowOptions) error {
+		state, err := cli.GetClient().GetStore().GetState()
+		if err != nil {
+			return err
+		}
+		printObject(state)
+		return nil
+	})
+
+	shellutils.R(&StateShowOptions{}, "state-reset", "Reset simulator state to default", func(cli *simulator.SRegion, args *StateShowOptions) error {
+		return cli.GetClient().GetStore().Reset()
+	})
+
+	type LatencySetOptions struct {
+		LATENCY_MS int `help:"Latency of every api call in milliseconds"`
+	}
+	shellutils.R(&LatencySetOptions{}, "latency-set", "Set api latency", func(cli *simulator.SRegion, args *LatencySetOptions) error {
+		return cli.GetClient().GetStore().SetLatency(args.LATENCY_MS)
+	})
+
+	type FaultInjectOptions struct {
+		OP    string  `help:"Api op pattern, e.g. create:instance or *:disk"`
+		ERROR string  `help:"Error to return, e.g. NotFound, Timeout or any message"`
+		Count int     `help:"Times to fail, negative means forever" default:"1"`
+		Rate  float64 `help:"Probability of failure, 0 means always"`
+	}
+	shellutils.R(&FaultInjectOptions{}, "fault-inject", "Inject api fault", func(cli *simulator.SRegion, args *FaultInjectOptions) error {
+		return cli.GetClient().GetStore().InjectFault(simulator.SFault{
+			Op:    args.OP,
+			Error: args.ERROR,
+			Count: args.Count,
+			Rate:  args.Rate,
+		})
+	})
+
+	shellutils.R(&StateShowOptions{}, "fault-clear", "Clear injected faults", func(cli *simulator.SRegion, args *StateShowOptions) error {
+		return cli.GetClient().GetStore().ClearFaults()
+	})
+
+	type ResourceUpsertOptions struct {
+		KIND string `help:"Resource kind, e.g. instance"`
+		DATA string `help:"Resource json, with id to update an existing one"`
+	}
+	shellutils.R(&ResourceUpsertOptions{}, "resource-upsert", "Create or update remote resource directly", func(cli *simulator.SRegion, args *ResourceUpsertOptions) error {
+		data, err := jsonutils.ParseString(args.DATA)
+		if err != nil {
+			return err
+		}
+		ret, err := cli.GetClient().GetStore().Upsert(args.KIND, data)
+		if err != nil {
+			return err
+		}
+		printObject(ret)
+		return nil
+	})
+
+	type ResourceDeleteOptions struct {
+		KIND string
+		ID   string
+	}
+	shellutils.R(&ResourceDeleteOptions{}, "resource-delete", "Delete remote resource directly", func(cli *simulator.SRegion, args *ResourceDeleteOptions) error {
+		return cli.GetClient().GetStore().Delete(args.KIND, args.ID)
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/simulator"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type InstanceListOptions struct {
+		Host string
+	}
+	shellutils.R(&InstanceListOptions{}, "instance-list", "List instances", func(cli *simulator.SRegion, args *InstanceListOptions) error {
+		instances, err := cli.GetInstances(args.Host)
+		if err != nil {
+			return err
+		}
+		printList(instances, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type InstanceIdOptions struct {
+		ID string
+	}
+	shellutils.R(&InstanceIdOptions{}, "instance-show", "Show instance", func(cli *simulator.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		printObject(instance)
+		return nil
+	})
+
+	type InstanceCreateOptions struct {
+		HOST      string `help:"Host to create instance on"`
+		NAME      string
+		IMAGE     string
+		NETWORK   string
+		Cpu       int   `default:"1"`
+		MemoryMb  int   `default:"1024"`
+		SysDiskGb int   `default:"30"`
+		DataDisk  []int `help:"Data disk size in GB"`
+	}
+	shellutils.R(&InstanceCreateOptions{}, "instance-create", "Create instance", func(cli *simulator.SRegion, args *InstanceCreateOptions) error {
+		host, err := cli.GetHost(args.HOST)
+		if err != nil {
+			return err
+		}
+		desc := &cloudprovider.SManagedVMCreateConfig{
+			Name:              args.NAME,
+			ExternalImageId:   args.IMAGE,
+			ExternalNetworkId: args.NETWORK,
+			Cpu:               args.Cpu,
+			MemoryMB:          args.MemoryMb,
+			SysDisk:           cloudprovider.SDiskInfo{SizeGB: args.SysDiskGb},
+		}
+		for _, size := range args.DataDisk {
+			desc.DataDisks = append(desc.DataDisks, cloudprovider.SDiskInfo{SizeGB: size})
+		}
+		instance, err := cli.CreateInstance(host, desc)
+		if err != nil {
+			return err
+		}
+		printObject(instance)
+		return nil
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-start", "Start instance", func(cli *simulator.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.StartVM(context.Background())
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-stop", "Stop instance", func(cli *simulator.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.StopVM(context.Background(), &cloudprovider.ServerStopOptions{})
+	})
+
+	shellutils.R(&InstanceIdOptions{}, "instance-delete", "Delete instance", func(cli *simulator.SRegion, args *InstanceIdOptions) error {
+		instance, err := cli.GetInstance(args.ID)
+		if err != nil {
+			return err
+		}
+		return instance.DeleteVM(context.Background())
+	})
+
+	type DiskListOptions struct {
+		Storage  string
+		Instance string
+	}
+	shellutils.R(&DiskListOptions{}, "disk-list", "List disks", func(cli *simulator.SRegion, args *DiskListOptions) error {
+		disks, err := cli.GetDisks(args.Storage, args.Instance)
+		if err != nil {
+			return err
+		}
+		printList(disks, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DiskCreateOptions struct {
+		STORAGE string
+		NAME    string
+		SIZE_GB int
+	}
+	shellutils.R(&DiskCreateOptions{}, "disk-create", "Create disk", func(cli *simulator.SRegion, args *DiskCreateOptions) error {
+		disk, err := cli.CreateDisk(args.STORAGE, args.NAME, args.SIZE_GB*1024, "", "")
+		if err != nil {
+			return err
+		}
+		printObject(disk)
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/multicloud/simulator"
+	"yunion.io/x/onecloud/pkg/multicloud/test"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	test.TestShell()
+
+	type ZoneListOptions struct {
+	}
+	shellutils.R(&ZoneListOptions{}, "zone-list", "List zones", func(cli *simulator.SRegion, args *ZoneListOptions) error {
+		zones, err := cli.GetZones()
+		if err != nil {
+			return err
+		}
+		printList(zones, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type VpcListOptions struct {
+	}
+	shellutils.R(&VpcListOptions{}, "vpc-list", "List vpcs", func(cli *simulator.SRegion, args *VpcListOptions) error {
+		vpcs, err := cli.GetVpcs()
+		if err != nil {
+			return err
+		}
+		printList(vpcs, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type VpcCreateOptions struct {
+		NAME string
+		CIDR string
+	}
+	shellutils.R(&VpcCreateOptions{}, "vpc-create", "Create vpc", func(cli *simulator.SRegion, args *VpcCreateOptions) error {
+		vpc, err := cli.CreateVpc(args.NAME, args.CIDR)
+		if err != nil {
+			return err
+		}
+		printObject(vpc)
+		return nil
+	})
+
+	type NetworkListOptions struct {
+		WIRE string `help:"Wire id, e.g. vpc-1/zone-1"`
+	}
+	shellutils.R(&NetworkListOptions{}, "network-list", "List networks", func(cli *simulator.SRegion, args *NetworkListOptions) error {
+		wire, err := cli.GetWire(args.WIRE)
+		if err != nil {
+			return err
+		}
+		networks, err := wire.GetNetworks()
+		if err != nil {
+			return err
+		}
+		printList(networks, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type HostListOptions struct {
+		Zone string
+	}
+	shellutils.R(&HostListOptions{}, "host-list", "List hosts", func(cli *simulator.SRegion, args *HostListOptions) error {
+		hosts, err := cli.GetHosts(args.Zone)
+		if err != nil {
+			return err
+		}
+		printList(hosts, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type StorageListOptions struct {
+		Zone string
+	}
+	shellutils.R(&StorageListOptions{}, "storage-list", "List storages", func(cli *simulator.SRegion, args *StorageListOptions) error {
+		storages, err := cli.GetStorages(args.Zone)
+		if err != nil {
+			return err
+		}
+		printList(storages, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type ImageListOptions struct {
+	}
+	shellutils.R(&ImageListOptions{}, "image-list", "List images", func(cli *simulator.SRegion, args *ImageListOptions) error {
+		images, err := cli.GetImages()
+		if err != nil {
+			return err
+		}
+		printList(images, 0, 0, 0, []string{})
+		return nil
+	})
+}
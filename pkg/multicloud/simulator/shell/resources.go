@@ -0,0 +1,122 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud/simulator"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+	type EipListOptions struct {
+	}
+	shellutils.R(&EipListOptions{}, "eip-list", "List eips", func(cli *simulator.SRegion, args *EipListOptions) error {
+		eips, err := cli.GetEips()
+		if err != nil {
+			return err
+		}
+		printList(eips, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type EipCreateOptions struct {
+		NAME      string
+		Bandwidth int `default:"100"`
+		Network   string
+		Ip        string
+	}
+	shellutils.R(&EipCreateOptions{}, "eip-create", "Create eip", func(cli *simulator.SRegion, args *EipCreateOptions) error {
+		eip, err := cli.CreateEip(&cloudprovider.SEip{
+			Name:              args.NAME,
+			BandwidthMbps:     args.Bandwidth,
+			NetworkExternalId: args.Network,
+			IP:                args.Ip,
+		})
+		if err != nil {
+			return err
+		}
+		printObject(eip)
+		return nil
+	})
+
+	type LoadbalancerListOptions struct {
+	}
+	shellutils.R(&LoadbalancerListOptions{}, "lb-list", "List loadbalancers", func(cli *simulator.SRegion, args *LoadbalancerListOptions) error {
+		lbs, err := cli.GetLoadbalancers()
+		if err != nil {
+			return err
+		}
+		printList(lbs, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type BucketListOptions struct {
+	}
+	shellutils.R(&BucketListOptions{}, "bucket-list", "List buckets", func(cli *simulator.SRegion, args *BucketListOptions) error {
+		buckets, err := cli.GetBuckets()
+		if err != nil {
+			return err
+		}
+		printList(buckets, 0, 0, 0, []string{"id", "name", "storage_class", "acl", "created_at"})
+		return nil
+	})
+
+	type BucketCreateOptions struct {
+		NAME         string
+		StorageClass string `default:"STANDARD"`
+		Acl          string
+	}
+	shellutils.R(&BucketCreateOptions{}, "bucket-create", "Create bucket", func(cli *simulator.SRegion, args *BucketCreateOptions) error {
+		return cli.CreateIBucket(args.NAME, args.StorageClass, args.Acl)
+	})
+
+	type DBInstanceListOptions struct {
+	}
+	shellutils.R(&DBInstanceListOptions{}, "dbinstance-list", "List dbinstances", func(cli *simulator.SRegion, args *DBInstanceListOptions) error {
+		rds, err := cli.GetDBInstances()
+		if err != nil {
+			return err
+		}
+		printList(rds, 0, 0, 0, []string{})
+		return nil
+	})
+
+	type DBInstanceCreateOptions struct {
+		NAME          string
+		NETWORK       string
+		Engine        string `default:"MySQL"`
+		EngineVersion string `default:"5.7"`
+		VcpuCount     int    `default:"2"`
+		VmemSizeMb    int    `default:"4096"`
+		DiskSizeGb    int    `default:"20"`
+	}
+	shellutils.R(&DBInstanceCreateOptions{}, "dbinstance-create", "Create dbinstance", func(cli *simulator.SRegion, args *DBInstanceCreateOptions) error {
+		rds, err := cli.CreateDBInstance(&cloudprovider.SManagedDBInstanceCreateConfig{
+			Name:          args.NAME,
+			NetworkId:     args.NETWORK,
+			Engine:        args.Engine,
+			EngineVersion: args.EngineVersion,
+			VcpuCount:     args.VcpuCount,
+			VmemSizeMb:    args.VmemSizeMb,
+			DiskSizeGB:    args.DiskSizeGb,
+		})
+		if err != nil {
+			return err
+		}
+		printObject(rds)
+		return nil
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+const (
+	CLOUD_PROVIDER_SIMULATOR = api.CLOUD_PROVIDER_SIMULATOR
+	SIMULATOR_DEFAULT_REGION = "region-1"
+)
+
+type SimulatorClientConfig struct {
+	cpcfg cloudprovider.ProviderConfig
+
+	url     string
+	account string
+
+	debug bool
+}
+
+// url 为状态存储地址, 如 memory://default 或 file:///tmp/simulator.json
+func NewSimulatorClientConfig(url, account string) *SimulatorClientConfig {
+	if len(url) == 0 {
+		url = DEFAULT_STORE_URL
+	}
+	cfg := &SimulatorClientConfig{
+		url:     url,
+		account: account,
+	}
+	return cfg
+}
+
+func (cfg *SimulatorClientConfig) CloudproviderConfig(cpcfg cloudprovider.ProviderConfig) *SimulatorClientConfig {
+	cfg.cpcfg = cpcfg
+	return cfg
+}
+
+func (cfg *SimulatorClientConfig) Debug(debug bool) *SimulatorClientConfig {
+	cfg.debug = debug
+	return cfg
+}
+
+type SSimulatorClient struct {
+	*SimulatorClientConfig
+
+	store *SStore
+}
+
+func NewSimulatorClient(cfg *SimulatorClientConfig) (*SSimulatorClient, error) {
+	store, err := GetStore(cfg.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetStore")
+	}
+	cli := &SSimulatorClient{
+		SimulatorClientConfig: cfg,
+		store:                 store,
+	}
+	_, err = cli.GetRegions()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetRegions")
+	}
+	return cli, nil
+}
+
+func (cli *SSimulatorClient) GetStore() *SStore {
+	return cli.store
+}
+
+func (cli *SSimulatorClient) GetCloudRegionExternalIdPrefix() string {
+	return fmt.Sprintf("%s/%s/", CLOUD_PROVIDER_SIMULATOR, cli.cpcfg.Id)
+}
+
+func (cli *SSimulatorClient) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	subAccount := cloudprovider.SSubAccount{
+		Account:      cli.account,
+		Name:         cli.cpcfg.Name,
+		HealthStatus: api.CLOUD_PROVIDER_HEALTH_NORMAL,
+	}
+	return []cloudprovider.SSubAccount{subAccount}, nil
+}
+
+func (cli *SSimulatorClient) GetAccountId() string {
+	return cli.url
+}
+
+func (cli *SSimulatorClient) GetRegions() ([]SRegion, error) {
+	regions := []SRegion{}
+	err := cli.store.view("list:region", func(state *SSimulatorState) error {
+		for i := range state.Regions {
+			regions = append(regions, SRegion{client: cli, SRegionRecord: state.Regions[i]})
+		}
+		return nil
+	})
+	return regions, err
+}
+
+// 区域可通过控制接口增删, 每次均从存储中重新获取
+func (cli *SSimulatorClient) GetIRegions() []cloudprovider.ICloudRegion {
+	regions, err := cli.GetRegions()
+	if err != nil {
+		log.Errorf("simulator GetRegions error: %v", err)
+		return nil
+	}
+	ret := []cloudprovider.ICloudRegion{}
+	for i := range regions {
+		ret = append(ret, &regions[i])
+	}
+	return ret
+}
+
+func (cli *SSimulatorClient) GetRegion(regionId string) (*SRegion, error) {
+	if len(regionId) == 0 {
+		regionId = SIMULATOR_DEFAULT_REGION
+	}
+	regions, err := cli.GetRegions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range regions {
+		if regions[i].Id == regionId {
+			return &regions[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "region %s", regionId)
+}
+
+func (cli *SSimulatorClient) GetIRegionById(id string) (cloudprovider.ICloudRegion, error) {
+	regions, err := cli.GetRegions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range regions {
+		if regions[i].GetGlobalId() == id {
+			return &regions[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (cli *SSimulatorClient) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (cli *SSimulatorClient) GetCapabilities() []string {
+	caps := []string{
+		cloudprovider.CLOUD_CAPABILITY_COMPUTE,
+		cloudprovider.CLOUD_CAPABILITY_NETWORK,
+		cloudprovider.CLOUD_CAPABILITY_LOADBALANCER,
+		cloudprovider.CLOUD_CAPABILITY_OBJECTSTORE,
+		cloudprovider.CLOUD_CAPABILITY_RDS,
+	}
+	return caps
+}
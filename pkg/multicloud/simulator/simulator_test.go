@@ -0,0 +1,280 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+func newTestRegion(t *testing.T, url string) *SRegion {
+	cli, err := NewSimulatorClient(NewSimulatorClientConfig(url, "test").CloudproviderConfig(cloudprovider.ProviderConfig{Id: "test"}))
+	if err != nil {
+		t.Fatalf("NewSimulatorClient: %v", err)
+	}
+	region, err := cli.GetRegion(SIMULATOR_DEFAULT_REGION)
+	if err != nil {
+		t.Fatalf("GetRegion: %v", err)
+	}
+	return region
+}
+
+func TestInstanceLifecycle(t *testing.T) {
+	region := newTestRegion(t, "memory://"+t.Name())
+
+	ihost, err := region.GetIHostById("host-1")
+	if err != nil {
+		t.Fatalf("GetIHostById: %v", err)
+	}
+	ivm, err := ihost.CreateVM(&cloudprovider.SManagedVMCreateConfig{
+		Name:              "vm1",
+		ExternalImageId:   "image-1",
+		ExternalNetworkId: "network-1",
+		Cpu:               2,
+		MemoryMB:          2048,
+		SysDisk:           cloudprovider.SDiskInfo{SizeGB: 30},
+		DataDisks:         []cloudprovider.SDiskInfo{{SizeGB: 100}},
+	})
+	if err != nil {
+		t.Fatalf("CreateVM: %v", err)
+	}
+	if ivm.GetStatus() != api.VM_RUNNING {
+		t.Errorf("status = %s, want %s", ivm.GetStatus(), api.VM_RUNNING)
+	}
+	disks, err := ivm.GetIDisks()
+	if err != nil || len(disks) != 2 {
+		t.Fatalf("GetIDisks = %d, %v", len(disks), err)
+	}
+	if disks[0].GetDiskType() != api.DISK_TYPE_SYS || disks[0].GetDiskSizeMB() != 30*1024 {
+		t.Errorf("system disk = %s %d", disks[0].GetDiskType(), disks[0].GetDiskSizeMB())
+	}
+	nics, err := ivm.GetINics()
+	if err != nil || len(nics) != 1 {
+		t.Fatalf("GetINics = %d, %v", len(nics), err)
+	}
+	if ip := nics[0].GetIP(); ip != "10.0.0.2" {
+		t.Errorf("nic ip = %s, want 10.0.0.2", ip)
+	}
+
+	err = ivm.StopVM(context.Background(), &cloudprovider.ServerStopOptions{})
+	if err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	err = ivm.Refresh()
+	if err != nil || ivm.GetStatus() != api.VM_READY {
+		t.Errorf("status after stop = %s, %v", ivm.GetStatus(), err)
+	}
+
+	err = ivm.DeleteVM(context.Background())
+	if err != nil {
+		t.Fatalf("DeleteVM: %v", err)
+	}
+	_, err = region.GetIVMById(ivm.GetGlobalId())
+	if errors.Cause(err) != cloudprovider.ErrNotFound {
+		t.Errorf("GetIVMById after delete: %v", err)
+	}
+	_, err = region.GetIDiskById(disks[1].GetGlobalId())
+	if errors.Cause(err) != cloudprovider.ErrNotFound {
+		t.Errorf("GetIDiskById after delete: %v", err)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	region := newTestRegion(t, "memory://"+t.Name())
+	store := region.GetClient().GetStore()
+
+	err := store.InjectFault(SFault{Op: "list:*", Error: "Timeout", Count: 2})
+	if err != nil {
+		t.Fatalf("InjectFault: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = region.GetIVpcs()
+		if errors.Cause(err) != cloudprovider.ErrTimeout {
+			t.Errorf("GetIVpcs #%d: %v, want timeout", i, err)
+		}
+	}
+	_, err = region.GetIVpcs()
+	if err != nil {
+		t.Errorf("GetIVpcs after faults exhausted: %v", err)
+	}
+
+	err = store.InjectFault(SFault{Op: "create:eip", Error: "quota exceeded", Count: -1})
+	if err != nil {
+		t.Fatalf("InjectFault: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err = region.CreateEIP(&cloudprovider.SEip{Name: "eip"})
+		if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+			t.Errorf("CreateEIP #%d: %v", i, err)
+		}
+	}
+	err = store.ClearFaults()
+	if err != nil {
+		t.Fatalf("ClearFaults: %v", err)
+	}
+	_, err = region.CreateEIP(&cloudprovider.SEip{Name: "eip"})
+	if err != nil {
+		t.Errorf("CreateEIP after clear: %v", err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	region := newTestRegion(t, "memory://"+t.Name())
+	err := region.GetClient().GetStore().SetLatency(50)
+	if err != nil {
+		t.Fatalf("SetLatency: %v", err)
+	}
+	start := time.Now()
+	_, err = region.GetIZones()
+	if err != nil {
+		t.Fatalf("GetIZones: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("GetIZones took %s, want at least 50ms", elapsed)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simulator")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	fileName := filepath.Join(dir, "state.json")
+	region := newTestRegion(t, "file://"+fileName)
+
+	// 模拟另一个进程直接修改状态文件
+	state, err := region.GetClient().GetStore().GetState()
+	if err != nil {
+		t.Fatalf("GetState: %v", err)
+	}
+	state.Hosts[0].Status = HOST_STATUS_OFFLINE
+	// 保证修改时间或大小发生变化
+	time.Sleep(10 * time.Millisecond)
+	err = ioutil.WriteFile(fileName, []byte(jsonutils.Marshal(state).PrettyString()), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	host, err := region.GetHost("host-1")
+	if err != nil {
+		t.Fatalf("GetHost: %v", err)
+	}
+	if host.GetHostStatus() != api.HOST_OFFLINE {
+		t.Errorf("host status = %s, want %s", host.GetHostStatus(), api.HOST_OFFLINE)
+	}
+}
+
+func TestBucketObjects(t *testing.T) {
+	region := newTestRegion(t, "memory://"+t.Name())
+	err := region.CreateIBucket("bucket1", "STANDARD", "")
+	if err != nil {
+		t.Fatalf("CreateIBucket: %v", err)
+	}
+	ibucket, err := region.GetIBucketById("bucket1")
+	if err != nil {
+		t.Fatalf("GetIBucketById: %v", err)
+	}
+	for _, key := range []string{"a/1", "a/2", "b", "c/1"} {
+		err = ibucket.PutObject(context.Background(), key, bytes.NewReader([]byte(key)), int64(len(key)), "", "", nil)
+		if err != nil {
+			t.Fatalf("PutObject %s: %v", key, err)
+		}
+	}
+	result, err := ibucket.ListObjects("", "", "/", 10)
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(result.Objects) != 1 || len(result.CommonPrefixes) != 2 {
+		t.Errorf("ListObjects = %d objects %d prefixes", len(result.Objects), len(result.CommonPrefixes))
+	}
+	reader, err := ibucket.GetObject(context.Background(), "a/2", &cloudprovider.SGetObjectRange{Start: 2, End: 2})
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	data, _ := ioutil.ReadAll(reader)
+	if string(data) != "2" {
+		t.Errorf("GetObject range = %q", data)
+	}
+	err = region.DeleteIBucket("bucket1")
+	if errors.Cause(err) != cloudprovider.ErrInvalidStatus {
+		t.Errorf("DeleteIBucket not empty: %v", err)
+	}
+}
+
+func TestControlHandler(t *testing.T) {
+	region := newTestRegion(t, "memory://"+t.Name())
+	server := httptest.NewServer(NewControlHandler(region.GetClient().GetStore()))
+	defer server.Close()
+
+	request := func(method, path, body string) (int, jsonutils.JSONObject) {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		obj, _ := jsonutils.Parse(data)
+		return resp.StatusCode, obj
+	}
+
+	code, obj := request("POST", "/resources/instance", `{"name":"remote-vm","host_id":"host-1","status":"running"}`)
+	if code != http.StatusOK {
+		t.Fatalf("create instance: %d %s", code, obj)
+	}
+	id, _ := obj.GetString("id")
+	ivm, err := region.GetIVMById(id)
+	if err != nil {
+		t.Fatalf("GetIVMById(%s): %v", id, err)
+	}
+	if ivm.GetName() != "remote-vm" {
+		t.Errorf("name = %s", ivm.GetName())
+	}
+
+	code, _ = request("POST", "/resources/instance", fmt.Sprintf(`{"id":"%s","status":"stopped"}`, id))
+	if code != http.StatusOK {
+		t.Fatalf("update instance: %d", code)
+	}
+	ivm.Refresh()
+	if ivm.GetStatus() != api.VM_READY {
+		t.Errorf("status after remote stop = %s", ivm.GetStatus())
+	}
+
+	code, _ = request("DELETE", "/resources/instance/"+id, "")
+	if code != http.StatusOK {
+		t.Fatalf("delete instance: %d", code)
+	}
+	code, _ = request("GET", "/resources/instance/"+id, "")
+	if code != http.StatusNotFound {
+		t.Errorf("get deleted instance: %d", code)
+	}
+	code, _ = request("GET", "/resources/unknown", "")
+	if code != http.StatusNotImplemented {
+		t.Errorf("list unknown kind: %d", code)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SStorage struct {
+	multicloud.SResourceBase
+	SStorageRecord
+
+	zone *SZone
+}
+
+var storageTypes = map[string]string{
+	"local":  api.STORAGE_SIMULATOR_LOCAL,
+	"shared": api.STORAGE_SIMULATOR_SHARED,
+}
+
+// zoneId为空时返回区域内所有存储
+func (region *SRegion) GetStorages(zoneId string) ([]SStorage, error) {
+	zones, err := region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	storages := []SStorage{}
+	err = region.getStore().view("list:storage", func(state *SSimulatorState) error {
+		for i := range zones {
+			if len(zoneId) > 0 && zones[i].Id != zoneId {
+				continue
+			}
+			for j := range state.Storages {
+				if state.Storages[j].ZoneId == zones[i].Id {
+					storages = append(storages, SStorage{zone: &zones[i], SStorageRecord: state.Storages[j]})
+				}
+			}
+		}
+		return nil
+	})
+	return storages, err
+}
+
+func (region *SRegion) GetStorage(id string) (*SStorage, error) {
+	storages, err := region.GetStorages("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range storages {
+		if storages[i].Id == id {
+			return &storages[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s", id)
+}
+
+func (storage *SStorage) GetId() string {
+	return storage.Id
+}
+
+func (storage *SStorage) GetName() string {
+	return storage.Name
+}
+
+func (storage *SStorage) GetGlobalId() string {
+	return storage.Id
+}
+
+func (storage *SStorage) GetSysTags() map[string]string {
+	return storage.Tags
+}
+
+func (storage *SStorage) GetStatus() string {
+	if storage.Status == api.STORAGE_OFFLINE {
+		return api.STORAGE_OFFLINE
+	}
+	return api.STORAGE_ONLINE
+}
+
+func (storage *SStorage) Refresh() error {
+	_storage, err := storage.zone.region.GetStorage(storage.Id)
+	if err != nil {
+		return err
+	}
+	storage.SStorageRecord = _storage.SStorageRecord
+	return nil
+}
+
+func (storage *SStorage) GetIZone() cloudprovider.ICloudZone {
+	return storage.zone
+}
+
+func (storage *SStorage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return storage.zone.region.getStoragecache()
+}
+
+func (storage *SStorage) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := storage.zone.region.GetDisks(storage.Id, "")
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudDisk{}
+	for i := range disks {
+		ret = append(ret, &disks[i])
+	}
+	return ret, nil
+}
+
+func (storage *SStorage) GetIDiskById(diskId string) (cloudprovider.ICloudDisk, error) {
+	disk, err := storage.zone.region.GetDisk(diskId)
+	if err != nil {
+		return nil, err
+	}
+	if disk.StorageId != storage.Id {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s not in storage %s", diskId, storage.Name)
+	}
+	return disk, nil
+}
+
+func (storage *SStorage) CreateIDisk(conf *cloudprovider.DiskCreateConfig) (cloudprovider.ICloudDisk, error) {
+	return storage.zone.region.CreateDisk(storage.Id, conf.Name, conf.SizeGb*1024, conf.Desc, conf.ProjectId)
+}
+
+func (storage *SStorage) GetStorageType() string {
+	if storageType, ok := storageTypes[storage.StorageType]; ok {
+		return storageType
+	}
+	return storage.StorageType
+}
+
+func (storage *SStorage) GetMediumType() string {
+	if len(storage.MediumType) > 0 {
+		return storage.MediumType
+	}
+	return api.DISK_TYPE_ROTATE
+}
+
+func (storage *SStorage) GetCapacityMB() int64 {
+	return storage.CapacityMb
+}
+
+func (storage *SStorage) GetCapacityUsedMB() int64 {
+	used := int64(0)
+	storage.zone.region.getStore().view("get:storage", func(state *SSimulatorState) error {
+		for i := range state.Disks {
+			if state.Disks[i].StorageId == storage.Id {
+				used += int64(state.Disks[i].SizeMb)
+			}
+		}
+		return nil
+	})
+	return used
+}
+
+func (storage *SStorage) GetStorageConf() jsonutils.JSONObject {
+	return jsonutils.NewDict()
+}
+
+func (storage *SStorage) GetEnabled() bool {
+	return storage.Enabled
+}
+
+func (storage *SStorage) GetMountPoint() string {
+	return ""
+}
+
+func (storage *SStorage) IsSysDiskStore() bool {
+	return true
+}
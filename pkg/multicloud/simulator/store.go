@@ -0,0 +1,598 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+const (
+	STORE_SCHEME_MEMORY = "memory"
+	STORE_SCHEME_FILE   = "file"
+
+	DEFAULT_STORE_URL = "memory://default"
+)
+
+// 远端资源类型, 控制接口及故障注入均使用该名称
+const (
+	KIND_REGION       = "region"
+	KIND_ZONE         = "zone"
+	KIND_VPC          = "vpc"
+	KIND_NETWORK      = "network"
+	KIND_HOST         = "host"
+	KIND_STORAGE      = "storage"
+	KIND_IMAGE        = "image"
+	KIND_INSTANCE     = "instance"
+	KIND_DISK         = "disk"
+	KIND_EIP          = "eip"
+	KIND_LOADBALANCER = "loadbalancer"
+	KIND_BUCKET       = "bucket"
+	KIND_DBINSTANCE   = "dbinstance"
+)
+
+var (
+	storeLock sync.Mutex
+	stores    = map[string]*SStore{}
+
+	// 故障注入时可使用的错误名称, 其余名称作为普通错误信息返回
+	faultErrors = map[string]error{
+		"NotFound":       cloudprovider.ErrNotFound,
+		"DuplicateId":    cloudprovider.ErrDuplicateId,
+		"InvalidStatus":  cloudprovider.ErrInvalidStatus,
+		"Timeout":        cloudprovider.ErrTimeout,
+		"NotImplemented": cloudprovider.ErrNotImplemented,
+		"NotSupported":   cloudprovider.ErrNotSupported,
+	}
+)
+
+// SFault 故障注入规则
+// Op 为操作名称, 格式为 action:kind, 如 create:instance, 支持 * 通配, 如 *:disk
+// Count 为剩余触发次数, -1 表示一直触发
+// Rate 为触发概率, 0 表示每次都触发
+type SFault struct {
+	Op    string
+	Error string
+	Count int
+	Rate  float64
+}
+
+func (fault *SFault) match(op string) bool {
+	matched, _ := path.Match(fault.Op, op)
+	return matched
+}
+
+func (fault *SFault) error(op string) error {
+	if err, ok := faultErrors[fault.Error]; ok {
+		return errors.Wrapf(err, "simulated fault on %s", op)
+	}
+	msg := fault.Error
+	if len(msg) == 0 {
+		msg = "simulated fault"
+	}
+	return errors.Wrap(errors.Error(msg), op)
+}
+
+type SRecordBase struct {
+	Id     string
+	Name   string
+	Status string
+	Tags   map[string]string
+}
+
+type SRegionRecord struct {
+	SRecordBase
+}
+
+type SZoneRecord struct {
+	SRecordBase
+	RegionId string
+}
+
+type SVpcRecord struct {
+	SRecordBase
+	RegionId  string
+	CidrBlock string
+	IsDefault bool
+}
+
+type SNetworkRecord struct {
+	SRecordBase
+	VpcId   string
+	ZoneId  string
+	Cidr    string
+	Gateway string
+}
+
+type SHostRecord struct {
+	SRecordBase
+	ZoneId    string
+	AccessIp  string
+	AccessMac string
+	SN        string
+	CpuCount  int
+	CpuDesc   string
+	CpuMhz    int
+	MemSizeMb int
+	Enabled   bool
+}
+
+type SStorageRecord struct {
+	SRecordBase
+	ZoneId      string
+	StorageType string
+	MediumType  string
+	CapacityMb  int64
+	Enabled     bool
+}
+
+type SImageRecord struct {
+	SRecordBase
+	RegionId    string
+	OsType      string
+	OsDist      string
+	OsVersion   string
+	OsArch      string
+	Format      string
+	SizeBytes   int64
+	MinDiskGb   int
+	MinRamMb    int
+	CreatedAt   time.Time
+	InstanceId  string
+	Description string
+}
+
+type SNicRecord struct {
+	Id        string
+	NetworkId string
+	Ip        string
+	Mac       string
+}
+
+type SInstanceRecord struct {
+	SRecordBase
+	HostId       string
+	ImageId      string
+	OsType       string
+	OsName       string
+	InstanceType string
+	Cpu          int
+	MemoryMb     int
+	Nics         []SNicRecord
+	SecgroupIds  []string
+	ProjectId    string
+	UserData     string
+	Description  string
+	CreatedAt    time.Time
+}
+
+type SDiskRecord struct {
+	SRecordBase
+	StorageId   string
+	InstanceId  string
+	DiskType    string
+	TemplateId  string
+	SizeMb      int
+	Index       int
+	ProjectId   string
+	Description string
+	CreatedAt   time.Time
+}
+
+type SEipRecord struct {
+	SRecordBase
+	RegionId      string
+	NetworkId     string
+	Ip            string
+	Bandwidth     int
+	ChargeType    string
+	AssociateType string
+	AssociateId   string
+	ProjectId     string
+	CreatedAt     time.Time
+}
+
+type SLoadbalancerRecord struct {
+	SRecordBase
+	RegionId    string
+	ZoneId      string
+	VpcId       string
+	NetworkIds  []string
+	Address     string
+	AddressType string
+	Spec        string
+	ChargeType  string
+	EgressMbps  int
+	ProjectId   string
+	CreatedAt   time.Time
+}
+
+type SObjectRecord struct {
+	Key          string
+	Content      []byte
+	ETag         string
+	StorageClass string
+	Acl          string
+	Meta         map[string]string
+	LastModified time.Time
+}
+
+type SBucketRecord struct {
+	SRecordBase
+	RegionId     string
+	StorageClass string
+	Acl          string
+	Objects      []SObjectRecord
+	CreatedAt    time.Time
+}
+
+type SDBInstanceRecord struct {
+	SRecordBase
+	RegionId      string
+	ZoneId        string
+	VpcId         string
+	NetworkId     string
+	Address       string
+	Port          int
+	Engine        string
+	EngineVersion string
+	Category      string
+	InstanceType  string
+	StorageType   string
+	VcpuCount     int
+	VmemSizeMb    int
+	DiskSizeGb    int
+	ProjectId     string
+	CreatedAt     time.Time
+}
+
+// SSimulatorState 模拟云的全部远端状态, 可整体序列化为json文件
+type SSimulatorState struct {
+	Regions       []SRegionRecord
+	Zones         []SZoneRecord
+	Vpcs          []SVpcRecord
+	Networks      []SNetworkRecord
+	Hosts         []SHostRecord
+	Storages      []SStorageRecord
+	Images        []SImageRecord
+	Instances     []SInstanceRecord
+	Disks         []SDiskRecord
+	Eips          []SEipRecord
+	Loadbalancers []SLoadbalancerRecord
+	Buckets       []SBucketRecord
+	Dbinstances   []SDBInstanceRecord
+
+	// 每次调用的模拟延迟, 单位毫秒
+	LatencyMs int
+	Faults    []SFault
+}
+
+// 资源类型与状态中列表字段的对应关系
+var kindFields = map[string]string{
+	KIND_REGION:       "Regions",
+	KIND_ZONE:         "Zones",
+	KIND_VPC:          "Vpcs",
+	KIND_NETWORK:      "Networks",
+	KIND_HOST:         "Hosts",
+	KIND_STORAGE:      "Storages",
+	KIND_IMAGE:        "Images",
+	KIND_INSTANCE:     "Instances",
+	KIND_DISK:         "Disks",
+	KIND_EIP:          "Eips",
+	KIND_LOADBALANCER: "Loadbalancers",
+	KIND_BUCKET:       "Buckets",
+	KIND_DBINSTANCE:   "Dbinstances",
+}
+
+func (state *SSimulatorState) records(kind string) (reflect.Value, error) {
+	field, ok := kindFields[kind]
+	if !ok {
+		return reflect.Value{}, errors.Wrapf(cloudprovider.ErrNotSupported, "kind %s", kind)
+	}
+	return reflect.ValueOf(state).Elem().FieldByName(field), nil
+}
+
+func recordId(v reflect.Value) string {
+	return v.FieldByName("Id").String()
+}
+
+// NewDefaultState 默认拓扑: 一个区域, 一个可用区, 一个宿主机, 本地及共享存储各一个, 一个默认vpc及子网和一个镜像
+func NewDefaultState() *SSimulatorState {
+	now := time.Now().UTC()
+	region := SRecordBase{Id: "region-1", Name: "Simulator Region 1"}
+	zone := SRecordBase{Id: "zone-1", Name: "Simulator Zone 1"}
+	return &SSimulatorState{
+		Regions: []SRegionRecord{{SRecordBase: region}},
+		Zones:   []SZoneRecord{{SRecordBase: zone, RegionId: region.Id}},
+		Vpcs: []SVpcRecord{
+			{
+				SRecordBase: SRecordBase{Id: "vpc-1", Name: "default", Status: "available"},
+				RegionId:    region.Id,
+				CidrBlock:   "10.0.0.0/16",
+				IsDefault:   true,
+			},
+		},
+		Networks: []SNetworkRecord{
+			{
+				SRecordBase: SRecordBase{Id: "network-1", Name: "default", Status: "available"},
+				VpcId:       "vpc-1",
+				ZoneId:      zone.Id,
+				Cidr:        "10.0.0.0/24",
+				Gateway:     "10.0.0.1",
+			},
+		},
+		Hosts: []SHostRecord{
+			{
+				SRecordBase: SRecordBase{Id: "host-1", Name: "simulator-host-1", Status: "online"},
+				ZoneId:      zone.Id,
+				AccessIp:    "192.168.100.11",
+				AccessMac:   "00:22:00:00:00:01",
+				SN:          "SIM-0001",
+				CpuCount:    32,
+				CpuDesc:     "Simulated CPU",
+				CpuMhz:      2400,
+				MemSizeMb:   128 * 1024,
+				Enabled:     true,
+			},
+		},
+		Storages: []SStorageRecord{
+			{
+				SRecordBase: SRecordBase{Id: "storage-local-1", Name: "local-1", Status: "online"},
+				ZoneId:      zone.Id,
+				StorageType: "local",
+				MediumType:  "ssd",
+				CapacityMb:  1024 * 1024,
+				Enabled:     true,
+			},
+			{
+				SRecordBase: SRecordBase{Id: "storage-shared-1", Name: "shared-1", Status: "online"},
+				ZoneId:      zone.Id,
+				StorageType: "shared",
+				MediumType:  "rotate",
+				CapacityMb:  10 * 1024 * 1024,
+				Enabled:     true,
+			},
+		},
+		Images: []SImageRecord{
+			{
+				SRecordBase: SRecordBase{Id: "image-1", Name: "centos-7", Status: "active"},
+				RegionId:    region.Id,
+				OsType:      "Linux",
+				OsDist:      "CentOS",
+				OsVersion:   "7",
+				OsArch:      "x86_64",
+				Format:      "qcow2",
+				SizeBytes:   1 << 30,
+				MinDiskGb:   10,
+				CreatedAt:   now,
+			},
+		},
+	}
+}
+
+// SStore 模拟云状态存储, 可为进程内存储或json文件
+// 使用文件存储时, 每次访问前检查文件是否被外部修改, 每次变更后写回文件
+type SStore struct {
+	lock sync.Mutex
+
+	url      string
+	filePath string
+
+	state *SSimulatorState
+
+	modTime time.Time
+	size    int64
+}
+
+// GetStore 按url获取存储, 相同url在同一进程内共享同一份状态
+// url 格式为 memory://<name> 或 file:///path/to/state.json
+func GetStore(storeUrl string) (*SStore, error) {
+	if len(storeUrl) == 0 {
+		storeUrl = DEFAULT_STORE_URL
+	}
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	if store, ok := stores[storeUrl]; ok {
+		return store, nil
+	}
+	store, err := newStore(storeUrl)
+	if err != nil {
+		return nil, err
+	}
+	stores[storeUrl] = store
+	return store, nil
+}
+
+func newStore(storeUrl string) (*SStore, error) {
+	u, err := url.Parse(storeUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s", storeUrl)
+	}
+	store := &SStore{url: storeUrl}
+	switch u.Scheme {
+	case STORE_SCHEME_MEMORY:
+		store.state = NewDefaultState()
+	case STORE_SCHEME_FILE:
+		store.filePath = u.Path
+		if len(store.filePath) == 0 {
+			return nil, fmt.Errorf("empty file path in %s", storeUrl)
+		}
+		_, err := os.Stat(store.filePath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "stat %s", store.filePath)
+			}
+			store.state = NewDefaultState()
+			err = store.save()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			err = store.reload()
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported simulator store %s, use memory://<name> or file:///<path>", storeUrl)
+	}
+	return store, nil
+}
+
+func (store *SStore) GetUrl() string {
+	return store.url
+}
+
+func (store *SStore) reload() error {
+	if len(store.filePath) == 0 {
+		return nil
+	}
+	info, err := os.Stat(store.filePath)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s", store.filePath)
+	}
+	if store.state != nil && info.ModTime().Equal(store.modTime) && info.Size() == store.size {
+		return nil
+	}
+	data, err := ioutil.ReadFile(store.filePath)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", store.filePath)
+	}
+	obj, err := jsonutils.Parse(data)
+	if err != nil {
+		return errors.Wrapf(err, "parse %s", store.filePath)
+	}
+	state := &SSimulatorState{}
+	err = obj.Unmarshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshal %s", store.filePath)
+	}
+	store.state = state
+	store.modTime = info.ModTime()
+	store.size = info.Size()
+	return nil
+}
+
+func (store *SStore) save() error {
+	if len(store.filePath) == 0 {
+		return nil
+	}
+	data := jsonutils.Marshal(store.state).PrettyString()
+	err := ioutil.WriteFile(store.filePath, []byte(data), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "write %s", store.filePath)
+	}
+	info, err := os.Stat(store.filePath)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s", store.filePath)
+	}
+	store.modTime = info.ModTime()
+	store.size = info.Size()
+	return nil
+}
+
+// call 模拟一次远端调用: 按配置延迟, 并检查是否命中故障注入规则
+func (store *SStore) call(op string) error {
+	store.lock.Lock()
+	err := store.reload()
+	if err != nil {
+		store.lock.Unlock()
+		return err
+	}
+	latency := time.Duration(store.state.LatencyMs) * time.Millisecond
+	var fault *SFault
+	for i := range store.state.Faults {
+		f := &store.state.Faults[i]
+		if f.Count == 0 || !f.match(op) {
+			continue
+		}
+		if f.Rate > 0 && rand.Float64() >= f.Rate {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+		}
+		fault = &SFault{Op: f.Op, Error: f.Error}
+		err = store.save()
+		break
+	}
+	store.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	if fault != nil {
+		return fault.error(op)
+	}
+	return nil
+}
+
+// view 只读访问状态
+func (store *SStore) view(op string, f func(state *SSimulatorState) error) error {
+	err := store.call(op)
+	if err != nil {
+		return err
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	err = store.reload()
+	if err != nil {
+		return err
+	}
+	return f(store.state)
+}
+
+// update 修改状态, 成功后持久化
+func (store *SStore) update(op string, f func(state *SSimulatorState) error) error {
+	err := store.call(op)
+	if err != nil {
+		return err
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	err = store.reload()
+	if err != nil {
+		return err
+	}
+	err = f(store.state)
+	if err != nil {
+		return err
+	}
+	return store.save()
+}
+
+func newId(prefix string) string {
+	return fmt.Sprintf("%s-%s", prefix, strings.Split(stringutils.UUID4(), "-")[0])
+}
+
+func newMac() string {
+	return fmt.Sprintf("00:22:%02x:%02x:%02x:%02x", rand.Intn(256), rand.Intn(256), rand.Intn(256), rand.Intn(256))
+}
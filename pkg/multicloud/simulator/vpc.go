@@ -0,0 +1,313 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SVpc struct {
+	multicloud.SVpc
+	SVpcRecord
+
+	region *SRegion
+}
+
+func (region *SRegion) GetVpcs() ([]SVpc, error) {
+	vpcs := []SVpc{}
+	err := region.getStore().view("list:vpc", func(state *SSimulatorState) error {
+		for i := range state.Vpcs {
+			if state.Vpcs[i].RegionId == region.Id {
+				vpcs = append(vpcs, SVpc{region: region, SVpcRecord: state.Vpcs[i]})
+			}
+		}
+		return nil
+	})
+	return vpcs, err
+}
+
+func (region *SRegion) GetVpc(id string) (*SVpc, error) {
+	vpc := &SVpc{region: region}
+	err := region.getStore().view("get:vpc", func(state *SSimulatorState) error {
+		for i := range state.Vpcs {
+			if state.Vpcs[i].Id == id && state.Vpcs[i].RegionId == region.Id {
+				vpc.SVpcRecord = state.Vpcs[i]
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "vpc %s", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vpc, nil
+}
+
+func (region *SRegion) CreateVpc(name, cidr string) (*SVpc, error) {
+	_, err := netutils.NewIPV4Prefix(cidr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cidr %s", cidr)
+	}
+	vpc := &SVpc{region: region}
+	vpc.SVpcRecord = SVpcRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_VPC), Name: name, Status: api.VPC_STATUS_AVAILABLE},
+		RegionId:    region.Id,
+		CidrBlock:   cidr,
+	}
+	err = region.getStore().update("create:vpc", func(state *SSimulatorState) error {
+		state.Vpcs = append(state.Vpcs, vpc.SVpcRecord)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vpc, nil
+}
+
+func (vpc *SVpc) GetId() string {
+	return vpc.Id
+}
+
+func (vpc *SVpc) GetName() string {
+	return vpc.Name
+}
+
+func (vpc *SVpc) GetGlobalId() string {
+	return vpc.Id
+}
+
+func (vpc *SVpc) GetIsDefault() bool {
+	return vpc.IsDefault
+}
+
+func (vpc *SVpc) GetCidrBlock() string {
+	return vpc.CidrBlock
+}
+
+func (vpc *SVpc) GetStatus() string {
+	if len(vpc.Status) > 0 {
+		return vpc.Status
+	}
+	return api.VPC_STATUS_AVAILABLE
+}
+
+func (vpc *SVpc) GetSysTags() map[string]string {
+	return vpc.Tags
+}
+
+func (vpc *SVpc) Refresh() error {
+	_vpc, err := vpc.region.GetVpc(vpc.Id)
+	if err != nil {
+		return err
+	}
+	vpc.SVpcRecord = _vpc.SVpcRecord
+	return nil
+}
+
+func (vpc *SVpc) GetRegion() cloudprovider.ICloudRegion {
+	return vpc.region
+}
+
+// 每个可用区模拟一个二层网络
+func (vpc *SVpc) GetWires() ([]SWire, error) {
+	zones, err := vpc.region.GetZones()
+	if err != nil {
+		return nil, err
+	}
+	wires := []SWire{}
+	for i := range zones {
+		wires = append(wires, SWire{vpc: vpc, zone: &zones[i]})
+	}
+	return wires, nil
+}
+
+func (vpc *SVpc) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	wires, err := vpc.GetWires()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudWire{}
+	for i := range wires {
+		ret = append(ret, &wires[i])
+	}
+	return ret, nil
+}
+
+func (vpc *SVpc) GetIWireById(wireId string) (cloudprovider.ICloudWire, error) {
+	wires, err := vpc.GetWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		if wires[i].GetGlobalId() == wireId {
+			return &wires[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "wire %s", wireId)
+}
+
+func (vpc *SVpc) GetISecurityGroups() ([]cloudprovider.ICloudSecurityGroup, error) {
+	return []cloudprovider.ICloudSecurityGroup{}, nil
+}
+
+func (vpc *SVpc) GetIRouteTables() ([]cloudprovider.ICloudRouteTable, error) {
+	return []cloudprovider.ICloudRouteTable{}, nil
+}
+
+func (vpc *SVpc) GetIRouteTableById(routeTableId string) (cloudprovider.ICloudRouteTable, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (vpc *SVpc) Delete() error {
+	return vpc.region.getStore().update("delete:vpc", func(state *SSimulatorState) error {
+		for i := range state.Networks {
+			if state.Networks[i].VpcId == vpc.Id {
+				return errors.Wrapf(cloudprovider.ErrInvalidStatus, "vpc %s has network %s", vpc.Id, state.Networks[i].Id)
+			}
+		}
+		for i := range state.Vpcs {
+			if state.Vpcs[i].Id == vpc.Id {
+				state.Vpcs = append(state.Vpcs[:i], state.Vpcs[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Wrapf(cloudprovider.ErrNotFound, "vpc %s", vpc.Id)
+	})
+}
+
+type SWire struct {
+	multicloud.SResourceBase
+
+	vpc  *SVpc
+	zone *SZone
+}
+
+func (wire *SWire) GetId() string {
+	return fmt.Sprintf("%s/%s", wire.vpc.Id, wire.zone.Id)
+}
+
+func (wire *SWire) GetName() string {
+	return fmt.Sprintf("%s-%s", wire.vpc.Name, wire.zone.Name)
+}
+
+func (wire *SWire) GetGlobalId() string {
+	return wire.GetId()
+}
+
+func (wire *SWire) GetStatus() string {
+	return "available"
+}
+
+func (wire *SWire) IsEmulated() bool {
+	return true
+}
+
+func (wire *SWire) GetIVpc() cloudprovider.ICloudVpc {
+	return wire.vpc
+}
+
+func (wire *SWire) GetIZone() cloudprovider.ICloudZone {
+	return wire.zone
+}
+
+func (wire *SWire) GetBandwidth() int {
+	return 10000
+}
+
+func (wire *SWire) GetNetworks() ([]SNetwork, error) {
+	networks := []SNetwork{}
+	err := wire.vpc.region.getStore().view("list:network", func(state *SSimulatorState) error {
+		for i := range state.Networks {
+			if state.Networks[i].VpcId == wire.vpc.Id && state.Networks[i].ZoneId == wire.zone.Id {
+				networks = append(networks, SNetwork{wire: wire, SNetworkRecord: state.Networks[i]})
+			}
+		}
+		return nil
+	})
+	return networks, err
+}
+
+func (wire *SWire) GetINetworks() ([]cloudprovider.ICloudNetwork, error) {
+	networks, err := wire.GetNetworks()
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudNetwork{}
+	for i := range networks {
+		ret = append(ret, &networks[i])
+	}
+	return ret, nil
+}
+
+func (wire *SWire) GetINetworkById(netid string) (cloudprovider.ICloudNetwork, error) {
+	networks, err := wire.GetNetworks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range networks {
+		if networks[i].GetGlobalId() == netid {
+			return &networks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", netid)
+}
+
+func (wire *SWire) CreateINetwork(opts *cloudprovider.SNetworkCreateOptions) (cloudprovider.ICloudNetwork, error) {
+	prefix, err := netutils.NewIPV4Prefix(opts.Cidr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cidr %s", opts.Cidr)
+	}
+	network := &SNetwork{wire: wire}
+	network.SNetworkRecord = SNetworkRecord{
+		SRecordBase: SRecordBase{Id: newId(KIND_NETWORK), Name: opts.Name, Status: api.NETWORK_STATUS_AVAILABLE},
+		VpcId:       wire.vpc.Id,
+		ZoneId:      wire.zone.Id,
+		Cidr:        opts.Cidr,
+		Gateway:     prefix.ToIPRange().StartIp().StepUp().String(),
+	}
+	err = wire.vpc.region.getStore().update("create:network", func(state *SSimulatorState) error {
+		state.Networks = append(state.Networks, network.SNetworkRecord)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// wireId 格式为 vpcId/zoneId
+func (region *SRegion) GetWire(wireId string) (*SWire, error) {
+	parts := strings.Split(wireId, "/")
+	if len(parts) != 2 {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "wire %s", wireId)
+	}
+	vpc, err := region.GetVpc(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	zone, err := region.GetZone(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return &SWire{vpc: vpc, zone: zone}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SZone struct {
+	multicloud.SResourceBase
+	SZoneRecord
+
+	region *SRegion
+}
+
+func (zone *SZone) GetId() string {
+	return zone.Id
+}
+
+func (zone *SZone) GetName() string {
+	return zone.Name
+}
+
+func (zone *SZone) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(zone.GetName()).CN(zone.GetName())
+	return table
+}
+
+func (zone *SZone) GetGlobalId() string {
+	return fmt.Sprintf("%s/%s", zone.region.GetGlobalId(), zone.Id)
+}
+
+func (zone *SZone) GetStatus() string {
+	if len(zone.Status) > 0 {
+		return zone.Status
+	}
+	return api.ZONE_ENABLE
+}
+
+func (zone *SZone) GetSysTags() map[string]string {
+	return zone.Tags
+}
+
+func (zone *SZone) GetIRegion() cloudprovider.ICloudRegion {
+	return zone.region
+}
+
+func (zone *SZone) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	hosts, err := zone.region.GetHosts(zone.Id)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudHost{}
+	for i := range hosts {
+		ret = append(ret, &hosts[i])
+	}
+	return ret, nil
+}
+
+func (zone *SZone) GetIHostById(id string) (cloudprovider.ICloudHost, error) {
+	host, err := zone.region.GetHost(id)
+	if err != nil {
+		return nil, err
+	}
+	if host.ZoneId != zone.Id {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return host, nil
+}
+
+func (zone *SZone) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	storages, err := zone.region.GetStorages(zone.Id)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudStorage{}
+	for i := range storages {
+		ret = append(ret, &storages[i])
+	}
+	return ret, nil
+}
+
+func (zone *SZone) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	storage, err := zone.region.GetStorage(id)
+	if err != nil {
+		return nil, err
+	}
+	if storage.ZoneId != zone.Id {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return storage, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type SReplayConfig struct {
+	// 接口路径前缀, 去掉后再拼接录制文件名
+	ApiPrefix string
+	// 录制文件缺失时返回的响应体, %s 为请求路径
+	NotFoundFormat string
+	// 校验请求的认证信息, 不通过时返回401
+	Auth func(r *http.Request) bool
+}
+
+// fixtureName 按 METHOD_路径_查询参数 生成录制文件名
+func fixtureName(r *http.Request, path string) string {
+	name := r.Method + strings.Replace(path, "/", "_", -1)
+	if len(r.URL.RawQuery) > 0 {
+		name += "_" + strings.Replace(r.URL.RawQuery, "=", "_", -1)
+	}
+	return strings.Replace(name, ":", "_", -1)
+}
+
+// NewReplayServer 回放测试包 testdata 下录制的接口响应
+func NewReplayServer(t *testing.T, conf SReplayConfig) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conf.Auth != nil && !conf.Auth(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, conf.ApiPrefix)
+		name := fixtureName(r, path)
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+		if err != nil {
+			t.Logf("missing fixture %s", name)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, conf.NotFoundFormat, path)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+}
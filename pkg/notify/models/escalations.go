@@ -0,0 +1,297 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/util/sets"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	DEFAULT_ESCALATION_ACK_TIMEOUT_MINUTES = 15
+)
+
+// 升级链, 重要通知在超时未确认时依次通知下一接收人
+type SEscalationManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var EscalationManager *SEscalationManager
+
+func init() {
+	EscalationManager = &SEscalationManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SEscalation{},
+			"escalations_tbl",
+			"escalation",
+			"escalations",
+		),
+	}
+	EscalationManager.SetVirtualObject(EscalationManager)
+}
+
+type SEscalation struct {
+	db.SStandaloneResourceBase
+	db.SEnabledResourceBase
+
+	// 按顺序排列的接收人Id, 逗号分隔
+	Receivers string `nullable:"false" create:"required"`
+	// 通知升级接收人使用的渠道, 为空时沿用原通知渠道
+	ContactType string `width:"16" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+	// 等待确认的分钟数
+	AckTimeoutMinutes int `nullable:"false" default:"15" create:"optional" list:"user" get:"user"`
+	// 低于此级别的通知不升级
+	MinPriority string `width:"16" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+}
+
+func (em *SEscalationManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return true
+}
+
+func (e *SEscalation) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return true
+}
+
+func validateEscalationReceivers(ctx context.Context, idOrNames []string) ([]string, error) {
+	receivers, err := ReceiverManager.FetchByIdOrNames(ctx, idOrNames...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReceiverManager.FetchByIdOrNames")
+	}
+	ids := make([]string, 0, len(idOrNames))
+	seen := sets.NewString()
+	for _, idOrName := range idOrNames {
+		id := ""
+		for i := range receivers {
+			if receivers[i].Id == idOrName || receivers[i].Name == idOrName {
+				id = receivers[i].Id
+				break
+			}
+		}
+		if len(id) == 0 {
+			return nil, httperrors.NewInputParameterError("no such receiver %q", idOrName)
+		}
+		if seen.Has(id) {
+			continue
+		}
+		seen.Insert(id)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func validateEscalationContactType(contactType string) error {
+	if len(contactType) == 0 {
+		return nil
+	}
+	allContactType, err := ConfigManager.allContactType()
+	if err != nil {
+		return errors.Wrap(err, "allContactType")
+	}
+	if !utils.IsInStringArray(contactType, allContactType) {
+		return httperrors.NewInputParameterError("Unconfigured contact type %q", contactType)
+	}
+	return nil
+}
+
+func (em *SEscalationManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.EscalationCreateInput) (api.EscalationCreateInput, error) {
+	var err error
+	input.StandaloneResourceCreateInput, err = em.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Receivers) == 0 {
+		return input, httperrors.NewMissingParameterError("receivers")
+	}
+	input.Receivers, err = validateEscalationReceivers(ctx, input.Receivers)
+	if err != nil {
+		return input, err
+	}
+	err = validateEscalationContactType(input.ContactType)
+	if err != nil {
+		return input, err
+	}
+	if input.AckTimeoutMinutes == 0 {
+		input.AckTimeoutMinutes = DEFAULT_ESCALATION_ACK_TIMEOUT_MINUTES
+	}
+	if input.AckTimeoutMinutes < 0 {
+		return input, httperrors.NewInputParameterError("invalid ack_timeout_minutes %d", input.AckTimeoutMinutes)
+	}
+	if len(input.MinPriority) == 0 {
+		input.MinPriority = api.NOTIFICATION_PRIORITY_IMPORTANT
+	}
+	if !utils.IsInStringArray(input.MinPriority, api.NOTIFICATION_PRIORITIES) {
+		return input, httperrors.NewInputParameterError("invalid min_priority %q", input.MinPriority)
+	}
+	return input, nil
+}
+
+func (e *SEscalation) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	var input api.EscalationCreateInput
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return err
+	}
+	e.Receivers = strings.Join(input.Receivers, ",")
+	if input.Enabled == nil {
+		e.Enabled = tristate.True
+	}
+	return e.SStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (e *SEscalation) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.EscalationUpdateInput) (api.EscalationUpdateInput, error) {
+	var err error
+	input.StandaloneResourceBaseUpdateInput, err = e.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Receivers) > 0 {
+		input.Receivers, err = validateEscalationReceivers(ctx, input.Receivers)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.ContactType != nil {
+		err = validateEscalationContactType(*input.ContactType)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.AckTimeoutMinutes < 0 {
+		return input, httperrors.NewInputParameterError("invalid ack_timeout_minutes %d", input.AckTimeoutMinutes)
+	}
+	if len(input.MinPriority) > 0 && !utils.IsInStringArray(input.MinPriority, api.NOTIFICATION_PRIORITIES) {
+		return input, httperrors.NewInputParameterError("invalid min_priority %q", input.MinPriority)
+	}
+	return input, nil
+}
+
+func (e *SEscalation) PreUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	e.SStandaloneResourceBase.PreUpdate(ctx, userCred, query, data)
+	var input api.EscalationUpdateInput
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return
+	}
+	if len(input.Receivers) > 0 {
+		e.Receivers = strings.Join(input.Receivers, ",")
+	}
+	if input.ContactType != nil {
+		e.ContactType = *input.ContactType
+	}
+	if input.AckTimeoutMinutes > 0 {
+		e.AckTimeoutMinutes = input.AckTimeoutMinutes
+	}
+	if len(input.MinPriority) > 0 {
+		e.MinPriority = input.MinPriority
+	}
+}
+
+func (em *SEscalationManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.EscalationListInput) (*sqlchemy.SQuery, error) {
+	q, err := em.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	q, err = em.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledResourceBaseListInput)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (em *SEscalationManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.EscalationDetails {
+	rows := make([]api.EscalationDetails, len(objs))
+	stdRows := em.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].StandaloneResourceDetails = stdRows[i]
+		rows[i].Receivers = objs[i].(*SEscalation).getReceivers()
+	}
+	return rows
+}
+
+func (e *SEscalation) ValidateDeleteCondition(ctx context.Context) error {
+	if SubscriptionManager.Query().Equals("escalation_id", e.Id).Count() > 0 {
+		return httperrors.NewNotEmptyError("escalation %s is used by subscriptions", e.Name)
+	}
+	return e.SStandaloneResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (e *SEscalation) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return db.IsAdminAllowPerform(userCred, e, "enable")
+}
+
+func (e *SEscalation) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(e, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (e *SEscalation) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return db.IsAdminAllowPerform(userCred, e, "disable")
+}
+
+func (e *SEscalation) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(e, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (e *SEscalation) getReceivers() []string {
+	if len(e.Receivers) == 0 {
+		return []string{}
+	}
+	return strings.Split(e.Receivers, ",")
+}
+
+func (e *SEscalation) getAckTimeout() time.Duration {
+	minutes := e.AckTimeoutMinutes
+	if minutes <= 0 {
+		minutes = DEFAULT_ESCALATION_ACK_TIMEOUT_MINUTES
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// 已禁用或已删除的升级链返回 nil
+func (em *SEscalationManager) fetchEnabled(id string) (*SEscalation, error) {
+	q := em.Query().Equals("id", id).IsTrue("enabled")
+	escalations := make([]SEscalation, 0, 1)
+	err := db.FetchModelObjects(em, q, &escalations)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	if len(escalations) == 0 {
+		return nil, nil
+	}
+	return &escalations[0], nil
+}
@@ -32,6 +32,9 @@ func InitDB() error {
 		ConfigManager,
 		TemplateManager,
 		ReceiverNotificationManager,
+		TopicManager,
+		SubscriptionManager,
+		EscalationManager,
 	} {
 		err := manager.InitializeData()
 		if err != nil {
@@ -16,6 +16,7 @@ package models
 
 import (
 	"context"
+	"crypto/md5"
 	"database/sql"
 	"fmt"
 	"time"
@@ -29,6 +30,7 @@ import (
 
 	api "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/image/policy"
@@ -71,6 +73,21 @@ type SNotification struct {
 	ReceivedAt time.Time `nullable:"true" list:"user" get:"user"`
 	SendTimes  int
 	Tag        string `width:"16" nullable:"true" index:"true" create:"optional"`
+
+	// 确认时间及确认人
+	AckedAt time.Time `nullable:"true" list:"user" get:"user"`
+	AckedBy string    `width:"128" charset:"ascii" nullable:"true" list:"user" get:"user"`
+
+	// 升级链及当前升级级别, 超过 EscalateAt 仍未确认则通知下一接收人
+	EscalationId    string    `width:"128" charset:"ascii" nullable:"true" index:"true" list:"user" get:"user"`
+	EscalationLevel int       `nullable:"false" default:"0" list:"user" get:"user"`
+	EscalateAt      time.Time `nullable:"true" list:"user" get:"user"`
+
+	// 去重键, 去重窗口内同一接收人只发送一次
+	DedupKey string `width:"128" charset:"ascii" nullable:"true" index:"true" create:"optional" list:"user" get:"user"`
+
+	// 免打扰时段内的通知延迟到 SendAfter 之后发送
+	SendAfter time.Time `nullable:"true" list:"user" get:"user"`
 }
 
 const (
@@ -126,6 +143,12 @@ func (nm *SNotificationManager) ValidateCreateData(ctx context.Context, userCred
 			return input, httperrors.NewInputParameterError("no valid receiver or contact")
 		}
 	}
+	if len(input.DedupKey) == 0 {
+		input.DedupKey = fmt.Sprintf("%x", md5.Sum([]byte(input.Topic+"\n"+input.Message)))
+	}
+	if len(input.DedupKey) > 128 {
+		return input, httperrors.NewInputParameterError("dedup_key too long")
+	}
 	nowStr := time.Now().Format("2006-01-02 15:04:05")
 	if len(input.Priority) == 0 {
 		input.Priority = api.NOTIFICATION_PRIORITY_NORMAL
@@ -176,6 +199,15 @@ func (n *SNotification) PostCreate(ctx context.Context, userCred mcclient.TokenC
 			n.SetAllMetadata(ctx, metadata, userCred)
 		}
 	}
+	if n.SendAfter.After(time.Now()) {
+		// 由 ReleaseDeferred 在免打扰时段结束后发送
+		n.SetStatus(userCred, api.NOTIFICATION_STATUS_DEFERRED, "")
+		return
+	}
+	n.startSendTask(ctx, userCred)
+}
+
+func (n *SNotification) startSendTask(ctx context.Context, userCred mcclient.TokenCredential) {
 	n.SetStatus(userCred, api.NOTIFICATION_STATUS_RECEIVED, "")
 	task, err := taskman.TaskManager.NewTask(ctx, "NotificationSendTask", n, userCred, nil, "", "")
 	if err != nil {
@@ -185,6 +217,25 @@ func (n *SNotification) PostCreate(ctx context.Context, userCred mcclient.TokenC
 	}
 }
 
+// 发送免打扰时段已结束的延迟通知
+func (nm *SNotificationManager) ReleaseDeferred(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := nm.Query().Equals("status", api.NOTIFICATION_STATUS_DEFERRED).LE("send_after", time.Now())
+	ns := make([]SNotification, 0)
+	err := db.FetchModelObjects(nm, q, &ns)
+	if err != nil {
+		log.Errorf("fail to FetchModelObjects: %v", err)
+		return
+	}
+	for i := range ns {
+		func() {
+			lockman.LockObject(ctx, &ns[i])
+			defer lockman.ReleaseObject(ctx, &ns[i])
+
+			ns[i].startSendTask(ctx, userCred)
+		}()
+	}
+}
+
 func (nm *SNotificationManager) FetchCustomizeColumns(
 	ctx context.Context,
 	userCred mcclient.TokenCredential,
@@ -219,7 +270,12 @@ func (n *SNotification) GetExtraDetails(
 }
 
 func (n *SNotification) ReceiverNotificationsNotOK() ([]SReceiverNotification, error) {
-	rnq := ReceiverNotificationManager.Query().Equals("notification_id", n.Id).NotEquals("status", api.RECEIVER_NOTIFICATION_OK)
+	rnq := ReceiverNotificationManager.Query().Equals("notification_id", n.Id).NotIn("status", []string{
+		api.RECEIVER_NOTIFICATION_OK,
+		api.RECEIVER_NOTIFICATION_SUPPRESSED,
+		api.RECEIVER_NOTIFICATION_DIGEST_PENDING,
+		api.RECEIVER_NOTIFICATION_DIGESTED,
+	})
 	rns := make([]SReceiverNotification, 0, 1)
 	err := db.FetchModelObjects(ReceiverNotificationManager, rnq, &rns)
 	if err == sql.ErrNoRows {
@@ -521,3 +577,150 @@ func (nm *SNotificationManager) ReSend(ctx context.Context, userCred mcclient.To
 		}
 	}
 }
+
+func (n *SNotification) isReceiver(userId string) bool {
+	q := ReceiverNotificationManager.Query().Equals("notification_id", n.Id)
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.Equals(q.Field("receiver_id"), userId),
+		sqlchemy.Equals(q.Field("contact"), userId),
+	))
+	return q.Count() > 0
+}
+
+func (n *SNotification) AllowPerformAck(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.NotificationAckInput) bool {
+	return n.isReceiver(userCred.GetUserId()) || db.IsAdminAllowPerform(userCred, n, "ack")
+}
+
+// 确认通知, 停止升级; 确认升级通知同时确认其来源通知
+func (n *SNotification) PerformAck(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.NotificationAckInput) (jsonutils.JSONObject, error) {
+	err := n.ack(userCred)
+	if err != nil {
+		return nil, err
+	}
+	originId := n.GetMetadata(api.NOTIFICATION_METADATA_ESCALATED_FROM, nil)
+	if len(originId) > 0 {
+		origin, err := NotificationManager.FetchById(originId)
+		if err != nil {
+			if errors.Cause(err) != sql.ErrNoRows {
+				return nil, errors.Wrapf(err, "fetch notification %s", originId)
+			}
+		} else {
+			err = origin.(*SNotification).ack(userCred)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (n *SNotification) ack(userCred mcclient.TokenCredential) error {
+	if !n.AckedAt.IsZero() {
+		return nil
+	}
+	_, err := db.Update(n, func() error {
+		n.AckedAt = time.Now()
+		n.AckedBy = userCred.GetUserId()
+		n.EscalateAt = time.Time{}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(n, "ack", n.AckedBy, userCred)
+	return nil
+}
+
+// 升级超时未确认的通知
+func (nm *SNotificationManager) Escalate(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	now := time.Now()
+	q := nm.Query().IsNotEmpty("escalation_id").IsNull("acked_at").IsNotNull("escalate_at").LE("escalate_at", now)
+	ns := make([]SNotification, 0)
+	err := db.FetchModelObjects(nm, q, &ns)
+	if err != nil {
+		log.Errorf("fail to FetchModelObjects: %v", err)
+		return
+	}
+	for i := range ns {
+		if !ns[i].needEscalate(now) {
+			continue
+		}
+		err := ns[i].escalate(ctx, userCred)
+		if err != nil {
+			log.Errorf("escalate notification %s: %v", ns[i].Id, err)
+		}
+	}
+}
+
+// 已确认的通知不再升级
+func (n *SNotification) needEscalate(now time.Time) bool {
+	if len(n.EscalationId) == 0 || !n.AckedAt.IsZero() || n.EscalateAt.IsZero() {
+		return false
+	}
+	return !n.EscalateAt.After(now)
+}
+
+// 返回当前级别应通知的接收人及下一次升级时间, 最后一级之后不再升级
+func escalationStep(level int, receivers []string, now time.Time, ackTimeout time.Duration) (string, time.Time, bool) {
+	if level < 0 || level >= len(receivers) {
+		return "", time.Time{}, false
+	}
+	next := time.Time{}
+	if level+1 < len(receivers) {
+		next = now.Add(ackTimeout)
+	}
+	return receivers[level], next, true
+}
+
+func (n *SNotification) escalate(ctx context.Context, userCred mcclient.TokenCredential) error {
+	if !n.AckedAt.IsZero() {
+		return nil
+	}
+	escalation, err := EscalationManager.fetchEnabled(n.EscalationId)
+	if err != nil {
+		return errors.Wrap(err, "fetch escalation")
+	}
+	var receivers []string
+	ackTimeout := time.Duration(0)
+	if escalation != nil {
+		receivers = escalation.getReceivers()
+		ackTimeout = escalation.getAckTimeout()
+	}
+	receiver, next, ok := escalationStep(n.EscalationLevel, receivers, time.Now(), ackTimeout)
+	if !ok {
+		// 升级链已走完
+		_, err := db.Update(n, func() error {
+			n.EscalateAt = time.Time{}
+			return nil
+		})
+		return err
+	}
+	contactType := escalation.ContactType
+	if len(contactType) == 0 {
+		contactType = n.ContactType
+	}
+	input := api.NotificationCreateInput{
+		Receivers:   []string{receiver},
+		ContactType: contactType,
+		Topic:       n.Topic,
+		Priority:    n.Priority,
+		Message:     n.Message,
+		Tag:         n.Tag,
+		Metadata: map[string]interface{}{
+			api.NOTIFICATION_METADATA_ESCALATED_FROM: n.Id,
+		},
+		IgnoreNonexistentReceiver: true,
+		// 升级通知不应被原通知去重
+		DedupKey: fmt.Sprintf("escalation-%s-%d", n.Id, n.EscalationLevel),
+	}
+	_, err = NotificationManager.create(ctx, userCred, input, "", time.Time{})
+	if err != nil {
+		log.Errorf("notify escalation receiver %s of notification %s: %v", receiver, n.Id, err)
+	}
+	_, err = db.Update(n, func() error {
+		n.EscalationLevel += 1
+		n.EscalateAt = next
+		return nil
+	})
+	return err
+}
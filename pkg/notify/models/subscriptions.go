@@ -0,0 +1,509 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// 用户或用户组对主题的订阅
+type SSubscriptionManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SDomainizedResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var SubscriptionManager *SSubscriptionManager
+
+func init() {
+	SubscriptionManager = &SSubscriptionManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SSubscription{},
+			"subscriptions_tbl",
+			"subscription",
+			"subscriptions",
+		),
+	}
+	SubscriptionManager.SetVirtualObject(SubscriptionManager)
+}
+
+type SSubscription struct {
+	db.SStandaloneResourceBase
+	// 订阅者所属域
+	db.SDomainizedResourceBase
+	db.SEnabledResourceBase
+
+	TopicId string `width:"128" charset:"ascii" nullable:"false" index:"true" create:"required" list:"user" get:"user"`
+
+	// 订阅者类型, user 或 group
+	SubscriberType string `width:"16" charset:"ascii" nullable:"false" create:"required" list:"user" get:"user"`
+	// 用户Id或用户组Id
+	SubscriberId string `width:"128" charset:"ascii" nullable:"false" index:"true" create:"required" list:"user" get:"user"`
+
+	// 只订阅指定项目或域下资源的事件
+	ProjectId        string `width:"128" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+	ResourceDomainId string `width:"128" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+
+	// 接收渠道, 逗号分隔
+	ContactTypes string `width:"256" charset:"ascii" nullable:"true" create:"optional"`
+	// 最低通知级别
+	MinPriority string `width:"16" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+
+	// 免打扰时段, HH:MM, 紧急通知不受影响
+	QuietHoursStart string `width:"8" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+	QuietHoursEnd   string `width:"8" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+
+	// 未确认时的升级链
+	EscalationId string `width:"128" charset:"ascii" nullable:"true" create:"optional" list:"user" get:"user"`
+}
+
+var quietHourRegexp = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+func (sm *SSubscriptionManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeUser
+}
+
+func (sm *SSubscriptionManager) FilterByOwner(q *sqlchemy.SQuery, owner mcclient.IIdentityProvider, scope rbacutils.TRbacScope) *sqlchemy.SQuery {
+	if owner == nil {
+		return q
+	}
+	switch scope {
+	case rbacutils.ScopeDomain:
+		q = q.Equals("domain_id", owner.GetProjectDomainId())
+	case rbacutils.ScopeProject, rbacutils.ScopeUser:
+		q = q.Equals("subscriber_type", api.SUBSCRIBER_TYPE_USER).Equals("subscriber_id", owner.GetUserId())
+	}
+	return q
+}
+
+// 组订阅以组Id作为属主用户, 普通用户无法访问
+func (s *SSubscription) GetOwnerId() mcclient.IIdentityProvider {
+	return &db.SOwnerId{DomainId: s.DomainId, UserId: s.SubscriberId}
+}
+
+func (s *SSubscription) IsOwner(userCred mcclient.TokenCredential) bool {
+	return s.SubscriberType == api.SUBSCRIBER_TYPE_USER && s.SubscriberId == userCred.GetUserId()
+}
+
+func (sm *SSubscriptionManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return true
+}
+
+func (sm *SSubscriptionManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.SubscriptionCreateInput) (api.SubscriptionCreateInput, error) {
+	var err error
+	input.StandaloneResourceCreateInput, err = sm.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Topic) == 0 {
+		input.Topic = input.TopicId
+	}
+	if len(input.Topic) == 0 {
+		return input, httperrors.NewMissingParameterError("topic")
+	}
+	topicObj, err := TopicManager.FetchByIdOrName(nil, input.Topic)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return input, httperrors.NewResourceNotFoundError2(TopicManager.Keyword(), input.Topic)
+		}
+		return input, errors.Wrap(err, "TopicManager.FetchByIdOrName")
+	}
+	topic := topicObj.(*STopic)
+	input.TopicId = topic.Id
+
+	isAdmin := db.IsAdminAllowCreate(userCred, sm)
+	isDomainAdmin := isAdmin || db.IsDomainAllowCreate(userCred, sm)
+
+	if len(input.SubscriberType) == 0 {
+		input.SubscriberType = api.SUBSCRIBER_TYPE_USER
+	}
+	if len(input.Subscriber) == 0 {
+		input.Subscriber = input.SubscriberId
+	}
+	var domainId string
+	switch input.SubscriberType {
+	case api.SUBSCRIBER_TYPE_USER:
+		if len(input.Subscriber) == 0 {
+			input.Subscriber = userCred.GetUserId()
+		}
+		receivers, err := ReceiverManager.FetchByIdOrNames(ctx, input.Subscriber)
+		if err != nil {
+			return input, errors.Wrap(err, "ReceiverManager.FetchByIdOrNames")
+		}
+		if len(receivers) == 0 {
+			return input, httperrors.NewResourceNotFoundError2(ReceiverManager.Keyword(), input.Subscriber)
+		}
+		if len(receivers) > 1 {
+			return input, httperrors.NewDuplicateResourceError("duplicate receiver %q", input.Subscriber)
+		}
+		input.SubscriberId = receivers[0].Id
+		domainId = receivers[0].DomainId
+	case api.SUBSCRIBER_TYPE_GROUP:
+		if len(input.Subscriber) == 0 {
+			return input, httperrors.NewMissingParameterError("subscriber")
+		}
+		session := auth.GetAdminSession(ctx, "", "")
+		group, err := modules.Groups.Get(session, input.Subscriber, nil)
+		if err != nil {
+			if jErr, ok := err.(*httputils.JSONClientError); ok && jErr.Code == 404 {
+				return input, httperrors.NewResourceNotFoundError2("group", input.Subscriber)
+			}
+			return input, errors.Wrap(err, "Groups.Get")
+		}
+		input.SubscriberId, _ = group.GetString("id")
+		domainId, _ = group.GetString("domain_id")
+	default:
+		return input, httperrors.NewInputParameterError("invalid subscriber_type %q", input.SubscriberType)
+	}
+	// 为他人或用户组订阅需要域管理权限
+	if input.SubscriberType != api.SUBSCRIBER_TYPE_USER || input.SubscriberId != userCred.GetUserId() {
+		if !isDomainAdmin || (!isAdmin && domainId != userCred.GetProjectDomainId()) {
+			return input, httperrors.NewForbiddenError("not allow to subscribe for %s %s", input.SubscriberType, input.Subscriber)
+		}
+	}
+	input.ProjectDomainId = domainId
+
+	// 非管理员只能订阅权限范围内资源的事件
+	if !isAdmin {
+		if len(input.ResourceDomainId) == 0 {
+			input.ResourceDomainId = userCred.GetProjectDomainId()
+		}
+		if input.ResourceDomainId != userCred.GetProjectDomainId() {
+			return input, httperrors.NewForbiddenError("not allow to subscribe events of domain %s", input.ResourceDomainId)
+		}
+		if !isDomainAdmin {
+			if len(input.ProjectId) == 0 {
+				input.ProjectId = userCred.GetProjectId()
+			}
+			if input.ProjectId != userCred.GetProjectId() {
+				return input, httperrors.NewForbiddenError("not allow to subscribe events of project %s", input.ProjectId)
+			}
+		}
+	}
+
+	input.ContactTypes, err = validateSubscriptionContactTypes(input.ContactTypes)
+	if err != nil {
+		return input, err
+	}
+	if len(input.MinPriority) == 0 {
+		input.MinPriority = api.NOTIFICATION_PRIORITY_NORMAL
+	}
+	if !utils.IsInStringArray(input.MinPriority, api.NOTIFICATION_PRIORITIES) {
+		return input, httperrors.NewInputParameterError("invalid min_priority %q", input.MinPriority)
+	}
+	err = validateQuietHours(input.QuietHoursStart, input.QuietHoursEnd)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Escalation) == 0 {
+		input.Escalation = input.EscalationId
+	}
+	if len(input.Escalation) > 0 {
+		escalation, err := EscalationManager.FetchByIdOrName(nil, input.Escalation)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return input, httperrors.NewResourceNotFoundError2(EscalationManager.Keyword(), input.Escalation)
+			}
+			return input, errors.Wrap(err, "EscalationManager.FetchByIdOrName")
+		}
+		input.EscalationId = escalation.GetId()
+	}
+	if len(input.Name) == 0 {
+		input.Name = fmt.Sprintf("%s-%s", topic.Name, input.Subscriber)
+	}
+	return input, nil
+}
+
+func validateSubscriptionContactTypes(contactTypes []string) ([]string, error) {
+	if len(contactTypes) == 0 {
+		return []string{api.WEBCONSOLE}, nil
+	}
+	allContactType, err := ConfigManager.allContactType()
+	if err != nil {
+		return nil, errors.Wrap(err, "allContactType")
+	}
+	for _, ct := range contactTypes {
+		if !utils.IsInStringArray(ct, allContactType) {
+			return nil, httperrors.NewInputParameterError("Unconfigured contact type %q", ct)
+		}
+	}
+	return contactTypes, nil
+}
+
+func validateQuietHours(start, end string) error {
+	if len(start) == 0 && len(end) == 0 {
+		return nil
+	}
+	if !quietHourRegexp.MatchString(start) {
+		return httperrors.NewInputParameterError("invalid quiet_hours_start %q, should be HH:MM", start)
+	}
+	if !quietHourRegexp.MatchString(end) {
+		return httperrors.NewInputParameterError("invalid quiet_hours_end %q, should be HH:MM", end)
+	}
+	return nil
+}
+
+func (s *SSubscription) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	var input api.SubscriptionCreateInput
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return err
+	}
+	s.DomainId = input.ProjectDomainId
+	s.ContactTypes = strings.Join(input.ContactTypes, ",")
+	if input.Enabled == nil {
+		s.Enabled = tristate.True
+	}
+	return s.SStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (s *SSubscription) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SubscriptionUpdateInput) (api.SubscriptionUpdateInput, error) {
+	var err error
+	input.StandaloneResourceBaseUpdateInput, err = s.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.ContactTypes) > 0 {
+		input.ContactTypes, err = validateSubscriptionContactTypes(input.ContactTypes)
+		if err != nil {
+			return input, err
+		}
+	}
+	if len(input.MinPriority) > 0 && !utils.IsInStringArray(input.MinPriority, api.NOTIFICATION_PRIORITIES) {
+		return input, httperrors.NewInputParameterError("invalid min_priority %q", input.MinPriority)
+	}
+	start, end := s.QuietHoursStart, s.QuietHoursEnd
+	if input.QuietHoursStart != nil {
+		start = *input.QuietHoursStart
+	}
+	if input.QuietHoursEnd != nil {
+		end = *input.QuietHoursEnd
+	}
+	err = validateQuietHours(start, end)
+	if err != nil {
+		return input, err
+	}
+	if input.Escalation != nil {
+		escalationId := ""
+		if len(*input.Escalation) > 0 {
+			escalation, err := EscalationManager.FetchByIdOrName(nil, *input.Escalation)
+			if err != nil {
+				if errors.Cause(err) == sql.ErrNoRows {
+					return input, httperrors.NewResourceNotFoundError2(EscalationManager.Keyword(), *input.Escalation)
+				}
+				return input, errors.Wrap(err, "EscalationManager.FetchByIdOrName")
+			}
+			escalationId = escalation.GetId()
+		}
+		input.EscalationId = &escalationId
+	}
+	return input, nil
+}
+
+func (s *SSubscription) PreUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	s.SStandaloneResourceBase.PreUpdate(ctx, userCred, query, data)
+	var input api.SubscriptionUpdateInput
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return
+	}
+	if len(input.ContactTypes) > 0 {
+		s.ContactTypes = strings.Join(input.ContactTypes, ",")
+	}
+	if len(input.MinPriority) > 0 {
+		s.MinPriority = input.MinPriority
+	}
+	if input.QuietHoursStart != nil {
+		s.QuietHoursStart = *input.QuietHoursStart
+	}
+	if input.QuietHoursEnd != nil {
+		s.QuietHoursEnd = *input.QuietHoursEnd
+	}
+	if input.EscalationId != nil {
+		s.EscalationId = *input.EscalationId
+	}
+}
+
+func (sm *SSubscriptionManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.SubscriptionListInput) (*sqlchemy.SQuery, error) {
+	q, err := sm.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	q, err = sm.SDomainizedResourceBaseManager.ListItemFilter(ctx, q, userCred, input.DomainizedResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	q, err = sm.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledResourceBaseListInput)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Topic) > 0 {
+		topic, err := TopicManager.FetchByIdOrName(nil, input.Topic)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(TopicManager.Keyword(), input.Topic)
+			}
+			return nil, errors.Wrap(err, "TopicManager.FetchByIdOrName")
+		}
+		q = q.Equals("topic_id", topic.GetId())
+	}
+	if len(input.SubscriberType) > 0 {
+		q = q.Equals("subscriber_type", input.SubscriberType)
+	}
+	if len(input.SubscriberId) > 0 {
+		q = q.Equals("subscriber_id", input.SubscriberId)
+	}
+	if len(input.Escalation) > 0 {
+		escalation, err := EscalationManager.FetchByIdOrName(nil, input.Escalation)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(EscalationManager.Keyword(), input.Escalation)
+			}
+			return nil, errors.Wrap(err, "EscalationManager.FetchByIdOrName")
+		}
+		q = q.Equals("escalation_id", escalation.GetId())
+	}
+	return q, nil
+}
+
+func (sm *SSubscriptionManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.SubscriptionDetails {
+	rows := make([]api.SubscriptionDetails, len(objs))
+	stdRows := sm.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	domainRows := sm.SDomainizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		sub := objs[i].(*SSubscription)
+		rows[i].StandaloneResourceDetails = stdRows[i]
+		rows[i].DomainizedResourceInfo = domainRows[i]
+		rows[i].ContactTypes = sub.getContactTypes()
+		if topic, err := TopicManager.FetchById(sub.TopicId); err == nil {
+			rows[i].Topic = topic.GetName()
+		}
+		if len(sub.EscalationId) > 0 {
+			if escalation, err := EscalationManager.FetchById(sub.EscalationId); err == nil {
+				rows[i].Escalation = escalation.GetName()
+			}
+		}
+	}
+	return rows
+}
+
+func (s *SSubscription) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return s.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, s, "enable")
+}
+
+func (s *SSubscription) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(s, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (s *SSubscription) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return s.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, s, "disable")
+}
+
+func (s *SSubscription) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(s, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (s *SSubscription) getContactTypes() []string {
+	if len(s.ContactTypes) == 0 {
+		return []string{api.WEBCONSOLE}
+	}
+	return strings.Split(s.ContactTypes, ",")
+}
+
+// 当前时间是否处于免打扰时段, 结束时间早于开始时间表示跨天
+func (s *SSubscription) inQuietHours(now time.Time) bool {
+	if len(s.QuietHoursStart) == 0 || len(s.QuietHoursEnd) == 0 || s.QuietHoursStart == s.QuietHoursEnd {
+		return false
+	}
+	cur := now.Format("15:04")
+	if s.QuietHoursStart < s.QuietHoursEnd {
+		return cur >= s.QuietHoursStart && cur < s.QuietHoursEnd
+	}
+	return cur >= s.QuietHoursStart || cur < s.QuietHoursEnd
+}
+
+// 免打扰时段的结束时间, 延迟的通知在此之后发送
+func (s *SSubscription) quietHoursEnd(now time.Time) time.Time {
+	end, err := time.ParseInLocation("15:04", s.QuietHoursEnd, now.Location())
+	if err != nil {
+		return now
+	}
+	ret := time.Date(now.Year(), now.Month(), now.Day(), end.Hour(), end.Minute(), 0, 0, now.Location())
+	if !ret.After(now) {
+		ret = ret.AddDate(0, 0, 1)
+	}
+	return ret
+}
+
+// 订阅是否匹配事件, 未指定项目或域的订阅匹配所有资源
+func (s *SSubscription) matches(input api.TopicPublishInput) bool {
+	if len(s.ProjectId) > 0 && s.ProjectId != input.ProjectId {
+		return false
+	}
+	if len(s.ResourceDomainId) > 0 && s.ResourceDomainId != input.DomainId {
+		return false
+	}
+	return priorityLevel(input.Priority) >= priorityLevel(s.MinPriority)
+}
+
+func (sm *SSubscriptionManager) fetchMatched(topicId string, input api.TopicPublishInput) ([]SSubscription, error) {
+	q := sm.Query().Equals("topic_id", topicId).IsTrue("enabled")
+	subs := make([]SSubscription, 0)
+	err := db.FetchModelObjects(sm, q, &subs)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return filterMatched(subs, input), nil
+}
+
+func filterMatched(subs []SSubscription, input api.TopicPublishInput) []SSubscription {
+	ret := make([]SSubscription, 0, len(subs))
+	for i := range subs {
+		if subs[i].matches(input) {
+			ret = append(ret, subs[i])
+		}
+	}
+	return ret
+}
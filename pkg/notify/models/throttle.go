@@ -0,0 +1,368 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/notify/options"
+)
+
+const (
+	// 汇总通知最多列出的条目数
+	maxDigestItems = 50
+)
+
+var (
+	// 已发送或将要发送的接收记录, 用于去重
+	dedupReceiverNotificationStatus = []string{
+		api.RECEIVER_NOTIFICATION_SENT,
+		api.RECEIVER_NOTIFICATION_OK,
+		api.RECEIVER_NOTIFICATION_DIGEST_PENDING,
+		api.RECEIVER_NOTIFICATION_DIGESTED,
+	}
+	// 实际发出的接收记录, 用于限速
+	sentReceiverNotificationStatus = []string{
+		api.RECEIVER_NOTIFICATION_SENT,
+		api.RECEIVER_NOTIFICATION_OK,
+	}
+)
+
+func (rn *SReceiverNotification) MarkStatus(ctx context.Context, status string, reason string) error {
+	_, err := db.Update(rn, func() error {
+		rn.Status = status
+		rn.FailedReason = reason
+		return nil
+	})
+	return err
+}
+
+func (rn *SReceiverNotification) isDefaultReceiver() bool {
+	return rn.ReceiverID == ReceiverIdDefault || len(rn.ReceiverID) == 0
+}
+
+// 解析 contact_type:count 形式的渠道限速配置
+func parseChannelRateLimits(str string) map[string]int {
+	limits := make(map[string]int)
+	for _, seg := range strings.Split(str, ",") {
+		seg = strings.TrimSpace(seg)
+		if len(seg) == 0 {
+			continue
+		}
+		pos := strings.LastIndex(seg, ":")
+		if pos <= 0 {
+			log.Errorf("invalid channel rate limit %q", seg)
+			continue
+		}
+		limit, err := strconv.Atoi(seg[pos+1:])
+		if err != nil {
+			log.Errorf("invalid channel rate limit %q: %v", seg, err)
+			continue
+		}
+		limits[seg[:pos]] = limit
+	}
+	return limits
+}
+
+// 被拦截的接收记录及其状态
+type sThrottleMark struct {
+	rn     *SReceiverNotification
+	status string
+	reason string
+}
+
+// 发送前对接收记录去重、限速及汇总, 被拦截的记录标记相应状态, 返回需要立即发送的记录
+func (n *SNotification) Throttle(ctx context.Context, rns []*SReceiverNotification) []*SReceiverNotification {
+	now := time.Now()
+	findDup := func(rn *SReceiverNotification) string {
+		since := now.Add(-time.Duration(options.Options.DedupWindowSeconds) * time.Second)
+		dupId, err := n.findDuplicate(rn, since)
+		if err != nil {
+			log.Errorf("find duplicate of notification %s: %v", n.Id, err)
+		}
+		return dupId
+	}
+	countSent := func(receiverId, contactType string) int {
+		since := now.Add(-time.Duration(options.Options.RateLimitWindowSeconds) * time.Second)
+		return countSentNotifications(since, receiverId, contactType)
+	}
+	send, marks := n.throttle(rns, findDup, countSent)
+	for _, m := range marks {
+		m.rn.MarkStatus(ctx, m.status, m.reason)
+	}
+	return send
+}
+
+func (n *SNotification) throttle(
+	rns []*SReceiverNotification,
+	findDup func(rn *SReceiverNotification) string,
+	countSent func(receiverId, contactType string) int,
+) ([]*SReceiverNotification, []sThrottleMark) {
+	if n.Tag == api.NOTIFICATION_TAG_DIGEST {
+		return rns, nil
+	}
+	marks := make([]sThrottleMark, 0)
+	passed := make([]*SReceiverNotification, 0, len(rns))
+
+	// 去重
+	if options.Options.DedupWindowSeconds > 0 && len(n.DedupKey) > 0 {
+		for _, rn := range rns {
+			if dupId := findDup(rn); len(dupId) > 0 {
+				marks = append(marks, sThrottleMark{rn, api.RECEIVER_NOTIFICATION_SUPPRESSED, fmt.Sprintf("duplicate of notification %s within %ds", dupId, options.Options.DedupWindowSeconds)})
+				continue
+			}
+			passed = append(passed, rn)
+		}
+	} else {
+		passed = append(passed, rns...)
+	}
+
+	// 站内信不限速也不汇总
+	if n.ContactType == api.WEBCONSOLE {
+		return passed, marks
+	}
+
+	// 普通通知进入汇总
+	if options.Options.DigestIntervalMinutes > 0 && n.Priority == api.NOTIFICATION_PRIORITY_NORMAL {
+		rest := make([]*SReceiverNotification, 0, len(passed))
+		for _, rn := range passed {
+			if rn.isDefaultReceiver() {
+				rest = append(rest, rn)
+				continue
+			}
+			marks = append(marks, sThrottleMark{rn, api.RECEIVER_NOTIFICATION_DIGEST_PENDING, ""})
+		}
+		passed = rest
+	}
+
+	// 紧急通知不限速
+	if n.Priority == api.NOTIFICATION_PRIORITY_CRITICAL {
+		return passed, marks
+	}
+	// 超出限速的通知不丢弃, 在下一次汇总时发送
+	ret := make([]*SReceiverNotification, 0, len(passed))
+	channelLimit, hasChannelLimit := parseChannelRateLimits(options.Options.ChannelRateLimits)[n.ContactType]
+	channelCount := 0
+	if hasChannelLimit {
+		channelCount = countSent("", n.ContactType)
+	}
+	receiverCount := make(map[string]int)
+	for _, rn := range passed {
+		if hasChannelLimit && channelCount >= channelLimit {
+			marks = append(marks, sThrottleMark{rn, api.RECEIVER_NOTIFICATION_DIGEST_PENDING, fmt.Sprintf("exceed rate limit of %s: %d in %ds", n.ContactType, channelLimit, options.Options.RateLimitWindowSeconds)})
+			continue
+		}
+		if options.Options.ReceiverRateLimit > 0 && !rn.isDefaultReceiver() {
+			cnt, ok := receiverCount[rn.ReceiverID]
+			if !ok {
+				cnt = countSent(rn.ReceiverID, "")
+			}
+			if cnt >= options.Options.ReceiverRateLimit {
+				marks = append(marks, sThrottleMark{rn, api.RECEIVER_NOTIFICATION_DIGEST_PENDING, fmt.Sprintf("exceed rate limit of receiver: %d in %ds", options.Options.ReceiverRateLimit, options.Options.RateLimitWindowSeconds)})
+				receiverCount[rn.ReceiverID] = cnt
+				continue
+			}
+			receiverCount[rn.ReceiverID] = cnt + 1
+		}
+		channelCount += 1
+		ret = append(ret, rn)
+	}
+	return ret, marks
+}
+
+// 查找去重窗口内发给同一接收人的相同通知
+func (n *SNotification) findDuplicate(rn *SReceiverNotification, since time.Time) (string, error) {
+	nq := NotificationManager.Query("id").Equals("dedup_key", n.DedupKey).Equals("contact_type", n.ContactType).NotEquals("id", n.Id).GE("created_at", since).SubQuery()
+	q := ReceiverNotificationManager.Query("notification_id").In("status", dedupReceiverNotificationStatus)
+	if rn.isDefaultReceiver() {
+		q = q.Equals("contact", rn.Contact)
+	} else {
+		q = q.Equals("receiver_id", rn.ReceiverID)
+	}
+	q = q.Join(nq, sqlchemy.Equals(q.Field("notification_id"), nq.Field("id")))
+	row := struct {
+		NotificationId string
+	}{}
+	err := q.First(&row)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return row.NotificationId, nil
+}
+
+// 统计限速窗口内已发出的通知数, 站内信不计入
+func countSentNotifications(since time.Time, receiverId string, contactType string) int {
+	nq := NotificationManager.Query("id")
+	if len(contactType) > 0 {
+		nq = nq.Equals("contact_type", contactType)
+	} else {
+		nq = nq.NotEquals("contact_type", api.WEBCONSOLE)
+	}
+	subq := nq.SubQuery()
+	q := ReceiverNotificationManager.Query().In("status", sentReceiverNotificationStatus).GE("send_at", since)
+	if len(receiverId) > 0 {
+		q = q.Equals("receiver_id", receiverId)
+	}
+	q = q.Join(subq, sqlchemy.Equals(q.Field("notification_id"), subq.Field("id")))
+	return q.Count()
+}
+
+type sDigestItem struct {
+	rn           *SReceiverNotification
+	notification *SNotification
+}
+
+// 发送汇总通知, 每个接收人每个渠道一条
+func (nm *SNotificationManager) SendDigests(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := ReceiverNotificationManager.Query().Equals("status", api.RECEIVER_NOTIFICATION_DIGEST_PENDING)
+	rns := make([]SReceiverNotification, 0)
+	err := db.FetchModelObjects(ReceiverNotificationManager, q, &rns)
+	if err != nil {
+		log.Errorf("fetch digest pending receiver notifications: %v", err)
+		return
+	}
+	if len(rns) == 0 {
+		return
+	}
+	nIds := make([]string, 0, len(rns))
+	for i := range rns {
+		nIds = append(nIds, rns[i].NotificationID)
+	}
+	ns := make([]SNotification, 0, len(nIds))
+	err = db.FetchModelObjects(nm, nm.Query().In("id", nIds), &ns)
+	if err != nil {
+		log.Errorf("fetch digest pending notifications: %v", err)
+		return
+	}
+	nMap := make(map[string]*SNotification, len(ns))
+	for i := range ns {
+		nMap[ns[i].Id] = &ns[i]
+	}
+	groups, missing := groupDigestItems(rns, nMap)
+	for _, rn := range missing {
+		// 通知已清理
+		rn.MarkStatus(ctx, api.RECEIVER_NOTIFICATION_FAIL, "notification not found")
+	}
+	for key, items := range groups {
+		err := nm.sendDigest(ctx, userCred, items)
+		if err != nil {
+			log.Errorf("send digest to %s: %v", key, err)
+		}
+	}
+}
+
+// 按接收人和渠道分组, 没有接收人的记录(如机器人)按联系方式分组
+func groupDigestItems(rns []SReceiverNotification, nMap map[string]*SNotification) (map[string][]sDigestItem, []*SReceiverNotification) {
+	groups := make(map[string][]sDigestItem)
+	missing := make([]*SReceiverNotification, 0)
+	for i := range rns {
+		n, ok := nMap[rns[i].NotificationID]
+		if !ok {
+			missing = append(missing, &rns[i])
+			continue
+		}
+		key := "receiver:" + rns[i].ReceiverID
+		if rns[i].isDefaultReceiver() {
+			key = "contact:" + rns[i].Contact
+		}
+		key += "/" + n.ContactType
+		groups[key] = append(groups[key], sDigestItem{rn: &rns[i], notification: n})
+	}
+	return groups, missing
+}
+
+func (nm *SNotificationManager) sendDigest(ctx context.Context, userCred mcclient.TokenCredential, items []sDigestItem) error {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].notification.ReceivedAt.Before(items[j].notification.ReceivedAt)
+	})
+	receiverId := items[0].rn.ReceiverID
+	contactType := items[0].notification.ContactType
+	lang := ""
+	receiver, err := items[0].rn.Receiver()
+	if err == nil {
+		lang, _ = receiver.GetTemplateLang(ctx)
+	}
+
+	start := items[0].notification.ReceivedAt.Format("2006-01-02 15:04:05")
+	end := items[len(items)-1].notification.ReceivedAt.Format("2006-01-02 15:04:05")
+	var topic string
+	lines := make([]string, 0, len(items)+2)
+	if lang == api.TEMPLATE_LANG_EN {
+		topic = "Notification digest"
+		lines = append(lines, fmt.Sprintf("%d notifications between %s and %s:", len(items), start, end))
+	} else {
+		topic = "通知汇总"
+		lines = append(lines, fmt.Sprintf("%s 至 %s 共 %d 条通知:", start, end, len(items)))
+	}
+	for i, item := range items {
+		if i >= maxDigestItems {
+			if lang == api.TEMPLATE_LANG_EN {
+				lines = append(lines, fmt.Sprintf("... and %d more", len(items)-maxDigestItems))
+			} else {
+				lines = append(lines, fmt.Sprintf("... 及其他 %d 条", len(items)-maxDigestItems))
+			}
+			break
+		}
+		n := item.notification
+		title := n.Topic
+		p, err := TemplateManager.NotifyFilter(n.ContactType, n.Topic, n.Message, lang)
+		if err == nil && len(p.Title) > 0 {
+			title = p.Title
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s", n.ReceivedAt.Format("01-02 15:04:05"), title))
+	}
+
+	input := api.NotificationCreateInput{
+		ContactType:               contactType,
+		Topic:                     topic,
+		Priority:                  api.NOTIFICATION_PRIORITY_NORMAL,
+		Message:                   strings.Join(lines, "\n"),
+		Tag:                       api.NOTIFICATION_TAG_DIGEST,
+		IgnoreNonexistentReceiver: true,
+	}
+	if items[0].rn.isDefaultReceiver() {
+		input.Contacts = []string{items[0].rn.Contact}
+	} else {
+		input.Receivers = []string{receiverId}
+	}
+	digest, err := nm.create(ctx, userCred, input, "", time.Time{})
+	if err != nil {
+		// 避免反复重试无效的接收人
+		for _, item := range items {
+			item.rn.MarkStatus(ctx, api.RECEIVER_NOTIFICATION_FAIL, fmt.Sprintf("fail to send digest: %v", err))
+		}
+		return errors.Wrap(err, "create digest notification")
+	}
+	for _, item := range items {
+		item.rn.MarkStatus(ctx, api.RECEIVER_NOTIFICATION_DIGESTED, fmt.Sprintf("sent in digest notification %s", digest.Id))
+	}
+	return nil
+}
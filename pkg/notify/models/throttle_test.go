@@ -0,0 +1,200 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/notify/options"
+)
+
+func TestParseChannelRateLimits(t *testing.T) {
+	cases := []struct {
+		in   string
+		want map[string]int
+	}{
+		{in: "", want: map[string]int{}},
+		{in: "dingtalk-robot:20, feishu-robot:10", want: map[string]int{"dingtalk-robot": 20, "feishu-robot": 10}},
+		{in: "email:abc,:3,mobile,webhook:5", want: map[string]int{"webhook": 5}},
+	}
+	for _, c := range cases {
+		got := parseChannelRateLimits(c.in)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseChannelRateLimits(%q) want %v got %v", c.in, c.want, got)
+		}
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	saved := options.Options
+	defer func() {
+		options.Options = saved
+	}()
+
+	receivers := func(ids ...string) []*SReceiverNotification {
+		rns := make([]*SReceiverNotification, 0, len(ids))
+		for _, id := range ids {
+			rns = append(rns, &SReceiverNotification{ReceiverID: id})
+		}
+		return rns
+	}
+	ids := func(rns []*SReceiverNotification) []string {
+		ret := []string{}
+		for _, rn := range rns {
+			ret = append(ret, rn.ReceiverID)
+		}
+		return ret
+	}
+	marked := func(marks []sThrottleMark) map[string]string {
+		ret := map[string]string{}
+		for _, m := range marks {
+			ret[m.rn.ReceiverID] = m.status
+		}
+		return ret
+	}
+
+	cases := []struct {
+		name       string
+		opts       options.NotifyOption
+		n          SNotification
+		rns        []*SReceiverNotification
+		dups       map[string]bool
+		sent       map[string]int
+		wantSend   []string
+		wantMarked map[string]string
+	}{
+		{
+			name:     "dedup disabled",
+			n:        SNotification{ContactType: api.EMAIL, DedupKey: "k"},
+			rns:      receivers("u1", "u2"),
+			dups:     map[string]bool{"u1": true},
+			wantSend: []string{"u1", "u2"},
+		},
+		{
+			name:       "dedup window",
+			opts:       options.NotifyOption{DedupWindowSeconds: 600},
+			n:          SNotification{ContactType: api.EMAIL, DedupKey: "k"},
+			rns:        receivers("u1", "u2"),
+			dups:       map[string]bool{"u1": true},
+			wantSend:   []string{"u2"},
+			wantMarked: map[string]string{"u1": api.RECEIVER_NOTIFICATION_SUPPRESSED},
+		},
+		{
+			name:       "receiver limit to digest",
+			opts:       options.NotifyOption{ReceiverRateLimit: 2},
+			n:          SNotification{ContactType: api.EMAIL, Priority: api.NOTIFICATION_PRIORITY_IMPORTANT},
+			rns:        receivers("u1", "u2"),
+			sent:       map[string]int{"u1": 2, "u2": 1},
+			wantSend:   []string{"u2"},
+			wantMarked: map[string]string{"u1": api.RECEIVER_NOTIFICATION_DIGEST_PENDING},
+		},
+		{
+			name:       "channel limit to digest",
+			opts:       options.NotifyOption{ChannelRateLimits: "email:3"},
+			n:          SNotification{ContactType: api.EMAIL, Priority: api.NOTIFICATION_PRIORITY_IMPORTANT},
+			rns:        receivers("u1", "u2", "u3"),
+			sent:       map[string]int{api.EMAIL: 2},
+			wantSend:   []string{"u1"},
+			wantMarked: map[string]string{"u2": api.RECEIVER_NOTIFICATION_DIGEST_PENDING, "u3": api.RECEIVER_NOTIFICATION_DIGEST_PENDING},
+		},
+		{
+			name:     "critical bypasses limits",
+			opts:     options.NotifyOption{ReceiverRateLimit: 1, ChannelRateLimits: "email:1", DigestIntervalMinutes: 10},
+			n:        SNotification{ContactType: api.EMAIL, Priority: api.NOTIFICATION_PRIORITY_CRITICAL},
+			rns:      receivers("u1", "u2"),
+			sent:     map[string]int{"u1": 5, api.EMAIL: 5},
+			wantSend: []string{"u1", "u2"},
+		},
+		{
+			name:       "normal priority digested",
+			opts:       options.NotifyOption{DigestIntervalMinutes: 10},
+			n:          SNotification{ContactType: api.EMAIL, Priority: api.NOTIFICATION_PRIORITY_NORMAL},
+			rns:        append(receivers("u1"), &SReceiverNotification{ReceiverID: ReceiverIdDefault, Contact: "a@b.c"}),
+			wantSend:   []string{ReceiverIdDefault},
+			wantMarked: map[string]string{"u1": api.RECEIVER_NOTIFICATION_DIGEST_PENDING},
+		},
+		{
+			name:     "webconsole not limited",
+			opts:     options.NotifyOption{ReceiverRateLimit: 1},
+			n:        SNotification{ContactType: api.WEBCONSOLE, Priority: api.NOTIFICATION_PRIORITY_IMPORTANT},
+			rns:      receivers("u1"),
+			sent:     map[string]int{"u1": 5},
+			wantSend: []string{"u1"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			options.Options = c.opts
+			findDup := func(rn *SReceiverNotification) string {
+				if c.dups[rn.ReceiverID] {
+					return "dup"
+				}
+				return ""
+			}
+			countSent := func(receiverId, contactType string) int {
+				return c.sent[receiverId+contactType]
+			}
+			send, marks := c.n.throttle(c.rns, findDup, countSent)
+			if got := ids(send); !reflect.DeepEqual(got, c.wantSend) {
+				t.Errorf("want send %v got %v", c.wantSend, got)
+			}
+			want := c.wantMarked
+			if want == nil {
+				want = map[string]string{}
+			}
+			if got := marked(marks); !reflect.DeepEqual(got, want) {
+				t.Errorf("want marked %v got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestGroupDigestItems(t *testing.T) {
+	nMap := map[string]*SNotification{
+		"n1": {ContactType: api.EMAIL},
+		"n2": {ContactType: api.EMAIL},
+		"n3": {ContactType: api.MOBILE},
+		"n4": {ContactType: api.DINGTALK_ROBOT},
+	}
+	rns := []SReceiverNotification{
+		{ReceiverID: "u1", NotificationID: "n1"},
+		{ReceiverID: "u1", NotificationID: "n2"},
+		{ReceiverID: "u1", NotificationID: "n3"},
+		{ReceiverID: "u2", NotificationID: "n1"},
+		{ReceiverID: ReceiverIdDefault, Contact: "robot1", NotificationID: "n4"},
+		{ReceiverID: ReceiverIdDefault, Contact: "robot2", NotificationID: "n4"},
+		{ReceiverID: "u1", NotificationID: "deleted"},
+	}
+	groups, missing := groupDigestItems(rns, nMap)
+	got := map[string]int{}
+	for key, items := range groups {
+		got[key] = len(items)
+	}
+	want := map[string]int{
+		"receiver:u1/" + api.EMAIL:             2,
+		"receiver:u1/" + api.MOBILE:            1,
+		"receiver:u2/" + api.EMAIL:             1,
+		"contact:robot1/" + api.DINGTALK_ROBOT: 1,
+		"contact:robot2/" + api.DINGTALK_ROBOT: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if len(missing) != 1 || missing[0].NotificationID != "deleted" {
+		t.Errorf("want 1 missing notification got %d", len(missing))
+	}
+}
@@ -0,0 +1,459 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/util/sets"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// 通知主题, 由各服务按资源类型和事件类别发布, 用户通过订阅接收
+type STopicManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var TopicManager *STopicManager
+
+func init() {
+	TopicManager = &STopicManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			STopic{},
+			"topics_tbl",
+			"topic",
+			"topics",
+		),
+	}
+	TopicManager.SetVirtualObject(TopicManager)
+}
+
+type STopic struct {
+	db.SStandaloneResourceBase
+	db.SEnabledResourceBase
+
+	// 资源类型, 如 server, cloudaccount
+	ResourceType string `width:"64" charset:"ascii" nullable:"false" index:"true" create:"required" list:"user" get:"user"`
+	// 事件类别, 如 failure, balance_low
+	EventClass string `width:"64" charset:"ascii" nullable:"false" index:"true" create:"required" list:"user" get:"user"`
+	// 渲染消息使用的模板主题
+	TemplateTopic string `width:"128" nullable:"true" create:"optional" update:"admin" list:"user" get:"user"`
+}
+
+func (tm *STopicManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return true
+}
+
+func (t *STopic) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return true
+}
+
+func (tm *STopicManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.TopicCreateInput) (api.TopicCreateInput, error) {
+	var err error
+	input.StandaloneResourceCreateInput, err = tm.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.ResourceType) == 0 {
+		return input, httperrors.NewMissingParameterError("resource_type")
+	}
+	if len(input.EventClass) == 0 {
+		return input, httperrors.NewMissingParameterError("event_class")
+	}
+	_, err = tm.fetchByResourceEvent(input.ResourceType, input.EventClass)
+	if err == nil {
+		return input, httperrors.NewDuplicateResourceError("topic of %s %s exists", input.ResourceType, input.EventClass)
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return input, err
+	}
+	if len(input.Name) == 0 {
+		input.Name = fmt.Sprintf("%s-%s", input.ResourceType, input.EventClass)
+	}
+	return input, nil
+}
+
+func (t *STopic) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	if !data.Contains("enabled") {
+		t.Enabled = tristate.True
+	}
+	return t.SStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (tm *STopicManager) fetchByResourceEvent(resourceType, eventClass string) (*STopic, error) {
+	q := tm.Query().Equals("resource_type", resourceType).Equals("event_class", eventClass)
+	topics := make([]STopic, 0, 1)
+	err := db.FetchModelObjects(tm, q, &topics)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	if len(topics) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &topics[0], nil
+}
+
+func (tm *STopicManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.TopicListInput) (*sqlchemy.SQuery, error) {
+	q, err := tm.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	q, err = tm.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledResourceBaseListInput)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.ResourceType) > 0 {
+		q = q.Equals("resource_type", input.ResourceType)
+	}
+	if len(input.EventClass) > 0 {
+		q = q.Equals("event_class", input.EventClass)
+	}
+	return q, nil
+}
+
+func (tm *STopicManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.TopicDetails {
+	rows := make([]api.TopicDetails, len(objs))
+	stdRows := tm.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		topic := objs[i].(*STopic)
+		rows[i].StandaloneResourceDetails = stdRows[i]
+		rows[i].SubscriptionCount = SubscriptionManager.Query().Equals("topic_id", topic.Id).Count()
+	}
+	return rows
+}
+
+func (t *STopic) ValidateDeleteCondition(ctx context.Context) error {
+	if SubscriptionManager.Query().Equals("topic_id", t.Id).Count() > 0 {
+		return httperrors.NewNotEmptyError("topic %s is subscribed", t.Name)
+	}
+	return t.SStandaloneResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (t *STopic) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return db.IsAdminAllowPerform(userCred, t, "enable")
+}
+
+func (t *STopic) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(t, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (t *STopic) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return db.IsAdminAllowPerform(userCred, t, "disable")
+}
+
+func (t *STopic) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(t, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (t *STopic) getTemplateTopic() string {
+	if len(t.TemplateTopic) > 0 {
+		return t.TemplateTopic
+	}
+	return strings.ToUpper(t.Name)
+}
+
+func (tm *STopicManager) AllowPerformPublish(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowClassPerform(userCred, tm, "publish")
+}
+
+// 发布事件到主题, 按订阅生成通知
+func (tm *STopicManager) PerformPublish(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.TopicPublishInput) (api.TopicPublishOutput, error) {
+	output := api.TopicPublishOutput{}
+	var topic *STopic
+	if len(input.Topic) > 0 {
+		obj, err := tm.FetchByIdOrName(nil, input.Topic)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return output, httperrors.NewResourceNotFoundError2(tm.Keyword(), input.Topic)
+			}
+			return output, errors.Wrap(err, "FetchByIdOrName")
+		}
+		topic = obj.(*STopic)
+	} else {
+		if len(input.ResourceType) == 0 || len(input.EventClass) == 0 {
+			return output, httperrors.NewMissingParameterError("topic")
+		}
+		var err error
+		topic, err = tm.fetchByResourceEvent(input.ResourceType, input.EventClass)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				// 没有人关心的事件
+				return output, nil
+			}
+			return output, err
+		}
+	}
+	if len(input.Message) == 0 {
+		return output, httperrors.NewMissingParameterError("message")
+	}
+	if len(input.Priority) == 0 {
+		input.Priority = api.NOTIFICATION_PRIORITY_NORMAL
+	}
+	if !utils.IsInStringArray(input.Priority, api.NOTIFICATION_PRIORITIES) {
+		return output, httperrors.NewInputParameterError("invalid priority %q", input.Priority)
+	}
+	if !topic.GetEnabled() {
+		return output, nil
+	}
+	ids, err := topic.publish(ctx, userCred, input)
+	if err != nil {
+		return output, err
+	}
+	output.Notifications = ids
+	return output, nil
+}
+
+type sTopicDelivery struct {
+	contactType  string
+	escalationId string
+	// 非零表示处于免打扰时段, 延迟到该时间发送
+	sendAfter time.Time
+	receivers []string
+}
+
+func (t *STopic) publish(ctx context.Context, userCred mcclient.TokenCredential, input api.TopicPublishInput) ([]string, error) {
+	subs, err := SubscriptionManager.fetchMatched(t.Id, input)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetchMatched")
+	}
+	if len(subs) == 0 {
+		return nil, nil
+	}
+	allContactType, err := ConfigManager.allContactType()
+	if err != nil {
+		return nil, errors.Wrap(err, "allContactType")
+	}
+	receiversOf := func(sub *SSubscription) ([]string, error) {
+		return sub.getReceiverIds(ctx)
+	}
+	escalationOf := func(sub *SSubscription) string {
+		if len(sub.EscalationId) == 0 {
+			return ""
+		}
+		escalation, err := EscalationManager.fetchEnabled(sub.EscalationId)
+		if err != nil {
+			log.Errorf("subscription %s fetch escalation %s: %v", sub.Name, sub.EscalationId, err)
+			return ""
+		}
+		if escalation == nil || priorityLevel(input.Priority) < priorityLevel(escalation.MinPriority) {
+			return ""
+		}
+		return escalation.Id
+	}
+	deliveries := planDeliveries(subs, input.Priority, allContactType, time.Now(), receiversOf, escalationOf)
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		if len(d.receivers) == 0 {
+			continue
+		}
+		metadata := map[string]interface{}{
+			"topic_id": t.Id,
+		}
+		if len(input.ResourceId) > 0 {
+			metadata["resource_id"] = input.ResourceId
+		}
+		if len(input.ResourceName) > 0 {
+			metadata["resource_name"] = input.ResourceName
+		}
+		createInput := api.NotificationCreateInput{
+			Receivers:                 d.receivers,
+			ContactType:               d.contactType,
+			Topic:                     t.getTemplateTopic(),
+			Priority:                  input.Priority,
+			Message:                   input.Message,
+			Tag:                       input.Tag,
+			Metadata:                  metadata,
+			IgnoreNonexistentReceiver: true,
+			DedupKey:                  input.DedupKey,
+		}
+		n, err := NotificationManager.create(ctx, userCred, createInput, d.escalationId, d.sendAfter)
+		if err != nil {
+			log.Errorf("topic %s create %s notification: %v", t.Name, d.contactType, err)
+			continue
+		}
+		ids = append(ids, n.Id)
+	}
+	return ids, nil
+}
+
+// 按渠道, 升级链及发送时间合并订阅的接收人, 同一接收人同一渠道只通知一次;
+// 免打扰时段内的非致命通知延迟到时段结束后发送, 先处理非免打扰订阅使接收人优先立即收到
+func planDeliveries(
+	subs []SSubscription,
+	priority string,
+	allContactType []string,
+	now time.Time,
+	receiversOf func(sub *SSubscription) ([]string, error),
+	escalationOf func(sub *SSubscription) string,
+) []*sTopicDelivery {
+	immediate := make([]*SSubscription, 0, len(subs))
+	quiet := make([]*SSubscription, 0)
+	for i := range subs {
+		if subs[i].inQuietHours(now) && priority != api.NOTIFICATION_PRIORITY_CRITICAL {
+			quiet = append(quiet, &subs[i])
+		} else {
+			immediate = append(immediate, &subs[i])
+		}
+	}
+	deliveries := make([]*sTopicDelivery, 0)
+	notified := make(map[string]sets.String)
+	plan := func(sub *SSubscription, sendAfter time.Time) {
+		receivers, err := receiversOf(sub)
+		if err != nil {
+			log.Errorf("subscription %s get receivers: %v", sub.Name, err)
+			return
+		}
+		escalationId := escalationOf(sub)
+		for _, ct := range sub.getContactTypes() {
+			if !utils.IsInStringArray(ct, allContactType) {
+				continue
+			}
+			if _, ok := notified[ct]; !ok {
+				notified[ct] = sets.NewString()
+			}
+			var delivery *sTopicDelivery
+			for _, d := range deliveries {
+				if d.contactType == ct && d.escalationId == escalationId && d.sendAfter.Equal(sendAfter) {
+					delivery = d
+					break
+				}
+			}
+			if delivery == nil {
+				delivery = &sTopicDelivery{contactType: ct, escalationId: escalationId, sendAfter: sendAfter}
+				deliveries = append(deliveries, delivery)
+			}
+			for _, r := range receivers {
+				if notified[ct].Has(r) {
+					continue
+				}
+				notified[ct].Insert(r)
+				delivery.receivers = append(delivery.receivers, r)
+			}
+		}
+	}
+	for _, sub := range immediate {
+		plan(sub, time.Time{})
+	}
+	for _, sub := range quiet {
+		plan(sub, sub.quietHoursEnd(now))
+	}
+	return deliveries
+}
+
+// 获取订阅者对应的接收人, 组订阅展开为组内用户
+func (s *SSubscription) getReceiverIds(ctx context.Context) ([]string, error) {
+	if s.SubscriberType != api.SUBSCRIBER_TYPE_GROUP {
+		return []string{s.SubscriberId}, nil
+	}
+	session := auth.GetAdminSession(ctx, "", "")
+	params := jsonutils.NewDict()
+	params.Set("limit", jsonutils.NewInt(0))
+	result, err := modules.Groups.GetUsers(session, s.SubscriberId, params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Groups.GetUsers %s", s.SubscriberId)
+	}
+	ids := make([]string, 0, len(result.Data))
+	for i := range result.Data {
+		id, _ := result.Data[i].GetString("id")
+		if len(id) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func priorityLevel(priority string) int {
+	switch priority {
+	case api.NOTIFICATION_PRIORITY_CRITICAL:
+		return 2
+	case api.NOTIFICATION_PRIORITY_IMPORTANT:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sendAfter 非零时通知延迟到该时间后由 ReleaseDeferred 发送
+func (nm *SNotificationManager) create(ctx context.Context, userCred mcclient.TokenCredential, input api.NotificationCreateInput, escalationId string, sendAfter time.Time) (*SNotification, error) {
+	data := input.JSON(input)
+	model, err := db.DoCreate(nm, ctx, userCred, nil, data, userCred)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.DoCreate")
+	}
+	n := model.(*SNotification)
+	if !sendAfter.IsZero() {
+		_, err = db.Update(n, func() error {
+			n.SendAfter = sendAfter
+			return nil
+		})
+		if err != nil {
+			log.Errorf("set send_after of notification %s: %v", n.Id, err)
+		}
+	}
+	if len(escalationId) > 0 {
+		escalation, err := EscalationManager.fetchEnabled(escalationId)
+		if err == nil && escalation != nil {
+			// 延迟发送的通知从发送时间开始计算确认超时
+			start := time.Now()
+			if sendAfter.After(start) {
+				start = sendAfter
+			}
+			_, err = db.Update(n, func() error {
+				n.EscalationId = escalation.Id
+				n.EscalationLevel = 0
+				n.EscalateAt = start.Add(escalation.getAckTimeout())
+				return nil
+			})
+		}
+		if err != nil {
+			log.Errorf("set escalation of notification %s: %v", n.Id, err)
+		}
+	}
+	func() {
+		lockman.LockObject(ctx, n)
+		defer lockman.ReleaseObject(ctx, n)
+
+		n.PostCreate(ctx, userCred, userCred, nil, data)
+	}()
+	return n, nil
+}
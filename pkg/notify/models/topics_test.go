@@ -0,0 +1,202 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	subs := []SSubscription{
+		{SubscriberId: "all"},
+		{SubscriberId: "project", ProjectId: "p1"},
+		{SubscriberId: "domain", ResourceDomainId: "d1"},
+		{SubscriberId: "important", MinPriority: api.NOTIFICATION_PRIORITY_IMPORTANT},
+	}
+	cases := []struct {
+		name  string
+		input api.TopicPublishInput
+		want  []string
+	}{
+		{
+			name:  "no project",
+			input: api.TopicPublishInput{Priority: api.NOTIFICATION_PRIORITY_NORMAL},
+			want:  []string{"all"},
+		},
+		{
+			name:  "project and domain",
+			input: api.TopicPublishInput{ProjectId: "p1", DomainId: "d1", Priority: api.NOTIFICATION_PRIORITY_NORMAL},
+			want:  []string{"all", "project", "domain"},
+		},
+		{
+			name:  "other project",
+			input: api.TopicPublishInput{ProjectId: "p2", DomainId: "d2", Priority: api.NOTIFICATION_PRIORITY_CRITICAL},
+			want:  []string{"all", "important"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := []string{}
+			for _, sub := range filterMatched(subs, c.input) {
+				got = append(got, sub.SubscriberId)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %v got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	now := time.Date(2020, 1, 1, 23, 30, 0, 0, time.UTC)
+	sub := &SSubscription{QuietHoursStart: "22:00", QuietHoursEnd: "08:00"}
+	if !sub.inQuietHours(now) {
+		t.Fatalf("%s should be in quiet hours", now)
+	}
+	want := time.Date(2020, 1, 2, 8, 0, 0, 0, time.UTC)
+	if got := sub.quietHoursEnd(now); !got.Equal(want) {
+		t.Errorf("want %s got %s", want, got)
+	}
+	now = time.Date(2020, 1, 2, 7, 0, 0, 0, time.UTC)
+	if got := sub.quietHoursEnd(now); !got.Equal(want) {
+		t.Errorf("want %s got %s", want, got)
+	}
+}
+
+func TestPlanDeliveries(t *testing.T) {
+	now := time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC)
+	members := map[string][]string{
+		"ops": {"u1", "u2"},
+	}
+	receiversOf := func(sub *SSubscription) ([]string, error) {
+		if sub.SubscriberType == api.SUBSCRIBER_TYPE_GROUP {
+			return members[sub.SubscriberId], nil
+		}
+		return []string{sub.SubscriberId}, nil
+	}
+	escalationOf := func(sub *SSubscription) string {
+		return sub.EscalationId
+	}
+	quietEnd := time.Date(2020, 1, 2, 8, 0, 0, 0, time.UTC)
+	type delivery struct {
+		contactType  string
+		escalationId string
+		sendAfter    time.Time
+		receivers    []string
+	}
+	cases := []struct {
+		name     string
+		subs     []SSubscription
+		priority string
+		want     []delivery
+	}{
+		{
+			name: "group expanded and deduplicated",
+			subs: []SSubscription{
+				{SubscriberType: api.SUBSCRIBER_TYPE_GROUP, SubscriberId: "ops", ContactTypes: api.EMAIL},
+				{SubscriberType: api.SUBSCRIBER_TYPE_USER, SubscriberId: "u2", ContactTypes: api.EMAIL + "," + api.WEBCONSOLE},
+				{SubscriberType: api.SUBSCRIBER_TYPE_USER, SubscriberId: "u3", ContactTypes: "unknown"},
+			},
+			priority: api.NOTIFICATION_PRIORITY_NORMAL,
+			want: []delivery{
+				{contactType: api.EMAIL, receivers: []string{"u1", "u2"}},
+				{contactType: api.WEBCONSOLE, receivers: []string{"u2"}},
+			},
+		},
+		{
+			name: "quiet hours deferred",
+			subs: []SSubscription{
+				{SubscriberType: api.SUBSCRIBER_TYPE_USER, SubscriberId: "u1", QuietHoursStart: "22:00", QuietHoursEnd: "08:00"},
+				{SubscriberType: api.SUBSCRIBER_TYPE_GROUP, SubscriberId: "ops", EscalationId: "e1"},
+			},
+			priority: api.NOTIFICATION_PRIORITY_IMPORTANT,
+			want: []delivery{
+				{contactType: api.WEBCONSOLE, escalationId: "e1", receivers: []string{"u1", "u2"}},
+				{contactType: api.WEBCONSOLE, sendAfter: quietEnd},
+			},
+		},
+		{
+			name: "critical ignores quiet hours",
+			subs: []SSubscription{
+				{SubscriberType: api.SUBSCRIBER_TYPE_USER, SubscriberId: "u1", QuietHoursStart: "22:00", QuietHoursEnd: "08:00"},
+			},
+			priority: api.NOTIFICATION_PRIORITY_CRITICAL,
+			want: []delivery{
+				{contactType: api.WEBCONSOLE, receivers: []string{"u1"}},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ds := planDeliveries(c.subs, c.priority, []string{api.EMAIL, api.WEBCONSOLE}, now, receiversOf, escalationOf)
+			got := make([]delivery, 0, len(ds))
+			for _, d := range ds {
+				got = append(got, delivery{d.contactType, d.escalationId, d.sendAfter, d.receivers})
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %+v got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestEscalationStep(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	receivers := []string{"u1", "u2", "u3"}
+	cases := []struct {
+		level    int
+		receiver string
+		next     time.Time
+		ok       bool
+	}{
+		{level: 0, receiver: "u1", next: now.Add(time.Minute), ok: true},
+		{level: 1, receiver: "u2", next: now.Add(time.Minute), ok: true},
+		{level: 2, receiver: "u3", ok: true},
+		{level: 3},
+	}
+	for _, c := range cases {
+		receiver, next, ok := escalationStep(c.level, receivers, now, time.Minute)
+		if receiver != c.receiver || !next.Equal(c.next) || ok != c.ok {
+			t.Errorf("level %d: want (%s, %s, %v) got (%s, %s, %v)", c.level, c.receiver, c.next, c.ok, receiver, next, ok)
+		}
+	}
+}
+
+func TestAckStopsEscalation(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := &SNotification{
+		EscalationId: "e1",
+		EscalateAt:   now.Add(-time.Minute),
+	}
+	if !n.needEscalate(now) {
+		t.Fatalf("unacked notification should escalate")
+	}
+	if n.needEscalate(now.Add(-time.Hour)) {
+		t.Errorf("notification should not escalate before escalate_at")
+	}
+	n.AckedAt = now
+	if n.needEscalate(now) {
+		t.Errorf("acked notification should not escalate")
+	}
+	// 已确认的通知直接返回, 不再查询升级链
+	if err := n.escalate(context.Background(), nil); err != nil {
+		t.Errorf("escalate acked notification: %v", err)
+	}
+}
@@ -33,6 +33,12 @@ type NotifyOption struct {
 
 	VerifyExpireInterval int `help:"expire interval of verify message; minutes" default:"2"`
 	VerifyValidInterval  int `help:"valid interval of verify message; miniutes" default:"20"`
+
+	DedupWindowSeconds     int    `help:"Suppress notifications with same dedup key to the same receiver within this window; 0 to disable" default:"0"`
+	RateLimitWindowSeconds int    `help:"Window of notification rate limits; seconds" default:"60"`
+	ReceiverRateLimit      int    `help:"Max notifications sent to a receiver within rate limit window, the rest are sent in next digest; 0 means no limit" default:"0"`
+	ChannelRateLimits      string `help:"Max notifications sent through a contact type within rate limit window, the rest are sent in next digest, e.g. dingtalk-robot:20,feishu-robot:20; empty means no limit" default:""`
+	DigestIntervalMinutes  int    `help:"Batch normal priority notifications into a digest sent every DigestIntervalMinutes; 0 to disable" default:"0"`
 }
 
 var Options NotifyOption
@@ -48,6 +48,9 @@ func InitHandlers(app *appsrv.Application) {
 		models.NotificationManager,
 		models.ConfigManager,
 		models.TemplateManager,
+		models.TopicManager,
+		models.SubscriptionManager,
+		models.EscalationManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
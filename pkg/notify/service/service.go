@@ -74,6 +74,17 @@ func StartService() {
 
 	// wrapped func to resend notifications
 	cron.AddJobAtIntervals("ReSendNotifications", time.Duration(opts.ReSendScope)*time.Second, models.NotificationManager.ReSend)
+	cron.AddJobAtIntervals("EscalateNotifications", time.Minute, models.NotificationManager.Escalate)
+	cron.AddJobAtIntervals("ReleaseDeferredNotifications", time.Minute, models.NotificationManager.ReleaseDeferred)
+	// 汇总普通通知及超出限速的通知
+	digestInterval := time.Duration(opts.DigestIntervalMinutes) * time.Minute
+	if digestInterval <= 0 {
+		digestInterval = time.Duration(opts.RateLimitWindowSeconds) * time.Second
+	}
+	if digestInterval <= 0 {
+		digestInterval = time.Minute
+	}
+	cron.AddJobAtIntervals("SendNotificationDigests", digestInterval, models.NotificationManager.SendDigests)
 	cron.Start()
 
 	app.ServeForever(applicaion, baseOpts)
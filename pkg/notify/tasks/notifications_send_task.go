@@ -50,17 +50,24 @@ func (self *NotificationSendTask) OnInit(ctx context.Context, obj db.IStandalone
 	}
 	notification.SetStatus(self.UserCred, apis.NOTIFICATION_STATUS_SENDING, "")
 
+	// dedup, rate limit and digest
+	rnPtrs := make([]*models.SReceiverNotification, len(rns))
+	for i := range rns {
+		rnPtrs[i] = &rns[i]
+	}
+	rnPtrs = notification.Throttle(ctx, rnPtrs)
+
 	// split rns
 	var (
 		rnsWithReceiver    []*models.SReceiverNotification
 		rnsWithoutReceiver []*models.SReceiverNotification
 	)
 
-	for i := range rns {
-		if rns[i].ReceiverID == models.ReceiverIdDefault {
-			rnsWithoutReceiver = append(rnsWithoutReceiver, &rns[i])
+	for _, rn := range rnPtrs {
+		if rn.ReceiverID == models.ReceiverIdDefault {
+			rnsWithoutReceiver = append(rnsWithoutReceiver, rn)
 		} else {
-			rnsWithReceiver = append(rnsWithReceiver, &rns[i])
+			rnsWithReceiver = append(rnsWithReceiver, rn)
 		}
 	}
 
@@ -0,0 +1,78 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"fmt"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// NumaPredicate 要求 strict NUMA 策略的虚拟机能完整放入主机的单个 NUMA 节点,
+// 节点内未被独占绑定的 CPU 和剩余内存都需满足需求
+type NumaPredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *NumaPredicate) Name() string {
+	return "host_numa"
+}
+
+func (p *NumaPredicate) Clone() core.FitPredicate {
+	return &NumaPredicate{}
+}
+
+func (p *NumaPredicate) PreExecute(u *core.Unit, cs []core.Candidater) (bool, error) {
+	d := u.SchedData()
+	if d.ServerConfigs == nil || d.NumaPolicy != compute.GUEST_NUMA_POLICY_STRICT {
+		return false, nil
+	}
+	return d.Ncpu > 0, nil
+}
+
+func (p *NumaPredicate) Execute(u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+	d := u.SchedData()
+
+	nodes := c.Getter().NumaFree()
+	if len(nodes) == 0 {
+		h.Exclude2("numa_topology", "unknown", "reported by host")
+		return h.GetResult()
+	}
+	capacity := int64(0)
+	maxFreeCpu, maxFreeMem := 0, 0
+	for _, node := range nodes {
+		cnt := node.FreeCpuCount / d.Ncpu
+		if d.Memory > 0 && node.FreeMemSizeMb/d.Memory < cnt {
+			cnt = node.FreeMemSizeMb / d.Memory
+		}
+		if cnt > 0 {
+			capacity += int64(cnt)
+		}
+		if node.FreeCpuCount > maxFreeCpu {
+			maxFreeCpu, maxFreeMem = node.FreeCpuCount, node.FreeMemSizeMb
+		}
+	}
+	if capacity == 0 {
+		h.Exclude2("numa_node_free",
+			fmt.Sprintf("cpu %d, memory %dM", maxFreeCpu, maxFreeMem),
+			fmt.Sprintf("cpu %d, memory %dM", d.Ncpu, d.Memory))
+		return h.GetResult()
+	}
+	h.SetCapacity(capacity)
+	return h.GetResult()
+}
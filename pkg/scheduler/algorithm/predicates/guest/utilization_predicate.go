@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"fmt"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// UtilizationPredicate 过滤监控数据中实际利用率超过上限的主机，未配置上限时不生效，
+// 无监控数据的主机不会被过滤
+type UtilizationPredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *UtilizationPredicate) Name() string {
+	return "host_utilization"
+}
+
+func (p *UtilizationPredicate) Clone() core.FitPredicate {
+	return &UtilizationPredicate{}
+}
+
+func (p *UtilizationPredicate) PreExecute(u *core.Unit, cs []core.Candidater) (bool, error) {
+	opts := o.GetOptions()
+	if opts.HostUtilizationMaxCpuPercent <= 0 && opts.HostUtilizationMaxMemoryPercent <= 0 &&
+		opts.HostUtilizationMaxDiskIoPercent <= 0 && opts.HostUtilizationMaxNetworkPercent <= 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *UtilizationPredicate) Execute(u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+
+	util := c.Getter().Utilization()
+	if util == nil {
+		return h.GetResult()
+	}
+	opts := o.GetOptions()
+	for _, m := range []struct {
+		name string
+		val  *float64
+		max  float64
+	}{
+		{"cpu_utilization", util.Cpu, opts.HostUtilizationMaxCpuPercent},
+		{"memory_utilization", util.Memory, opts.HostUtilizationMaxMemoryPercent},
+		{"disk_io_utilization", util.DiskIo, opts.HostUtilizationMaxDiskIoPercent},
+		{"network_utilization", util.Network, opts.HostUtilizationMaxNetworkPercent},
+	} {
+		if m.max > 0 && m.val != nil && *m.val > m.max {
+			h.Exclude2(m.name, fmt.Sprintf("%.1f%%", *m.val), fmt.Sprintf("<= %.1f%%", m.max))
+		}
+	}
+	return h.GetResult()
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"yunion.io/x/onecloud/pkg/scheduler/api"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+	"yunion.io/x/onecloud/pkg/scheduler/test/mock"
+)
+
+func newFloat(v float64) *float64 {
+	return &v
+}
+
+func TestUtilizationPredicateExecute(t *testing.T) {
+	opts := o.GetOptions()
+	saved := *opts
+	defer func() { *opts = saved }()
+	opts.HostUtilizationMaxCpuPercent = 80
+	opts.HostUtilizationMaxMemoryPercent = 90
+	opts.HostUtilizationMaxDiskIoPercent = 0
+	opts.HostUtilizationMaxNetworkPercent = 50
+
+	cases := []struct {
+		name    string
+		util    *core.HostUtilization
+		ok      bool
+		reasons int
+	}{
+		{
+			name: "no metrics",
+			util: nil,
+			ok:   true,
+		},
+		{
+			name: "nil metrics",
+			util: &core.HostUtilization{},
+			ok:   true,
+		},
+		{
+			name: "below limits",
+			util: &core.HostUtilization{Cpu: newFloat(80), Memory: newFloat(50), Network: newFloat(10)},
+			ok:   true,
+		},
+		{
+			name: "no limit of disk io",
+			util: &core.HostUtilization{DiskIo: newFloat(100)},
+			ok:   true,
+		},
+		{
+			name:    "cpu exceeds",
+			util:    &core.HostUtilization{Cpu: newFloat(80.5), Memory: newFloat(50)},
+			reasons: 1,
+		},
+		{
+			name:    "memory and network exceed",
+			util:    &core.HostUtilization{Memory: newFloat(95), Network: newFloat(60)},
+			reasons: 2,
+		},
+	}
+	for _, c := range cases {
+		ctrl := gomock.NewController(t)
+		getter := mock.NewMockCandidatePropertyGetter(ctrl)
+		getter.EXPECT().Utilization().Return(c.util).AnyTimes()
+		candidate := mock.NewMockCandidater(ctrl)
+		candidate.EXPECT().Getter().Return(getter).AnyTimes()
+		candidate.EXPECT().IndexKey().Return("host1").AnyTimes()
+
+		p := &UtilizationPredicate{}
+		ok, reasons, err := p.Execute(core.NewScheduleUnit(&api.SchedInfo{}, nil), candidate)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if ok != c.ok || len(reasons) != c.reasons {
+			t.Errorf("%s: want (%v, %d reasons) got (%v, %v)", c.name, c.ok, c.reasons, ok, reasons)
+		}
+		ctrl.Finish()
+	}
+}
+
+func TestUtilizationPredicatePreExecute(t *testing.T) {
+	opts := o.GetOptions()
+	saved := *opts
+	defer func() { *opts = saved }()
+
+	opts.HostUtilizationMaxCpuPercent = 0
+	opts.HostUtilizationMaxMemoryPercent = 0
+	opts.HostUtilizationMaxDiskIoPercent = 0
+	opts.HostUtilizationMaxNetworkPercent = 0
+	if ok, _ := (&UtilizationPredicate{}).PreExecute(nil, nil); ok {
+		t.Errorf("predicate should be skipped without limits")
+	}
+	opts.HostUtilizationMaxDiskIoPercent = 70
+	if ok, _ := (&UtilizationPredicate{}).PreExecute(nil, nil); !ok {
+		t.Errorf("predicate should be executed with disk io limit")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// UtilizationPriority 按监控数据中主机的实际利用率打分，加权利用率越低得分越高，
+// 无监控数据的主机不打分
+type UtilizationPriority struct {
+	priorities.BasePriority
+}
+
+func (p *UtilizationPriority) Name() string {
+	return "host_utilization"
+}
+
+func (p *UtilizationPriority) Clone() core.Priority {
+	return &UtilizationPriority{}
+}
+
+func (p *UtilizationPriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := priorities.NewPriorityHelper(p, u, c)
+
+	util := c.Getter().Utilization()
+	if util == nil {
+		return h.GetResult()
+	}
+	if val, ok := utilizationScore(util, getUtilizationWeights(u)); ok {
+		h.SetScore(val)
+	}
+	return h.GetResult()
+}
+
+func (p *UtilizationPriority) ScoreIntervals() score.Intervals {
+	return score.NewIntervals(10, 40, 70)
+}
+
+func getUtilizationWeights(u *core.Unit) computeapi.SchedUtilizationWeights {
+	if w := u.SchedData().UtilizationWeights; w != nil {
+		return *w
+	}
+	opts := o.GetOptions()
+	return computeapi.SchedUtilizationWeights{
+		Cpu:     opts.HostUtilizationCpuWeight,
+		Memory:  opts.HostUtilizationMemoryWeight,
+		DiskIo:  opts.HostUtilizationDiskIoWeight,
+		Network: opts.HostUtilizationNetworkWeight,
+	}
+}
+
+// utilizationScore 返回 100 - 加权平均利用率，只统计有数据且权重大于0的指标
+func utilizationScore(util *core.HostUtilization, weights computeapi.SchedUtilizationWeights) (int, bool) {
+	var sum, totalWeight float64
+	for _, m := range []struct {
+		val    *float64
+		weight float64
+	}{
+		{util.Cpu, weights.Cpu},
+		{util.Memory, weights.Memory},
+		{util.DiskIo, weights.DiskIo},
+		{util.Network, weights.Network},
+	} {
+		if m.val == nil || m.weight <= 0 {
+			continue
+		}
+		val := *m.val
+		if val > 100 {
+			val = 100
+		} else if val < 0 {
+			val = 0
+		}
+		sum += val * m.weight
+		totalWeight += m.weight
+	}
+	if totalWeight == 0 {
+		return 0, false
+	}
+	return int(100 - sum/totalWeight), true
+}
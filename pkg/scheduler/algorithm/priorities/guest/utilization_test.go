@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"testing"
+
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+func newFloat(v float64) *float64 {
+	return &v
+}
+
+func TestUtilizationScore(t *testing.T) {
+	equal := computeapi.SchedUtilizationWeights{Cpu: 1, Memory: 1, DiskIo: 1, Network: 1}
+	cases := []struct {
+		name    string
+		util    *core.HostUtilization
+		weights computeapi.SchedUtilizationWeights
+		want    int
+		ok      bool
+	}{
+		{
+			name:    "all metrics equal weights",
+			util:    &core.HostUtilization{Cpu: newFloat(20), Memory: newFloat(40), DiskIo: newFloat(60), Network: newFloat(80)},
+			weights: equal,
+			want:    50,
+			ok:      true,
+		},
+		{
+			name:    "nil metrics skipped",
+			util:    &core.HostUtilization{Cpu: newFloat(30)},
+			weights: equal,
+			want:    70,
+			ok:      true,
+		},
+		{
+			name:    "no metrics",
+			util:    &core.HostUtilization{},
+			weights: equal,
+		},
+		{
+			name:    "zero weights",
+			util:    &core.HostUtilization{Cpu: newFloat(30), Memory: newFloat(50)},
+			weights: computeapi.SchedUtilizationWeights{},
+		},
+		{
+			name:    "zero weight metric skipped",
+			util:    &core.HostUtilization{Cpu: newFloat(90), Memory: newFloat(10)},
+			weights: computeapi.SchedUtilizationWeights{Memory: 1},
+			want:    90,
+			ok:      true,
+		},
+		{
+			name:    "negative weight skipped",
+			util:    &core.HostUtilization{Cpu: newFloat(90), Memory: newFloat(10)},
+			weights: computeapi.SchedUtilizationWeights{Cpu: -1, Memory: 1},
+			want:    90,
+			ok:      true,
+		},
+		{
+			name:    "weighted",
+			util:    &core.HostUtilization{Cpu: newFloat(10), Memory: newFloat(70)},
+			weights: computeapi.SchedUtilizationWeights{Cpu: 2, Memory: 1},
+			want:    70,
+			ok:      true,
+		},
+		{
+			name:    "out of range clamped",
+			util:    &core.HostUtilization{Cpu: newFloat(150), Memory: newFloat(-50)},
+			weights: equal,
+			want:    50,
+			ok:      true,
+		},
+	}
+	for _, c := range cases {
+		got, ok := utilizationScore(c.util, c.weights)
+		if ok != c.ok || got != c.want {
+			t.Errorf("%s: want (%d, %v) got (%d, %v)", c.name, c.want, c.ok, got, ok)
+		}
+	}
+}
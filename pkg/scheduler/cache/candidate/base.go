@@ -163,6 +163,14 @@ func (b baseHostGetter) OvnCapable() bool {
 	return false
 }
 
+func (b baseHostGetter) Utilization() *core.HostUtilization {
+	return nil
+}
+
+func (b baseHostGetter) NumaFree() []core.HostNumaFree {
+	return nil
+}
+
 func (b baseHostGetter) ResourceType() string {
 	return reviseResourceType(b.h.ResourceType)
 }
@@ -97,6 +97,14 @@ func (h *hostGetter) OvnCapable() bool {
 	return len(h.h.OvnVersion) > 0
 }
 
+func (h *hostGetter) Utilization() *core.HostUtilization {
+	return h.h.Utilization
+}
+
+func (h *hostGetter) NumaFree() []core.HostNumaFree {
+	return h.h.NumaFree
+}
+
 type HostDesc struct {
 	*BaseHostDesc
 
@@ -127,6 +135,12 @@ type HostDesc struct {
 	IOBoundCount int64    `json:"io_bound_count"`
 	IOLoad       *float64 `json:"io_load"`
 
+	// 监控数据中的实际利用率
+	Utilization *core.HostUtilization `json:"utilization"`
+
+	// 各NUMA节点可供独占绑定的资源, 未上报拓扑时为空
+	NumaFree []core.HostNumaFree `json:"numa_free"`
+
 	// server
 	GuestCount         int64 `json:"guest_count"`
 	CreatingGuestCount int64 `json:"creating_guest_count"`
@@ -244,7 +258,8 @@ type HostBuilder struct {
 	//diskStats           []models.StorageCapacity
 	// isolatedDevicesDict map[string][]interface{}
 
-	cpuIOLoads map[string]map[string]float64
+	cpuIOLoads   map[string]map[string]float64
+	utilizations map[string]*core.HostUtilization
 
 	schedtags []computemodels.SSchedtag
 	zoneSkus  map[string][]computemodels.SServerSku
@@ -450,6 +465,7 @@ func (b *HostBuilder) init(ids []string) error {
 	setFuncs := []func(){
 		func() { b.setHosts(ids, errMessageChannel) },
 		func() { b.setSchedtags(ids, errMessageChannel) },
+		func() { b.utilizations = hostUtilizationCache.get() },
 		func() {
 			b.setGuests(ids, errMessageChannel)
 			b.setIsolatedDevs(ids, errMessageChannel)
@@ -816,6 +832,8 @@ func (b *HostBuilder) buildOne(host *computemodels.SHost) (interface{}, error) {
 		//b.fillResidentGroups,
 		b.fillMetadata,
 		b.fillCPUIOLoads,
+		b.fillUtilization,
+		b.fillNumaFree,
 	}
 
 	for _, f := range fillFuncs {
@@ -1051,6 +1069,13 @@ func (b *HostBuilder) fillCPUIOLoads(desc *HostDesc, host *computemodels.SHost)
 	return nil
 }
 
+func (b *HostBuilder) fillUtilization(desc *HostDesc, host *computemodels.SHost) error {
+	if b.utilizations != nil {
+		desc.Utilization = b.utilizations[host.Id]
+	}
+	return nil
+}
+
 func (b *HostBuilder) loadByName(hostID, name string) *float64 {
 	if b.cpuIOLoads == nil {
 		return nil
@@ -1065,3 +1090,53 @@ func (b *HostBuilder) loadByName(hostID, name string) *float64 {
 	}
 	return nil
 }
+
+func (b *HostBuilder) fillNumaFree(desc *HostDesc, host *computemodels.SHost) error {
+	if host.Topology == nil || len(host.Topology.Nodes) == 0 {
+		return nil
+	}
+	freeCpus := make(map[int]int)
+	freeMems := make(map[int]int)
+	for _, node := range host.Topology.Nodes {
+		freeCpus[node.NodeId] = len(node.Cpus)
+		freeMems[node.NodeId] = node.MemSizeMb
+	}
+	// 尚未在本机完成绑定的虚拟机, 按宿主机分配策略估算占用剩余最多的节点
+	unpinned := []computemodels.SGuest{}
+	for _, gst := range b.hostGuests[host.Id] {
+		guest := gst.(computemodels.SGuest)
+		if guest.NumaPolicy != computeapi.GUEST_NUMA_POLICY_STRICT {
+			continue
+		}
+		if !IsGuestRunning(guest) && !IsGuestCreating(guest) {
+			continue
+		}
+		pin := guest.CpuNumaPin
+		if pin == nil || pin.HostId != host.Id {
+			unpinned = append(unpinned, guest)
+			continue
+		}
+		if _, ok := freeCpus[pin.NodeId]; ok {
+			freeCpus[pin.NodeId] -= len(pin.VcpuPin)
+			freeMems[pin.NodeId] -= pin.MemSizeMb
+		}
+	}
+	for _, guest := range unpinned {
+		nodeId, maxFree := -1, -1
+		for _, node := range host.Topology.Nodes {
+			if freeCpus[node.NodeId] > maxFree {
+				nodeId, maxFree = node.NodeId, freeCpus[node.NodeId]
+			}
+		}
+		freeCpus[nodeId] -= guest.VcpuCount
+		freeMems[nodeId] -= guest.VmemSize
+	}
+	for _, node := range host.Topology.Nodes {
+		desc.NumaFree = append(desc.NumaFree, core.HostNumaFree{
+			NodeId:        node.NodeId,
+			FreeCpuCount:  freeCpus[node.NodeId],
+			FreeMemSizeMb: freeMems[node.NodeId],
+		})
+	}
+	return nil
+}
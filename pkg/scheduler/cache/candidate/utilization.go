@@ -0,0 +1,195 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package candidate
+
+import (
+	"fmt"
+	gosync "sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	identityapi "yunion.io/x/onecloud/pkg/apis/identity"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+)
+
+type sHostUtilizationCache struct {
+	lock       gosync.Mutex
+	expireAt   time.Time
+	refreshing bool
+	data       map[string]*core.HostUtilization
+	fetch      func() (map[string]*core.HostUtilization, error)
+}
+
+var hostUtilizationCache = &sHostUtilizationCache{fetch: fetchHostUtilizations}
+
+// 缓存过期后在后台刷新，刷新完成前返回旧数据，不阻塞调度；查询失败时保留旧数据直到下次过期
+func (c *sHostUtilizationCache) get() map[string]*core.HostUtilization {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.refreshing && !time.Now().Before(c.expireAt) {
+		c.refreshing = true
+		go c.refresh()
+	}
+	return c.data
+}
+
+func (c *sHostUtilizationCache) refresh() {
+	data, err := c.fetch()
+	ttl, perr := time.ParseDuration(o.GetOptions().HostUtilizationCacheTTL)
+	if perr != nil || ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.refreshing = false
+	c.expireAt = time.Now().Add(ttl)
+	if err != nil {
+		log.Warningf("fetch host utilizations from tsdb: %v", err)
+		return
+	}
+	c.data = data
+}
+
+type sUtilizationQuery struct {
+	sql     string
+	columns []string
+	set     func(u *core.HostUtilization, vals []float64)
+}
+
+func getUtilizationQueries(dbName string, windowSec int64) []sUtilizationQuery {
+	bandwidth := float64(o.GetOptions().HostUtilizationNetBandwidthMbps) * 1000 * 1000
+	return []sUtilizationQuery{
+		{
+			sql: fmt.Sprintf(`SELECT PERCENTILE("usage_active", 95) AS "cpu" FROM "%s".."cpu" WHERE time > now() - %ds AND "cpu" = 'cpu-total' GROUP BY "host_id"`,
+				dbName, windowSec),
+			columns: []string{"cpu"},
+			set: func(u *core.HostUtilization, vals []float64) {
+				u.Cpu = &vals[0]
+			},
+		},
+		{
+			sql: fmt.Sprintf(`SELECT PERCENTILE("used_percent", 95) AS "memory" FROM "%s".."mem" WHERE time > now() - %ds GROUP BY "host_id"`,
+				dbName, windowSec),
+			columns: []string{"memory"},
+			set: func(u *core.HostUtilization, vals []float64) {
+				u.Memory = &vals[0]
+			},
+		},
+		{
+			// io_time为累计繁忙毫秒数，每秒增量除以10即为繁忙百分比
+			sql: fmt.Sprintf(`SELECT PERCENTILE("util", 95) AS "disk_io" FROM (SELECT NON_NEGATIVE_DERIVATIVE(MAX("io_time"), 1s) / 10 AS "util" FROM "%s".."diskio" WHERE time > now() - %ds GROUP BY time(1m), "host_id", "name") GROUP BY "host_id"`,
+				dbName, windowSec),
+			columns: []string{"disk_io"},
+			set: func(u *core.HostUtilization, vals []float64) {
+				u.DiskIo = &vals[0]
+			},
+		},
+		{
+			sql: fmt.Sprintf(`SELECT PERCENTILE("recv", 95) AS "recv", PERCENTILE("sent", 95) AS "sent" FROM (SELECT NON_NEGATIVE_DERIVATIVE(MAX("bytes_recv"), 1s) * 8 AS "recv", NON_NEGATIVE_DERIVATIVE(MAX("bytes_sent"), 1s) * 8 AS "sent" FROM "%s".."net" WHERE time > now() - %ds GROUP BY time(1m), "host_id", "interface") GROUP BY "host_id"`,
+				dbName, windowSec),
+			columns: []string{"recv", "sent"},
+			set: func(u *core.HostUtilization, vals []float64) {
+				if bandwidth <= 0 {
+					return
+				}
+				bps := vals[0]
+				if vals[1] > bps {
+					bps = vals[1]
+				}
+				util := bps / bandwidth * 100
+				u.Network = &util
+			},
+		},
+	}
+}
+
+func fetchHostUtilizations() (map[string]*core.HostUtilization, error) {
+	opts := o.GetOptions()
+	epType := opts.SessionEndpointType
+	if len(epType) == 0 {
+		epType = identityapi.EndpointInterfaceInternal
+	}
+	url, err := auth.GetServiceURL(apis.SERVICE_TYPE_INFLUXDB, opts.Region, "", epType)
+	if err != nil {
+		return nil, errors.Wrap(err, "get influxdb service url")
+	}
+	window, err := time.ParseDuration(opts.HostUtilizationWindow)
+	if err != nil || window <= 0 {
+		window = 30 * time.Minute
+	}
+	db := influxdb.NewInfluxdb(url)
+	ret := make(map[string]*core.HostUtilization)
+	for _, q := range getUtilizationQueries(opts.HostUtilizationDatabase, int64(window.Seconds())) {
+		results, err := db.Query(q.sql)
+		if err != nil {
+			return nil, errors.Wrapf(err, "query %s", q.sql)
+		}
+		if len(results) == 0 {
+			continue
+		}
+		for _, series := range results[0] {
+			if series.Tags == nil || len(series.Values) == 0 {
+				continue
+			}
+			hostId, _ := series.Tags.GetString("host_id")
+			if len(hostId) == 0 {
+				continue
+			}
+			vals, ok := parseUtilizationValues(series.Columns, series.Values[0], q.columns)
+			if !ok {
+				continue
+			}
+			u, ok := ret[hostId]
+			if !ok {
+				u = &core.HostUtilization{}
+				ret[hostId] = u
+			}
+			q.set(u, vals)
+		}
+	}
+	return ret, nil
+}
+
+func parseUtilizationValues(columns []string, row []jsonutils.JSONObject, want []string) ([]float64, bool) {
+	vals := make([]float64, len(want))
+	for i, name := range want {
+		found := false
+		for j, col := range columns {
+			if col != name || j >= len(row) || row[j] == nil || row[j] == jsonutils.JSONNull {
+				continue
+			}
+			val, err := row[j].Float()
+			if err != nil {
+				return nil, false
+			}
+			vals[i] = val
+			found = true
+			break
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return vals, true
+}
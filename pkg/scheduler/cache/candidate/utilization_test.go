@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package candidate
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+func TestParseUtilizationValues(t *testing.T) {
+	cases := []struct {
+		name    string
+		columns []string
+		row     []jsonutils.JSONObject
+		want    []string
+		vals    []float64
+		ok      bool
+	}{
+		{
+			name:    "single column",
+			columns: []string{"time", "cpu"},
+			row:     []jsonutils.JSONObject{jsonutils.NewInt(0), jsonutils.NewFloat64(12.5)},
+			want:    []string{"cpu"},
+			vals:    []float64{12.5},
+			ok:      true,
+		},
+		{
+			name:    "multiple columns in other order",
+			columns: []string{"time", "sent", "recv"},
+			row:     []jsonutils.JSONObject{jsonutils.NewInt(0), jsonutils.NewFloat64(2), jsonutils.NewInt(3)},
+			want:    []string{"recv", "sent"},
+			vals:    []float64{3, 2},
+			ok:      true,
+		},
+		{
+			name:    "missing column",
+			columns: []string{"time", "recv"},
+			row:     []jsonutils.JSONObject{jsonutils.NewInt(0), jsonutils.NewFloat64(2)},
+			want:    []string{"recv", "sent"},
+		},
+		{
+			name:    "null value",
+			columns: []string{"time", "cpu"},
+			row:     []jsonutils.JSONObject{jsonutils.NewInt(0), jsonutils.JSONNull},
+			want:    []string{"cpu"},
+		},
+		{
+			name:    "short row",
+			columns: []string{"time", "cpu"},
+			row:     []jsonutils.JSONObject{jsonutils.NewInt(0)},
+			want:    []string{"cpu"},
+		},
+		{
+			name:    "not a number",
+			columns: []string{"time", "cpu"},
+			row:     []jsonutils.JSONObject{jsonutils.NewInt(0), jsonutils.NewString("high")},
+			want:    []string{"cpu"},
+		},
+	}
+	for _, c := range cases {
+		vals, ok := parseUtilizationValues(c.columns, c.row, c.want)
+		if ok != c.ok {
+			t.Errorf("%s: want ok %v got %v", c.name, c.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if len(vals) != len(c.vals) {
+			t.Errorf("%s: want %v got %v", c.name, c.vals, vals)
+			continue
+		}
+		for i := range vals {
+			if vals[i] != c.vals[i] {
+				t.Errorf("%s: want %v got %v", c.name, c.vals, vals)
+				break
+			}
+		}
+	}
+}
+
+func TestHostUtilizationCacheServesStale(t *testing.T) {
+	cpu := 10.0
+	stale := map[string]*core.HostUtilization{"host1": {Cpu: &cpu}}
+	fresh := map[string]*core.HostUtilization{"host2": {Cpu: &cpu}}
+	release := make(chan struct{})
+	fetched := make(chan struct{}, 1)
+	c := &sHostUtilizationCache{
+		data: stale,
+		fetch: func() (map[string]*core.HostUtilization, error) {
+			<-release
+			fetched <- struct{}{}
+			return fresh, nil
+		},
+	}
+
+	// the refresh blocks in background, get returns stale data at once
+	for i := 0; i < 2; i++ {
+		if data := c.get(); data["host1"] == nil {
+			t.Fatalf("want stale data during refresh, got %v", data)
+		}
+	}
+	close(release)
+	<-fetched
+	for i := 0; i < 100; i++ {
+		if data := c.get(); data["host2"] != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("cache is not refreshed")
+}
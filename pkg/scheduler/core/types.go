@@ -110,6 +110,9 @@ type CandidatePropertyGetter interface {
 
 	GetPendingUsage() *schedmodels.SPendingUsage
 
+	Utilization() *HostUtilization
+	NumaFree() []HostNumaFree
+
 	// isloatedDevices
 	UnusedIsolatedDevices() []*IsolatedDeviceDesc
 	UnusedIsolatedDevicesByType(devType string) []*IsolatedDeviceDesc
@@ -122,6 +125,21 @@ type CandidatePropertyGetter interface {
 	db.IResource
 }
 
+// HostUtilization 主机近期实际资源利用率，取时间窗口内的p95，单位为百分比，无监控数据时为nil
+type HostUtilization struct {
+	Cpu     *float64 `json:"cpu"`
+	Memory  *float64 `json:"memory"`
+	DiskIo  *float64 `json:"disk_io"`
+	Network *float64 `json:"network"`
+}
+
+// HostNumaFree 主机单个NUMA节点上未被独占绑定的CPU数量和剩余内存
+type HostNumaFree struct {
+	NodeId        int `json:"node_id"`
+	FreeCpuCount  int `json:"free_cpu_count"`
+	FreeMemSizeMb int `json:"free_mem_size_mb"`
+}
+
 // Candidater replace host Candidate resource info
 type Candidater interface {
 	Getter() CandidatePropertyGetter
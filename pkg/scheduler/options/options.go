@@ -89,6 +89,21 @@ type SchedOptions struct {
 	WireDBCachePeriod string `help:"Wire database cache period" default:"5m"`
 
 	SkuRefreshInterval string `help:"Server SKU refresh interval" default:"12h"`
+
+	// host utilization options
+	HostUtilizationWindow           string  `help:"Time window of host utilization samples queried from monitor TSDB" default:"30m"`
+	HostUtilizationCacheTTL         string  `help:"Host utilization cache TTL" default:"1m"`
+	HostUtilizationDatabase         string  `help:"TSDB database of host metrics" default:"telegraf"`
+	HostUtilizationNetBandwidthMbps int64   `help:"Reference host network bandwidth in Mbps used to calculate network utilization" default:"10000"`
+	HostUtilizationCpuWeight        float64 `help:"Default weight of host p95 cpu utilization" default:"1"`
+	HostUtilizationMemoryWeight     float64 `help:"Default weight of host p95 memory utilization" default:"1"`
+	HostUtilizationDiskIoWeight     float64 `help:"Default weight of host p95 disk io utilization" default:"0.5"`
+	HostUtilizationNetworkWeight    float64 `help:"Default weight of host p95 network utilization" default:"0.5"`
+
+	HostUtilizationMaxCpuPercent     float64 `help:"Exclude hosts whose p95 cpu utilization exceeds this percent, 0 means no limit" default:"0"`
+	HostUtilizationMaxMemoryPercent  float64 `help:"Exclude hosts whose p95 memory utilization exceeds this percent, 0 means no limit" default:"0"`
+	HostUtilizationMaxDiskIoPercent  float64 `help:"Exclude hosts whose p95 disk io utilization exceeds this percent, 0 means no limit" default:"0"`
+	HostUtilizationMaxNetworkPercent float64 `help:"Exclude hosts whose p95 network utilization exceeds this percent, 0 means no limit" default:"0"`
 }
 
 var (
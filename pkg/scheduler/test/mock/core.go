@@ -417,6 +417,20 @@ func (mr *MockCandidatePropertyGetterMockRecorder) Networks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Networks", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).Networks))
 }
 
+// NumaFree mocks base method
+func (m *MockCandidatePropertyGetter) NumaFree() []core.HostNumaFree {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumaFree")
+	ret0, _ := ret[0].([]core.HostNumaFree)
+	return ret0
+}
+
+// NumaFree indicates an expected call of NumaFree
+func (mr *MockCandidatePropertyGetterMockRecorder) NumaFree() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumaFree", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).NumaFree))
+}
+
 // OvnCapable mocks base method
 func (m *MockCandidatePropertyGetter) OvnCapable() bool {
 	m.ctrl.T.Helper()
@@ -683,6 +697,20 @@ func (mr *MockCandidatePropertyGetterMockRecorder) UnusedIsolatedDevicesByVendor
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnusedIsolatedDevicesByVendorModel", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).UnusedIsolatedDevicesByVendorModel), arg0)
 }
 
+// Utilization mocks base method
+func (m *MockCandidatePropertyGetter) Utilization() *core.HostUtilization {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Utilization")
+	ret0, _ := ret[0].(*core.HostUtilization)
+	return ret0
+}
+
+// Utilization indicates an expected call of Utilization
+func (mr *MockCandidatePropertyGetterMockRecorder) Utilization() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Utilization", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).Utilization))
+}
+
 // Zone mocks base method
 func (m *MockCandidatePropertyGetter) Zone() *models.SZone {
 	m.ctrl.T.Helper()
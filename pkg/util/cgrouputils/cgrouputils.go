@@ -508,11 +508,13 @@ type CGroupCPUSetTask struct {
 	*CGroupTask
 
 	cpuset string
+	mems   string
 }
 
 const (
-	CPUSET_CPUS = "cpuset.cpus"
-	CPUSET_MEMS = "cpuset.mems"
+	CPUSET_CPUS           = "cpuset.cpus"
+	CPUSET_MEMS           = "cpuset.mems"
+	CPUSET_MEMORY_MIGRATE = "cpuset.memory_migrate"
 )
 
 func (c *CGroupCPUSetTask) Module() string {
@@ -520,6 +522,10 @@ func (c *CGroupCPUSetTask) Module() string {
 }
 
 func (c *CGroupCPUSetTask) GetStaticConfig() map[string]string {
+	if len(c.mems) > 0 {
+		// 绑定内存节点时同时迁移已分配的内存页
+		return map[string]string{CPUSET_MEMS: c.mems, CPUSET_MEMORY_MIGRATE: "1"}
+	}
 	return map[string]string{CPUSET_MEMS: GetRootParam(c.Module(), CPUSET_MEMS, "")}
 }
 
@@ -536,6 +542,13 @@ func NewCGroupCPUSetTask(pid string, coreNum int, cpuset string) CGroupCPUSetTas
 	return task
 }
 
+func NewCGroupCPUSetMemsTask(pid string, cpuset string, mems string) CGroupCPUSetTask {
+	task := NewCGroupCPUSetTask(pid, 0, cpuset)
+	task.mems = mems
+	task.SetHand(&task)
+	return task
+}
+
 func Init() bool {
 	for _, hand := range []ICGroupTask{&CGroupTask{}, &CGroupCPUTask{}, &CGroupIOTask{}} {
 		if !hand.init() {
@@ -573,7 +586,7 @@ func CgroupDestroy(pid string) bool {
 		&CGroupCPUTask{&CGroupTask{}},
 		&CGroupIOTask{&CGroupTask{}},
 		&CGroupMemoryTask{&CGroupTask{}},
-		&CGroupCPUSetTask{&CGroupTask{}, ""},
+		&CGroupCPUSetTask{CGroupTask: &CGroupTask{}},
 		&CGroupIOHardlimitTask{CGroupIOTask: &CGroupIOTask{&CGroupTask{}}},
 	}
 	for _, hand := range tasks {
@@ -38,7 +38,8 @@ var (
 	utilHistory map[string][]float64
 )
 
-func RebalanceProcesses(pids []string) {
+// RebalanceProcesses 将进程均衡到各物理 CPU 上, reservedCpus 为虚拟机独占的 CPU, 不参与均衡
+func RebalanceProcesses(pids []string, reservedCpus []int) {
 	rebalanceProcessesLock.Lock()
 	if rebalanceProcessesRunning {
 		rebalanceProcessesLock.Unlock()
@@ -48,14 +49,14 @@ func RebalanceProcesses(pids []string) {
 		rebalanceProcessesLock.Unlock()
 	}
 
-	err := rebalanceProcesses(pids)
+	err := rebalanceProcesses(pids, reservedCpus)
 	if err != nil {
 		log.Errorf("rebalance processes error: %s", err)
 	}
 	rebalanceProcessesRunning = false
 }
 
-func rebalanceProcesses(pids []string) error {
+func rebalanceProcesses(pids []string, reservedCpus []int) error {
 	FetchHistoryUtil()
 
 	cpu, err := GetSystemCpu()
@@ -63,18 +64,19 @@ func rebalanceProcesses(pids []string) error {
 		log.Errorln(err)
 		return err
 	}
+	cpu = cpu.Exclude(reservedCpus)
 	cpuCount := cpu.GetPhysicalNum()
 	if cpuCount <= 1 {
 		return nil
 	}
 
-	info, err := GetProcessesCpuinfo(pids)
+	info, err := getProcessesCpuinfo(pids, cpu)
 	if err != nil {
 		return err
 	}
 	ret := ArrangeProcesses(info, cpuCount)
 	if len(ret) != 0 {
-		CommitProcessesCpuset(ret)
+		commitProcessesCpuset(ret, cpu)
 	}
 
 	SaveHistoryUtil()
@@ -82,10 +84,19 @@ func rebalanceProcesses(pids []string) error {
 }
 
 func CommitProcessesCpuset(cpus []CPULoad) {
-	for i, cpu := range cpus {
-		for _, proc := range cpu.Processes {
+	cpu, err := GetSystemCpu()
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	commitProcessesCpuset(cpus, cpu)
+}
+
+func commitProcessesCpuset(loads []CPULoad, cpu *CPU) {
+	for i, load := range loads {
+		for _, proc := range load.Processes {
 			if proc.Cpuset == nil || *proc.Cpuset != i {
-				CommitProcessCpuset(proc, i)
+				commitProcessCpuset(proc, i, cpu)
 			}
 		}
 	}
@@ -93,6 +104,10 @@ func CommitProcessesCpuset(cpus []CPULoad) {
 
 func CommitProcessCpuset(proc *ProcessCPUinfo, idx int) {
 	cpu, _ := GetSystemCpu()
+	commitProcessCpuset(proc, idx, cpu)
+}
+
+func commitProcessCpuset(proc *ProcessCPUinfo, idx int, cpu *CPU) {
 	sets := cpu.GetCpuset(idx)
 	if len(sets) > 0 {
 		cpuset := NewCGroupCPUSetTask(strconv.Itoa(proc.Pid), 0, sets)
@@ -143,7 +158,7 @@ func ArrangeProcesses(infos []*ProcessCPUinfo, cpuCount int) []CPULoad {
 
 	for _, info := range infos {
 		procs.AddProcess(info)
-		if info.Cpuset != nil && *info.Cpuset < cpuCount {
+		if info.Cpuset != nil && *info.Cpuset >= 0 && *info.Cpuset < cpuCount {
 			cpus[*info.Cpuset].AddProcess(info)
 		} else {
 			newProc = true
@@ -166,6 +181,15 @@ func ArrangeProcesses(infos []*ProcessCPUinfo, cpuCount int) []CPULoad {
 }
 
 func GetProcessesCpuinfo(pids []string) ([]*ProcessCPUinfo, error) {
+	cpu, err := GetSystemCpu()
+	if err != nil {
+		log.Errorln(err)
+		return nil, err
+	}
+	return getProcessesCpuinfo(pids, cpu)
+}
+
+func getProcessesCpuinfo(pids []string, cpu *CPU) ([]*ProcessCPUinfo, error) {
 	if len(pids) == 0 {
 		var err error
 		pids, err = GetAllPids()
@@ -174,12 +198,12 @@ func GetProcessesCpuinfo(pids []string) ([]*ProcessCPUinfo, error) {
 			return nil, err
 		}
 	}
-	cpu, err := GetSystemCpu()
+	sysCpu, err := GetSystemCpu()
 	if err != nil {
 		log.Errorln(err)
 		return nil, err
 	}
-	coreCnt := len(cpu.DieList[0].CoreList)
+	coreCnt := len(sysCpu.DieList[0].CoreList)
 
 	var ret = []*ProcessCPUinfo{}
 	for _, pid := range pids {
@@ -188,7 +212,7 @@ func GetProcessesCpuinfo(pids []string) ([]*ProcessCPUinfo, error) {
 			log.Errorln(err)
 			return nil, err
 		}
-		info, err := NewProcessCPUinfo(ipid)
+		info, err := newProcessCPUinfo(ipid, cpu)
 		if err != nil {
 			log.Errorln(err)
 			return nil, err
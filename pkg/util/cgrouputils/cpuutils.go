@@ -83,6 +83,27 @@ func (c *CPU) GetCpuset(idx int) string {
 	}
 }
 
+// Exclude 返回去除 cpus 后的 CPU 拓扑, 不再有可用核的物理 CPU 被移除
+func (c *CPU) Exclude(cpus []int) *CPU {
+	excluded := make(map[int]bool, len(cpus))
+	for _, cpu := range cpus {
+		excluded[cpu] = true
+	}
+	ret := &CPU{DieList: make([]*CPUDie, 0, len(c.DieList))}
+	for _, d := range c.DieList {
+		die := NewCPUDie(len(ret.DieList))
+		for _, core := range d.CoreList {
+			if !excluded[core.Index] {
+				die.AddCore(core)
+			}
+		}
+		if len(die.CoreList) > 0 {
+			ret.DieList = append(ret.DieList, die)
+		}
+	}
+	return ret
+}
+
 func (c *CPU) GetPhysicalNum() int {
 	return len(c.DieList)
 }
@@ -227,6 +248,16 @@ func GetProcessWeight(share *float64, util float64) float64 {
 }
 
 func NewProcessCPUinfo(pid int) (*ProcessCPUinfo, error) {
+	cpu, err := GetSystemCpu()
+	if err != nil {
+		log.Errorln(err)
+		return nil, err
+	}
+	return newProcessCPUinfo(pid, cpu)
+}
+
+// newProcessCPUinfo 获取进程的 CPU 信息, 进程的 cpuset 按 cpu 中的物理 CPU 识别
+func newProcessCPUinfo(pid int, cpu *CPU) (*ProcessCPUinfo, error) {
 	cpuinfo := new(ProcessCPUinfo)
 	cpuinfo.Pid = pid
 	spid := strconv.Itoa(pid)
@@ -247,13 +278,8 @@ func NewProcessCPUinfo(pid int) (*ProcessCPUinfo, error) {
 	if cpusetTask.taskIsExist() {
 		cpuset := cpusetTask.GetParam("cpuset.cpus")
 		if len(cpuset) > 0 {
-			c, err := GetSystemCpu()
-			if err != nil {
-				log.Errorln(err)
-			} else {
-				icpuset := c.GetPhysicalId(ParseCpusetStr(cpuset))
-				cpuinfo.Cpuset = &icpuset
-			}
+			icpuset := cpu.GetPhysicalId(ParseCpusetStr(cpuset))
+			cpuinfo.Cpuset = &icpuset
 		}
 	}
 
@@ -0,0 +1,271 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgrouputils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+const (
+	sysNodePath = "/sys/devices/system/node"
+)
+
+// NumaNode 主机上一个 NUMA 节点的 CPU 和内存
+type NumaNode struct {
+	NodeId    int
+	Cpus      []int
+	MemSizeMb int
+}
+
+// NumaPin 虚拟机在某个 NUMA 节点上的绑定结果, VcpuPin[i] 为第 i 个 vCPU 独占的物理 CPU
+type NumaPin struct {
+	NodeId       int
+	VcpuPin      []int
+	EmulatorCpus []int
+	MemSizeMb    int
+}
+
+// GetNumaNodes 从 sysfs 读取主机 NUMA 拓扑
+func GetNumaNodes() ([]NumaNode, error) {
+	files, err := ioutil.ReadDir(sysNodePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read node dir")
+	}
+	re := regexp.MustCompile(`^node(\d+)$`)
+	nodes := []NumaNode{}
+	for _, f := range files {
+		m := re.FindStringSubmatch(f.Name())
+		if m == nil {
+			continue
+		}
+		nodeId, _ := strconv.Atoi(m[1])
+		nodePath := path.Join(sysNodePath, f.Name())
+		cpulist, err := fileutils2.FileGetContents(path.Join(nodePath, "cpulist"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read node%d cpulist", nodeId)
+		}
+		cpus, err := ParseCpuList(cpulist)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse node%d cpulist", nodeId)
+		}
+		if len(cpus) == 0 {
+			// 无 CPU 的内存节点不参与绑定
+			continue
+		}
+		meminfo, err := fileutils2.FileGetContents(path.Join(nodePath, "meminfo"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read node%d meminfo", nodeId)
+		}
+		nodes = append(nodes, NumaNode{
+			NodeId:    nodeId,
+			Cpus:      cpus,
+			MemSizeMb: parseNodeMemTotalMb(meminfo),
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeId < nodes[j].NodeId })
+	return nodes, nil
+}
+
+// parseNodeMemTotalMb 解析形如 "Node 0 MemTotal:  65843132 kB" 的行
+func parseNodeMemTotalMb(meminfo string) int {
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 4 && fields[2] == "MemTotal:" {
+			kb, _ := strconv.Atoi(fields[3])
+			return kb / 1024
+		}
+	}
+	return 0
+}
+
+// ParseCpuList 解析内核 cpulist 格式, 如 "0-3,8,10-11"
+func ParseCpuList(s string) ([]int, error) {
+	cpus := []int{}
+	for _, part := range strings.Split(strings.TrimSpace(s), ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cpu %q", part)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.Atoi(bounds[1])
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid cpu range %q", part)
+			}
+		}
+		for i := start; i <= end; i++ {
+			cpus = append(cpus, i)
+		}
+	}
+	return cpus, nil
+}
+
+// FormatCpuList 将 CPU 列表格式化为内核 cpulist 格式
+func FormatCpuList(cpus []int) string {
+	if len(cpus) == 0 {
+		return ""
+	}
+	sorted := make([]int, len(cpus))
+	copy(sorted, cpus)
+	sort.Ints(sorted)
+	parts := []string{}
+	start, prev := sorted[0], sorted[0]
+	flush := func() {
+		if start == prev {
+			parts = append(parts, strconv.Itoa(start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", start, prev))
+		}
+	}
+	for _, cpu := range sorted[1:] {
+		if cpu == prev {
+			continue
+		}
+		if cpu == prev+1 {
+			prev = cpu
+			continue
+		}
+		flush()
+		start, prev = cpu, cpu
+	}
+	flush()
+	return strings.Join(parts, ",")
+}
+
+// AllocNumaPin 在单个 NUMA 节点内为虚拟机选取独占 CPU,
+// pinned 为本机已绑定虚拟机的结果, 优先选择剩余 CPU 最多的节点
+func AllocNumaPin(nodes []NumaNode, pinned []NumaPin, vcpuCount int, memSizeMb int) (*NumaPin, error) {
+	usedCpus := map[int]bool{}
+	usedMem := map[int]int{}
+	for _, pin := range pinned {
+		for _, cpu := range pin.VcpuPin {
+			usedCpus[cpu] = true
+		}
+		usedMem[pin.NodeId] += pin.MemSizeMb
+	}
+	var best *NumaPin
+	bestFree := -1
+	for _, node := range nodes {
+		free := []int{}
+		for _, cpu := range node.Cpus {
+			if !usedCpus[cpu] {
+				free = append(free, cpu)
+			}
+		}
+		if len(free) < vcpuCount {
+			continue
+		}
+		if memSizeMb > 0 && node.MemSizeMb > 0 && node.MemSizeMb-usedMem[node.NodeId] < memSizeMb {
+			continue
+		}
+		if len(free) <= bestFree {
+			continue
+		}
+		pin := &NumaPin{NodeId: node.NodeId, VcpuPin: free[:vcpuCount], MemSizeMb: memSizeMb}
+		// 模拟器线程使用节点内未被独占的 CPU, 节点被占满时与 vCPU 共用
+		if len(free) > vcpuCount {
+			pin.EmulatorCpus = free[vcpuCount:]
+		} else {
+			pin.EmulatorCpus = pin.VcpuPin
+		}
+		best, bestFree = pin, len(free)
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no numa node has %d free cpus and %dMB memory", vcpuCount, memSizeMb)
+	}
+	return best, nil
+}
+
+// SetThreadAffinity 将线程绑定到指定的 CPU 上
+func SetThreadAffinity(tid int, cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	return unix.SchedSetaffinity(tid, &set)
+}
+
+// ExcludeCpus 返回 cpus 中不属于 excluded 的 CPU
+func ExcludeCpus(cpus []int, excluded []int) []int {
+	set := make(map[int]bool, len(excluded))
+	for _, cpu := range excluded {
+		set[cpu] = true
+	}
+	ret := make([]int, 0, len(cpus))
+	for _, cpu := range cpus {
+		if !set[cpu] {
+			ret = append(ret, cpu)
+		}
+	}
+	return ret
+}
+
+// CgroupCpusetExclude 将进程的 cpuset 去除 cpus, 未设置过 cpuset 的进程以全部 CPU 为基础;
+// 去除后没有剩余 CPU 时改用除 cpus 外的全部 CPU
+func CgroupCpusetExclude(pid string, cpus []int) bool {
+	task := NewCGroupCPUSetTask(pid, 0, "")
+	rootCpus, err := ParseCpuList(GetRootParam(task.Module(), CPUSET_CPUS, ""))
+	if err != nil {
+		log.Errorf("parse root cpuset: %s", err)
+		return false
+	}
+	curCpus := rootCpus
+	if task.taskIsExist() {
+		if cur := task.GetParam(CPUSET_CPUS); len(cur) > 0 {
+			curCpus, err = ParseCpuList(cur)
+			if err != nil {
+				log.Errorf("parse cpuset of %s: %s", pid, err)
+				return false
+			}
+		}
+	}
+	left := ExcludeCpus(curCpus, cpus)
+	if len(left) == len(curCpus) {
+		return true
+	}
+	if len(left) == 0 {
+		left = ExcludeCpus(rootCpus, cpus)
+	}
+	if len(left) == 0 {
+		log.Errorf("no cpu left for %s after excluding %s", pid, FormatCpuList(cpus))
+		return false
+	}
+	task.cpuset = FormatCpuList(left)
+	return task.SetTask()
+}
+
+// CgroupNumaSet 将进程限定在 NUMA 节点的 CPU 上, 并绑定节点内存
+func CgroupNumaSet(pid string, cpus []int, nodeId int) bool {
+	task := NewCGroupCPUSetMemsTask(pid, FormatCpuList(cpus), strconv.Itoa(nodeId))
+	return task.SetTask()
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgrouputils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCpuList(t *testing.T) {
+	cases := []struct {
+		in   string
+		cpus []int
+		out  string
+	}{
+		{"0-3,8,10-11\n", []int{0, 1, 2, 3, 8, 10, 11}, "0-3,8,10-11"},
+		{"5", []int{5}, "5"},
+		{"", []int{}, ""},
+	}
+	for _, c := range cases {
+		cpus, err := ParseCpuList(c.in)
+		if err != nil {
+			t.Fatalf("parse %q: %s", c.in, err)
+		}
+		if !reflect.DeepEqual(cpus, c.cpus) {
+			t.Errorf("parse %q: want %v got %v", c.in, c.cpus, cpus)
+		}
+		if out := FormatCpuList(cpus); out != c.out {
+			t.Errorf("format %v: want %q got %q", cpus, c.out, out)
+		}
+	}
+	if _, err := ParseCpuList("3-1"); err == nil {
+		t.Errorf("expect error for reversed range")
+	}
+}
+
+func TestAllocNumaPin(t *testing.T) {
+	nodes := []NumaNode{
+		{NodeId: 0, Cpus: []int{0, 1, 2, 3}, MemSizeMb: 8192},
+		{NodeId: 1, Cpus: []int{4, 5, 6, 7}, MemSizeMb: 8192},
+	}
+	pinned := []NumaPin{
+		{NodeId: 0, VcpuPin: []int{0}, MemSizeMb: 1024},
+	}
+	pin, err := AllocNumaPin(nodes, pinned, 2, 2048)
+	if err != nil {
+		t.Fatalf("alloc: %s", err)
+	}
+	if pin.NodeId != 1 || !reflect.DeepEqual(pin.VcpuPin, []int{4, 5}) || !reflect.DeepEqual(pin.EmulatorCpus, []int{6, 7}) {
+		t.Errorf("unexpected pin %#v", pin)
+	}
+
+	pinned = append(pinned, *pin)
+	pin, err = AllocNumaPin(nodes, pinned, 3, 2048)
+	if err != nil {
+		t.Fatalf("alloc: %s", err)
+	}
+	if pin.NodeId != 0 || !reflect.DeepEqual(pin.VcpuPin, []int{1, 2, 3}) || !reflect.DeepEqual(pin.EmulatorCpus, pin.VcpuPin) {
+		t.Errorf("unexpected pin %#v", pin)
+	}
+
+	// 单个节点内存不足时不能跨节点分配
+	if _, err := AllocNumaPin(nodes, nil, 1, 10240); err == nil {
+		t.Errorf("expect error when no node has enough memory")
+	}
+	if _, err := AllocNumaPin(nodes, nil, 5, 1024); err == nil {
+		t.Errorf("expect error when no node has enough cpus")
+	}
+}
+
+func TestExcludeCpus(t *testing.T) {
+	if got := ExcludeCpus([]int{0, 1, 2, 3}, []int{1, 3, 5}); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("want [0 2] got %v", got)
+	}
+	if got := ExcludeCpus([]int{0, 1}, nil); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("want [0 1] got %v", got)
+	}
+}
+
+func TestCPUExclude(t *testing.T) {
+	cpu := &CPU{}
+	for i := 0; i < 8; i++ {
+		core := NewCPUCore(i)
+		core.PhysicalId = i / 4
+		cpu.AddCore(core)
+	}
+	// 独占的 CPU 不参与均衡, 全部被独占的物理 CPU 不再可选
+	ret := cpu.Exclude([]int{1, 4, 5, 6, 7})
+	if ret.GetPhysicalNum() != 1 || ret.GetCpuset(0) != "0,2,3" {
+		t.Errorf("unexpected cpusets %v", ret.DieList)
+	}
+	if ret.GetPhysicalId("0,2,3") != 0 || ret.GetPhysicalId(cpu.GetCpuset(0)) != -1 {
+		t.Errorf("cpuset including excluded cpus should not match")
+	}
+	if cpu.GetPhysicalNum() != 2 || cpu.GetCpuset(0) != "0,1,2,3" {
+		t.Errorf("Exclude should not change the original topology")
+	}
+}
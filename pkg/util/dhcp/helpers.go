@@ -50,6 +50,10 @@ type ResponseConfig struct {
 	BootServer string
 	BootFile   string
 	BootBlock  uint16
+
+	// OptVendorClassIdentifier 60, UEFI HTTP Boot client only accepts
+	// offer with HTTPClient vendor class
+	VendorClassId string
 }
 
 func (conf ResponseConfig) GetHostname() string {
@@ -135,23 +139,28 @@ func makeDHCPReplyPacket(req Packet, conf *ResponseConfig, msgType MessageType)
 	if conf.DNSServer != nil {
 		opts = append(opts, Option{OptionDomainNameServer, GetOptIP(conf.DNSServer)})
 	}
-	resp := ReplyPacket(req, msgType, conf.ServerIP, conf.ClientIP, conf.LeaseTime, opts)
+	// options must be added before ReplyPacket pads the packet, options
+	// appended after padding are ignored by client
 	if conf.BootServer != "" {
 		//resp.Options[OptOverload] = []byte{3}
-		resp.SetSIAddr(net.ParseIP(conf.BootServer))
-		resp.AddOption(OptionTFTPServerName, []byte(fmt.Sprintf("%s\x00", conf.BootServer)))
+		opts = append(opts, Option{OptionTFTPServerName, []byte(fmt.Sprintf("%s\x00", conf.BootServer))})
 	}
 	if conf.BootFile != "" {
-		resp.AddOption(OptionBootFileName, []byte(fmt.Sprintf("%s\x00", conf.BootFile)))
-		sz := make([]byte, 2)
-		binary.BigEndian.PutUint16(sz, conf.BootBlock)
-		resp.AddOption(OptionBootFileSize, sz)
+		opts = append(opts, Option{OptionBootFileName, []byte(fmt.Sprintf("%s\x00", conf.BootFile))})
+		if conf.BootBlock > 0 {
+			sz := make([]byte, 2)
+			binary.BigEndian.PutUint16(sz, conf.BootBlock)
+			opts = append(opts, Option{OptionBootFileSize, sz})
+		}
+	}
+	if conf.VendorClassId != "" {
+		opts = append(opts, Option{OptionVendorClassIdentifier, []byte(conf.VendorClassId)})
 	}
 	//if bs, _ := req.ParseOptions().Bytes(OptionClientMachineIdentifier); bs != nil {
 	//resp.AddOption(OptionClientMachineIdentifier, bs)
 	//}
 	if conf.RenewalTime > 0 {
-		resp.AddOption(OptionRenewalTimeValue, GetOptTime(conf.RenewalTime))
+		opts = append(opts, Option{OptionRenewalTimeValue, GetOptTime(conf.RenewalTime)})
 	}
 	if conf.Routes != nil {
 		var optCode = OptClasslessRouteLin
@@ -160,9 +169,13 @@ func makeDHCPReplyPacket(req Packet, conf *ResponseConfig, msgType MessageType)
 		}
 		for _, route := range conf.Routes {
 			routeBytes := GetClasslessRoutePack(route)
-			resp.AddOption(optCode, routeBytes)
+			opts = append(opts, Option{optCode, routeBytes})
 		}
 	}
+	resp := ReplyPacket(req, msgType, conf.ServerIP, conf.ClientIP, conf.LeaseTime, opts)
+	if conf.BootServer != "" {
+		resp.SetSIAddr(net.ParseIP(conf.BootServer))
+	}
 	return resp
 }
 
@@ -42,6 +42,7 @@ import (
 	"yunion.io/x/pkg/utils"
 
 	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/util/tracing"
 )
 
 type THttpMethod string
@@ -478,6 +479,12 @@ func requestInternal(client sClient, ctx context.Context, method THttpMethod, ur
 	if len(ctxData.RequestId) > 0 {
 		header.Set("X-Request-Id", ctxData.RequestId)
 	}
+	ctx, span := tracing.StartSpan(ctx, string(method), tracing.SpanKindClient)
+	if span != nil {
+		tracing.Inject(ctx, header)
+		span.SetAttribute("http.method", string(method))
+		span.SetAttribute("http.url", urlStr)
+	}
 	req, err := http.NewRequest(string(method), urlStr, body)
 	if err != nil {
 		return nil, nil, err
@@ -517,6 +524,17 @@ func requestInternal(client sClient, ctx context.Context, method THttpMethod, ur
 		}
 	}
 	resp, err := client.Do(req)
+	if span != nil {
+		if err != nil {
+			span.SetError(err.Error())
+		} else {
+			span.SetAttribute("http.status_code", resp.StatusCode)
+			if resp.StatusCode >= http.StatusInternalServerError {
+				span.SetError(resp.Status)
+			}
+		}
+		span.End()
+	}
 	if err != nil {
 		red(err.Error())
 		return req, nil, err
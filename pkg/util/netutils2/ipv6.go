@@ -0,0 +1,215 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutils2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const ErrInvalidIPV6 = errors.Error("InvalidIPV6Error")
+
+// IPV6Addr 以高低两个64位整数表示的IPv6地址，便于做地址加减与比较
+type IPV6Addr struct {
+	hi uint64
+	lo uint64
+}
+
+func NewIPV6Addr(ipstr string) (IPV6Addr, error) {
+	ip := net.ParseIP(strings.TrimSpace(ipstr))
+	if ip == nil || ip.To4() != nil {
+		return IPV6Addr{}, errors.Wrapf(ErrInvalidIPV6, "invalid ipv6 address %q", ipstr)
+	}
+	return ipv6AddrFromIP(ip), nil
+}
+
+func ipv6AddrFromIP(ip net.IP) IPV6Addr {
+	ip = ip.To16()
+	return IPV6Addr{
+		hi: binary.BigEndian.Uint64(ip[:8]),
+		lo: binary.BigEndian.Uint64(ip[8:]),
+	}
+}
+
+func (addr IPV6Addr) ToIP() net.IP {
+	ip := make(net.IP, net.IPv6len)
+	binary.BigEndian.PutUint64(ip[:8], addr.hi)
+	binary.BigEndian.PutUint64(ip[8:], addr.lo)
+	return ip
+}
+
+func (addr IPV6Addr) String() string {
+	return addr.ToIP().String()
+}
+
+func (addr IPV6Addr) StepUp() IPV6Addr {
+	lo := addr.lo + 1
+	hi := addr.hi
+	if lo == 0 {
+		hi += 1
+	}
+	return IPV6Addr{hi: hi, lo: lo}
+}
+
+func (addr IPV6Addr) StepDown() IPV6Addr {
+	lo := addr.lo - 1
+	hi := addr.hi
+	if addr.lo == 0 {
+		hi -= 1
+	}
+	return IPV6Addr{hi: hi, lo: lo}
+}
+
+func (addr IPV6Addr) Cmp(addr2 IPV6Addr) int {
+	switch {
+	case addr.hi < addr2.hi:
+		return -1
+	case addr.hi > addr2.hi:
+		return 1
+	case addr.lo < addr2.lo:
+		return -1
+	case addr.lo > addr2.lo:
+		return 1
+	}
+	return 0
+}
+
+func ipv6Mask(masklen uint8) (uint64, uint64) {
+	switch {
+	case masklen <= 0:
+		return 0, 0
+	case masklen >= 128:
+		return ^uint64(0), ^uint64(0)
+	case masklen <= 64:
+		return ^uint64(0) << uint(64-masklen), 0
+	default:
+		return ^uint64(0), ^uint64(0) << uint(128-masklen)
+	}
+}
+
+func (addr IPV6Addr) NetAddr(masklen uint8) IPV6Addr {
+	mhi, mlo := ipv6Mask(masklen)
+	return IPV6Addr{hi: addr.hi & mhi, lo: addr.lo & mlo}
+}
+
+func (addr IPV6Addr) BroadcastAddr(masklen uint8) IPV6Addr {
+	mhi, mlo := ipv6Mask(masklen)
+	return IPV6Addr{hi: addr.hi | ^mhi, lo: addr.lo | ^mlo}
+}
+
+type IPV6Prefix struct {
+	Address IPV6Addr
+	MaskLen uint8
+}
+
+func NewIPV6Prefix(prefix string) (IPV6Prefix, error) {
+	ip, ipnet, err := net.ParseCIDR(strings.TrimSpace(prefix))
+	if err != nil || ip.To4() != nil {
+		return IPV6Prefix{}, errors.Wrapf(ErrInvalidIPV6, "invalid ipv6 prefix %q", prefix)
+	}
+	ones, _ := ipnet.Mask.Size()
+	addr := ipv6AddrFromIP(ip)
+	return IPV6Prefix{Address: addr.NetAddr(uint8(ones)), MaskLen: uint8(ones)}, nil
+}
+
+func (pref IPV6Prefix) String() string {
+	return fmt.Sprintf("%s/%d", pref.Address, pref.MaskLen)
+}
+
+func (pref IPV6Prefix) Contains(addr IPV6Addr) bool {
+	return addr.NetAddr(pref.MaskLen) == pref.Address
+}
+
+// ToIPRange 返回前缀内可分配的地址范围，去掉了全零的Subnet-Router anycast地址
+func (pref IPV6Prefix) ToIPRange() IPV6AddrRange {
+	start := pref.Address
+	if pref.MaskLen < 127 {
+		start = start.StepUp()
+	}
+	return NewIPV6AddrRange(start, pref.Address.BroadcastAddr(pref.MaskLen))
+}
+
+type IPV6AddrRange struct {
+	start IPV6Addr
+	end   IPV6Addr
+}
+
+func NewIPV6AddrRange(ip1 IPV6Addr, ip2 IPV6Addr) IPV6AddrRange {
+	if ip1.Cmp(ip2) > 0 {
+		ip1, ip2 = ip2, ip1
+	}
+	return IPV6AddrRange{start: ip1, end: ip2}
+}
+
+func (ar IPV6AddrRange) StartIp() IPV6Addr {
+	return ar.start
+}
+
+func (ar IPV6AddrRange) EndIp() IPV6Addr {
+	return ar.end
+}
+
+func (ar IPV6AddrRange) Contains(ip IPV6Addr) bool {
+	return ar.start.Cmp(ip) <= 0 && ip.Cmp(ar.end) <= 0
+}
+
+func (ar IPV6AddrRange) IsOverlap(ar2 IPV6AddrRange) bool {
+	return !(ar.end.Cmp(ar2.start) < 0 || ar2.end.Cmp(ar.start) < 0)
+}
+
+// Random 在范围内随机取一个地址，范围超过2^63时只在起始地址之后的2^63个地址内选取
+func (ar IPV6AddrRange) Random() IPV6Addr {
+	span := uint64(1<<63 - 1)
+	if ar.end.hi == ar.start.hi || (ar.end.hi == ar.start.hi+1 && ar.end.lo < ar.start.lo) {
+		if diff := ar.end.lo - ar.start.lo; diff < span {
+			span = diff
+		}
+	}
+	if span == 0 {
+		return ar.start
+	}
+	offset := uint64(rand.Int63n(int64(span)))
+	lo := ar.start.lo + offset
+	hi := ar.start.hi
+	if lo < ar.start.lo {
+		hi += 1
+	}
+	return IPV6Addr{hi: hi, lo: lo}
+}
+
+func (ar IPV6AddrRange) String() string {
+	return fmt.Sprintf("%s-%s", ar.start, ar.end)
+}
+
+// MacToEUI64 根据MAC地址按SLAAC规则生成前缀内的接口地址
+func MacToEUI64(prefix IPV6Prefix, mac string) (IPV6Addr, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return IPV6Addr{}, errors.Wrapf(ErrInvalidIPV6, "invalid mac %q", mac)
+	}
+	eui := []byte{hw[0] ^ 0x02, hw[1], hw[2], 0xff, 0xfe, hw[3], hw[4], hw[5]}
+	return IPV6Addr{hi: prefix.Address.hi, lo: binary.BigEndian.Uint64(eui)}, nil
+}
+
+// IsIPV6Prefix 判断字符串是否为IPv6的CIDR
+func IsIPV6Prefix(prefix string) bool {
+	_, err := NewIPV6Prefix(prefix)
+	return err == nil
+}
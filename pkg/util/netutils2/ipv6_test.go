@@ -0,0 +1,61 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutils2
+
+import (
+	"testing"
+)
+
+func TestIPV6Addr(t *testing.T) {
+	addr, err := NewIPV6Addr("fd00::ffff:ffff:ffff:ffff")
+	if err != nil {
+		t.Fatalf("NewIPV6Addr: %v", err)
+	}
+	if got := addr.StepUp().String(); got != "fd00:0:0:1::" {
+		t.Errorf("StepUp got %s", got)
+	}
+	if got := addr.StepUp().StepDown(); got != addr {
+		t.Errorf("StepDown got %s", got)
+	}
+	if got := addr.NetAddr(64).String(); got != "fd00::" {
+		t.Errorf("NetAddr got %s", got)
+	}
+	if _, err := NewIPV6Addr("10.0.0.1"); err == nil {
+		t.Errorf("ipv4 address should be rejected")
+	}
+}
+
+func TestIPV6Prefix(t *testing.T) {
+	prefix, err := NewIPV6Prefix("fd00:1::5/64")
+	if err != nil {
+		t.Fatalf("NewIPV6Prefix: %v", err)
+	}
+	if prefix.String() != "fd00:1::/64" {
+		t.Errorf("prefix got %s", prefix)
+	}
+	r := prefix.ToIPRange()
+	if r.StartIp().String() != "fd00:1::1" || r.EndIp().String() != "fd00:1::ffff:ffff:ffff:ffff" {
+		t.Errorf("range got %s", r)
+	}
+	for i := 0; i < 10; i++ {
+		if ip := r.Random(); !r.Contains(ip) {
+			t.Errorf("random %s out of range %s", ip, r)
+		}
+	}
+	eui, _ := MacToEUI64(prefix, "00:22:33:44:55:66")
+	if eui.String() != "fd00:1::222:33ff:fe44:5566" {
+		t.Errorf("eui64 got %s", eui)
+	}
+}
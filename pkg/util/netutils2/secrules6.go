@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutils2
+
+import (
+	"net"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/secrules"
+)
+
+// secrules只能解析IPv4地址, IPv6规则沿用相同的文本格式, 地址段单独处理:
+//   in:allow 2001:db8::/64 tcp 22
+
+// IsSecurityRule6 规则是否只作用于IPv6地址
+func IsSecurityRule6(rule *secrules.SecurityRule) bool {
+	return rule.IPNet != nil && rule.IPNet.IP.To4() == nil
+}
+
+// FormatSecurityRule6 输出IPv6规则的文本形式
+func FormatSecurityRule6(rule secrules.SecurityRule) string {
+	ipnet := rule.IPNet
+	rule.IPNet = &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}
+	segs := strings.SplitN(rule.String(), " ", 2)
+	addr := ipnet.String()
+	if ones, bits := ipnet.Mask.Size(); ones == bits {
+		addr = ipnet.IP.String()
+	}
+	return strings.Join(append([]string{segs[0], addr}, segs[1:]...), " ")
+}
+
+// ParseSecurityRule6 解析FormatSecurityRule6输出的IPv6规则
+func ParseSecurityRule6(pattern string) (*secrules.SecurityRule, error) {
+	segs := strings.Fields(pattern)
+	if len(segs) < 3 {
+		return nil, errors.Wrapf(ErrInvalidIPV6, "invalid ipv6 rule %q", pattern)
+	}
+	var ipnet *net.IPNet
+	if _, n, err := net.ParseCIDR(segs[1]); err == nil {
+		ipnet = n
+	} else if ip := net.ParseIP(segs[1]); ip != nil {
+		ipnet = &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	}
+	if ipnet == nil || ipnet.IP.To4() != nil {
+		return nil, errors.Wrapf(ErrInvalidIPV6, "invalid ipv6 rule %q", pattern)
+	}
+	rule, err := secrules.ParseSecurityRule(strings.Join(append(segs[:1:1], segs[2:]...), " "))
+	if err != nil {
+		return nil, errors.Wrapf(err, "ParseSecurityRule %q", pattern)
+	}
+	rule.IPNet = ipnet
+	return rule, nil
+}
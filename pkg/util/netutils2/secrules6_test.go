@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutils2
+
+import (
+	"testing"
+)
+
+func TestSecurityRule6RoundTrip(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "in:allow 2001:db8::/64 tcp 22", want: "in:allow 2001:db8::/64 tcp 22"},
+		{in: "out:deny 2001:db8::1 udp 53,123", want: "out:deny 2001:db8::1 udp 53,123"},
+		{in: "in:allow 2001:db8::1/128 icmp", want: "in:allow 2001:db8::1 icmp"},
+		{in: "in:allow ::/0 tcp 80-90", want: "in:allow ::/0 tcp 80-90"},
+		{in: "in:deny fd00::/8 any", want: "in:deny fd00::/8 any"},
+	}
+	for _, c := range cases {
+		rule, err := ParseSecurityRule6(c.in)
+		if err != nil {
+			t.Errorf("parse %q: %s", c.in, err)
+			continue
+		}
+		if !IsSecurityRule6(rule) {
+			t.Errorf("%q should be an ipv6 rule", c.in)
+		}
+		got := FormatSecurityRule6(*rule)
+		if got != c.want {
+			t.Errorf("format %q: want %q got %q", c.in, c.want, got)
+		}
+		again, err := ParseSecurityRule6(got)
+		if err != nil {
+			t.Errorf("parse formatted %q: %s", got, err)
+			continue
+		}
+		if again.String() != rule.String() || again.IPNet.String() != rule.IPNet.String() {
+			t.Errorf("round trip of %q changed rule: %s %s", c.in, again.String(), again.IPNet)
+		}
+	}
+}
+
+func TestParseSecurityRule6Invalid(t *testing.T) {
+	for _, in := range []string{
+		"in:allow 10.0.0.0/8 tcp 22",
+		"in:allow tcp 22",
+		"in:allow",
+		"allow 2001:db8::/64 tcp 22",
+	} {
+		if _, err := ParseSecurityRule6(in); err == nil {
+			t.Errorf("%q should not be parsed as ipv6 rule", in)
+		}
+	}
+}
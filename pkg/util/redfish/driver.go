@@ -72,6 +72,17 @@ type IRedfishDriver interface {
 	BmcReset(ctx context.Context) error
 
 	GetBiosInfo(ctx context.Context) (SBiosInfo, error)
+	// SetBiosAttributes writes attributes to the BIOS pending settings,
+	// which take effect on next system reset
+	SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) error
+
+	GetUpdateServiceInfo(ctx context.Context) (SUpdateServiceInfo, error)
+	GetFirmwareInventory(ctx context.Context) ([]SFirmwareInfo, error)
+	SimpleUpdate(ctx context.Context, imageUri string, protocol string, targets []string) error
+
+	GetEventSubscriptions(ctx context.Context) ([]SEventSubscription, error)
+	CreateEventSubscription(ctx context.Context, destination string, eventTypes []string, subContext string) (SEventSubscription, error)
+	DeleteEventSubscription(ctx context.Context, path string) error
 
 	GetIndicatorLED(ctx context.Context) (bool, error)
 	SetIndicatorLED(ctx context.Context, on bool) error
@@ -0,0 +1,78 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+)
+
+// SEventRecord is a single record in the Events array of a Redfish Event
+// message pushed by BMC to the subscription destination
+type SEventRecord struct {
+	EventType      string `json:"EventType"`
+	EventId        string `json:"EventId"`
+	EventTimestamp string `json:"EventTimestamp"`
+	Severity       string `json:"Severity"`
+	Message        string `json:"Message"`
+	MessageId      string `json:"MessageId"`
+}
+
+type SEventPayload struct {
+	Id      string         `json:"Id"`
+	Name    string         `json:"Name"`
+	Context string         `json:"Context"`
+	Events  []SEventRecord `json:"Events"`
+}
+
+// ParseEventPayload parses an event message pushed by BMC and converts
+// the event records into SEvent, so that they can be stored in the same
+// way as the events read from LogServices
+func ParseEventPayload(body jsonutils.JSONObject) (string, []SEvent, error) {
+	payload := SEventPayload{}
+	err := body.Unmarshal(&payload)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Unmarshal event payload")
+	}
+	now := time.Now().UTC()
+	events := make([]SEvent, 0, len(payload.Events))
+	for _, rec := range payload.Events {
+		evt := SEvent{
+			EventId:  rec.EventId,
+			Message:  rec.Message,
+			Severity: rec.Severity,
+			Type:     EVENT_TYPE_ALERT,
+		}
+		if len(evt.EventId) == 0 {
+			evt.EventId = rec.MessageId
+		}
+		if len(evt.Message) == 0 {
+			evt.Message = rec.MessageId
+		}
+		if len(rec.EventTimestamp) > 0 {
+			evt.Created, err = time.Parse(time.RFC3339, rec.EventTimestamp)
+			if err != nil {
+				evt.Created = now
+			}
+		} else {
+			evt.Created = now
+		}
+		events = append(events, evt)
+	}
+	SortEvents(events)
+	return payload.Context, events, nil
+}
@@ -0,0 +1,362 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+// resources modeled after the public-rackmount1 profile of DMTF Redfish-Mockup-Server
+var mockupResources = map[string]string{
+	"/redfish/v1": `{"@odata.id":"/redfish/v1","RedfishVersion":"1.6.0",
+		"Systems":{"@odata.id":"/redfish/v1/Systems"},
+		"Managers":{"@odata.id":"/redfish/v1/Managers"},
+		"UpdateService":{"@odata.id":"/redfish/v1/UpdateService"},
+		"EventService":{"@odata.id":"/redfish/v1/EventService"}}`,
+	"/redfish/v1/Systems": `{"Members":[{"@odata.id":"/redfish/v1/Systems/437XR1138R2"}]}`,
+	"/redfish/v1/Systems/437XR1138R2": `{"Id":"437XR1138R2",
+		"Boot":{"BootSourceOverrideEnabled":"Disabled","BootSourceOverrideTarget":"None",
+			"BootSourceOverrideTarget@Redfish.AllowableValues":["None","Pxe","Cd","Usb","Hdd","BiosSetup"]},
+		"Bios":{"@odata.id":"/redfish/v1/Systems/437XR1138R2/Bios"}}`,
+	"/redfish/v1/Managers":     `{"Members":[{"@odata.id":"/redfish/v1/Managers/BMC"}]}`,
+	"/redfish/v1/Managers/BMC": `{"Id":"BMC","VirtualMedia":{"@odata.id":"/redfish/v1/Managers/BMC/VirtualMedia"}}`,
+	"/redfish/v1/Managers/BMC/VirtualMedia": `{"Members":[
+		{"@odata.id":"/redfish/v1/Managers/BMC/VirtualMedia/Floppy1"},
+		{"@odata.id":"/redfish/v1/Managers/BMC/VirtualMedia/CD1"}]}`,
+	"/redfish/v1/Managers/BMC/VirtualMedia/Floppy1": `{"Id":"Floppy1","MediaTypes":["Floppy","USBStick"],"Image":null}`,
+	"/redfish/v1/Managers/BMC/VirtualMedia/CD1": `{"Id":"CD1","MediaTypes":["CD","DVD"],"Image":null,"Inserted":false,
+		"Actions":{"#VirtualMedia.InsertMedia":{"target":"/redfish/v1/Managers/BMC/VirtualMedia/CD1/Actions/VirtualMedia.InsertMedia"},
+			"#VirtualMedia.EjectMedia":{"target":"/redfish/v1/Managers/BMC/VirtualMedia/CD1/Actions/VirtualMedia.EjectMedia"}}}`,
+	"/redfish/v1/Systems/437XR1138R2/Bios": `{"AttributeRegistry":"BiosAttributeRegistryP89.v1_0_0",
+		"Attributes":{"AdminPhone":"","BootMode":"Uefi","ProcCoreDisable":0,"UsbControl":"UsbEnabled"},
+		"@Redfish.Settings":{"SettingsObject":{"@odata.id":"/redfish/v1/Systems/437XR1138R2/Bios/Settings"}}}`,
+	"/redfish/v1/Systems/437XR1138R2/Bios/Settings": `{"Attributes":{"BootMode":"Uefi"}}`,
+	"/redfish/v1/UpdateService": `{"ServiceEnabled":true,
+		"FirmwareInventory":{"@odata.id":"/redfish/v1/UpdateService/FirmwareInventory"},
+		"Actions":{"#UpdateService.SimpleUpdate":{"target":"/redfish/v1/UpdateService/Actions/SimpleUpdate",
+			"TransferProtocol@Redfish.AllowableValues":["HTTP","HTTPS"]}}}`,
+	"/redfish/v1/UpdateService/FirmwareInventory": `{"Members":[
+		{"@odata.id":"/redfish/v1/UpdateService/FirmwareInventory/BMC"},
+		{"@odata.id":"/redfish/v1/UpdateService/FirmwareInventory/BIOS"}]}`,
+	"/redfish/v1/UpdateService/FirmwareInventory/BMC": `{"Id":"BMC","Name":"Contoso BMC Firmware",
+		"Version":"1.45.455b66-rev4","Updateable":true,"SoftwareId":"1624A9DF-5E13-47FC-874A-DF3AFF143089",
+		"Status":{"State":"Enabled","Health":"OK"}}`,
+	"/redfish/v1/UpdateService/FirmwareInventory/BIOS": `{"Id":"BIOS","Name":"Contoso BIOS Firmware",
+		"Version":"P79 v1.45 (12/06/2017)","Updateable":true,"SoftwareId":"FEE82A67-6CE2-4625-9F44-237AD2402C28",
+		"Status":{"State":"Enabled","Health":"OK"}}`,
+	"/redfish/v1/EventService": `{"ServiceEnabled":true,
+		"EventTypesForSubscription":["StatusChange","ResourceUpdated","Alert"],
+		"Subscriptions":{"@odata.id":"/redfish/v1/EventService/Subscriptions"}}`,
+	"/redfish/v1/EventService/Subscriptions": `{"Members":[]}`,
+}
+
+type sMockupServer struct {
+	lock      sync.Mutex
+	resources map[string]jsonutils.JSONObject
+	posts     map[string]jsonutils.JSONObject
+	nextId    int
+}
+
+func newMockupServer() *sMockupServer {
+	srv := &sMockupServer{
+		resources: make(map[string]jsonutils.JSONObject),
+		posts:     make(map[string]jsonutils.JSONObject),
+		nextId:    1,
+	}
+	for k, v := range mockupResources {
+		srv.resources[k], _ = jsonutils.ParseString(v)
+	}
+	return srv
+}
+
+func (srv *sMockupServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	var body jsonutils.JSONObject
+	if r.Body != nil {
+		data, _ := ioutil.ReadAll(r.Body)
+		if len(data) > 0 {
+			body, _ = jsonutils.Parse(data)
+		}
+	}
+	switch r.Method {
+	case "GET":
+		res, ok := srv.resources[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(res.String()))
+	case "PATCH":
+		res, ok := srv.resources[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		dict := res.(*jsonutils.JSONDict)
+		attrs, _ := body.Get("Attributes")
+		if attrs != nil {
+			cur, _ := dict.Get("Attributes")
+			cur.(*jsonutils.JSONDict).Update(attrs)
+		}
+		boot, _ := body.Get("Boot")
+		if boot != nil {
+			cur, _ := dict.Get("Boot")
+			cur.(*jsonutils.JSONDict).Update(boot)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "POST":
+		srv.posts[path] = body
+		if path == "/redfish/v1/EventService/Subscriptions" {
+			subPath := fmt.Sprintf("%s/%d", path, srv.nextId)
+			sub := body.(*jsonutils.JSONDict)
+			sub.Set("Id", jsonutils.NewString(fmt.Sprintf("%d", srv.nextId)))
+			sub.Set("@odata.id", jsonutils.NewString(subPath))
+			srv.nextId++
+			srv.resources[subPath] = sub
+			coll := srv.resources[path].(*jsonutils.JSONDict)
+			members, _ := coll.GetArray("Members")
+			link := jsonutils.NewDict()
+			link.Set("@odata.id", jsonutils.NewString(subPath))
+			coll.Set("Members", jsonutils.NewArray(append(members, link)...))
+			w.Header().Set("Location", "http://"+r.Host+subPath)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		for action, inserted := range map[string]bool{
+			"/Actions/VirtualMedia.InsertMedia": true,
+			"/Actions/VirtualMedia.EjectMedia":  false,
+		} {
+			if !strings.HasSuffix(path, action) {
+				continue
+			}
+			media := srv.resources[strings.TrimSuffix(path, action)].(*jsonutils.JSONDict)
+			if inserted {
+				media.Set("Image", jsonutils.NewString(jsonutils.GetAnyString(body, []string{"Image"})))
+			} else {
+				media.Set("Image", jsonutils.JSONNull)
+			}
+			media.Set("Inserted", jsonutils.NewBool(inserted))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		if _, ok := srv.resources[path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(srv.resources, path)
+		parent := path[:strings.LastIndex(path, "/")]
+		if coll, ok := srv.resources[parent].(*jsonutils.JSONDict); ok {
+			members, _ := coll.GetArray("Members")
+			left := make([]jsonutils.JSONObject, 0)
+			for _, m := range members {
+				if p, _ := m.GetString("@odata.id"); p != path {
+					left = append(left, m)
+				}
+			}
+			coll.Set("Members", jsonutils.NewArray(left...))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newTestDriver connects to the Redfish-Mockup-Server given by env
+// REDFISH_MOCKUP_ENDPOINT, or to an in-process mockup otherwise
+func newTestDriver(t *testing.T) (redfish.IRedfishDriver, *sMockupServer, func()) {
+	endpoint := os.Getenv("REDFISH_MOCKUP_ENDPOINT")
+	var mockup *sMockupServer
+	closeFunc := func() {}
+	if len(endpoint) == 0 {
+		mockup = newMockupServer()
+		ts := httptest.NewServer(mockup)
+		closeFunc = ts.Close
+		endpoint = ts.URL
+	}
+	drv := NewGenericRedfishApi(endpoint, "root", "password", false)
+	err := drv.Probe(context.Background())
+	if err != nil {
+		closeFunc()
+		t.Fatalf("probe %s fail %s", endpoint, err)
+	}
+	return drv, mockup, closeFunc
+}
+
+func TestBiosAttributes(t *testing.T) {
+	ctx := context.Background()
+	drv, _, closeFunc := newTestDriver(t)
+	defer closeFunc()
+	bios, err := drv.GetBiosInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetBiosInfo %s", err)
+	}
+	if bios.PendingReboot {
+		t.Errorf("unexpected pending attributes %s", bios.PendingAttributes)
+	}
+	err = drv.SetBiosAttributes(ctx, jsonutils.Marshal(map[string]string{"UsbControl": "UsbDisabled"}))
+	if err != nil {
+		t.Fatalf("SetBiosAttributes %s", err)
+	}
+	bios, err = drv.GetBiosInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetBiosInfo %s", err)
+	}
+	if !bios.PendingReboot {
+		t.Errorf("expect pending reboot")
+	}
+	if val, _ := bios.PendingAttributes.GetString("UsbControl"); val != "UsbDisabled" {
+		t.Errorf("expect pending UsbControl=UsbDisabled, got %s", bios.PendingAttributes)
+	}
+	if val, _ := bios.Attributes.GetString("UsbControl"); val != "UsbEnabled" {
+		t.Errorf("current UsbControl should not change before reset, got %s", val)
+	}
+	err = drv.SetBiosAttributes(ctx, jsonutils.Marshal(map[string]string{"NoSuchAttribute": "x"}))
+	if err == nil {
+		t.Errorf("expect error for unknown attribute")
+	}
+}
+
+func TestFirmwareInventory(t *testing.T) {
+	ctx := context.Background()
+	drv, mockup, closeFunc := newTestDriver(t)
+	defer closeFunc()
+	fws, err := drv.GetFirmwareInventory(ctx)
+	if err != nil {
+		t.Fatalf("GetFirmwareInventory %s", err)
+	}
+	if len(fws) == 0 {
+		t.Fatalf("empty firmware inventory")
+	}
+	for _, fw := range fws {
+		if len(fw.Id) == 0 || len(fw.Path) == 0 || len(fw.Version) == 0 {
+			t.Errorf("incomplete firmware info %s", jsonutils.Marshal(fw))
+		}
+	}
+	err = drv.SimpleUpdate(ctx, "http://10.0.0.1/bmc.bin", "HTTP", []string{fws[0].Path})
+	if err != nil {
+		t.Fatalf("SimpleUpdate %s", err)
+	}
+	if mockup != nil {
+		body := mockup.posts["/redfish/v1/UpdateService/Actions/SimpleUpdate"]
+		if uri, _ := body.GetString("ImageURI"); uri != "http://10.0.0.1/bmc.bin" {
+			t.Errorf("unexpected SimpleUpdate body %s", body)
+		}
+	}
+	err = drv.SimpleUpdate(ctx, "nfs://10.0.0.1/bmc.bin", "NFS", nil)
+	if mockup != nil && err == nil {
+		t.Errorf("expect unsupported protocol error")
+	}
+}
+
+func TestEventSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	drv, _, closeFunc := newTestDriver(t)
+	defer closeFunc()
+	dest := "http://10.0.0.2:8879/baremetals/test/redfish-events"
+	sub, err := drv.CreateEventSubscription(ctx, dest, nil, "secret")
+	if err != nil {
+		t.Fatalf("CreateEventSubscription %s", err)
+	}
+	if sub.Destination != dest || sub.Context != "secret" || len(sub.Path) == 0 {
+		t.Errorf("unexpected subscription %s", jsonutils.Marshal(sub))
+	}
+	subs, err := drv.GetEventSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("GetEventSubscriptions %s", err)
+	}
+	found := false
+	for i := range subs {
+		if subs[i].Path == sub.Path {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("subscription %s not listed", sub.Path)
+	}
+	err = drv.DeleteEventSubscription(ctx, sub.Path)
+	if err != nil {
+		t.Fatalf("DeleteEventSubscription %s", err)
+	}
+}
+
+func TestParseEventPayload(t *testing.T) {
+	payload := `{"@odata.type":"#Event.v1_3_0.Event","Id":"1","Name":"Event Array","Context":"secret",
+		"Events":[{"EventType":"Alert","EventId":"5","EventTimestamp":"2020-04-20T10:01:00+08:00","Severity":"Warning",
+			"Message":"The LAN has been disconnected","MessageId":"Alert.1.0.LanDisconnect"},
+		{"EventType":"StatusChange","EventTimestamp":"2020-04-20T10:00:00+08:00","MessageId":"ResourceEvent.1.0.StatusChange"}]}`
+	json, _ := jsonutils.ParseString(payload)
+	ctx, events, err := redfish.ParseEventPayload(json)
+	if err != nil {
+		t.Fatalf("ParseEventPayload %s", err)
+	}
+	if ctx != "secret" {
+		t.Errorf("expect context secret, got %s", ctx)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expect 2 events, got %d", len(events))
+	}
+	if events[0].EventId != "ResourceEvent.1.0.StatusChange" || events[1].EventId != "5" {
+		t.Errorf("events not sorted or EventId not filled: %s", jsonutils.Marshal(events))
+	}
+	if events[1].Type != redfish.EVENT_TYPE_ALERT {
+		t.Errorf("unexpected event type %s", events[1].Type)
+	}
+}
+
+func TestVirtualCdrom(t *testing.T) {
+	ctx := context.Background()
+	drv, _, closeFunc := newTestDriver(t)
+	defer closeFunc()
+	isoUrl := "http://10.0.0.2:9879/bootiso/test.iso"
+	err := redfish.MountVirtualCdrom(ctx, drv, isoUrl, true)
+	if err != nil {
+		t.Fatalf("MountVirtualCdrom %s", err)
+	}
+	_, cdInfo, err := drv.GetVirtualCdromInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetVirtualCdromInfo %s", err)
+	}
+	if cdInfo.Image != isoUrl {
+		t.Errorf("expect image %s, got %s", isoUrl, cdInfo.Image)
+	}
+	_, sysInfo, err := drv.GetSystemInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetSystemInfo %s", err)
+	}
+	if sysInfo.NextBootDev != "Cd" {
+		t.Errorf("expect next boot from Cd, got %s", sysInfo.NextBootDev)
+	}
+	err = redfish.UmountVirtualCdrom(ctx, drv)
+	if err != nil {
+		t.Fatalf("UmountVirtualCdrom %s", err)
+	}
+	_, cdInfo, _ = drv.GetVirtualCdromInfo(ctx)
+	if cdInfo.Image != "" {
+		t.Errorf("image not ejected: %s", cdInfo.Image)
+	}
+}
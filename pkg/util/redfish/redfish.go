@@ -339,22 +339,65 @@ func (r *SBaseRedfishClient) GetVirtualCdromInfo(ctx context.Context) (string, S
 		imgPath = ""
 	}
 	cdInfo.Image = imgPath
+	if jsonResp.Contains("Actions", "#VirtualMedia.InsertMedia") || jsonResp.Contains("Image") {
+		cdInfo.SupportAction = true
+	}
 	return path, cdInfo, nil
 }
 
+// getVirtualMediaActionPath returns the target of standard VirtualMedia
+// actions, e.g. #VirtualMedia.InsertMedia, empty if the BMC only supports
+// patching Image property
+func (r *SBaseRedfishClient) getVirtualMediaActionPath(ctx context.Context, path string, action string) string {
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		log.Warningf("r.Get %s fail %s", path, err)
+		return ""
+	}
+	target, _ := resp.GetString("Actions", action, "target")
+	return target
+}
+
 func (r *SBaseRedfishClient) MountVirtualCdrom(ctx context.Context, path string, cdromUrl string, boot bool) error {
 	info := jsonutils.NewDict()
 	info.Set("Image", jsonutils.NewString(cdromUrl))
 
-	resp, err := r.Patch(ctx, path, info)
-	if err != nil {
-		return errors.Wrap(err, "r.Patch")
+	target := r.getVirtualMediaActionPath(ctx, path, "#VirtualMedia.InsertMedia")
+	if len(target) > 0 {
+		info.Set("Inserted", jsonutils.JSONTrue)
+		info.Set("WriteProtected", jsonutils.JSONTrue)
+		_, _, err := r.Post(ctx, target, info)
+		if err != nil {
+			return errors.Wrap(err, "Actions/VirtualMedia.InsertMedia")
+		}
+	} else {
+		resp, err := r.Patch(ctx, path, info)
+		if err != nil {
+			return errors.Wrap(err, "r.Patch")
+		}
+		if r.IsDebug && resp != nil {
+			log.Debugf("%s", resp.PrettyString())
+		}
+	}
+	if boot {
+		err := r.SetNextBootVirtualCdrom(ctx)
+		if err != nil {
+			return errors.Wrap(err, "r.SetNextBootVirtualCdrom")
+		}
 	}
-	log.Debugf("%s", resp.PrettyString())
 	return nil
 }
 
 func (r *SBaseRedfishClient) UmountVirtualCdrom(ctx context.Context, path string) error {
+	target := r.getVirtualMediaActionPath(ctx, path, "#VirtualMedia.EjectMedia")
+	if len(target) > 0 {
+		_, _, err := r.Post(ctx, target, jsonutils.NewDict())
+		if err != nil {
+			return errors.Wrap(err, "Actions/VirtualMedia.EjectMedia")
+		}
+		return nil
+	}
+
 	info := jsonutils.NewDict()
 	info.Set("Image", jsonutils.JSONNull)
 
@@ -362,12 +405,32 @@ func (r *SBaseRedfishClient) UmountVirtualCdrom(ctx context.Context, path string
 	if err != nil {
 		return errors.Wrap(err, "r.Patch")
 	}
-	log.Debugf("%s", resp.PrettyString())
+	if r.IsDebug && resp != nil {
+		log.Debugf("%s", resp.PrettyString())
+	}
 	return nil
 }
 
+// SetNextBootVirtualCdrom boots from virtual CD-ROM once on next system reset
 func (r *SBaseRedfishClient) SetNextBootVirtualCdrom(ctx context.Context) error {
-	return httperrors.ErrNotImplemented
+	path, sysInfo, err := r.IRedfishDriver().GetSystemInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "r.GetSystemInfo")
+	}
+	if len(sysInfo.NextBootDevSupported) > 0 && !utils.IsInStringArray("Cd", sysInfo.NextBootDevSupported) {
+		return errors.Wrapf(httperrors.ErrNotSupported, "Cd not supported: %s", sysInfo.NextBootDevSupported)
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString("Cd"), "Boot", "BootSourceOverrideTarget")
+	params.Add(jsonutils.NewString("Once"), "Boot", "BootSourceOverrideEnabled")
+	resp, err := r.Patch(ctx, path, params)
+	if err != nil {
+		return errors.Wrap(err, "r.Patch")
+	}
+	if r.IsDebug && resp != nil {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return nil
 }
 
 func (r *SBaseRedfishClient) GetSystemInfo(ctx context.Context) (string, SSystemInfo, error) {
@@ -638,21 +701,286 @@ func (r *SBaseRedfishClient) ClearManagerLogs(ctx context.Context) error {
 	return r.ClearLogs(ctx, r.IRedfishDriver().GetClearManagerLogsPath(), "Managers", 1)
 }
 
-func (r *SBaseRedfishClient) GetBiosInfo(ctx context.Context) (SBiosInfo, error) {
-	biosInfo := SBiosInfo{}
-	path, _, err := r.GetResource(ctx, "Systems", "0")
+func (r *SBaseRedfishClient) getBiosJSON(ctx context.Context) (string, jsonutils.JSONObject, error) {
+	path, system, err := r.GetResource(ctx, "Systems", "0")
 	if err != nil {
-		return biosInfo, errors.Wrap(err, "r.GetResource Systems 0")
+		return "", nil, errors.Wrap(err, "r.GetResource Systems 0")
+	}
+	biosPath, _ := system.GetString("Bios", r.IRedfishDriver().LinkKey())
+	if len(biosPath) == 0 {
+		biosPath = httputils.JoinPath(path, "Bios/")
 	}
-	biosPath := httputils.JoinPath(path, "Bios/")
 	resp, err := r.Get(ctx, biosPath)
 	if err != nil {
-		return biosInfo, errors.Wrapf(err, "r.Get %s", biosPath)
+		return biosPath, nil, errors.Wrapf(err, "r.Get %s", biosPath)
+	}
+	if r.IsDebug {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return biosPath, resp, nil
+}
+
+// getBiosSettingsPath returns the path of BIOS settings object, to which
+// pending attributes are written. Fallback to the BIOS resource itself
+// if the BMC does not declare a settings object
+func (r *SBaseRedfishClient) getBiosSettingsPath(biosPath string, bios jsonutils.JSONObject) string {
+	settingsPath, _ := bios.GetString("@Redfish.Settings", "SettingsObject", r.IRedfishDriver().LinkKey())
+	if len(settingsPath) == 0 {
+		return biosPath
+	}
+	return settingsPath
+}
+
+func (r *SBaseRedfishClient) GetBiosInfo(ctx context.Context) (SBiosInfo, error) {
+	biosInfo := SBiosInfo{}
+	biosPath, resp, err := r.getBiosJSON(ctx)
+	if err != nil {
+		return biosInfo, errors.Wrap(err, "getBiosJSON")
+	}
+	biosInfo.AttributeRegistry, _ = resp.GetString("AttributeRegistry")
+	attrs, _ := resp.GetMap("Attributes")
+	biosInfo.Attributes = jsonutils.Marshal(attrs)
+	pending := jsonutils.NewDict()
+	settingsPath := r.getBiosSettingsPath(biosPath, resp)
+	if settingsPath != biosPath {
+		settings, err := r.Get(ctx, settingsPath)
+		if err != nil {
+			log.Warningf("get BIOS settings %s fail %s", settingsPath, err)
+		} else {
+			settingAttrs, _ := settings.GetMap("Attributes")
+			for k, v := range settingAttrs {
+				if cur, ok := attrs[k]; ok && cur.Equals(v) {
+					continue
+				}
+				pending.Set(k, v)
+			}
+		}
 	}
-	log.Debugf("%s", resp.PrettyString())
+	biosInfo.PendingAttributes = pending
+	biosInfo.PendingReboot = pending.Length() > 0
 	return biosInfo, nil
 }
 
+func (r *SBaseRedfishClient) SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) error {
+	biosPath, bios, err := r.getBiosJSON(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getBiosJSON")
+	}
+	attrMap, err := attrs.GetMap()
+	if err != nil {
+		return errors.Wrap(httperrors.ErrBadRequest, "attributes should be a dict")
+	}
+	if len(attrMap) == 0 {
+		return nil
+	}
+	current, _ := bios.GetMap("Attributes")
+	for k := range attrMap {
+		if _, ok := current[k]; !ok {
+			return errors.Wrapf(httperrors.ErrBadRequest, "unknown BIOS attribute %s", k)
+		}
+	}
+	params := jsonutils.NewDict()
+	params.Add(attrs, "Attributes")
+	resp, err := r.Patch(ctx, r.getBiosSettingsPath(biosPath, bios), params)
+	if err != nil {
+		return errors.Wrap(err, "r.Patch")
+	}
+	if r.IsDebug && resp != nil {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return nil
+}
+
+// getCollectionMembers fetches every member of a resource collection
+func (r *SBaseRedfishClient) getCollectionMembers(ctx context.Context, collection jsonutils.JSONObject) ([]string, []jsonutils.JSONObject, error) {
+	collection = r.IRedfishDriver().GetParent(collection)
+	members, err := collection.GetArray(r.IRedfishDriver().MemberKey())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "find member error")
+	}
+	paths := make([]string, 0, len(members))
+	ret := make([]jsonutils.JSONObject, 0, len(members))
+	for i := range members {
+		path, _ := members[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(path) == 0 {
+			continue
+		}
+		resp, err := r.Get(ctx, path)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "r.Get %s", path)
+		}
+		paths = append(paths, path)
+		ret = append(ret, resp)
+	}
+	return paths, ret, nil
+}
+
+func (r *SBaseRedfishClient) GetUpdateServiceInfo(ctx context.Context) (SUpdateServiceInfo, error) {
+	info := SUpdateServiceInfo{}
+	_, resp, err := r.GetResource(ctx, "UpdateService")
+	if err != nil {
+		return info, errors.Wrap(err, "GetResource UpdateService")
+	}
+	info.ServiceEnabled = jsonutils.QueryBoolean(resp, "ServiceEnabled", true)
+	info.SimpleUpdatePath, _ = resp.GetString("Actions", "#UpdateService.SimpleUpdate", "target")
+	info.TransferProtocols, _ = jsonutils.GetStringArray(resp, "Actions", "#UpdateService.SimpleUpdate", "TransferProtocol@Redfish.AllowableValues")
+	return info, nil
+}
+
+func (r *SBaseRedfishClient) GetFirmwareInventory(ctx context.Context) ([]SFirmwareInfo, error) {
+	_, resp, err := r.GetResource(ctx, "UpdateService", "FirmwareInventory")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource UpdateService FirmwareInventory")
+	}
+	paths, members, err := r.getCollectionMembers(ctx, resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "getCollectionMembers")
+	}
+	ret := make([]SFirmwareInfo, len(members))
+	for i := range members {
+		err = members[i].Unmarshal(&ret[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unmarshal %s", paths[i])
+		}
+		ret[i].Path = paths[i]
+		ret[i].State, _ = members[i].GetString("Status", "State")
+		ret[i].Health, _ = members[i].GetString("Status", "Health")
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) SimpleUpdate(ctx context.Context, imageUri string, protocol string, targets []string) error {
+	info, err := r.IRedfishDriver().GetUpdateServiceInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetUpdateServiceInfo")
+	}
+	if !info.ServiceEnabled {
+		return errors.Wrap(httperrors.ErrNotSupported, "UpdateService disabled")
+	}
+	if len(info.SimpleUpdatePath) == 0 {
+		return errors.Wrap(httperrors.ErrNotSupported, "Actions.#UpdateService.SimpleUpdate.target")
+	}
+	if len(protocol) > 0 && len(info.TransferProtocols) > 0 && !utils.IsInStringArray(protocol, info.TransferProtocols) {
+		return errors.Wrapf(httperrors.ErrBadRequest, "%s not supported: %s", protocol, info.TransferProtocols)
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(imageUri), "ImageURI")
+	if len(protocol) > 0 {
+		params.Add(jsonutils.NewString(protocol), "TransferProtocol")
+	}
+	if len(targets) > 0 {
+		params.Add(jsonutils.NewStringArray(targets), "Targets")
+	}
+	_, _, err = r.Post(ctx, info.SimpleUpdatePath, params)
+	if err != nil {
+		return errors.Wrap(err, "Actions/UpdateService.SimpleUpdate")
+	}
+	return nil
+}
+
+func (r *SBaseRedfishClient) getEventSubscriptionsPath(ctx context.Context) (string, jsonutils.JSONObject, error) {
+	_, eventSrv, err := r.GetResource(ctx, "EventService")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "GetResource EventService")
+	}
+	if !jsonutils.QueryBoolean(eventSrv, "ServiceEnabled", true) {
+		return "", nil, errors.Wrap(httperrors.ErrNotSupported, "EventService disabled")
+	}
+	path, err := eventSrv.GetString("Subscriptions", r.IRedfishDriver().LinkKey())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "EventService.Subscriptions")
+	}
+	return path, eventSrv, nil
+}
+
+func (r *SBaseRedfishClient) parseEventSubscription(path string, resp jsonutils.JSONObject) (SEventSubscription, error) {
+	sub := SEventSubscription{}
+	err := resp.Unmarshal(&sub)
+	if err != nil {
+		return sub, errors.Wrapf(err, "Unmarshal %s", path)
+	}
+	sub.Path = path
+	return sub, nil
+}
+
+func (r *SBaseRedfishClient) GetEventSubscriptions(ctx context.Context) ([]SEventSubscription, error) {
+	path, _, err := r.getEventSubscriptionsPath(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getEventSubscriptionsPath")
+	}
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "r.Get %s", path)
+	}
+	paths, members, err := r.getCollectionMembers(ctx, resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "getCollectionMembers")
+	}
+	ret := make([]SEventSubscription, len(members))
+	for i := range members {
+		ret[i], err = r.parseEventSubscription(paths[i], members[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) CreateEventSubscription(ctx context.Context, destination string, eventTypes []string, subContext string) (SEventSubscription, error) {
+	sub := SEventSubscription{}
+	path, eventSrv, err := r.getEventSubscriptionsPath(ctx)
+	if err != nil {
+		return sub, errors.Wrap(err, "getEventSubscriptionsPath")
+	}
+	if len(eventTypes) == 0 {
+		eventTypes, _ = jsonutils.GetStringArray(eventSrv, "EventTypesForSubscription")
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(destination), "Destination")
+	params.Add(jsonutils.NewString(EVENT_SUBSCRIPTION_PROTOCOL), "Protocol")
+	if len(subContext) > 0 {
+		params.Add(jsonutils.NewString(subContext), "Context")
+	}
+	if len(eventTypes) > 0 {
+		params.Add(jsonutils.NewStringArray(eventTypes), "EventTypes")
+	}
+	hdr, resp, err := r.Post(ctx, path, params)
+	if err != nil {
+		return sub, errors.Wrap(err, "create EventService subscription")
+	}
+	subPath := hdr.Get("Location")
+	pos := strings.Index(subPath, r.IRedfishDriver().BasePath())
+	if pos > 0 {
+		subPath = subPath[pos:]
+	}
+	if len(subPath) > 0 && (resp == nil || !resp.Contains("Destination")) {
+		resp, err = r.Get(ctx, subPath)
+		if err != nil {
+			return sub, errors.Wrapf(err, "r.Get %s", subPath)
+		}
+	}
+	if resp == nil {
+		sub.Path = subPath
+		sub.Destination = destination
+		sub.Context = subContext
+		sub.Protocol = EVENT_SUBSCRIPTION_PROTOCOL
+		sub.EventTypes = eventTypes
+		return sub, nil
+	}
+	if len(subPath) == 0 {
+		subPath, _ = resp.GetString(r.IRedfishDriver().LinkKey())
+	}
+	return r.parseEventSubscription(subPath, resp)
+}
+
+func (r *SBaseRedfishClient) DeleteEventSubscription(ctx context.Context, path string) error {
+	_, _, err := r.Delete(ctx, path)
+	if err != nil {
+		return errors.Wrapf(err, "r.Delete %s", path)
+	}
+	return nil
+}
+
 func (r *SBaseRedfishClient) GetIndicatorLEDInternal(ctx context.Context, subsys string) (string, string, error) {
 	path, resp, err := r.GetResource(ctx, subsys, "0")
 	if err != nil {
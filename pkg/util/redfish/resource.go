@@ -21,6 +21,8 @@ import (
 	"strings"
 	"time"
 
+	"yunion.io/x/jsonutils"
+
 	"yunion.io/x/onecloud/pkg/util/influxdb"
 )
 
@@ -49,6 +51,8 @@ type SSystemInfo struct {
 const (
 	EVENT_TYPE_SYSTEM  = "system"
 	EVENT_TYPE_MANAGER = "manager"
+	// events pushed by BMC through EventService subscription
+	EVENT_TYPE_ALERT = "alert"
 )
 
 type SEvent struct {
@@ -70,6 +74,47 @@ func SortEvents(el []SEvent) {
 }
 
 type SBiosInfo struct {
+	AttributeRegistry string `json:"AttributeRegistry"`
+
+	// current BIOS attributes
+	Attributes jsonutils.JSONObject `json:"Attributes"`
+	// attributes written to the settings object but not yet applied,
+	// they take effect after next system reset
+	PendingAttributes jsonutils.JSONObject `json:"PendingAttributes"`
+
+	PendingReboot bool `json:"PendingReboot"`
+}
+
+type SFirmwareInfo struct {
+	Id         string `json:"Id"`
+	Name       string `json:"Name"`
+	Version    string `json:"Version"`
+	SoftwareId string `json:"SoftwareId"`
+	Updateable bool   `json:"Updateable"`
+	State      string `json:"State"`
+	Health     string `json:"Health"`
+	Path       string `json:"Path"`
+}
+
+type SUpdateServiceInfo struct {
+	ServiceEnabled bool `json:"ServiceEnabled"`
+	// target path of #UpdateService.SimpleUpdate action
+	SimpleUpdatePath string `json:"SimpleUpdatePath"`
+	// allowable values of TransferProtocol, e.g. HTTP, HTTPS, NFS
+	TransferProtocols []string `json:"TransferProtocols"`
+}
+
+const (
+	EVENT_SUBSCRIPTION_PROTOCOL = "Redfish"
+)
+
+type SEventSubscription struct {
+	Id          string   `json:"Id"`
+	Path        string   `json:"Path"`
+	Destination string   `json:"Destination"`
+	Context     string   `json:"Context"`
+	Protocol    string   `json:"Protocol"`
+	EventTypes  []string `json:"EventTypes"`
 }
 
 type SPower struct {
@@ -0,0 +1,105 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shellutils
+
+import (
+	"fmt"
+	"os"
+
+	"yunion.io/x/structarg"
+)
+
+// 云平台命令行工具的全局参数需实现此接口
+type ICliOptions interface {
+	IsHelp() bool
+	GetSubcommand() string
+}
+
+// 创建命令行解析器, 并注册 help 及 CommandTable 中的所有子命令
+func NewSubcommandParser(options ICliOptions, prog, desc string) (*structarg.ArgumentParser, error) {
+	parse, e := structarg.NewArgumentParser(options,
+		prog,
+		desc,
+		fmt.Sprintf(`See "%s help COMMAND" for help on a specific command.`, prog))
+
+	if e != nil {
+		return nil, e
+	}
+
+	subcmd := parse.GetSubcommand()
+	if subcmd == nil {
+		return nil, fmt.Errorf("No subcommand argument.")
+	}
+	type HelpOptions struct {
+		SUBCOMMAND string `help:"sub-command name"`
+	}
+	R(&HelpOptions{}, "help", "Show help of a subcommand", func(args *HelpOptions) error {
+		helpstr, e := subcmd.SubHelpString(args.SUBCOMMAND)
+		if e != nil {
+			return e
+		} else {
+			fmt.Print(helpstr)
+			return nil
+		}
+	})
+	for _, v := range CommandTable {
+		_, e := subcmd.AddSubParser(v.Options, v.Command, v.Desc, v.Callback)
+		if e != nil {
+			return nil, e
+		}
+	}
+	return parse, nil
+}
+
+func ShowErrorAndExit(e error) {
+	fmt.Fprintf(os.Stderr, "%s", e)
+	fmt.Fprintln(os.Stderr)
+	os.Exit(1)
+}
+
+// 解析命令行参数并执行子命令, 除 help 外的子命令以 newClient 返回的对象作为第一个参数
+func ParseAndRun(parser *structarg.ArgumentParser, args []string, newClient func() (interface{}, error)) {
+	e := parser.ParseArgs(args, false)
+	options := parser.Options().(ICliOptions)
+
+	if options.IsHelp() {
+		fmt.Print(parser.HelpString())
+		return
+	}
+	subcmd := parser.GetSubcommand()
+	subparser := subcmd.GetSubParser()
+	if e != nil {
+		if subparser != nil {
+			fmt.Print(subparser.Usage())
+		} else {
+			fmt.Print(parser.Usage())
+		}
+		ShowErrorAndExit(e)
+	}
+	suboptions := subparser.Options()
+	if options.GetSubcommand() == "help" {
+		e = subcmd.Invoke(suboptions)
+	} else {
+		var client interface{}
+		client, e = newClient()
+		if e != nil {
+			ShowErrorAndExit(e)
+		}
+		e = subcmd.Invoke(client, suboptions)
+	}
+	if e != nil {
+		ShowErrorAndExit(e)
+	}
+}
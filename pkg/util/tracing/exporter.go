@@ -0,0 +1,264 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	INSTRUMENTATION_SCOPE = "yunion.io/x/onecloud"
+
+	DEFAULT_OTLP_ENDPOINT = "http://127.0.0.1:4318/v1/traces"
+)
+
+// types below follow the JSON encoding of OTLP ExportTraceServiceRequest
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesData struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func otlpValue(val interface{}) otlpAnyValue {
+	ret := otlpAnyValue{}
+	switch v := val.(type) {
+	case string:
+		ret.StringValue = &v
+	case bool:
+		ret.BoolValue = &v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprintf("%d", v)
+		ret.IntValue = &s
+	case float32:
+		f := float64(v)
+		ret.DoubleValue = &f
+	case float64:
+		ret.DoubleValue = &v
+	default:
+		s := fmt.Sprintf("%v", v)
+		ret.StringValue = &s
+	}
+	return ret
+}
+
+func otlpAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ret := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, otlpKeyValue{Key: k, Value: otlpValue(attrs[k])})
+	}
+	return ret
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// MarshalOtlpJSON encodes spans as an OTLP/JSON ExportTraceServiceRequest
+func MarshalOtlpJSON(serviceName string, spans []*SSpan) ([]byte, error) {
+	resAttrs := map[string]interface{}{
+		"service.name": serviceName,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		resAttrs["host.name"] = hostname
+	}
+	ospans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.lock.Lock()
+		ospan := otlpSpan{
+			TraceId:           span.SpanContext.TraceID.String(),
+			SpanId:            span.SpanContext.SpanID.String(),
+			Name:              span.Name,
+			Kind:              int(span.Kind),
+			StartTimeUnixNano: unixNano(span.StartTime),
+			EndTimeUnixNano:   unixNano(span.EndTime),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if span.ParentSpanID.IsValid() {
+			ospan.ParentSpanId = span.ParentSpanID.String()
+		}
+		if span.StatusError {
+			// STATUS_CODE_ERROR
+			ospan.Status = otlpStatus{Code: 2, Message: span.StatusMessage}
+		}
+		span.lock.Unlock()
+		ospans = append(ospans, ospan)
+	}
+	data := otlpTracesData{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{Attributes: otlpAttributes(resAttrs)},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: INSTRUMENTATION_SCOPE},
+						Spans: ospans,
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(&data)
+}
+
+type sOtlpHttpExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOtlpHttpExporter exports spans to the OTLP/HTTP traces endpoint of a collector in JSON encoding
+func NewOtlpHttpExporter(endpoint string) IExporter {
+	if len(endpoint) == 0 {
+		endpoint = DEFAULT_OTLP_ENDPOINT
+	}
+	return &sOtlpHttpExporter{
+		endpoint: endpoint,
+		// the default transport is used on purpose, requests to the collector are not traced
+		client: &http.Client{Timeout: exportTimeout},
+	}
+}
+
+func (exp *sOtlpHttpExporter) ExportSpans(ctx context.Context, serviceName string, spans []*SSpan) error {
+	body, err := MarshalOtlpJSON(serviceName, spans)
+	if err != nil {
+		return errors.Wrap(err, "MarshalOtlpJSON")
+	}
+	req, err := http.NewRequest("POST", exp.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "http.NewRequest")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := exp.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "post %s", exp.endpoint)
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("post %s: %s %s", exp.endpoint, resp.Status, msg)
+	}
+	return nil
+}
+
+func (exp *sOtlpHttpExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+type sFileExporter struct {
+	lock   sync.Mutex
+	writer io.Writer
+	file   *os.File
+}
+
+// NewFileExporter appends one OTLP/JSON request per line to path, or writes to stdout if path is empty or "-",
+// the output can be replayed by the otlpjsonfile receiver of the OpenTelemetry collector
+func NewFileExporter(path string) (IExporter, error) {
+	exp := &sFileExporter{}
+	if len(path) == 0 || path == "-" {
+		exp.writer = os.Stdout
+		return exp, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+	exp.file = file
+	exp.writer = file
+	return exp, nil
+}
+
+func (exp *sFileExporter) ExportSpans(ctx context.Context, serviceName string, spans []*SSpan) error {
+	body, err := MarshalOtlpJSON(serviceName, spans)
+	if err != nil {
+		return errors.Wrap(err, "MarshalOtlpJSON")
+	}
+	exp.lock.Lock()
+	defer exp.lock.Unlock()
+	_, err = exp.writer.Write(append(body, '\n'))
+	if err != nil {
+		return errors.Wrap(err, "write")
+	}
+	return nil
+}
+
+func (exp *sFileExporter) Shutdown(ctx context.Context) error {
+	if exp.file != nil {
+		return exp.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"yunion.io/x/log"
+)
+
+const (
+	maxQueueSize  = 2048
+	maxBatchSize  = 512
+	batchTimeout  = 5 * time.Second
+	exportTimeout = 30 * time.Second
+)
+
+type IExporter interface {
+	ExportSpans(ctx context.Context, serviceName string, spans []*SSpan) error
+	Shutdown(ctx context.Context) error
+}
+
+type sProvider struct {
+	serviceName   string
+	samplePercent int
+	exporter      IExporter
+
+	queue chan *SSpan
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// defaultProvider is nil until Init is called, spans are then
+// still propagated but never sampled nor exported
+var defaultProvider *sProvider
+
+// Init starts exporting sampled spans of serviceName in batches,
+// samplePercent is the percentage of new traces started by this service to sample,
+// traces continued from a remote parent follow the sampling decision of the parent
+func Init(serviceName string, samplePercent int, exporter IExporter) {
+	p := &sProvider{
+		serviceName:   serviceName,
+		samplePercent: samplePercent,
+		exporter:      exporter,
+		queue:         make(chan *SSpan, maxQueueSize),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go p.run()
+	defaultProvider = p
+	log.Infof("tracing of %s enabled, sample %d%%", serviceName, samplePercent)
+}
+
+func IsEnabled() bool {
+	return defaultProvider != nil
+}
+
+// Shutdown exports the pending spans and stops the exporter
+func Shutdown() {
+	p := defaultProvider
+	if p == nil {
+		return
+	}
+	defaultProvider = nil
+	close(p.stop)
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		log.Warningf("tracing shutdown timeout, pending spans are dropped")
+	}
+	err := p.exporter.Shutdown(ctx)
+	if err != nil {
+		log.Errorf("shutdown tracing exporter fail: %s", err)
+	}
+}
+
+// sample decides on the lower 8 bytes of the trace id,
+// the same way as the TraceIDRatioBased sampler of OpenTelemetry
+func (p *sProvider) sample(id TraceID) bool {
+	if p == nil || p.samplePercent <= 0 {
+		return false
+	}
+	if p.samplePercent >= 100 {
+		return true
+	}
+	x := binary.BigEndian.Uint64(id[8:16]) >> 1
+	return x < uint64(p.samplePercent)*((1<<63)/100)
+}
+
+func (p *sProvider) onEnd(span *SSpan) {
+	if p == nil {
+		return
+	}
+	select {
+	case p.queue <- span:
+	default:
+		log.Warningf("tracing queue full, span %s dropped", span.Name)
+	}
+}
+
+func (p *sProvider) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(batchTimeout)
+	defer ticker.Stop()
+
+	batch := make([]*SSpan, 0, maxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		err := p.exporter.ExportSpans(ctx, p.serviceName, batch)
+		if err != nil {
+			log.Errorf("export %d spans fail: %s", len(batch), err)
+		}
+		batch = make([]*SSpan, 0, maxBatchSize)
+	}
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	TRACEPARENT_HEADER = "traceparent"
+
+	ErrInvalidTraceparent = errors.Error("invalid traceparent")
+)
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// Remote is true if the context is propagated from another process
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the context as a W3C traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+func decodeHex(dst []byte, val string) bool {
+	if len(val) != len(dst)*2 || strings.ToLower(val) != val {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(val))
+	return err == nil
+}
+
+// ParseTraceparent parses a W3C traceparent header value, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(val string) (SpanContext, error) {
+	sc := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(val), "-")
+	if len(parts) < 4 {
+		return sc, errors.Wrapf(ErrInvalidTraceparent, "%q", val)
+	}
+	var version, flags [1]byte
+	if !decodeHex(version[:], parts[0]) || version[0] == 0xff || (version[0] == 0 && len(parts) != 4) {
+		return sc, errors.Wrapf(ErrInvalidTraceparent, "version of %q", val)
+	}
+	if !decodeHex(sc.TraceID[:], parts[1]) || !sc.TraceID.IsValid() {
+		return sc, errors.Wrapf(ErrInvalidTraceparent, "trace-id of %q", val)
+	}
+	if !decodeHex(sc.SpanID[:], parts[2]) || !sc.SpanID.IsValid() {
+		return sc, errors.Wrapf(ErrInvalidTraceparent, "parent-id of %q", val)
+	}
+	if !decodeHex(flags[:], parts[3]) {
+		return sc, errors.Wrapf(ErrInvalidTraceparent, "trace-flags of %q", val)
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, nil
+}
+
+type SpanKind int
+
+// values are those of OTLP
+const (
+	SpanKindInternal = SpanKind(1)
+	SpanKindServer   = SpanKind(2)
+	SpanKindClient   = SpanKind(3)
+)
+
+type SSpan struct {
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]interface{}
+
+	StatusError   bool
+	StatusMessage string
+
+	lock sync.Mutex
+}
+
+// SetAttribute records a string, bool, integer or float attribute on the span, a nil span is allowed
+func (span *SSpan) SetAttribute(key string, val interface{}) {
+	if span == nil {
+		return
+	}
+	span.lock.Lock()
+	defer span.lock.Unlock()
+	if span.Attributes == nil {
+		span.Attributes = make(map[string]interface{})
+	}
+	span.Attributes[key] = val
+}
+
+func (span *SSpan) SetError(msg string) {
+	if span == nil {
+		return
+	}
+	span.lock.Lock()
+	defer span.lock.Unlock()
+	span.StatusError = true
+	span.StatusMessage = msg
+}
+
+// End finishes the span and queues it for export if it is sampled, calling End more than once has no effect
+func (span *SSpan) End() {
+	if span == nil {
+		return
+	}
+	span.lock.Lock()
+	if !span.EndTime.IsZero() {
+		span.lock.Unlock()
+		return
+	}
+	span.EndTime = time.Now()
+	span.lock.Unlock()
+	if span.SpanContext.Sampled {
+		defaultProvider.onEnd(span)
+	}
+}
+
+type spanKey struct{}
+type remoteSpanContextKey struct{}
+
+func ContextWithSpan(ctx context.Context, span *SSpan) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func SpanFromContext(ctx context.Context) *SSpan {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*SSpan)
+	return span
+}
+
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the context of the current span, or the remote one if no span is started yet
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext
+	}
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(remoteSpanContextKey{}).(SpanContext)
+	return sc
+}
+
+func newSpanID() SpanID {
+	id := SpanID{}
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newTraceID() TraceID {
+	id := TraceID{}
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// StartSpan starts a span as the child of the span in ctx, or the root of a new trace if there is none,
+// no span is started and nil is returned if tracing is not enabled and ctx is not in a trace
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *SSpan) {
+	parent := SpanContextFromContext(ctx)
+	if !parent.IsValid() && !IsEnabled() {
+		return ctx, nil
+	}
+	span := &SSpan{
+		Name:      name,
+		Kind:      kind,
+		StartTime: time.Now(),
+	}
+	if parent.IsValid() {
+		span.SpanContext.TraceID = parent.TraceID
+		span.SpanContext.Sampled = parent.Sampled
+		span.ParentSpanID = parent.SpanID
+	} else {
+		span.SpanContext.TraceID = newTraceID()
+		span.SpanContext.Sampled = defaultProvider.sample(span.SpanContext.TraceID)
+	}
+	span.SpanContext.SpanID = newSpanID()
+	return ContextWithSpan(ctx, span), span
+}
+
+// Extract continues the trace propagated in the traceparent header of a request
+func Extract(ctx context.Context, header http.Header) context.Context {
+	val := header.Get(TRACEPARENT_HEADER)
+	if len(val) == 0 {
+		return ctx
+	}
+	sc, err := ParseTraceparent(val)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject propagates the trace in ctx to the traceparent header of a request
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if sc.IsValid() {
+		header.Set(TRACEPARENT_HEADER, sc.Traceparent())
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		in      string
+		wantErr bool
+		sampled bool
+	}{
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sampled: true},
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{in: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", sampled: true},
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{in: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{in: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{in: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{in: "00-4bf92f3577b34da6-00f067aa0ba902b7-01", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, c := range cases {
+		sc, err := ParseTraceparent(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", c.in, err)
+			continue
+		}
+		if sc.Sampled != c.sampled {
+			t.Errorf("%q: sampled %v", c.in, sc.Sampled)
+		}
+		if !strings.HasPrefix(c.in, "00") {
+			continue
+		}
+		if got := sc.Traceparent(); got != c.in {
+			t.Errorf("%q: format back to %q", c.in, got)
+		}
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	header := http.Header{}
+	header.Set(TRACEPARENT_HEADER, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Extract(context.Background(), header)
+	ctx, span := StartSpan(ctx, "server", SpanKindServer)
+	if span.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id not continued: %s", span.SpanContext.TraceID)
+	}
+	if span.ParentSpanID.String() != "00f067aa0ba902b7" || !span.SpanContext.Sampled {
+		t.Errorf("parent not continued: %s %v", span.ParentSpanID, span.SpanContext.Sampled)
+	}
+	out := http.Header{}
+	Inject(ctx, out)
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanContext.SpanID.String() + "-01"
+	if got := out.Get(TRACEPARENT_HEADER); got != want {
+		t.Errorf("inject %q, want %q", got, want)
+	}
+
+	// without a provider no new trace is started
+	_, root := StartSpan(context.Background(), "root", SpanKindInternal)
+	if root != nil {
+		t.Errorf("unexpected root span %#v", root.SpanContext)
+	}
+}
+
+func TestMarshalOtlpJSON(t *testing.T) {
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteSpanContext(context.Background(), sc)
+	_, span := StartSpan(ctx, "GET /servers", SpanKindServer)
+	span.SetAttribute("http.status_code", 500)
+	span.SetAttribute("http.method", "GET")
+	span.SetError("boom")
+	span.End()
+
+	body, err := MarshalOtlpJSON("region", []*SSpan{span})
+	if err != nil {
+		t.Fatalf("MarshalOtlpJSON: %s", err)
+	}
+	data := otlpTracesData{}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		t.Fatalf("unmarshal %s: %s", body, err)
+	}
+	if len(data.ResourceSpans) != 1 || len(data.ResourceSpans[0].ScopeSpans) != 1 || len(data.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("unexpected layout %s", body)
+	}
+	svc := ""
+	for _, attr := range data.ResourceSpans[0].Resource.Attributes {
+		if attr.Key == "service.name" && attr.Value.StringValue != nil {
+			svc = *attr.Value.StringValue
+		}
+	}
+	if svc != "region" {
+		t.Errorf("service.name %q in %s", svc, body)
+	}
+	ospan := data.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if ospan.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || ospan.ParentSpanId != "00f067aa0ba902b7" || ospan.Name != "GET /servers" {
+		t.Errorf("unexpected span %s", body)
+	}
+	if ospan.Kind != 2 || ospan.Status.Code != 2 {
+		t.Errorf("kind %d status %d", ospan.Kind, ospan.Status.Code)
+	}
+	if len(ospan.Attributes) != 2 || ospan.Attributes[1].Value.IntValue == nil || *ospan.Attributes[1].Value.IntValue != "500" {
+		t.Errorf("int attribute in %s", body)
+	}
+}
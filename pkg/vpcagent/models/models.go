@@ -144,6 +144,7 @@ type SecurityGroupRule struct {
 	compute_models.SSecurityGroupRule
 
 	SecurityGroup *SecurityGroup `json:"-"`
+	AddressGroup  *AddressGroup  `json:"-"`
 }
 
 func (el *SecurityGroupRule) Copy() *SecurityGroupRule {
@@ -152,6 +153,16 @@ func (el *SecurityGroupRule) Copy() *SecurityGroupRule {
 	}
 }
 
+type AddressGroup struct {
+	compute_models.SAddressGroup
+}
+
+func (el *AddressGroup) Copy() *AddressGroup {
+	return &AddressGroup{
+		SAddressGroup: el.SAddressGroup,
+	}
+}
+
 type Elasticip struct {
 	compute_models.SElasticip
 
@@ -174,3 +185,13 @@ func (el *DnsRecord) Copy() *DnsRecord {
 		SDnsRecord: el.SDnsRecord,
 	}
 }
+
+type FlowLog struct {
+	compute_models.SFlowLog
+}
+
+func (el *FlowLog) Copy() *FlowLog {
+	return &FlowLog{
+		SFlowLog: el.SFlowLog,
+	}
+}
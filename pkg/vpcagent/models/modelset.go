@@ -34,6 +34,7 @@ type (
 	Hosts              map[string]*Host
 	SecurityGroups     map[string]*SecurityGroup
 	SecurityGroupRules map[string]*SecurityGroupRule
+	AddressGroups      map[string]*AddressGroup
 	Elasticips         map[string]*Elasticip
 	NetworkAddresses   map[string]*NetworkAddress
 
@@ -41,6 +42,7 @@ type (
 	Guestsecgroups map[string]*Guestsecgroup // key: guestId/secgroupId
 
 	DnsRecords map[string]*DnsRecord
+	FlowLogs   map[string]*FlowLog
 )
 
 func (set Vpcs) ModelManager() mcclient_modulebase.IBaseManager {
@@ -524,6 +526,46 @@ func (set SecurityGroupRules) Copy() apihelper.IModelSet {
 	return setCopy
 }
 
+func (ms SecurityGroupRules) joinAddressGroups(subEntries AddressGroups) bool {
+	correct := true
+	for _, m := range ms {
+		m.AddressGroup = nil
+		if m.AddressGroupId == "" {
+			continue
+		}
+		subEntry, ok := subEntries[m.AddressGroupId]
+		if !ok {
+			log.Warningf("secgrouprule %s: address group %s not found",
+				m.Id, m.AddressGroupId)
+			correct = false
+			continue
+		}
+		m.AddressGroup = subEntry
+	}
+	return correct
+}
+
+func (set AddressGroups) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.AddressGroups
+}
+
+func (set AddressGroups) NewModel() db.IModel {
+	return &AddressGroup{}
+}
+
+func (set AddressGroups) AddModel(i db.IModel) {
+	m := i.(*AddressGroup)
+	set[m.Id] = m
+}
+
+func (set AddressGroups) Copy() apihelper.IModelSet {
+	setCopy := AddressGroups{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
 func (set Guestsecgroups) ModelManager() mcclient_modulebase.IBaseManager {
 	return &mcclient_modules.Serversecgroups
 }
@@ -631,3 +673,61 @@ func (set DnsRecords) Copy() apihelper.IModelSet {
 	}
 	return setCopy
 }
+
+func (set FlowLogs) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.FlowLogs
+}
+
+func (set FlowLogs) NewModel() db.IModel {
+	return &FlowLog{}
+}
+
+func (set FlowLogs) AddModel(i db.IModel) {
+	m := i.(*FlowLog)
+	set[m.Id] = m
+}
+
+func (set FlowLogs) Copy() apihelper.IModelSet {
+	setCopy := FlowLogs{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+// GuestnetworkFlowLog 返回作用于该网卡的流日志, 网卡级优先于网络级, 网络级优先于VPC级
+func (set FlowLogs) GuestnetworkFlowLog(guestnetwork *Guestnetwork) *FlowLog {
+	var (
+		r     *FlowLog
+		rPrio int
+	)
+	for _, fl := range set {
+		if !fl.Enabled.Bool() || fl.PendingDeleted {
+			continue
+		}
+		prio := 0
+		switch fl.ResourceType {
+		case computeapis.FLOW_LOG_RESOURCE_SERVER:
+			if fl.ResourceId == guestnetwork.GuestId && (fl.Ifname == "" || fl.Ifname == guestnetwork.Ifname) {
+				prio = 3
+			}
+		case computeapis.FLOW_LOG_RESOURCE_NETWORK:
+			if fl.ResourceId == guestnetwork.NetworkId {
+				prio = 2
+			}
+		case computeapis.FLOW_LOG_RESOURCE_VPC:
+			if network := guestnetwork.Network; network != nil && network.Vpc != nil && fl.ResourceId == network.Vpc.Id {
+				prio = 1
+			}
+		}
+		if prio == 0 {
+			continue
+		}
+		// 同级多条时取Id最小者, 保证结果稳定
+		if prio > rPrio || (prio == rPrio && fl.Id < r.Id) {
+			r = fl
+			rPrio = prio
+		}
+	}
+	return r
+}
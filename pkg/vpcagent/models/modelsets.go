@@ -28,12 +28,14 @@ type ModelSetsMaxUpdatedAt struct {
 	Hosts              time.Time
 	SecurityGroups     time.Time
 	SecurityGroupRules time.Time
+	AddressGroups      time.Time
 	Guestnetworks      time.Time
 	Guestsecgroups     time.Time
 	Elasticips         time.Time
 	NetworkAddresses   time.Time
 
 	DnsRecords time.Time
+	FlowLogs   time.Time
 }
 
 func NewModelSetsMaxUpdatedAt() *ModelSetsMaxUpdatedAt {
@@ -45,12 +47,14 @@ func NewModelSetsMaxUpdatedAt() *ModelSetsMaxUpdatedAt {
 		Hosts:              apihelper.PseudoZeroTime,
 		SecurityGroups:     apihelper.PseudoZeroTime,
 		SecurityGroupRules: apihelper.PseudoZeroTime,
+		AddressGroups:      apihelper.PseudoZeroTime,
 		Guestnetworks:      apihelper.PseudoZeroTime,
 		Guestsecgroups:     apihelper.PseudoZeroTime,
 		Elasticips:         apihelper.PseudoZeroTime,
 		NetworkAddresses:   apihelper.PseudoZeroTime,
 
 		DnsRecords: apihelper.PseudoZeroTime,
+		FlowLogs:   apihelper.PseudoZeroTime,
 	}
 }
 
@@ -62,12 +66,14 @@ type ModelSets struct {
 	Hosts              Hosts
 	SecurityGroups     SecurityGroups
 	SecurityGroupRules SecurityGroupRules
+	AddressGroups      AddressGroups
 	Guestnetworks      Guestnetworks
 	Guestsecgroups     Guestsecgroups
 	Elasticips         Elasticips
 	NetworkAddresses   NetworkAddresses
 
 	DnsRecords DnsRecords
+	FlowLogs   FlowLogs
 }
 
 func NewModelSets() *ModelSets {
@@ -79,12 +85,14 @@ func NewModelSets() *ModelSets {
 		Hosts:              Hosts{},
 		SecurityGroups:     SecurityGroups{},
 		SecurityGroupRules: SecurityGroupRules{},
+		AddressGroups:      AddressGroups{},
 		Guestnetworks:      Guestnetworks{},
 		Guestsecgroups:     Guestsecgroups{},
 		Elasticips:         Elasticips{},
 		NetworkAddresses:   NetworkAddresses{},
 
 		DnsRecords: DnsRecords{},
+		FlowLogs:   FlowLogs{},
 	}
 }
 
@@ -97,6 +105,7 @@ func (mss *ModelSets) ModelSetList() []apihelper.IModelSet {
 		mss.Guests,
 		mss.Hosts,
 		mss.SecurityGroups,
+		mss.AddressGroups,
 		mss.SecurityGroupRules,
 		mss.Guestnetworks,
 		mss.Guestsecgroups,
@@ -104,6 +113,7 @@ func (mss *ModelSets) ModelSetList() []apihelper.IModelSet {
 		mss.NetworkAddresses,
 
 		mss.DnsRecords,
+		mss.FlowLogs,
 	}
 }
 
@@ -120,12 +130,14 @@ func (mss *ModelSets) copy_() *ModelSets {
 		Hosts:              mss.Hosts.Copy().(Hosts),
 		SecurityGroups:     mss.SecurityGroups.Copy().(SecurityGroups),
 		SecurityGroupRules: mss.SecurityGroupRules.Copy().(SecurityGroupRules),
+		AddressGroups:      mss.AddressGroups.Copy().(AddressGroups),
 		Guestnetworks:      mss.Guestnetworks.Copy().(Guestnetworks),
 		Guestsecgroups:     mss.Guestsecgroups.Copy().(Guestsecgroups),
 		Elasticips:         mss.Elasticips.Copy().(Elasticips),
 		NetworkAddresses:   mss.NetworkAddresses.Copy().(NetworkAddresses),
 
 		DnsRecords: mss.DnsRecords.Copy().(DnsRecords),
+		FlowLogs:   mss.FlowLogs.Copy().(FlowLogs),
 	}
 	return mssCopy
 }
@@ -172,6 +184,7 @@ func (mss *ModelSets) join() bool {
 	p = append(p, mss.Guests.joinHosts(mss.Hosts))
 	p = append(p, mss.Guests.joinSecurityGroups(mss.SecurityGroups))
 	p = append(p, mss.SecurityGroups.joinSecurityGroupRules(mss.SecurityGroupRules))
+	p = append(p, mss.SecurityGroupRules.joinAddressGroups(mss.AddressGroups))
 	p = append(p, mss.Guestsecgroups.join(mss.SecurityGroups, mss.Guests))
 	p = append(p, mss.Guestnetworks.joinGuests(mss.Guests))
 	p = append(p, mss.Guestnetworks.joinElasticips(mss.Elasticips))
@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"strings"
+
+	"yunion.io/x/ovsdb/schema/ovn_nb"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+const (
+	flowLogMeterPrefix   = "fl-"
+	flowLogAclSeverity   = "info"
+	flowLogMeterUnit     = "pktps"
+	flowLogMeterBandDrop = "drop"
+)
+
+// flowLogMatchAcl 按流日志的流量类型判断ACL是否需要记录日志
+func flowLogMatchAcl(fl *agentmodels.FlowLog, acl *ovn_nb.ACL) bool {
+	switch fl.TrafficType {
+	case apis.FLOW_LOG_TRAFFIC_ACCEPT:
+		return strings.HasPrefix(acl.Action, "allow")
+	case apis.FLOW_LOG_TRAFFIC_REJECT:
+		return acl.Action == "drop" || acl.Action == "reject"
+	default:
+		return true
+	}
+}
+
+// flowLogAcl 为ACL开启日志, 日志经meter限速后由ovn-controller写入本地日志文件
+func flowLogAcl(fl *agentmodels.FlowLog, acl *ovn_nb.ACL) {
+	if fl == nil || !flowLogMatchAcl(fl, acl) {
+		return
+	}
+	acl.Log = true
+	acl.Name = ptr(flowLogAclName(fl.Id, acl.Direction))
+	acl.Severity = ptr(flowLogAclSeverity)
+	acl.Meter = ptr(flowLogMeterName(fl.Id))
+	// Log为false时不参与比较, 以external_ids区分是否开启日志
+	acl.ExternalIds[externalKeyOcFlowLog] = fl.Id + "/" + fl.TrafficType
+}
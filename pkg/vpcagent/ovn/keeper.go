@@ -35,6 +35,9 @@ import (
 const (
 	externalKeyOcVersion = "oc-version"
 	externalKeyOcRef     = "oc-ref"
+
+	externalKeyOcFlowLog     = "oc-flowlog"
+	externalKeyOcFlowLogRate = "oc-flowlog-rate"
 )
 
 type OVNNorthboundKeeper struct {
@@ -54,6 +57,9 @@ func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthbou
 		&db.DHCPOptions,
 		&db.QoS,
 		&db.DNS,
+		&db.AddressSet,
+		&db.Meter,
+		&db.MeterBand,
 	}
 	args := []string{"--format=json", "list", "<tbl>"}
 	for _, itbl := range itbls {
@@ -323,18 +329,43 @@ func (keeper *OVNNorthboundKeeper) ClaimNetwork(ctx context.Context, network *ag
 	} else {
 		dhcpopts.Options["dns_server"] = "{223.5.5.5,223.6.6.6}"
 	}
-
-	var (
-		args      []string
-		ocVersion = fmt.Sprintf("%s.%d", network.UpdatedAt, network.UpdateVersion)
-	)
-	allFound, args := cmp(&keeper.DB, ocVersion,
+	irows := []types.IRow{
 		netLs,
 		netRnp,
 		netNrp,
 		netMdp,
 		dhcpopts,
+	}
+
+	var dhcpopts6 *ovn_nb.DHCPOptions
+	if network.IsSupportIPv6() {
+		// 双栈网络：路由器端口发送RA，要求虚拟机通过有状态DHCPv6获取地址
+		netRnp.Networks = append(netRnp.Networks, fmt.Sprintf("%s/%d", network.GuestGateway6, network.GuestIp6Mask))
+		netRnp.Ipv6RaConfigs = map[string]string{
+			"address_mode":  "dhcpv6_stateful",
+			"send_periodic": "true",
+			"mtu":           fmt.Sprintf("%d", mtu),
+		}
+		dhcpopts6 = &ovn_nb.DHCPOptions{
+			Cidr: network.GetIP6Prefix(),
+			Options: map[string]string{
+				"server_id": dhcpMac,
+			},
+			ExternalIds: map[string]string{
+				externalKeyOcRef: dhcp6OptRef(network.Id),
+			},
+		}
+		if network.GuestDns6 != "" {
+			dhcpopts6.Options["dns_server"] = "{" + network.GuestDns6 + "}"
+		}
+		irows = append(irows, dhcpopts6)
+	}
+
+	var (
+		args      []string
+		ocVersion = fmt.Sprintf("%s.%d", network.UpdatedAt, network.UpdateVersion)
 	)
+	allFound, args := cmp(&keeper.DB, ocVersion, irows...)
 	if allFound {
 		return nil
 	}
@@ -343,11 +374,35 @@ func (keeper *OVNNorthboundKeeper) ClaimNetwork(ctx context.Context, network *ag
 	args = append(args, ovnCreateArgs(netNrp, netNrp.Name)...)
 	args = append(args, ovnCreateArgs(netMdp, netMdp.Name)...)
 	args = append(args, ovnCreateArgs(dhcpopts, "dhcpopts")...)
+	if dhcpopts6 != nil {
+		args = append(args, ovnCreateArgs(dhcpopts6, "dhcpopts6")...)
+	}
 	args = append(args, "--", "add", "Logical_Switch", netLs.Name, "ports", "@"+netNrp.Name, "@"+netMdp.Name)
 	args = append(args, "--", "add", "Logical_Router", vpcLrName(network.Vpc.Id), "ports", "@"+netRnp.Name)
 	return keeper.cli.Must(ctx, "ClaimNetwork", args)
 }
 
+func (keeper *OVNNorthboundKeeper) findDhcpOpt(ctx context.Context, ocRef string) (string, error) {
+	dhcpOptQuery := &ovn_nb.DHCPOptions{
+		ExternalIds: map[string]string{
+			externalKeyOcRef: ocRef,
+		},
+	}
+	if m := keeper.DB.DHCPOptions.FindOneMatchNonZeros(dhcpOptQuery); m != nil {
+		return m.OvsdbUuid(), nil
+	}
+	args := []string{
+		"--bare", "--columns=_uuid", "find", "DHCP_Options",
+		fmt.Sprintf("external_ids:%s=%q", externalKeyOcRef, ocRef),
+	}
+	res := keeper.cli.Must(ctx, "find dhcpopt", args)
+	dhcpOpt := strings.TrimSpace(res.Output)
+	if dhcpOpt == "" {
+		return "", fmt.Errorf("cannot find dhcpopt %s", ocRef)
+	}
+	return dhcpOpt, nil
+}
+
 func (keeper *OVNNorthboundKeeper) ClaimVpcHost(ctx context.Context, vpc *agentmodels.Vpc, host *agentmodels.Host) error {
 	var (
 		ocVersion = fmt.Sprintf("%s.%d", host.UpdatedAt, host.UpdateVersion)
@@ -405,7 +460,7 @@ func (keeper *OVNNorthboundKeeper) ClaimVpcEipgw(ctx context.Context, vpc *agent
 	return keeper.cli.Must(ctx, "ClaimVpcEipgw", args)
 }
 
-func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestnetwork *agentmodels.Guestnetwork) error {
+func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestnetwork *agentmodels.Guestnetwork, flowLog *agentmodels.FlowLog) error {
 	var (
 		// Callers assure that guestnetwork.Guest is not nil
 		guest   = guestnetwork.Guest
@@ -421,26 +476,21 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 		ocQosRef        = fmt.Sprintf("qos/%s/%s/%s", network.Id, guestnetwork.GuestId, guestnetwork.Ifname)
 		ocQosEipRef     = fmt.Sprintf("qos-eip/%s/%s/%s", vpc.Id, guestnetwork.GuestId, guestnetwork.Ifname)
 		dhcpOpt         string
+		dhcpOpt6        string
+		hasIp6          = guestnetwork.Ip6Addr != "" && network.IsSupportIPv6()
 	)
 
 	{
-		dhcpOptQuery := &ovn_nb.DHCPOptions{
-			ExternalIds: map[string]string{
-				externalKeyOcRef: guestnetwork.NetworkId,
-			},
+		var err error
+		dhcpOpt, err = keeper.findDhcpOpt(ctx, guestnetwork.NetworkId)
+		if err != nil {
+			return err
 		}
-		if m := keeper.DB.DHCPOptions.FindOneMatchNonZeros(dhcpOptQuery); m != nil {
-			dhcpOpt = m.OvsdbUuid()
-		} else {
-			args := []string{
-				"--bare", "--columns=_uuid", "find", "DHCP_Options",
-				fmt.Sprintf("external_ids:%s=%q", externalKeyOcRef, guestnetwork.NetworkId),
+		if hasIp6 {
+			dhcpOpt6, err = keeper.findDhcpOpt(ctx, dhcp6OptRef(guestnetwork.NetworkId))
+			if err != nil {
+				return err
 			}
-			res := keeper.cli.Must(ctx, "find dhcpopt", args)
-			dhcpOpt = strings.TrimSpace(res.Output)
-		}
-		if dhcpOpt == "" {
-			return fmt.Errorf("cannot find dhcpopt for subnet %s", guestnetwork.NetworkId)
 		}
 	}
 
@@ -454,12 +504,19 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 	}
 	sort.Strings(subIPs[1:])
 	sort.Strings(subIPms[1:])
+	if hasIp6 {
+		subIPs = append(subIPs, guestnetwork.Ip6Addr)
+		subIPms = append(subIPms, fmt.Sprintf("%s/%d", guestnetwork.Ip6Addr, network.GuestIp6Mask))
+	}
 	gnp := &ovn_nb.LogicalSwitchPort{
 		Name:          lportName,
 		Addresses:     []string{fmt.Sprintf("%s %s", guestnetwork.MacAddr, strings.Join(subIPs, " "))},
 		Dhcpv4Options: &dhcpOpt,
 		Options:       map[string]string{},
 	}
+	if hasIp6 {
+		gnp.Dhcpv6Options = &dhcpOpt6
+	}
 	if guest.SrcMacCheck.IsFalse() {
 		gnp.Addresses = append(gnp.Addresses, "unknown")
 		// empty, not nil, as match condition
@@ -578,15 +635,26 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 	{
 		sgrs := guest.OrderedSecurityGroupRules()
 		for _, sgr := range sgrs {
-			acl, err := ruleToAcl(lportName, sgr)
+			sgrAcls, err := ruleToAcls(lportName, sgr)
 			if err != nil {
 				log.Errorf("converting security group rule to acl: %v", err)
 				break
 			}
-			acl.ExternalIds = map[string]string{
-				externalKeyOcRef: ocAclRef,
+			for _, acl := range sgrAcls {
+				acl.ExternalIds = map[string]string{
+					externalKeyOcRef: ocAclRef,
+				}
+				flowLogAcl(flowLog, acl)
+				acls = append(acls, acl)
+			}
+		}
+		if hasIp6 && len(acls) > 0 {
+			for _, acl := range ip6InfraAcls(lportName) {
+				acl.ExternalIds = map[string]string{
+					externalKeyOcRef: ocAclRef,
+				}
+				acls = append(acls, acl)
 			}
-			acls = append(acls, acl)
 		}
 	}
 
@@ -634,6 +702,84 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 	return keeper.cli.Must(ctx, "ClaimGuestnetwork", args)
 }
 
+// ClaimAddressGroup 地址组按地址族映射为Address_Set, 供ACL以$name引用
+func (keeper *OVNNorthboundKeeper) ClaimAddressGroup(ctx context.Context, ag *agentmodels.AddressGroup) error {
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", ag.UpdatedAt, ag.UpdateVersion)
+		irows     []types.IRow
+	)
+	for _, as := range addressGroupToAddressSets(ag) {
+		irows = append(irows, as)
+	}
+	if len(irows) == 0 {
+		return nil
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, irows...)
+	if allFound {
+		return nil
+	}
+	for i, irow := range irows {
+		args = append(args, ovnCreateArgs(irow, fmt.Sprintf("as%d", i))...)
+	}
+	return keeper.cli.Must(ctx, "ClaimAddressGroup", args)
+}
+
+// addressGroupToAddressSets IPv4与IPv6地址各一个Address_Set, 空地址族不生成
+func addressGroupToAddressSets(ag *agentmodels.AddressGroup) []*ovn_nb.AddressSet {
+	var sets []*ovn_nb.AddressSet
+	for _, ip6 := range []bool{false, true} {
+		addrs := ag.IpList
+		if ip6 {
+			addrs = ag.Ip6List
+		}
+		if addrs == nil || len(*addrs) == 0 {
+			continue
+		}
+		sets = append(sets, &ovn_nb.AddressSet{
+			Name:      addressSetName(ag.Id, ip6),
+			Addresses: []string(*addrs),
+			ExternalIds: map[string]string{
+				externalKeyOcRef: ag.Id,
+			},
+		})
+	}
+	return sets
+}
+
+// ClaimFlowLogMeter 流日志ACL引用的meter, 超出速率的日志由ovn-controller丢弃
+func (keeper *OVNNorthboundKeeper) ClaimFlowLogMeter(ctx context.Context, fl *agentmodels.FlowLog) error {
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", fl.UpdatedAt, fl.UpdateVersion)
+		rate      = int64(fl.RateLimit)
+	)
+	if rate <= 0 {
+		rate = apis.FLOW_LOG_DEFAULT_RATE_LIMIT
+	}
+	// Meter_Band不是根表, 无法单独比较与删除, 以meter的external_ids记录速率,
+	// 旧的band在meter删除后由ovsdb回收
+	meter := &ovn_nb.Meter{
+		Name: flowLogMeterName(fl.Id),
+		Unit: flowLogMeterUnit,
+		ExternalIds: map[string]string{
+			externalKeyOcRef:         fl.Id,
+			externalKeyOcFlowLogRate: fmt.Sprintf("%d", rate),
+		},
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, meter)
+	if allFound {
+		return nil
+	}
+	band := &ovn_nb.MeterBand{
+		Action:    flowLogMeterBandDrop,
+		Rate:      rate,
+		BurstSize: rate,
+	}
+	args = append(args, ovnCreateArgs(band, "band")...)
+	args = append(args, ovnCreateArgs(meter, "meter")...)
+	args = append(args, "bands=@band")
+	return keeper.cli.Must(ctx, "ClaimFlowLogMeter", args)
+}
+
 func (keeper *OVNNorthboundKeeper) ClaimVpcGuestDnsRecords(ctx context.Context, vpc *agentmodels.Vpc) error {
 	var (
 		grs = map[string][]string{}
@@ -755,6 +901,8 @@ func (keeper *OVNNorthboundKeeper) Mark(ctx context.Context) {
 		&db.DHCPOptions,
 		&db.QoS,
 		&db.DNS,
+		&db.AddressSet,
+		&db.Meter,
 	}
 	for _, itbl := range itbls {
 		for _, irow := range itbl.Rows() {
@@ -830,5 +978,33 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 			keeper.cli.Must(ctx, "Sweep qos", args)
 		}
 	}
+	{ // address sets are referenced by acl match, remove them after acls
+		var irows []types.IRow
+		for _, irow := range db.AddressSet.Rows() {
+			if _, ok := irow.GetExternalId(externalKeyOcVersion); !ok {
+				irows = append(irows, irow)
+			}
+		}
+		args := ovnutil.OvnNbctlArgsDestroy(irows)
+		if len(args) > 0 {
+			keeper.cli.Must(ctx, "Sweep address sets", args)
+		}
+	}
+	{ // meters are referenced by acl name, remove them after acls
+		var irows []types.IRow
+		for i := range db.Meter {
+			irow := &db.Meter[i]
+			if !strings.HasPrefix(irow.Name, flowLogMeterPrefix) {
+				continue
+			}
+			if _, ok := irow.GetExternalId(externalKeyOcVersion); !ok {
+				irows = append(irows, irow)
+			}
+		}
+		args := ovnutil.OvnNbctlArgsDestroy(irows)
+		if len(args) > 0 {
+			keeper.cli.Must(ctx, "Sweep meters", args)
+		}
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package ovn
 
 import (
 	"fmt"
+	"strings"
 )
 
 func vpcLrName(vpcId string) string {
@@ -67,6 +68,20 @@ func vpcEipLspName(vpcId string, eipgwId string) string {
 	return fmt.Sprintf("vpc-ep/%s/%s", vpcId, eipgwId)
 }
 
+// addressSetName Address_Set名称只允许字母数字与下划线, 按地址族区分
+func addressSetName(agId string, ip6 bool) string {
+	af := "ip4"
+	if ip6 {
+		af = "ip6"
+	}
+	return fmt.Sprintf("as_%s_%s", strings.Replace(agId, "-", "_", -1), af)
+}
+
+// dhcp6OptRef DHCPv6选项的ocRef，与IPv4选项(直接使用网络Id)区分
+func dhcp6OptRef(netId string) string {
+	return fmt.Sprintf("dhcp6/%s", netId)
+}
+
 func netLsName(netId string) string {
 	return fmt.Sprintf("subnet/%s", netId)
 }
@@ -89,3 +104,16 @@ func netMdpName(netId string) string {
 func gnpName(netId string, ifname string) string {
 	return fmt.Sprintf("iface-%s-%s", netId, ifname)
 }
+
+// flowLogAclName ACL日志名称, 宿主机采集端据此关联流日志与方向
+func flowLogAclName(flowLogId string, dir string) string {
+	d := "in"
+	if dir == aclDirFromLport {
+		d = "out"
+	}
+	return fmt.Sprintf("fl/%s/%s", flowLogId, d)
+}
+
+func flowLogMeterName(flowLogId string) string {
+	return fmt.Sprintf("fl-%s", flowLogId)
+}
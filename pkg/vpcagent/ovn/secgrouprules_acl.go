@@ -21,7 +21,54 @@ const (
 	aclDirFromLport = "from-lport"
 )
 
-func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule) (*ovn_nb.ACL, error) {
+// ruleToAcls 将安全组规则转换为ACL, 引用地址组的规则按地址族分别匹配对应的Address_Set
+func ruleToAcls(lport string, rule *agentmodels.SecurityGroupRule) ([]*ovn_nb.ACL, error) {
+	if rule.AddressGroupId == "" {
+		l3proto, l3addr := cidrToL3Match(rule.CIDR)
+		acl, err := ruleToAcl(lport, rule, l3proto, l3addr)
+		if err != nil {
+			return nil, err
+		}
+		return []*ovn_nb.ACL{acl}, nil
+	}
+	ag := rule.AddressGroup
+	if ag == nil {
+		return nil, errors.Wrapf(errBadSecgroupRule, "address group %q not found", rule.AddressGroupId)
+	}
+	var acls []*ovn_nb.ACL
+	if ag.IpList != nil && len(*ag.IpList) > 0 {
+		acl, err := ruleToAcl(lport, rule, "ip4", "$"+addressSetName(ag.Id, false))
+		if err != nil {
+			return nil, err
+		}
+		acls = append(acls, acl)
+	}
+	if ag.Ip6List != nil && len(*ag.Ip6List) > 0 {
+		acl, err := ruleToAcl(lport, rule, "ip6", "$"+addressSetName(ag.Id, true))
+		if err != nil {
+			return nil, err
+		}
+		acls = append(acls, acl)
+	}
+	return acls, nil
+}
+
+// cidrToL3Match 未指定或0.0.0.0/0的规则同时作用于IPv4与IPv6
+func cidrToL3Match(cidr string) (l3proto string, l3addr string) {
+	cidr = strings.TrimSpace(cidr)
+	if cidr == "" || cidr == "0.0.0.0/0" {
+		return "ip", ""
+	}
+	if strings.Contains(cidr, ":") {
+		if cidr == "::/0" {
+			return "ip6", ""
+		}
+		return "ip6", cidr
+	}
+	return "ip4", cidr
+}
+
+func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule, l3proto, l3addr string) (*ovn_nb.ACL, error) {
 	var (
 		dir    string
 		action string
@@ -58,9 +105,9 @@ func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule) (*ovn_nb.ACL,
 	}
 
 	addL3Match := func() {
-		matches = append(matches, "ip4")
-		if cidr := strings.TrimSpace(rule.CIDR); cidr != "" && cidr != "0.0.0.0/0" {
-			matches = append(matches, fmt.Sprintf("ip4.%s == %s", l3subfn, cidr))
+		matches = append(matches, l3proto)
+		if l3addr != "" {
+			matches = append(matches, fmt.Sprintf("%s.%s == %s", l3proto, l3subfn, l3addr))
 		}
 	}
 	addL4Match := func(l4proto string) {
@@ -135,7 +182,14 @@ func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule) (*ovn_nb.ACL,
 		addL4Match("udp")
 	case secrules.PROTO_ICMP:
 		addL3Match()
-		matches = append(matches, "icmp4")
+		switch l3proto {
+		case "ip4":
+			matches = append(matches, "icmp4")
+		case "ip6":
+			matches = append(matches, "icmp6")
+		default:
+			matches = append(matches, "icmp")
+		}
 	default:
 		return nil, errors.Wrapf(errBadSecgroupRule, "unknown protocol %q", rule.Protocol)
 	}
@@ -152,3 +206,23 @@ func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule) (*ovn_nb.ACL,
 	}
 	return acl, nil
 }
+
+// 高于安全组规则的优先级，保证拒绝规则不会阻断邻居发现与DHCPv6
+const aclPriorityIp6Infra = 32000
+
+func ip6InfraAcls(lport string) []*ovn_nb.ACL {
+	return []*ovn_nb.ACL{
+		&ovn_nb.ACL{
+			Priority:  aclPriorityIp6Infra,
+			Direction: aclDirFromLport,
+			Match:     fmt.Sprintf("inport == %q && (nd || nd_rs || (udp && udp.dst == 547))", lport),
+			Action:    "allow",
+		},
+		&ovn_nb.ACL{
+			Priority:  aclPriorityIp6Infra,
+			Direction: aclDirToLport,
+			Match:     fmt.Sprintf("outport == %q && (nd || nd_ra || (udp && udp.dst == 546))", lport),
+			Action:    "allow",
+		},
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	compute_api "yunion.io/x/onecloud/pkg/apis/compute"
+	compute_models "yunion.io/x/onecloud/pkg/compute/models"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+func testAddressGroup(id string, ips, ip6s []string) *agentmodels.AddressGroup {
+	ag := &agentmodels.AddressGroup{}
+	ag.Id = id
+	if ips != nil {
+		l := compute_api.SAddressList(ips)
+		ag.IpList = &l
+	}
+	if ip6s != nil {
+		l := compute_api.SAddressList(ip6s)
+		ag.Ip6List = &l
+	}
+	return ag
+}
+
+func TestAddressGroupToAddressSets(t *testing.T) {
+	cases := []struct {
+		name  string
+		ag    *agentmodels.AddressGroup
+		names []string
+		addrs [][]string
+	}{
+		{
+			name:  "dual stack",
+			ag:    testAddressGroup("ag-1", []string{"10.0.0.0/8", "192.168.1.10/32"}, []string{"2001:db8::/64"}),
+			names: []string{"as_ag_1_ip4", "as_ag_1_ip6"},
+			addrs: [][]string{{"10.0.0.0/8", "192.168.1.10/32"}, {"2001:db8::/64"}},
+		},
+		{
+			name:  "ipv6 only",
+			ag:    testAddressGroup("ag-2", []string{}, []string{"fd00::/8"}),
+			names: []string{"as_ag_2_ip6"},
+			addrs: [][]string{{"fd00::/8"}},
+		},
+		{
+			name: "empty",
+			ag:   testAddressGroup("ag-3", nil, nil),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sets := addressGroupToAddressSets(c.ag)
+			if len(sets) != len(c.names) {
+				t.Fatalf("want %d address sets got %d", len(c.names), len(sets))
+			}
+			for i, as := range sets {
+				if as.Name != c.names[i] {
+					t.Errorf("want name %s got %s", c.names[i], as.Name)
+				}
+				if !reflect.DeepEqual(as.Addresses, c.addrs[i]) {
+					t.Errorf("want addresses %v got %v", c.addrs[i], as.Addresses)
+				}
+				if as.ExternalIds[externalKeyOcRef] != c.ag.Id {
+					t.Errorf("address set %s not referenced to %s", as.Name, c.ag.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestRuleToAclsAddressGroup(t *testing.T) {
+	newRule := func(ag *agentmodels.AddressGroup) *agentmodels.SecurityGroupRule {
+		return &agentmodels.SecurityGroupRule{
+			SSecurityGroupRule: compute_models.SSecurityGroupRule{
+				Direction:      "in",
+				Action:         "allow",
+				Protocol:       "tcp",
+				Ports:          "22",
+				AddressGroupId: "ag-1",
+			},
+			AddressGroup: ag,
+		}
+	}
+	t.Run("dual stack", func(t *testing.T) {
+		acls, err := ruleToAcls("lport", newRule(testAddressGroup("ag-1", []string{"10.0.0.0/8"}, []string{"2001:db8::/64"})))
+		if err != nil {
+			t.Fatalf("ruleToAcls: %v", err)
+		}
+		want := []string{
+			`outport == "lport" && ip4 && ip4.src == $as_ag_1_ip4 && tcp && tcp.dst == 22`,
+			`outport == "lport" && ip6 && ip6.src == $as_ag_1_ip6 && tcp && tcp.dst == 22`,
+		}
+		if len(acls) != len(want) {
+			t.Fatalf("want %d acls got %d", len(want), len(acls))
+		}
+		for i := range acls {
+			if acls[i].Match != want[i] {
+				t.Errorf("want match %s got %s", want[i], acls[i].Match)
+			}
+		}
+	})
+	t.Run("empty group", func(t *testing.T) {
+		acls, err := ruleToAcls("lport", newRule(testAddressGroup("ag-1", nil, nil)))
+		if err != nil {
+			t.Fatalf("ruleToAcls: %v", err)
+		}
+		if len(acls) != 0 {
+			t.Errorf("empty address group should not match anything, got %d acls", len(acls))
+		}
+	})
+	t.Run("group not found", func(t *testing.T) {
+		_, err := ruleToAcls("lport", newRule(nil))
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("want not found error, got %v", err)
+		}
+	})
+}
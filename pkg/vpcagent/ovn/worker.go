@@ -115,6 +115,15 @@ func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error
 	}
 
 	ovndb.Mark(ctx)
+	for _, ag := range mss.AddressGroups {
+		ovndb.ClaimAddressGroup(ctx, ag)
+	}
+	for _, fl := range mss.FlowLogs {
+		if !fl.Enabled.Bool() || fl.PendingDeleted {
+			continue
+		}
+		ovndb.ClaimFlowLogMeter(ctx, fl)
+	}
 	for _, vpc := range mss.Vpcs {
 		if vpc.Id == apis.DEFAULT_VPC_ID {
 			continue
@@ -161,7 +170,7 @@ func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error
 
 					ovndb.ClaimVpcHost(ctx, vpc, host)
 				}
-				ovndb.ClaimGuestnetwork(ctx, guestnetwork)
+				ovndb.ClaimGuestnetwork(ctx, guestnetwork, mss.FlowLogs.GuestnetworkFlowLog(guestnetwork))
 			}
 		}
 	}